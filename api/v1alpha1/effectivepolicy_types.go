@@ -0,0 +1,114 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	gatewayapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	kuadrantv1 "github.com/kuadrant/kuadrant-operator/api/v1"
+)
+
+var (
+	EffectivePolicyGroupKind  = schema.GroupKind{Group: GroupVersion.Group, Kind: "EffectivePolicy"}
+	EffectivePoliciesResource = GroupVersion.WithResource("effectivepolicies")
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="RouteKind",type="string",JSONPath=".status.targetRef.kind",description="Kind of the route of the rule"
+// +kubebuilder:printcolumn:name="Route",type="string",JSONPath=".status.targetRef.name",description="Name of the route of the rule"
+// +kubebuilder:printcolumn:name="Rule",type="string",JSONPath=".status.targetRef.sectionName",description="Name of the route rule"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// EffectivePolicy is a read-only summary of the policies in effect on a route rule, computed by the Kuadrant
+// operator by merging the policies attached to the route rule and to its ancestors
+type EffectivePolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Status EffectivePolicyStatus `json:"status,omitempty"`
+}
+
+// EffectivePolicyStatus holds the effective policies of a route rule
+type EffectivePolicyStatus struct {
+	// TargetRef identifies the route rule
+	TargetRef gatewayapiv1alpha2.LocalPolicyTargetReferenceWithSectionName `json:"targetRef"`
+
+	// Paths holds the effective policies of the route rule for each gateway listener the route is attached to
+	// +optional
+	Paths []EffectivePolicyPath `json:"paths,omitempty"`
+}
+
+// EffectivePolicyPath holds the effective policies of a route rule through a gateway listener
+type EffectivePolicyPath struct {
+	// Gateway is the namespaced name of the gateway
+	Gateway string `json:"gateway"`
+
+	// Listener is the name of the gateway listener
+	Listener string `json:"listener"`
+
+	// Auth summarises the effective AuthPolicy
+	// +optional
+	Auth *EffectivePolicySummary `json:"auth,omitempty"`
+
+	// RateLimit summarises the effective RateLimitPolicy
+	// +optional
+	RateLimit *EffectivePolicySummary `json:"rateLimit,omitempty"`
+
+	// TokenRateLimit summarises the effective TokenRateLimitPolicy
+	// +optional
+	TokenRateLimit *EffectivePolicySummary `json:"tokenRateLimit,omitempty"`
+}
+
+// EffectivePolicySummary lists the merged rules of an effective policy along with the policies they come from
+type EffectivePolicySummary struct {
+	// SourcePolicies are the locators of the policies contributing to the effective policy
+	// +optional
+	SourcePolicies []string `json:"sourcePolicies,omitempty"`
+
+	// Rules are the merged rules of the effective policy
+	// +optional
+	Rules []EffectiveRule `json:"rules,omitempty"`
+}
+
+// EffectiveRule is a merged rule of an effective policy
+type EffectiveRule struct {
+	// Name of the rule, e.g. the name of a limit, or the section and name of an auth rule as in `authentication#jwt`
+	Name string `json:"name"`
+
+	// Source is the locator of the policy the rule comes from
+	Source string `json:"source"`
+
+	// Rates of the limit, for rate limit rules
+	// +optional
+	Rates []kuadrantv1.Rate `json:"rates,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// EffectivePolicyList contains a list of EffectivePolicy
+type EffectivePolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EffectivePolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&EffectivePolicy{}, &EffectivePolicyList{})
+}
@@ -26,6 +26,164 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EffectivePolicy) DeepCopyInto(out *EffectivePolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EffectivePolicy.
+func (in *EffectivePolicy) DeepCopy() *EffectivePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(EffectivePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EffectivePolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EffectivePolicyList) DeepCopyInto(out *EffectivePolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]EffectivePolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EffectivePolicyList.
+func (in *EffectivePolicyList) DeepCopy() *EffectivePolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(EffectivePolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EffectivePolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EffectivePolicyPath) DeepCopyInto(out *EffectivePolicyPath) {
+	*out = *in
+	if in.Auth != nil {
+		in, out := &in.Auth, &out.Auth
+		*out = new(EffectivePolicySummary)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RateLimit != nil {
+		in, out := &in.RateLimit, &out.RateLimit
+		*out = new(EffectivePolicySummary)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TokenRateLimit != nil {
+		in, out := &in.TokenRateLimit, &out.TokenRateLimit
+		*out = new(EffectivePolicySummary)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EffectivePolicyPath.
+func (in *EffectivePolicyPath) DeepCopy() *EffectivePolicyPath {
+	if in == nil {
+		return nil
+	}
+	out := new(EffectivePolicyPath)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EffectivePolicyStatus) DeepCopyInto(out *EffectivePolicyStatus) {
+	*out = *in
+	in.TargetRef.DeepCopyInto(&out.TargetRef)
+	if in.Paths != nil {
+		in, out := &in.Paths, &out.Paths
+		*out = make([]EffectivePolicyPath, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EffectivePolicyStatus.
+func (in *EffectivePolicyStatus) DeepCopy() *EffectivePolicyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EffectivePolicyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EffectivePolicySummary) DeepCopyInto(out *EffectivePolicySummary) {
+	*out = *in
+	if in.SourcePolicies != nil {
+		in, out := &in.SourcePolicies, &out.SourcePolicies
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]EffectiveRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EffectivePolicySummary.
+func (in *EffectivePolicySummary) DeepCopy() *EffectivePolicySummary {
+	if in == nil {
+		return nil
+	}
+	out := new(EffectivePolicySummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EffectiveRule) DeepCopyInto(out *EffectiveRule) {
+	*out = *in
+	if in.Rates != nil {
+		in, out := &in.Rates, &out.Rates
+		*out = make([]v1.Rate, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EffectiveRule.
+func (in *EffectiveRule) DeepCopy() *EffectiveRule {
+	if in == nil {
+		return nil
+	}
+	out := new(EffectiveRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MergeableTokenRateLimitPolicySpec) DeepCopyInto(out *MergeableTokenRateLimitPolicySpec) {
 	*out = *in
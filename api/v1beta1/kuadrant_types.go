@@ -21,9 +21,13 @@ import (
 
 	"github.com/go-logr/logr"
 	"github.com/google/go-cmp/cmp"
+	limitadorv1alpha1 "github.com/kuadrant/limitador-operator/api/v1alpha1"
 	"github.com/kuadrant/policy-machinery/machinery"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/ptr"
 
 	"github.com/kuadrant/kuadrant-operator/internal/kuadrant"
@@ -106,6 +110,54 @@ type Observability struct {
 	// Tracing configures distributed tracing for request flows through the system.
 	// +optional
 	Tracing *Tracing `json:"tracing"`
+
+	// Alerts configures the PrometheusRule with the Kuadrant alerts generated when observability is enabled.
+	// +optional
+	Alerts *Alerts `json:"alerts,omitempty"`
+
+	// Dashboards configures the Grafana dashboard ConfigMaps generated when observability is enabled.
+	// +optional
+	Dashboards *Dashboards `json:"dashboards,omitempty"`
+
+	// Gateways overrides the observability settings for specific gateways.
+	// +optional
+	// +listType=map
+	// +listMapKey=namespace
+	// +listMapKey=name
+	Gateways []GatewayObservability `json:"gateways,omitempty"`
+}
+
+// GatewayObservability overrides the observability settings for a gateway
+type GatewayObservability struct {
+	// Name of the gateway.
+	Name string `json:"name"`
+
+	// Namespace of the gateway.
+	Namespace string `json:"namespace"`
+
+	// DecisionLog overrides the decision log settings of the data plane for the gateway.
+	// +optional
+	DecisionLog *DecisionLog `json:"decisionLog,omitempty"`
+
+	// Tracing overrides the tracing collector the gateway exports spans to.
+	// +optional
+	Tracing *GatewayTracing `json:"tracing,omitempty"`
+}
+
+// GatewayTracing configures the tracing collector of a gateway
+type GatewayTracing struct {
+	// Endpoint is the URL of the tracing collector backend where the spans of the gateway should be sent.
+	// +kubebuilder:validation:MinLength=1
+	Endpoint string `json:"endpoint"`
+
+	// Insecure controls whether to skip TLS certificate verification.
+	// +optional
+	Insecure bool `json:"insecure,omitempty"`
+
+	// ClientCertificateSecretRef references a Secret of type kubernetes.io/tls in the namespace of the gateway
+	// with the client certificate presented to the tracing collector.
+	// +optional
+	ClientCertificateSecretRef *corev1.LocalObjectReference `json:"clientCertificateSecretRef,omitempty"`
 }
 
 // DataPlane configures logging and observability for data plane components.
@@ -122,6 +174,42 @@ type DataPlane struct {
 	// If set, this header value will be included in log output for request correlation.
 	// +optional
 	HTTPHeaderIdentifier *string `json:"httpHeaderIdentifier"`
+
+	// DecisionLog configures the structured decision log emitted by the data plane for each request.
+	// +optional
+	DecisionLog *DecisionLog `json:"decisionLog,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=allowed;denied;limited
+type DecisionOutcome string
+
+const (
+	DecisionOutcomeAllowed DecisionOutcome = "allowed"
+	DecisionOutcomeDenied  DecisionOutcome = "denied"
+	DecisionOutcomeLimited DecisionOutcome = "limited"
+)
+
+// DecisionLog configures the structured decision log emitted by the wasm shim. Each entry records the outcome of a
+// request along with the limits that fired and their counter values, and the auth rules that denied the request.
+type DecisionLog struct {
+	// Enable turns on the decision log.
+	Enable bool `json:"enable,omitempty"`
+
+	// Outcomes selects the requests to log by their outcome. Defaults to denied and limited requests.
+	// +optional
+	Outcomes []DecisionOutcome `json:"outcomes,omitempty"`
+
+	// SamplingPercentage is the percentage of the selected requests that are logged. Defaults to 100.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	SamplingPercentage *int32 `json:"samplingPercentage,omitempty"`
+
+	// Fields are additional fields of the log entries, keyed by field name, as CEL expressions evaluated on the
+	// request (e.g. `request.headers['x-user-id']` or `auth.identity.sub`). Fields that are not valid CEL expressions
+	// are left out and reported in the DecisionLogValid condition of the Kuadrant status.
+	// +optional
+	Fields map[string]string `json:"fields,omitempty"`
 }
 
 // Tracing configures distributed tracing integration for request flows.
@@ -131,11 +219,104 @@ type Tracing struct {
 	// This endpoint is used by Auth (Authorino), RateLimiting (Limitador) and WASM services for exporting trace data.
 	// If tracing endpoints have been configured directly in Authorino or Limitador CRs, those take precedence
 	// over this default value.
-	// Note: Per-gateway overrides are not currently supported.
+	// The endpoint of the WASM services can be overridden per gateway in `observability.gateways`.
 	DefaultEndpoint string `json:"defaultEndpoint,omitempty"`
 
 	// Insecure controls whether to skip TLS certificate verification.
 	Insecure bool `json:"insecure,omitempty"`
+
+	// ClientCertificateSecretRef references a Secret of type kubernetes.io/tls with the client certificate
+	// presented by the gateways to the tracing collector. The Secret must exist in the namespace of each gateway.
+	// +optional
+	ClientCertificateSecretRef *corev1.LocalObjectReference `json:"clientCertificateSecretRef,omitempty"`
+
+	// SamplingPercentage is the percentage of the requests traced by the WASM services. Defaults to 100.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	SamplingPercentage *int32 `json:"samplingPercentage,omitempty"`
+
+	// Propagation is the format of the trace context propagated in the request headers by the WASM services.
+	// Defaults to w3c.
+	// +optional
+	Propagation TracingPropagation `json:"propagation,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=w3c;b3;jaeger
+type TracingPropagation string
+
+const (
+	TracingPropagationW3C    TracingPropagation = "w3c"
+	TracingPropagationB3     TracingPropagation = "b3"
+	TracingPropagationJaeger TracingPropagation = "jaeger"
+)
+
+// TracingFor returns the tracing collector of a gateway, either the gateway override or the default endpoint.
+// Returns nil if no tracing collector is configured for the gateway.
+func (o *Observability) TracingFor(gateway k8stypes.NamespacedName) *GatewayTracing {
+	if o == nil {
+		return nil
+	}
+	override, found := lo.Find(o.Gateways, func(g GatewayObservability) bool {
+		return g.Namespace == gateway.Namespace && g.Name == gateway.Name
+	})
+	if found && override.Tracing != nil {
+		return override.Tracing
+	}
+	if o.Tracing == nil || o.Tracing.DefaultEndpoint == "" {
+		return nil
+	}
+	return &GatewayTracing{
+		Endpoint:                   o.Tracing.DefaultEndpoint,
+		Insecure:                   o.Tracing.Insecure,
+		ClientCertificateSecretRef: o.Tracing.ClientCertificateSecretRef,
+	}
+}
+
+// IsTracingConfigured returns true if a tracing collector is configured by default or for any gateway
+func (o *Observability) IsTracingConfigured() bool {
+	if o == nil {
+		return false
+	}
+	if o.Tracing != nil && o.Tracing.DefaultEndpoint != "" {
+		return true
+	}
+	return lo.SomeBy(o.Gateways, func(g GatewayObservability) bool { return g.Tracing != nil })
+}
+
+// Alerts configures the Kuadrant alerts, generated in a PrometheusRule in the namespace of the Kuadrant CR.
+type Alerts struct {
+	// Disable turns off the generation of the PrometheusRule with the Kuadrant alerts.
+	// +optional
+	Disable bool `json:"disable,omitempty"`
+
+	// PolicyNotEnforcedFor is how long a policy has to remain not enforced before the KuadrantPolicyNotEnforced
+	// alert fires. Defaults to 10m.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^([0-9]+(ms|s|m|h|d|w|y))+$`
+	PolicyNotEnforcedFor string `json:"policyNotEnforcedFor,omitempty"`
+
+	// AuthDenialRateThreshold is the number of denied requests per second above which the
+	// KuadrantAuthDenialRateSpike alert fires. Defaults to 10.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	AuthDenialRateThreshold int32 `json:"authDenialRateThreshold,omitempty"`
+
+	// Labels are added to every alert, e.g. to route the alerts in Alertmanager.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// Dashboards configures the Grafana dashboards, generated in ConfigMaps in the namespace of the Kuadrant CR.
+type Dashboards struct {
+	// Disable turns off the generation of the Grafana dashboard ConfigMaps.
+	// +optional
+	Disable bool `json:"disable,omitempty"`
+
+	// Labels are added to the dashboard ConfigMaps, e.g. to match the label selector of the Grafana dashboards
+	// sidecar. Defaults to `grafana_dashboard: "1"`.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
 }
 
 // LogLevel defines a logging level with its activation predicate
@@ -159,6 +340,99 @@ type Components struct {
 	// +optional
 	// DeveloperPortal enables the developer portal integration including APIProduct and APIKeyRequest CRDs
 	DeveloperPortal *DeveloperPortal `json:"developerPortal,omitempty"`
+
+	// +optional
+	// Limitador configures the Limitador instance managed by Kuadrant.
+	// The fields set are merged into the Limitador custom resource; the fields left unset can still be
+	// set directly on the Limitador custom resource.
+	Limitador *LimitadorComponent `json:"limitador,omitempty"`
+
+	// +optional
+	// Authorino configures the Authorino instance managed by Kuadrant.
+	// The fields set are merged into the Authorino custom resource; the fields left unset can still be
+	// set directly on the Authorino custom resource.
+	Authorino *AuthorinoComponent `json:"authorino,omitempty"`
+}
+
+// LimitadorComponent configures the Limitador instance managed by Kuadrant
+// +kubebuilder:validation:XValidation:rule="!(has(self.replicas) && has(self.autoscaling))",message="replicas and autoscaling are mutually exclusive"
+type LimitadorComponent struct {
+	// Replicas is the number of Limitador pods
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Autoscaling scales the Limitador pods with a HorizontalPodAutoscaler, which owns the number of replicas.
+	// Note that the limits are not shared between the pods unless the storage is shared, e.g. redis.
+	// The pods request 250m of CPU when no CPU request is set in the resources, as the autoscaler scales on the CPU
+	// utilization relative to the request.
+	// +optional
+	Autoscaling *ComponentAutoscaling `json:"autoscaling,omitempty"`
+
+	// Resources are the compute resource requirements of the Limitador pods
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Storage is the storage backend of the Limitador counters.
+	// Defaults to in-memory storage.
+	// +optional
+	Storage *limitadorv1alpha1.Storage `json:"storage,omitempty"`
+}
+
+// AuthorinoComponent configures the Authorino instance managed by Kuadrant
+// +kubebuilder:validation:XValidation:rule="!(has(self.replicas) && has(self.autoscaling))",message="replicas and autoscaling are mutually exclusive"
+type AuthorinoComponent struct {
+	// Replicas is the number of Authorino pods
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Autoscaling scales the Authorino pods with a HorizontalPodAutoscaler, which owns the number of replicas.
+	// The pods request 250m of CPU when no CPU request is set in the resources, as the autoscaler scales on the CPU
+	// utilization relative to the request.
+	// +optional
+	Autoscaling *ComponentAutoscaling `json:"autoscaling,omitempty"`
+
+	// Resources are the compute resource requirements of the Authorino pods
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// ComponentAutoscaling configures the HorizontalPodAutoscaler of the pods of a component
+// +kubebuilder:validation:XValidation:rule="!has(self.minReplicas) || self.minReplicas <= self.maxReplicas",message="minReplicas must not be greater than maxReplicas"
+type ComponentAutoscaling struct {
+	// MinReplicas is the lower limit of the number of pods
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=1
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+
+	// MaxReplicas is the upper limit of the number of pods
+	// +kubebuilder:validation:Minimum=1
+	MaxReplicas int32 `json:"maxReplicas"`
+
+	// TargetCPUUtilizationPercentage is the average CPU utilization of the pods, relative to their requested CPU,
+	// that the autoscaler aims for
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=80
+	TargetCPUUtilizationPercentage *int32 `json:"targetCPUUtilizationPercentage,omitempty"`
+}
+
+// LimitadorComponent returns the configuration of the Limitador instance, if any
+func (c *Components) LimitadorComponent() *LimitadorComponent {
+	if c == nil {
+		return nil
+	}
+	return c.Limitador
+}
+
+// AuthorinoComponent returns the configuration of the Authorino instance, if any
+func (c *Components) AuthorinoComponent() *AuthorinoComponent {
+	if c == nil {
+		return nil
+	}
+	return c.Authorino
 }
 
 type DeveloperPortal struct {
@@ -6,6 +6,8 @@ import (
 	"testing"
 
 	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/ptr"
 )
 
@@ -358,3 +360,51 @@ func TestIsDeveloperPortalEnabled(t *testing.T) {
 		assert.Assert(subT, !got)
 	})
 }
+
+func TestObservabilityTracingFor(t *testing.T) {
+	gateway := k8stypes.NamespacedName{Namespace: "gateway-system", Name: "external"}
+
+	assert.Assert(t, (*Observability)(nil).TracingFor(gateway) == nil)
+	assert.Assert(t, (&Observability{}).TracingFor(gateway) == nil)
+	assert.Assert(t, !(&Observability{}).IsTracingConfigured())
+
+	observability := &Observability{
+		Tracing: &Tracing{
+			DefaultEndpoint:            "rpc://tempo:4317",
+			Insecure:                   true,
+			ClientCertificateSecretRef: &corev1.LocalObjectReference{Name: "tracing-client"},
+		},
+	}
+	assert.Assert(t, observability.IsTracingConfigured())
+	assert.DeepEqual(t, observability.TracingFor(gateway), &GatewayTracing{
+		Endpoint:                   "rpc://tempo:4317",
+		Insecure:                   true,
+		ClientCertificateSecretRef: &corev1.LocalObjectReference{Name: "tracing-client"},
+	})
+
+	observability = &Observability{
+		Gateways: []GatewayObservability{
+			{Name: "external", Namespace: "gateway-system", Tracing: &GatewayTracing{Endpoint: "rpc://otel-collector:4317"}},
+		},
+	}
+	assert.Assert(t, observability.IsTracingConfigured())
+	assert.Equal(t, observability.TracingFor(gateway).Endpoint, "rpc://otel-collector:4317")
+	assert.Assert(t, observability.TracingFor(k8stypes.NamespacedName{Namespace: "gateway-system", Name: "internal"}) == nil)
+}
+
+func TestComponents(t *testing.T) {
+	var components *Components
+	assert.Assert(t, components.LimitadorComponent() == nil)
+	assert.Assert(t, components.AuthorinoComponent() == nil)
+
+	components = &Components{
+		Limitador: &LimitadorComponent{Replicas: ptr.To(int32(2))},
+		Authorino: &AuthorinoComponent{Replicas: ptr.To(int32(3))},
+	}
+	assert.Equal(t, *components.LimitadorComponent().Replicas, int32(2))
+	assert.Equal(t, *components.AuthorinoComponent().Replicas, int32(3))
+
+	copied := components.DeepCopy()
+	*copied.Limitador.Replicas = 5
+	assert.Equal(t, *components.LimitadorComponent().Replicas, int32(2))
+}
@@ -0,0 +1,232 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gatewayapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	exttypes "github.com/kuadrant/kuadrant-operator/pkg/extension/types"
+)
+
+const (
+	DefaultClientIPHeader  = "x-forwarded-for"
+	DefaultRefreshInterval = 15 * time.Minute
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// IPPolicy attaches to HTTPRoutes or Gateways to allow or deny requests based on the
+// IP address, or the country, of the client.
+type IPPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   IPPolicySpec   `json:"spec,omitempty"`
+	Status IPPolicyStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:validation:XValidation:rule="has(self.allow) || has(self.deny)",message="At least one of allow or deny must be set"
+// +kubebuilder:validation:XValidation:rule="has(self.geo) || !((has(self.allow) && has(self.allow.countries)) || (has(self.deny) && has(self.deny.countries)))",message="geo is required to match countries"
+type IPPolicySpec struct {
+	// Reference to the Gateway API resource to which this policy applies.
+	// +kubebuilder:validation:XValidation:rule="self.group == 'gateway.networking.k8s.io'",message="Invalid targetRef.group. The only supported value is 'gateway.networking.k8s.io'"
+	// +kubebuilder:validation:XValidation:rule="self.kind == 'HTTPRoute' || self.kind == 'Gateway'",message="Invalid targetRef.kind. The only supported values are 'HTTPRoute' and 'Gateway'"
+	TargetRef gatewayapiv1alpha2.LocalPolicyTargetReferenceWithSectionName `json:"targetRef"`
+
+	// Allow is the set of clients allowed. When set, requests from any other client are denied.
+	// +optional
+	Allow *IPSet `json:"allow,omitempty"`
+
+	// Deny is the set of clients denied. Deny takes precedence over allow.
+	// +optional
+	Deny *IPSet `json:"deny,omitempty"`
+
+	// ClientIP configures how the IP address of the client is determined.
+	// Defaults to the source address of the connection to the gateway.
+	// +optional
+	ClientIP *ClientIP `json:"clientIP,omitempty"`
+
+	// Geo configures how the country of the client is determined.
+	// Required to match countries.
+	// +optional
+	Geo *Geo `json:"geo,omitempty"`
+
+	// RefreshInterval is how often the external lists are fetched again. Defaults to 15m.
+	// +optional
+	RefreshInterval *metav1.Duration `json:"refreshInterval,omitempty"`
+}
+
+// IPSet is a set of clients, matched when any of the CIDRs, lists or countries matches
+type IPSet struct {
+	// CIDRs are IPv4 or IPv6 addresses or ranges in CIDR notation, e.g. 10.0.0.0/8, 192.168.1.10 or 2001:db8::/32
+	// +kubebuilder:validation:MaxItems=256
+	// +kubebuilder:validation:items:Pattern=`^[0-9a-fA-F:.]+(/[0-9]{1,3})?$`
+	// +optional
+	CIDRs []string `json:"cidrs,omitempty"`
+
+	// Lists are external lists of CIDRs, one per line. Blank lines and comments starting with '#' are ignored.
+	// +kubebuilder:validation:MaxItems=8
+	// +optional
+	Lists []ListReference `json:"lists,omitempty"`
+
+	// Countries are ISO 3166-1 alpha-2 country codes, e.g. FR
+	// +kubebuilder:validation:MaxItems=250
+	// +kubebuilder:validation:items:Pattern=`^[A-Z]{2}$`
+	// +optional
+	Countries []string `json:"countries,omitempty"`
+}
+
+// ListReference references an external list of CIDRs
+// +kubebuilder:validation:XValidation:rule="has(self.configMap) != has(self.url)",message="Exactly one of configMap or url must be set"
+type ListReference struct {
+	// ConfigMap is a key of a ConfigMap in the namespace of the policy holding the list
+	// +optional
+	ConfigMap *ConfigMapKeyReference `json:"configMap,omitempty"`
+
+	// URL of a feed serving the list, fetched over HTTPS. The host must be allowed
+	// by the cluster administrator, see IP_POLICY_FEED_HOSTS.
+	// +kubebuilder:validation:Pattern=`^https://`
+	// +optional
+	URL string `json:"url,omitempty"`
+}
+
+// ConfigMapKeyReference references a key of a ConfigMap
+type ConfigMapKeyReference struct {
+	// Name of the ConfigMap
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Key of the ConfigMap holding the list
+	// +kubebuilder:validation:MinLength=1
+	Key string `json:"key"`
+}
+
+// ClientIP configures how the IP address of the client is determined
+type ClientIP struct {
+	// TrustedHops is the number of trusted proxies in front of the gateway, each appending the address
+	// of its peer to the header. The client address is the entry of the header at that position
+	// counting from the right. When 0, the source address of the connection is used.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=10
+	// +optional
+	TrustedHops int `json:"trustedHops,omitempty"`
+
+	// Header listing the client and proxy addresses. Defaults to x-forwarded-for.
+	// +optional
+	Header string `json:"header,omitempty"`
+}
+
+// GetHeader returns the header listing the client and proxy addresses, defaulted
+func (c *ClientIP) GetHeader() string {
+	if c == nil || c.Header == "" {
+		return DefaultClientIPHeader
+	}
+	return c.Header
+}
+
+// Geo configures how the country of the client is determined
+type Geo struct {
+	// CountryHeader is the header holding the ISO 3166-1 alpha-2 country code of the client, set by a
+	// trusted CDN or load balancer in front of the gateway, e.g. cf-ipcountry
+	// +kubebuilder:validation:MinLength=1
+	CountryHeader string `json:"countryHeader"`
+}
+
+func (p *IPPolicy) GetName() string {
+	return p.Name
+}
+
+func (p *IPPolicy) GetNamespace() string {
+	return p.Namespace
+}
+
+func (p *IPPolicy) GetTargetRefs() []gatewayapiv1alpha2.LocalPolicyTargetReferenceWithSectionName {
+	return []gatewayapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+		p.Spec.TargetRef,
+	}
+}
+
+func (p *IPPolicy) GetStatus() exttypes.PolicyStatus {
+	return &p.Status
+}
+
+// GetLists returns the external lists referenced by the allow and the deny sets
+func (p *IPPolicy) GetLists() []ListReference {
+	var lists []ListReference
+	for _, set := range []*IPSet{p.Spec.Allow, p.Spec.Deny} {
+		if set != nil {
+			lists = append(lists, set.Lists...)
+		}
+	}
+	return lists
+}
+
+// GetRefreshInterval returns how often the external lists are fetched again, defaulted
+func (p *IPPolicy) GetRefreshInterval() time.Duration {
+	if p.Spec.RefreshInterval == nil || p.Spec.RefreshInterval.Duration <= 0 {
+		return DefaultRefreshInterval
+	}
+	return p.Spec.RefreshInterval.Duration
+}
+
+// IPPolicyStatus defines the observed state of IPPolicy
+type IPPolicyStatus struct {
+	// ObservedGeneration reflects the generation of the most recently observed spec.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Represents the observations of a IPPolicy's current state.
+	// Known .status.conditions.type are: "Accepted", "Enforced"
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
+}
+
+func (s *IPPolicyStatus) GetObservedGeneration() int64 {
+	return s.ObservedGeneration
+}
+
+func (s *IPPolicyStatus) SetObservedGeneration(generation int64) {
+	s.ObservedGeneration = generation
+}
+
+func (s *IPPolicyStatus) GetConditions() []metav1.Condition {
+	return s.Conditions
+}
+
+func (s *IPPolicyStatus) SetConditions(conditions []metav1.Condition) {
+	s.Conditions = conditions
+}
+
+//+kubebuilder:object:root=true
+
+// IPPolicyList contains a list of IPPolicy
+type IPPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []IPPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&IPPolicy{}, &IPPolicyList{})
+}
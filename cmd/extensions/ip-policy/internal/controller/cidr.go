@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+// ipRange is an inclusive range of IP addresses, of a single family
+type ipRange struct {
+	from, to netip.Addr
+}
+
+// parseCIDR parses an IPv4 or IPv6 address or range in CIDR notation. IPv4-mapped IPv6 addresses are
+// parsed as the IPv4 addresses they map.
+func parseCIDR(s string) (ipRange, error) {
+	if !strings.Contains(s, "/") {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return ipRange{}, err
+		}
+		s = netip.PrefixFrom(addr, addr.BitLen()).String()
+	}
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return ipRange{}, err
+	}
+	if prefix.Addr().Zone() != "" {
+		return ipRange{}, fmt.Errorf("%s has a zone, which is not supported", s)
+	}
+	if prefix.Addr().Is4In6() {
+		if prefix.Bits() < 96 {
+			return ipRange{}, fmt.Errorf("%s is not an IPv4-mapped range", s)
+		}
+		prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+	}
+	prefix = prefix.Masked()
+
+	// the last address of the range has all the bits after the prefix set
+	to := prefix.Addr().AsSlice()
+	for bit := prefix.Bits(); bit < len(to)*8; bit++ {
+		to[bit/8] |= 1 << (7 - bit%8)
+	}
+	last, _ := netip.AddrFromSlice(to)
+	return ipRange{from: prefix.Addr(), to: last}, nil
+}
+
+// parseCIDRs parses IPv4 or IPv6 addresses or ranges in CIDR notation
+func parseCIDRs(cidrs []string) ([]ipRange, error) {
+	ranges := make([]ipRange, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		r, err := parseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+// parseList parses a list of CIDRs, one per line, ignoring blank lines and comments starting with '#'.
+// The errors only tell the line, as the content of the list is not to be echoed.
+func parseList(reader io.Reader) ([]ipRange, error) {
+	var ranges []ipRange
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		r, err := parseCIDR(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d is not an IP address or range", line)
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, scanner.Err()
+}
+
+// mergeRanges sorts the ranges, the IPv4 ones first, and merges the overlapping and adjacent ones
+func mergeRanges(ranges []ipRange) []ipRange {
+	if len(ranges) == 0 {
+		return nil
+	}
+	sorted := make([]ipRange, len(ranges))
+	copy(sorted, ranges)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].from.Less(sorted[j].from) })
+
+	merged := []ipRange{sorted[0]}
+	for _, r := range sorted[1:] {
+		last := &merged[len(merged)-1]
+		// addresses of different families never compare as adjacent, the next address of the last IPv4
+		// one being invalid
+		if next := last.to.Next(); r.from.Compare(last.to) <= 0 || r.from == next {
+			if last.to.Less(r.to) {
+				last.to = r.to
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// splitRanges splits the ranges by family
+func splitRanges(ranges []ipRange) (v4, v6 []ipRange) {
+	for _, r := range ranges {
+		if r.from.Is4() {
+			v4 = append(v4, r)
+		} else {
+			v6 = append(v6, r)
+		}
+	}
+	return v4, v6
+}
@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/kuadrant/kuadrant-operator/cmd/extensions/ip-policy/api/v1alpha1"
+	"github.com/kuadrant/kuadrant-operator/internal/kuadrant"
+	extcontroller "github.com/kuadrant/kuadrant-operator/pkg/extension/controller"
+	"github.com/kuadrant/kuadrant-operator/pkg/extension/types"
+)
+
+// +kubebuilder:rbac:groups=extensions.kuadrant.io,resources=ippolicies,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=extensions.kuadrant.io,resources=ippolicies/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=extensions.kuadrant.io,resources=ippolicies/finalizers,verbs=update
+
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch
+
+type IPPolicyReconciler struct {
+	types.ExtensionBase
+
+	feeds *feedCache
+}
+
+func NewIPPolicyReconciler() *IPPolicyReconciler {
+	return &IPPolicyReconciler{feeds: newFeedCache(allowedFeedHosts())}
+}
+
+func (r *IPPolicyReconciler) Reconcile(ctx context.Context, request reconcile.Request, kuadrantCtx types.KuadrantCtx) (reconcile.Result, error) {
+	if err := r.Configure(ctx); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to configure extension: %w", err)
+	}
+	r.Logger.Info("reconciling ippolicy started")
+	defer r.Logger.Info("reconciling ippolicy completed")
+
+	ipPolicy := &v1alpha1.IPPolicy{}
+	if err := r.Client.Get(ctx, request.NamespacedName, ipPolicy); err != nil {
+		if errors.IsNotFound(err) {
+			r.Logger.Error(err, "ippolicy not found")
+			return reconcile.Result{}, nil
+		}
+		r.Logger.Error(err, "failed to retrieve ippolicy")
+		return reconcile.Result{}, err
+	}
+
+	if ipPolicy.GetDeletionTimestamp() != nil {
+		r.Logger.Info("ippolicy marked for deletion")
+		return reconcile.Result{}, nil
+	}
+
+	specErr := r.reconcileSpec(ctx, ipPolicy, kuadrantCtx)
+	if err := extcontroller.ReportTargetStatus(ctx, kuadrantCtx, ipPolicy, specErr); err != nil {
+		r.Logger.Error(err, "failed to report policy status")
+	}
+	statusErr := extcontroller.UpdatePolicyStatus(ctx, r.Client, ipPolicy, specErr)
+
+	if specErr != nil {
+		return reconcile.Result{}, specErr
+	}
+	if statusErr != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to update status: %w", statusErr)
+	}
+
+	// the external lists are read again periodically, to pick up the changes of the feeds and configmaps
+	if len(ipPolicy.GetLists()) > 0 {
+		return reconcile.Result{RequeueAfter: ipPolicy.GetRefreshInterval()}, nil
+	}
+	return reconcile.Result{}, nil
+}
+
+func (r *IPPolicyReconciler) validateTarget(ctx context.Context, pol *v1alpha1.IPPolicy) error {
+	ref := pol.Spec.TargetRef
+	nn := k8stypes.NamespacedName{Name: string(ref.Name), Namespace: pol.Namespace}
+
+	var obj client.Object
+	switch ref.Kind {
+	case "Gateway":
+		obj = &gwapiv1.Gateway{}
+	case "HTTPRoute":
+		obj = &gwapiv1.HTTPRoute{}
+	default:
+		return fmt.Errorf("unsupported targetRef kind: %s", ref.Kind)
+	}
+
+	if err := r.Client.Get(ctx, nn, obj); err != nil {
+		if errors.IsNotFound(err) {
+			return kuadrant.NewErrTargetNotFound("IPPolicy", ref.LocalPolicyTargetReference, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// reconcileSpec resolves the allow and deny sets of the policy and commits the pipeline denying the
+// requests of the clients not allowed. The denial is an access action, evaluated before any other action
+// of the data plane, so that the requests denied are neither authenticated nor rate limited.
+func (r *IPPolicyReconciler) reconcileSpec(ctx context.Context, pol *v1alpha1.IPPolicy, kuadrantCtx types.KuadrantCtx) error {
+	if err := r.validateTarget(ctx, pol); err != nil {
+		return err
+	}
+
+	var allowRanges, denyRanges []ipRange
+	var err error
+	if pol.Spec.Allow != nil {
+		if allowRanges, err = r.resolveSet(ctx, pol, pol.Spec.Allow); err != nil {
+			r.Logger.Error(err, "failed to resolve allow set")
+			return err
+		}
+	}
+	if pol.Spec.Deny != nil {
+		if denyRanges, err = r.resolveSet(ctx, pol, pol.Spec.Deny); err != nil {
+			r.Logger.Error(err, "failed to resolve deny set")
+			return err
+		}
+	}
+
+	pipeline := kuadrantCtx.NewPipeline(pol)
+
+	if err := pipeline.OnHTTPAccess(types.DenyAction{
+		Predicate:  denyPredicate(pol, allowRanges, denyRanges),
+		WithStatus: http.StatusForbidden,
+	}); err != nil {
+		return err
+	}
+
+	if err := pipeline.Commit(ctx); err != nil {
+		r.Logger.Error(err, "failed to commit pipeline")
+		return err
+	}
+
+	r.Logger.Info("pipeline committed successfully", "allowRanges", len(allowRanges), "denyRanges", len(denyRanges))
+	return nil
+}
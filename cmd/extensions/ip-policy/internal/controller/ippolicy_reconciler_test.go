@@ -0,0 +1,194 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	"github.com/kuadrant/kuadrant-operator/cmd/extensions/ip-policy/api/v1alpha1"
+	extcontroller "github.com/kuadrant/kuadrant-operator/pkg/extension/controller"
+	extpb "github.com/kuadrant/kuadrant-operator/pkg/extension/grpc/v1"
+	exttesting "github.com/kuadrant/kuadrant-operator/pkg/extension/testing"
+	"github.com/kuadrant/kuadrant-operator/pkg/extension/types"
+)
+
+// newTestClient returns a fake client setting the kind of the objects read, as the cache of the manager
+// does, the kind of the policy identifying its pipeline
+func newTestClient(t *testing.T, objs ...client.Object) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := gwapiv1.Install(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).
+		WithStatusSubresource(&v1alpha1.IPPolicy{}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				if err := c.Get(ctx, key, obj, opts...); err != nil {
+					return err
+				}
+				gvk, err := apiutil.GVKForObject(obj, c.Scheme())
+				if err != nil {
+					return err
+				}
+				obj.GetObjectKind().SetGroupVersionKind(gvk)
+				return nil
+			},
+		}).Build()
+}
+
+func TestIPPolicyReconciler(t *testing.T) {
+	healthy := true
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("203.0.113.0/24\n2001:db8:ff::/48\n"))
+	}))
+	defer server.Close()
+
+	policy := &v1alpha1.IPPolicy{
+		TypeMeta:   metav1.TypeMeta{APIVersion: v1alpha1.GroupVersion.String(), Kind: "IPPolicy"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "blocklist"},
+		Spec: v1alpha1.IPPolicySpec{
+			TargetRef: gatewayapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+				LocalPolicyTargetReference: gatewayapiv1alpha2.LocalPolicyTargetReference{
+					Group: gwapiv1.GroupName,
+					Kind:  "Gateway",
+					Name:  "gateway",
+				},
+			},
+			Deny: &v1alpha1.IPSet{
+				CIDRs: []string{"198.51.100.7"},
+				Lists: []v1alpha1.ListReference{{URL: server.URL}},
+			},
+		},
+	}
+	gateway := &gwapiv1.Gateway{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "gateway"}}
+	k8sClient := newTestClient(t, policy.DeepCopy(), gateway)
+
+	now := time.Now()
+	reconciler := NewIPPolicyReconciler()
+	reconciler.feeds = testFeedCache(server)
+	reconciler.feeds.now = func() time.Time { return now }
+
+	srv := exttesting.NewServer(t)
+	ctrl := srv.NewController(extcontroller.ExtensionConfig{
+		Name:       "ip-policy",
+		PolicyKind: "IPPolicy",
+		ForType:    &v1alpha1.IPPolicy{},
+		Reconcile:  reconciler.Reconcile,
+	}, k8sClient, k8sClient.Scheme())
+
+	reconcilePolicy := func() *v1alpha1.IPPolicy {
+		t.Helper()
+		if _, err := ctrl.Reconcile(context.Background(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(policy)}); err != nil {
+			t.Fatalf("unexpected reconcile error: %v", err)
+		}
+		reconciled := &v1alpha1.IPPolicy{}
+		if err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(policy), reconciled); err != nil {
+			t.Fatal(err)
+		}
+		return reconciled
+	}
+
+	reconciled := reconcilePolicy()
+
+	// the denial is evaluated before any other action of the data plane
+	actions := srv.Pipeline(policy, exttesting.PhaseAccess)
+	if len(actions) != 1 {
+		t.Fatalf("expected 1 access action, got %d", len(actions))
+	}
+	if actions[0].ActionType != extpb.ActionType_ACTION_TYPE_DENY || actions[0].WithStatus != http.StatusForbidden {
+		t.Errorf("unexpected access action: %+v", actions[0])
+	}
+	for _, bound := range []string{"3405803776", "20010db800ff00000000000000000000", "3325256711"} {
+		if !strings.Contains(actions[0].Predicate, bound) {
+			t.Errorf("expected the predicate to match the ranges of the CIDRs and of the list, missing %s", bound)
+		}
+	}
+	if len(srv.Pipeline(policy, exttesting.PhaseRequest)) != 0 {
+		t.Error("expected no request actions")
+	}
+	if !meta.IsStatusConditionTrue(reconciled.Status.Conditions, string(types.PolicyConditionEnforced)) {
+		t.Errorf("expected the policy to be enforced, got %v", reconciled.Status.Conditions)
+	}
+
+	// the list can no longer be fetched, the last one fetched is still enforced
+	healthy = false
+	now = now.Add(2 * v1alpha1.DefaultRefreshInterval)
+	reconciled = reconcilePolicy()
+
+	if len(srv.Pipeline(policy, exttesting.PhaseAccess)) != 1 {
+		t.Error("expected the denial to be kept")
+	}
+	if !meta.IsStatusConditionTrue(reconciled.Status.Conditions, string(gatewayapiv1alpha2.PolicyConditionAccepted)) {
+		t.Errorf("expected the policy to be accepted, got %v", reconciled.Status.Conditions)
+	}
+	enforced := meta.FindStatusCondition(reconciled.Status.Conditions, string(types.PolicyConditionEnforced))
+	if enforced == nil || enforced.Status != metav1.ConditionFalse || !strings.Contains(enforced.Message, "enforcing the list fetched at") {
+		t.Errorf("expected the stale list to be reported, got %v", enforced)
+	}
+}
+
+func TestIPPolicyReconciler_TargetNotFound(t *testing.T) {
+	policy := &v1alpha1.IPPolicy{
+		TypeMeta:   metav1.TypeMeta{APIVersion: v1alpha1.GroupVersion.String(), Kind: "IPPolicy"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "allowlist"},
+		Spec: v1alpha1.IPPolicySpec{
+			TargetRef: gatewayapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+				LocalPolicyTargetReference: gatewayapiv1alpha2.LocalPolicyTargetReference{
+					Group: gwapiv1.GroupName,
+					Kind:  "HTTPRoute",
+					Name:  "missing",
+				},
+			},
+			Allow: &v1alpha1.IPSet{CIDRs: []string{"10.0.0.0/8"}},
+		},
+	}
+	k8sClient := newTestClient(t, policy.DeepCopy())
+
+	srv := exttesting.NewServer(t)
+	ctrl := srv.NewController(extcontroller.ExtensionConfig{
+		Name:       "ip-policy",
+		PolicyKind: "IPPolicy",
+		ForType:    &v1alpha1.IPPolicy{},
+		Reconcile:  NewIPPolicyReconciler().Reconcile,
+	}, k8sClient, k8sClient.Scheme())
+
+	if _, err := ctrl.Reconcile(context.Background(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(policy)}); err == nil {
+		t.Error("expected the reconcile to fail")
+	}
+	if len(srv.Pipeline(policy, exttesting.PhaseAccess)) != 0 {
+		t.Error("expected no denial for a policy whose target is missing")
+	}
+
+	reconciled := &v1alpha1.IPPolicy{}
+	if err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(policy), reconciled); err != nil {
+		t.Fatal(err)
+	}
+	accepted := meta.FindStatusCondition(reconciled.Status.Conditions, string(gatewayapiv1alpha2.PolicyConditionAccepted))
+	if accepted == nil || accepted.Status != metav1.ConditionFalse || accepted.Reason != string(gatewayapiv1alpha2.PolicyReasonTargetNotFound) {
+		t.Errorf("expected the policy not to be accepted for its missing target, got %v", accepted)
+	}
+}
@@ -0,0 +1,228 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/env"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/kuadrant/kuadrant-operator/cmd/extensions/ip-policy/api/v1alpha1"
+	extcontroller "github.com/kuadrant/kuadrant-operator/pkg/extension/controller"
+)
+
+const (
+	// maxFeedBytes is the maximum size of a list fetched from a URL
+	maxFeedBytes = 4 << 20
+
+	feedTimeout = 30 * time.Second
+
+	// maxFeedRedirects is the maximum number of redirects followed when fetching a list
+	maxFeedRedirects = 5
+)
+
+// allowedFeedHosts returns the hosts the lists can be fetched from, set by the cluster administrator as a
+// comma-separated list. Lists served by URLs are disabled when none is set.
+func allowedFeedHosts() map[string]bool {
+	hosts := make(map[string]bool)
+	for _, host := range strings.Split(env.GetString("IP_POLICY_FEED_HOSTS", ""), ",") {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			hosts[host] = true
+		}
+	}
+	return hosts
+}
+
+// staleListError tells that a list could not be fetched again, the list fetched last being served instead
+type staleListError struct {
+	fetchedAt time.Time
+	err       error
+}
+
+func (e *staleListError) Error() string {
+	return fmt.Sprintf("%v, enforcing the list fetched at %s", e.err, e.fetchedAt.UTC().Format(time.RFC3339))
+}
+
+func (e *staleListError) Unwrap() error {
+	return e.err
+}
+
+type cachedFeed struct {
+	ranges    []ipRange
+	fetchedAt time.Time
+}
+
+// feedCache fetches the lists served by URLs, keeping them in memory until they are due for a refresh
+type feedCache struct {
+	httpClient   *http.Client
+	now          func() time.Time
+	allowedHosts map[string]bool
+
+	mutex sync.Mutex
+	feeds map[string]cachedFeed
+}
+
+func newFeedCache(allowedHosts map[string]bool) *feedCache {
+	c := &feedCache{
+		now:          time.Now,
+		allowedHosts: allowedHosts,
+		feeds:        make(map[string]cachedFeed),
+	}
+	c.httpClient = &http.Client{
+		Timeout: feedTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxFeedRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxFeedRedirects)
+			}
+			return c.checkURL(req.URL)
+		},
+	}
+	return c
+}
+
+// checkURL restricts the lists to the https URLs of the allowed hosts, so that policies cannot have the
+// extension reach arbitrary endpoints of the cluster network
+func (c *feedCache) checkURL(u *url.URL) error {
+	if u.Scheme != "https" {
+		return fmt.Errorf("list %s is not served over https", u.Redacted())
+	}
+	if !c.allowedHosts[strings.ToLower(u.Hostname())] {
+		return fmt.Errorf("host %s of list %s is not allowed, see IP_POLICY_FEED_HOSTS", u.Hostname(), u.Redacted())
+	}
+	return nil
+}
+
+// get returns the list served by the URL, fetched again when older than the refresh interval.
+// The last list fetched is returned when the URL cannot be fetched again, along with a staleListError.
+func (c *feedCache) get(ctx context.Context, rawURL string, refreshInterval time.Duration) ([]ipRange, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid list url: %w", err)
+	}
+	if err := c.checkURL(u); err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	cached, found := c.feeds[rawURL]
+	c.mutex.Unlock()
+	if found && c.now().Sub(cached.fetchedAt) < refreshInterval {
+		return cached.ranges, nil
+	}
+
+	ranges, err := c.fetch(ctx, u)
+	if err != nil {
+		if found {
+			return cached.ranges, &staleListError{fetchedAt: cached.fetchedAt, err: err}
+		}
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	c.feeds[rawURL] = cachedFeed{ranges: ranges, fetchedAt: c.now()}
+	c.mutex.Unlock()
+	return ranges, nil
+}
+
+// fetch fetches the list served by the URL. The errors never carry the content served, as they end up in
+// the status of the policy.
+func (c *feedCache) fetch(ctx context.Context, u *url.URL) ([]ipRange, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch list %s: %w", u.Redacted(), errors.Unwrap(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch list %s: unexpected status %d", u.Redacted(), resp.StatusCode)
+	}
+	ranges, err := parseList(io.LimitReader(resp.Body, maxFeedBytes))
+	if err != nil {
+		return nil, fmt.Errorf("invalid list %s: %w", u.Redacted(), err)
+	}
+	return ranges, nil
+}
+
+// resolveSet returns the ranges of the CIDRs and of the lists of the set, merged
+func (r *IPPolicyReconciler) resolveSet(ctx context.Context, pol *v1alpha1.IPPolicy, set *v1alpha1.IPSet) ([]ipRange, error) {
+	ranges, err := parseCIDRs(set.CIDRs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, list := range set.Lists {
+		var listRanges []ipRange
+		switch {
+		case list.ConfigMap != nil:
+			listRanges, err = r.readConfigMapList(ctx, pol.Namespace, list.ConfigMap)
+		default:
+			listRanges, err = r.feeds.get(ctx, list.URL, pol.GetRefreshInterval())
+			// a list that could not be refreshed is still enforced, the policy is reported as not enforced
+			// with its latest version
+			var stale *staleListError
+			if errors.As(err, &stale) {
+				extcontroller.ReportEnforcementError(ctx, stale)
+				err = nil
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, listRanges...)
+	}
+
+	merged := mergeRanges(ranges)
+	if len(merged) > maxRanges {
+		return nil, fmt.Errorf("too many IP ranges: %d after merging, the maximum is %d", len(merged), maxRanges)
+	}
+	return merged, nil
+}
+
+func (r *IPPolicyReconciler) readConfigMapList(ctx context.Context, namespace string, ref *v1alpha1.ConfigMapKeyReference) ([]ipRange, error) {
+	configMap := &corev1.ConfigMap{}
+	if err := r.Client.Get(ctx, k8stypes.NamespacedName{Name: ref.Name, Namespace: namespace}, configMap); err != nil {
+		return nil, fmt.Errorf("failed to read list from configmap %s: %w", ref.Name, err)
+	}
+	data, found := configMap.Data[ref.Key]
+	if !found {
+		return nil, fmt.Errorf("key %s not found in configmap %s", ref.Key, ref.Name)
+	}
+	ranges, err := parseList(strings.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid list in configmap %s: %w", ref.Name, err)
+	}
+	return ranges, nil
+}
+
+// ConfigMapToPolicies maps a ConfigMap to the policies of its namespace reading a list from it, so that
+// the changes of the list are enforced without waiting for the refresh interval
+func ConfigMapToPolicies(ctx context.Context, c client.Reader, obj client.Object) []reconcile.Request {
+	policies := &v1alpha1.IPPolicyList{}
+	if err := c.List(ctx, policies, client.InNamespace(obj.GetNamespace())); err != nil {
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, pol := range policies.Items {
+		if lo.ContainsBy(pol.GetLists(), func(list v1alpha1.ListReference) bool {
+			return list.ConfigMap != nil && list.ConfigMap.Name == obj.GetName()
+		}) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&pol)})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,229 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/kuadrant/kuadrant-operator/cmd/extensions/ip-policy/api/v1alpha1"
+)
+
+func testRange(from, to string) ipRange {
+	return ipRange{from: netip.MustParseAddr(from), to: netip.MustParseAddr(to)}
+}
+
+func TestParseCIDR(t *testing.T) {
+	cases := []struct {
+		cidr    string
+		want    ipRange
+		wantErr bool
+	}{
+		{cidr: "10.0.0.0/8", want: testRange("10.0.0.0", "10.255.255.255")},
+		{cidr: "192.168.1.10", want: testRange("192.168.1.10", "192.168.1.10")},
+		{cidr: "192.168.1.10/24", want: testRange("192.168.1.0", "192.168.1.255")},
+		{cidr: "0.0.0.0/0", want: testRange("0.0.0.0", "255.255.255.255")},
+		{cidr: "2001:db8::/32", want: testRange("2001:db8::", "2001:db8:ffff:ffff:ffff:ffff:ffff:ffff")},
+		{cidr: "2001:db8::1", want: testRange("2001:db8::1", "2001:db8::1")},
+		{cidr: "::ffff:10.0.0.0/104", want: testRange("10.0.0.0", "10.255.255.255")},
+		{cidr: "10.0.0.0/33", wantErr: true},
+		{cidr: "2001:db8::/129", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.cidr, func(t *testing.T) {
+			got, err := parseCIDR(tc.cidr)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("range = %s-%s, want %s-%s", got.from, got.to, tc.want.from, tc.want.to)
+			}
+		})
+	}
+}
+
+func TestMergeRanges(t *testing.T) {
+	ranges := mergeRanges([]ipRange{
+		testRange("0.0.0.20", "0.0.0.30"),
+		testRange("::", "::ffff"),
+		testRange("0.0.0.0", "0.0.0.5"),
+		testRange("0.0.0.6", "0.0.0.10"),
+		testRange("0.0.0.25", "0.0.0.40"),
+		testRange("255.255.255.240", "255.255.255.255"),
+		testRange("0.0.0.50", "0.0.0.50"),
+		testRange("::1:0", "::1:ffff"),
+	})
+	want := []ipRange{
+		testRange("0.0.0.0", "0.0.0.10"),
+		testRange("0.0.0.20", "0.0.0.40"),
+		testRange("0.0.0.50", "0.0.0.50"),
+		testRange("255.255.255.240", "255.255.255.255"),
+		testRange("::", "::1:ffff"),
+	}
+	if !reflect.DeepEqual(ranges, want) {
+		t.Errorf("merged ranges = %v, want %v", ranges, want)
+	}
+}
+
+func TestParseList(t *testing.T) {
+	ranges, err := parseList(strings.NewReader("# blocked networks\n\n10.0.0.0/8 ; internal\n192.168.1.10 # single address\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ipRange{testRange("10.0.0.0", "10.255.255.255"), testRange("192.168.1.10", "192.168.1.10")}
+	if !reflect.DeepEqual(ranges, want) {
+		t.Errorf("ranges = %v, want %v", ranges, want)
+	}
+
+	_, err = parseList(strings.NewReader("10.0.0.0/8\nnot-an-ip\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected an error on line 2, got %v", err)
+	}
+	if err != nil && strings.Contains(err.Error(), "not-an-ip") {
+		t.Errorf("expected the error not to echo the content of the list, got %v", err)
+	}
+}
+
+// testFeedCache returns a feed cache trusting the test server
+func testFeedCache(server *httptest.Server) *feedCache {
+	u, _ := url.Parse(server.URL)
+	cache := newFeedCache(map[string]bool{u.Hostname(): true})
+	cache.httpClient.Transport = server.Client().Transport
+	return cache
+}
+
+func TestFeedCache(t *testing.T) {
+	requests := 0
+	healthy := true
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintf(w, "10.0.0.%d\n", requests)
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	cache := testFeedCache(server)
+	cache.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	ranges, err := cache.get(ctx, server.URL, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []ipRange{testRange("10.0.0.1", "10.0.0.1")}; !reflect.DeepEqual(ranges, want) {
+		t.Errorf("ranges = %v, want %v", ranges, want)
+	}
+
+	if _, err := cache.get(ctx, server.URL, time.Minute); err != nil || requests != 1 {
+		t.Errorf("expected the list to be served from the cache, got %d requests and error %v", requests, err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	ranges, err = cache.get(ctx, server.URL, time.Minute)
+	if err != nil || requests != 2 {
+		t.Fatalf("expected the list to be fetched again, got %d requests and error %v", requests, err)
+	}
+	if want := []ipRange{testRange("10.0.0.2", "10.0.0.2")}; !reflect.DeepEqual(ranges, want) {
+		t.Errorf("ranges = %v, want %v", ranges, want)
+	}
+
+	healthy = false
+	now = now.Add(2 * time.Minute)
+	ranges, err = cache.get(ctx, server.URL, time.Minute)
+	if len(ranges) != 1 {
+		t.Errorf("expected the last list fetched to be kept, got %v", ranges)
+	}
+	var stale *staleListError
+	if !errors.As(err, &stale) {
+		t.Errorf("expected the failure to refresh the list to be reported, got %v", err)
+	}
+	if _, err := cache.get(ctx, server.URL+"/other", time.Minute); err == nil {
+		t.Error("expected an error for a list never fetched")
+	}
+}
+
+func TestFeedCache_URLs(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "https://169.254.169.254/latest/meta-data", http.StatusFound)
+		default:
+			fmt.Fprintln(w, "secret-token")
+		}
+	}))
+	defer server.Close()
+
+	cache := testFeedCache(server)
+	ctx := context.Background()
+	u, _ := url.Parse(server.URL)
+
+	cases := map[string]string{
+		"plain http":       "http://" + u.Host + "/list",
+		"host not allowed": "https://localhost:" + u.Port() + "/list",
+		"redirect":         server.URL + "/redirect",
+		"invalid list":     server.URL + "/list",
+	}
+	for name, feed := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := cache.get(ctx, feed, time.Minute)
+			if err == nil {
+				t.Fatal("expected the list to be rejected")
+			}
+			if strings.Contains(err.Error(), "secret-token") {
+				t.Errorf("expected the error not to echo the content served, got %v", err)
+			}
+		})
+	}
+
+	if _, err := newFeedCache(allowedFeedHosts()).get(ctx, server.URL, time.Minute); err == nil {
+		t.Error("expected the lists served by URLs to be disabled when no host is allowed")
+	}
+}
+
+func TestConfigMapToPolicies(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	policy := func(namespace, name, configMap string) *v1alpha1.IPPolicy {
+		return &v1alpha1.IPPolicy{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Spec: v1alpha1.IPPolicySpec{
+				Deny: &v1alpha1.IPSet{Lists: []v1alpha1.ListReference{{ConfigMap: &v1alpha1.ConfigMapKeyReference{Name: configMap, Key: "list"}}}},
+			},
+		}
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		policy("default", "blocklist", "blocked"),
+		policy("default", "other", "other"),
+		policy("elsewhere", "blocklist", "blocked"),
+	).Build()
+
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "blocked"}}
+	requests := ConfigMapToPolicies(context.Background(), c, configMap)
+	want := []reconcile.Request{{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: "blocklist"}}}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("requests = %v, want %v", requests, want)
+	}
+}
@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kuadrant/kuadrant-operator/cmd/extensions/ip-policy/api/v1alpha1"
+)
+
+// maxRanges is the maximum number of IP ranges of a set, once merged, bounding the size of the predicate
+const maxRanges = 2048
+
+// The predicate reads the IP address of the client once, bound to these variables by comprehensions:
+// the address as a string, stripped of its port, and its value as an integer when IPv4, -1 otherwise,
+// and as 32 hexadecimal digits when IPv6, empty otherwise. Both values compare in the order of the
+// addresses, so that the ranges are matched by comparing them to their bounds.
+const (
+	clientIPVar = "ip"
+	ipv4Var     = "n4"
+	ipv6Var     = "n6"
+)
+
+// clientIPExpression returns the CEL expression of the IP address of the client. Behind trusted proxies,
+// it is the entry of the header at the position of the number of trusted hops counting from the right,
+// falling back to the source address when the header is missing or has too few entries. IPv4-mapped IPv6
+// addresses are read as the IPv4 addresses they map.
+func clientIPExpression(clientIP *v1alpha1.ClientIP) string {
+	// IPv6 source addresses are enclosed in brackets, followed by the port
+	address := "(source.address.startsWith('[') ? source.address.substring(1, source.address.indexOf(']')) : source.address.split(':')[0])"
+	if clientIP != nil && clientIP.TrustedHops > 0 {
+		header := strconv.Quote(strings.ToLower(clientIP.GetHeader()))
+		entries := fmt.Sprintf("request.headers[%s].split(',')", header)
+		address = fmt.Sprintf("(%s in request.headers && size(%s) >= %d ? %s[size(%s) - %d].trim() : %s)",
+			header, entries, clientIP.TrustedHops, entries, entries, clientIP.TrustedHops, address)
+	}
+	return fmt.Sprintf("[%s.lowerAscii()].map(a, a.startsWith('::ffff:') && a.contains('.') ? a.substring(7) : a)[0]", address)
+}
+
+// ipv4Expression returns the CEL expression of the value of the client IP as an integer, or -1 when it
+// is not an IPv4 address
+func ipv4Expression() string {
+	return fmt.Sprintf("[%s.split('.')].map(o, size(o) == 4 && o.all(x, x.matches('^[0-9]{1,3}$') && int(x) <= 255) ? "+
+		"int(o[0]) * 16777216 + int(o[1]) * 65536 + int(o[2]) * 256 + int(o[3]) : -1)[0]", clientIPVar)
+}
+
+// ipv6Expression returns the CEL expression of the value of the client IP as 32 hexadecimal digits, or an
+// empty string when it is not an IPv6 address. The groups elided by '::' are restored as zeros and each
+// group is padded to 4 digits.
+func ipv6Expression() string {
+	group := "x.matches('^[0-9a-f]{1,4}$')"
+	return fmt.Sprintf("[%s.split('::')].map(p, "+
+		"[[p[0] == '' ? [] : p[0].split(':'), size(p) == 2 && p[1] != '' ? p[1].split(':') : []]].map(s, "+
+		"size(p) <= 2 && (size(p) == 2 ? size(s[0]) + size(s[1]) < 8 : size(s[0]) == 8) && s[0].all(x, %s) && s[1].all(x, %s) ? "+
+		"[[0, 1, 2, 3, 4, 5, 6, 7].map(i, i < size(s[0]) ? s[0][i] : (i >= 8 - size(s[1]) ? s[1][i - 8 + size(s[1])] : '0'))"+
+		".map(x, ('000' + x).substring(size(x) - 1))].map(h, h[0] + h[1] + h[2] + h[3] + h[4] + h[5] + h[6] + h[7])[0] : '')[0])[0]",
+		clientIPVar, group, group)
+}
+
+// rangesExpression returns the CEL expression matching the client IP against the ranges
+func rangesExpression(ranges []ipRange) string {
+	v4, v6 := splitRanges(ranges)
+	var matches []string
+	if len(v4) > 0 {
+		bounds := make([]string, 0, len(v4))
+		for _, r := range v4 {
+			bounds = append(bounds, fmt.Sprintf("[%d,%d]", binary.BigEndian.Uint32(r.from.AsSlice()), binary.BigEndian.Uint32(r.to.AsSlice())))
+		}
+		matches = append(matches, fmt.Sprintf("[%s].exists(r, %s >= r[0] && %s <= r[1])", strings.Join(bounds, ","), ipv4Var, ipv4Var))
+	}
+	if len(v6) > 0 {
+		bounds := make([]string, 0, len(v6))
+		for _, r := range v6 {
+			bounds = append(bounds, fmt.Sprintf("['%s','%s']", hex.EncodeToString(r.from.AsSlice()), hex.EncodeToString(r.to.AsSlice())))
+		}
+		matches = append(matches, fmt.Sprintf("[%s].exists(r, %s >= r[0] && %s <= r[1])", strings.Join(bounds, ","), ipv6Var, ipv6Var))
+	}
+	return strings.Join(matches, " || ")
+}
+
+// countriesExpression returns the CEL expression matching the country of the client, read from the
+// header set by a trusted CDN or load balancer, against the countries
+func countriesExpression(geo *v1alpha1.Geo, countries []string) string {
+	header := strconv.Quote(strings.ToLower(geo.CountryHeader))
+	quoted := make([]string, 0, len(countries))
+	for _, country := range countries {
+		quoted = append(quoted, strconv.Quote(country))
+	}
+	return fmt.Sprintf("(%s in request.headers && request.headers[%s] in [%s])",
+		header, header, strings.Join(quoted, ","))
+}
+
+// setExpression returns the CEL expression matching the clients of a set, given its resolved ranges
+func setExpression(pol *v1alpha1.IPPolicy, set *v1alpha1.IPSet, ranges []ipRange) string {
+	var matches []string
+	if len(ranges) > 0 {
+		matches = append(matches, rangesExpression(ranges))
+	}
+	if len(set.Countries) > 0 && pol.Spec.Geo != nil {
+		matches = append(matches, countriesExpression(pol.Spec.Geo, set.Countries))
+	}
+	if len(matches) == 0 {
+		return "false"
+	}
+	return strings.Join(matches, " || ")
+}
+
+// denyPredicate returns the CEL predicate of the requests denied: those not matching the allow set,
+// when set, and those matching the deny set. The clients whose address is neither IPv4 nor IPv6, e.g.
+// a malformed header entry, cannot be matched against the ranges of the deny set, and are denied rather
+// than let through.
+func denyPredicate(pol *v1alpha1.IPPolicy, allowRanges, denyRanges []ipRange) string {
+	var predicates []string
+	if pol.Spec.Allow != nil {
+		predicates = append(predicates, fmt.Sprintf("!(%s)", setExpression(pol, pol.Spec.Allow, allowRanges)))
+	}
+	if pol.Spec.Deny != nil {
+		if len(denyRanges) > 0 {
+			predicates = append(predicates, fmt.Sprintf("(%s < 0 && %s == '')", ipv4Var, ipv6Var))
+		}
+		predicates = append(predicates, fmt.Sprintf("(%s)", setExpression(pol, pol.Spec.Deny, denyRanges)))
+	}
+	predicate := strings.Join(predicates, " || ")
+	if len(allowRanges) == 0 && len(denyRanges) == 0 {
+		return predicate
+	}
+	return fmt.Sprintf("[%s].exists(%s, [%s].exists(%s, [%s].exists(%s, %s)))",
+		clientIPExpression(pol.Spec.ClientIP), clientIPVar, ipv4Expression(), ipv4Var, ipv6Expression(), ipv6Var, predicate)
+}
@@ -0,0 +1,128 @@
+//go:build unit
+
+package controller
+
+import (
+	"testing"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+
+	"github.com/kuadrant/kuadrant-operator/cmd/extensions/ip-policy/api/v1alpha1"
+)
+
+func evalDenyPredicate(t *testing.T, predicate, sourceAddress string, headers map[string]string) bool {
+	t.Helper()
+	env, err := cel.NewEnv(cel.Variable("source", cel.DynType), cel.Variable("request", cel.DynType), ext.Strings())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ast, iss := env.Compile(predicate)
+	if iss != nil && iss.Err() != nil {
+		t.Fatalf("compile failed for predicate %q: %v", predicate, iss.Err())
+	}
+	prg, err := env.Program(ast)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, _, err := prg.Eval(map[string]interface{}{
+		"source":  map[string]interface{}{"address": sourceAddress},
+		"request": map[string]interface{}{"headers": headers},
+	})
+	if err != nil {
+		t.Fatalf("eval failed for predicate %q: %v", predicate, err)
+	}
+	return out.Value().(bool)
+}
+
+func mustParseCIDRs(t *testing.T, cidrs ...string) []ipRange {
+	t.Helper()
+	ranges, err := parseCIDRs(cidrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mergeRanges(ranges)
+}
+
+func TestDenyPredicate(t *testing.T) {
+	pol := &v1alpha1.IPPolicy{
+		Spec: v1alpha1.IPPolicySpec{
+			Allow: &v1alpha1.IPSet{CIDRs: []string{"10.0.0.0/8", "2001:db8::/32"}, Countries: []string{"FR"}},
+			Deny:  &v1alpha1.IPSet{CIDRs: []string{"10.1.0.0/16", "2001:db8:1::/48"}},
+			Geo:   &v1alpha1.Geo{CountryHeader: "CF-IPCountry"},
+		},
+	}
+	predicate := denyPredicate(pol, mustParseCIDRs(t, pol.Spec.Allow.CIDRs...), mustParseCIDRs(t, pol.Spec.Deny.CIDRs...))
+
+	cases := []struct {
+		name          string
+		sourceAddress string
+		headers       map[string]string
+		denied        bool
+	}{
+		{name: "allowed address", sourceAddress: "10.2.3.4:51234", denied: false},
+		{name: "denied address within the allowed range", sourceAddress: "10.1.3.4:51234", denied: true},
+		{name: "address not allowed", sourceAddress: "192.168.1.1:51234", denied: true},
+		{name: "allowed country", sourceAddress: "192.168.1.1:51234", headers: map[string]string{"cf-ipcountry": "FR"}, denied: false},
+		{name: "country not allowed", sourceAddress: "192.168.1.1:51234", headers: map[string]string{"cf-ipcountry": "US"}, denied: true},
+		{name: "allowed ipv6 address", sourceAddress: "[2001:db8::1]:51234", denied: false},
+		{name: "allowed ipv6 address in full", sourceAddress: "[2001:0DB8:0:0:0:0:0:1]:51234", denied: false},
+		{name: "denied ipv6 address within the allowed range", sourceAddress: "[2001:db8:1::7]:51234", denied: true},
+		{name: "ipv6 address not allowed", sourceAddress: "[2001:db9::1]:51234", denied: true},
+		{name: "ipv6 loopback not allowed", sourceAddress: "[::1]:51234", denied: true},
+		{name: "allowed ipv4-mapped address", sourceAddress: "[::ffff:10.2.3.4]:51234", denied: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := evalDenyPredicate(t, predicate, tc.sourceAddress, tc.headers); got != tc.denied {
+				t.Errorf("denied = %v, want %v", got, tc.denied)
+			}
+		})
+	}
+}
+
+func TestDenyPredicate_TrustedHops(t *testing.T) {
+	pol := &v1alpha1.IPPolicy{
+		Spec: v1alpha1.IPPolicySpec{
+			Deny:     &v1alpha1.IPSet{CIDRs: []string{"203.0.113.0/24", "2001:db8:ff::/48"}},
+			ClientIP: &v1alpha1.ClientIP{TrustedHops: 2},
+		},
+	}
+	predicate := denyPredicate(pol, nil, mustParseCIDRs(t, pol.Spec.Deny.CIDRs...))
+
+	cases := []struct {
+		name          string
+		sourceAddress string
+		headers       map[string]string
+		denied        bool
+	}{
+		{name: "denied client behind the proxies", sourceAddress: "10.0.0.1:4000", headers: map[string]string{"x-forwarded-for": "1.1.1.1, 203.0.113.7, 10.0.0.2"}, denied: true},
+		{name: "spoofed entry ignored", sourceAddress: "10.0.0.1:4000", headers: map[string]string{"x-forwarded-for": "203.0.113.7, 198.51.100.1, 10.0.0.2"}, denied: false},
+		{name: "too few entries", sourceAddress: "203.0.113.9:4000", headers: map[string]string{"x-forwarded-for": "10.0.0.2"}, denied: true},
+		{name: "header missing", sourceAddress: "198.51.100.1:4000", denied: false},
+		{name: "ipv6 client behind the proxies", sourceAddress: "10.0.0.1:4000", headers: map[string]string{"x-forwarded-for": "1.1.1.1, 2001:db8::1, 10.0.0.2"}, denied: false},
+		{name: "denied ipv6 client behind the proxies", sourceAddress: "10.0.0.1:4000", headers: map[string]string{"x-forwarded-for": "1.1.1.1, 2001:db8:ff::9, 10.0.0.2"}, denied: true},
+		{name: "malformed entry", sourceAddress: "10.0.0.1:4000", headers: map[string]string{"x-forwarded-for": "1.1.1.1, 203.0.113, 10.0.0.2"}, denied: true},
+		{name: "ipv6 address", sourceAddress: "[2001:db8::1]:4000", denied: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := evalDenyPredicate(t, predicate, tc.sourceAddress, tc.headers); got != tc.denied {
+				t.Errorf("denied = %v, want %v", got, tc.denied)
+			}
+		})
+	}
+}
+
+func TestDenyPredicate_EmptySets(t *testing.T) {
+	pol := &v1alpha1.IPPolicy{
+		Spec: v1alpha1.IPPolicySpec{
+			Allow: &v1alpha1.IPSet{},
+		},
+	}
+	if predicate := denyPredicate(pol, nil, nil); predicate != "!(false)" {
+		t.Errorf("predicate = %s, want %s", predicate, "!(false)")
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+
+	corev1 "k8s.io/api/core/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/kuadrant/kuadrant-operator/cmd/extensions/ip-policy/api/v1alpha1"
+	"github.com/kuadrant/kuadrant-operator/cmd/extensions/ip-policy/internal/controller"
+	extcontroller "github.com/kuadrant/kuadrant-operator/pkg/extension/controller"
+)
+
+var (
+	scheme = k8sruntime.NewScheme()
+)
+
+func init() {
+	utilruntime.Must(corev1.AddToScheme(scheme))
+	utilruntime.Must(gatewayapiv1.Install(scheme))
+	utilruntime.Must(v1alpha1.AddToScheme(scheme))
+}
+
+func main() {
+	reconciler := controller.NewIPPolicyReconciler()
+	builder, logger := extcontroller.NewBuilder("ip-policy-extension-controller")
+	extController, err := builder.
+		WithScheme(scheme).
+		WithReconciler(reconciler.Reconcile).
+		For(&v1alpha1.IPPolicy{}).
+		WatchesMapped(&corev1.ConfigMap{}, controller.ConfigMapToPolicies).
+		Build()
+	if err != nil {
+		logger.Error(err, "unable to create controller")
+		os.Exit(1)
+	}
+	if err := extController.Start(ctrl.SetupSignalHandler()); err != nil {
+		logger.Error(err, "unable to start extension controller")
+		os.Exit(1)
+	}
+}
@@ -20,14 +20,15 @@ import (
 	"fmt"
 	"net/url"
 	"path"
+	"sort"
+	"time"
 
-	"github.com/go-logr/logr"
-	"github.com/google/go-cmp/cmp"
 	authorinov1beta3 "github.com/kuadrant/authorino/api/v1beta3"
-	"github.com/kuadrant/limitador-operator/pkg/helpers"
 	"github.com/samber/lo"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	gatewayapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	exttypes "github.com/kuadrant/kuadrant-operator/pkg/extension/types"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -38,11 +39,25 @@ const (
 	DefaultTokenExchangePath = "/oauth/token" //nolint:gosec
 	DefaultAuthorizePath     = "/oauth/authorize"
 	DefaultTokenSourceName   = "jwt"
+	DefaultRefreshPath       = "/auth/refresh"
+	DefaultLogoutPath        = "/logout"
+	DefaultLoginPath         = "/auth/login"
+	DiscoveryPath            = "/.well-known/openid-configuration"
+	DefaultSessionCookieName = "oidc-session"
+	DefaultSessionLifetime   = time.Hour
+	DefaultRefreshBefore     = 30 * time.Second
+	DefaultCookieSameSite    = "Lax"
+	DefaultPKCECookieName    = "oidc-pkce"
+	DefaultPKCECookieMaxAge  = 5 * time.Minute
+	DefaultGroupsClaim       = "groups"
+	DefaultRolesClaim        = "roles"
 
 	StatusConditionReady string = "Ready"
 )
 
 // OIDCPolicySpec defines the desired state of OIDCPolicy
+//
+//	+kubebuilder:validation:XValidation:rule="!has(self.session) || !has(self.session.cookie) || !has(self.session.cookie.sameSite) || self.session.cookie.sameSite != 'None' || (has(self.provider.redirectURI) && self.provider.redirectURI.startsWith('https://'))",message="sameSite None requires an https redirectURI"
 type OIDCPolicySpec struct {
 	// Reference to the object to which this policy applies.
 	// +kubebuilder:validation:XValidation:rule="self.group == 'gateway.networking.k8s.io'",message="Invalid targetRef.group. The only supported value is 'gateway.networking.k8s.io'"
@@ -60,6 +75,9 @@ type OIDCPolicySpecProper struct {
 	// Auth holds the information regarding AuthN/AuthZ
 	// +optional
 	Auth *Auth `json:"auth,omitempty"`
+	// Session holds the settings of the session established after a successful login
+	// +optional
+	Session *Session `json:"session,omitempty"`
 }
 
 type Auth struct {
@@ -70,11 +88,100 @@ type Auth struct {
 	// Claims contains the JWT Claims https://www.rfc-editor.org/rfc/rfc7519.html#section-4
 	// +optional
 	Claims map[string]string `json:"claims,omitempty"`
+
+	// Groups the End-User must be a member of at least one of, as listed by the GroupsClaim
+	// +optional
+	Groups []string `json:"groups,omitempty"`
+
+	// GroupsClaim is the JWT claim listing the groups of the End-User. Nested claims are separated by dots.
+	// Default value is "groups"
+	// +optional
+	GroupsClaim string `json:"groupsClaim,omitempty"`
+
+	// Roles defines the roles the End-User must hold
+	// +optional
+	Roles *Roles `json:"roles,omitempty"`
+
+	// Predicates are Common Expression Language (CEL) predicates over the JWT claims, available as auth.identity,
+	// that must all hold for the request to be authorized
+	// +optional
+	Predicates []string `json:"predicates,omitempty"`
+
+	// ForwardClaims maps the names of the headers added to the request forwarded upstream to the JWT claims they hold.
+	// Nested claims are separated by dots and list claims are joined with commas
+	// +optional
+	ForwardClaims map[string]string `json:"forwardClaims,omitempty"`
+}
+
+// Roles defines role-based authorization, where roles may include other roles
+type Roles struct {
+	// Claim is the JWT claim listing the roles of the End-User. Nested claims are separated by dots,
+	// e.g. "realm_access.roles". Default value is "roles"
+	// +optional
+	Claim string `json:"claim,omitempty"`
+
+	// Required roles, of which the End-User must hold at least one, either directly or through the Hierarchy
+	// +kubebuilder:validation:MinItems=1
+	Required []string `json:"required"`
+
+	// Hierarchy maps roles to the roles they include, e.g. "admin: [editor]" and "editor: [viewer]" grant the
+	// viewer role to admins
+	// +optional
+	Hierarchy map[string][]string `json:"hierarchy,omitempty"`
+}
+
+// Session defines the lifetime of the authenticated session and the attributes of the cookies holding it
+//
+//	+kubebuilder:validation:XValidation:rule="!has(self.idleTimeout) || has(self.refresh)",message="idleTimeout requires refresh to be enabled"
+type Session struct {
+	// Lifetime is the maximum duration of a session, after which the user has to go through the login flow again
+	// regardless of activity. Default value is 1h
+	// +optional
+	Lifetime *metav1.Duration `json:"lifetime,omitempty"`
+
+	// IdleTimeout is the duration of inactivity after which the session can no longer be refreshed, counted from
+	// the expiry of the last tokens obtained. It only applies when refresh is enabled. Default value is the session lifetime
+	// +optional
+	IdleTimeout *metav1.Duration `json:"idleTimeout,omitempty"`
+
+	// Refresh enables keeping the refresh token issued by the IDP, encrypted in a cookie, to transparently obtain
+	// new tokens when they are about to expire. Requires the token source to be a cookie
+	// +optional
+	Refresh *SessionRefresh `json:"refresh,omitempty"`
+
+	// Cookie holds the attributes of the cookies set along the OIDC flow
+	// +optional
+	Cookie *CookieAttributes `json:"cookie,omitempty"`
+}
+
+// SessionRefresh defines the settings related to refreshing tokens
+type SessionRefresh struct {
+	// Before is how long before the expiry of the token it is refreshed. Default value is 30s
+	// +optional
+	Before *metav1.Duration `json:"before,omitempty"`
+
+	// EncryptionKeyRef references the key used to encrypt the refresh token kept in the session cookie, and the PKCE code verifier.
+	// If omitted, a key is generated and stored in a Secret in the namespace of Authorino, named <policy namespace>-<policy name>-session
+	// +optional
+	EncryptionKeyRef *SecretKeyReference `json:"encryptionKeyRef,omitempty"`
+}
+
+// CookieAttributes defines the attributes of the cookies set along the OIDC flow
+type CookieAttributes struct {
+	// SameSite attribute of the cookies. Default value is Lax.
+	// None requires an https RedirectURI, as browsers only send SameSite=None cookies along with the Secure attribute
+	// +kubebuilder:validation:Enum=Strict;Lax;None
+	// +optional
+	SameSite string `json:"sameSite,omitempty"`
+
+	// Domain attribute of the cookies. Default value is the hostname of the gateway listener
+	// +optional
+	Domain string `json:"domain,omitempty"`
 }
 
 // SecretKeyReference Reference to a Kubernetes secret
 type SecretKeyReference struct {
-	// The name of the secret in the Kuadrant's namespace to select from.
+	// The name of the secret in the policy's namespace to select from.
 	Name string `json:"name"`
 
 	// The key of the secret to select from.  Must be a valid secret key.
@@ -102,6 +209,15 @@ type Provider struct {
 	// OAuth2 Client ID.
 	ClientID string `json:"clientID"`
 
+	// ClientSecretRef references the OAuth2 Client Secret of a confidential client, which authenticates the client
+	// at the TokenEndpoint (client_secret_basic). The secret is never copied into the generated AuthPolicies.
+	// +optional
+	ClientSecretRef *SecretKeyReference `json:"clientSecretRef,omitempty"`
+
+	// PKCE enables Proof Key for Code Exchange (RFC 7636) with the S256 challenge method
+	// +optional
+	PKCE bool `json:"pkce,omitempty"`
+
 	// The full URL of the Authorization endpoints
 	// AuthorizationEndpoint performs Authentication of the End-User. Default value is the IssuerURL + "/oauth/authorize"
 	// +optional
@@ -114,6 +230,16 @@ type Provider struct {
 	// TokenEndpoint defines the URL to obtain an Access Token, an ID Token, and optionally a Refresh Token. Default value is the IssuerURL + "/oauth/token"
 	// +optional
 	TokenEndpoint string `json:"tokenEndpoint,omitempty"`
+
+	// EndSessionEndpoint defines the URL to log the End-User out at the IDP (RP-Initiated Logout).
+	// Default value is the end_session_endpoint found through OpenID Connect Discovery at the IssuerURL
+	// +optional
+	EndSessionEndpoint string `json:"endSessionEndpoint,omitempty"`
+
+	// PostLogoutRedirectURI defines the URL the End-User is sent to after logging out, which must be registered in the IDP.
+	// Default value is the base URL of the gateway
+	// +optional
+	PostLogoutRedirectURI string `json:"postLogoutRedirectURI,omitempty"`
 }
 
 // OIDCPolicyStatus defines the observed state of OIDCPolicy
@@ -158,6 +284,10 @@ func (p *OIDCPolicy) GetTargetRefs() []gatewayapiv1alpha2.LocalPolicyTargetRefer
 	}
 }
 
+func (p *OIDCPolicy) GetStatus() exttypes.PolicyStatus {
+	return &p.Status
+}
+
 func (p *OIDCPolicy) GetTokenRequestURL() (string, error) {
 	var tokenURL *url.URL
 	var err error
@@ -246,6 +376,53 @@ func (p *OIDCPolicy) GetClaims() map[string]string {
 	return nil
 }
 
+func (p *OIDCPolicy) GetGroupsClaim() string {
+	if p.Spec.Auth != nil && p.Spec.Auth.GroupsClaim != "" {
+		return p.Spec.Auth.GroupsClaim
+	}
+	return DefaultGroupsClaim
+}
+
+func (p *OIDCPolicy) GetRolesClaim() string {
+	if p.Spec.Auth != nil && p.Spec.Auth.Roles != nil && p.Spec.Auth.Roles.Claim != "" {
+		return p.Spec.Auth.Roles.Claim
+	}
+	return DefaultRolesClaim
+}
+
+// GetGrantingRoles returns the roles granting any of the required roles, either directly or through
+// the hierarchy, sorted by name.
+func (p *OIDCPolicy) GetGrantingRoles() []string {
+	if p.Spec.Auth == nil || p.Spec.Auth.Roles == nil {
+		return nil
+	}
+	roles := p.Spec.Auth.Roles
+
+	// invert the hierarchy, so it maps roles to the roles including them
+	includedBy := make(map[string][]string)
+	for role, included := range roles.Hierarchy {
+		for _, r := range included {
+			includedBy[r] = append(includedBy[r], role)
+		}
+	}
+
+	granting := make(map[string]struct{})
+	pending := append([]string(nil), roles.Required...)
+	for len(pending) > 0 {
+		role := pending[0]
+		pending = pending[1:]
+		if _, found := granting[role]; found {
+			continue
+		}
+		granting[role] = struct{}{}
+		pending = append(pending, includedBy[role]...)
+	}
+
+	grantingRoles := lo.Keys(granting)
+	sort.Strings(grantingRoles)
+	return grantingRoles
+}
+
 func (p *OIDCPolicy) GetTokenSource() *authorinov1beta3.Credentials {
 	if p.Spec.Auth != nil && p.Spec.Auth.TokenSource != nil {
 		return p.Spec.Auth.TokenSource
@@ -253,6 +430,134 @@ func (p *OIDCPolicy) GetTokenSource() *authorinov1beta3.Credentials {
 	return &authorinov1beta3.Credentials{Cookie: &authorinov1beta3.Named{Name: DefaultTokenSourceName}}
 }
 
+// GetDiscoveryURL returns the URL of the OpenID Connect Discovery document of the issuer, or an empty
+// string when the provider is not configured with an IssuerURL.
+func (p *OIDCPolicy) GetDiscoveryURL() (string, error) {
+	if p.Spec.Provider.IssuerURL == "" {
+		return "", nil
+	}
+	discoveryURL, err := url.Parse(p.Spec.Provider.IssuerURL)
+	if err != nil {
+		return "", err
+	}
+	discoveryURL.Path = path.Join(discoveryURL.Path, DiscoveryPath)
+	return discoveryURL.String(), nil
+}
+
+// GetPostLogoutRedirectURL returns the URL the End-User is sent to after logging out, defaulting to
+// the base URL for post-authentication redirects.
+func (p *OIDCPolicy) GetPostLogoutRedirectURL(igwURL *url.URL) (string, error) {
+	if p.Spec.Provider.PostLogoutRedirectURI != "" {
+		postLogoutURL, err := url.Parse(p.Spec.Provider.PostLogoutRedirectURI)
+		if err != nil {
+			return "", err
+		}
+		return postLogoutURL.String(), nil
+	}
+	baseURL, err := p.GetBaseURL(igwURL)
+	if err != nil {
+		return "", err
+	}
+	baseURL.Path = "/"
+	return baseURL.String(), nil
+}
+
+// GetEndSessionQuery returns the query parameters of the logout request to the EndSessionEndpoint,
+// but for the id_token_hint which is only known at request time.
+func (p *OIDCPolicy) GetEndSessionQuery(igwURL *url.URL) (string, error) {
+	postLogoutURL, err := p.GetPostLogoutRedirectURL(igwURL)
+	if err != nil {
+		return "", err
+	}
+	query := url.Values{}
+	query.Set("client_id", p.Spec.Provider.ClientID)
+	query.Set("post_logout_redirect_uri", postLogoutURL)
+	return query.Encode(), nil
+}
+
+func (p *OIDCPolicy) GetSessionLifetime() time.Duration {
+	if p.Spec.Session != nil && p.Spec.Session.Lifetime != nil {
+		return p.Spec.Session.Lifetime.Duration
+	}
+	return DefaultSessionLifetime
+}
+
+func (p *OIDCPolicy) GetSessionIdleTimeout() time.Duration {
+	if p.Spec.Session != nil && p.Spec.Session.IdleTimeout != nil {
+		return p.Spec.Session.IdleTimeout.Duration
+	}
+	return p.GetSessionLifetime()
+}
+
+// GetSessionCookieName returns the name of the cookie holding the session, derived from the name of the policy
+// so that the sessions of the policies sharing a host do not overwrite each other
+func (p *OIDCPolicy) GetSessionCookieName() string {
+	return DefaultSessionCookieName + "-" + p.Name
+}
+
+// GetRefreshPath returns the path of the route refreshing the tokens of a session, derived from the name of the
+// policy so that the policies sharing a host each get their own route
+func (p *OIDCPolicy) GetRefreshPath() string {
+	return DefaultRefreshPath + "/" + p.Name
+}
+
+func (p *OIDCPolicy) IsRefreshEnabled() bool {
+	return p.Spec.Session != nil && p.Spec.Session.Refresh != nil
+}
+
+func (p *OIDCPolicy) GetRefreshBefore() time.Duration {
+	if p.IsRefreshEnabled() && p.Spec.Session.Refresh.Before != nil {
+		return p.Spec.Session.Refresh.Before.Duration
+	}
+	return DefaultRefreshBefore
+}
+
+func (p *OIDCPolicy) GetCookieSameSite() string {
+	if p.Spec.Session != nil && p.Spec.Session.Cookie != nil && p.Spec.Session.Cookie.SameSite != "" {
+		return p.Spec.Session.Cookie.SameSite
+	}
+	return DefaultCookieSameSite
+}
+
+// GetCookieDomain returns the domain of the cookies set along the OIDC flow,
+// defaulting to the given listener hostname.
+func (p *OIDCPolicy) GetCookieDomain(hostname string) string {
+	if p.Spec.Session != nil && p.Spec.Session.Cookie != nil && p.Spec.Session.Cookie.Domain != "" {
+		return p.Spec.Session.Cookie.Domain
+	}
+	return hostname
+}
+
+// IsSessionKeyRequired tells whether a key is needed by the session service to seal the cookies set along the OIDC flow
+func (p *OIDCPolicy) IsSessionKeyRequired() bool {
+	return p.IsRefreshEnabled() || p.Spec.Provider.PKCE
+}
+
+// GetLoginURL returns the URL the End-User is sent to for logging in. It is the AuthorizationEndpoint,
+// unless PKCE is enabled, which requires going through the login route to set up the code challenge.
+func (p *OIDCPolicy) GetLoginURL(igwURL *url.URL) (string, error) {
+	if !p.Spec.Provider.PKCE {
+		return p.GetAuthorizeURL(igwURL)
+	}
+	loginURL, err := p.GetBaseURL(igwURL)
+	if err != nil {
+		return "", err
+	}
+	loginURL.Path = DefaultLoginPath
+	return loginURL.String(), nil
+}
+
+// GetRefreshURL returns the URL of the route refreshing the tokens of a session.
+// It shares the base URL of post-authentication redirects.
+func (p *OIDCPolicy) GetRefreshURL(igwURL *url.URL) (*url.URL, error) {
+	refreshURL, err := p.GetBaseURL(igwURL)
+	if err != nil {
+		return nil, err
+	}
+	refreshURL.Path = p.GetRefreshPath()
+	return refreshURL, nil
+}
+
 func (p *OIDCPolicy) redirectURL(igwURL *url.URL) (*url.URL, error) {
 	var redirectURL *url.URL
 	var err error
@@ -297,23 +602,20 @@ type OIDCPolicyList struct {
 	Items           []OIDCPolicy `json:"items"`
 }
 
-func (s *OIDCPolicyStatus) Equals(other *OIDCPolicyStatus, logger logr.Logger) bool {
-	if s.ObservedGeneration != other.ObservedGeneration {
-		diff := cmp.Diff(s.ObservedGeneration, other.ObservedGeneration)
-		logger.V(1).Info("status observedGeneration not equal", "difference", diff)
-		return false
-	}
+func (s *OIDCPolicyStatus) GetObservedGeneration() int64 {
+	return s.ObservedGeneration
+}
 
-	// Marshalling sorts by condition type
-	currentMarshaledJSON, _ := helpers.ConditionMarshal(s.Conditions)
-	otherMarshaledJSON, _ := helpers.ConditionMarshal(other.Conditions)
-	if string(currentMarshaledJSON) != string(otherMarshaledJSON) {
-		diff := cmp.Diff(string(currentMarshaledJSON), string(otherMarshaledJSON))
-		logger.V(1).Info("status conditions not equal", "difference", diff)
-		return false
-	}
+func (s *OIDCPolicyStatus) SetObservedGeneration(generation int64) {
+	s.ObservedGeneration = generation
+}
+
+func (s *OIDCPolicyStatus) GetConditions() []metav1.Condition {
+	return s.Conditions
+}
 
-	return true
+func (s *OIDCPolicyStatus) SetConditions(conditions []metav1.Condition) {
+	s.Conditions = conditions
 }
 
 func init() {
@@ -4,10 +4,11 @@ package v1alpha1
 
 import (
 	"net/url"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
-	"github.com/go-logr/logr"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	gatewayapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 )
@@ -135,34 +136,6 @@ func TestGetTargetRefs(t *testing.T) {
 	}
 }
 
-func TestOIDCPolicyStatus_Equals(t *testing.T) {
-	var (
-		conditions = []metav1.Condition{
-			{
-				Type: StatusConditionReady,
-			},
-		}
-		status = &OIDCPolicyStatus{
-			ObservedGeneration: 0,
-			Conditions:         conditions,
-		}
-	)
-	s := OIDCPolicyStatus{ObservedGeneration: int64(1)}
-	if s.Equals(status, logr.Logger{}) {
-		t.Errorf("observed generation should be different")
-	}
-
-	s = OIDCPolicyStatus{ObservedGeneration: status.ObservedGeneration}
-	if s.Equals(status, logr.Logger{}) {
-		t.Errorf("conditions should be different")
-	}
-
-	s = OIDCPolicyStatus{ObservedGeneration: status.ObservedGeneration, Conditions: status.Conditions}
-	if !s.Equals(status, logr.Logger{}) {
-		t.Errorf("status should be the same")
-	}
-}
-
 func TestGetBaseURL(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -281,3 +254,167 @@ func mockMinimalOIDCPolicy() *OIDCPolicy {
 		},
 	}
 }
+
+func TestSessionDefaults(t *testing.T) {
+	policy := mockMinimalOIDCPolicy()
+
+	if policy.GetSessionLifetime() != DefaultSessionLifetime {
+		t.Errorf("incorrect session lifetime, actual = %v, expected %v", policy.GetSessionLifetime(), DefaultSessionLifetime)
+	}
+	if policy.GetSessionIdleTimeout() != DefaultSessionLifetime {
+		t.Errorf("incorrect idle timeout, actual = %v, expected %v", policy.GetSessionIdleTimeout(), DefaultSessionLifetime)
+	}
+	if policy.IsRefreshEnabled() {
+		t.Error("expected refresh to be disabled")
+	}
+	if policy.GetCookieSameSite() != "Lax" {
+		t.Errorf("incorrect SameSite, actual = %v, expected Lax", policy.GetCookieSameSite())
+	}
+	if policy.GetCookieDomain("example.com") != "example.com" {
+		t.Errorf("incorrect cookie domain, actual = %v, expected example.com", policy.GetCookieDomain("example.com"))
+	}
+
+	policy.Spec.Session = &Session{
+		Lifetime:    &metav1.Duration{Duration: 8 * time.Hour},
+		IdleTimeout: &metav1.Duration{Duration: 30 * time.Minute},
+		Refresh:     &SessionRefresh{Before: &metav1.Duration{Duration: time.Minute}},
+		Cookie:      &CookieAttributes{SameSite: "Strict", Domain: "apps.example.com"},
+	}
+
+	if policy.GetSessionLifetime() != 8*time.Hour {
+		t.Errorf("incorrect session lifetime, actual = %v, expected 8h", policy.GetSessionLifetime())
+	}
+	if policy.GetSessionIdleTimeout() != 30*time.Minute {
+		t.Errorf("incorrect idle timeout, actual = %v, expected 30m", policy.GetSessionIdleTimeout())
+	}
+	if !policy.IsRefreshEnabled() || policy.GetRefreshBefore() != time.Minute {
+		t.Errorf("incorrect refresh settings, actual = %v, expected 1m", policy.GetRefreshBefore())
+	}
+	if policy.GetCookieSameSite() != "Strict" {
+		t.Errorf("incorrect SameSite, actual = %v, expected Strict", policy.GetCookieSameSite())
+	}
+	if policy.GetCookieDomain("example.com") != "apps.example.com" {
+		t.Errorf("incorrect cookie domain, actual = %v, expected apps.example.com", policy.GetCookieDomain("example.com"))
+	}
+}
+
+func TestGetRefreshURL(t *testing.T) {
+	policy := mockMinimalOIDCPolicy()
+	policy.Name = "oidc"
+	gwURL, err := url.Parse("https://gateway.example.com:8443")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refreshURL, err := policy.GetRefreshURL(gwURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refreshURL.String() != "https://gateway.example.com:8443/auth/refresh/oidc" {
+		t.Errorf("incorrect refresh URL, actual = %v", refreshURL)
+	}
+
+	policy.Spec.Provider.RedirectURI = "https://app.example.com/custom/callback"
+	if refreshURL, err = policy.GetRefreshURL(gwURL); err != nil {
+		t.Fatal(err)
+	}
+	if refreshURL.String() != "https://app.example.com/auth/refresh/oidc" {
+		t.Errorf("incorrect refresh URL, actual = %v", refreshURL)
+	}
+}
+
+func TestGetEndSessionQuery(t *testing.T) {
+	policy := mockMinimalOIDCPolicy()
+	gwURL, err := url.Parse("https://gateway.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	discoveryURL, err := policy.GetDiscoveryURL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if discoveryURL != "https://issuer.com/.well-known/openid-configuration" {
+		t.Errorf("incorrect discovery URL, actual = %v", discoveryURL)
+	}
+
+	query, err := policy.GetEndSessionQuery(gwURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "client_id=client123&post_logout_redirect_uri=https%3A%2F%2Fgateway.example.com%2F" {
+		t.Errorf("incorrect end session query, actual = %v", query)
+	}
+
+	policy.Spec.Provider.PostLogoutRedirectURI = "https://app.example.com/bye"
+	if query, err = policy.GetEndSessionQuery(gwURL); err != nil {
+		t.Fatal(err)
+	}
+	if query != "client_id=client123&post_logout_redirect_uri=https%3A%2F%2Fapp.example.com%2Fbye" {
+		t.Errorf("incorrect end session query, actual = %v", query)
+	}
+
+	policy.Spec.Provider.IssuerURL = ""
+	if discoveryURL, err = policy.GetDiscoveryURL(); err != nil || discoveryURL != "" {
+		t.Errorf("expected no discovery URL without issuer, actual = %v, %v", discoveryURL, err)
+	}
+}
+
+func TestGetLoginURL(t *testing.T) {
+	policy := mockMinimalOIDCPolicy()
+	gwURL, err := url.Parse("https://gateway.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loginURL, err := policy.GetLoginURL(gwURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authorizeURL, err := policy.GetAuthorizeURL(gwURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loginURL != authorizeURL {
+		t.Errorf("expected the authorize URL without PKCE, actual = %v", loginURL)
+	}
+	if policy.IsSessionKeyRequired() {
+		t.Error("expected no session key to be required")
+	}
+
+	policy.Spec.Provider.PKCE = true
+	if loginURL, err = policy.GetLoginURL(gwURL); err != nil {
+		t.Fatal(err)
+	}
+	if loginURL != "https://gateway.example.com/auth/login" {
+		t.Errorf("incorrect login URL, actual = %v", loginURL)
+	}
+	if !policy.IsSessionKeyRequired() {
+		t.Error("expected PKCE to require a session key")
+	}
+}
+
+func TestGetGrantingRoles(t *testing.T) {
+	policy := mockMinimalOIDCPolicy()
+	if roles := policy.GetGrantingRoles(); roles != nil {
+		t.Errorf("expected no roles, actual = %v", roles)
+	}
+	if policy.GetRolesClaim() != "roles" || policy.GetGroupsClaim() != "groups" {
+		t.Errorf("unexpected default claims %s, %s", policy.GetRolesClaim(), policy.GetGroupsClaim())
+	}
+
+	policy.Spec.Auth = &Auth{Roles: &Roles{
+		Required: []string{"viewer"},
+		Hierarchy: map[string][]string{
+			"admin":   {"editor", "auditor"},
+			"editor":  {"viewer"},
+			"auditor": {"viewer"},
+			"owner":   {"admin"},
+			"viewer":  {"owner"}, // cycles are tolerated
+			"guest":   {},
+		},
+	}}
+	if roles := policy.GetGrantingRoles(); !reflect.DeepEqual(roles, []string{"admin", "auditor", "editor", "owner", "viewer"}) {
+		t.Errorf("unexpected granting roles, actual = %v", roles)
+	}
+}
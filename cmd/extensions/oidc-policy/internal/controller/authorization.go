@@ -0,0 +1,153 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	authorinov1beta3 "github.com/kuadrant/authorino/api/v1beta3"
+	"github.com/samber/lo"
+
+	kuadrantv1 "github.com/kuadrant/kuadrant-operator/api/v1"
+	"github.com/kuadrant/kuadrant-operator/cmd/extensions/oidc-policy/api/v1alpha1"
+	celvalidator "github.com/kuadrant/kuadrant-operator/internal/cel"
+)
+
+// claimSelector returns the CEL selector of the claim within the JWT identity, given its dot separated path.
+// Every level is selected by index with a quoted key, so that claim names are never read as CEL syntax. The
+// selection is wrapped in dyn(), as the checker would otherwise resolve conversions such as string() statically.
+func claimSelector(claim string) string {
+	selector := "auth.identity"
+	for _, segment := range strings.Split(claim, ".") {
+		selector += "[" + strconv.Quote(segment) + "]"
+	}
+	return "dyn(" + selector + ")"
+}
+
+// claimPresentPredicate returns the CEL predicate telling whether the JWT identity holds the claim.
+// Every level of a nested claim is guarded, as selecting a missing key raises an error.
+func claimPresentPredicate(claim string) string {
+	guards := []string{"has(auth.identity)"}
+	selector := "auth.identity"
+	for _, segment := range strings.Split(claim, ".") {
+		key := strconv.Quote(segment)
+		guards = append(guards, fmt.Sprintf("%s in %s", key, selector))
+		selector += "[" + key + "]"
+	}
+	return strings.Join(guards, " && ")
+}
+
+// claimPredicate returns the CEL predicate matching a claim holding the value, either as a scalar or as an
+// item of a list-valued claim
+func claimPredicate(claim, value string) string {
+	selector := claimSelector(claim)
+	quoted := strconv.Quote(value)
+	return fmt.Sprintf(`%s && (%s == %s || (type(%s) == list && %s in %s))`, claimPresentPredicate(claim), selector, quoted, selector, quoted, selector)
+}
+
+// predicatesValidatorPolicy names the environment the predicates of the policy are validated in
+const predicatesValidatorPolicy = "OIDCPolicy"
+
+// validatePredicates checks the predicates of the policy compile as CEL, with the JWT identity available as
+// auth.identity, so that a broken predicate fails the reconcile rather than every request
+func validatePredicates(pol *v1alpha1.OIDCPolicy) error {
+	if pol.Spec.Auth == nil || len(pol.Spec.Auth.Predicates) == 0 {
+		return nil
+	}
+	validator, err := celvalidator.NewValidatorBuilder().PushPolicyBinding(predicatesValidatorPolicy, "auth", cel.DynType).Build()
+	if err != nil {
+		return err
+	}
+	for i, predicate := range pol.Spec.Auth.Predicates {
+		ast, err := validator.Validate(predicatesValidatorPolicy, predicate)
+		if err != nil {
+			return fmt.Errorf("invalid auth.predicates[%d]: %w", i, err)
+		}
+		if outputType := ast.OutputType(); !outputType.IsExactType(cel.BoolType) && !outputType.IsExactType(cel.DynType) {
+			return fmt.Errorf("invalid auth.predicates[%d]: expected a bool, got %s", i, outputType)
+		}
+	}
+	return nil
+}
+
+// anyOfPredicate returns the CEL predicate matching a claim holding any of the values. A list-valued claim
+// matches when any of its items is one of the values, while a scalar claim has to be one of them.
+func anyOfPredicate(claim string, values []string) (string, error) {
+	list, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	selector := claimSelector(claim)
+	return fmt.Sprintf(`%s && (type(%s) == list ? %s.exists(v, v in %s) : %s in %s)`, claimPresentPredicate(claim), selector, selector, list, selector, list), nil
+}
+
+// forwardedClaimExpression returns the CEL expression of the header value holding the claim, or an
+// empty value when the claim is missing
+func forwardedClaimExpression(claim string) string {
+	selector := claimSelector(claim)
+	return fmt.Sprintf(`%s ? (type(%s) == list ? %s.map(v, string(v)).join(",") : string(%s)) : ""`, claimPresentPredicate(claim), selector, selector, selector)
+}
+
+// buildAuthorizationPatterns returns the patterns the JWT identity must all match for the request to be
+// authorized, out of the claims, groups, roles and predicates of the policy
+func buildAuthorizationPatterns(pol *v1alpha1.OIDCPolicy) ([]authorinov1beta3.PatternExpressionOrRef, error) {
+	var predicates []string
+
+	// sorted, so that the generated AuthPolicy does not change between reconciles
+	claims := pol.GetClaims()
+	claimNames := lo.Keys(claims)
+	sort.Strings(claimNames)
+	for _, k := range claimNames {
+		predicates = append(predicates, claimPredicate(k, claims[k]))
+	}
+
+	if auth := pol.Spec.Auth; auth != nil {
+		if len(auth.Groups) > 0 {
+			predicate, err := anyOfPredicate(pol.GetGroupsClaim(), auth.Groups)
+			if err != nil {
+				return nil, err
+			}
+			predicates = append(predicates, predicate)
+		}
+		if auth.Roles != nil {
+			predicate, err := anyOfPredicate(pol.GetRolesClaim(), pol.GetGrantingRoles())
+			if err != nil {
+				return nil, err
+			}
+			predicates = append(predicates, predicate)
+		}
+		predicates = append(predicates, auth.Predicates...)
+	}
+
+	return lo.Map(predicates, func(predicate string, _ int) authorinov1beta3.PatternExpressionOrRef {
+		return authorinov1beta3.PatternExpressionOrRef{
+			CelPredicate: authorinov1beta3.CelPredicate{Predicate: predicate},
+		}
+	}), nil
+}
+
+// buildForwardedClaimHeaders returns the headers added to the request forwarded upstream, holding the
+// claims of the JWT identity
+func buildForwardedClaimHeaders(pol *v1alpha1.OIDCPolicy) map[string]kuadrantv1.MergeableHeaderSuccessResponseSpec {
+	if pol.Spec.Auth == nil || len(pol.Spec.Auth.ForwardClaims) == 0 {
+		return nil
+	}
+	headers := make(map[string]kuadrantv1.MergeableHeaderSuccessResponseSpec, len(pol.Spec.Auth.ForwardClaims))
+	for header, claim := range pol.Spec.Auth.ForwardClaims {
+		headers[header] = kuadrantv1.MergeableHeaderSuccessResponseSpec{
+			HeaderSuccessResponseSpec: authorinov1beta3.HeaderSuccessResponseSpec{
+				SuccessResponseSpec: authorinov1beta3.SuccessResponseSpec{
+					AuthResponseMethodSpec: authorinov1beta3.AuthResponseMethodSpec{
+						Plain: &authorinov1beta3.PlainAuthResponseSpec{
+							Expression: authorinov1beta3.CelExpression(forwardedClaimExpression(claim)),
+						},
+					},
+				},
+			},
+		}
+	}
+	return headers
+}
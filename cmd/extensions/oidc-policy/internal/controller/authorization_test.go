@@ -0,0 +1,190 @@
+//go:build unit
+
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+
+	"github.com/kuadrant/kuadrant-operator/cmd/extensions/oidc-policy/api/v1alpha1"
+)
+
+// evalIdentityExpression evaluates the expression over the JWT identity with the string extensions
+// enabled by authorino.
+func evalIdentityExpression(t *testing.T, expression string, identity map[string]interface{}) interface{} {
+	t.Helper()
+	env, err := cel.NewEnv(cel.Variable("auth", cel.DynType), ext.Strings())
+	if err != nil {
+		t.Fatalf("cel.NewEnv failed: %v", err)
+	}
+	ast, iss := env.Compile(expression)
+	if iss != nil && iss.Err() != nil {
+		t.Fatalf("compile failed for expression %q: %v", expression, iss.Err())
+	}
+	prg, err := env.Program(ast)
+	if err != nil {
+		t.Fatalf("program failed: %v", err)
+	}
+	out, _, err := prg.Eval(map[string]interface{}{"auth": map[string]interface{}{"identity": identity}})
+	if err != nil {
+		t.Fatalf("eval failed: %v", err)
+	}
+	return out.Value()
+}
+
+func TestAnyOfPredicate(t *testing.T) {
+	cases := []struct {
+		name     string
+		claim    string
+		identity map[string]interface{}
+		want     bool
+	}{
+		{
+			name:     "list claim contains one of the values",
+			claim:    "groups",
+			identity: map[string]interface{}{"groups": []interface{}{"dev", "ops"}},
+			want:     true,
+		},
+		{
+			name:     "list claim contains none of the values",
+			claim:    "groups",
+			identity: map[string]interface{}{"groups": []interface{}{"dev"}},
+			want:     false,
+		},
+		{
+			name:     "scalar claim is one of the values",
+			claim:    "groups",
+			identity: map[string]interface{}{"groups": "admin"},
+			want:     true,
+		},
+		{
+			name:     "nested claim",
+			claim:    "realm_access.roles",
+			identity: map[string]interface{}{"realm_access": map[string]interface{}{"roles": []interface{}{"admin"}}},
+			want:     true,
+		},
+		{
+			name:     "missing nested claim",
+			claim:    "realm_access.roles",
+			identity: map[string]interface{}{"sub": "1234567890"},
+			want:     false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			predicate, err := anyOfPredicate(tc.claim, []string{"admin", "ops"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := evalIdentityExpression(t, predicate, tc.identity); got != tc.want {
+				t.Errorf("anyOfPredicate over %v = %v, want %v", tc.identity, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestForwardedClaimExpression(t *testing.T) {
+	identity := map[string]interface{}{
+		"email":  "user@example.com",
+		"groups": []interface{}{"dev", "ops"},
+		"age":    int64(42),
+	}
+	for claim, want := range map[string]string{
+		"email":   "user@example.com",
+		"groups":  "dev,ops",
+		"age":     "42",
+		"missing": "",
+	} {
+		if got := evalIdentityExpression(t, forwardedClaimExpression(claim), identity); got != want {
+			t.Errorf("forwarded claim %s = %v, want %s", claim, got, want)
+		}
+	}
+}
+
+func TestClaimPredicate_Escaping(t *testing.T) {
+	identity := map[string]interface{}{
+		"x-tenant": "acme",
+		`name") || true || ("`: "whatever",
+	}
+	if got := evalIdentityExpression(t, claimPredicate("x-tenant", "acme"), identity); got != true {
+		t.Errorf("expected claim with a dash to match, got %v", got)
+	}
+	if got := evalIdentityExpression(t, claimPredicate("x-tenant", `acme" || true || "`), identity); got != false {
+		t.Errorf("expected quotes in the value not to escape the string literal, got %v", got)
+	}
+	if got := evalIdentityExpression(t, claimPredicate(`name") || true || ("`, "other"), identity); got != false {
+		t.Errorf("expected quotes in the claim name not to escape the key, got %v", got)
+	}
+}
+
+func TestValidatePredicates(t *testing.T) {
+	pol := sessionTestPolicy(nil)
+	if err := validatePredicates(pol); err != nil {
+		t.Errorf("expected no predicates to be valid, got %v", err)
+	}
+
+	pol.Spec.Auth = &v1alpha1.Auth{Predicates: []string{`auth.identity.email.endsWith("@example.com")`, `"admin" in auth.identity.groups`}}
+	if err := validatePredicates(pol); err != nil {
+		t.Errorf("expected predicates to be valid, got %v", err)
+	}
+
+	for _, predicate := range []string{`auth.identity.email ==`, `unknown.email == "a"`, `"not a bool"`} {
+		pol.Spec.Auth.Predicates = []string{`true`, predicate}
+		if err := validatePredicates(pol); err == nil || !strings.Contains(err.Error(), "auth.predicates[1]") {
+			t.Errorf("expected predicate %q to be rejected, got %v", predicate, err)
+		}
+	}
+}
+
+func TestBuildMainAuthPolicy_Authorization(t *testing.T) {
+	pol := sessionTestPolicy(nil)
+	pol.Spec.Auth = &v1alpha1.Auth{
+		Claims: map[string]string{"email_verified": "true", "aud": "client"},
+		Groups: []string{"engineering"},
+		Roles: &v1alpha1.Roles{
+			Claim:     "realm_access.roles",
+			Required:  []string{"viewer"},
+			Hierarchy: map[string][]string{"admin": {"editor"}, "editor": {"viewer"}},
+		},
+		Predicates:    []string{`auth.identity.email.endsWith("@example.com")`},
+		ForwardClaims: map[string]string{"X-User-Email": "email", "X-Groups": "groups"},
+	}
+
+	authPol, err := buildMainAuthPolicy(pol, sessionTestGateway)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheme := authPol.Spec.Overrides.AuthScheme
+
+	patterns := scheme.Authorization["oidc"].PatternMatching.Patterns
+	if len(patterns) != 5 {
+		t.Fatalf("expected 5 patterns, got %d", len(patterns))
+	}
+	identity := map[string]interface{}{
+		"email":          "user@example.com",
+		"email_verified": "true",
+		"aud":            "client",
+		"groups":         []interface{}{"engineering"},
+		"realm_access":   map[string]interface{}{"roles": []interface{}{"admin"}},
+	}
+	for _, pattern := range patterns {
+		if got := evalIdentityExpression(t, pattern.Predicate, identity); got != true {
+			t.Errorf("expected pattern to hold: %s", pattern.Predicate)
+		}
+	}
+	if patterns[0].Predicate != claimPredicate("aud", "client") {
+		t.Errorf("expected claim patterns to be sorted, got %s", patterns[0].Predicate)
+	}
+
+	headers := scheme.Response.Success.Headers
+	if len(headers) != 2 {
+		t.Fatalf("expected 2 forwarded claim headers, got %d", len(headers))
+	}
+	if got := evalIdentityExpression(t, string(headers["X-Groups"].Plain.Expression), identity); got != "engineering" {
+		t.Errorf("unexpected X-Groups header %v", got)
+	}
+}
@@ -0,0 +1,178 @@
+package controller
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+
+	authorinov1beta3 "github.com/kuadrant/authorino/api/v1beta3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/env"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/kuadrant/kuadrant-operator/cmd/extensions/oidc-policy/api/v1alpha1"
+)
+
+const (
+	// clientCredentialsSecretKey is the key of the client credentials in the Secret read by Authorino
+	clientCredentialsSecretKey = "credentials"
+	// secretsFinalizer cleans up the Secrets of the policy in the namespace of Authorino, i.e. the client
+	// credentials and the generated session key, which cannot be garbage collected through an owner reference.
+	// It is named after the client credentials, the first of them.
+	secretsFinalizer = "extensions.kuadrant.io/oidc-client-credentials"
+)
+
+// authorinoNamespace returns the namespace of Authorino, where the AuthConfigs enforcing the
+// AuthPolicies live and where Authorino reads the secrets they reference from. It is shared with
+// the kuadrant operator, which the extension inherits the environment of.
+func authorinoNamespace() string {
+	return env.GetString("OPERATOR_NAMESPACE", "kuadrant-system")
+}
+
+func clientCredentialsSecretName(pol *v1alpha1.OIDCPolicy) string {
+	return fmt.Sprintf("%s-%s-client", pol.Namespace, pol.Name)
+}
+
+// clientCredentialsSecretRef returns the reference to the client credentials passed by Authorino to
+// the TokenEndpoint, or nil for public clients
+func clientCredentialsSecretRef(pol *v1alpha1.OIDCPolicy) *authorinov1beta3.SecretKeyReference {
+	if pol.Spec.Provider.ClientSecretRef == nil {
+		return nil
+	}
+	return &authorinov1beta3.SecretKeyReference{
+		Name: clientCredentialsSecretName(pol),
+		Key:  clientCredentialsSecretKey,
+	}
+}
+
+// buildClientCredentialsSecret returns the Secret holding the client credentials encoded for the
+// HTTP Basic authentication scheme (RFC 6749, Section 2.3.1)
+func buildClientCredentialsSecret(pol *v1alpha1.OIDCPolicy, clientSecret []byte) *corev1.Secret {
+	credentials := url.QueryEscape(pol.Spec.Provider.ClientID) + ":" + url.QueryEscape(string(clientSecret))
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: corev1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      clientCredentialsSecretName(pol),
+			Namespace: authorinoNamespace(),
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			clientCredentialsSecretKey: []byte(base64.StdEncoding.EncodeToString([]byte(credentials))),
+		},
+	}
+}
+
+// reconcileClientCredentials copies the client secret referenced by the policy into the namespace of
+// Authorino, which injects it into the token request, so that it never shows in the AuthPolicies.
+func (r *OIDCPolicyReconciler) reconcileClientCredentials(ctx context.Context, pol *v1alpha1.OIDCPolicy) error {
+	ref := pol.Spec.Provider.ClientSecretRef
+	if ref == nil {
+		return r.deleteSecret(ctx, pol, clientCredentialsSecretName(pol))
+	}
+
+	secret := &corev1.Secret{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Namespace: pol.Namespace, Name: ref.Name}, secret); err != nil {
+		return fmt.Errorf("failed to get client secret: %w", err)
+	}
+	clientSecret := secret.Data[ref.Key]
+	if len(clientSecret) == 0 {
+		return fmt.Errorf("client secret %s/%s is empty", ref.Name, ref.Key)
+	}
+
+	if err := r.addSecretsFinalizer(ctx, pol); err != nil {
+		return err
+	}
+
+	if _, err := r.kCtx.ReconcileObject(ctx, &corev1.Secret{}, buildClientCredentialsSecret(pol, clientSecret), secretDataMutator); err != nil {
+		r.Logger.Error(err, "Error reconciling OIDC client credentials Secret")
+		return err
+	}
+
+	r.Logger.Info("Successfully reconciled OIDC client credentials Secret")
+	return nil
+}
+
+// addSecretsFinalizer makes sure the Secrets of the policy in the namespace of Authorino are cleaned up
+// along with the policy
+func (r *OIDCPolicyReconciler) addSecretsFinalizer(ctx context.Context, pol *v1alpha1.OIDCPolicy) error {
+	if !controllerutil.AddFinalizer(pol, secretsFinalizer) {
+		return nil
+	}
+	if err := r.Client.Update(ctx, pol); err != nil {
+		r.Logger.Error(err, "Error adding secrets finalizer")
+		return err
+	}
+	return nil
+}
+
+// deleteSecret deletes a Secret of the policy in the namespace of Authorino, if any. There is none unless
+// the policy holds the finalizer.
+func (r *OIDCPolicyReconciler) deleteSecret(ctx context.Context, pol *v1alpha1.OIDCPolicy, name string) error {
+	if !controllerutil.ContainsFinalizer(pol, secretsFinalizer) {
+		return nil
+	}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: authorinoNamespace()}}
+	if err := r.Client.Delete(ctx, secret); err != nil && !errors.IsNotFound(err) {
+		r.Logger.Error(err, "Error deleting OIDC Secret", "secret", name)
+		return err
+	}
+	return nil
+}
+
+// finalizeSecrets deletes the Secrets of the policy in the namespace of Authorino, if any, and releases the policy
+func (r *OIDCPolicyReconciler) finalizeSecrets(ctx context.Context, pol *v1alpha1.OIDCPolicy) error {
+	if !controllerutil.ContainsFinalizer(pol, secretsFinalizer) {
+		return nil
+	}
+	for _, name := range []string{clientCredentialsSecretName(pol), sessionKeySecretName(pol)} {
+		if err := r.deleteSecret(ctx, pol, name); err != nil {
+			return err
+		}
+	}
+
+	controllerutil.RemoveFinalizer(pol, secretsFinalizer)
+	return r.Client.Update(ctx, pol)
+}
+
+// SecretToPolicies maps a Secret to the policies reading it, so that rotating the client secret or the session
+// key is enforced without waiting for the next reconcile: the Secrets referenced by the policies of its namespace
+// and, in the namespace of Authorino, the Secrets the extension keeps for them, which are restored when modified.
+func SecretToPolicies(ctx context.Context, c client.Reader, obj client.Object) []reconcile.Request {
+	policies := &v1alpha1.OIDCPolicyList{}
+	if err := c.List(ctx, policies); err != nil {
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, pol := range policies.Items {
+		if secretReadBy(&pol, obj) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&pol)})
+		}
+	}
+	return requests
+}
+
+func secretReadBy(pol *v1alpha1.OIDCPolicy, secret client.Object) bool {
+	if secret.GetNamespace() == authorinoNamespace() {
+		switch secret.GetName() {
+		case sessionServiceTokenSecretName:
+			return pol.IsSessionKeyRequired()
+		case clientCredentialsSecretName(pol), sessionKeySecretName(pol):
+			return true
+		}
+	}
+	if secret.GetNamespace() != pol.Namespace {
+		return false
+	}
+	if ref := pol.Spec.Provider.ClientSecretRef; ref != nil && ref.Name == secret.GetName() {
+		return true
+	}
+	return pol.IsRefreshEnabled() && pol.Spec.Session.Refresh.EncryptionKeyRef != nil && pol.Spec.Session.Refresh.EncryptionKeyRef.Name == secret.GetName()
+}
@@ -0,0 +1,97 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"encoding/base64"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/kuadrant/kuadrant-operator/cmd/extensions/oidc-policy/api/v1alpha1"
+)
+
+func TestBuildClientCredentialsSecret(t *testing.T) {
+	t.Setenv("OPERATOR_NAMESPACE", "kuadrant")
+	secret := buildClientCredentialsSecret(sessionTestPolicy(nil), []byte("s3cr:t"))
+	if secret.Name != "default-oidc-client" || secret.Namespace != "kuadrant" {
+		t.Errorf("unexpected secret %s/%s", secret.Namespace, secret.Name)
+	}
+	credentials, err := base64.StdEncoding.DecodeString(string(secret.Data[clientCredentialsSecretKey]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(credentials) != "client+123:s3cr%3At" {
+		t.Errorf("unexpected credentials %s", credentials)
+	}
+}
+
+func TestBuildCallbackAuthPolicy_ClientSecret(t *testing.T) {
+	pol := sessionTestPolicy(nil)
+	authPol, err := buildCallbackAuthPolicy(pol, sessionTestGateway)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token := authPol.Spec.Overrides.AuthScheme.Metadata["token"].Http; token.SharedSecret != nil || token.Credentials.AuthorizationHeader != nil {
+		t.Errorf("expected no client credentials for a public client, got %+v", token)
+	}
+
+	pol.Spec.Provider.ClientSecretRef = &v1alpha1.SecretKeyReference{Name: "idp", Key: "secret"}
+	if authPol, err = buildCallbackAuthPolicy(pol, sessionTestGateway); err != nil {
+		t.Fatal(err)
+	}
+	token := authPol.Spec.Overrides.AuthScheme.Metadata["token"].Http
+	if token.SharedSecret == nil || token.SharedSecret.Name != "default-oidc-client" || token.SharedSecret.Key != clientCredentialsSecretKey {
+		t.Errorf("unexpected shared secret %+v", token.SharedSecret)
+	}
+	if token.Credentials.AuthorizationHeader == nil || token.Credentials.AuthorizationHeader.Prefix != "Basic" {
+		t.Errorf("expected client credentials in the authorization header, got %+v", token.Credentials)
+	}
+}
+
+func TestSecretToPolicies(t *testing.T) {
+	t.Setenv("OPERATOR_NAMESPACE", "kuadrant")
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	confidential := sessionTestPolicy(nil)
+	confidential.Name = "confidential"
+	confidential.Spec.Provider.ClientSecretRef = &v1alpha1.SecretKeyReference{Name: "idp", Key: "secret"}
+	refreshing := sessionTestPolicy(&v1alpha1.Session{
+		Refresh: &v1alpha1.SessionRefresh{EncryptionKeyRef: &v1alpha1.SecretKeyReference{Name: "session-key", Key: "key"}},
+	})
+	refreshing.Name = "refreshing"
+	public := sessionTestPolicy(nil)
+	public.Name = "public"
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(confidential, refreshing, public).Build()
+
+	request := func(pol *v1alpha1.OIDCPolicy) reconcile.Request {
+		return reconcile.Request{NamespacedName: k8stypes.NamespacedName{Namespace: pol.Namespace, Name: pol.Name}}
+	}
+	tests := []struct {
+		namespace, name string
+		want            []reconcile.Request
+	}{
+		{namespace: confidential.Namespace, name: "idp", want: []reconcile.Request{request(confidential)}},
+		{namespace: refreshing.Namespace, name: "session-key", want: []reconcile.Request{request(refreshing)}},
+		{namespace: "elsewhere", name: "idp"},
+		{namespace: "kuadrant", name: "default-confidential-client", want: []reconcile.Request{request(confidential)}},
+		{namespace: "kuadrant", name: "default-refreshing-session", want: []reconcile.Request{request(refreshing)}},
+		{namespace: "kuadrant", name: sessionServiceTokenSecretName, want: []reconcile.Request{request(refreshing)}},
+		{namespace: "kuadrant", name: "unrelated"},
+	}
+	for _, tt := range tests {
+		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: tt.namespace, Name: tt.name}}
+		if requests := SecretToPolicies(context.Background(), c, secret); !reflect.DeepEqual(requests, tt.want) {
+			t.Errorf("secret %s/%s: requests = %v, want %v", tt.namespace, tt.name, requests, tt.want)
+		}
+	}
+}
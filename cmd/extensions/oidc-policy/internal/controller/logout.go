@@ -0,0 +1,209 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	authorinov1beta3 "github.com/kuadrant/authorino/api/v1beta3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	kuadrantv1 "github.com/kuadrant/kuadrant-operator/api/v1"
+	"github.com/kuadrant/kuadrant-operator/cmd/extensions/oidc-policy/api/v1alpha1"
+)
+
+// discoveryCacheTTL is the duration in seconds the OpenID Connect Discovery document is cached for
+const discoveryCacheTTL = 3600
+
+// idTokenHintExpression returns the CEL expression of the id_token_hint query parameter of the
+// logout request, taken from the token cookie when present.
+func idTokenHintExpression(cookieName string) string {
+	return fmt.Sprintf(`(%s ? "&id_token_hint=" + %s : "")`, hasCookiePredicate(cookieName), cookieValueExpression(cookieName))
+}
+
+// buildLogoutLocationExpression returns the CEL expression of the location the End-User is sent to
+// by the logout route. It is the EndSessionEndpoint of the IDP if known, either configured or found
+// through discovery, and the post-logout redirect URL otherwise. The URLs are quoted as CEL string
+// literals, so that no character of theirs can break out of the expression.
+func buildLogoutLocationExpression(pol *v1alpha1.OIDCPolicy, igw *ingressGatewayInfo) (string, error) {
+	igwURL := igw.GetURL()
+	postLogoutURL, err := pol.GetPostLogoutRedirectURL(igwURL)
+	if err != nil {
+		return "", err
+	}
+	query, err := pol.GetEndSessionQuery(igwURL)
+	if err != nil {
+		return "", err
+	}
+
+	hint := `""`
+	if tokenSource := pol.GetTokenSource(); tokenSource.GetType() == authorinov1beta3.CookieCredentials {
+		hint = idTokenHintExpression(tokenSource.Cookie.Name)
+	}
+
+	if endpoint := pol.Spec.Provider.EndSessionEndpoint; endpoint != "" {
+		separator := "?"
+		if strings.Contains(endpoint, "?") {
+			separator = "&"
+		}
+		return fmt.Sprintf(`%s + %s`, strconv.Quote(endpoint+separator+query), hint), nil
+	}
+
+	if pol.Spec.Provider.IssuerURL == "" {
+		return strconv.Quote(postLogoutURL), nil
+	}
+
+	return fmt.Sprintf(`has(auth.metadata.discovery.end_session_endpoint) ? auth.metadata.discovery.end_session_endpoint + (auth.metadata.discovery.end_session_endpoint.contains("?") ? "&" : "?") + %s + %s : %s`, strconv.Quote(query), hint, strconv.Quote(postLogoutURL)), nil
+}
+
+func buildLogoutHTTPRoute(pol *v1alpha1.OIDCPolicy, igw *ingressGatewayInfo) *gatewayapiv1.HTTPRoute {
+	return buildFlowHTTPRoute(pol, igw, pol.Name+"-logout", v1alpha1.DefaultLogoutPath)
+}
+
+// buildLogoutAuthPolicy returns the AuthPolicy of the logout route, which clears the cookies of the
+// session and sends the End-User to the IDP to end the session there as well.
+func buildLogoutAuthPolicy(pol *v1alpha1.OIDCPolicy, igw *ingressGatewayInfo) (*kuadrantv1.AuthPolicy, error) {
+	location, err := buildLogoutLocationExpression(pol, igw)
+	if err != nil {
+		return nil, err
+	}
+	discoveryURL, err := pol.GetDiscoveryURL()
+	if err != nil {
+		return nil, err
+	}
+
+	logoutRoute := gatewayapiv1alpha2.LocalPolicyTargetReference{
+		Group: gatewayapiv1alpha2.GroupName,
+		Kind:  gatewayapiv1alpha2.Kind("HTTPRoute"),
+		Name:  gatewayapiv1alpha2.ObjectName(pol.Name + "-logout"),
+	}
+
+	headers := map[string]authorinov1beta3.ValueOrSelector{
+		"location": {Expression: authorinov1beta3.CelExpression(location)},
+	}
+	if tokenSource := pol.GetTokenSource(); tokenSource.GetType() == authorinov1beta3.CookieCredentials {
+		cookies := newCookieSettings(pol, igw)
+		headers["set-cookie"] = authorinov1beta3.ValueOrSelector{
+			Expression: authorinov1beta3.CelExpression(fmt.Sprintf(`"%s=; %s; Max-Age=0"`, tokenSource.Cookie.Name, cookies.attributes("/"))),
+		}
+		headers[sessionCookieHeader] = authorinov1beta3.ValueOrSelector{
+			Expression: authorinov1beta3.CelExpression(fmt.Sprintf(`"%s=; %s; Max-Age=0"`, pol.GetSessionCookieName(), cookies.attributes(pol.GetRefreshPath()))),
+		}
+	}
+
+	var metadata map[string]kuadrantv1.MergeableMetadataSpec
+	if pol.Spec.Provider.EndSessionEndpoint == "" && discoveryURL != "" {
+		metadata = map[string]kuadrantv1.MergeableMetadataSpec{
+			"discovery": {
+				MetadataSpec: authorinov1beta3.MetadataSpec{
+					CommonEvaluatorSpec: authorinov1beta3.CommonEvaluatorSpec{
+						Cache: &authorinov1beta3.EvaluatorCaching{
+							Key: authorinov1beta3.ValueOrSelector{Expression: authorinov1beta3.CelExpression(strconv.Quote(discoveryURL))},
+							TTL: discoveryCacheTTL,
+						},
+					},
+					MetadataMethodSpec: authorinov1beta3.MetadataMethodSpec{
+						Http: &authorinov1beta3.HttpEndpointSpec{
+							Url: discoveryURL,
+						},
+					},
+				},
+			},
+		}
+	}
+
+	return &kuadrantv1.AuthPolicy{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "AuthPolicy",
+			APIVersion: kuadrantv1.GroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pol.Name + "-logout",
+			Namespace: pol.Namespace,
+		},
+		Spec: kuadrantv1.AuthPolicySpec{
+			TargetRef: gatewayapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+				LocalPolicyTargetReference: logoutRoute,
+			},
+			Overrides: &kuadrantv1.MergeableAuthPolicySpec{
+				Strategy: "merge",
+				AuthPolicySpecProper: kuadrantv1.AuthPolicySpecProper{
+					AuthScheme: &kuadrantv1.AuthSchemeSpec{
+						Metadata: metadata,
+						Authorization: map[string]kuadrantv1.MergeableAuthorizationSpec{
+							"deny": {
+								AuthorizationSpec: authorinov1beta3.AuthorizationSpec{
+									AuthorizationMethodSpec: authorinov1beta3.AuthorizationMethodSpec{
+										Opa: &authorinov1beta3.OpaAuthorizationSpec{
+											Rego: "allow = false",
+										},
+									},
+								},
+							},
+						},
+						Response: &kuadrantv1.MergeableResponseSpec{
+							Unauthorized: &kuadrantv1.MergeableDenyWithSpec{
+								DenyWithSpec: authorinov1beta3.DenyWithSpec{
+									Code:    302,
+									Headers: headers,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+func (r *OIDCPolicyReconciler) reconcileLogoutHTTPRoute(ctx context.Context, pol *v1alpha1.OIDCPolicy, igw *ingressGatewayInfo) error {
+	return r.reconcileFlowHTTPRoute(ctx, pol, buildLogoutHTTPRoute(pol, igw), "Logout")
+}
+
+func (r *OIDCPolicyReconciler) reconcileLogoutAuthPolicy(ctx context.Context, pol *v1alpha1.OIDCPolicy, igw *ingressGatewayInfo) (*kuadrantv1.AuthPolicy, error) {
+	desiredAuthPol, err := buildLogoutAuthPolicy(pol, igw)
+	if err != nil {
+		return nil, err
+	}
+	return r.reconcileFlowAuthPolicy(ctx, pol, desiredAuthPol, "Logout")
+}
+
+// reconcileFlowHTTPRoute reconciles the HTTPRoute of a step of the OIDC flow, owned by the policy
+func (r *OIDCPolicyReconciler) reconcileFlowHTTPRoute(ctx context.Context, pol *v1alpha1.OIDCPolicy, desiredHTTPRoute *gatewayapiv1.HTTPRoute, step string) error {
+	err := controllerutil.SetControllerReference(pol, desiredHTTPRoute, r.Scheme)
+	if err != nil {
+		r.Logger.Error(err, fmt.Sprintf("Error setting OwnerReference on %s HTTPRoute", strings.ToLower(step)))
+		return err
+	}
+
+	routeMutator := httpRouteMutator(httpObjectMetaRouteMutator, httpSpecRouteMutator)
+	if err = r.reconcileHTTPRoute(ctx, desiredHTTPRoute, routeMutator); err != nil {
+		r.Logger.Error(err, fmt.Sprintf("Error reconciling OIDC %s HTTPRoute", step))
+		return err
+	}
+
+	r.Logger.Info(fmt.Sprintf("Successfully reconciled OIDC %s HTTPRoute", step))
+	return nil
+}
+
+// reconcileFlowAuthPolicy reconciles the AuthPolicy of a step of the OIDC flow, owned by the policy
+func (r *OIDCPolicyReconciler) reconcileFlowAuthPolicy(ctx context.Context, pol *v1alpha1.OIDCPolicy, desiredAuthPol *kuadrantv1.AuthPolicy, step string) (*kuadrantv1.AuthPolicy, error) {
+	err := controllerutil.SetControllerReference(pol, desiredAuthPol, r.Scheme)
+	if err != nil {
+		r.Logger.Error(err, fmt.Sprintf("Error setting OwnerReference on %s AuthPolicy", strings.ToLower(step)))
+		return nil, err
+	}
+
+	reconciledPol, err := r.reconcileAuthPolicy(ctx, desiredAuthPol, authPolicyMutator(authPolicySpecMutator))
+	if err != nil {
+		r.Logger.Error(err, fmt.Sprintf("Error reconciling OIDC %s AuthPolicy", step))
+		return nil, err
+	}
+
+	r.Logger.Info(fmt.Sprintf("Successfully reconciled OIDC %s AuthPolicy", step))
+	return reconciledPol, nil
+}
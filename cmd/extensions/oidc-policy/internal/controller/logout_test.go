@@ -0,0 +1,161 @@
+//go:build unit
+
+package controller
+
+import (
+	"testing"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+	authorinov1beta3 "github.com/kuadrant/authorino/api/v1beta3"
+
+	"github.com/kuadrant/kuadrant-operator/cmd/extensions/oidc-policy/api/v1alpha1"
+)
+
+// evalLogoutLocation evaluates the logout location expression with the
+// string extensions enabled by authorino.
+func evalLogoutLocation(t *testing.T, expression string, vars map[string]interface{}) string {
+	t.Helper()
+	env, err := cel.NewEnv(cel.Variable("auth", cel.DynType), cel.Variable("request", cel.DynType), ext.Strings())
+	if err != nil {
+		t.Fatalf("cel.NewEnv failed: %v", err)
+	}
+	ast, iss := env.Compile(expression)
+	if iss != nil && iss.Err() != nil {
+		t.Fatalf("compile failed for expression %q: %v", expression, iss.Err())
+	}
+	prg, err := env.Program(ast)
+	if err != nil {
+		t.Fatalf("program failed: %v", err)
+	}
+	out, _, err := prg.Eval(vars)
+	if err != nil {
+		t.Fatalf("eval failed: %v", err)
+	}
+	got, ok := out.Value().(string)
+	if !ok {
+		t.Fatalf("expression did not return a string, got %T", out.Value())
+	}
+	return got
+}
+
+func TestBuildLogoutLocationExpression(t *testing.T) {
+	withCookie := map[string]interface{}{
+		"request": map[string]interface{}{"headers": map[string]interface{}{"cookie": "target=/home; jwt=header.payload.signature"}},
+		"auth":    map[string]interface{}{"metadata": map[string]interface{}{"discovery": map[string]interface{}{"end_session_endpoint": "https://issuer.com/logout"}}},
+	}
+	withoutCookie := map[string]interface{}{
+		"request": map[string]interface{}{"headers": map[string]interface{}{}},
+		"auth":    map[string]interface{}{"metadata": map[string]interface{}{"discovery": map[string]interface{}{}}},
+	}
+
+	tests := []struct {
+		name     string
+		provider func(*v1alpha1.Provider)
+		vars     map[string]interface{}
+		expected string
+	}{
+		{
+			name:     "discovered end session endpoint",
+			vars:     withCookie,
+			expected: "https://issuer.com/logout?client_id=client+123&post_logout_redirect_uri=https%3A%2F%2Fexample.com%2F&id_token_hint=header.payload.signature",
+		},
+		{
+			name:     "no end session endpoint in discovery",
+			vars:     withoutCookie,
+			expected: "https://example.com/",
+		},
+		{
+			name: "configured end session endpoint and post logout redirect URI",
+			provider: func(p *v1alpha1.Provider) {
+				p.EndSessionEndpoint = "https://idp.example.com/logout?tenant=a"
+				p.PostLogoutRedirectURI = "https://example.com/goodbye"
+			},
+			vars:     withoutCookie,
+			expected: "https://idp.example.com/logout?tenant=a&client_id=client+123&post_logout_redirect_uri=https%3A%2F%2Fexample.com%2Fgoodbye",
+		},
+		{
+			name: "configured end session endpoint with characters to escape",
+			provider: func(p *v1alpha1.Provider) {
+				p.EndSessionEndpoint = `https://idp.example.com/logout?tenant="a\b`
+			},
+			vars:     withoutCookie,
+			expected: `https://idp.example.com/logout?tenant="a\b&client_id=client+123&post_logout_redirect_uri=https%3A%2F%2Fexample.com%2F`,
+		},
+		{
+			name: "no issuer",
+			provider: func(p *v1alpha1.Provider) {
+				p.IssuerURL = ""
+				p.JwksURL = "https://issuer.com/jwks"
+			},
+			vars:     withCookie,
+			expected: "https://example.com/",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pol := sessionTestPolicy(nil)
+			if tt.provider != nil {
+				tt.provider(pol.Spec.Provider)
+			}
+			expression, err := buildLogoutLocationExpression(pol, sessionTestGateway)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if location := evalLogoutLocation(t, expression, tt.vars); location != tt.expected {
+				t.Errorf("unexpected location, got %s, want %s", location, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBuildLogoutAuthPolicy(t *testing.T) {
+	pol := sessionTestPolicy(nil)
+	authPol, err := buildLogoutAuthPolicy(pol, sessionTestGateway)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authPol.Name != "oidc-logout" || string(authPol.Spec.TargetRef.Name) != "oidc-logout" {
+		t.Errorf("unexpected logout AuthPolicy target: %s -> %s", authPol.Name, authPol.Spec.TargetRef.Name)
+	}
+
+	scheme := authPol.Spec.Overrides.AuthScheme
+	discovery, found := scheme.Metadata["discovery"]
+	if !found || discovery.Http.Url != "https://issuer.com/.well-known/openid-configuration" || discovery.Cache == nil {
+		t.Errorf("expected cached discovery metadata, got %+v", scheme.Metadata)
+	}
+
+	headers := scheme.Response.Unauthorized.Headers
+	if cookie := string(headers["set-cookie"].Expression); cookie != `"jwt=; domain=example.com; HttpOnly; Secure; SameSite=Lax; Path=/; Max-Age=0"` {
+		t.Errorf("unexpected token cookie: %s", cookie)
+	}
+	if cookie := string(headers[sessionCookieHeader].Expression); cookie != `"oidc-session-oidc=; domain=example.com; HttpOnly; Secure; SameSite=Lax; Path=/auth/refresh/oidc; Max-Age=0"` {
+		t.Errorf("unexpected session cookie: %s", cookie)
+	}
+
+	pol.Spec.Provider.EndSessionEndpoint = "https://idp.example.com/logout"
+	pol.Spec.Auth = &v1alpha1.Auth{TokenSource: &authorinov1beta3.Credentials{AuthorizationHeader: &authorinov1beta3.Prefixed{Prefix: "Bearer"}}}
+	if authPol, err = buildLogoutAuthPolicy(pol, sessionTestGateway); err != nil {
+		t.Fatal(err)
+	}
+	if authPol.Spec.Overrides.AuthScheme.Metadata != nil {
+		t.Error("expected no discovery with a configured end session endpoint")
+	}
+	if _, found := authPol.Spec.Overrides.AuthScheme.Response.Unauthorized.Headers["set-cookie"]; found {
+		t.Error("expected no cookie to clear with a header token source")
+	}
+}
+
+func TestBuildLogoutHTTPRoute(t *testing.T) {
+	route := buildLogoutHTTPRoute(sessionTestPolicy(nil), sessionTestGateway)
+	if route.Name != "oidc-logout" {
+		t.Errorf("unexpected route name %s", route.Name)
+	}
+	if path := *route.Spec.Rules[0].Matches[0].Path.Value; path != "/logout" {
+		t.Errorf("unexpected route path %s", path)
+	}
+	if string(route.Spec.ParentRefs[0].Name) != "gateway" || string(*route.Spec.ParentRefs[0].Namespace) != "gateway-system" {
+		t.Errorf("unexpected parent ref %+v", route.Spec.ParentRefs[0])
+	}
+}
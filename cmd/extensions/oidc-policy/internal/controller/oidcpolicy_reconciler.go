@@ -6,14 +6,12 @@ import (
 	"fmt"
 	"net/url"
 	"reflect"
-	"slices"
+	"strconv"
 	"strings"
 
 	authorinov1beta3 "github.com/kuadrant/authorino/api/v1beta3"
 	"github.com/kuadrant/policy-machinery/machinery"
-	"github.com/samber/lo"
 	"k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/utils/ptr"
@@ -32,7 +30,8 @@ import (
 
 type OIDCPolicyReconciler struct {
 	types.ExtensionBase
-	kCtx types.KuadrantCtx
+	kCtx     types.KuadrantCtx
+	sessions *SessionService
 }
 
 type ingressGatewayInfo struct {
@@ -60,8 +59,8 @@ func (g *ingressGatewayInfo) GetURL() *url.URL {
 	return g.url
 }
 
-func NewOIDCPolicyReconciler() *OIDCPolicyReconciler {
-	return &OIDCPolicyReconciler{}
+func NewOIDCPolicyReconciler(sessions *SessionService) *OIDCPolicyReconciler {
+	return &OIDCPolicyReconciler{sessions: sessions}
 }
 
 func (r *OIDCPolicyReconciler) WithKuadrantCtx(kCtx types.KuadrantCtx) *OIDCPolicyReconciler {
@@ -83,6 +82,10 @@ func (r *OIDCPolicyReconciler) WithKuadrantCtx(kCtx types.KuadrantCtx) *OIDCPoli
 //+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes,verbs=get;create;list;watch;update;patch
 //+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes/status,verbs=get;update;patch
 
+// core permissions. The Secrets the extension writes all live in the namespace of Authorino, where the
+// extension-secrets-role grants writing them.
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+
 // TODO: Current OIDC Workflow works only for Browser apps and Native apps that manage the Auth via browser
 // TODO: It only implements Authentication using the Authorization Code Flow (Recommended). Missing Implicit and Hybrid Flow
 // TODO: Expand TokenSource to work with credentials other than cookie
@@ -99,6 +102,7 @@ func (r *OIDCPolicyReconciler) Reconcile(ctx context.Context, request reconcile.
 	if err := r.Client.Get(ctx, request.NamespacedName, oidcPolicy); err != nil {
 		if errors.IsNotFound(err) {
 			r.Logger.Error(err, "OIDCPolicy not found")
+			r.sessions.Unregister(request.NamespacedName)
 			return reconcile.Result{}, nil
 		}
 		r.Logger.Error(err, "Failed to get OIDCPolicy")
@@ -107,6 +111,11 @@ func (r *OIDCPolicyReconciler) Reconcile(ctx context.Context, request reconcile.
 
 	if oidcPolicy.GetDeletionTimestamp() != nil {
 		r.Logger.Info("OIDCPolicy marked to be deleted")
+		r.sessions.Unregister(request.NamespacedName)
+		if err := r.finalizeSecrets(ctx, oidcPolicy); err != nil {
+			r.Logger.Error(err, "Failed to clean up secrets")
+			return ctrl.Result{}, err
+		}
 		return ctrl.Result{}, nil
 	}
 
@@ -128,84 +137,113 @@ func (r *OIDCPolicyReconciler) Reconcile(ctx context.Context, request reconcile.
 
 	r.Logger.V(1).Info("Resolving ingress gateway info", "ingressGatewayData", ingressGatewayData)
 
-	oidcPolicyStatus, specErr := r.reconcileSpec(ctx, oidcPolicy, &ingressGatewayData)
-	statusResult, statusErr := r.reconcileStatus(ctx, oidcPolicy, oidcPolicyStatus)
+	authPolicies, specErr := r.reconcileSpec(ctx, oidcPolicy, &ingressGatewayData)
+	if err := extcontroller.ReportTargetStatus(ctx, kCtx, oidcPolicy, specErr); err != nil {
+		r.Logger.Error(err, "failed to report policy status")
+	}
+	statusErr := extcontroller.UpdatePolicyStatus(ctx, r.Client, oidcPolicy, specErr, authPolicies...)
 
 	if specErr != nil {
 		return ctrl.Result{}, specErr
 	}
 
 	if statusErr != nil {
-		return ctrl.Result{}, statusErr
-	}
-
-	if statusResult.RequeueAfter > 0 {
-		r.Logger.Info("Reconciling status not finished. Requeueing.")
-		return statusResult, nil
+		return ctrl.Result{}, fmt.Errorf("failed to update status: %w", statusErr)
 	}
 
 	r.Logger.Info("successfully reconciled")
 	return reconcile.Result{}, nil
 }
 
-func (r *OIDCPolicyReconciler) reconcileSpec(ctx context.Context, pol *v1alpha1.OIDCPolicy, igw *ingressGatewayInfo) (*v1alpha1.OIDCPolicyStatus, error) {
+// reconcileSpec returns the AuthPolicies enforcing the OIDC flow, which the
+// policy is enforced through.
+func (r *OIDCPolicyReconciler) reconcileSpec(ctx context.Context, pol *v1alpha1.OIDCPolicy, igw *ingressGatewayInfo) ([]client.Object, error) {
+	if err := validateSession(pol); err != nil {
+		return nil, err
+	}
+	if err := validatePredicates(pol); err != nil {
+		return nil, err
+	}
+
+	// Reconcile the client secret of confidential clients, passed by Authorino to the token endpoint
+	if err := r.reconcileClientCredentials(ctx, pol); err != nil {
+		r.Logger.Error(err, "Failed to reconcile client credentials")
+		return nil, err
+	}
+
+	// Register the key sealing the session and PKCE cookies with the session service
+	if pol.IsSessionKeyRequired() {
+		if err := r.reconcileSessionService(ctx, pol); err != nil {
+			r.Logger.Error(err, "Failed to reconcile session service")
+			return nil, err
+		}
+	} else {
+		r.sessions.Unregister(client.ObjectKeyFromObject(pol))
+	}
+
 	// Reconcile AuthPolicy for the oidc policy http route
 	mainAuthPol, err := r.reconcileMainAuthPolicy(ctx, pol, igw)
 	if err != nil {
 		r.Logger.Error(err, "Failed to reconcile main AuthPolicy")
-		return calculateErrorStatus(pol, err), err
+		return nil, err
 	}
 
 	// Reconcile HTTPRoute for the callback for exchanging code/token
 	if err = r.reconcileCallbackHTTPRoute(ctx, pol, igw); err != nil {
 		r.Logger.Error(err, "Failed to reconcile callback HTTPRoute")
-		return calculateErrorStatus(pol, err), err
+		return nil, err
 	}
 	// Reconcile AuthPolicy for the Token exchange flow with metadata http call
 	callbackPol, err := r.reconcileCallbackAuthPolicy(ctx, pol, igw)
 	if err != nil {
 		r.Logger.Error(err, "Failed to reconcile callback AuthPolicy")
-		return calculateErrorStatus(pol, err), err
+		return nil, err
 	}
 
-	// Check if AuthPolicies are enforced
-	for _, enforcedErr := range []error{
-		isAuthPolicyEnforced(mainAuthPol),
-		isAuthPolicyEnforced(callbackPol),
-	} {
-		if enforcedErr != nil {
-			return calculateEnforcedStatus(pol, enforcedErr), nil
-		}
+	// Reconcile HTTPRoute and AuthPolicy for logging out
+	if err = r.reconcileLogoutHTTPRoute(ctx, pol, igw); err != nil {
+		r.Logger.Error(err, "Failed to reconcile logout HTTPRoute")
+		return nil, err
+	}
+	logoutPol, err := r.reconcileLogoutAuthPolicy(ctx, pol, igw)
+	if err != nil {
+		r.Logger.Error(err, "Failed to reconcile logout AuthPolicy")
+		return nil, err
 	}
 
-	return calculateEnforcedStatus(pol, nil),
-		nil
-}
+	authPolicies := []client.Object{mainAuthPol, callbackPol, logoutPol}
 
-func (r *OIDCPolicyReconciler) reconcileStatus(ctx context.Context, pol *v1alpha1.OIDCPolicy, newStatus *v1alpha1.OIDCPolicyStatus) (ctrl.Result, error) {
-	equalStatus := pol.Status.Equals(newStatus, r.Logger)
-	r.Logger.Info("Status", "status is different", !equalStatus)
-	r.Logger.Info("Status", "generation is different", pol.Generation != pol.Status.ObservedGeneration)
-	if equalStatus && pol.Generation == pol.Status.ObservedGeneration {
-		// Steady state
-		r.Logger.Info("Status was not updated")
-		return reconcile.Result{}, nil
-	}
-
-	r.Logger.V(1).Info("Updating Status", "sequence no:", fmt.Sprintf("sequence No: %v->%v", pol.Status.ObservedGeneration, newStatus.ObservedGeneration))
-
-	pol.Status = *newStatus
-	updateErr := r.Client.Status().Update(ctx, pol)
-	if updateErr != nil {
-		// Ignore conflicts, resource might just be outdated.
-		if errors.IsConflict(updateErr) {
-			r.Logger.Info("Failed to update status: resource might just be outdated")
-			return reconcile.Result{Requeue: true}, nil
+	// Reconcile HTTPRoute and AuthPolicy for setting up the code challenge of the authorization request
+	if pol.Spec.Provider.PKCE {
+		if err = r.reconcileLoginHTTPRoute(ctx, pol, igw); err != nil {
+			r.Logger.Error(err, "Failed to reconcile login HTTPRoute")
+			return nil, err
+		}
+		loginPol, err := r.reconcileLoginAuthPolicy(ctx, pol, igw)
+		if err != nil {
+			r.Logger.Error(err, "Failed to reconcile login AuthPolicy")
+			return nil, err
 		}
+		authPolicies = append(authPolicies, loginPol)
+	}
 
-		return reconcile.Result{}, fmt.Errorf("failed to update status: %w", updateErr)
+	// Reconcile HTTPRoute and AuthPolicy for refreshing the tokens of a session
+	if pol.IsRefreshEnabled() {
+		if err = r.reconcileRefreshHTTPRoute(ctx, pol, igw); err != nil {
+			r.Logger.Error(err, "Failed to reconcile refresh HTTPRoute")
+			return nil, err
+		}
+		refreshPol, err := r.reconcileRefreshAuthPolicy(ctx, pol, igw)
+		if err != nil {
+			r.Logger.Error(err, "Failed to reconcile refresh AuthPolicy")
+			return nil, err
+		}
+		authPolicies = append(authPolicies, refreshPol)
 	}
-	return ctrl.Result{}, nil
+
+	// every object desired for the policy was reconciled, the ones no longer desired can be pruned
+	extcontroller.MarkDesiredStateComplete(ctx)
+	return authPolicies, nil
 }
 
 func (r *OIDCPolicyReconciler) reconcileMainAuthPolicy(ctx context.Context, pol *v1alpha1.OIDCPolicy, igw *ingressGatewayInfo) (*kuadrantv1.AuthPolicy, error) {
@@ -290,50 +328,43 @@ func (r *OIDCPolicyReconciler) reconcileHTTPRoute(ctx context.Context, desired *
 	return err
 }
 
-// claimPredicate builds the CEL predicate that matches a configured claim against
-// the JWT identity. A scalar claim (the common case, e.g. email, sub, email_verified)
-// is matched by equality; a list-valued claim (e.g. groups, roles) is matched by
-// membership. The combined expression covers both because CEL `==` between mismatched
-// types yields false rather than erroring, so the `in` branch only applies when the
-// claim is actually a list.
-//
-// Accessing a missing field with dot notation raises a no_such_field error in CEL
-// rather than yielding false, so the predicate is guarded with has() on the identity
-// and the claim key; a token that lacks the claim then evaluates to false instead of
-// failing the whole authorization expression.
-func claimPredicate(k, v string) string {
-	return fmt.Sprintf(`has(auth.identity) && has(auth.identity.%s) && (auth.identity.%s == "%s" || (type(auth.identity.%s) == list && "%s" in auth.identity.%s))`, k, k, v, k, v, k)
-}
-
-func buildTargetCookieExpression(hostname string, protocol gatewayapiv1.ProtocolType) string {
+// buildTargetCookieExpression returns the CEL expression of the cookie holding the target of the
+// original request during the login flow. It is always SameSite=Lax, as it must be sent along with
+// the redirect from the IDP back to the callback.
+func buildTargetCookieExpression(cookies cookieSettings) string {
+	cookies.sameSite = v1alpha1.DefaultCookieSameSite
 	return fmt.Sprintf(`
-"target=" + request.url_path + (has(request.query) && request.query != "" ? "?" + request.query : "") + "; domain=%s; HttpOnly; %s SameSite=Lax; Path=/; Max-Age=3600"`, hostname, getSecureFlag(protocol))
+"target=" + request.url_path + (has(request.query) && request.query != "" ? "?" + request.query : "") + "; %s; Max-Age=3600"`, cookies.attributes("/"))
 }
 
 func buildMainAuthPolicy(pol *v1alpha1.OIDCPolicy, igw *ingressGatewayInfo) (*kuadrantv1.AuthPolicy, error) {
-	authorizeURL, err := pol.GetAuthorizeURL(igw.GetURL())
+	loginURL, err := pol.GetLoginURL(igw.GetURL())
 	if err != nil {
 		return nil, err
 	}
-	serializedAuthorizeURL, err := json.Marshal(authorizeURL)
+	// with refresh enabled, unauthenticated requests go through the refresh route first, which falls
+	// back to the login URL when the session cannot be refreshed
+	if pol.IsRefreshEnabled() {
+		refreshURL, err := pol.GetRefreshURL(igw.GetURL())
+		if err != nil {
+			return nil, err
+		}
+		loginURL = refreshURL.String()
+	}
+	serializedLoginURL, err := json.Marshal(loginURL)
 	if err != nil {
 		return nil, err
 	}
 
-	setCookie := buildTargetCookieExpression(igw.Hostname, igw.Protocol)
+	setCookie := buildTargetCookieExpression(newCookieSettings(pol, igw))
 
 	var authorization = map[string]kuadrantv1.MergeableAuthorizationSpec{}
-	var authPatterns []authorinov1beta3.PatternExpressionOrRef
-	claims := pol.GetClaims()
-
-	if len(claims) > 0 {
-		for k, v := range claims {
-			authPatterns = append(authPatterns, authorinov1beta3.PatternExpressionOrRef{
-				CelPredicate: authorinov1beta3.CelPredicate{
-					Predicate: claimPredicate(k, v),
-				},
-			})
-		}
+	authPatterns, err := buildAuthorizationPatterns(pol)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(authPatterns) > 0 {
 		authorization = map[string]kuadrantv1.MergeableAuthorizationSpec{
 			"oidc": {
 				AuthorizationSpec: authorinov1beta3.AuthorizationSpec{
@@ -353,7 +384,7 @@ func buildMainAuthPolicy(pol *v1alpha1.OIDCPolicy, igw *ingressGatewayInfo) (*ku
 			},
 		}
 		if pol.Spec.Provider.IssuerURL != "" {
-			authorization["oidc"].Conditions[0].Predicate = fmt.Sprintf(`auth.identity.iss == "%s"`, pol.Spec.Provider.IssuerURL)
+			authorization["oidc"].Conditions[0].Predicate = fmt.Sprintf(`auth.identity.iss == %s`, strconv.Quote(pol.Spec.Provider.IssuerURL))
 		}
 	}
 
@@ -390,7 +421,7 @@ func buildMainAuthPolicy(pol *v1alpha1.OIDCPolicy, igw *ingressGatewayInfo) (*ku
 									Headers: map[string]authorinov1beta3.ValueOrSelector{
 										"location": {
 											Value: runtime.RawExtension{
-												Raw: serializedAuthorizeURL,
+												Raw: serializedLoginURL,
 											},
 										},
 										"set-cookie": {
@@ -399,6 +430,9 @@ func buildMainAuthPolicy(pol *v1alpha1.OIDCPolicy, igw *ingressGatewayInfo) (*ku
 									},
 								},
 							},
+							Success: kuadrantv1.MergeableWrappedSuccessResponseSpec{
+								Headers: buildForwardedClaimHeaders(pol),
+							},
 						},
 					},
 				},
@@ -415,8 +449,13 @@ func buildMainAuthPolicy(pol *v1alpha1.OIDCPolicy, igw *ingressGatewayInfo) (*ku
 }
 
 func buildCallbackHTTPRoute(pol *v1alpha1.OIDCPolicy, igw *ingressGatewayInfo) *gatewayapiv1.HTTPRoute {
+	return buildFlowHTTPRoute(pol, igw, pol.Name+"-callback", v1alpha1.DefaultCallbackPath)
+}
+
+// buildFlowHTTPRoute returns the HTTPRoute attaching a step of the OIDC flow, served by its
+// AuthPolicy, to the path of the ingress gateway
+func buildFlowHTTPRoute(pol *v1alpha1.OIDCPolicy, igw *ingressGatewayInfo, name, path string) *gatewayapiv1.HTTPRoute {
 	pathMatch := gatewayapiv1.PathMatchPathPrefix
-	path := v1alpha1.DefaultCallbackPath
 	gwName := gatewayapiv1.ObjectName(igw.Name)
 	gwNamespace := gatewayapiv1.Namespace(igw.Namespace)
 
@@ -426,7 +465,7 @@ func buildCallbackHTTPRoute(pol *v1alpha1.OIDCPolicy, igw *ingressGatewayInfo) *
 			APIVersion: gatewayapiv1.GroupVersion.String(),
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      pol.Name + "-callback",
+			Name:      name,
 			Namespace: pol.Namespace,
 		},
 		Spec: gatewayapiv1.HTTPRouteSpec{
@@ -454,12 +493,15 @@ func buildCallbackHTTPRoute(pol *v1alpha1.OIDCPolicy, igw *ingressGatewayInfo) *
 	}
 }
 
+// regoCookiesRule parses the cookies of the request into the cookies object
+const regoCookiesRule = `cookies := { name: value | raw_cookies := input.request.headers.cookie; cookie_parts := split(raw_cookies, ";"); part := cookie_parts[_]; trimmed := trim(part, " "); eq_idx := indexof(trimmed, "="); eq_idx != -1; name := trim(substring(trimmed, 0, eq_idx), " "); value := trim(substring(trimmed, eq_idx + 1, -1), " ")}`
+
 func buildOpaAuthorizationRule(baseURL *url.URL, igwURL *url.URL, authorizeURL string) string {
-	return fmt.Sprintf(`cookies := { name: value | raw_cookies := input.request.headers.cookie; cookie_parts := split(raw_cookies, ";"); part := cookie_parts[_]; trimmed := trim(part, " "); eq_idx := indexof(trimmed, "="); eq_idx != -1; name := trim(substring(trimmed, 0, eq_idx), " "); value := trim(substring(trimmed, eq_idx + 1, -1), " ")}
+	return fmt.Sprintf(`%s
 location := concat("", ["%s", cookies.target]) { input.auth.metadata.token.id_token; cookies.target }
 location := "%s" { input.auth.metadata.token.id_token; not cookies.target }
 location := "%s" { not input.auth.metadata.token.id_token }
-allow = true`, baseURL, igwURL, authorizeURL)
+allow = true`, regoCookiesRule, baseURL, igwURL, authorizeURL)
 }
 
 func buildCallbackAuthPolicy(pol *v1alpha1.OIDCPolicy, igw *ingressGatewayInfo) (*kuadrantv1.AuthPolicy, error) {
@@ -468,7 +510,7 @@ func buildCallbackAuthPolicy(pol *v1alpha1.OIDCPolicy, igw *ingressGatewayInfo)
 	if err != nil {
 		return nil, err
 	}
-	authorizeURL, err := pol.GetAuthorizeURL(igwURL)
+	loginURL, err := pol.GetLoginURL(igwURL)
 	if err != nil {
 		return nil, err
 	}
@@ -492,11 +534,56 @@ func buildCallbackAuthPolicy(pol *v1alpha1.OIDCPolicy, igw *ingressGatewayInfo)
 
 	callbackMethod := authorinov1beta3.HttpMethod("POST")
 
+	tokenConditions := []authorinov1beta3.PatternExpressionOrRef{
+		{
+			CelPredicate: authorinov1beta3.CelPredicate{
+				Predicate: `request.query.split("&").map(entry, entry.split("=")).filter(pair, pair[0] == "code").map(pair, pair[1]).size() > 0`,
+			},
+		},
+	}
+
+	// with PKCE, the token exchange only happens with the code verifier opened out of the PKCE cookie
+	metadata := map[string]kuadrantv1.MergeableMetadataSpec{}
+	opaAuthorizationRule := buildOpaAuthorizationRule(baseURL, igwURL, loginURL)
+	token, tokenPriority := "auth.metadata.token", 0
+	if pol.Spec.Provider.PKCE {
+		metadata["pkce"] = openPKCEMetadata(pol)
+		callBodyCelExpression = fmt.Sprintf("%s + %s", callBodyCelExpression, codeVerifierExpression())
+		tokenConditions = append(tokenConditions, metadataFieldPredicate("pkce", "verifier"))
+		opaAuthorizationRule = buildPKCEOpaAuthorizationRule(baseURL, igwURL, loginURL)
+		token, tokenPriority = "auth.authorization.location.token", 1
+	}
+
 	callbackBody := authorinov1beta3.ValueOrSelector{
 		Expression: authorinov1beta3.CelExpression(callBodyCelExpression),
 	}
+	metadata["token"] = kuadrantv1.MergeableMetadataSpec{
+		MetadataSpec: authorinov1beta3.MetadataSpec{
+			CommonEvaluatorSpec: authorinov1beta3.CommonEvaluatorSpec{
+				Priority:   tokenPriority,
+				Conditions: tokenConditions,
+			},
+			MetadataMethodSpec: authorinov1beta3.MetadataMethodSpec{
+				Http: &authorinov1beta3.HttpEndpointSpec{
+					Url:          tokenRequestURL,
+					Method:       &callbackMethod,
+					Body:         &callbackBody,
+					SharedSecret: clientCredentialsSecretRef(pol),
+					Credentials:  clientCredentials(pol),
+				},
+			},
+		},
+	}
 
-	opaAuthorizationRule := buildOpaAuthorizationRule(baseURL, igwURL, authorizeURL)
+	// the refresh token obtained by the token exchange is sealed into a new session
+	headers := credentialsHeader(pol, igw, token)
+	if pol.IsRefreshEnabled() {
+		metadata["sealed"] = sealSessionMetadata(pol, tokenPriority+1, "auth.metadata.token.refresh_token", "")
+		opaAuthorizationRule += "\n" + buildCallbackSessionRule()
+		headers[sessionCookieHeader] = authorinov1beta3.ValueOrSelector{
+			Expression: authorinov1beta3.CelExpression(sessionCookieExpression(pol, newCookieSettings(pol, igw), "auth.authorization.location.session_cookie")),
+		}
+	}
 
 	return &kuadrantv1.AuthPolicy{
 		TypeMeta: metav1.TypeMeta{
@@ -515,28 +602,7 @@ func buildCallbackAuthPolicy(pol *v1alpha1.OIDCPolicy, igw *ingressGatewayInfo)
 				Strategy: "merge",
 				AuthPolicySpecProper: kuadrantv1.AuthPolicySpecProper{
 					AuthScheme: &kuadrantv1.AuthSchemeSpec{
-						Metadata: map[string]kuadrantv1.MergeableMetadataSpec{
-							"token": {
-								MetadataSpec: authorinov1beta3.MetadataSpec{
-									CommonEvaluatorSpec: authorinov1beta3.CommonEvaluatorSpec{
-										Conditions: []authorinov1beta3.PatternExpressionOrRef{
-											{
-												CelPredicate: authorinov1beta3.CelPredicate{
-													Predicate: `request.query.split("&").map(entry, entry.split("=")).filter(pair, pair[0] == "code").map(pair, pair[1]).size() > 0`,
-												},
-											},
-										},
-									},
-									MetadataMethodSpec: authorinov1beta3.MetadataMethodSpec{
-										Http: &authorinov1beta3.HttpEndpointSpec{
-											Url:    tokenRequestURL,
-											Method: &callbackMethod,
-											Body:   &callbackBody,
-										},
-									},
-								},
-							},
-						},
+						Metadata: metadata,
 						Authorization: map[string]kuadrantv1.MergeableAuthorizationSpec{
 							"location": {
 								AuthorizationSpec: authorinov1beta3.AuthorizationSpec{
@@ -568,7 +634,7 @@ func buildCallbackAuthPolicy(pol *v1alpha1.OIDCPolicy, igw *ingressGatewayInfo)
 							Unauthorized: &kuadrantv1.MergeableDenyWithSpec{
 								DenyWithSpec: authorinov1beta3.DenyWithSpec{
 									Code:    302,
-									Headers: credentialsHeader(pol.GetTokenSource(), igw),
+									Headers: headers,
 								},
 							},
 						},
@@ -673,26 +739,41 @@ func credentialsSource(tokenSource *authorinov1beta3.Credentials) authorinov1bet
 	}
 }
 
-func credentialsHeader(tokenSource *authorinov1beta3.Credentials, igw *ingressGatewayInfo) map[string]authorinov1beta3.ValueOrSelector {
+// clientCredentials returns where Authorino passes the client credentials to the TokenEndpoint
+func clientCredentials(pol *v1alpha1.OIDCPolicy) authorinov1beta3.Credentials {
+	if pol.Spec.Provider.ClientSecretRef == nil {
+		return authorinov1beta3.Credentials{}
+	}
+	return authorinov1beta3.Credentials{AuthorizationHeader: &authorinov1beta3.Prefixed{Prefix: "Basic"}}
+}
+
+// credentialsHeader returns the headers of the callback response, given the CEL expression of the
+// response of the token endpoint
+func credentialsHeader(pol *v1alpha1.OIDCPolicy, igw *ingressGatewayInfo, token string) map[string]authorinov1beta3.ValueOrSelector {
+	tokenSource := pol.GetTokenSource()
 	headers := make(map[string]authorinov1beta3.ValueOrSelector)
 	headers["location"] = authorinov1beta3.ValueOrSelector{Expression: "auth.authorization.location.location"}
 	switch tokenSource.GetType() {
 	case authorinov1beta3.AuthorizationHeaderCredentials:
-		headers["Authorization"] = authorinov1beta3.ValueOrSelector{Expression: authorinov1beta3.CelExpression(fmt.Sprintf(`"%s " + auth.metadata.token.id_token`, tokenSource.AuthorizationHeader.Prefix))}
+		headers["Authorization"] = authorinov1beta3.ValueOrSelector{Expression: authorinov1beta3.CelExpression(fmt.Sprintf(`"%s " + %s.id_token`, tokenSource.AuthorizationHeader.Prefix, token))}
 	case authorinov1beta3.CustomHeaderCredentials:
-		headers[tokenSource.CustomHeader.Name] = authorinov1beta3.ValueOrSelector{Expression: "auth.metadata.token.id_token"}
+		headers[tokenSource.CustomHeader.Name] = authorinov1beta3.ValueOrSelector{Expression: authorinov1beta3.CelExpression(token + ".id_token")}
 	case authorinov1beta3.CookieCredentials:
-		headers["set-cookie"] = cookieHeader(tokenSource.Cookie.Name, igw)
+		headers["set-cookie"] = cookieHeader(pol, tokenSource.Cookie.Name, igw, token)
 	default:
-		headers["set-cookie"] = cookieHeader(v1alpha1.DefaultTokenSourceName, igw)
+		headers["set-cookie"] = cookieHeader(pol, v1alpha1.DefaultTokenSourceName, igw, token)
 	}
 	return headers
 }
 
-func cookieHeader(cookieName string, igw *ingressGatewayInfo) authorinov1beta3.ValueOrSelector {
-	return authorinov1beta3.ValueOrSelector{Expression: authorinov1beta3.CelExpression(fmt.Sprintf(`
-"%s=" + auth.metadata.token.id_token + "; domain=%s; HttpOnly; %s SameSite=Lax; Path=/; Max-Age=3600"
-`, cookieName, igw.Hostname, getSecureFlag(igw.Protocol)))}
+func cookieHeader(pol *v1alpha1.OIDCPolicy, cookieName string, igw *ingressGatewayInfo, token string) authorinov1beta3.ValueOrSelector {
+	cookies := newCookieSettings(pol, igw)
+	expression := tokenCookieExpression(pol, cookieName, cookies, token)
+	if pol.Spec.Provider.PKCE {
+		// the token is withheld by the authorization rule unless the code verifier can be trusted
+		expression = fmt.Sprintf(`has(%s.id_token) ? %s : "%s=; %s; Max-Age=0"`, token, expression, cookieName, cookies.attributes("/"))
+	}
+	return authorinov1beta3.ValueOrSelector{Expression: authorinov1beta3.CelExpression(expression)}
 }
 
 func getSecureFlag(protocol gatewayapiv1.ProtocolType) string {
@@ -702,36 +783,3 @@ func getSecureFlag(protocol gatewayapiv1.ProtocolType) string {
 	}
 	return flag
 }
-
-func isAuthPolicyEnforced(authPolicy *kuadrantv1.AuthPolicy) error {
-	cond, found := lo.Find(authPolicy.Status.GetConditions(), func(c metav1.Condition) bool {
-		return c.Type == string(types.PolicyConditionEnforced)
-	})
-	if !found || cond.Status == metav1.ConditionFalse {
-		return fmt.Errorf("AuthPolicy %s is not enforced", authPolicy.Name)
-	}
-	return nil
-}
-
-func calculateErrorStatus(pol *v1alpha1.OIDCPolicy, specErr error) *v1alpha1.OIDCPolicyStatus {
-	newStatus := &v1alpha1.OIDCPolicyStatus{
-		ObservedGeneration: pol.Generation,
-		// Copy initial conditions. Otherwise, status will always be updated
-		Conditions: slices.Clone(pol.Status.Conditions),
-	}
-	meta.SetStatusCondition(&newStatus.Conditions, *extcontroller.AcceptedCondition(pol, specErr))
-	meta.RemoveStatusCondition(&newStatus.Conditions, string(types.PolicyConditionEnforced))
-	return newStatus
-}
-
-func calculateEnforcedStatus(pol *v1alpha1.OIDCPolicy, enforcedErr error) *v1alpha1.OIDCPolicyStatus {
-	newStatus := &v1alpha1.OIDCPolicyStatus{
-		ObservedGeneration: pol.Generation,
-		// Copy initial conditions. Otherwise, status will always be updated
-		Conditions: slices.Clone(pol.Status.Conditions),
-	}
-
-	meta.SetStatusCondition(&newStatus.Conditions, *extcontroller.AcceptedCondition(pol, nil))
-	meta.SetStatusCondition(&newStatus.Conditions, *extcontroller.EnforcedCondition(pol, enforcedErr, true))
-	return newStatus
-}
@@ -10,6 +10,8 @@ import (
 
 	"github.com/google/cel-go/cel"
 	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/kuadrant/kuadrant-operator/cmd/extensions/oidc-policy/api/v1alpha1"
 )
 
 // evalClaimPredicate compiles the predicate produced by claimPredicate and
@@ -223,6 +225,11 @@ func TestBuildOpaAuthorizationRule_JWTScenarios(t *testing.T) {
 	}
 }
 
+// defaultCookieSettings returns the cookie settings of a policy with no session configuration
+func defaultCookieSettings(hostname string, protocol gatewayapiv1.ProtocolType) cookieSettings {
+	return newCookieSettings(&v1alpha1.OIDCPolicy{}, &ingressGatewayInfo{Hostname: hostname, Protocol: protocol})
+}
+
 func TestBuildTargetCookieExpression(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -263,7 +270,7 @@ func TestBuildTargetCookieExpression(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := buildTargetCookieExpression(tt.hostname, tt.protocol)
+			result := buildTargetCookieExpression(defaultCookieSettings(tt.hostname, tt.protocol))
 
 			for _, expected := range tt.want {
 				if !strings.Contains(result, expected) {
@@ -278,7 +285,7 @@ func TestBuildTargetCookieExpression_QueryStringHandling(t *testing.T) {
 	hostname := "example.com"
 	protocol := gatewayapiv1.HTTPProtocolType
 
-	expression := buildTargetCookieExpression(hostname, protocol)
+	expression := buildTargetCookieExpression(defaultCookieSettings(hostname, protocol))
 
 	// Verify the expression includes query string handling
 	requiredPatterns := []string{
@@ -302,7 +309,7 @@ func TestBuildTargetCookieExpression_QueryStringHandling(t *testing.T) {
 }
 
 func TestBuildTargetCookieExpression_Examples(t *testing.T) {
-	expression := buildTargetCookieExpression("example.com", gatewayapiv1.HTTPSProtocolType)
+	expression := buildTargetCookieExpression(defaultCookieSettings("example.com", gatewayapiv1.HTTPSProtocolType))
 
 	// Document the expected behavior with examples
 	examples := []struct {
@@ -474,7 +481,7 @@ func TestIngressGatewayInfo_GetURL_PortInCookieDomain(t *testing.T) {
 	}
 
 	// Cookie domain uses igw.Hostname (without port), which is correct
-	cookieExpr := buildTargetCookieExpression(igw.Hostname, igw.Protocol)
+	cookieExpr := buildTargetCookieExpression(newCookieSettings(&v1alpha1.OIDCPolicy{}, igw))
 	if !strings.Contains(cookieExpr, "domain=example.com") {
 		t.Error("Cookie domain should use hostname without port")
 	}
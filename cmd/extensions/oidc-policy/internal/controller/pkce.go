@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	authorinov1beta3 "github.com/kuadrant/authorino/api/v1beta3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	kuadrantv1 "github.com/kuadrant/kuadrant-operator/api/v1"
+	"github.com/kuadrant/kuadrant-operator/cmd/extensions/oidc-policy/api/v1alpha1"
+)
+
+// pkceCookieExpression returns the CEL expression of the cookie holding the sealed code verifier,
+// given the CEL expression of its value. It is only ever sent to the callback route and, like the
+// target cookie, is always SameSite=Lax so that it is sent along with the redirect from the IDP.
+func pkceCookieExpression(cookies cookieSettings, value string) string {
+	cookies.sameSite = v1alpha1.DefaultCookieSameSite
+	return fmt.Sprintf(`"%s=" + %s + "; %s; Max-Age=%d"`, v1alpha1.DefaultPKCECookieName, value,
+		cookies.attributes(v1alpha1.DefaultCallbackPath), int64(v1alpha1.DefaultPKCECookieMaxAge.Seconds()))
+}
+
+// codeVerifierExpression returns the CEL expression of the code_verifier parameter of the token
+// request, opened by the session service out of the PKCE cookie
+func codeVerifierExpression() string {
+	return `"&code_verifier=" + auth.metadata.pkce.verifier`
+}
+
+// openPKCEMetadata returns the metadata evaluator opening the PKCE cookie with the session service, which
+// yields the code verifier as long as the cookie was sealed by the login route of the policy and has not
+// expired
+func openPKCEMetadata(pol *v1alpha1.OIDCPolicy) kuadrantv1.MergeableMetadataSpec {
+	return sessionServiceMetadata(pol, sessionServiceOpenPKCEPath, 0, authorinov1beta3.NamedValuesOrSelectors{
+		"cookie": {Expression: optionalCookieValueExpression(v1alpha1.DefaultPKCECookieName)},
+	})
+}
+
+// buildPKCEOpaAuthorizationRule returns the Rego rule of the callback when PKCE is enabled, where the token
+// exchange only happens with a code verifier opened out of the PKCE cookie
+func buildPKCEOpaAuthorizationRule(baseURL, igwURL *url.URL, loginURL string) string {
+	return fmt.Sprintf(`%s
+token := input.auth.metadata.token { input.auth.metadata.token.id_token }
+token := {} { not input.auth.metadata.token.id_token }
+location := concat("", ["%s", cookies.target]) { token.id_token; cookies.target }
+location := "%s" { token.id_token; not cookies.target }
+location := "%s" { not token.id_token }
+allow = true`, regoCookiesRule, baseURL, igwURL, loginURL)
+}
+
+func buildLoginHTTPRoute(pol *v1alpha1.OIDCPolicy, igw *ingressGatewayInfo) *gatewayapiv1.HTTPRoute {
+	return buildFlowHTTPRoute(pol, igw, pol.Name+"-login", v1alpha1.DefaultLoginPath)
+}
+
+// buildLoginAuthPolicy returns the AuthPolicy of the login route, which has the session service generate a
+// code verifier, sends the End-User to the AuthorizationEndpoint with the S256 code challenge of it and keeps
+// the sealed code verifier in a short-lived cookie for the callback.
+func buildLoginAuthPolicy(pol *v1alpha1.OIDCPolicy, igw *ingressGatewayInfo) (*kuadrantv1.AuthPolicy, error) {
+	authorizeURL, err := pol.GetAuthorizeURL(igw.GetURL())
+	if err != nil {
+		return nil, err
+	}
+
+	loginRoute := gatewayapiv1alpha2.LocalPolicyTargetReference{
+		Group: gatewayapiv1alpha2.GroupName,
+		Kind:  gatewayapiv1alpha2.Kind("HTTPRoute"),
+		Name:  gatewayapiv1alpha2.ObjectName(pol.Name + "-login"),
+	}
+
+	return &kuadrantv1.AuthPolicy{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "AuthPolicy",
+			APIVersion: kuadrantv1.GroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pol.Name + "-login",
+			Namespace: pol.Namespace,
+		},
+		Spec: kuadrantv1.AuthPolicySpec{
+			TargetRef: gatewayapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+				LocalPolicyTargetReference: loginRoute,
+			},
+			Overrides: &kuadrantv1.MergeableAuthPolicySpec{
+				Strategy: "merge",
+				AuthPolicySpecProper: kuadrantv1.AuthPolicySpecProper{
+					AuthScheme: &kuadrantv1.AuthSchemeSpec{
+						Metadata: map[string]kuadrantv1.MergeableMetadataSpec{
+							"pkce": sessionServiceMetadata(pol, sessionServiceNewPKCEPath, 0, authorinov1beta3.NamedValuesOrSelectors{}),
+						},
+						Authorization: map[string]kuadrantv1.MergeableAuthorizationSpec{
+							"deny": {
+								AuthorizationSpec: authorinov1beta3.AuthorizationSpec{
+									AuthorizationMethodSpec: authorinov1beta3.AuthorizationMethodSpec{
+										Opa: &authorinov1beta3.OpaAuthorizationSpec{
+											Rego: "allow = false",
+										},
+									},
+								},
+							},
+						},
+						Response: &kuadrantv1.MergeableResponseSpec{
+							Unauthorized: &kuadrantv1.MergeableDenyWithSpec{
+								DenyWithSpec: authorinov1beta3.DenyWithSpec{
+									Code: 302,
+									Headers: map[string]authorinov1beta3.ValueOrSelector{
+										"location":   {Expression: authorinov1beta3.CelExpression(fmt.Sprintf(`"%s&code_challenge=" + auth.metadata.pkce.challenge + "&code_challenge_method=S256"`, authorizeURL))},
+										"set-cookie": {Expression: authorinov1beta3.CelExpression(pkceCookieExpression(newCookieSettings(pol, igw), "auth.metadata.pkce.cookie"))},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+func (r *OIDCPolicyReconciler) reconcileLoginHTTPRoute(ctx context.Context, pol *v1alpha1.OIDCPolicy, igw *ingressGatewayInfo) error {
+	return r.reconcileFlowHTTPRoute(ctx, pol, buildLoginHTTPRoute(pol, igw), "Login")
+}
+
+func (r *OIDCPolicyReconciler) reconcileLoginAuthPolicy(ctx context.Context, pol *v1alpha1.OIDCPolicy, igw *ingressGatewayInfo) (*kuadrantv1.AuthPolicy, error) {
+	desiredAuthPol, err := buildLoginAuthPolicy(pol, igw)
+	if err != nil {
+		return nil, err
+	}
+	return r.reconcileFlowAuthPolicy(ctx, pol, desiredAuthPol, "Login")
+}
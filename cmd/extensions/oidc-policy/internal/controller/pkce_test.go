@@ -0,0 +1,174 @@
+//go:build unit
+
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+
+	"github.com/kuadrant/kuadrant-operator/cmd/extensions/oidc-policy/api/v1alpha1"
+)
+
+func pkceTestPolicy() *v1alpha1.OIDCPolicy {
+	pol := sessionTestPolicy(nil)
+	pol.Spec.Provider.PKCE = true
+	return pol
+}
+
+func TestBuildMainAuthPolicy_RedirectsToLoginRoute(t *testing.T) {
+	authPol, err := buildMainAuthPolicy(pkceTestPolicy(), sessionTestGateway)
+	if err != nil {
+		t.Fatal(err)
+	}
+	location := string(authPol.Spec.Overrides.AuthScheme.Response.Unauthenticated.Headers["location"].Value.Raw)
+	if location != `"https://example.com/auth/login"` {
+		t.Errorf("unexpected location %s", location)
+	}
+}
+
+func TestBuildLoginAuthPolicy(t *testing.T) {
+	authPol, err := buildLoginAuthPolicy(pkceTestPolicy(), sessionTestGateway)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authPol.Name != "oidc-login" || string(authPol.Spec.TargetRef.Name) != "oidc-login" {
+		t.Errorf("unexpected login AuthPolicy target: %s -> %s", authPol.Name, authPol.Spec.TargetRef.Name)
+	}
+
+	scheme := authPol.Spec.Overrides.AuthScheme
+	pkce := scheme.Metadata["pkce"].Http
+	if pkce == nil || pkce.Url != "http://kuadrant-operator-oidc-session.kuadrant-system.svc.cluster.local:8086/pkce/new" {
+		t.Fatalf("expected the code verifier to be generated by the session service, got %+v", pkce)
+	}
+	if string(pkce.Parameters["policy"].Value.Raw) != `"default/oidc"` {
+		t.Errorf("unexpected policy parameter %s", pkce.Parameters["policy"].Value.Raw)
+	}
+	if pkce.SharedSecret == nil || pkce.SharedSecret.Name != sessionServiceTokenSecretName {
+		t.Errorf("expected Authorino to authenticate to the session service, got %+v", pkce.SharedSecret)
+	}
+	for _, authorization := range scheme.Authorization {
+		if strings.Contains(authorization.Opa.Rego, "crypto.") {
+			t.Errorf("expected no crypto in the rego, got %s", authorization.Opa.Rego)
+		}
+	}
+
+	headers := scheme.Response.Unauthorized.Headers
+	login := map[string]interface{}{"metadata": map[string]interface{}{"pkce": map[string]interface{}{"challenge": "xyz", "cookie": "sealed"}}}
+	if location := evalCookieExpression(t, string(headers["location"].Expression), login); location != "https://issuer.com/oauth/authorize?client_id=client+123&redirect_uri=https%3A%2F%2Fexample.com%2Fauth%2Fcallback&response_type=code&scope=openid&code_challenge=xyz&code_challenge_method=S256" {
+		t.Errorf("unexpected location %s", location)
+	}
+	if cookie := evalCookieExpression(t, string(headers["set-cookie"].Expression), login); cookie != "oidc-pkce=sealed; domain=example.com; HttpOnly; Secure; SameSite=Lax; Path=/auth/callback; Max-Age=300" {
+		t.Errorf("unexpected pkce cookie: %s", cookie)
+	}
+}
+
+func TestOptionalCookieValueExpression(t *testing.T) {
+	env, err := cel.NewEnv(cel.Variable("request", cel.DynType), ext.Strings())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ast, iss := env.Compile(string(optionalCookieValueExpression(v1alpha1.DefaultPKCECookieName)))
+	if iss != nil && iss.Err() != nil {
+		t.Fatal(iss.Err())
+	}
+	prg, err := env.Program(ast)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for cookie, expected := range map[string]string{
+		"target=/home; oidc-pkce=abc123": "abc123",
+		"target=/home":                   "",
+	} {
+		out, _, err := prg.Eval(map[string]interface{}{
+			"request": map[string]interface{}{"headers": map[string]interface{}{"cookie": cookie}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out.Value() != expected {
+			t.Errorf("unexpected cookie value %v for %q", out.Value(), cookie)
+		}
+	}
+}
+
+func TestMetadataFieldPredicate(t *testing.T) {
+	env, err := cel.NewEnv(cel.Variable("auth", cel.DynType))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ast, iss := env.Compile(metadataFieldPredicate("pkce", "verifier").Predicate)
+	if iss != nil && iss.Err() != nil {
+		t.Fatal(iss.Err())
+	}
+	prg, err := env.Program(ast)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		metadata map[string]interface{}
+		expected bool
+	}{
+		{metadata: map[string]interface{}{"pkce": map[string]interface{}{"verifier": "abc"}}, expected: true},
+		{metadata: map[string]interface{}{"pkce": map[string]interface{}{}}, expected: false},
+		{metadata: map[string]interface{}{}, expected: false},
+	} {
+		out, _, err := prg.Eval(map[string]interface{}{"auth": map[string]interface{}{"metadata": tc.metadata}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out.Value() != tc.expected {
+			t.Errorf("expected %v for %v, got %v", tc.expected, tc.metadata, out.Value())
+		}
+	}
+}
+
+func TestBuildCallbackAuthPolicy_PKCE(t *testing.T) {
+	authPol, err := buildCallbackAuthPolicy(pkceTestPolicy(), sessionTestGateway)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheme := authPol.Spec.Overrides.AuthScheme
+
+	pkce := scheme.Metadata["pkce"]
+	if pkce.Http == nil || !strings.HasSuffix(pkce.Http.Url, sessionServiceOpenPKCEPath) || pkce.Priority != 0 {
+		t.Errorf("expected the pkce cookie to be opened by the session service first, got %+v", pkce)
+	}
+
+	token := scheme.Metadata["token"]
+	if token.Priority != 1 || len(token.Conditions) != 2 || token.Conditions[1].Predicate != `"pkce" in auth.metadata && has(auth.metadata.pkce.verifier)` {
+		t.Errorf("expected the token exchange to require the code verifier, got %+v", token.Conditions)
+	}
+	if !strings.Contains(string(token.Http.Body.Expression), `"&code_verifier=" + auth.metadata.pkce.verifier`) {
+		t.Errorf("expected the code verifier in the token request, got %s", token.Http.Body.Expression)
+	}
+
+	rego := scheme.Authorization["location"].Opa.Rego
+	for _, expected := range []string{
+		`token := input.auth.metadata.token { input.auth.metadata.token.id_token }`,
+		`location := "https://example.com/auth/login" { not token.id_token }`,
+	} {
+		if !strings.Contains(rego, expected) {
+			t.Errorf("callback rego missing expected pattern: %s\nGot: %s", expected, rego)
+		}
+	}
+	if strings.Contains(rego, "crypto.") {
+		t.Errorf("expected no crypto in the rego, got %s", rego)
+	}
+
+	headers := scheme.Response.Unauthorized.Headers
+	verified := map[string]interface{}{"authorization": map[string]interface{}{"location": map[string]interface{}{
+		"token": map[string]interface{}{"id_token": "abc"},
+	}}}
+	if cookie := evalCookieExpression(t, string(headers["set-cookie"].Expression), verified); !strings.HasPrefix(cookie, "jwt=abc;") {
+		t.Errorf("unexpected token cookie: %s", cookie)
+	}
+	unverified := map[string]interface{}{"authorization": map[string]interface{}{"location": map[string]interface{}{
+		"token": map[string]interface{}{},
+	}}}
+	if cookie := evalCookieExpression(t, string(headers["set-cookie"].Expression), unverified); !strings.HasSuffix(cookie, "Max-Age=0") {
+		t.Errorf("expected token cookie to be cleared, got %s", cookie)
+	}
+}
@@ -0,0 +1,461 @@
+package controller
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"net/url"
+
+	authorinov1beta3 "github.com/kuadrant/authorino/api/v1beta3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	kuadrantv1 "github.com/kuadrant/kuadrant-operator/api/v1"
+	"github.com/kuadrant/kuadrant-operator/cmd/extensions/oidc-policy/api/v1alpha1"
+)
+
+const (
+	// sessionKeySecretKey is the key of the generated session key in its Secret
+	sessionKeySecretKey = "key"
+	// minSessionKeyLength is the minimum length in bytes of the session key
+	minSessionKeyLength = 32
+
+	// sessionCookieHeader sets the session cookie along with the token cookie. Authorino keys the
+	// response headers case-sensitively while the data plane appends all of them, so using another
+	// case than the token cookie header lets both cookies be set in the same response.
+	sessionCookieHeader = "Set-Cookie"
+)
+
+// cookieSettings holds the attributes of the cookies set along the OIDC flow
+type cookieSettings struct {
+	domain   string
+	sameSite string
+	secure   bool
+}
+
+func newCookieSettings(pol *v1alpha1.OIDCPolicy, igw *ingressGatewayInfo) cookieSettings {
+	return cookieSettings{
+		domain:   pol.GetCookieDomain(igw.Hostname),
+		sameSite: pol.GetCookieSameSite(),
+		secure:   igw.Protocol == gatewayapiv1.HTTPSProtocolType,
+	}
+}
+
+// attributes returns the cookie attributes, up to but excluding Max-Age
+func (c cookieSettings) attributes(path string) string {
+	protocol := gatewayapiv1.HTTPProtocolType
+	if c.secure {
+		protocol = gatewayapiv1.HTTPSProtocolType
+	}
+	return fmt.Sprintf("domain=%s; HttpOnly; %s SameSite=%s; Path=%s", c.domain, getSecureFlag(protocol), c.sameSite, path)
+}
+
+// hasCookiePredicate returns the CEL predicate telling whether the request carries the cookie
+func hasCookiePredicate(cookieName string) string {
+	return fmt.Sprintf(`has(request.headers.cookie) && request.headers.cookie.split(";").exists(c, c.trim().startsWith("%s="))`, cookieName)
+}
+
+// cookieValueExpression returns the CEL expression of the value of the cookie, which must be present
+// in the request
+func cookieValueExpression(cookieName string) string {
+	return fmt.Sprintf(`request.headers.cookie.split(";").map(c, c.trim()).filter(c, c.startsWith("%s="))[0].substring(%d)`, cookieName, len(cookieName)+1)
+}
+
+// tokenMaxAgeExpression returns the CEL expression of the Max-Age of the token cookie, given the CEL
+// expression of the token endpoint response. When refresh is enabled, the cookie expires ahead of the
+// token so that the next request goes through the refresh route rather than presenting a token about
+// to expire.
+func tokenMaxAgeExpression(pol *v1alpha1.OIDCPolicy, token string) string {
+	lifetime := int64(pol.GetSessionLifetime().Seconds())
+	if !pol.IsRefreshEnabled() {
+		return fmt.Sprintf(`"%d"`, lifetime)
+	}
+	before := int64(pol.GetRefreshBefore().Seconds())
+	return fmt.Sprintf(`(has(%[1]s.expires_in) ? string(int(%[1]s.expires_in) > %[2]d ? int(%[1]s.expires_in) - %[2]d : int(%[1]s.expires_in)) : "%[3]d")`, token, before, lifetime)
+}
+
+// tokenCookieExpression returns the CEL expression of the cookie holding the ID token, given the CEL
+// expression of the token endpoint response.
+func tokenCookieExpression(pol *v1alpha1.OIDCPolicy, cookieName string, cookies cookieSettings, token string) string {
+	return fmt.Sprintf(`
+"%s=" + %s.id_token + "; %s; Max-Age=" + %s
+`, cookieName, token, cookies.attributes("/"), tokenMaxAgeExpression(pol, token))
+}
+
+// sessionCookieExpression returns the CEL expression of the session cookie, given the CEL expression
+// of the sealed session. The cookie is cleared when there is no session, and is only ever sent to the
+// refresh route of the policy. It lasts as long as the session may, the session service rejecting the
+// sessions that outlived their lifetime or were idle for too long.
+func sessionCookieExpression(pol *v1alpha1.OIDCPolicy, cookies cookieSettings, sealed string) string {
+	attributes := cookies.attributes(pol.GetRefreshPath())
+	return fmt.Sprintf(`
+%[2]s != null ? "%[1]s=" + %[2]s + "; %[3]s; Max-Age=%[4]d" : "%[1]s=; %[3]s; Max-Age=0"
+`, pol.GetSessionCookieName(), sealed, attributes, int64(pol.GetSessionLifetime().Seconds()))
+}
+
+// sessionServiceMetadata returns the metadata evaluator sending the request to the session service, with the
+// given body parameters on top of the policy the cookies belong to
+func sessionServiceMetadata(pol *v1alpha1.OIDCPolicy, path string, priority int, parameters authorinov1beta3.NamedValuesOrSelectors, conditions ...authorinov1beta3.PatternExpressionOrRef) kuadrantv1.MergeableMetadataSpec {
+	method := authorinov1beta3.HttpMethod("POST")
+	parameters["policy"] = authorinov1beta3.ValueOrSelector{Value: runtime.RawExtension{Raw: []byte(fmt.Sprintf("%q", pol.Namespace+"/"+pol.Name))}}
+	return kuadrantv1.MergeableMetadataSpec{
+		MetadataSpec: authorinov1beta3.MetadataSpec{
+			CommonEvaluatorSpec: authorinov1beta3.CommonEvaluatorSpec{
+				Priority:   priority,
+				Conditions: conditions,
+			},
+			MetadataMethodSpec: authorinov1beta3.MetadataMethodSpec{
+				Http: &authorinov1beta3.HttpEndpointSpec{
+					Url:         sessionServiceURL(path),
+					Method:      &method,
+					Parameters:  parameters,
+					ContentType: "application/json",
+					SharedSecret: &authorinov1beta3.SecretKeyReference{
+						Name: sessionServiceTokenSecretName,
+						Key:  sessionServiceTokenSecretKey,
+					},
+				},
+			},
+		},
+	}
+}
+
+// metadataFieldPredicate returns the CEL predicate telling whether a metadata evaluator yielded the field,
+// which holds false rather than failing when the evaluator was skipped
+func metadataFieldPredicate(metadata, field string) authorinov1beta3.PatternExpressionOrRef {
+	return authorinov1beta3.PatternExpressionOrRef{
+		CelPredicate: authorinov1beta3.CelPredicate{
+			Predicate: fmt.Sprintf(`"%[1]s" in auth.metadata && has(auth.metadata.%[1]s.%[2]s)`, metadata, field),
+		},
+	}
+}
+
+// optionalCookieValueExpression returns the CEL expression of the value of the cookie, or an empty string
+// when the request does not carry the cookie
+func optionalCookieValueExpression(cookieName string) authorinov1beta3.CelExpression {
+	return authorinov1beta3.CelExpression(fmt.Sprintf(`%s ? %s : ""`, hasCookiePredicate(cookieName), cookieValueExpression(cookieName)))
+}
+
+// sealSessionMetadata returns the metadata evaluator sealing the refresh token obtained by the token
+// evaluator into the session, given the CEL expressions of the refresh token and of the start of the session,
+// if it carries on an existing one. The lifetime of the tokens obtained along is sealed as well, the End-User
+// not being idle as long as the tokens are valid.
+func sealSessionMetadata(pol *v1alpha1.OIDCPolicy, priority int, refreshToken, issuedAt string) kuadrantv1.MergeableMetadataSpec {
+	parameters := authorinov1beta3.NamedValuesOrSelectors{
+		"refreshToken": {Expression: authorinov1beta3.CelExpression(refreshToken)},
+		"expiresIn":    {Expression: "has(auth.metadata.token.expires_in) ? double(auth.metadata.token.expires_in) : 0.0"},
+	}
+	if issuedAt != "" {
+		parameters["issuedAt"] = authorinov1beta3.ValueOrSelector{Expression: authorinov1beta3.CelExpression(issuedAt)}
+	}
+	return sessionServiceMetadata(pol, sessionServiceSealSessionPath, priority, parameters, metadataFieldPredicate("token", "id_token"))
+}
+
+// buildCallbackSessionRule returns the Rego rule exposing the session sealed by the callback, if any
+func buildCallbackSessionRule() string {
+	return `session_cookie := input.auth.metadata.sealed.session { input.auth.metadata.sealed.session }
+session_cookie := null { not input.auth.metadata.sealed.session }`
+}
+
+// buildRefreshOpaAuthorizationRule returns the Rego rule of the refresh route, which exposes the refreshed
+// tokens and the session sealed again, as the IDP may have rotated the refresh token. On success, the user is
+// sent back to the target of the original request. Otherwise, e.g. when the session outlived its lifetime,
+// the user is sent through the login flow.
+func buildRefreshOpaAuthorizationRule(baseURL, igwURL *url.URL, loginURL string) string {
+	return fmt.Sprintf(`%s
+refreshed { input.auth.metadata.token.id_token }
+sealed { input.auth.metadata.sealed.session }
+token := input.auth.metadata.token { refreshed }
+token := null { not refreshed }
+id_token := input.auth.metadata.token.id_token { refreshed }
+id_token := null { not refreshed }
+session_cookie := input.auth.metadata.sealed.session { sealed }
+session_cookie := null { not sealed }
+location := concat("", ["%s", cookies.target]) { refreshed; cookies.target }
+location := "%s" { refreshed; not cookies.target }
+location := "%s" { not refreshed }
+allow = true`, regoCookiesRule, baseURL, igwURL, loginURL)
+}
+
+func buildRefreshHTTPRoute(pol *v1alpha1.OIDCPolicy, igw *ingressGatewayInfo) *gatewayapiv1.HTTPRoute {
+	return buildFlowHTTPRoute(pol, igw, pol.Name+"-refresh", pol.GetRefreshPath())
+}
+
+// buildRefreshAuthPolicy returns the AuthPolicy of the refresh route, which opens the session with the session
+// service, refreshes the tokens with the refresh token it holds and seals the session again
+func buildRefreshAuthPolicy(pol *v1alpha1.OIDCPolicy, igw *ingressGatewayInfo) (*kuadrantv1.AuthPolicy, error) {
+	igwURL := igw.GetURL()
+	tokenRequestURL, err := pol.GetTokenRequestURL()
+	if err != nil {
+		return nil, err
+	}
+	loginURL, err := pol.GetLoginURL(igwURL)
+	if err != nil {
+		return nil, err
+	}
+	baseURL, err := pol.GetBaseURL(igwURL)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshRoute := gatewayapiv1alpha2.LocalPolicyTargetReference{
+		Group: gatewayapiv1alpha2.GroupName,
+		Kind:  gatewayapiv1alpha2.Kind("HTTPRoute"),
+		Name:  gatewayapiv1alpha2.ObjectName(pol.Name + "-refresh"),
+	}
+
+	refreshMethod := authorinov1beta3.HttpMethod("POST")
+	refreshParameters := authorinov1beta3.NamedValuesOrSelectors{
+		"grant_type":    {Value: runtime.RawExtension{Raw: []byte(`"refresh_token"`)}},
+		"client_id":     {Value: runtime.RawExtension{Raw: []byte(fmt.Sprintf("%q", pol.Spec.Provider.ClientID))}},
+		"refresh_token": {Expression: "auth.metadata.session.refreshToken"},
+	}
+
+	cookies := newCookieSettings(pol, igw)
+	tokenCookieName := pol.GetTokenSource().Cookie.Name
+	refreshed := "auth.authorization.refresh"
+	tokenCookie := fmt.Sprintf(`%s.id_token != null ? %s : "%s=; %s; Max-Age=0"`, refreshed,
+		tokenCookieExpression(pol, tokenCookieName, cookies, refreshed+".token"), tokenCookieName, cookies.attributes("/"))
+
+	return &kuadrantv1.AuthPolicy{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "AuthPolicy",
+			APIVersion: kuadrantv1.GroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pol.Name + "-refresh",
+			Namespace: pol.Namespace,
+		},
+		Spec: kuadrantv1.AuthPolicySpec{
+			TargetRef: gatewayapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+				LocalPolicyTargetReference: refreshRoute,
+			},
+			Overrides: &kuadrantv1.MergeableAuthPolicySpec{
+				Strategy: "merge",
+				AuthPolicySpecProper: kuadrantv1.AuthPolicySpecProper{
+					AuthScheme: &kuadrantv1.AuthSchemeSpec{
+						Metadata: map[string]kuadrantv1.MergeableMetadataSpec{
+							"session": sessionServiceMetadata(pol, sessionServiceOpenSessionPath, 0, authorinov1beta3.NamedValuesOrSelectors{
+								"session": {Expression: optionalCookieValueExpression(pol.GetSessionCookieName())},
+							}),
+							"token": {
+								MetadataSpec: authorinov1beta3.MetadataSpec{
+									CommonEvaluatorSpec: authorinov1beta3.CommonEvaluatorSpec{
+										Priority:   1,
+										Conditions: []authorinov1beta3.PatternExpressionOrRef{metadataFieldPredicate("session", "refreshToken")},
+									},
+									MetadataMethodSpec: authorinov1beta3.MetadataMethodSpec{
+										Http: &authorinov1beta3.HttpEndpointSpec{
+											Url:          tokenRequestURL,
+											Method:       &refreshMethod,
+											Parameters:   refreshParameters,
+											SharedSecret: clientCredentialsSecretRef(pol),
+											Credentials:  clientCredentials(pol),
+										},
+									},
+								},
+							},
+							"sealed": sealSessionMetadata(pol, 2,
+								"has(auth.metadata.token.refresh_token) ? auth.metadata.token.refresh_token : auth.metadata.session.refreshToken",
+								"auth.metadata.session.issuedAt"),
+						},
+						Authorization: map[string]kuadrantv1.MergeableAuthorizationSpec{
+							"refresh": {
+								AuthorizationSpec: authorinov1beta3.AuthorizationSpec{
+									AuthorizationMethodSpec: authorinov1beta3.AuthorizationMethodSpec{
+										Opa: &authorinov1beta3.OpaAuthorizationSpec{
+											Rego:      buildRefreshOpaAuthorizationRule(baseURL, igwURL, loginURL),
+											AllValues: true,
+										},
+									},
+									CommonEvaluatorSpec: authorinov1beta3.CommonEvaluatorSpec{
+										Priority: 1,
+									},
+								},
+							},
+							"deny": {
+								AuthorizationSpec: authorinov1beta3.AuthorizationSpec{
+									AuthorizationMethodSpec: authorinov1beta3.AuthorizationMethodSpec{
+										Opa: &authorinov1beta3.OpaAuthorizationSpec{
+											Rego: "allow = false",
+										},
+									},
+									CommonEvaluatorSpec: authorinov1beta3.CommonEvaluatorSpec{
+										Priority: 2,
+									},
+								},
+							},
+						},
+						Response: &kuadrantv1.MergeableResponseSpec{
+							Unauthorized: &kuadrantv1.MergeableDenyWithSpec{
+								DenyWithSpec: authorinov1beta3.DenyWithSpec{
+									Code: 302,
+									Headers: map[string]authorinov1beta3.ValueOrSelector{
+										"location":          {Expression: "auth.authorization.refresh.location"},
+										"set-cookie":        {Expression: authorinov1beta3.CelExpression(tokenCookie)},
+										sessionCookieHeader: {Expression: authorinov1beta3.CelExpression(sessionCookieExpression(pol, cookies, refreshed+".session_cookie"))},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+// validateSession checks the session settings can be enforced with the configured token source and client
+func validateSession(pol *v1alpha1.OIDCPolicy) error {
+	if !pol.IsRefreshEnabled() {
+		return nil
+	}
+	if pol.GetTokenSource().GetType() != authorinov1beta3.CookieCredentials {
+		return fmt.Errorf("session refresh requires the token source to be a cookie")
+	}
+	return nil
+}
+
+// reconcileSessionService registers the key sealing the session and PKCE cookies of the policy with the
+// session service, along with the token Authorino authenticates to the service with
+func (r *OIDCPolicyReconciler) reconcileSessionService(ctx context.Context, pol *v1alpha1.OIDCPolicy) error {
+	key, err := r.reconcileSessionKey(ctx, pol)
+	if err != nil {
+		return err
+	}
+	if err := r.sessions.Register(pol, key); err != nil {
+		return err
+	}
+	token, err := r.reconcileSessionServiceToken(ctx)
+	if err != nil {
+		return err
+	}
+	r.sessions.SetToken(token)
+	return nil
+}
+
+// reconcileSessionServiceToken returns the token Authorino authenticates to the session service with. It is
+// generated once and kept in a Secret in the namespace of Authorino, which reads it from there, and is shared
+// by all the policies.
+func (r *OIDCPolicyReconciler) reconcileSessionServiceToken(ctx context.Context) ([]byte, error) {
+	desired := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: corev1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      sessionServiceTokenSecretName,
+			Namespace: authorinoNamespace(),
+		},
+		Type: corev1.SecretTypeOpaque,
+	}
+
+	existing := &corev1.Secret{}
+	err := r.Client.Get(ctx, client.ObjectKeyFromObject(desired), existing)
+	switch {
+	case err == nil && len(existing.Data[sessionServiceTokenSecretKey]) > 0:
+		return existing.Data[sessionServiceTokenSecretKey], nil
+	case err == nil || errors.IsNotFound(err):
+		desired.Data = map[string][]byte{sessionServiceTokenSecretKey: []byte(hex.EncodeToString(randomBytes(sessionServiceTokenLength)))}
+	default:
+		return nil, err
+	}
+
+	if _, err := r.kCtx.ReconcileObject(ctx, &corev1.Secret{}, desired, secretDataMutator); err != nil {
+		r.Logger.Error(err, "Error reconciling OIDC session service token Secret")
+		return nil, err
+	}
+	return desired.Data[sessionServiceTokenSecretKey], nil
+}
+
+func sessionKeySecretName(pol *v1alpha1.OIDCPolicy) string {
+	return fmt.Sprintf("%s-%s-session", pol.Namespace, pol.Name)
+}
+
+// reconcileSessionKey returns the key sealing the session and PKCE cookies, which is only ever read by the
+// session service. Unless the policy references a key of its own, a key is generated once and kept in a
+// Secret in the namespace of Authorino, alongside the other Secrets of the policy, which is deleted along
+// with the policy.
+func (r *OIDCPolicyReconciler) reconcileSessionKey(ctx context.Context, pol *v1alpha1.OIDCPolicy) ([]byte, error) {
+	if pol.IsRefreshEnabled() && pol.Spec.Session.Refresh.EncryptionKeyRef != nil {
+		ref := pol.Spec.Session.Refresh.EncryptionKeyRef
+		secret := &corev1.Secret{}
+		if err := r.Client.Get(ctx, client.ObjectKey{Namespace: pol.Namespace, Name: ref.Name}, secret); err != nil {
+			return nil, fmt.Errorf("failed to get session encryption key: %w", err)
+		}
+		key := secret.Data[ref.Key]
+		if len(key) < minSessionKeyLength {
+			return nil, fmt.Errorf("session encryption key %s/%s must be at least %d bytes long", ref.Name, ref.Key, minSessionKeyLength)
+		}
+		return key, nil
+	}
+
+	desired := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: corev1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      sessionKeySecretName(pol),
+			Namespace: authorinoNamespace(),
+		},
+		Type: corev1.SecretTypeOpaque,
+	}
+
+	// the key is kept across reconciles, as rotating it ends every session
+	existing := &corev1.Secret{}
+	err := r.Client.Get(ctx, client.ObjectKeyFromObject(desired), existing)
+	switch {
+	case err == nil && len(existing.Data[sessionKeySecretKey]) >= minSessionKeyLength:
+		desired.Data = map[string][]byte{sessionKeySecretKey: existing.Data[sessionKeySecretKey]}
+	case err == nil || errors.IsNotFound(err):
+		desired.Data = map[string][]byte{sessionKeySecretKey: randomBytes(minSessionKeyLength)}
+	default:
+		return nil, err
+	}
+
+	if err := r.addSecretsFinalizer(ctx, pol); err != nil {
+		return nil, err
+	}
+	if _, err := r.kCtx.ReconcileObject(ctx, &corev1.Secret{}, desired, secretDataMutator); err != nil {
+		r.Logger.Error(err, "Error reconciling OIDC session Secret")
+		return nil, err
+	}
+
+	return desired.Data[sessionKeySecretKey], nil
+}
+
+func (r *OIDCPolicyReconciler) reconcileRefreshHTTPRoute(ctx context.Context, pol *v1alpha1.OIDCPolicy, igw *ingressGatewayInfo) error {
+	return r.reconcileFlowHTTPRoute(ctx, pol, buildRefreshHTTPRoute(pol, igw), "Refresh")
+}
+
+func (r *OIDCPolicyReconciler) reconcileRefreshAuthPolicy(ctx context.Context, pol *v1alpha1.OIDCPolicy, igw *ingressGatewayInfo) (*kuadrantv1.AuthPolicy, error) {
+	desiredAuthPol, err := buildRefreshAuthPolicy(pol, igw)
+	if err != nil {
+		return nil, err
+	}
+	return r.reconcileFlowAuthPolicy(ctx, pol, desiredAuthPol, "Refresh")
+}
+
+func secretDataMutator(existingObj, desiredObj client.Object) (bool, error) {
+	existing, ok := existingObj.(*corev1.Secret)
+	if !ok {
+		return false, fmt.Errorf("%T is not a *corev1.Secret", existingObj)
+	}
+	desired, ok := desiredObj.(*corev1.Secret)
+	if !ok {
+		return false, fmt.Errorf("%T is not a *corev1.Secret", desiredObj)
+	}
+
+	if maps.EqualFunc(existing.Data, desired.Data, func(a, b []byte) bool { return string(a) == string(b) }) {
+		return false, nil
+	}
+	existing.Data = desired.Data
+	return true, nil
+}
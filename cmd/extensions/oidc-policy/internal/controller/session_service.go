@@ -0,0 +1,371 @@
+package controller
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/env"
+
+	"github.com/kuadrant/kuadrant-operator/cmd/extensions/oidc-policy/api/v1alpha1"
+)
+
+const (
+	// the port differs from the pprof endpoint of the operator, which runs in the same pod
+	defaultSessionServicePort = 8086
+	// sessionServiceName is the name of the Service Authorino reaches the session service through
+	sessionServiceName = "kuadrant-operator-oidc-session"
+
+	// sessionServiceTokenSecretName is the Secret, in the namespace of Authorino, holding the token Authorino
+	// authenticates to the session service with
+	sessionServiceTokenSecretName = "kuadrant-oidc-session-service"
+	sessionServiceTokenSecretKey  = "token"
+	sessionServiceTokenLength     = 32
+
+	sessionServiceSealSessionPath = "/session/seal"
+	sessionServiceOpenSessionPath = "/session/open"
+	sessionServiceNewPKCEPath     = "/pkce/new"
+	sessionServiceOpenPKCEPath    = "/pkce/open"
+
+	// the purposes of the sealed cookies, authenticated along with the policy so that a cookie sealed for
+	// one purpose or policy cannot be opened for another
+	sealPurposeSession = "session"
+	sealPurposePKCE    = "pkce"
+
+	sessionCipherKeyInfo   = "kuadrant.io/oidc-policy session"
+	pkceCodeVerifierLength = 32
+	maxSessionRequestBytes = 16 << 10
+)
+
+// sessionServiceURL returns the URL of the session service as seen by Authorino, which lives in the same
+// namespace as the operator
+func sessionServiceURL(path string) string {
+	return fmt.Sprintf("http://%s.%s.svc.cluster.local:%d%s", sessionServiceName, authorinoNamespace(), defaultSessionServicePort, path)
+}
+
+// sessionPolicy holds the cipher sealing the cookies of a policy, the lifetime of its sessions and how long they
+// may be idle
+type sessionPolicy struct {
+	aead        cipher.AEAD
+	lifetime    time.Duration
+	idleTimeout time.Duration
+}
+
+// SessionService seals and opens the cookies of the OIDC flow that the End-User must not be able to read
+// nor forge, i.e. the session holding the refresh token and the PKCE code verifier. The cookies are
+// encrypted with AES-256-GCM under a key derived from the session key of each policy. Authorino calls the
+// service along the flow, authenticated by a token it reads from a Secret, so that the keys never show in
+// the AuthPolicies.
+type SessionService struct {
+	logger logr.Logger
+	now    func() time.Time
+
+	mu       sync.RWMutex
+	token    []byte
+	policies map[k8stypes.NamespacedName]sessionPolicy
+}
+
+func NewSessionService(logger logr.Logger) *SessionService {
+	return &SessionService{
+		logger:   logger.WithName("SessionService"),
+		now:      time.Now,
+		policies: make(map[k8stypes.NamespacedName]sessionPolicy),
+	}
+}
+
+// SetToken sets the token Authorino authenticates to the service with
+func (s *SessionService) SetToken(token []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = token
+}
+
+// Register sets the key sealing the cookies of a policy, the lifetime of its sessions and how long they may be idle
+func (s *SessionService) Register(pol *v1alpha1.OIDCPolicy, key []byte) error {
+	aead, err := newSessionCipher(key)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.policies[k8stypes.NamespacedName{Namespace: pol.Namespace, Name: pol.Name}] = sessionPolicy{
+		aead:        aead,
+		lifetime:    pol.GetSessionLifetime(),
+		idleTimeout: pol.GetSessionIdleTimeout(),
+	}
+	return nil
+}
+
+// Unregister forgets the key of a policy, after which its cookies cannot be opened anymore
+func (s *SessionService) Unregister(policy k8stypes.NamespacedName) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.policies, policy)
+}
+
+// Run serves the session service until the context is done. It returns an error when the service cannot be
+// served, e.g. because its port is taken, as the refresh and PKCE flows do not work without it.
+func (s *SessionService) Run(ctx context.Context) error {
+	port, err := env.GetInt("OIDC_SESSION_SERVICE_PORT", defaultSessionServicePort)
+	if err != nil {
+		s.logger.Error(err, "invalid OIDC_SESSION_SERVICE_PORT, using default", "default", defaultSessionServicePort)
+		port = defaultSessionServicePort
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return fmt.Errorf("failed to listen for session service: %w", err)
+	}
+
+	server := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		s.logger.Info("starting session service", "port", port)
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
+	}()
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("session service failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	s.logger.Info("stopping session service")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		s.logger.Error(err, "session service shutdown error")
+	}
+	return nil
+}
+
+// Handler returns the HTTP handler of the session service. Every endpoint takes and returns a JSON object.
+// Cookies that cannot be opened, e.g. tampered with, sealed for another policy or expired, yield an empty
+// object, so that Authorino carries on with the flow as if there were no cookie.
+func (s *SessionService) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+sessionServiceSealSessionPath, s.handle(s.sealSession))
+	mux.HandleFunc("POST "+sessionServiceOpenSessionPath, s.handle(s.openSession))
+	mux.HandleFunc("POST "+sessionServiceNewPKCEPath, s.handle(s.newPKCE))
+	mux.HandleFunc("POST "+sessionServiceOpenPKCEPath, s.handle(s.openPKCE))
+	return mux
+}
+
+// sessionServiceRequest is the body of the requests to the session service
+type sessionServiceRequest struct {
+	// Policy is the namespace/name of the OIDCPolicy the cookie belongs to
+	Policy string `json:"policy"`
+	// RefreshToken is the refresh token to seal into the session
+	RefreshToken string `json:"refreshToken,omitempty"`
+	// IssuedAt is the time, in seconds since the epoch, the session started at. It is kept when the session
+	// is sealed again after a refresh; defaults to now.
+	IssuedAt float64 `json:"issuedAt,omitempty"`
+	// ExpiresIn is the lifetime, in seconds, of the tokens obtained along with the refresh token, during which
+	// the End-User is not idle; defaults to the lifetime of the session.
+	ExpiresIn float64 `json:"expiresIn,omitempty"`
+	// Session is the sealed session to open
+	Session string `json:"session,omitempty"`
+	// Cookie is the sealed PKCE cookie to open
+	Cookie string `json:"cookie,omitempty"`
+}
+
+// sealedSession is the content of the session cookie. ActiveUntil is the time the tokens obtained along with
+// the refresh token expire at, from which on the session is idle until refreshed.
+type sealedSession struct {
+	RefreshToken string `json:"rt"`
+	IssuedAt     int64  `json:"iat"`
+	ActiveUntil  int64  `json:"act"`
+}
+
+// sealedPKCE is the content of the PKCE cookie
+type sealedPKCE struct {
+	Verifier string `json:"v"`
+	IssuedAt int64  `json:"iat"`
+}
+
+type sessionServiceHandler func(policy k8stypes.NamespacedName, settings sessionPolicy, request sessionServiceRequest) (map[string]any, error)
+
+func (s *SessionService) handle(handler sessionServiceHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.authenticated(r) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var request sessionServiceRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSessionRequestBytes)).Decode(&request); err != nil {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+
+		response := map[string]any{}
+		namespace, name, _ := strings.Cut(request.Policy, "/")
+		policy := k8stypes.NamespacedName{Namespace: namespace, Name: name}
+		s.mu.RLock()
+		settings, found := s.policies[policy]
+		s.mu.RUnlock()
+		if found {
+			var err error
+			if response, err = handler(policy, settings, request); err != nil {
+				s.logger.V(1).Info("failed to handle session request", "path", r.URL.Path, "policy", request.Policy, "error", err.Error())
+				response = map[string]any{}
+			}
+		} else {
+			s.logger.V(1).Info("unknown policy", "path", r.URL.Path, "policy", request.Policy)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			s.logger.Error(err, "failed to write session response")
+		}
+	}
+}
+
+// authenticated tells whether the request carries the token shared with Authorino as a bearer token
+func (s *SessionService) authenticated(r *http.Request) bool {
+	s.mu.RLock()
+	token := s.token
+	s.mu.RUnlock()
+	presented, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return len(token) > 0 && found && subtle.ConstantTimeCompare([]byte(presented), token) == 1
+}
+
+func (s *SessionService) sealSession(policy k8stypes.NamespacedName, settings sessionPolicy, request sessionServiceRequest) (map[string]any, error) {
+	if request.RefreshToken == "" {
+		return nil, errors.New("missing refresh token")
+	}
+	now := s.now()
+	issuedAt := int64(request.IssuedAt)
+	if issuedAt == 0 {
+		issuedAt = now.Unix()
+	}
+	expiresIn := time.Duration(request.ExpiresIn) * time.Second
+	if expiresIn <= 0 {
+		expiresIn = settings.lifetime
+	}
+	sealed, err := seal(settings.aead, sealPurposeSession, policy, sealedSession{
+		RefreshToken: request.RefreshToken,
+		IssuedAt:     issuedAt,
+		ActiveUntil:  now.Add(expiresIn).Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"session": sealed}, nil
+}
+
+// openSession returns the refresh token of the session, as long as the session has not outlived its lifetime
+// nor been idle for longer than the idle timeout. The End-User is active as long as the tokens last obtained
+// are valid, and any request past their expiry goes through the refresh route, which seals the session again.
+func (s *SessionService) openSession(policy k8stypes.NamespacedName, settings sessionPolicy, request sessionServiceRequest) (map[string]any, error) {
+	var session sealedSession
+	if err := open(settings.aead, sealPurposeSession, policy, request.Session, &session); err != nil {
+		return nil, err
+	}
+	now := s.now()
+	if now.Sub(time.Unix(session.IssuedAt, 0)) >= settings.lifetime {
+		return nil, errors.New("session outlived its lifetime")
+	}
+	if now.Sub(time.Unix(session.ActiveUntil, 0)) >= settings.idleTimeout {
+		return nil, errors.New("session idle for too long")
+	}
+	return map[string]any{"refreshToken": session.RefreshToken, "issuedAt": session.IssuedAt}, nil
+}
+
+// newPKCE generates a code verifier (RFC 7636, Section 4.1) and returns its S256 code challenge along with
+// the verifier sealed into the PKCE cookie
+func (s *SessionService) newPKCE(policy k8stypes.NamespacedName, settings sessionPolicy, _ sessionServiceRequest) (map[string]any, error) {
+	verifier := base64.RawURLEncoding.EncodeToString(randomBytes(pkceCodeVerifierLength))
+	challenge := sha256.Sum256([]byte(verifier))
+	cookie, err := seal(settings.aead, sealPurposePKCE, policy, sealedPKCE{Verifier: verifier, IssuedAt: s.now().Unix()})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"challenge": base64.RawURLEncoding.EncodeToString(challenge[:]), "cookie": cookie}, nil
+}
+
+// openPKCE returns the code verifier sealed into the PKCE cookie, as long as the cookie has not expired
+func (s *SessionService) openPKCE(policy k8stypes.NamespacedName, settings sessionPolicy, request sessionServiceRequest) (map[string]any, error) {
+	var pkce sealedPKCE
+	if err := open(settings.aead, sealPurposePKCE, policy, request.Cookie, &pkce); err != nil {
+		return nil, err
+	}
+	if s.now().Sub(time.Unix(pkce.IssuedAt, 0)) >= v1alpha1.DefaultPKCECookieMaxAge {
+		return nil, errors.New("pkce cookie expired")
+	}
+	return map[string]any{"verifier": pkce.Verifier}, nil
+}
+
+// newSessionCipher returns the AES-256-GCM cipher keyed with a key derived from the session key by HKDF-SHA256
+func newSessionCipher(key []byte) (cipher.AEAD, error) {
+	if len(key) < minSessionKeyLength {
+		return nil, fmt.Errorf("session key must be at least %d bytes long", minSessionKeyLength)
+	}
+	derived, err := hkdf.Key(sha256.New, key, nil, sessionCipherKeyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(derived)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts the JSON encoding of the value with a random nonce, authenticating the purpose and the policy
+// along with it, and returns the nonce and the ciphertext base64url encoded
+func seal(aead cipher.AEAD, purpose string, policy k8stypes.NamespacedName, value any) (string, error) {
+	plaintext, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	nonce := randomBytes(aead.NonceSize())
+	return base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, plaintext, sealAdditionalData(purpose, policy))), nil
+}
+
+// open decrypts a value sealed for the purpose and the policy into the value pointed to
+func open(aead cipher.AEAD, purpose string, policy k8stypes.NamespacedName, sealed string, value any) error {
+	data, err := base64.RawURLEncoding.DecodeString(sealed)
+	if err != nil {
+		return err
+	}
+	if len(data) < aead.NonceSize() {
+		return errors.New("sealed value too short")
+	}
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], sealAdditionalData(purpose, policy))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(plaintext, value)
+}
+
+func sealAdditionalData(purpose string, policy k8stypes.NamespacedName) []byte {
+	return []byte(purpose + ":" + policy.String())
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	_, _ = rand.Read(b) // never returns an error
+	return b
+}
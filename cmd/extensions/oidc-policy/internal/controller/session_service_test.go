@@ -0,0 +1,194 @@
+//go:build unit
+
+package controller
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+
+	"github.com/kuadrant/kuadrant-operator/cmd/extensions/oidc-policy/api/v1alpha1"
+)
+
+func testSessionService(t *testing.T, now *time.Time) (*SessionService, *v1alpha1.OIDCPolicy) {
+	t.Helper()
+	sessions := NewSessionService(logr.Discard())
+	sessions.now = func() time.Time { return *now }
+	sessions.SetToken([]byte("secret-token"))
+
+	pol := sessionTestPolicy(&v1alpha1.Session{
+		Lifetime: &metav1.Duration{Duration: time.Hour},
+		Refresh:  &v1alpha1.SessionRefresh{},
+	})
+	if err := sessions.Register(pol, []byte(strings.Repeat("k", minSessionKeyLength))); err != nil {
+		t.Fatal(err)
+	}
+	return sessions, pol
+}
+
+func callSessionService(t *testing.T, sessions *SessionService, path, token string, body map[string]any) (int, map[string]any) {
+	t.Helper()
+	payload, _ := json.Marshal(body)
+	request := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(payload))
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	recorder := httptest.NewRecorder()
+	sessions.Handler().ServeHTTP(recorder, request)
+	response := map[string]any{}
+	if recorder.Code == http.StatusOK {
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return recorder.Code, response
+}
+
+func TestSessionService_Authentication(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	sessions, _ := testSessionService(t, &now)
+
+	for _, token := range []string{"", "wrong-token"} {
+		if code, _ := callSessionService(t, sessions, sessionServiceNewPKCEPath, token, map[string]any{"policy": "default/oidc"}); code != http.StatusUnauthorized {
+			t.Errorf("expected request with token %q to be rejected, got %d", token, code)
+		}
+	}
+
+	sessions.SetToken(nil)
+	if code, _ := callSessionService(t, sessions, sessionServiceNewPKCEPath, "", map[string]any{"policy": "default/oidc"}); code != http.StatusUnauthorized {
+		t.Errorf("expected requests to be rejected until the token is set, got %d", code)
+	}
+}
+
+func TestSessionService_Session(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	sessions, pol := testSessionService(t, &now)
+	policy := pol.Namespace + "/" + pol.Name
+
+	_, sealed := callSessionService(t, sessions, sessionServiceSealSessionPath, "secret-token", map[string]any{"policy": policy, "refreshToken": "rt-1"})
+	session, ok := sealed["session"].(string)
+	if !ok || session == "" || strings.Contains(session, "rt-1") {
+		t.Fatalf("expected a sealed session, got %v", sealed)
+	}
+
+	_, opened := callSessionService(t, sessions, sessionServiceOpenSessionPath, "secret-token", map[string]any{"policy": policy, "session": session})
+	if opened["refreshToken"] != "rt-1" || opened["issuedAt"] != float64(now.Unix()) {
+		t.Errorf("unexpected opened session %v", opened)
+	}
+
+	// sealing the session again after a refresh keeps its start
+	now = now.Add(30 * time.Minute)
+	_, resealed := callSessionService(t, sessions, sessionServiceSealSessionPath, "secret-token", map[string]any{"policy": policy, "refreshToken": "rt-2", "issuedAt": opened["issuedAt"]})
+	_, opened = callSessionService(t, sessions, sessionServiceOpenSessionPath, "secret-token", map[string]any{"policy": policy, "session": resealed["session"]})
+	if opened["refreshToken"] != "rt-2" || opened["issuedAt"] != float64(now.Add(-30*time.Minute).Unix()) {
+		t.Errorf("unexpected opened session %v", opened)
+	}
+
+	invalid := []struct {
+		name string
+		body map[string]any
+	}{
+		{name: "tampered", body: map[string]any{"policy": policy, "session": session[:len(session)-2] + "AA"}},
+		{name: "other policy", body: map[string]any{"policy": "default/other", "session": session}},
+		{name: "malformed", body: map[string]any{"policy": policy, "session": "not-a-session"}},
+		{name: "missing", body: map[string]any{"policy": policy, "session": ""}},
+	}
+	for _, tc := range invalid {
+		if code, opened := callSessionService(t, sessions, sessionServiceOpenSessionPath, "secret-token", tc.body); code != http.StatusOK || len(opened) != 0 {
+			t.Errorf("%s: expected an empty response, got %d %v", tc.name, code, opened)
+		}
+	}
+
+	// the session outlives its lifetime regardless of refreshes
+	now = now.Add(30 * time.Minute)
+	if _, opened := callSessionService(t, sessions, sessionServiceOpenSessionPath, "secret-token", map[string]any{"policy": policy, "session": resealed["session"]}); len(opened) != 0 {
+		t.Errorf("expected the expired session not to be opened, got %v", opened)
+	}
+
+	// sessions cannot be opened once the policy is gone
+	sessions.Unregister(k8stypes.NamespacedName{Namespace: pol.Namespace, Name: pol.Name})
+	if _, opened := callSessionService(t, sessions, sessionServiceOpenSessionPath, "secret-token", map[string]any{"policy": policy, "session": session}); len(opened) != 0 {
+		t.Errorf("expected the session of an unknown policy not to be opened, got %v", opened)
+	}
+}
+
+func TestSessionService_IdleTimeout(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	sessions := NewSessionService(logr.Discard())
+	sessions.now = func() time.Time { return now }
+	sessions.SetToken([]byte("secret-token"))
+	pol := sessionTestPolicy(&v1alpha1.Session{
+		Lifetime:    &metav1.Duration{Duration: 8 * time.Hour},
+		IdleTimeout: &metav1.Duration{Duration: 15 * time.Minute},
+		Refresh:     &v1alpha1.SessionRefresh{},
+	})
+	if err := sessions.Register(pol, []byte(strings.Repeat("k", minSessionKeyLength))); err != nil {
+		t.Fatal(err)
+	}
+	policy := pol.Namespace + "/" + pol.Name
+
+	_, sealed := callSessionService(t, sessions, sessionServiceSealSessionPath, "secret-token", map[string]any{"policy": policy, "refreshToken": "rt-1", "expiresIn": 300})
+
+	// the idle timeout only runs from the expiry of the tokens, during which the End-User is active
+	now = now.Add(19 * time.Minute)
+	_, opened := callSessionService(t, sessions, sessionServiceOpenSessionPath, "secret-token", map[string]any{"policy": policy, "session": sealed["session"]})
+	if opened["refreshToken"] != "rt-1" {
+		t.Fatalf("expected the session to be opened before the idle timeout, got %v", opened)
+	}
+
+	// refreshing the session keeps it active
+	_, resealed := callSessionService(t, sessions, sessionServiceSealSessionPath, "secret-token", map[string]any{"policy": policy, "refreshToken": "rt-2", "issuedAt": opened["issuedAt"], "expiresIn": 300})
+	now = now.Add(10 * time.Minute)
+	if _, opened = callSessionService(t, sessions, sessionServiceOpenSessionPath, "secret-token", map[string]any{"policy": policy, "session": resealed["session"]}); opened["refreshToken"] != "rt-2" {
+		t.Errorf("expected the refreshed session to be opened, got %v", opened)
+	}
+
+	// the session is idle once it has not been refreshed for the idle timeout past the expiry of the tokens
+	if _, opened = callSessionService(t, sessions, sessionServiceOpenSessionPath, "secret-token", map[string]any{"policy": policy, "session": sealed["session"]}); len(opened) != 0 {
+		t.Errorf("expected the idle session not to be opened, got %v", opened)
+	}
+}
+
+func TestSessionService_PKCE(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	sessions, pol := testSessionService(t, &now)
+	policy := pol.Namespace + "/" + pol.Name
+
+	_, pkce := callSessionService(t, sessions, sessionServiceNewPKCEPath, "secret-token", map[string]any{"policy": policy})
+	cookie, _ := pkce["cookie"].(string)
+	_, opened := callSessionService(t, sessions, sessionServiceOpenPKCEPath, "secret-token", map[string]any{"policy": policy, "cookie": cookie})
+	verifier, _ := opened["verifier"].(string)
+	if len(verifier) < 43 || strings.Contains(cookie, verifier) {
+		t.Fatalf("unexpected code verifier %q sealed into %q", verifier, cookie)
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+	if pkce["challenge"] != base64.RawURLEncoding.EncodeToString(challenge[:]) {
+		t.Errorf("expected the S256 challenge of the verifier, got %v", pkce["challenge"])
+	}
+
+	// a pkce cookie does not open as a session
+	if _, opened := callSessionService(t, sessions, sessionServiceOpenSessionPath, "secret-token", map[string]any{"policy": policy, "session": cookie}); len(opened) != 0 {
+		t.Errorf("expected the pkce cookie not to open as a session, got %v", opened)
+	}
+
+	now = now.Add(v1alpha1.DefaultPKCECookieMaxAge)
+	if _, opened := callSessionService(t, sessions, sessionServiceOpenPKCEPath, "secret-token", map[string]any{"policy": policy, "cookie": cookie}); len(opened) != 0 {
+		t.Errorf("expected the expired pkce cookie not to be opened, got %v", opened)
+	}
+}
+
+func TestNewSessionCipher(t *testing.T) {
+	if _, err := newSessionCipher([]byte("short")); err == nil {
+		t.Error("expected short keys to be rejected")
+	}
+}
@@ -0,0 +1,296 @@
+//go:build unit
+
+package controller
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	authorinov1beta3 "github.com/kuadrant/authorino/api/v1beta3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/kuadrant/kuadrant-operator/cmd/extensions/oidc-policy/api/v1alpha1"
+)
+
+func evalCookieExpression(t *testing.T, expression string, auth map[string]interface{}) string {
+	t.Helper()
+	env, err := cel.NewEnv(cel.Variable("auth", cel.DynType))
+	if err != nil {
+		t.Fatalf("cel.NewEnv failed: %v", err)
+	}
+	ast, iss := env.Compile(expression)
+	if iss != nil && iss.Err() != nil {
+		t.Fatalf("compile failed for expression %q: %v", expression, iss.Err())
+	}
+	prg, err := env.Program(ast)
+	if err != nil {
+		t.Fatalf("program failed: %v", err)
+	}
+	out, _, err := prg.Eval(map[string]interface{}{"auth": auth})
+	if err != nil {
+		t.Fatalf("eval failed: %v", err)
+	}
+	got, ok := out.Value().(string)
+	if !ok {
+		t.Fatalf("expression did not return a string, got %T", out.Value())
+	}
+	return got
+}
+
+func sessionTestPolicy(session *v1alpha1.Session) *v1alpha1.OIDCPolicy {
+	return &v1alpha1.OIDCPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "oidc", Namespace: "default"},
+		Spec: v1alpha1.OIDCPolicySpec{
+			OIDCPolicySpecProper: v1alpha1.OIDCPolicySpecProper{
+				Provider: &v1alpha1.Provider{
+					IssuerURL: "https://issuer.com",
+					ClientID:  "client 123",
+				},
+				Session: session,
+			},
+		},
+	}
+}
+
+var sessionTestGateway = &ingressGatewayInfo{
+	Hostname:  "example.com",
+	Name:      "gateway",
+	Namespace: "gateway-system",
+	Protocol:  gatewayapiv1.HTTPSProtocolType,
+	Port:      443,
+}
+
+func TestTokenCookieExpression(t *testing.T) {
+	token := map[string]interface{}{"metadata": map[string]interface{}{"token": map[string]interface{}{"id_token": "abc", "expires_in": float64(300)}}}
+
+	pol := sessionTestPolicy(nil)
+	cookie := evalCookieExpression(t, tokenCookieExpression(pol, "jwt", newCookieSettings(pol, sessionTestGateway), "auth.metadata.token"), token)
+	if cookie != "jwt=abc; domain=example.com; HttpOnly; Secure; SameSite=Lax; Path=/; Max-Age=3600" {
+		t.Errorf("unexpected default cookie: %s", cookie)
+	}
+
+	pol = sessionTestPolicy(&v1alpha1.Session{
+		Lifetime: &metav1.Duration{Duration: 8 * time.Hour},
+		Refresh:  &v1alpha1.SessionRefresh{Before: &metav1.Duration{Duration: time.Minute}},
+		Cookie:   &v1alpha1.CookieAttributes{SameSite: "Strict", Domain: "apps.example.com"},
+	})
+	cookies := newCookieSettings(pol, sessionTestGateway)
+	expression := tokenCookieExpression(pol, "jwt", cookies, "auth.metadata.token")
+	cookie = evalCookieExpression(t, expression, token)
+	if cookie != "jwt=abc; domain=apps.example.com; HttpOnly; Secure; SameSite=Strict; Path=/; Max-Age=240" {
+		t.Errorf("unexpected refreshable cookie: %s", cookie)
+	}
+
+	// a token about to expire is kept until it expires
+	shortLived := map[string]interface{}{"metadata": map[string]interface{}{"token": map[string]interface{}{"id_token": "abc", "expires_in": float64(30)}}}
+	if cookie = evalCookieExpression(t, expression, shortLived); !strings.HasSuffix(cookie, "Max-Age=30") {
+		t.Errorf("unexpected cookie for a short-lived token: %s", cookie)
+	}
+
+	// without expires_in, the cookie lives as long as the session
+	noExpiry := map[string]interface{}{"metadata": map[string]interface{}{"token": map[string]interface{}{"id_token": "abc"}}}
+	if cookie = evalCookieExpression(t, expression, noExpiry); !strings.HasSuffix(cookie, "Max-Age=28800") {
+		t.Errorf("unexpected cookie for a token without expiry: %s", cookie)
+	}
+}
+
+func TestSessionCookieExpression(t *testing.T) {
+	pol := sessionTestPolicy(&v1alpha1.Session{
+		IdleTimeout: &metav1.Duration{Duration: 15 * time.Minute},
+		Refresh:     &v1alpha1.SessionRefresh{},
+	})
+	expression := sessionCookieExpression(pol, newCookieSettings(pol, sessionTestGateway), "auth.authorization.refresh.session_cookie")
+
+	sealed := map[string]interface{}{"authorization": map[string]interface{}{"refresh": map[string]interface{}{"session_cookie": "nonce.data.mac"}}}
+	if cookie := evalCookieExpression(t, expression, sealed); cookie != "oidc-session-oidc=nonce.data.mac; domain=example.com; HttpOnly; Secure; SameSite=Lax; Path=/auth/refresh/oidc; Max-Age=3600" {
+		t.Errorf("unexpected session cookie: %s", cookie)
+	}
+
+	cleared := map[string]interface{}{"authorization": map[string]interface{}{"refresh": map[string]interface{}{"session_cookie": nil}}}
+	if cookie := evalCookieExpression(t, expression, cleared); cookie != "oidc-session-oidc=; domain=example.com; HttpOnly; Secure; SameSite=Lax; Path=/auth/refresh/oidc; Max-Age=0" {
+		t.Errorf("unexpected cleared session cookie: %s", cookie)
+	}
+}
+
+func TestBuildTargetCookieExpression_IgnoresConfiguredSameSite(t *testing.T) {
+	pol := sessionTestPolicy(&v1alpha1.Session{Cookie: &v1alpha1.CookieAttributes{SameSite: "Strict", Domain: "apps.example.com"}})
+	expression := buildTargetCookieExpression(newCookieSettings(pol, sessionTestGateway))
+
+	if !strings.Contains(expression, "SameSite=Lax") {
+		t.Errorf("expected target cookie to be SameSite=Lax, got %s", expression)
+	}
+	if !strings.Contains(expression, "domain=apps.example.com") {
+		t.Errorf("expected target cookie to use the configured domain, got %s", expression)
+	}
+}
+
+func TestBuildMainAuthPolicy_RedirectsToRefreshRoute(t *testing.T) {
+	pol := sessionTestPolicy(nil)
+	authPol, err := buildMainAuthPolicy(pol, sessionTestGateway)
+	if err != nil {
+		t.Fatal(err)
+	}
+	location := string(authPol.Spec.Overrides.AuthScheme.Response.Unauthenticated.Headers["location"].Value.Raw)
+	if !strings.HasPrefix(location, `"https://issuer.com/oauth/authorize?`) {
+		t.Errorf("expected redirect to the authorize endpoint, got %s", location)
+	}
+
+	pol = sessionTestPolicy(&v1alpha1.Session{Refresh: &v1alpha1.SessionRefresh{}})
+	authPol, err = buildMainAuthPolicy(pol, sessionTestGateway)
+	if err != nil {
+		t.Fatal(err)
+	}
+	location = string(authPol.Spec.Overrides.AuthScheme.Response.Unauthenticated.Headers["location"].Value.Raw)
+	if location != `"https://example.com/auth/refresh/oidc"` {
+		t.Errorf("expected redirect to the refresh route, got %s", location)
+	}
+}
+
+func TestBuildCallbackAuthPolicy_SealsSession(t *testing.T) {
+	authPol, err := buildCallbackAuthPolicy(sessionTestPolicy(nil), sessionTestGateway)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, found := authPol.Spec.Overrides.AuthScheme.Response.Unauthorized.Headers[sessionCookieHeader]; found {
+		t.Error("expected no session cookie without refresh")
+	}
+	if _, found := authPol.Spec.Overrides.AuthScheme.Metadata["sealed"]; found {
+		t.Error("expected no session to be sealed without refresh")
+	}
+
+	authPol, err = buildCallbackAuthPolicy(sessionTestPolicy(&v1alpha1.Session{Refresh: &v1alpha1.SessionRefresh{}}), sessionTestGateway)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheme := authPol.Spec.Overrides.AuthScheme
+	sealed := scheme.Metadata["sealed"]
+	if sealed.Http == nil || sealed.Http.Url != "http://kuadrant-operator-oidc-session.kuadrant-system.svc.cluster.local:8086/session/seal" || sealed.Priority != 1 {
+		t.Fatalf("expected the session to be sealed by the session service after the token exchange, got %+v", sealed)
+	}
+	if sealed.Http.Parameters["refreshToken"].Expression != "auth.metadata.token.refresh_token" {
+		t.Errorf("unexpected refresh token parameter %+v", sealed.Http.Parameters["refreshToken"])
+	}
+	if _, found := sealed.Http.Parameters["issuedAt"]; found {
+		t.Error("expected a new session to start at the time of the login")
+	}
+
+	headers := scheme.Response.Unauthorized.Headers
+	if _, found := headers["set-cookie"]; !found {
+		t.Error("expected token cookie")
+	}
+	if !strings.Contains(string(headers[sessionCookieHeader].Expression), "auth.authorization.location.session_cookie") {
+		t.Errorf("unexpected session cookie: %s", headers[sessionCookieHeader].Expression)
+	}
+	rego := scheme.Authorization["location"].Opa.Rego
+	if !strings.Contains(rego, "session_cookie := input.auth.metadata.sealed.session { input.auth.metadata.sealed.session }") || strings.Contains(rego, "crypto.") {
+		t.Errorf("expected rego to expose the sealed session, got %s", rego)
+	}
+}
+
+func TestBuildRefreshAuthPolicy(t *testing.T) {
+	pol := sessionTestPolicy(&v1alpha1.Session{
+		Lifetime: &metav1.Duration{Duration: 2 * time.Hour},
+		Refresh:  &v1alpha1.SessionRefresh{},
+	})
+
+	authPol, err := buildRefreshAuthPolicy(pol, sessionTestGateway)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authPol.Name != "oidc-refresh" || string(authPol.Spec.TargetRef.Name) != "oidc-refresh" || authPol.Spec.TargetRef.Kind != "HTTPRoute" {
+		t.Errorf("unexpected refresh AuthPolicy target: %s -> %s %s", authPol.Name, authPol.Spec.TargetRef.Kind, authPol.Spec.TargetRef.Name)
+	}
+
+	scheme := authPol.Spec.Overrides.AuthScheme
+	session := scheme.Metadata["session"]
+	if session.Http == nil || !strings.HasSuffix(session.Http.Url, sessionServiceOpenSessionPath) || session.Priority != 0 {
+		t.Errorf("expected the session to be opened by the session service first, got %+v", session)
+	}
+	token := scheme.Metadata["token"]
+	if token.Http == nil || token.Http.Url != "https://issuer.com/oauth/token" || token.Priority != 1 {
+		t.Fatalf("expected the tokens to be refreshed after the session is opened, got %+v", token)
+	}
+	if string(token.Http.Parameters["grant_type"].Value.Raw) != `"refresh_token"` || string(token.Http.Parameters["client_id"].Value.Raw) != `"client 123"` || token.Http.Parameters["refresh_token"].Expression != "auth.metadata.session.refreshToken" {
+		t.Errorf("unexpected refresh request parameters %+v", token.Http.Parameters)
+	}
+	if len(token.Conditions) != 1 || token.Conditions[0].Predicate != `"session" in auth.metadata && has(auth.metadata.session.refreshToken)` {
+		t.Errorf("expected the tokens to only be refreshed for an open session, got %+v", token.Conditions)
+	}
+	sealed := scheme.Metadata["sealed"]
+	if sealed.Http == nil || !strings.HasSuffix(sealed.Http.Url, sessionServiceSealSessionPath) || sealed.Priority != 2 || sealed.Http.Parameters["issuedAt"].Expression != "auth.metadata.session.issuedAt" {
+		t.Errorf("expected the session to be sealed again keeping its start, got %+v", sealed)
+	}
+
+	rego := scheme.Authorization["refresh"].Opa.Rego
+	for _, expected := range []string{
+		`refreshed { input.auth.metadata.token.id_token }`,
+		`session_cookie := input.auth.metadata.sealed.session { sealed }`,
+		`location := "https://issuer.com/oauth/authorize?`,
+	} {
+		if !strings.Contains(rego, expected) {
+			t.Errorf("refresh rego missing expected pattern: %s\nGot: %s", expected, rego)
+		}
+	}
+	if strings.Contains(rego, "crypto.") || strings.Contains(rego, "http.send") {
+		t.Errorf("expected no crypto nor requests in the rego, got %s", rego)
+	}
+
+	headers := scheme.Response.Unauthorized.Headers
+	refreshed := map[string]interface{}{"authorization": map[string]interface{}{"refresh": map[string]interface{}{
+		"id_token":       "abc",
+		"token":          map[string]interface{}{"id_token": "abc", "expires_in": float64(300)},
+		"session_cookie": "sealed-session",
+	}}}
+	if cookie := evalCookieExpression(t, string(headers["set-cookie"].Expression), refreshed); cookie != "jwt=abc; domain=example.com; HttpOnly; Secure; SameSite=Lax; Path=/; Max-Age=270" {
+		t.Errorf("unexpected refreshed token cookie: %s", cookie)
+	}
+	if cookie := evalCookieExpression(t, string(headers[sessionCookieHeader].Expression), refreshed); !strings.HasPrefix(cookie, "oidc-session-oidc=sealed-session;") {
+		t.Errorf("unexpected refreshed session cookie: %s", cookie)
+	}
+
+	failed := map[string]interface{}{"authorization": map[string]interface{}{"refresh": map[string]interface{}{
+		"id_token":       nil,
+		"token":          nil,
+		"session_cookie": nil,
+	}}}
+	if cookie := evalCookieExpression(t, string(headers["set-cookie"].Expression), failed); !strings.HasSuffix(cookie, "Max-Age=0") {
+		t.Errorf("expected token cookie to be cleared, got %s", cookie)
+	}
+	if cookie := evalCookieExpression(t, string(headers[sessionCookieHeader].Expression), failed); !strings.HasSuffix(cookie, "Max-Age=0") {
+		t.Errorf("expected session cookie to be cleared, got %s", cookie)
+	}
+
+	pol.Spec.Provider.ClientSecretRef = &v1alpha1.SecretKeyReference{Name: "idp", Key: "secret"}
+	if authPol, err = buildRefreshAuthPolicy(pol, sessionTestGateway); err != nil {
+		t.Fatal(err)
+	}
+	if token := authPol.Spec.Overrides.AuthScheme.Metadata["token"].Http; token.SharedSecret == nil || token.SharedSecret.Name != "default-oidc-client" {
+		t.Errorf("expected the client credentials in the refresh request of a confidential client, got %+v", token.SharedSecret)
+	}
+}
+
+func TestValidateSession(t *testing.T) {
+	pol := sessionTestPolicy(&v1alpha1.Session{Refresh: &v1alpha1.SessionRefresh{}})
+	if err := validateSession(pol); err != nil {
+		t.Errorf("expected default token source to support refresh, got %v", err)
+	}
+
+	pol.Spec.Auth = &v1alpha1.Auth{TokenSource: &authorinov1beta3.Credentials{AuthorizationHeader: &authorinov1beta3.Prefixed{Prefix: "Bearer"}}}
+	if err := validateSession(pol); err == nil {
+		t.Error("expected refresh with a header token source to be rejected")
+	}
+
+	pol.Spec.Auth = nil
+	pol.Spec.Provider.ClientSecretRef = &v1alpha1.SecretKeyReference{Name: "idp", Key: "secret"}
+	if err := validateSession(pol); err != nil {
+		t.Errorf("expected refresh with a confidential client to be supported, got %v", err)
+	}
+
+	pol.Spec.Session.Refresh = nil
+	if err := validateSession(pol); err != nil {
+		t.Errorf("expected no error without refresh, got %v", err)
+	}
+}
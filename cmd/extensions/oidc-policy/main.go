@@ -30,21 +30,31 @@ func init() {
 }
 
 func main() {
-	oidcPolicyReconciler := controller.NewOIDCPolicyReconciler()
 	builder, logger := extcontroller.NewBuilder("oidc-policy-controller")
+	sessionService := controller.NewSessionService(logger)
+	oidcPolicyReconciler := controller.NewOIDCPolicyReconciler(sessionService)
 	extController, err := builder.
 		WithScheme(scheme).
 		WithReconciler(oidcPolicyReconciler.Reconcile).
 		For(&v1alpha1.OIDCPolicy{}).
 		Owns(&kuadrantv1.AuthPolicy{}).
 		Owns(&gatewayapiv1.HTTPRoute{}).
+		WatchesMapped(&corev1.Secret{}, controller.SecretToPolicies).
 		Build()
 	if err != nil {
 		logger.Error(err, "unable to create controller")
 		os.Exit(1)
 	}
 
-	if err = extController.Start(ctrl.SetupSignalHandler()); err != nil {
+	ctx := ctrl.SetupSignalHandler()
+	go func() {
+		if err := sessionService.Run(ctx); err != nil {
+			logger.Error(err, "unable to run session service")
+			os.Exit(1)
+		}
+	}()
+
+	if err = extController.Start(ctx); err != nil {
 		logger.Error(err, "unable to start extension controller")
 		os.Exit(1)
 	}
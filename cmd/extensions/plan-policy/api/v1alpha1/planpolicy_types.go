@@ -17,18 +17,26 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"strings"
+	"time"
 
-	"github.com/go-logr/logr"
-	"github.com/google/go-cmp/cmp"
-	"github.com/kuadrant/limitador-operator/pkg/helpers"
+	"github.com/samber/lo"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	gatewayapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 
 	kuadrantv1 "github.com/kuadrant/kuadrant-operator/api/v1"
+	kuadrantv1alpha1 "github.com/kuadrant/kuadrant-operator/api/v1alpha1"
 	"github.com/kuadrant/kuadrant-operator/internal/utils"
+	exttypes "github.com/kuadrant/kuadrant-operator/pkg/extension/types"
+)
+
+const (
+	DefaultSubscriptionTierField = "tier"
+	DefaultSubscriptionCacheTTL  = time.Minute
 )
 
 var (
@@ -62,15 +70,52 @@ func (p *PlanPolicy) GetTargetRefs() []gatewayapiv1alpha2.LocalPolicyTargetRefer
 	}
 }
 
+func (p *PlanPolicy) GetStatus() exttypes.PolicyStatus {
+	return &p.Status
+}
+
 func (p *PlanPolicy) ToRateLimits() map[string]kuadrantv1.Limit {
-	return utils.Associate(p.Spec.Plans, func(plan Plan) (string, kuadrantv1.Limit) {
+	// plans limited in tokens only are left out, rather than turned into limits without rates
+	plans := lo.Filter(p.Spec.Plans, func(plan Plan, _ int) bool {
+		return plan.Limits.Tokens == nil || len(plan.Limits.ToRates()) > 0
+	})
+	return utils.Associate(plans, func(plan Plan) (string, kuadrantv1.Limit) {
 		return plan.Tier, kuadrantv1.Limit{
-			When:  kuadrantv1.NewWhenPredicates(fmt.Sprintf(`auth.kuadrant.plan == "%s"`, plan.Tier)),
+			When:  p.tierPredicates(plan.Tier),
 			Rates: plan.Limits.ToRates(),
 		}
 	})
 }
 
+func (p *PlanPolicy) ToTokenRateLimits() map[string]kuadrantv1alpha1.TokenLimit {
+	plans := lo.Filter(p.Spec.Plans, func(plan Plan, _ int) bool {
+		return plan.Limits.Tokens != nil
+	})
+	return utils.Associate(plans, func(plan Plan) (string, kuadrantv1alpha1.TokenLimit) {
+		return plan.Tier, kuadrantv1alpha1.TokenLimit{
+			When:  p.tierPredicates(plan.Tier),
+			Rates: plan.Limits.Tokens.ToRates(),
+		}
+	})
+}
+
+// tierPredicates returns the predicates of the limits of a tier, matching the plan the identity was
+// categorised in, or the tier looked up in the subscription service
+func (p *PlanPolicy) tierPredicates(tier string) kuadrantv1.WhenPredicates {
+	if p.Spec.Subscription != nil {
+		return kuadrantv1.NewWhenPredicates(fmt.Sprintf(`%s() == "%s"`, p.SubscriptionFunctionName(), tier))
+	}
+	return kuadrantv1.NewWhenPredicates(fmt.Sprintf(`auth.kuadrant.plan == "%s"`, tier))
+}
+
+// SubscriptionFunctionName returns the name of the CEL function evaluating to the tier looked up in the
+// subscription service. It is unique to the policy, so that policies at different levels of the hierarchy
+// can each look up their own subscriptions.
+func (p *PlanPolicy) SubscriptionFunctionName() string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s/%s", p.GetNamespace(), p.GetName())))
+	return fmt.Sprintf("plan_%s.tier", hex.EncodeToString(hash[:4]))
+}
+
 func (p *PlanPolicy) BuildCelExpression() string {
 	var tierList strings.Builder
 	var tierPredicates strings.Builder
@@ -95,6 +140,11 @@ func (p *PlanPolicy) BuildCelExpression() string {
 }
 
 // PlanPolicySpec defines the desired state of PlanPolicy
+// +kubebuilder:validation:XValidation:rule="self.targetRef.kind != 'GRPCRoute' || self.plans.all(p, !has(p.limits) || !has(p.limits.tokens))",message="token limits are only supported when targeting a HTTPRoute or Gateway"
+// +kubebuilder:validation:XValidation:rule="has(self.subscription) || self.plans.all(p, has(p.predicate))",message="plans require a predicate, unless their tier is looked up in a subscription service"
+// +kubebuilder:validation:XValidation:rule="!has(self.subscription) || self.plans.all(p, !has(p.predicate))",message="plans do not support predicates when their tier is looked up in a subscription service"
+// +kubebuilder:validation:XValidation:rule="!has(self.subscription) || !has(self.reporting)",message="reporting is not supported when the tiers are looked up in a subscription service"
+// +kubebuilder:validation:XValidation:rule="!has(self.reporting) || !has(self.reporting.remainingHeader) || !self.reporting.remainingHeader || self.targetRef.kind != 'Gateway'",message="reporting.remainingHeader is only supported when targeting a HTTPRoute or GRPCRoute"
 type PlanPolicySpec struct {
 	// Reference to the object to which this policy applies.
 	// +kubebuilder:validation:XValidation:rule="self.group == 'gateway.networking.k8s.io'",message="Invalid targetRef.group. The only supported value is 'gateway.networking.k8s.io'"
@@ -103,6 +153,79 @@ type PlanPolicySpec struct {
 
 	// Plans defines the list of plans for the policy. The identity is categorised by the first matching plan in the list.
 	Plans []Plan `json:"plans"`
+
+	// Reporting exposes the plan of the identities and the quota they have left on the responses.
+	// +optional
+	Reporting *Reporting `json:"reporting,omitempty"`
+
+	// Subscription looks the tier of the requests up in an external subscription service, rather than
+	// categorising them through the predicates of the plans.
+	// +optional
+	Subscription *Subscription `json:"subscription,omitempty"`
+}
+
+// Subscription configures the gRPC service the tiers are looked up in
+type Subscription struct {
+	// URL of the gRPC subscription service, e.g. grpc://subscriptions.billing.svc.cluster.local:8081
+	// +kubebuilder:validation:Pattern=`^grpc://`
+	URL string `json:"url"`
+
+	// Service is the fully qualified name of the gRPC service, e.g. billing.v1.SubscriptionService
+	// +kubebuilder:validation:MinLength=1
+	Service string `json:"service"`
+
+	// Method is the name of the gRPC method returning the subscription, e.g. GetSubscription
+	// +kubebuilder:validation:MinLength=1
+	Method string `json:"method"`
+
+	// Request is the CEL expression building the request message, keyed e.g. by API key or org id:
+	// billing.v1.SubscriptionRequest{api_key: request.headers["x-api-key"]}
+	// The subscription is looked up before the request is authenticated, so it cannot reference auth.
+	// +kubebuilder:validation:MinLength=1
+	Request string `json:"request"`
+
+	// TierField is the dot-separated path of the field of the response holding the tier. Defaults to "tier".
+	// +optional
+	TierField string `json:"tierField,omitempty"`
+
+	// CacheTTL is how long the response of the subscription service is cached, keyed by the request message,
+	// i.e. by the identity it is built from. Defaults to 1m. 0s disables the cache. The host of the subscription
+	// service must be allowed to be cached by the cluster administrator, see PLAN_SUBSCRIPTION_CACHE_HOSTS.
+	// +optional
+	CacheTTL *metav1.Duration `json:"cacheTTL,omitempty"`
+}
+
+func (s *Subscription) GetTierField() string {
+	if s.TierField == "" {
+		return DefaultSubscriptionTierField
+	}
+	return s.TierField
+}
+
+func (s *Subscription) GetCacheTTL() time.Duration {
+	if s.CacheTTL == nil {
+		return DefaultSubscriptionCacheTTL
+	}
+	return s.CacheTTL.Duration
+}
+
+// Reporting configures the plan headers added to the responses
+type Reporting struct {
+	// TierHeader adds the X-Plan-Tier header, holding the tier of the identity, to the responses.
+	// +optional
+	TierHeader bool `json:"tierHeader,omitempty"`
+
+	// RemainingHeader adds the X-Plan-Remaining header, holding the number of requests left to the identity
+	// within the most constrained limit of its plan, to the responses. The quota is checked against the
+	// Limitador counters, which has to be configured with the DRAFT_VERSION_03 rate limit headers.
+	// +optional
+	RemainingHeader bool `json:"remainingHeader,omitempty"`
+
+	// QuotaServiceURL is the gRPC URL of the Limitador instance the quota is checked against.
+	// Defaults to the Limitador instance managed by Kuadrant.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^grpc://`
+	QuotaServiceURL string `json:"quotaServiceURL,omitempty"`
 }
 
 type Plan struct {
@@ -114,8 +237,10 @@ type Plan struct {
 	Limits Limits `json:"limits,omitempty"`
 
 	// Predicate is a CEL expression used to determine if the plan is applied.
+	// Required unless the tier is looked up in a subscription service.
+	// +optional
 	// +kubebuilder:validation:MinLength=1
-	Predicate string `json:"predicate"`
+	Predicate string `json:"predicate,omitempty"`
 }
 
 type Limits struct {
@@ -138,9 +263,43 @@ type Limits struct {
 	// Custom defines any additional limits defined in terms of a RateLimitPolicy Rate.
 	// +optional
 	Custom []kuadrantv1.Rate `json:"custom,omitempty"`
+
+	// Tokens contains the limits of tokens that the plan enforces, enforced through a TokenRateLimitPolicy.
+	// +optional
+	Tokens *TokenLimits `json:"tokens,omitempty"`
 }
 
 func (l *Limits) ToRates() []kuadrantv1.Rate {
+	return toRates(l.Daily, l.Weekly, l.Monthly, l.Yearly, l.Custom)
+}
+
+type TokenLimits struct {
+	// Daily limit of tokens for this plan.
+	// +optional
+	Daily *int `json:"daily,omitempty"`
+
+	// Weekly limit of tokens for this plan.
+	// +optional
+	Weekly *int `json:"weekly,omitempty"`
+
+	// Monthly limit of tokens for this plan.
+	// +optional
+	Monthly *int `json:"monthly,omitempty"`
+
+	// Yearly limit of tokens for this plan.
+	// +optional
+	Yearly *int `json:"yearly,omitempty"`
+
+	// Custom defines any additional limits of tokens defined in terms of a TokenRateLimitPolicy Rate.
+	// +optional
+	Custom []kuadrantv1.Rate `json:"custom,omitempty"`
+}
+
+func (l *TokenLimits) ToRates() []kuadrantv1.Rate {
+	return toRates(l.Daily, l.Weekly, l.Monthly, l.Yearly, l.Custom)
+}
+
+func toRates(daily, weekly, monthly, yearly *int, custom []kuadrantv1.Rate) []kuadrantv1.Rate {
 	rates := make([]kuadrantv1.Rate, 0)
 	addRate := func(limit *int, window kuadrantv1.Duration) {
 		if limit != nil {
@@ -150,11 +309,11 @@ func (l *Limits) ToRates() []kuadrantv1.Rate {
 			})
 		}
 	}
-	addRate(l.Daily, "24h")
-	addRate(l.Weekly, "168h")
-	addRate(l.Monthly, "730h")
-	addRate(l.Yearly, "8760h")
-	rates = append(rates, l.Custom...)
+	addRate(daily, "24h")
+	addRate(weekly, "168h")
+	addRate(monthly, "730h")
+	addRate(yearly, "8760h")
+	rates = append(rates, custom...)
 	return rates
 }
 
@@ -173,23 +332,20 @@ type PlanPolicyStatus struct {
 	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
 }
 
-func (s *PlanPolicyStatus) Equals(other *PlanPolicyStatus, logger logr.Logger) bool {
-	if s.ObservedGeneration != other.ObservedGeneration {
-		diff := cmp.Diff(s.ObservedGeneration, other.ObservedGeneration)
-		logger.V(1).Info("status observedGeneration not equal", "difference", diff)
-		return false
-	}
+func (s *PlanPolicyStatus) GetObservedGeneration() int64 {
+	return s.ObservedGeneration
+}
 
-	// Marshalling sorts by condition type
-	currentMarshaledJSON, _ := helpers.ConditionMarshal(s.Conditions)
-	otherMarshaledJSON, _ := helpers.ConditionMarshal(other.Conditions)
-	if string(currentMarshaledJSON) != string(otherMarshaledJSON) {
-		diff := cmp.Diff(string(currentMarshaledJSON), string(otherMarshaledJSON))
-		logger.V(1).Info("status conditions not equal", "difference", diff)
-		return false
-	}
+func (s *PlanPolicyStatus) SetObservedGeneration(generation int64) {
+	s.ObservedGeneration = generation
+}
+
+func (s *PlanPolicyStatus) GetConditions() []metav1.Condition {
+	return s.Conditions
+}
 
-	return true
+func (s *PlanPolicyStatus) SetConditions(conditions []metav1.Condition) {
+	s.Conditions = conditions
 }
 
 //+kubebuilder:object:root=true
@@ -0,0 +1,103 @@
+//go:build unit
+
+package v1alpha1
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	kuadrantv1 "github.com/kuadrant/kuadrant-operator/api/v1"
+)
+
+func TestPlanPolicy_ToRateLimitsAndToTokenRateLimits(t *testing.T) {
+	policy := &PlanPolicy{
+		Spec: PlanPolicySpec{
+			Plans: []Plan{
+				{
+					Tier:      "free",
+					Predicate: "true",
+					Limits:    Limits{Tokens: &TokenLimits{Daily: ptr.To(100000)}},
+				},
+				{
+					Tier:      "pro",
+					Predicate: "true",
+					Limits: Limits{
+						Daily:  ptr.To(1000),
+						Tokens: &TokenLimits{Daily: ptr.To(10000000), Custom: []kuadrantv1.Rate{{Limit: 50000, Window: "1m"}}},
+					},
+				},
+				{
+					Tier:      "legacy",
+					Predicate: "true",
+				},
+			},
+		},
+	}
+
+	rateLimits := policy.ToRateLimits()
+	if _, found := rateLimits["free"]; found {
+		t.Error("expected the plan limited in tokens only to be left out of the rate limits")
+	}
+	if !reflect.DeepEqual(rateLimits["pro"].Rates, []kuadrantv1.Rate{{Limit: 1000, Window: "24h"}}) {
+		t.Errorf("unexpected request rates %v", rateLimits["pro"].Rates)
+	}
+	if _, found := rateLimits["legacy"]; !found {
+		t.Error("expected the plan without limits to be kept in the rate limits")
+	}
+
+	tokenRateLimits := policy.ToTokenRateLimits()
+	if len(tokenRateLimits) != 2 {
+		t.Fatalf("expected 2 token rate limits, got %d", len(tokenRateLimits))
+	}
+	if !reflect.DeepEqual(tokenRateLimits["free"].Rates, []kuadrantv1.Rate{{Limit: 100000, Window: "24h"}}) {
+		t.Errorf("unexpected token rates %v", tokenRateLimits["free"].Rates)
+	}
+	if !reflect.DeepEqual(tokenRateLimits["pro"].Rates, []kuadrantv1.Rate{{Limit: 10000000, Window: "24h"}, {Limit: 50000, Window: "1m"}}) {
+		t.Errorf("unexpected token rates %v", tokenRateLimits["pro"].Rates)
+	}
+	if predicate := tokenRateLimits["pro"].When[0].Predicate; predicate != `auth.kuadrant.plan == "pro"` {
+		t.Errorf("unexpected token limit predicate %s", predicate)
+	}
+}
+
+func TestPlanPolicy_SubscriptionTierPredicates(t *testing.T) {
+	policy := &PlanPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "plans", Namespace: "default"},
+		Spec: PlanPolicySpec{
+			Plans: []Plan{
+				{Tier: "free", Limits: Limits{Daily: ptr.To(100), Tokens: &TokenLimits{Daily: ptr.To(100000)}}},
+			},
+			Subscription: &Subscription{
+				URL:     "grpc://subscriptions.billing.svc.cluster.local:8081",
+				Service: "billing.v1.SubscriptionService",
+				Method:  "GetSubscription",
+				Request: `billing.v1.SubscriptionRequest{api_key: request.headers["x-api-key"]}`,
+			},
+		},
+	}
+
+	name := policy.SubscriptionFunctionName()
+	if !regexp.MustCompile(`^plan_[0-9a-f]{8}\.tier$`).MatchString(name) {
+		t.Fatalf("unexpected subscription function name %s", name)
+	}
+	other := policy.DeepCopy()
+	other.Name = "other"
+	if other.SubscriptionFunctionName() == name {
+		t.Error("expected the subscription function name to be unique to the policy")
+	}
+
+	want := name + `() == "free"`
+	if predicate := policy.ToRateLimits()["free"].When[0].Predicate; predicate != want {
+		t.Errorf("unexpected limit predicate %s, want %s", predicate, want)
+	}
+	if predicate := policy.ToTokenRateLimits()["free"].When[0].Predicate; predicate != want {
+		t.Errorf("unexpected token limit predicate %s, want %s", predicate, want)
+	}
+	if field := policy.Spec.Subscription.GetTierField(); field != DefaultSubscriptionTierField {
+		t.Errorf("unexpected default tier field %s", field)
+	}
+}
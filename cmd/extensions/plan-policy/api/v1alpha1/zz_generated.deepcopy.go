@@ -54,6 +54,11 @@ func (in *Limits) DeepCopyInto(out *Limits) {
 		*out = make([]v1.Rate, len(*in))
 		copy(*out, *in)
 	}
+	if in.Tokens != nil {
+		in, out := &in.Tokens, &out.Tokens
+		*out = new(TokenLimits)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Limits.
@@ -152,6 +157,16 @@ func (in *PlanPolicySpec) DeepCopyInto(out *PlanPolicySpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Reporting != nil {
+		in, out := &in.Reporting, &out.Reporting
+		*out = new(Reporting)
+		**out = **in
+	}
+	if in.Subscription != nil {
+		in, out := &in.Subscription, &out.Subscription
+		*out = new(Subscription)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlanPolicySpec.
@@ -185,3 +200,78 @@ func (in *PlanPolicyStatus) DeepCopy() *PlanPolicyStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Reporting) DeepCopyInto(out *Reporting) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Reporting.
+func (in *Reporting) DeepCopy() *Reporting {
+	if in == nil {
+		return nil
+	}
+	out := new(Reporting)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Subscription) DeepCopyInto(out *Subscription) {
+	*out = *in
+	if in.CacheTTL != nil {
+		in, out := &in.CacheTTL, &out.CacheTTL
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Subscription.
+func (in *Subscription) DeepCopy() *Subscription {
+	if in == nil {
+		return nil
+	}
+	out := new(Subscription)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TokenLimits) DeepCopyInto(out *TokenLimits) {
+	*out = *in
+	if in.Daily != nil {
+		in, out := &in.Daily, &out.Daily
+		*out = new(int)
+		**out = **in
+	}
+	if in.Weekly != nil {
+		in, out := &in.Weekly, &out.Weekly
+		*out = new(int)
+		**out = **in
+	}
+	if in.Monthly != nil {
+		in, out := &in.Monthly, &out.Monthly
+		*out = new(int)
+		**out = **in
+	}
+	if in.Yearly != nil {
+		in, out := &in.Yearly, &out.Yearly
+		*out = new(int)
+		**out = **in
+	}
+	if in.Custom != nil {
+		in, out := &in.Custom, &out.Custom
+		*out = make([]v1.Rate, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TokenLimits.
+func (in *TokenLimits) DeepCopy() *TokenLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(TokenLimits)
+	in.DeepCopyInto(out)
+	return out
+}
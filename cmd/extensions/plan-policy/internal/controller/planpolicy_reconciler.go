@@ -4,17 +4,15 @@ import (
 	"context"
 	"fmt"
 	"reflect"
-	"slices"
 
-	"github.com/samber/lo"
 	"k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	kuadrantv1 "github.com/kuadrant/kuadrant-operator/api/v1"
+	kuadrantv1alpha1 "github.com/kuadrant/kuadrant-operator/api/v1alpha1"
 	"github.com/kuadrant/kuadrant-operator/cmd/extensions/plan-policy/api/v1alpha1"
 	extcontroller "github.com/kuadrant/kuadrant-operator/pkg/extension/controller"
 	"github.com/kuadrant/kuadrant-operator/pkg/extension/types"
@@ -25,16 +23,18 @@ import (
 // +kubebuilder:rbac:groups=extensions.kuadrant.io,resources=planpolicies/finalizers,verbs=update
 
 // +kubebuilder:rbac:groups=kuadrant.io,resources=ratelimitpolicies,verbs=create;delete
+// +kubebuilder:rbac:groups=kuadrant.io,resources=tokenratelimitpolicies,verbs=create;delete
 
 // +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes,verbs=get;list;watch
 // +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=grpcroutes,verbs=get;list;watch
 
 type PlanPolicyReconciler struct {
 	types.ExtensionBase
+	subscriptions *SubscriptionCache
 }
 
-func NewPlanPolicyReconciler() *PlanPolicyReconciler {
-	return &PlanPolicyReconciler{}
+func NewPlanPolicyReconciler(subscriptions *SubscriptionCache) *PlanPolicyReconciler {
+	return &PlanPolicyReconciler{subscriptions: subscriptions}
 }
 
 func (r *PlanPolicyReconciler) Reconcile(ctx context.Context, request reconcile.Request, kuadrantCtx types.KuadrantCtx) (reconcile.Result, error) {
@@ -48,6 +48,7 @@ func (r *PlanPolicyReconciler) Reconcile(ctx context.Context, request reconcile.
 	if err := r.Client.Get(ctx, request.NamespacedName, planPolicy); err != nil {
 		if errors.IsNotFound(err) {
 			r.Logger.Error(err, "planpolicy not found")
+			r.subscriptions.Unregister(request.NamespacedName)
 			return reconcile.Result{}, nil
 		}
 		r.Logger.Error(err, "failed to retrieve planpolicy")
@@ -56,78 +57,91 @@ func (r *PlanPolicyReconciler) Reconcile(ctx context.Context, request reconcile.
 
 	if planPolicy.GetDeletionTimestamp() != nil {
 		r.Logger.Info("planpolicy marked for deletion")
+		r.subscriptions.Unregister(request.NamespacedName)
 		return reconcile.Result{}, nil
 	}
 
-	planPolicyStatus, specErr := r.reconcileSpec(ctx, planPolicy, kuadrantCtx)
-	statusResult, statusErr := r.reconcileStatus(ctx, planPolicy, planPolicyStatus)
+	corePolicies, specErr := r.reconcileSpec(ctx, planPolicy, kuadrantCtx)
+	if err := extcontroller.ReportTargetStatus(ctx, kuadrantCtx, planPolicy, specErr); err != nil {
+		r.Logger.Error(err, "failed to report policy status")
+	}
+	statusErr := extcontroller.UpdatePolicyStatus(ctx, r.Client, planPolicy, specErr, corePolicies...)
 
 	if specErr != nil {
 		return reconcile.Result{}, specErr
 	}
 	if statusErr != nil {
-		return reconcile.Result{}, statusErr
-	}
-
-	if statusResult.RequeueAfter > 0 {
-		r.Logger.Info("Reconciling status not finished. Requeueing.")
-		return statusResult, nil
+		return reconcile.Result{}, fmt.Errorf("failed to update status: %w", statusErr)
 	}
 
 	return reconcile.Result{}, nil
 }
 
-func (r *PlanPolicyReconciler) reconcileSpec(ctx context.Context, planPolicy *v1alpha1.PlanPolicy, kuadrantCtx types.KuadrantCtx) (*v1alpha1.PlanPolicyStatus, error) {
-	desiredRateLimitPolicy := r.buildDesiredRateLimitPolicy(planPolicy)
-	if err := controllerutil.SetControllerReference(planPolicy, desiredRateLimitPolicy, r.Scheme); err != nil {
-		r.Logger.Error(err, "failed to set controller reference")
-		return calculateErrorStatus(planPolicy, err), err
-	}
-	rateLimitPolicy, err := kuadrantCtx.ReconcileObject(ctx, &kuadrantv1.RateLimitPolicy{}, desiredRateLimitPolicy, rlpSpecMutator)
-	if err != nil {
-		r.Logger.Error(err, "failed to reconcile desired ratelimitpolicy")
-		return calculateErrorStatus(planPolicy, err), err
-	}
+// reconcileSpec reconciles the policies enforcing the limits of the plans, i.e. a RateLimitPolicy for the
+// limits of requests and a TokenRateLimitPolicy for the limits of tokens, and returns them. Either policy
+// is only produced when some plan has such limits, so that the stale one gets pruned.
+func (r *PlanPolicyReconciler) reconcileSpec(ctx context.Context, planPolicy *v1alpha1.PlanPolicy, kuadrantCtx types.KuadrantCtx) ([]client.Object, error) {
+	var corePolicies []client.Object
 
-	if err = kuadrantCtx.AddDataTo(ctx, planPolicy, types.DomainAuth, "plan", planPolicy.BuildCelExpression()); err != nil {
-		r.Logger.Error(err, "failed to add data to auth domain")
-		return calculateErrorStatus(planPolicy, err), err
-	}
-
-	if err = isRateLimitPolicyEnforced(rateLimitPolicy.(*kuadrantv1.RateLimitPolicy)); err != nil {
-		return calculateErrorStatus(planPolicy, err), err
+	// the function called by the limits is registered first, so that they are valid once created
+	if planPolicy.Spec.Subscription != nil {
+		if err := r.reconcileSubscription(ctx, planPolicy, kuadrantCtx); err != nil {
+			return nil, err
+		}
+	} else {
+		r.subscriptions.Unregister(client.ObjectKeyFromObject(planPolicy))
 	}
 
-	return calculateEnforcedStatus(planPolicy, nil), nil
-}
+	rateLimits := planPolicy.ToRateLimits()
+	tokenRateLimits := planPolicy.ToTokenRateLimits()
 
-func (r *PlanPolicyReconciler) reconcileStatus(ctx context.Context, pol *v1alpha1.PlanPolicy, newStatus *v1alpha1.PlanPolicyStatus) (reconcile.Result, error) {
-	equalStatus := pol.Status.Equals(newStatus, r.Logger)
-	r.Logger.Info("Status", "status is different", !equalStatus)
-	r.Logger.Info("Status", "generation is different", pol.Generation != pol.Status.ObservedGeneration)
-	if equalStatus && pol.Generation == pol.Status.ObservedGeneration {
-		// Steady state
-		r.Logger.Info("Status was not updated")
-		return reconcile.Result{}, nil
+	// a policy without any limit still gets its (empty) RateLimitPolicy, as it always did
+	if len(rateLimits) > 0 || len(tokenRateLimits) == 0 {
+		desiredRateLimitPolicy := r.buildDesiredRateLimitPolicy(planPolicy, rateLimits)
+		if err := controllerutil.SetControllerReference(planPolicy, desiredRateLimitPolicy, r.Scheme); err != nil {
+			r.Logger.Error(err, "failed to set controller reference")
+			return nil, err
+		}
+		rateLimitPolicy, err := kuadrantCtx.ReconcileObject(ctx, &kuadrantv1.RateLimitPolicy{}, desiredRateLimitPolicy, rlpSpecMutator)
+		if err != nil {
+			r.Logger.Error(err, "failed to reconcile desired ratelimitpolicy")
+			return nil, err
+		}
+		corePolicies = append(corePolicies, rateLimitPolicy)
 	}
 
-	r.Logger.V(1).Info("Updating Status", "sequence no:", fmt.Sprintf("sequence No: %v->%v", pol.Status.ObservedGeneration, newStatus.ObservedGeneration))
+	if len(tokenRateLimits) > 0 {
+		desiredTokenRateLimitPolicy := r.buildDesiredTokenRateLimitPolicy(planPolicy, tokenRateLimits)
+		if err := controllerutil.SetControllerReference(planPolicy, desiredTokenRateLimitPolicy, r.Scheme); err != nil {
+			r.Logger.Error(err, "failed to set controller reference")
+			return nil, err
+		}
+		tokenRateLimitPolicy, err := kuadrantCtx.ReconcileObject(ctx, &kuadrantv1alpha1.TokenRateLimitPolicy{}, desiredTokenRateLimitPolicy, trlpSpecMutator)
+		if err != nil {
+			r.Logger.Error(err, "failed to reconcile desired tokenratelimitpolicy")
+			return nil, err
+		}
+		corePolicies = append(corePolicies, tokenRateLimitPolicy)
+	}
 
-	pol.Status = *newStatus
-	updateErr := r.Client.Status().Update(ctx, pol)
-	if updateErr != nil {
-		// Ignore conflicts, resource might just be outdated.
-		if errors.IsConflict(updateErr) {
-			r.Logger.Info("Failed to update status: resource might just be outdated")
-			return reconcile.Result{Requeue: true}, nil
+	if planPolicy.Spec.Subscription == nil {
+		if err := kuadrantCtx.AddDataTo(ctx, planPolicy, types.DomainAuth, "plan", planPolicy.BuildCelExpression()); err != nil {
+			r.Logger.Error(err, "failed to add data to auth domain")
+			return nil, err
 		}
+	}
 
-		return reconcile.Result{}, fmt.Errorf("failed to update status: %w", updateErr)
+	if err := r.reconcileReporting(ctx, planPolicy, kuadrantCtx); err != nil {
+		r.Logger.Error(err, "failed to reconcile plan reporting")
+		return nil, err
 	}
-	return reconcile.Result{}, nil
+
+	// every object desired for the policy was reconciled, the ones no longer desired can be pruned
+	extcontroller.MarkDesiredStateComplete(ctx)
+	return corePolicies, nil
 }
 
-func (r *PlanPolicyReconciler) buildDesiredRateLimitPolicy(planPolicy *v1alpha1.PlanPolicy) *kuadrantv1.RateLimitPolicy {
+func (r *PlanPolicyReconciler) buildDesiredRateLimitPolicy(planPolicy *v1alpha1.PlanPolicy, limits map[string]kuadrantv1.Limit) *kuadrantv1.RateLimitPolicy {
 	return &kuadrantv1.RateLimitPolicy{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      planPolicy.GetName(),
@@ -136,7 +150,22 @@ func (r *PlanPolicyReconciler) buildDesiredRateLimitPolicy(planPolicy *v1alpha1.
 		Spec: kuadrantv1.RateLimitPolicySpec{
 			TargetRef: planPolicy.Spec.TargetRef,
 			RateLimitPolicySpecProper: kuadrantv1.RateLimitPolicySpecProper{
-				Limits: planPolicy.ToRateLimits(),
+				Limits: limits,
+			},
+		},
+	}
+}
+
+func (r *PlanPolicyReconciler) buildDesiredTokenRateLimitPolicy(planPolicy *v1alpha1.PlanPolicy, limits map[string]kuadrantv1alpha1.TokenLimit) *kuadrantv1alpha1.TokenRateLimitPolicy {
+	return &kuadrantv1alpha1.TokenRateLimitPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      planPolicy.GetName(),
+			Namespace: planPolicy.GetNamespace(),
+		},
+		Spec: kuadrantv1alpha1.TokenRateLimitPolicySpec{
+			TargetRef: planPolicy.Spec.TargetRef,
+			TokenRateLimitPolicySpecProper: kuadrantv1alpha1.TokenRateLimitPolicySpecProper{
+				Limits: limits,
 			},
 		},
 	}
@@ -163,36 +192,23 @@ func rlpSpecMutator(existingObj, desiredObj client.Object) (bool, error) {
 	return update, nil
 }
 
-func isRateLimitPolicyEnforced(rlPolicy *kuadrantv1.RateLimitPolicy) error {
-	cond, found := lo.Find(rlPolicy.Status.GetConditions(), func(c metav1.Condition) bool {
-		return c.Type == string(types.PolicyConditionEnforced)
-	})
-	if !found || cond.Status == metav1.ConditionFalse {
-		return fmt.Errorf("RateLimitPolicy %s is not enforced", rlPolicy.Name)
+func trlpSpecMutator(existingObj, desiredObj client.Object) (bool, error) {
+	var update bool
+	existing, ok := existingObj.(*kuadrantv1alpha1.TokenRateLimitPolicy)
+	if !ok {
+		return false, fmt.Errorf("%T is not a *kuadrantv1alpha1.TokenRateLimitPolicy", existingObj)
 	}
-	return nil
-}
-
-func calculateErrorStatus(pol *v1alpha1.PlanPolicy, specErr error) *v1alpha1.PlanPolicyStatus {
-	newStatus := &v1alpha1.PlanPolicyStatus{
-		ObservedGeneration: pol.Generation,
-		// Copy initial conditions. Otherwise, status will always be updated
-		Conditions: slices.Clone(pol.Status.Conditions),
+	desired, ok := desiredObj.(*kuadrantv1alpha1.TokenRateLimitPolicy)
+	if !ok {
+		return false, fmt.Errorf("%T is not a *kuadrantv1alpha1.TokenRateLimitPolicy", desiredObj)
 	}
-	meta.SetStatusCondition(&newStatus.Conditions, *extcontroller.AcceptedCondition(pol, specErr))
-	meta.RemoveStatusCondition(&newStatus.Conditions, string(types.PolicyConditionEnforced))
-
-	return newStatus
-}
-
-func calculateEnforcedStatus(pol *v1alpha1.PlanPolicy, enforcedErr error) *v1alpha1.PlanPolicyStatus {
-	newStatus := &v1alpha1.PlanPolicyStatus{
-		ObservedGeneration: pol.Generation,
-		// Copy initial conditions. Otherwise, status will always be updated
-		Conditions: slices.Clone(pol.Status.Conditions),
+	if !reflect.DeepEqual(desired.Spec.TargetRef, existing.Spec.TargetRef) {
+		existing.Spec.TargetRef = desired.Spec.TargetRef
+		update = true
 	}
-
-	meta.SetStatusCondition(&newStatus.Conditions, *extcontroller.AcceptedCondition(pol, nil))
-	meta.SetStatusCondition(&newStatus.Conditions, *extcontroller.EnforcedCondition(pol, enforcedErr, true))
-	return newStatus
+	if !reflect.DeepEqual(desired.Spec.TokenRateLimitPolicySpecProper, existing.Spec.TokenRateLimitPolicySpecProper) {
+		existing.Spec.TokenRateLimitPolicySpecProper = desired.Spec.TokenRateLimitPolicySpecProper
+		update = true
+	}
+	return update, nil
 }
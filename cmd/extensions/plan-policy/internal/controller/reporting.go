@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/samber/lo"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/env"
+
+	"github.com/kuadrant/kuadrant-operator/cmd/extensions/plan-policy/api/v1alpha1"
+	kuadrantcontrollers "github.com/kuadrant/kuadrant-operator/internal/controller"
+	"github.com/kuadrant/kuadrant-operator/internal/kuadrant"
+	"github.com/kuadrant/kuadrant-operator/pkg/extension/types"
+)
+
+const (
+	// quotaMethodName is the action method checking the quota left to the identity against Limitador
+	quotaMethodName = "plan-quota"
+	// quotaVar holds the response of the quota check, an envoy.service.ratelimit.v3.RateLimitResponse
+	quotaVar = "planQuota"
+
+	planTierHeader      = "x-plan-tier"
+	planRemainingHeader = "x-plan-remaining"
+
+	// limitadorRemainingHeader is the rate limit header of the most constrained limit reported by Limitador
+	limitadorRemainingHeader = "X-RateLimit-Remaining"
+	// overLimitCode is the OVER_LIMIT value of envoy.service.ratelimit.v3.RateLimitResponse.Code
+	overLimitCode = 2
+)
+
+// hasPlanPredicate holds when the identity was categorised in one of the plans
+const hasPlanPredicate = `has(auth.kuadrant) && has(auth.kuadrant.plan)`
+
+// quotaServiceURL returns the gRPC URL of the Limitador instance the quota is checked against
+func quotaServiceURL(pol *v1alpha1.PlanPolicy) string {
+	if url := pol.Spec.Reporting.QuotaServiceURL; url != "" {
+		return url
+	}
+	return fmt.Sprintf("grpc://limitador-%s.%s.svc.cluster.local:8081", kuadrant.LimitadorName, env.GetString("OPERATOR_NAMESPACE", "kuadrant-system"))
+}
+
+// quotaTiers returns the tiers the quota is checked for, i.e. those limited in number of requests
+func quotaTiers(pol *v1alpha1.PlanPolicy) []string {
+	tiers := lo.Keys(pol.ToRateLimits())
+	sort.Strings(tiers)
+	return tiers
+}
+
+// quotaPredicate holds when the identity was categorised in one of the plans limited in number of requests
+func quotaPredicate(pol *v1alpha1.PlanPolicy) (string, error) {
+	tiers, err := json.Marshal(quotaTiers(pol))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`%s && auth.kuadrant.plan in %s`, hasPlanPredicate, tiers), nil
+}
+
+// buildQuotaMessageTemplate returns the CEL template of the request checking the quota left to the identity.
+// The request carries the same domain and descriptor as the ones the data plane sends to enforce the limit of
+// the plan, so that Limitador reports on the very counters of the identity, without incrementing them.
+func buildQuotaMessageTemplate(pol *v1alpha1.PlanPolicy) (string, error) {
+	// the limits of the plans are enforced by a RateLimitPolicy named after the PlanPolicy, keyed by tier
+	rlpKey := k8stypes.NamespacedName{Name: pol.GetName(), Namespace: pol.GetNamespace()}
+	identifiers := make(map[string]string, len(pol.Spec.Plans))
+	for _, tier := range quotaTiers(pol) {
+		identifiers[tier] = kuadrantcontrollers.LimitNameToLimitadorIdentifier(rlpKey, tier)
+	}
+	identifiersJSON, err := json.Marshal(identifiers)
+	if err != nil {
+		return "", err
+	}
+	domain, err := json.Marshal(kuadrantcontrollers.LimitsNamespaceFromRoute(&metav1.ObjectMeta{Name: string(pol.Spec.TargetRef.Name), Namespace: pol.GetNamespace()}))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(`envoy.service.ratelimit.v3.RateLimitRequest{domain: %s, descriptors: [envoy.extensions.common.ratelimit.v3.RateLimitDescriptor{entries: [envoy.extensions.common.ratelimit.v3.RateLimitDescriptor.Entry{key: %s[auth.kuadrant.plan], value: "1"}]}], hits_addend: 1u}`, domain, identifiersJSON), nil
+}
+
+// buildTierHeader returns the CEL expression of the plan tier header added to the responses
+func buildTierHeader() string {
+	return fmt.Sprintf(`[["%s", auth.kuadrant.plan]]`, planTierHeader)
+}
+
+// buildRemainingHeader returns the CEL expression of the remaining quota header added to the responses
+func buildRemainingHeader() string {
+	remaining := fmt.Sprintf(`%s.response_headers_to_add.filter(h, h.key == "%s")`, quotaVar, limitadorRemainingHeader)
+	return fmt.Sprintf(`size(%s) > 0 ? [["%s", %s[0].value]] : (%s.overall_code == %d ? [["%s", "0"]] : [])`, remaining, planRemainingHeader, remaining, quotaVar, overLimitCode, planRemainingHeader)
+}
+
+// reconcileReporting commits the pipeline adding the plan headers to the responses. The pipeline is
+// committed empty when reporting is disabled, clearing the actions of any previous generation.
+func (r *PlanPolicyReconciler) reconcileReporting(ctx context.Context, pol *v1alpha1.PlanPolicy, kuadrantCtx types.KuadrantCtx) error {
+	pipeline := kuadrantCtx.NewPipeline(pol)
+
+	if reporting := pol.Spec.Reporting; reporting != nil {
+		if reporting.TierHeader {
+			if err := pipeline.OnHTTPResponse(
+				types.AddHeadersAction{
+					Predicate:    hasPlanPredicate,
+					HeadersToAdd: buildTierHeader(),
+				},
+			); err != nil {
+				return err
+			}
+		}
+
+		if reporting.RemainingHeader && len(quotaTiers(pol)) > 0 {
+			predicate, err := quotaPredicate(pol)
+			if err != nil {
+				return err
+			}
+			messageTemplate, err := buildQuotaMessageTemplate(pol)
+			if err != nil {
+				return err
+			}
+			if err := kuadrantCtx.RegisterActionMethod(ctx, pol, types.ActionMethodConfig{
+				Name:            quotaMethodName,
+				URL:             quotaServiceURL(pol),
+				Service:         "kuadrant.service.ratelimit.v1.RateLimitService",
+				Method:          "CheckRateLimit",
+				MessageTemplate: messageTemplate,
+			}); err != nil {
+				r.Logger.Error(err, "failed to register quota action method")
+				return err
+			}
+			if err := pipeline.OnHTTPRequest(
+				types.GRPCMethodAction{
+					Predicate: predicate,
+					Method:    quotaMethodName,
+					Var:       quotaVar,
+				},
+			); err != nil {
+				return err
+			}
+			if err := pipeline.OnHTTPResponse(
+				types.AddHeadersAction{
+					Predicate:    predicate,
+					HeadersToAdd: buildRemainingHeader(),
+				},
+			); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := pipeline.Commit(ctx); err != nil {
+		r.Logger.Error(err, "failed to commit pipeline")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+//go:build unit
+
+package controller
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/cel-go/cel"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	gatewayapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	"github.com/kuadrant/kuadrant-operator/cmd/extensions/plan-policy/api/v1alpha1"
+)
+
+func reportingTestPolicy(reporting *v1alpha1.Reporting) *v1alpha1.PlanPolicy {
+	return &v1alpha1.PlanPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "plans", Namespace: "default"},
+		Spec: v1alpha1.PlanPolicySpec{
+			TargetRef: gatewayapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+				LocalPolicyTargetReference: gatewayapiv1alpha2.LocalPolicyTargetReference{
+					Group: "gateway.networking.k8s.io",
+					Kind:  "HTTPRoute",
+					Name:  "toystore",
+				},
+			},
+			Plans: []v1alpha1.Plan{
+				{Tier: "gold", Predicate: "true"},
+				{Tier: "silver", Predicate: "true"},
+			},
+			Reporting: reporting,
+		},
+	}
+}
+
+func evalReportingHeaders(t *testing.T, expression string, quota map[string]interface{}) string {
+	t.Helper()
+	env, err := cel.NewEnv(cel.Variable("auth", cel.DynType), cel.Variable(quotaVar, cel.DynType))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ast, iss := env.Compile(expression)
+	if iss != nil && iss.Err() != nil {
+		t.Fatalf("compile failed for expression %q: %v", expression, iss.Err())
+	}
+	prg, err := env.Program(ast)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, _, err := prg.Eval(map[string]interface{}{
+		"auth":   map[string]interface{}{"kuadrant": map[string]interface{}{"plan": "gold"}},
+		quotaVar: quota,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pairs, err := out.ConvertToNative(reflect.TypeOf([][]string{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var headers []string
+	for _, pair := range pairs.([][]string) {
+		headers = append(headers, pair[0]+"="+pair[1])
+	}
+	return strings.Join(headers, ",")
+}
+
+func TestBuildTierHeader(t *testing.T) {
+	if got := evalReportingHeaders(t, buildTierHeader(), nil); got != "x-plan-tier=gold" {
+		t.Errorf("headers = %q, want %q", got, "x-plan-tier=gold")
+	}
+}
+
+func TestBuildRemainingHeader(t *testing.T) {
+	cases := []struct {
+		name  string
+		quota map[string]interface{}
+		want  string
+	}{
+		{
+			name: "remaining reported by limitador",
+			quota: map[string]interface{}{
+				"overall_code": 1,
+				"response_headers_to_add": []interface{}{
+					map[string]interface{}{"key": "X-RateLimit-Limit", "value": "100"},
+					map[string]interface{}{"key": "X-RateLimit-Remaining", "value": "42"},
+				},
+			},
+			want: "x-plan-remaining=42",
+		},
+		{
+			name:  "over limit without rate limit headers",
+			quota: map[string]interface{}{"overall_code": 2, "response_headers_to_add": []interface{}{}},
+			want:  "x-plan-remaining=0",
+		},
+		{
+			name:  "under limit without rate limit headers",
+			quota: map[string]interface{}{"overall_code": 1, "response_headers_to_add": []interface{}{}},
+			want:  "",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := evalReportingHeaders(t, buildRemainingHeader(), tc.quota); got != tc.want {
+				t.Errorf("headers = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestQuotaPredicate(t *testing.T) {
+	pol := reportingTestPolicy(&v1alpha1.Reporting{RemainingHeader: true})
+	pol.Spec.Plans = append(pol.Spec.Plans, v1alpha1.Plan{
+		Tier:      "tokens-only",
+		Predicate: "true",
+		Limits:    v1alpha1.Limits{Tokens: &v1alpha1.TokenLimits{Daily: ptr.To(100000)}},
+	})
+	predicate, err := quotaPredicate(pol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := hasPlanPredicate + ` && auth.kuadrant.plan in ["gold","silver"]`; predicate != want {
+		t.Errorf("predicate = %s, want %s", predicate, want)
+	}
+}
+
+func TestBuildQuotaMessageTemplate(t *testing.T) {
+	template, err := buildQuotaMessageTemplate(reportingTestPolicy(&v1alpha1.Reporting{RemainingHeader: true}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`domain: "default/toystore"`,
+		`"gold":"limit.gold__`,
+		`"silver":"limit.silver__`,
+		`[auth.kuadrant.plan], value: "1"`,
+	} {
+		if !strings.Contains(template, expected) {
+			t.Errorf("message template missing expected pattern: %s\nGot: %s", expected, template)
+		}
+	}
+}
+
+func TestQuotaServiceURL(t *testing.T) {
+	t.Setenv("OPERATOR_NAMESPACE", "kuadrant")
+	pol := reportingTestPolicy(&v1alpha1.Reporting{RemainingHeader: true})
+	if url := quotaServiceURL(pol); url != "grpc://limitador-limitador.kuadrant.svc.cluster.local:8081" {
+		t.Errorf("unexpected default quota service URL %s", url)
+	}
+	pol.Spec.Reporting.QuotaServiceURL = "grpc://limitador.example.com:8081"
+	if url := quotaServiceURL(pol); url != "grpc://limitador.example.com:8081" {
+		t.Errorf("unexpected quota service URL %s", url)
+	}
+}
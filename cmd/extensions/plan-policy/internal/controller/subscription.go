@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kuadrant/kuadrant-operator/cmd/extensions/plan-policy/api/v1alpha1"
+	"github.com/kuadrant/kuadrant-operator/pkg/extension/types"
+)
+
+// subscriptionMethodName is the action method looking the subscription of the request up
+const subscriptionMethodName = "plan-subscription"
+
+// reconcileSubscription exposes the tier looked up in the subscription service as a CEL function, called
+// by the predicates of the limits of the plans. The data plane calls the subscription service once per
+// request, before any other action, whichever the number of limits calling the function. Unless the cache
+// is disabled, the call goes through the subscription cache, which only reaches the subscription service
+// once per identity and TTL, under a service name unique to the policy.
+func (r *PlanPolicyReconciler) reconcileSubscription(ctx context.Context, pol *v1alpha1.PlanPolicy, kuadrantCtx types.KuadrantCtx) error {
+	subscription := pol.Spec.Subscription
+
+	subscriptionURL, subscriptionService := subscription.URL, subscription.Service
+	if subscription.GetCacheTTL() > 0 {
+		alias, err := r.subscriptions.Register(ctx, pol)
+		if err != nil {
+			r.Logger.Error(err, "failed to register subscription with the cache")
+			return err
+		}
+		subscriptionURL, subscriptionService = subscriptionCacheURL(), alias
+	} else {
+		r.subscriptions.Unregister(client.ObjectKeyFromObject(pol))
+	}
+
+	if err := kuadrantCtx.RegisterActionMethod(ctx, pol, types.ActionMethodConfig{
+		Name:            subscriptionMethodName,
+		URL:             subscriptionURL,
+		Service:         subscriptionService,
+		Method:          subscription.Method,
+		MessageTemplate: subscription.Request,
+	}); err != nil {
+		r.Logger.Error(err, "failed to register subscription action method")
+		return err
+	}
+
+	if err := kuadrantCtx.RegisterFunction(ctx, pol, types.FunctionConfig{
+		Name:        pol.SubscriptionFunctionName(),
+		Method:      subscriptionMethodName,
+		ResultField: subscription.GetTierField(),
+		ResultType:  types.FunctionTypeString,
+	}); err != nil {
+		r.Logger.Error(err, "failed to register subscription tier function")
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,449 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/samber/lo"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/env"
+
+	"github.com/kuadrant/kuadrant-operator/cmd/extensions/plan-policy/api/v1alpha1"
+	"github.com/kuadrant/kuadrant-operator/internal/extension"
+)
+
+const (
+	defaultSubscriptionCachePort = 8085
+	// subscriptionCacheServiceName is the name of the Service the data plane reaches the subscription cache through
+	subscriptionCacheServiceName = "kuadrant-operator-plan-subscription"
+	// maxSubscriptionCacheEntries bounds the number of cached responses, i.e. of identities
+	maxSubscriptionCacheEntries = 10000
+	// reflectionFullMethod is the method of the gRPC reflection API, answered by the cache out of the descriptors
+	// of the subscription services rather than forwarded to them
+	reflectionFullMethod = "/grpc.reflection.v1.ServerReflection/ServerReflectionInfo"
+)
+
+// subscriptionCacheURL returns the URL of the subscription cache as seen by the data plane
+func subscriptionCacheURL() string {
+	return fmt.Sprintf("grpc://%s.%s.svc.cluster.local:%d", subscriptionCacheServiceName, env.GetString("OPERATOR_NAMESPACE", "kuadrant-system"), defaultSubscriptionCachePort)
+}
+
+// allowedSubscriptionHosts returns the hosts of the subscription services the cache may call, set by the cluster
+// administrator as a comma-separated list. Caching the subscriptions is disabled when none is set.
+func allowedSubscriptionHosts() map[string]bool {
+	hosts := make(map[string]bool)
+	for _, host := range strings.Split(env.GetString("PLAN_SUBSCRIPTION_CACHE_HOSTS", ""), ",") {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			hosts[host] = true
+		}
+	}
+	return hosts
+}
+
+// subscriptionAlias returns the name of the service the data plane calls the cache with for a policy. It aliases the
+// subscription service within its package, so that the cache tells the policies apart, whichever their services.
+func subscriptionAlias(pol *v1alpha1.PlanPolicy) string {
+	sum := sha256.Sum256([]byte(pol.Namespace + "/" + pol.Name))
+	return pol.Spec.Subscription.Service + "_" + hex.EncodeToString(sum[:8])
+}
+
+// subscriptionUpstream is the subscription service of a policy
+type subscriptionUpstream struct {
+	// alias is the service the data plane calls the cache with, unique to the policy
+	alias      string
+	method     string
+	fullMethod string
+	target     string
+	ttl        time.Duration
+	// files are the serialized descriptors of the subscription service, keyed by file name, along with the file
+	// declaring the alias, which the cache answers reflection requests with
+	files     map[string][]byte
+	aliasFile string
+}
+
+type subscriptionCacheEntry struct {
+	response []byte
+	expires  time.Time
+}
+
+// SubscriptionCache caches the responses of the subscription services across requests. The data plane calls
+// the subscription method of a policy on the cache, under a service name unique to the policy, and the cache
+// forwards the request message as is to the subscription service of the policy, keeping the response for the
+// TTL of the policy, keyed by the policy and the request message. As the request message is built out of the
+// identity, e.g. the API key, so is the cache. The cache only calls the subscription services whose hosts are
+// allowed by the cluster administrator, and answers the reflection requests itself.
+type SubscriptionCache struct {
+	logger       logr.Logger
+	now          func() time.Time
+	dial         func(target string) (*grpc.ClientConn, error)
+	describe     extension.ReflectionFetcher
+	allowedHosts map[string]bool
+
+	mu       sync.Mutex
+	policies map[k8stypes.NamespacedName]subscriptionUpstream
+	conns    map[string]*grpc.ClientConn
+	entries  map[string]subscriptionCacheEntry
+}
+
+func NewSubscriptionCache(logger logr.Logger) *SubscriptionCache {
+	return &SubscriptionCache{
+		logger: logger.WithName("SubscriptionCache"),
+		now:    time.Now,
+		dial: func(target string) (*grpc.ClientConn, error) {
+			return grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		},
+		describe:     extension.NewReflectionClient().FetchServiceDescriptors,
+		allowedHosts: allowedSubscriptionHosts(),
+		policies:     make(map[k8stypes.NamespacedName]subscriptionUpstream),
+		conns:        make(map[string]*grpc.ClientConn),
+		entries:      make(map[string]subscriptionCacheEntry),
+	}
+}
+
+// Register sets the subscription service of a policy, whose host must be allowed, and returns the name of the
+// service the data plane calls the cache with for the policy. The descriptors of the subscription service are read
+// through its reflection API when the policy registers it.
+func (c *SubscriptionCache) Register(ctx context.Context, pol *v1alpha1.PlanPolicy) (string, error) {
+	subscription := pol.Spec.Subscription
+	parsed, err := url.Parse(subscription.URL)
+	if err != nil {
+		return "", fmt.Errorf("invalid subscription url %q: %w", subscription.URL, err)
+	}
+	if !c.allowedHosts[strings.ToLower(parsed.Hostname())] {
+		return "", fmt.Errorf("subscription service host %q is not allowed to be cached, set cacheTTL to 0s or have it added to PLAN_SUBSCRIPTION_CACHE_HOSTS", parsed.Hostname())
+	}
+	upstream := subscriptionUpstream{
+		alias:      subscriptionAlias(pol),
+		method:     subscription.Method,
+		fullMethod: "/" + subscription.Service + "/" + subscription.Method,
+		target:     parsed.Host,
+		ttl:        subscription.GetCacheTTL(),
+	}
+	policy := k8stypes.NamespacedName{Namespace: pol.Namespace, Name: pol.Name}
+
+	// the descriptors are only read again when the subscription service changes
+	c.mu.Lock()
+	registered, found := c.policies[policy]
+	c.mu.Unlock()
+	if found && registered.fullMethod == upstream.fullMethod && registered.target == upstream.target {
+		upstream.files, upstream.aliasFile = registered.files, registered.aliasFile
+	} else {
+		fds, err := c.describe(ctx, upstream.target, subscription.Service, subscription.Method)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the descriptors of subscription service %s: %w", subscription.Service, err)
+		}
+		if upstream.files, upstream.aliasFile, err = aliasSubscriptionService(fds, subscription.Service, upstream.alias); err != nil {
+			return "", err
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.policies[policy] = upstream
+	c.purgeLocked()
+	return upstream.alias, nil
+}
+
+// aliasSubscriptionService returns the serialized descriptors of the subscription service, where the file declaring
+// the service declares its alias as well, with the same methods, along with the name of that file
+func aliasSubscriptionService(fds *descriptorpb.FileDescriptorSet, service, alias string) (map[string][]byte, string, error) {
+	files := make(map[string][]byte, len(fds.GetFile()))
+	var aliasFile string
+	for _, file := range fds.GetFile() {
+		file = proto.Clone(file).(*descriptorpb.FileDescriptorProto)
+		for _, svc := range file.GetService() {
+			name := svc.GetName()
+			if file.GetPackage() != "" {
+				name = file.GetPackage() + "." + name
+			}
+			if name != service {
+				continue
+			}
+			aliased := proto.Clone(svc).(*descriptorpb.ServiceDescriptorProto)
+			aliased.Name = proto.String(alias[strings.LastIndex(alias, ".")+1:])
+			file.Service = append(file.Service, aliased)
+			aliasFile = file.GetName()
+			break
+		}
+		serialized, err := proto.Marshal(file)
+		if err != nil {
+			return nil, "", err
+		}
+		files[file.GetName()] = serialized
+	}
+	if aliasFile == "" {
+		return nil, "", fmt.Errorf("subscription service %s not found in its descriptors", service)
+	}
+	return files, aliasFile, nil
+}
+
+// Unregister forgets the subscription service of a policy, along with its cached responses
+func (c *SubscriptionCache) Unregister(policy k8stypes.NamespacedName) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, found := c.policies[policy]; !found {
+		return
+	}
+	delete(c.policies, policy)
+	c.purgeLocked()
+}
+
+// purgeLocked closes the connections to the subscription services no policy is registered with anymore, and drops
+// the responses of the policies no longer registered
+func (c *SubscriptionCache) purgeLocked() {
+	targets := make(map[string]bool, len(c.policies))
+	aliases := make(map[string]bool, len(c.policies))
+	for _, upstream := range c.policies {
+		targets[upstream.target] = true
+		aliases[upstream.alias] = true
+	}
+	for target, conn := range c.conns {
+		if !targets[target] {
+			_ = conn.Close()
+			delete(c.conns, target)
+		}
+	}
+	for key := range c.entries {
+		if alias, _, _ := strings.Cut(key, "\x00"); !aliases[alias] {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// lookup returns the subscription service of the policy the alias belongs to, along with the connection to it
+func (c *SubscriptionCache) lookup(alias string) (subscriptionUpstream, *grpc.ClientConn, error) {
+	upstream, found := c.lookupDescriptors(alias)
+	if !found {
+		return subscriptionUpstream{}, nil, status.Errorf(codes.Unimplemented, "unknown subscription service %s", alias)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	conn, found := c.conns[upstream.target]
+	if !found {
+		var err error
+		if conn, err = c.dial(upstream.target); err != nil {
+			return subscriptionUpstream{}, nil, status.Errorf(codes.Unavailable, "failed to connect to subscription service: %v", err)
+		}
+		c.conns[upstream.target] = conn
+	}
+	return upstream, conn, nil
+}
+
+func cacheKey(alias string, request []byte) string {
+	return alias + "\x00" + string(request)
+}
+
+func (c *SubscriptionCache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, found := c.entries[key]
+	if !found || !c.now().Before(entry.expires) {
+		return nil, false
+	}
+	return entry.response, true
+}
+
+func (c *SubscriptionCache) put(key string, response []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if len(c.entries) >= maxSubscriptionCacheEntries {
+		for k, entry := range c.entries {
+			if !now.Before(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	// still full of live entries, evict an arbitrary one
+	if len(c.entries) >= maxSubscriptionCacheEntries {
+		for k := range c.entries {
+			delete(c.entries, k)
+			break
+		}
+	}
+	c.entries[key] = subscriptionCacheEntry{response: response, expires: now.Add(ttl)}
+}
+
+// handle answers a call to the subscription method of a policy from the cache, or forwards it to the subscription
+// service of the policy and caches the response. Failed calls are not cached.
+func (c *SubscriptionCache) handle(_ any, stream grpc.ServerStream) error {
+	fullMethod, ok := grpc.MethodFromServerStream(stream)
+	if !ok {
+		return status.Error(codes.Internal, "unknown method")
+	}
+	if fullMethod == reflectionFullMethod {
+		return c.serveReflection(stream)
+	}
+
+	alias, method, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	upstream, conn, err := c.lookup(alias)
+	if err != nil {
+		return err
+	}
+	if method != upstream.method {
+		return status.Errorf(codes.Unimplemented, "unknown subscription method %s", fullMethod)
+	}
+
+	var request []byte
+	if err := stream.RecvMsg(&request); err != nil {
+		return err
+	}
+
+	key := cacheKey(alias, request)
+	if response, found := c.get(key); found {
+		return stream.SendMsg(&response)
+	}
+
+	var response []byte
+	if err := conn.Invoke(stream.Context(), upstream.fullMethod, &request, &response, grpc.ForceCodec(rawCodec{})); err != nil {
+		return err
+	}
+	c.put(key, response, upstream.ttl)
+	return stream.SendMsg(&response)
+}
+
+// serveReflection answers the reflection requests for the aliases of the subscription services, and the files they
+// depend on, out of the descriptors read when the policies registered them, so that the data plane can be given
+// the message types of the subscription services
+func (c *SubscriptionCache) serveReflection(stream grpc.ServerStream) error {
+	// the files asked by name are looked up in the descriptors of the alias last asked for
+	var files map[string][]byte
+	for {
+		var message []byte
+		if err := stream.RecvMsg(&message); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		request := &rpb.ServerReflectionRequest{}
+		if err := proto.Unmarshal(message, request); err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid reflection request: %v", err)
+		}
+
+		response := &rpb.ServerReflectionResponse{ValidHost: request.GetHost(), OriginalRequest: request}
+		var file []byte
+		switch request.MessageRequest.(type) {
+		case *rpb.ServerReflectionRequest_FileContainingSymbol:
+			if upstream, found := c.lookupDescriptors(request.GetFileContainingSymbol()); found {
+				files, file = upstream.files, upstream.files[upstream.aliasFile]
+			}
+		case *rpb.ServerReflectionRequest_FileByFilename:
+			file = files[request.GetFileByFilename()]
+		}
+		if file != nil {
+			response.MessageResponse = &rpb.ServerReflectionResponse_FileDescriptorResponse{
+				FileDescriptorResponse: &rpb.FileDescriptorResponse{FileDescriptorProto: [][]byte{file}},
+			}
+		} else {
+			response.MessageResponse = &rpb.ServerReflectionResponse_ErrorResponse{
+				ErrorResponse: &rpb.ErrorResponse{ErrorCode: int32(codes.NotFound), ErrorMessage: "not found"},
+			}
+		}
+
+		serialized, err := proto.Marshal(response)
+		if err != nil {
+			return err
+		}
+		if err := stream.SendMsg(&serialized); err != nil {
+			return err
+		}
+	}
+}
+
+// lookupDescriptors returns the subscription service of the policy the alias belongs to, without connecting to it
+func (c *SubscriptionCache) lookupDescriptors(alias string) (subscriptionUpstream, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return lo.Find(lo.Values(c.policies), func(upstream subscriptionUpstream) bool { return upstream.alias == alias })
+}
+
+// Server returns the gRPC server of the cache, which serves the subscription method of any policy registered
+func (c *SubscriptionCache) Server() *grpc.Server {
+	return grpc.NewServer(grpc.UnknownServiceHandler(c.handle), grpc.ForceServerCodec(rawCodec{}))
+}
+
+// Run serves the subscription cache until the context is done. It returns an error when the cache cannot be served,
+// e.g. because its port is taken.
+func (c *SubscriptionCache) Run(ctx context.Context) error {
+	port, err := env.GetInt("PLAN_SUBSCRIPTION_CACHE_PORT", defaultSubscriptionCachePort)
+	if err != nil {
+		c.logger.Error(err, "invalid PLAN_SUBSCRIPTION_CACHE_PORT, using default", "default", defaultSubscriptionCachePort)
+		port = defaultSubscriptionCachePort
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return fmt.Errorf("failed to listen for subscription cache: %w", err)
+	}
+
+	server := c.Server()
+	serveErr := make(chan error, 1)
+	go func() {
+		c.logger.Info("starting subscription cache", "port", port)
+		if err := server.Serve(listener); err != nil {
+			serveErr <- err
+		}
+	}()
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("subscription cache failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	c.logger.Info("stopping subscription cache")
+	server.GracefulStop()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for target, conn := range c.conns {
+		_ = conn.Close()
+		delete(c.conns, target)
+	}
+	return nil
+}
+
+// rawCodec passes the messages through as they are, so that the cache does not need to know the messages
+// of the subscription services
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	message, ok := v.(*[]byte)
+	if !ok {
+		return nil, fmt.Errorf("unexpected message type %T", v)
+	}
+	return *message, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	message, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("unexpected message type %T", v)
+	}
+	*message = append((*message)[:0], data...)
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
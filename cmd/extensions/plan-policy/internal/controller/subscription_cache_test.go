@@ -0,0 +1,225 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+
+	"github.com/kuadrant/kuadrant-operator/cmd/extensions/plan-policy/api/v1alpha1"
+	"github.com/kuadrant/kuadrant-operator/internal/extension"
+)
+
+// serveGRPC serves the gRPC server on a local port until the end of the test, and returns its address
+func serveGRPC(t *testing.T, server *grpc.Server) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+	return listener.Addr().String()
+}
+
+// subscriptionTestUpstream serves a subscription service answering with the tier of the request, and counts
+// the calls it gets
+func subscriptionTestUpstream(t *testing.T, calls *atomic.Int32) string {
+	t.Helper()
+	return serveGRPC(t, grpc.NewServer(grpc.ForceServerCodec(rawCodec{}), grpc.UnknownServiceHandler(func(_ any, stream grpc.ServerStream) error {
+		calls.Add(1)
+		var request []byte
+		if err := stream.RecvMsg(&request); err != nil {
+			return err
+		}
+		if string(request) == "unknown" {
+			return status.Error(codes.NotFound, "no subscription")
+		}
+		response := append([]byte("tier-of-"), request...)
+		return stream.SendMsg(&response)
+	})))
+}
+
+func subscriptionTestPolicy(name, upstream string, ttl *metav1.Duration) *v1alpha1.PlanPolicy {
+	return &v1alpha1.PlanPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: v1alpha1.PlanPolicySpec{
+			Subscription: &v1alpha1.Subscription{
+				URL:      "grpc://" + upstream,
+				Service:  "billing.v1.SubscriptionService",
+				Method:   "GetSubscription",
+				Request:  `billing.v1.SubscriptionRequest{api_key: request.headers["x-api-key"]}`,
+				CacheTTL: ttl,
+			},
+		},
+	}
+}
+
+// subscriptionTestCache returns a cache allowed to call the local subscription services, which describes them
+// without reading their descriptors through reflection
+func subscriptionTestCache() *SubscriptionCache {
+	cache := NewSubscriptionCache(logr.Discard())
+	cache.allowedHosts = map[string]bool{"127.0.0.1": true}
+	cache.describe = func(_ context.Context, _, _, _ string) (*descriptorpb.FileDescriptorSet, error) {
+		return &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{{
+			Name:    proto.String("billing/v1/subscription.proto"),
+			Package: proto.String("billing.v1"),
+			Service: []*descriptorpb.ServiceDescriptorProto{{
+				Name:   proto.String("SubscriptionService"),
+				Method: []*descriptorpb.MethodDescriptorProto{{Name: proto.String("GetSubscription")}},
+			}},
+		}}}, nil
+	}
+	return cache
+}
+
+func callSubscriptionCache(t *testing.T, conn *grpc.ClientConn, service, request string) (string, error) {
+	t.Helper()
+	message := []byte(request)
+	var response []byte
+	err := conn.Invoke(context.Background(), "/"+service+"/GetSubscription", &message, &response, grpc.ForceCodec(rawCodec{}))
+	return string(response), err
+}
+
+func TestSubscriptionCache(t *testing.T) {
+	var calls atomic.Int32
+	upstream := subscriptionTestUpstream(t, &calls)
+
+	now := time.Unix(1700000000, 0)
+	cache := subscriptionTestCache()
+	cache.now = func() time.Time { return now }
+	conn, err := grpc.NewClient(serveGRPC(t, cache.Server()), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	pol := subscriptionTestPolicy("plans", upstream, nil)
+	if _, err := callSubscriptionCache(t, conn, subscriptionAlias(pol), "key-1"); status.Code(err) != codes.Unimplemented {
+		t.Errorf("expected unregistered subscription services to be rejected, got %v", err)
+	}
+
+	alias, err := cache.Register(context.Background(), pol)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := callSubscriptionCache(t, conn, "billing.v1.SubscriptionService", "key-1"); status.Code(err) != codes.Unimplemented {
+		t.Errorf("expected the subscription service to be only reachable through its alias, got %v", err)
+	}
+	for range 3 {
+		if tier, err := callSubscriptionCache(t, conn, alias, "key-1"); err != nil || tier != "tier-of-key-1" {
+			t.Fatalf("unexpected subscription %q, %v", tier, err)
+		}
+	}
+	if tier, _ := callSubscriptionCache(t, conn, alias, "key-2"); tier != "tier-of-key-2" {
+		t.Errorf("unexpected subscription of another identity %q", tier)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("expected the subscription service to be called once per identity, got %d calls", n)
+	}
+
+	// failures are passed along and not cached
+	for range 2 {
+		if _, err := callSubscriptionCache(t, conn, alias, "unknown"); status.Code(err) != codes.NotFound {
+			t.Errorf("expected the failure of the subscription service, got %v", err)
+		}
+	}
+	if n := calls.Load(); n != 4 {
+		t.Errorf("expected failed calls not to be cached, got %d calls", n)
+	}
+
+	now = now.Add(v1alpha1.DefaultSubscriptionCacheTTL)
+	if _, err := callSubscriptionCache(t, conn, alias, "key-1"); err != nil || calls.Load() != 5 {
+		t.Errorf("expected the subscription to be looked up again once expired, got %d calls, %v", calls.Load(), err)
+	}
+
+	cache.Unregister(k8stypes.NamespacedName{Namespace: "default", Name: "plans"})
+	if _, err := callSubscriptionCache(t, conn, alias, "key-1"); status.Code(err) != codes.Unimplemented {
+		t.Errorf("expected the subscription service to be gone along with the policy, got %v", err)
+	}
+}
+
+func TestSubscriptionCache_Register(t *testing.T) {
+	var calls, otherCalls atomic.Int32
+	upstream := subscriptionTestUpstream(t, &calls)
+	other := subscriptionTestUpstream(t, &otherCalls)
+
+	cache := subscriptionTestCache()
+	conn, err := grpc.NewClient(serveGRPC(t, cache.Server()), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// policies of different tenants use the same subscription method of different subscription services
+	alias, err := cache.Register(context.Background(), subscriptionTestPolicy("plans", upstream, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherAlias, err := cache.Register(context.Background(), subscriptionTestPolicy("other", other, nil))
+	if err != nil {
+		t.Fatalf("expected policies to use the same subscription method of different services, got %v", err)
+	}
+	if alias == otherAlias {
+		t.Fatalf("expected the policies to be given different aliases, got %s", alias)
+	}
+
+	for _, service := range []string{alias, otherAlias} {
+		if tier, err := callSubscriptionCache(t, conn, service, "key-1"); err != nil || tier != "tier-of-key-1" {
+			t.Fatalf("unexpected subscription %q, %v", tier, err)
+		}
+	}
+	if calls.Load() != 1 || otherCalls.Load() != 1 {
+		t.Errorf("expected each policy to call its own subscription service, got %d and %d calls", calls.Load(), otherCalls.Load())
+	}
+
+	if _, err := cache.Register(context.Background(), subscriptionTestPolicy("elsewhere", "billing.example.com:8081", nil)); err == nil {
+		t.Error("expected the subscription service of a host not allowed to be rejected")
+	}
+}
+
+func TestSubscriptionCache_Reflection(t *testing.T) {
+	// the reflection service describes itself, standing for a subscription service
+	server := grpc.NewServer()
+	reflection.Register(server)
+	upstream := serveGRPC(t, server)
+
+	cache := NewSubscriptionCache(logr.Discard())
+	cache.allowedHosts = map[string]bool{"127.0.0.1": true}
+	address := serveGRPC(t, cache.Server())
+	fetch := extension.NewReflectionClient().WithTimeout(5 * time.Second).FetchServiceDescriptors
+
+	if _, err := fetch(context.Background(), address, "grpc.reflection.v1.ServerReflection", "ServerReflectionInfo"); err == nil {
+		t.Error("expected the reflection of the subscription services not to be forwarded")
+	}
+
+	pol := subscriptionTestPolicy("plans", upstream, nil)
+	pol.Spec.Subscription.Service = "grpc.reflection.v1.ServerReflection"
+	pol.Spec.Subscription.Method = "ServerReflectionInfo"
+	alias, err := cache.Register(context.Background(), pol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fds, err := fetch(context.Background(), address, alias, "ServerReflectionInfo")
+	if err != nil {
+		t.Fatalf("expected the cache to describe the alias of the subscription service, got %v", err)
+	}
+	if len(fds.File) == 0 {
+		t.Error("expected the descriptors of the subscription service")
+	}
+}
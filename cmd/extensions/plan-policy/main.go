@@ -10,6 +10,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	kuadrantv1 "github.com/kuadrant/kuadrant-operator/api/v1"
+	kuadrantv1alpha1 "github.com/kuadrant/kuadrant-operator/api/v1alpha1"
 	"github.com/kuadrant/kuadrant-operator/cmd/extensions/plan-policy/internal/controller"
 	extcontroller "github.com/kuadrant/kuadrant-operator/pkg/extension/controller"
 )
@@ -20,24 +21,35 @@ var (
 
 func init() {
 	utilruntime.Must(kuadrantv1.AddToScheme(scheme))
+	utilruntime.Must(kuadrantv1alpha1.AddToScheme(scheme))
 	utilruntime.Must(v1alpha1.AddToScheme(scheme))
 }
 
 func main() {
-	planPolicyReconciler := controller.NewPlanPolicyReconciler()
 	builder, logger := extcontroller.NewBuilder("plan-policy-extension-controller")
+	subscriptionCache := controller.NewSubscriptionCache(logger)
+	planPolicyReconciler := controller.NewPlanPolicyReconciler(subscriptionCache)
 	controller, err := builder.
 		WithScheme(scheme).
 		WithReconciler(planPolicyReconciler.Reconcile).
 		For(&v1alpha1.PlanPolicy{}).
 		Owns(&kuadrantv1.RateLimitPolicy{}).
+		Owns(&kuadrantv1alpha1.TokenRateLimitPolicy{}).
 		Build()
 	if err != nil {
 		logger.Error(err, "unable to create controller")
 		os.Exit(1)
 	}
 
-	if err = controller.Start(ctrl.SetupSignalHandler()); err != nil {
+	ctx := ctrl.SetupSignalHandler()
+	go func() {
+		if err := subscriptionCache.Run(ctx); err != nil {
+			logger.Error(err, "unable to run subscription cache")
+			os.Exit(1)
+		}
+	}()
+
+	if err = controller.Start(ctx); err != nil {
 		logger.Error(err, "unable to start extension controller")
 		os.Exit(1)
 	}
@@ -17,20 +17,17 @@ limitations under the License.
 package v1alpha1
 
 import (
-	"github.com/go-logr/logr"
-	"github.com/google/go-cmp/cmp"
-
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	gatewayapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 
-	extctrl "github.com/kuadrant/kuadrant-operator/pkg/extension/controller"
+	exttypes "github.com/kuadrant/kuadrant-operator/pkg/extension/types"
 )
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 
-// TelemetryPolicy enables custom metric labelling for Kuadrant data plane resources
-// through the use of dynamically evaluated CEL expressions.
+// TelemetryPolicy enables custom metric labelling and custom metrics for Kuadrant data plane
+// resources through the use of dynamically evaluated CEL expressions.
 type TelemetryPolicy struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -42,7 +39,7 @@ type TelemetryPolicy struct {
 type TelemetryPolicySpec struct {
 	// Reference to the object to which this policy applies.
 	// +kubebuilder:validation:XValidation:rule="self.group == 'gateway.networking.k8s.io'",message="Invalid targetRef.group. The only supported value is 'gateway.networking.k8s.io'"
-	// +kubebuilder:validation:XValidation:rule="self.kind == 'Gateway'",message="Invalid targetRef.kind. The only supported value is 'Gateway'"
+	// +kubebuilder:validation:XValidation:rule="self.kind == 'Gateway' || self.kind == 'HTTPRoute'",message="Invalid targetRef.kind. The only supported values are 'Gateway' and 'HTTPRoute'"
 	TargetRef gatewayapiv1alpha2.LocalPolicyTargetReferenceWithSectionName `json:"targetRef"`
 
 	// Metrics holds the telemetry metrics configuration
@@ -63,10 +60,26 @@ func (p *TelemetryPolicy) GetTargetRefs() []gatewayapiv1alpha2.LocalPolicyTarget
 	}
 }
 
+func (p *TelemetryPolicy) GetStatus() exttypes.PolicyStatus {
+	return &p.Status
+}
+
 // MetricsSpec defines the configuration for telemetry metrics
+// +kubebuilder:validation:XValidation:rule="has(self.default) || has(self.custom)",message="At least one of default or custom must be set"
 type MetricsSpec struct {
 	// Default metrics configuration that applies to all requests
-	Default MetricsConfig `json:"default"`
+	// +optional
+	Default *MetricsConfig `json:"default,omitempty"`
+
+	// Custom metrics recorded by the gateway for every request and exported with its Envoy stats.
+	// A custom metric of a policy targeting an HTTPRoute overrides the one of the same name of a policy
+	// targeting the Gateway.
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=16
+	// +optional
+	Custom []CustomMetric `json:"custom,omitempty"`
 }
 
 // MetricsConfig defines reusable metrics configuration that can be applied to different metric types
@@ -77,6 +90,44 @@ type MetricsConfig struct {
 	Labels map[string]string `json:"labels"`
 }
 
+// MetricType is the type of a custom metric
+// +kubebuilder:validation:Enum=Counter;Histogram
+type MetricType string
+
+const (
+	MetricTypeCounter   MetricType = "Counter"
+	MetricTypeHistogram MetricType = "Histogram"
+)
+
+// CustomMetric defines a metric recorded by the gateway for every request
+// +kubebuilder:validation:XValidation:rule="self.type == 'Histogram' || !has(self.buckets)",message="buckets are only allowed with type Histogram"
+type CustomMetric struct {
+	// Name of the metric
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z_][a-zA-Z0-9_]*$`
+	// +kubebuilder:validation:MaxLength=63
+	Name string `json:"name"`
+
+	// Type of the metric
+	// +kubebuilder:default=Counter
+	// +optional
+	Type MetricType `json:"type,omitempty"`
+
+	// Value is a CEL expression evaluating to the amount added to the counter, or to the value observed
+	// by the histogram, e.g. `response.size` or `responseBodyJSON('/usage/total_tokens')`.
+	// +kubebuilder:validation:MinLength=1
+	Value string `json:"value"`
+
+	// Labels to add to the metric, where keys are label names and values are CEL expressions.
+	// Only labels whose CEL expressions resolve successfully will be included.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Buckets are the upper bounds of the buckets of a histogram, in the unit of its value
+	// +kubebuilder:validation:MinItems=1
+	// +optional
+	Buckets []int64 `json:"buckets,omitempty"`
+}
+
 // TelemetryPolicyStatus defines the observed state of TelemetryPolicy
 type TelemetryPolicyStatus struct {
 	// ObservedGeneration reflects the generation of the most recently observed spec.
@@ -92,23 +143,20 @@ type TelemetryPolicyStatus struct {
 	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
 }
 
-func (s *TelemetryPolicyStatus) Equals(other *TelemetryPolicyStatus, logger logr.Logger) bool {
-	if s.ObservedGeneration != other.ObservedGeneration {
-		diff := cmp.Diff(s.ObservedGeneration, other.ObservedGeneration)
-		logger.V(1).Info("status observedGeneration not equal", "difference", diff)
-		return false
-	}
+func (s *TelemetryPolicyStatus) GetObservedGeneration() int64 {
+	return s.ObservedGeneration
+}
 
-	// Marshalling sorts by condition type
-	currentMarshaledJSON, _ := extctrl.ConditionMarshal(s.Conditions)
-	otherMarshaledJSON, _ := extctrl.ConditionMarshal(other.Conditions)
-	if string(currentMarshaledJSON) != string(otherMarshaledJSON) {
-		diff := cmp.Diff(string(currentMarshaledJSON), string(otherMarshaledJSON))
-		logger.V(1).Info("status conditions not equal", "difference", diff)
-		return false
-	}
+func (s *TelemetryPolicyStatus) SetObservedGeneration(generation int64) {
+	s.ObservedGeneration = generation
+}
+
+func (s *TelemetryPolicyStatus) GetConditions() []metav1.Condition {
+	return s.Conditions
+}
 
-	return true
+func (s *TelemetryPolicyStatus) SetConditions(conditions []metav1.Condition) {
+	s.Conditions = conditions
 }
 
 //+kubebuilder:object:root=true
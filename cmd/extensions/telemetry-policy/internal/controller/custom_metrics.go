@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kuadrant/kuadrant-operator/cmd/extensions/telemetry-policy/api/v1alpha1"
+	"github.com/kuadrant/kuadrant-operator/internal/wasm"
+	"github.com/kuadrant/kuadrant-operator/pkg/extension/types"
+)
+
+// customMetricBindings returns the request bindings defining the custom metric in the wasm config of the
+// gateway. The type and the buckets are passed as CEL literals, like the value and the labels.
+func customMetricBindings(metric v1alpha1.CustomMetric) (map[string]string, error) {
+	metricType := metric.Type
+	if metricType == "" {
+		metricType = v1alpha1.MetricTypeCounter
+	}
+
+	bindings := map[string]string{
+		types.KuadrantCustomMetricBinding(metric.Name, "type"):  strconv.Quote(strings.ToLower(string(metricType))),
+		types.KuadrantCustomMetricBinding(metric.Name, "value"): metric.Value,
+	}
+	if len(metric.Buckets) > 0 {
+		buckets, err := json.Marshal(metric.Buckets)
+		if err != nil {
+			return nil, err
+		}
+		bindings[types.KuadrantCustomMetricBinding(metric.Name, "buckets")] = string(buckets)
+	}
+	for label, expression := range metric.Labels {
+		bindings[types.KuadrantCustomMetricBinding(metric.Name, "labels."+label)] = expression
+	}
+
+	// the bindings are read back as the gateway does, which drops the invalid metrics
+	dataBindings := make([]wasm.DataBinding, 0, len(bindings))
+	for binding, expression := range bindings {
+		domain, field := wasm.DomainAndFieldName(binding)
+		dataBindings = append(dataBindings, wasm.DataBinding{Domain: domain, Field: field, Expression: expression})
+	}
+	if _, _, err := wasm.MetricsFromBindings(dataBindings); err != nil {
+		return nil, fmt.Errorf("invalid custom metric %s: %w", metric.Name, err)
+	}
+	return bindings, nil
+}
@@ -0,0 +1,73 @@
+//go:build unit
+
+package controller
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/kuadrant/kuadrant-operator/cmd/extensions/telemetry-policy/api/v1alpha1"
+	"github.com/kuadrant/kuadrant-operator/internal/wasm"
+)
+
+func TestCustomMetricBindings(t *testing.T) {
+	metric := v1alpha1.CustomMetric{
+		Name:    "tokens",
+		Type:    v1alpha1.MetricTypeHistogram,
+		Value:   "responseBodyJSON('/usage/total_tokens')",
+		Labels:  map[string]string{"model": "requestBodyJSON('/model')"},
+		Buckets: []int64{100, 1000, 10000},
+	}
+
+	bindings, err := customMetricBindings(metric)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the bindings must be read back by the operator as the very same metric
+	var dataBindings []wasm.DataBinding
+	for binding, expression := range bindings {
+		domain, field := wasm.DomainAndFieldName(binding)
+		dataBindings = append(dataBindings, wasm.DataBinding{Domain: domain, Field: field, Expression: expression})
+	}
+	others, metrics, err := wasm.MetricsFromBindings(dataBindings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(others) != 0 {
+		t.Errorf("expected no other bindings, got %v", others)
+	}
+	expected := []wasm.Metric{{
+		Name:    "tokens",
+		Type:    wasm.MetricTypeHistogram,
+		Value:   metric.Value,
+		Labels:  metric.Labels,
+		Buckets: metric.Buckets,
+	}}
+	if diff := cmp.Diff(expected, metrics); diff != "" {
+		t.Errorf("unexpected metrics (-want +got):\n%s", diff)
+	}
+}
+
+func TestCustomMetricBindings_DefaultsToCounter(t *testing.T) {
+	bindings, err := customMetricBindings(v1alpha1.CustomMetric{Name: "requests", Value: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := bindings["metrics.custom.requests.type"]; got != `"counter"` {
+		t.Errorf("unexpected metric type binding %s", got)
+	}
+}
+
+func TestCustomMetricBindings_Invalid(t *testing.T) {
+	metrics := []v1alpha1.CustomMetric{
+		{Name: "tokens", Type: v1alpha1.MetricTypeHistogram, Value: "1", Buckets: []int64{1000, 100}},
+		{Name: "requests", Value: "1", Labels: map[string]string{"model-name": "requestBodyJSON('/model')"}},
+	}
+	for _, metric := range metrics {
+		if _, err := customMetricBindings(metric); err == nil {
+			t.Errorf("expected custom metric %s to be rejected", metric.Name)
+		}
+	}
+}
@@ -17,13 +17,15 @@ limitations under the License.
 package v1alpha1
 
 import (
-	"github.com/go-logr/logr"
-	"github.com/google/go-cmp/cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	gatewayapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 
-	extctrl "github.com/kuadrant/kuadrant-operator/pkg/extension/controller"
+	kuadrantv1 "github.com/kuadrant/kuadrant-operator/api/v1"
+	exttypes "github.com/kuadrant/kuadrant-operator/pkg/extension/types"
 )
 
 //+kubebuilder:object:root=true
@@ -39,17 +41,185 @@ type ThreatPolicy struct {
 	Status ThreatPolicyStatus `json:"status,omitempty"`
 }
 
+// +kubebuilder:validation:XValidation:rule="has(self.threshold) != has(self.actions)",message="Exactly one of threshold or actions must be set"
+// +kubebuilder:validation:XValidation:rule="!(has(self.signals) && has(self.service) && has(self.service.request))",message="signals are not allowed with a custom service request"
 type ThreatPolicySpec struct {
 	// Reference to the Gateway API resource to which this policy applies.
 	// +kubebuilder:validation:XValidation:rule="self.group == 'gateway.networking.k8s.io'",message="Invalid targetRef.group. The only supported value is 'gateway.networking.k8s.io'"
 	// +kubebuilder:validation:XValidation:rule="self.kind == 'HTTPRoute' || self.kind == 'Gateway'",message="Invalid targetRef.kind. The only supported values are 'HTTPRoute' and 'Gateway'"
 	TargetRef gatewayapiv1alpha2.LocalPolicyTargetReferenceWithSectionName `json:"targetRef"`
 
+	// Service is the threat assessment service scoring the requests.
+	// Defaults to the threat.v1.ThreatAssessmentService at threat-assessment-service.security.svc.cluster.local:8080.
+	// +optional
+	Service *ThreatService `json:"service,omitempty"`
+
+	// Signals are the attributes of the request sent to the threat assessment service,
+	// in addition to the path and the source address.
+	// +optional
+	Signals *Signals `json:"signals,omitempty"`
+
 	// Threshold is the threat level threshold (0-10) for blocking requests.
 	// Requests with a threat score at or above this threshold will be blocked.
+	// Shorthand for a single Deny action.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=10
+	// +optional
+	Threshold *int `json:"threshold,omitempty"`
+
+	// Actions are the graduated responses to the threat score of the requests. Every action whose
+	// minimum score is reached applies, Deny taking precedence over Challenge.
+	// +listType=map
+	// +listMapKey=type
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=4
+	// +optional
+	Actions []ThreatAction `json:"actions,omitempty"`
+}
+
+const (
+	DefaultThreatServiceURL = "grpc://threat-assessment-service.security.svc.cluster.local:8080"
+	DefaultThreatService    = "threat.v1.ThreatAssessmentService"
+	DefaultThreatMethod     = "AssessRequest"
+	DefaultScoreField       = "threat_level"
+	DefaultTagHeader        = "x-threat-level"
+)
+
+// ThreatService is a gRPC service assessing the threat level of the requests
+type ThreatService struct {
+	// URL of the threat assessment service
+	// +kubebuilder:validation:Pattern=`^grpc://`
+	URL string `json:"url"`
+
+	// Service is the fully qualified name of the gRPC service
+	// +optional
+	Service string `json:"service,omitempty"`
+
+	// Method is the name of the method of the gRPC service
+	// +optional
+	Method string `json:"method,omitempty"`
+
+	// Request is a CEL expression building the request message to the method, e.g.
+	// `acme.v1.ScoreRequest{ip: source.address}`. Replaces the message built from the signals.
+	// +optional
+	Request string `json:"request,omitempty"`
+
+	// ScoreField is the field of the response holding the threat score, from 0 to 10
+	// +optional
+	ScoreField string `json:"scoreField,omitempty"`
+}
+
+// Signals selects the attributes of the request sent to the threat assessment service
+type Signals struct {
+	// Headers are the names of the request headers sent in the headers field of the request message
+	// +kubebuilder:validation:MaxItems=16
+	// +kubebuilder:validation:items:Pattern=`^[a-zA-Z0-9_.-]+$`
+	// +optional
+	Headers []string `json:"headers,omitempty"`
+
+	// UserAgent sends the user agent of the request in the user_agent field of the request message
+	// +optional
+	UserAgent bool `json:"userAgent,omitempty"`
+
+	// TLS sends the TLS version and the server name indication of the downstream connection
+	// in the tls_version and sni fields of the request message
+	// +optional
+	TLS bool `json:"tls,omitempty"`
+
+	// JA3Header is the name of the request header carrying the JA3 fingerprint of the TLS handshake of the
+	// client, as computed by the load balancer in front of the gateway. Sent in the ja3 field of the request message.
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9_.-]+$`
+	// +optional
+	JA3Header string `json:"ja3Header,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=Tag;RateLimit;Challenge;Deny
+type ThreatActionType string
+
+const (
+	// ThreatActionTag tags the request forwarded upstream with its threat score
+	ThreatActionTag ThreatActionType = "Tag"
+	// ThreatActionRateLimit applies stricter rate limits to the client
+	ThreatActionRateLimit ThreatActionType = "RateLimit"
+	// ThreatActionChallenge redirects the client to a challenge, e.g. a CAPTCHA
+	ThreatActionChallenge ThreatActionType = "Challenge"
+	// ThreatActionDeny rejects the request
+	ThreatActionDeny ThreatActionType = "Deny"
+)
+
+// ThreatAction is a response applied to the requests reaching a threat score
+// +kubebuilder:validation:XValidation:rule="self.type == 'RateLimit' ? has(self.rateLimit) : !has(self.rateLimit)",message="rateLimit must be set with type RateLimit only"
+// +kubebuilder:validation:XValidation:rule="self.type == 'Challenge' ? has(self.challenge) : !has(self.challenge)",message="challenge must be set with type Challenge only"
+// +kubebuilder:validation:XValidation:rule="self.type == 'Tag' || !has(self.header)",message="header is only allowed with type Tag"
+type ThreatAction struct {
+	// MinScore is the lowest threat score the action applies to
 	// +kubebuilder:validation:Minimum=0
 	// +kubebuilder:validation:Maximum=10
-	Threshold int `json:"threshold"`
+	MinScore int `json:"minScore"`
+
+	// Type of the action
+	Type ThreatActionType `json:"type"`
+
+	// Header is the name of the request header holding the threat score, for type Tag. Defaults to x-threat-level.
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9_.-]+$`
+	// +optional
+	Header string `json:"header,omitempty"`
+
+	// RateLimit holds the rates applied to each client address, for type RateLimit
+	// +optional
+	RateLimit *ThreatRateLimit `json:"rateLimit,omitempty"`
+
+	// Challenge holds the challenge the client is redirected to, for type Challenge
+	// +optional
+	Challenge *Challenge `json:"challenge,omitempty"`
+}
+
+type ThreatRateLimit struct {
+	// Rates of the limit applied to each client address
+	// +kubebuilder:validation:MinItems=1
+	Rates []kuadrantv1.Rate `json:"rates"`
+}
+
+type Challenge struct {
+	// URL of the challenge page the client is redirected to
+	// +kubebuilder:validation:Pattern=`^https?://`
+	URL string `json:"url"`
+}
+
+// GetActions returns the actions of the policy, the threshold standing for a single Deny action
+func (p *ThreatPolicy) GetActions() []ThreatAction {
+	if p.Spec.Threshold != nil {
+		return []ThreatAction{{MinScore: *p.Spec.Threshold, Type: ThreatActionDeny}}
+	}
+	return p.Spec.Actions
+}
+
+// GetService returns the threat assessment service of the policy, with the defaults applied
+func (p *ThreatPolicy) GetService() ThreatService {
+	service := ThreatService{}
+	if p.Spec.Service != nil {
+		service = *p.Spec.Service
+	}
+	if service.URL == "" {
+		service.URL = DefaultThreatServiceURL
+	}
+	if service.Service == "" {
+		service.Service = DefaultThreatService
+	}
+	if service.Method == "" {
+		service.Method = DefaultThreatMethod
+	}
+	if service.ScoreField == "" {
+		service.ScoreField = DefaultScoreField
+	}
+	return service
+}
+
+// ScoreFunctionName returns the name of the CEL function exposing the threat score of the request to
+// the rate limits, unique to the policy
+func (p *ThreatPolicy) ScoreFunctionName() string {
+	hash := sha256.Sum256([]byte(p.GetNamespace() + "/" + p.GetName()))
+	return fmt.Sprintf("threat_%s.score", hex.EncodeToString(hash[:4]))
 }
 
 func (p *ThreatPolicy) GetName() string {
@@ -66,6 +236,10 @@ func (p *ThreatPolicy) GetTargetRefs() []gatewayapiv1alpha2.LocalPolicyTargetRef
 	}
 }
 
+func (p *ThreatPolicy) GetStatus() exttypes.PolicyStatus {
+	return &p.Status
+}
+
 // ThreatPolicyStatus defines the observed state of ThreatPolicy
 type ThreatPolicyStatus struct {
 	// +optional
@@ -78,22 +252,20 @@ type ThreatPolicyStatus struct {
 	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
 }
 
-func (s *ThreatPolicyStatus) Equals(other *ThreatPolicyStatus, logger logr.Logger) bool {
-	if s.ObservedGeneration != other.ObservedGeneration {
-		diff := cmp.Diff(s.ObservedGeneration, other.ObservedGeneration)
-		logger.V(1).Info("status observedGeneration not equal", "difference", diff)
-		return false
-	}
+func (s *ThreatPolicyStatus) GetObservedGeneration() int64 {
+	return s.ObservedGeneration
+}
 
-	currentMarshaledJSON, _ := extctrl.ConditionMarshal(s.Conditions)
-	otherMarshaledJSON, _ := extctrl.ConditionMarshal(other.Conditions)
-	if string(currentMarshaledJSON) != string(otherMarshaledJSON) {
-		diff := cmp.Diff(string(currentMarshaledJSON), string(otherMarshaledJSON))
-		logger.V(1).Info("status conditions not equal", "difference", diff)
-		return false
-	}
+func (s *ThreatPolicyStatus) SetObservedGeneration(generation int64) {
+	s.ObservedGeneration = generation
+}
+
+func (s *ThreatPolicyStatus) GetConditions() []metav1.Condition {
+	return s.Conditions
+}
 
-	return true
+func (s *ThreatPolicyStatus) SetConditions(conditions []metav1.Condition) {
+	s.Conditions = conditions
 }
 
 //+kubebuilder:object:root=true
@@ -21,10 +21,71 @@ limitations under the License.
 package v1alpha1
 
 import (
-	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"github.com/kuadrant/kuadrant-operator/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Challenge) DeepCopyInto(out *Challenge) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Challenge.
+func (in *Challenge) DeepCopy() *Challenge {
+	if in == nil {
+		return nil
+	}
+	out := new(Challenge)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Signals) DeepCopyInto(out *Signals) {
+	*out = *in
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Signals.
+func (in *Signals) DeepCopy() *Signals {
+	if in == nil {
+		return nil
+	}
+	out := new(Signals)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ThreatAction) DeepCopyInto(out *ThreatAction) {
+	*out = *in
+	if in.RateLimit != nil {
+		in, out := &in.RateLimit, &out.RateLimit
+		*out = new(ThreatRateLimit)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Challenge != nil {
+		in, out := &in.Challenge, &out.Challenge
+		*out = new(Challenge)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ThreatAction.
+func (in *ThreatAction) DeepCopy() *ThreatAction {
+	if in == nil {
+		return nil
+	}
+	out := new(ThreatAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ThreatPolicy) DeepCopyInto(out *ThreatPolicy) {
 	*out = *in
@@ -88,6 +149,28 @@ func (in *ThreatPolicyList) DeepCopyObject() runtime.Object {
 func (in *ThreatPolicySpec) DeepCopyInto(out *ThreatPolicySpec) {
 	*out = *in
 	in.TargetRef.DeepCopyInto(&out.TargetRef)
+	if in.Service != nil {
+		in, out := &in.Service, &out.Service
+		*out = new(ThreatService)
+		**out = **in
+	}
+	if in.Signals != nil {
+		in, out := &in.Signals, &out.Signals
+		*out = new(Signals)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Threshold != nil {
+		in, out := &in.Threshold, &out.Threshold
+		*out = new(int)
+		**out = **in
+	}
+	if in.Actions != nil {
+		in, out := &in.Actions, &out.Actions
+		*out = make([]ThreatAction, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ThreatPolicySpec.
@@ -105,7 +188,7 @@ func (in *ThreatPolicyStatus) DeepCopyInto(out *ThreatPolicyStatus) {
 	*out = *in
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
-		*out = make([]v1.Condition, len(*in))
+		*out = make([]metav1.Condition, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -121,3 +204,38 @@ func (in *ThreatPolicyStatus) DeepCopy() *ThreatPolicyStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ThreatRateLimit) DeepCopyInto(out *ThreatRateLimit) {
+	*out = *in
+	if in.Rates != nil {
+		in, out := &in.Rates, &out.Rates
+		*out = make([]v1.Rate, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ThreatRateLimit.
+func (in *ThreatRateLimit) DeepCopy() *ThreatRateLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(ThreatRateLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ThreatService) DeepCopyInto(out *ThreatService) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ThreatService.
+func (in *ThreatService) DeepCopy() *ThreatService {
+	if in == nil {
+		return nil
+	}
+	out := new(ThreatService)
+	in.DeepCopyInto(out)
+	return out
+}
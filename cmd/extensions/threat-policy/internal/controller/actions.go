@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+
+	"github.com/kuadrant/kuadrant-operator/cmd/extensions/threat-policy/api/v1alpha1"
+	"github.com/kuadrant/kuadrant-operator/internal/wasm"
+	"github.com/kuadrant/kuadrant-operator/pkg/extension/types"
+)
+
+const (
+	// assessMethodName is the action method assessing the threat level of the request
+	assessMethodName = "assess-threat"
+	// threatVar holds the response of the threat assessment service
+	threatVar = "threatResponse"
+	// defaultRequestMessage is the request message built from the signals
+	defaultRequestMessage = "threat.v1.ThreatRequest"
+)
+
+// actionPrecedence orders the actions applied in the data plane, the terminal ones first, so that a denied
+// request is neither challenged nor tagged. Rate limits are enforced by a RateLimitPolicy instead.
+var actionPrecedence = []v1alpha1.ThreatActionType{
+	v1alpha1.ThreatActionDeny,
+	v1alpha1.ThreatActionChallenge,
+	v1alpha1.ThreatActionTag,
+}
+
+// headerValue returns the CEL expression of the value of the request header, empty when missing
+func headerValue(name string) string {
+	key := strconv.Quote(strings.ToLower(name))
+	return fmt.Sprintf(`%s in request.headers ? request.headers[%s] : ""`, key, key)
+}
+
+// buildMessageTemplate returns the CEL template of the request message sent to the threat assessment
+// service, the one of the service when set, else built from the signals of the policy
+func buildMessageTemplate(pol *v1alpha1.ThreatPolicy) string {
+	if request := pol.GetService().Request; request != "" {
+		return request
+	}
+
+	fields := []string{"uri: request.path", "source_ip: source.address"}
+	if signals := pol.Spec.Signals; signals != nil {
+		if signals.UserAgent {
+			fields = append(fields, "user_agent: "+headerValue("user-agent"))
+		}
+		if signals.TLS {
+			fields = append(fields,
+				`tls_version: has(connection.tls_version) ? connection.tls_version : ""`,
+				`sni: has(connection.requested_server_name) ? connection.requested_server_name : ""`,
+			)
+		}
+		if signals.JA3Header != "" {
+			fields = append(fields, "ja3: "+headerValue(signals.JA3Header))
+		}
+		if len(signals.Headers) > 0 {
+			headers := lo.Map(lo.Uniq(signals.Headers), func(name string, _ int) string {
+				return fmt.Sprintf("%s: %s", strconv.Quote(strings.ToLower(name)), headerValue(name))
+			})
+			fields = append(fields, fmt.Sprintf("headers: {%s}", strings.Join(headers, ", ")))
+		}
+	}
+
+	return fmt.Sprintf("%s{%s}", defaultRequestMessage, strings.Join(fields, ", "))
+}
+
+// hasRateLimit tells whether the policy limits the clients reaching a threat score
+func hasRateLimit(pol *v1alpha1.ThreatPolicy) bool {
+	return lo.ContainsBy(pol.GetActions(), func(a v1alpha1.ThreatAction) bool { return a.Type == v1alpha1.ThreatActionRateLimit })
+}
+
+// storedScore returns the CEL expression of the threat score fetched ahead of the pipeline by the score
+// function of the rate limit, where the rate limit applies
+func storedScore(pol *v1alpha1.ThreatPolicy) string {
+	return wasm.FunctionStorePath(pol.ScoreFunctionName())
+}
+
+// scoreExpression returns the CEL expression of the threat score of the request. With a rate limit, the score
+// fetched for the rate limit is reused rather than assessed again.
+func scoreExpression(pol *v1alpha1.ThreatPolicy) string {
+	score := fmt.Sprintf("%s.%s", threatVar, pol.GetService().ScoreField)
+	if !hasRateLimit(pol) {
+		return score
+	}
+	stored := storedScore(pol)
+	return fmt.Sprintf("(has(%s) ? %s : %s)", stored, stored, score)
+}
+
+// assessPredicate returns the predicate of the call to the threat assessment service, skipped when the score
+// was already fetched for the rate limit
+func assessPredicate(pol *v1alpha1.ThreatPolicy) string {
+	if !hasRateLimit(pol) {
+		return ""
+	}
+	return fmt.Sprintf("!has(%s)", storedScore(pol))
+}
+
+// buildRequestActions returns the actions assessing the request and applying the actions of the policy
+// reaching its threat score
+func buildRequestActions(pol *v1alpha1.ThreatPolicy) []types.Action {
+	score := scoreExpression(pol)
+	actions := []types.Action{
+		types.GRPCMethodAction{
+			Predicate: assessPredicate(pol),
+			Method:    assessMethodName,
+			Var:       threatVar,
+		},
+		types.FailAction{
+			Predicate:  fmt.Sprintf("%s < 0", score),
+			LogMessage: fmt.Sprintf("threat assessment returned invalid %s", pol.GetService().ScoreField),
+		},
+	}
+
+	for _, actionType := range actionPrecedence {
+		action, found := lo.Find(pol.GetActions(), func(a v1alpha1.ThreatAction) bool { return a.Type == actionType })
+		if !found {
+			continue
+		}
+		predicate := fmt.Sprintf("%s >= %d", score, action.MinScore)
+
+		switch action.Type {
+		case v1alpha1.ThreatActionDeny:
+			actions = append(actions, types.DenyAction{
+				Predicate:   predicate,
+				WithStatus:  403,
+				WithHeaders: fmt.Sprintf(`[["x-threat-blocked", "true"], ["x-threat-level", string(%s)]]`, score),
+				WithBody:    "'Request blocked: threat level exceeds threshold'",
+			})
+		case v1alpha1.ThreatActionChallenge:
+			actions = append(actions, types.DenyAction{
+				Predicate:   predicate,
+				WithStatus:  302,
+				WithHeaders: fmt.Sprintf(`[["location", %s], ["x-threat-level", string(%s)]]`, strconv.Quote(action.Challenge.URL), score),
+			})
+		case v1alpha1.ThreatActionTag:
+			header := action.Header
+			if header == "" {
+				header = v1alpha1.DefaultTagHeader
+			}
+			actions = append(actions, types.AddHeadersAction{
+				Predicate:    predicate,
+				HeadersToAdd: fmt.Sprintf(`[[%s, string(%s)]]`, strconv.Quote(strings.ToLower(header)), score),
+			})
+		}
+	}
+
+	return actions
+}
+
+// buildResponseActions returns the actions reporting the threat score of the request in the response
+func buildResponseActions(pol *v1alpha1.ThreatPolicy) []types.Action {
+	headers := fmt.Sprintf(`[["x-threat-score", string(%s)]]`, scoreExpression(pol))
+	if threshold := pol.Spec.Threshold; threshold != nil {
+		headers = fmt.Sprintf(`[["x-threat-threshold", "%d"], ["x-threat-score", string(%s)]]`, *threshold, scoreExpression(pol))
+	}
+	return []types.Action{
+		types.AddHeadersAction{
+			HeadersToAdd: headers,
+		},
+	}
+}
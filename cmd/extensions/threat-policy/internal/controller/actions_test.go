@@ -0,0 +1,174 @@
+//go:build unit
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/cel-go/cel"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	gatewayapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	kuadrantv1 "github.com/kuadrant/kuadrant-operator/api/v1"
+	"github.com/kuadrant/kuadrant-operator/cmd/extensions/threat-policy/api/v1alpha1"
+	"github.com/kuadrant/kuadrant-operator/internal/wasm"
+	"github.com/kuadrant/kuadrant-operator/pkg/extension/types"
+)
+
+func testThreatPolicy(spec v1alpha1.ThreatPolicySpec) *v1alpha1.ThreatPolicy {
+	spec.TargetRef = gatewayapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+		LocalPolicyTargetReference: gatewayapiv1alpha2.LocalPolicyTargetReference{
+			Group: "gateway.networking.k8s.io",
+			Kind:  "HTTPRoute",
+			Name:  "toystore",
+		},
+	}
+	return &v1alpha1.ThreatPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "threats", Namespace: "default"},
+		Spec:       spec,
+	}
+}
+
+func TestBuildMessageTemplate(t *testing.T) {
+	pol := testThreatPolicy(v1alpha1.ThreatPolicySpec{
+		Threshold: ptr.To(8),
+		Signals: &v1alpha1.Signals{
+			Headers:   []string{"X-Forwarded-For", "Accept-Language"},
+			UserAgent: true,
+			TLS:       true,
+			JA3Header: "X-JA3",
+		},
+	})
+	template := buildMessageTemplate(pol)
+
+	for _, expected := range []string{
+		`threat.v1.ThreatRequest{uri: request.path, source_ip: source.address`,
+		`user_agent: "user-agent" in request.headers ? request.headers["user-agent"] : ""`,
+		`tls_version: has(connection.tls_version)`,
+		`ja3: "x-ja3" in request.headers ? request.headers["x-ja3"] : ""`,
+		`headers: {"x-forwarded-for": `,
+		`"accept-language": "accept-language" in request.headers`,
+	} {
+		if !strings.Contains(template, expected) {
+			t.Errorf("message template missing expected pattern: %s\nGot: %s", expected, template)
+		}
+	}
+
+	pol.Spec.Signals = nil
+	pol.Spec.Service = &v1alpha1.ThreatService{URL: "grpc://scoring:9000", Request: `acme.v1.ScoreRequest{ip: source.address}`}
+	if template := buildMessageTemplate(pol); template != pol.Spec.Service.Request {
+		t.Errorf("expected the request of the service, got %s", template)
+	}
+}
+
+func TestBuildMessageTemplate_Headers(t *testing.T) {
+	env, err := cel.NewEnv(cel.Variable("request", cel.DynType))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"user-agent", "x-missing"} {
+		ast, iss := env.Compile(headerValue(name))
+		if iss != nil && iss.Err() != nil {
+			t.Fatalf("compile failed: %v", iss.Err())
+		}
+		prg, err := env.Program(ast)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, _, err := prg.Eval(map[string]interface{}{
+			"request": map[string]interface{}{"headers": map[string]string{"user-agent": "curl"}},
+		})
+		if err != nil {
+			t.Fatalf("eval failed for header %s: %v", name, err)
+		}
+		want := map[string]string{"user-agent": "curl", "x-missing": ""}[name]
+		if out.Value() != want {
+			t.Errorf("header %s = %v, want %q", name, out.Value(), want)
+		}
+	}
+}
+
+func TestBuildRequestActions(t *testing.T) {
+	pol := testThreatPolicy(v1alpha1.ThreatPolicySpec{
+		Service: &v1alpha1.ThreatService{URL: "grpc://scoring:9000", ScoreField: "score"},
+		Actions: []v1alpha1.ThreatAction{
+			{MinScore: 3, Type: v1alpha1.ThreatActionTag},
+			{MinScore: 5, Type: v1alpha1.ThreatActionRateLimit, RateLimit: &v1alpha1.ThreatRateLimit{Rates: []kuadrantv1.Rate{{Limit: 10, Window: "1m"}}}},
+			{MinScore: 7, Type: v1alpha1.ThreatActionChallenge, Challenge: &v1alpha1.Challenge{URL: "https://challenge.example.com"}},
+			{MinScore: 9, Type: v1alpha1.ThreatActionDeny},
+		},
+	})
+
+	// the score fetched for the rate limit is reused
+	stored := wasm.FunctionStorePath(pol.ScoreFunctionName())
+	score := fmt.Sprintf("(has(%s) ? %s : threatResponse.score)", stored, stored)
+
+	actions := buildRequestActions(pol)
+	if len(actions) != 5 {
+		t.Fatalf("expected 5 actions, got %d", len(actions))
+	}
+	if assess := actions[0].(types.GRPCMethodAction); assess.Predicate != "!has("+stored+")" {
+		t.Errorf("expected the assessment to be skipped when the score was fetched, got predicate %q", assess.Predicate)
+	}
+	if fail := actions[1].(types.FailAction); fail.Predicate != score+" < 0" {
+		t.Errorf("unexpected fail predicate %s", fail.Predicate)
+	}
+	deny, ok := actions[2].(types.DenyAction)
+	if !ok || deny.WithStatus != 403 || deny.Predicate != score+" >= 9" {
+		t.Errorf("expected the deny action to come first, got %+v", actions[2])
+	}
+	challenge, ok := actions[3].(types.DenyAction)
+	if !ok || challenge.WithStatus != 302 || !strings.Contains(challenge.WithHeaders, `["location", "https://challenge.example.com"]`) {
+		t.Errorf("expected the challenge action to come second, got %+v", actions[3])
+	}
+	tag, ok := actions[4].(types.AddHeadersAction)
+	if !ok || tag.Predicate != score+" >= 3" || tag.HeadersToAdd != fmt.Sprintf(`[["x-threat-level", string(%s)]]`, score) {
+		t.Errorf("expected the tag action to come last, got %+v", actions[4])
+	}
+}
+
+func TestBuildRequestActions_Threshold(t *testing.T) {
+	pol := testThreatPolicy(v1alpha1.ThreatPolicySpec{Threshold: ptr.To(8)})
+
+	actions := buildRequestActions(pol)
+	if len(actions) != 3 {
+		t.Fatalf("expected 3 actions, got %d", len(actions))
+	}
+	if assess := actions[0].(types.GRPCMethodAction); assess.Predicate != "" {
+		t.Errorf("expected the request to always be assessed without a rate limit, got predicate %q", assess.Predicate)
+	}
+	if deny := actions[2].(types.DenyAction); deny.Predicate != "threatResponse.threat_level >= 8" {
+		t.Errorf("unexpected deny predicate %s", deny.Predicate)
+	}
+	headers := buildResponseActions(pol)[0].(types.AddHeadersAction).HeadersToAdd
+	if !strings.Contains(headers, `["x-threat-threshold", "8"]`) {
+		t.Errorf("expected the threshold in the response headers, got %s", headers)
+	}
+}
+
+func TestBuildDesiredRateLimitPolicy(t *testing.T) {
+	action := v1alpha1.ThreatAction{
+		MinScore:  5,
+		Type:      v1alpha1.ThreatActionRateLimit,
+		RateLimit: &v1alpha1.ThreatRateLimit{Rates: []kuadrantv1.Rate{{Limit: 10, Window: "1m"}}},
+	}
+	pol := testThreatPolicy(v1alpha1.ThreatPolicySpec{Actions: []v1alpha1.ThreatAction{action}})
+
+	rlp := buildDesiredRateLimitPolicy(pol, action)
+	if rlp.Name != "threat-threats" || rlp.Spec.TargetRef != pol.Spec.TargetRef {
+		t.Errorf("unexpected ratelimitpolicy %s targeting %v", rlp.Name, rlp.Spec.TargetRef)
+	}
+	if rlp.Spec.Defaults == nil || rlp.Spec.Defaults.Strategy != kuadrantv1.PolicyRuleMergeStrategy {
+		t.Fatal("expected the limit to be a default merged with the other policies")
+	}
+	limit := rlp.Spec.Defaults.Limits[rateLimitName]
+	if want := pol.ScoreFunctionName() + "() >= 5"; limit.When[0].Predicate != want {
+		t.Errorf("limit predicate = %s, want %s", limit.When[0].Predicate, want)
+	}
+	if limit.Counters[0].Expression != "source.address" {
+		t.Errorf("unexpected counter %s", limit.Counters[0].Expression)
+	}
+}
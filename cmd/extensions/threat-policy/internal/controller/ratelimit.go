@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	kuadrantv1 "github.com/kuadrant/kuadrant-operator/api/v1"
+	"github.com/kuadrant/kuadrant-operator/cmd/extensions/threat-policy/api/v1alpha1"
+	"github.com/kuadrant/kuadrant-operator/pkg/extension/types"
+)
+
+// rateLimitName is the name of the limit applied to the clients reaching the threat score
+const rateLimitName = "threat"
+
+// reconcileRateLimit reconciles the RateLimitPolicy limiting the clients reaching the threat score of the
+// action. The score is exposed to the limit as a CEL function, evaluated by the data plane before the
+// rate limiting actions, i.e. ahead of the pipeline, which reuses it rather than assessing the request again.
+func (r *ThreatPolicyReconciler) reconcileRateLimit(ctx context.Context, pol *v1alpha1.ThreatPolicy, action v1alpha1.ThreatAction, kuadrantCtx types.KuadrantCtx) (client.Object, error) {
+	if err := kuadrantCtx.RegisterFunction(ctx, pol, types.FunctionConfig{
+		Name:        pol.ScoreFunctionName(),
+		Method:      assessMethodName,
+		ResultField: pol.GetService().ScoreField,
+		ResultType:  types.FunctionTypeInt,
+	}); err != nil {
+		r.Logger.Error(err, "failed to register threat score function")
+		return nil, err
+	}
+
+	desiredRateLimitPolicy := buildDesiredRateLimitPolicy(pol, action)
+	if err := controllerutil.SetControllerReference(pol, desiredRateLimitPolicy, r.Scheme); err != nil {
+		r.Logger.Error(err, "failed to set controller reference")
+		return nil, err
+	}
+	rateLimitPolicy, err := kuadrantCtx.ReconcileObject(ctx, &kuadrantv1.RateLimitPolicy{}, desiredRateLimitPolicy, rlpSpecMutator)
+	if err != nil {
+		r.Logger.Error(err, "failed to reconcile desired ratelimitpolicy")
+		return nil, err
+	}
+	return rateLimitPolicy, nil
+}
+
+// buildDesiredRateLimitPolicy builds the RateLimitPolicy counting the requests of each client address. Its
+// limit is a default merged with the ones of the other policies of the target, rather than replacing them.
+func buildDesiredRateLimitPolicy(pol *v1alpha1.ThreatPolicy, action v1alpha1.ThreatAction) *kuadrantv1.RateLimitPolicy {
+	return &kuadrantv1.RateLimitPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("threat-%s", pol.GetName()),
+			Namespace: pol.GetNamespace(),
+		},
+		Spec: kuadrantv1.RateLimitPolicySpec{
+			TargetRef: pol.Spec.TargetRef,
+			Defaults: &kuadrantv1.MergeableRateLimitPolicySpec{
+				Strategy: kuadrantv1.PolicyRuleMergeStrategy,
+				RateLimitPolicySpecProper: kuadrantv1.RateLimitPolicySpecProper{
+					Limits: map[string]kuadrantv1.Limit{
+						rateLimitName: {
+							When:     kuadrantv1.NewWhenPredicates(fmt.Sprintf("%s() >= %d", pol.ScoreFunctionName(), action.MinScore)),
+							Counters: []kuadrantv1.Counter{{Expression: "source.address"}},
+							Rates:    action.RateLimit.Rates,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func rlpSpecMutator(existingObj, desiredObj client.Object) (bool, error) {
+	var update bool
+	existing, ok := existingObj.(*kuadrantv1.RateLimitPolicy)
+	if !ok {
+		return false, fmt.Errorf("%T is not a *kuadrantv1.RateLimitPolicy", existingObj)
+	}
+	desired, ok := desiredObj.(*kuadrantv1.RateLimitPolicy)
+	if !ok {
+		return false, fmt.Errorf("%T is not a *kuadrantv1.RateLimitPolicy", desiredObj)
+	}
+	if !reflect.DeepEqual(desired.Spec, existing.Spec) {
+		existing.Spec = desired.Spec
+		update = true
+	}
+	return update, nil
+}
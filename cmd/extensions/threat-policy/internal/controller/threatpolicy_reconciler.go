@@ -3,12 +3,10 @@ package controller
 import (
 	"context"
 	"fmt"
-	"slices"
 
+	"github.com/samber/lo"
 	"k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/meta"
 	k8stypes "k8s.io/apimachinery/pkg/types"
-	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
@@ -23,7 +21,7 @@ import (
 // +kubebuilder:rbac:groups=extensions.kuadrant.io,resources=threatpolicies/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=extensions.kuadrant.io,resources=threatpolicies/finalizers,verbs=update
 
-const threatServiceURL = "grpc://threat-assessment-service.security.svc.cluster.local:8080"
+// +kubebuilder:rbac:groups=kuadrant.io,resources=ratelimitpolicies,verbs=create;delete
 
 type ThreatPolicyReconciler struct {
 	types.ExtensionBase
@@ -55,19 +53,17 @@ func (r *ThreatPolicyReconciler) Reconcile(ctx context.Context, request reconcil
 		return reconcile.Result{}, nil
 	}
 
-	policyStatus, specErr := r.reconcileSpec(ctx, threatPolicy, kuadrantCtx)
-	statusResult, statusErr := r.reconcileStatus(ctx, threatPolicy, policyStatus)
+	corePolicies, specErr := r.reconcileSpec(ctx, threatPolicy, kuadrantCtx)
+	if err := extcontroller.ReportTargetStatus(ctx, kuadrantCtx, threatPolicy, specErr); err != nil {
+		r.Logger.Error(err, "failed to report policy status")
+	}
+	statusErr := extcontroller.UpdatePolicyStatus(ctx, r.Client, threatPolicy, specErr, corePolicies...)
 
 	if specErr != nil {
 		return reconcile.Result{}, specErr
 	}
 	if statusErr != nil {
-		return reconcile.Result{}, statusErr
-	}
-
-	if statusResult.RequeueAfter > 0 {
-		r.Logger.Info("Reconciling status not finished. Requeueing.")
-		return statusResult, nil
+		return reconcile.Result{}, fmt.Errorf("failed to update status: %w", statusErr)
 	}
 
 	return reconcile.Result{}, nil
@@ -96,105 +92,56 @@ func (r *ThreatPolicyReconciler) validateTarget(ctx context.Context, pol *v1alph
 	return nil
 }
 
-func (r *ThreatPolicyReconciler) reconcileSpec(ctx context.Context, pol *v1alpha1.ThreatPolicy, kuadrantCtx types.KuadrantCtx) (*v1alpha1.ThreatPolicyStatus, error) {
+// reconcileSpec registers the method assessing the requests and commits the pipeline applying the
+// actions of the policy. Stricter rate limits are enforced by a RateLimitPolicy, returned when reconciled.
+func (r *ThreatPolicyReconciler) reconcileSpec(ctx context.Context, pol *v1alpha1.ThreatPolicy, kuadrantCtx types.KuadrantCtx) ([]client.Object, error) {
 	if err := r.validateTarget(ctx, pol); err != nil {
-		return calculateErrorStatus(pol, err), err
+		return nil, err
 	}
 
-	r.Logger.Info("registering action method", "url", threatServiceURL)
+	service := pol.GetService()
+	r.Logger.Info("registering action method", "url", service.URL)
 
 	if err := kuadrantCtx.RegisterActionMethod(ctx, pol, types.ActionMethodConfig{
-		Name:            "assess-threat",
-		URL:             threatServiceURL,
-		Service:         "threat.v1.ThreatAssessmentService",
-		Method:          "AssessRequest",
-		MessageTemplate: `threat.v1.ThreatRequest{uri: request.path, source_ip: source.address}`,
+		Name:            assessMethodName,
+		URL:             service.URL,
+		Service:         service.Service,
+		Method:          service.Method,
+		MessageTemplate: buildMessageTemplate(pol),
 	}); err != nil {
 		r.Logger.Error(err, "failed to register action method")
-		return calculateErrorStatus(pol, err), err
+		return nil, err
 	}
 
-	r.Logger.Info("action method registered successfully", "url", threatServiceURL)
+	r.Logger.Info("action method registered successfully", "url", service.URL)
+
+	var corePolicies []client.Object
+	if action, found := lo.Find(pol.GetActions(), func(a v1alpha1.ThreatAction) bool { return a.Type == v1alpha1.ThreatActionRateLimit }); found {
+		rateLimitPolicy, err := r.reconcileRateLimit(ctx, pol, action, kuadrantCtx)
+		if err != nil {
+			return nil, err
+		}
+		corePolicies = append(corePolicies, rateLimitPolicy)
+	}
 
 	pipeline := kuadrantCtx.NewPipeline(pol)
 
-	if err := pipeline.OnHTTPRequest(
-		types.GRPCMethodAction{
-			Method: "assess-threat",
-			Var:    "threatResponse",
-		},
-		types.FailAction{
-			Predicate:  `threatResponse.threat_level < 0`,
-			LogMessage: "threat assessment returned invalid threat_level",
-		},
-		types.DenyAction{
-			Predicate:   fmt.Sprintf("threatResponse.threat_level >= %d", pol.Spec.Threshold),
-			WithStatus:  403,
-			WithHeaders: `[["x-threat-blocked", "true"], ["x-threat-level", string(threatResponse.threat_level)]]`,
-			WithBody:    "'Request blocked: threat level exceeds threshold'",
-		},
-	); err != nil {
-		return calculateErrorStatus(pol, err), err
+	if err := pipeline.OnHTTPRequest(buildRequestActions(pol)...); err != nil {
+		return nil, err
 	}
 
-	if err := pipeline.OnHTTPResponse(
-		types.AddHeadersAction{
-			HeadersToAdd: fmt.Sprintf(`[["x-threat-threshold", "%d"], ["x-threat-score", string(threatResponse.threat_level)]]`, pol.Spec.Threshold),
-		},
-	); err != nil {
-		return calculateErrorStatus(pol, err), err
+	if err := pipeline.OnHTTPResponse(buildResponseActions(pol)...); err != nil {
+		return nil, err
 	}
 
-	err := pipeline.Commit(ctx)
-
-	if err != nil {
+	if err := pipeline.Commit(ctx); err != nil {
 		r.Logger.Error(err, "failed to commit pipeline")
-		return calculateErrorStatus(pol, err), err
+		return nil, err
 	}
 
 	r.Logger.Info("pipeline committed successfully")
-	return calculateEnforcedStatus(pol, nil), nil
-}
-
-func (r *ThreatPolicyReconciler) reconcileStatus(ctx context.Context, pol *v1alpha1.ThreatPolicy, newStatus *v1alpha1.ThreatPolicyStatus) (ctrl.Result, error) {
-	equalStatus := pol.Status.Equals(newStatus, r.Logger)
-	r.Logger.Info("Status", "status is different", !equalStatus)
-	r.Logger.Info("Status", "generation is different", pol.Generation != pol.Status.ObservedGeneration)
-	if equalStatus && pol.Generation == pol.Status.ObservedGeneration {
-		r.Logger.Info("Status was not updated")
-		return reconcile.Result{}, nil
-	}
-
-	r.Logger.V(1).Info("Updating Status", "sequence no:", fmt.Sprintf("sequence No: %v->%v", pol.Status.ObservedGeneration, newStatus.ObservedGeneration))
-
-	pol.Status = *newStatus
-	updateErr := r.Client.Status().Update(ctx, pol)
-	if updateErr != nil {
-		if errors.IsConflict(updateErr) {
-			r.Logger.Info("Failed to update status: resource might just be outdated")
-			return reconcile.Result{Requeue: true}, nil
-		}
-		return reconcile.Result{}, fmt.Errorf("failed to update status: %w", updateErr)
-	}
-	return ctrl.Result{}, nil
-}
 
-func calculateErrorStatus(pol *v1alpha1.ThreatPolicy, specErr error) *v1alpha1.ThreatPolicyStatus {
-	newStatus := &v1alpha1.ThreatPolicyStatus{
-		ObservedGeneration: pol.Generation,
-		Conditions:         slices.Clone(pol.Status.Conditions),
-	}
-	meta.SetStatusCondition(&newStatus.Conditions, *extcontroller.AcceptedCondition(pol, specErr))
-	meta.RemoveStatusCondition(&newStatus.Conditions, string(types.PolicyConditionEnforced))
-	return newStatus
-}
-
-func calculateEnforcedStatus(pol *v1alpha1.ThreatPolicy, enforcedErr error) *v1alpha1.ThreatPolicyStatus {
-	newStatus := &v1alpha1.ThreatPolicyStatus{
-		ObservedGeneration: pol.Generation,
-		Conditions:         slices.Clone(pol.Status.Conditions),
-	}
-	meta.SetStatusCondition(&newStatus.Conditions, *extcontroller.AcceptedCondition(pol, nil))
-	meta.SetStatusCondition(&newStatus.Conditions, *extcontroller.EnforcedCondition(pol, enforcedErr, true))
-	return newStatus
+	// every object desired for the policy was reconciled, the ones no longer desired can be pruned
+	extcontroller.MarkDesiredStateComplete(ctx)
+	return corePolicies, nil
 }
@@ -8,6 +8,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
 
+	kuadrantv1 "github.com/kuadrant/kuadrant-operator/api/v1"
 	"github.com/kuadrant/kuadrant-operator/cmd/extensions/threat-policy/api/v1alpha1"
 	"github.com/kuadrant/kuadrant-operator/cmd/extensions/threat-policy/internal/controller"
 	extcontroller "github.com/kuadrant/kuadrant-operator/pkg/extension/controller"
@@ -20,6 +21,7 @@ var (
 func init() {
 	utilruntime.Must(gatewayapiv1.Install(scheme))
 	utilruntime.Must(v1alpha1.AddToScheme(scheme))
+	utilruntime.Must(kuadrantv1.AddToScheme(scheme))
 }
 
 func main() {
@@ -29,6 +31,7 @@ func main() {
 		WithScheme(scheme).
 		WithReconciler(reconciler.Reconcile).
 		For(&v1alpha1.ThreatPolicy{}).
+		Owns(&kuadrantv1.RateLimitPolicy{}).
 		Build()
 	if err != nil {
 		logger.Error(err, "unable to create controller")
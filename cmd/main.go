@@ -215,8 +215,8 @@ func main() {
 	flag.Parse()
 
 	// Initialize OpenTelemetry metrics provider
-	// This bridges all Prometheus metrics (controller-runtime + custom metrics)
-	// to OTLP export without requiring any changes to existing metrics code
+	// Operator metrics are emitted through the meter provider and exposed to Prometheus by its exporter,
+	// while the remaining Prometheus metrics (controller-runtime) are bridged to OTLP export
 	metricsConfig := metrics.NewConfig(ctrlmetrics.Registry)
 	metricsProvider, err := metrics.NewProvider(context.Background(), otelConfig, metricsConfig)
 	if err != nil {
@@ -0,0 +1,58 @@
+package cel
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/google/cel-go/cel"
+
+	"github.com/kuadrant/kuadrant-operator/api/v1beta1"
+)
+
+// ValidateDecisionLogs validates the CEL expressions of the decision log fields of the Observability spec, both the
+// default ones of the data plane and the ones of the gateway overrides. The fields are evaluated once the request
+// went through all actions, so they can refer to the auth and ratelimit results.
+// Returns a copy of the Observability spec without the invalid fields, along with an error per invalid field.
+func ValidateDecisionLogs(observability *v1beta1.Observability) (*v1beta1.Observability, []error) {
+	if observability == nil {
+		return nil, nil
+	}
+
+	validator, err := NewRootValidatorBuilder().
+		PushPolicyBinding(AuthPolicyKind, AuthPolicyName, cel.AnyType).
+		PushPolicyBinding(RateLimitPolicyKind, RateLimitName, cel.AnyType).
+		Build()
+	if err != nil {
+		return observability, []error{err}
+	}
+
+	valid := observability.DeepCopy()
+	var errs []error
+	if valid.DataPlane != nil {
+		errs = append(errs, validateDecisionLogFields(validator, valid.DataPlane.DecisionLog, "dataPlane.decisionLog")...)
+	}
+	for i := range valid.Gateways {
+		gateway := &valid.Gateways[i]
+		path := fmt.Sprintf("gateways[%s/%s].decisionLog", gateway.Namespace, gateway.Name)
+		errs = append(errs, validateDecisionLogFields(validator, gateway.DecisionLog, path)...)
+	}
+	return valid, errs
+}
+
+// validateDecisionLogFields removes the invalid fields from the decision log and returns their errors, sorted by
+// field name
+func validateDecisionLogFields(validator *Validator, decisionLog *v1beta1.DecisionLog, path string) []error {
+	if decisionLog == nil {
+		return nil
+	}
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(decisionLog.Fields)) {
+		// the last policy binding sees all the bindings pushed before it
+		if _, err := validator.Validate(RateLimitPolicyKind, decisionLog.Fields[name]); err != nil {
+			errs = append(errs, fmt.Errorf("%s.fields.%s: %w", path, name, err))
+			delete(decisionLog.Fields, name)
+		}
+	}
+	return errs
+}
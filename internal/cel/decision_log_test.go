@@ -0,0 +1,61 @@
+package cel
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/kuadrant/kuadrant-operator/api/v1beta1"
+)
+
+func TestValidateDecisionLogs(t *testing.T) {
+	observability := &v1beta1.Observability{
+		DataPlane: &v1beta1.DataPlane{
+			DecisionLog: &v1beta1.DecisionLog{
+				Enable: true,
+				Fields: map[string]string{
+					"user":   "auth.identity.sub",
+					"hits":   "ratelimit.hits_addend",
+					"broken": "request.headers[",
+				},
+			},
+		},
+		Gateways: []v1beta1.GatewayObservability{
+			{
+				Name:      "external",
+				Namespace: "gateway-system",
+				DecisionLog: &v1beta1.DecisionLog{
+					Enable: true,
+					Fields: map[string]string{
+						"path":    "request.url_path",
+						"unknown": "other.field",
+					},
+				},
+			},
+		},
+	}
+
+	valid, errs := ValidateDecisionLogs(observability)
+
+	assert.Equal(t, len(errs), 2)
+	assert.ErrorContains(t, errs[0], "dataPlane.decisionLog.fields.broken:")
+	assert.ErrorContains(t, errs[1], "gateways[gateway-system/external].decisionLog.fields.unknown:")
+	assert.DeepEqual(t, valid.DataPlane.DecisionLog.Fields, map[string]string{
+		"user": "auth.identity.sub",
+		"hits": "ratelimit.hits_addend",
+	})
+	assert.DeepEqual(t, valid.Gateways[0].DecisionLog.Fields, map[string]string{"path": "request.url_path"})
+	// the spec itself is left untouched
+	assert.Equal(t, len(observability.DataPlane.DecisionLog.Fields), 3)
+	assert.Equal(t, len(observability.Gateways[0].DecisionLog.Fields), 2)
+}
+
+func TestValidateDecisionLogsWithoutFields(t *testing.T) {
+	valid, errs := ValidateDecisionLogs(&v1beta1.Observability{})
+	assert.Equal(t, len(errs), 0)
+	assert.Assert(t, valid != nil)
+
+	valid, errs = ValidateDecisionLogs(nil)
+	assert.Equal(t, len(errs), 0)
+	assert.Assert(t, valid == nil)
+}
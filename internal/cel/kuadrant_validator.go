@@ -1,6 +1,8 @@
 package cel
 
 import (
+	"strings"
+
 	"github.com/google/cel-go/cel"
 	"github.com/google/cel-go/common/types/ref"
 	"github.com/samber/lo"
@@ -103,6 +105,36 @@ func NewRootValidatorBuilder() *ValidatorBuilder {
 	return builder
 }
 
+var functionResultTypes = map[string]*cel.Type{
+	"bool":   cel.BoolType,
+	"int":    cel.IntType,
+	"uint":   cel.UintType,
+	"double": cel.DoubleType,
+	"string": cel.StringType,
+	"dyn":    cel.DynType,
+}
+
+// AddFunctionBindings declares the extension provided functions so that predicates
+// calling them type-check. Unknown result types are declared as dyn.
+func AddFunctionBindings(builder *ValidatorBuilder, functions []wasm.FunctionBinding) *ValidatorBuilder {
+	for _, fn := range functions {
+		resultType, ok := functionResultTypes[fn.ResultType]
+		if !ok {
+			resultType = cel.DynType
+		}
+		overload := cel.Overload(strings.ReplaceAll(fn.Name, ".", "_"),
+			[]*cel.Type{},
+			resultType,
+			cel.FunctionBinding(func(_ ...ref.Val) ref.Val {
+				// just for parsing and checking purposes, not evaluation
+				return nil
+			}),
+		)
+		builder.AddFunction(fn.Name, overload)
+	}
+	return builder
+}
+
 func ValidateWasmActionSpec(spec wasm.ActionSpec, validator *Validator) error {
 	pol := policyKindFromWasmServiceName(spec.ServiceName)
 	for _, predicate := range spec.Predicates {
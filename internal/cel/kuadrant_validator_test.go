@@ -136,3 +136,36 @@ func TestIssueCollectionGetByPolicyKind(t *testing.T) {
 	_, found = collection.GetByPolicyKind("non-existent")
 	assert.Equal(t, found, false)
 }
+
+func TestValidateWasmActionWithFunctionBindings(t *testing.T) {
+	functions := []wasm.FunctionBinding{
+		{Name: "threat.score", ResultType: "int"},
+		{Name: "plan.tier", ResultType: "string"},
+	}
+
+	builder := NewRootValidatorBuilder()
+	builder.PushPolicyBinding(RateLimitPolicyKind, RateLimitName, cel.AnyType)
+	AddFunctionBindings(builder, functions)
+	validator, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valid := wasm.ActionSpec{
+		ServiceName: wasm.RateLimitServiceName,
+		Predicates:  []string{"threat.score() > 5 && plan.tier() == 'gold'"},
+	}
+	assert.NilError(t, ValidateWasmActionSpec(valid, validator))
+
+	mismatched := wasm.ActionSpec{
+		ServiceName: wasm.RateLimitServiceName,
+		Predicates:  []string{"threat.score() == 'high'"},
+	}
+	assert.ErrorContains(t, ValidateWasmActionSpec(mismatched, validator), "no matching overload")
+
+	undeclared := wasm.ActionSpec{
+		ServiceName: wasm.RateLimitServiceName,
+		Predicates:  []string{"other.fn() > 5"},
+	}
+	assert.ErrorContains(t, ValidateWasmActionSpec(undeclared, validator), "undeclared reference")
+}
@@ -41,7 +41,8 @@ import (
 const AuthPolicyStatusUpdaterName = "AuthPolicyStatusUpdater"
 
 type AuthPolicyStatusUpdater struct {
-	client *dynamic.DynamicClient
+	client        *dynamic.DynamicClient
+	eventRecorder *PolicyEventRecorder
 }
 
 // AuthPolicyStatusUpdater reconciles to events with impact to change the status of AuthPolicy resources
@@ -122,6 +123,7 @@ func (r *AuthPolicyStatusUpdater) UpdateStatus(ctx context.Context, _ []controll
 			continue
 		}
 		newStatus.ObservedGeneration = policy.Generation
+		previousConditions := policy.Status.Conditions
 		policy.Status = *newStatus
 
 		obj, err := controller.Destruct(policy)
@@ -158,6 +160,8 @@ func (r *AuthPolicyStatusUpdater) UpdateStatus(ctx context.Context, _ []controll
 			continue
 		}
 
+		r.eventRecorder.RecordConditionTransitions(policy, previousConditions, policy.Status.Conditions)
+
 		span.AddEvent("policy status updated successfully")
 		span.SetStatus(codes.Ok, "")
 		span.End()
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"context"
+	"strings"
 	"sync"
 
 	authorinoopapi "github.com/kuadrant/authorino-operator/api/v1beta1"
@@ -10,6 +11,9 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
 	apiErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -40,6 +44,8 @@ func (r *AuthorinoReconciler) Subscription() *controller.Subscription {
 		{Kind: ptr.To(v1beta1.KuadrantGroupKind), EventType: ptr.To(controller.CreateEvent)},
 		{Kind: ptr.To(v1beta1.KuadrantGroupKind), EventType: ptr.To(controller.UpdateEvent)},
 		{Kind: ptr.To(v1beta1.AuthorinoGroupKind)},
+		// the replicas of authorino follow the ones desired by its autoscaler
+		{Kind: ptr.To(v1beta1.HorizontalPodAutoscalerGroupKind)},
 	}
 	if r.IsOpenShiftServerConfigInstalled {
 		events = append(events, controller.ResourceEventMatcher{Kind: ptr.To(openshift.APIServerGroupKind)})
@@ -69,6 +75,27 @@ func (r *AuthorinoReconciler) Reconcile(ctx context.Context, _ []controller.Reso
 		attribute.String("kuadrant.namespace", kobj.Namespace),
 	)
 
+	component := kobj.Spec.Components.AuthorinoComponent()
+
+	var autoscaling *v1beta1.ComponentAutoscaling
+	if component != nil {
+		autoscaling = component.Autoscaling
+	}
+	autoscaler := GetComponentAutoscalerFromTopology(topology, kobj.Namespace, authorinoDeploymentName)
+	if err := reconcileComponentAutoscaler(ctx, r.Client, kobj, authorinoDeploymentName, autoscaling, autoscaler); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to reconcile authorino autoscaler")
+		logger.Error(err, "failed to reconcile authorino autoscaler", "status", "error")
+		return err
+	}
+
+	if err := r.reconcileAuthorinoResources(ctx, kobj, component); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to reconcile authorino resources")
+		logger.Error(err, "failed to reconcile authorino resources", "status", "error")
+		return err
+	}
+
 	tlsMinVersion, tlsCipherSuites := openshift.ResolveTLSProfileFromTopology(topology, kobj, r.IsOpenShiftServerConfigInstalled)
 
 	clusterAuthorino := GetAuthorinoFromTopology(topology, state)
@@ -102,6 +129,8 @@ func (r *AuthorinoReconciler) Reconcile(ctx context.Context, _ []controller.Reso
 			}
 		}
 
+		applyAuthorinoComponent(&patch.Spec, component, autoscaler)
+
 		unstructuredAuthorino, err := controller.Destruct(patch)
 		if err != nil {
 			span.RecordError(err)
@@ -134,7 +163,31 @@ func (r *AuthorinoReconciler) Reconcile(ctx context.Context, _ []controller.Reso
 			// Cede ownership of conflicting fields
 			if apiErrors.IsConflict(err) {
 				logger.V(0).Info("Ceding ownership due to conflicting fields", "err", err.Error())
-				return nil
+				if component == nil || (component.Replicas == nil && component.Autoscaling == nil) {
+					return nil
+				}
+
+				// The fields set in the Kuadrant CR components must always be reconciled to our set values
+				if statusErr, ok := err.(apiErrors.APIStatus); ok && statusErr.Status().Details != nil {
+					for _, cause := range statusErr.Status().Details.Causes {
+						if cause.Field == ".spec.replicas" {
+							continue
+						}
+						path := strings.Split(cause.Field, ".")
+						unstructured.RemoveNestedField(unstructuredAuthorino.Object, path[1:]...)
+						logger.V(1).Info("Ceding ownership of conflicting field", "field", cause.Field)
+					}
+				}
+				_, err = r.Client.Resource(v1beta1.AuthorinosResource).Namespace(kobj.Namespace).Apply(
+					ctx,
+					unstructuredAuthorino.GetName(),
+					unstructuredAuthorino,
+					metav1.ApplyOptions{
+						FieldManager: FieldManagerName,
+						Force:        true,
+					},
+				)
+				return err
 			}
 			span.RecordError(err)
 			span.SetStatus(codes.Error, "failed to apply authorino")
@@ -192,6 +245,8 @@ func (r *AuthorinoReconciler) Reconcile(ctx context.Context, _ []controller.Reso
 		}
 	}
 
+	applyAuthorinoComponent(&authorino.Spec, component, autoscaler)
+
 	unstructuredAuthorino, err := controller.Destruct(authorino)
 	if err != nil {
 		span.RecordError(err)
@@ -233,6 +288,61 @@ func (r *AuthorinoReconciler) Reconcile(ctx context.Context, _ []controller.Reso
 	return nil
 }
 
+// reconcileAuthorinoResources applies the resources of the Authorino component to the deployment created by the
+// authorino operator, once created, as the Authorino CR has no resources
+func (r *AuthorinoReconciler) reconcileAuthorinoResources(ctx context.Context, kobj *v1beta1.Kuadrant, component *v1beta1.AuthorinoComponent) error {
+	resource := r.Client.Resource(v1beta1.DeploymentsResource).Namespace(kobj.Namespace)
+	if _, err := resource.Get(ctx, authorinoDeploymentName, metav1.GetOptions{}); err != nil {
+		if apiErrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	unstructuredDeployment, err := controller.Destruct(buildAuthorinoDeploymentPatch(kobj.Namespace, component))
+	if err != nil {
+		return err
+	}
+	_, err = resource.Apply(ctx, authorinoDeploymentName, unstructuredDeployment, metav1.ApplyOptions{
+		FieldManager: FieldManagerName,
+		Force:        true,
+	})
+	return err
+}
+
+// buildAuthorinoDeploymentPatch returns the patch of the deployment created by the authorino operator setting the
+// resources of the Authorino component on the authorino container. The authorino operator does not set the resources
+// of the container, which remain owned by the kuadrant operator, and released when unset.
+func buildAuthorinoDeploymentPatch(namespace string, component *v1beta1.AuthorinoComponent) *appsv1.Deployment {
+	container := corev1.Container{Name: authorinoContainerName}
+	if component != nil {
+		resources := component.Resources
+		if component.Autoscaling != nil {
+			resources = autoscaledResources(resources)
+		}
+		if resources != nil {
+			container.Resources = *resources
+		}
+	}
+	return &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       v1beta1.DeploymentGroupKind.Kind,
+			APIVersion: v1beta1.DeploymentsResource.GroupVersion().String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      authorinoDeploymentName,
+			Namespace: namespace,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{container},
+				},
+			},
+		},
+	}
+}
+
 func buildTLSPatch(existing authorinoopapi.Tls, minVersion string, cipherSuites []string) authorinoopapi.Tls {
 	if existing.Enabled != nil && *existing.Enabled {
 		return authorinoopapi.Tls{
@@ -242,3 +352,17 @@ func buildTLSPatch(existing authorinoopapi.Tls, minVersion string, cipherSuites
 	}
 	return authorinoopapi.Tls{}
 }
+
+// applyAuthorinoComponent merges the Authorino configuration of the Kuadrant CR into the desired Authorino spec.
+// Only the fields set are applied, so the fields left unset remain free to be managed on the Authorino CR.
+// When autoscaling, the replicas mirror the ones desired by the autoscaler, as the authorino operator resets the
+// replicas of the deployment to the ones of the Authorino CR, defaulting to 1.
+func applyAuthorinoComponent(spec *authorinoopapi.AuthorinoSpec, component *v1beta1.AuthorinoComponent, autoscaler *autoscalingv2.HorizontalPodAutoscaler) {
+	if component == nil {
+		return
+	}
+	spec.Replicas = component.Replicas
+	if component.Autoscaling != nil {
+		spec.Replicas = ptr.To(autoscaledReplicas(component.Autoscaling, autoscaler))
+	}
+}
@@ -4,8 +4,12 @@ import (
 	"testing"
 
 	authorinoopapi "github.com/kuadrant/authorino-operator/api/v1beta1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/utils/ptr"
+
+	"github.com/kuadrant/kuadrant-operator/api/v1beta1"
 )
 
 func TestBuildTLSPatch(t *testing.T) {
@@ -72,3 +76,95 @@ func TestBuildTLSPatch(t *testing.T) {
 		}
 	})
 }
+
+func TestApplyAuthorinoComponent(t *testing.T) {
+	t.Run("no component leaves the spec untouched", func(t *testing.T) {
+		spec := authorinoopapi.AuthorinoSpec{Replicas: ptr.To(int32(1))}
+		applyAuthorinoComponent(&spec, nil, nil)
+		if spec.Replicas == nil || *spec.Replicas != 1 {
+			t.Errorf("expected replicas to be untouched, got %v", spec.Replicas)
+		}
+	})
+
+	t.Run("replicas are merged into the spec", func(t *testing.T) {
+		spec := authorinoopapi.AuthorinoSpec{ClusterWide: true}
+		applyAuthorinoComponent(&spec, &v1beta1.AuthorinoComponent{Replicas: ptr.To(int32(3))}, nil)
+		if spec.Replicas == nil || *spec.Replicas != 3 {
+			t.Errorf("expected 3 replicas, got %v", spec.Replicas)
+		}
+		if !spec.ClusterWide {
+			t.Error("expected the other fields to be untouched")
+		}
+	})
+	t.Run("autoscaled replicas start at the minimum", func(t *testing.T) {
+		spec := authorinoopapi.AuthorinoSpec{}
+		component := &v1beta1.AuthorinoComponent{Autoscaling: &v1beta1.ComponentAutoscaling{MinReplicas: ptr.To(int32(2)), MaxReplicas: 5}}
+		applyAuthorinoComponent(&spec, component, nil)
+		if spec.Replicas == nil || *spec.Replicas != 2 {
+			t.Errorf("expected 2 replicas, got %v", spec.Replicas)
+		}
+	})
+
+	t.Run("autoscaled replicas follow the autoscaler", func(t *testing.T) {
+		spec := authorinoopapi.AuthorinoSpec{}
+		component := &v1beta1.AuthorinoComponent{Autoscaling: &v1beta1.ComponentAutoscaling{MaxReplicas: 5}}
+		autoscaler := &autoscalingv2.HorizontalPodAutoscaler{
+			Status: autoscalingv2.HorizontalPodAutoscalerStatus{DesiredReplicas: 4},
+		}
+		applyAuthorinoComponent(&spec, component, autoscaler)
+		if spec.Replicas == nil || *spec.Replicas != 4 {
+			t.Errorf("expected 4 replicas, got %v", spec.Replicas)
+		}
+	})
+}
+
+func TestBuildAuthorinoDeploymentPatch(t *testing.T) {
+	containerResources := func(t *testing.T, component *v1beta1.AuthorinoComponent) corev1.ResourceRequirements {
+		t.Helper()
+		patch := buildAuthorinoDeploymentPatch("kuadrant-system", component)
+		if patch.Name != "authorino" || patch.Namespace != "kuadrant-system" {
+			t.Errorf("unexpected deployment %s/%s", patch.Namespace, patch.Name)
+		}
+		containers := patch.Spec.Template.Spec.Containers
+		if len(containers) != 1 || containers[0].Name != "authorino" {
+			t.Fatalf("expected the authorino container only, got %+v", containers)
+		}
+		return containers[0].Resources
+	}
+
+	t.Run("no resources are set", func(t *testing.T) {
+		resources := containerResources(t, nil)
+		if len(resources.Requests) != 0 || len(resources.Limits) != 0 {
+			t.Errorf("expected no resources, got %+v", resources)
+		}
+	})
+
+	t.Run("resources are applied to the container", func(t *testing.T) {
+		resources := containerResources(t, &v1beta1.AuthorinoComponent{Resources: &corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")},
+		}})
+		if memory := resources.Requests[corev1.ResourceMemory]; memory.String() != "128Mi" {
+			t.Errorf("expected a memory request of 128Mi, got %s", memory.String())
+		}
+		if _, found := resources.Requests[corev1.ResourceCPU]; found {
+			t.Error("expected no cpu request when not autoscaling")
+		}
+	})
+
+	t.Run("autoscaled pods request cpu", func(t *testing.T) {
+		resources := containerResources(t, &v1beta1.AuthorinoComponent{Autoscaling: &v1beta1.ComponentAutoscaling{MaxReplicas: 3}})
+		if cpu := resources.Requests[corev1.ResourceCPU]; cpu.String() != "250m" {
+			t.Errorf("expected a cpu request of 250m, got %s", cpu.String())
+		}
+	})
+
+	t.Run("autoscaled pods keep the cpu requested", func(t *testing.T) {
+		resources := containerResources(t, &v1beta1.AuthorinoComponent{
+			Autoscaling: &v1beta1.ComponentAutoscaling{MaxReplicas: 3},
+			Resources:   &corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}},
+		})
+		if cpu := resources.Requests[corev1.ResourceCPU]; cpu.String() != "1" {
+			t.Errorf("expected a cpu request of 1, got %s", cpu.String())
+		}
+	})
+}
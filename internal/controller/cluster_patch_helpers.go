@@ -1,5 +1,11 @@
 package controllers
 
+import "net"
+
+// systemTrustStoreFile is the bundle of the CA certificates of the system in the Envoy image, the one Envoy Gateway
+// validates backends against when their BackendTLSPolicy trusts the system CA certificates
+const systemTrustStoreFile = "/etc/ssl/certs/ca-certificates.crt"
+
 // buildClusterPatch creates an Envoy cluster configuration patch with optional mTLS support.
 // HTTP/2 is always enabled since all current callers require it (gRPC upstreams).
 func buildClusterPatch(clusterName, host string, port int, mTLS bool) map[string]any {
@@ -41,44 +47,94 @@ func buildClusterPatch(clusterName, host string, port int, mTLS bool) map[string
 
 // buildMTLSTransportSocket creates the mTLS transport socket configuration using SDS
 func buildMTLSTransportSocket() map[string]interface{} {
+	return buildTLSTransportSocket(meshTLSContext("default", sdsGRPCConfigSource()), "")
+}
+
+// buildTLSTransportSocket creates the TLS transport socket configuration with the given common TLS context,
+// sending the given SNI, if any
+func buildTLSTransportSocket(commonTLSContext map[string]interface{}, sni string) map[string]interface{} {
+	tlsContext := map[string]interface{}{
+		"@type":              "type.googleapis.com/envoy.extensions.transport_sockets.tls.v3.UpstreamTlsContext",
+		"common_tls_context": commonTLSContext,
+	}
+	if sni != "" {
+		tlsContext["sni"] = sni
+	}
 	return map[string]interface{}{
-		"name": "envoy.transport_sockets.tls",
-		"typed_config": map[string]interface{}{
-			"@type": "type.googleapis.com/envoy.extensions.transport_sockets.tls.v3.UpstreamTlsContext",
-			"common_tls_context": map[string]interface{}{
-				"tls_certificate_sds_secret_configs": []interface{}{
-					map[string]interface{}{
-						"name": "default",
-						"sds_config": map[string]interface{}{
-							"api_config_source": map[string]interface{}{
-								"api_type": "GRPC",
-								"grpc_services": []interface{}{
-									map[string]interface{}{
-										"envoy_grpc": map[string]interface{}{
-											"cluster_name": "sds-grpc",
-										},
-									},
-								},
-							},
-						},
-					},
+		"name":         "envoy.transport_sockets.tls",
+		"typed_config": tlsContext,
+	}
+}
+
+// meshTLSContext returns the common TLS context presenting the client certificate served under the given name by
+// the given config source. The peer is validated against the mesh root CA, served by the Istio agent.
+func meshTLSContext(certificateSDSName string, certificateSDSConfig map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"tls_certificate_sds_secret_configs": []interface{}{
+			map[string]interface{}{
+				"name":       certificateSDSName,
+				"sds_config": certificateSDSConfig,
+			},
+		},
+		"validation_context_sds_secret_config": map[string]interface{}{
+			"name":       "ROOTCA",
+			"sds_config": sdsGRPCConfigSource(),
+		},
+	}
+}
+
+// systemTrustTLSContext returns the common TLS context validating the certificate of the peer against the system
+// trust store of the gateway, for the given host, and presenting the client certificate served under the given name
+// over ADS, if any
+func systemTrustTLSContext(host, certificateSDSName string) map[string]interface{} {
+	sanType := "DNS"
+	if net.ParseIP(host) != nil {
+		sanType = "IP_ADDRESS"
+	}
+	tlsContext := map[string]interface{}{
+		"validation_context": map[string]interface{}{
+			"trusted_ca": map[string]interface{}{
+				"filename": systemTrustStoreFile,
+			},
+			"match_typed_subject_alt_names": []interface{}{
+				map[string]interface{}{
+					"san_type": sanType,
+					"matcher":  map[string]interface{}{"exact": host},
 				},
-				"validation_context_sds_secret_config": map[string]interface{}{
-					"name": "ROOTCA",
-					"sds_config": map[string]interface{}{
-						"api_config_source": map[string]interface{}{
-							"api_type": "GRPC",
-							"grpc_services": []interface{}{
-								map[string]interface{}{
-									"envoy_grpc": map[string]interface{}{
-										"cluster_name": "sds-grpc",
-									},
-								},
-							},
-						},
+			},
+		},
+	}
+	if certificateSDSName != "" {
+		tlsContext["tls_certificate_sds_secret_configs"] = []interface{}{
+			map[string]interface{}{
+				"name":       certificateSDSName,
+				"sds_config": adsConfigSource(),
+			},
+		}
+	}
+	return tlsContext
+}
+
+// sdsGRPCConfigSource returns the config source of the secrets served by the SDS server of the Istio agent
+func sdsGRPCConfigSource() map[string]interface{} {
+	return map[string]interface{}{
+		"api_config_source": map[string]interface{}{
+			"api_type": "GRPC",
+			"grpc_services": []interface{}{
+				map[string]interface{}{
+					"envoy_grpc": map[string]interface{}{
+						"cluster_name": "sds-grpc",
 					},
 				},
 			},
 		},
 	}
 }
+
+// adsConfigSource returns the config source of the secrets served by the control plane of the gateway over ADS
+func adsConfigSource() map[string]interface{} {
+	return map[string]interface{}{
+		"ads":                  map[string]interface{}{},
+		"resource_api_version": "V3",
+	}
+}
@@ -39,13 +39,17 @@ func CommonLabels() map[string]string {
 }
 
 func PolicyAffectedCondition(policyKind string, policies []machinery.Policy) metav1.Condition {
+	return policyKeysAffectedCondition(policyKind, lo.Map(policies, func(item machinery.Policy, _ int) client.ObjectKey {
+		return client.ObjectKey{Name: item.GetName(), Namespace: item.GetNamespace()}
+	}))
+}
+
+func policyKeysAffectedCondition(policyKind string, policies []client.ObjectKey) metav1.Condition {
 	condition := metav1.Condition{
-		Type:   PolicyAffectedConditionType(policyKind),
-		Status: metav1.ConditionTrue,
-		Reason: string(gatewayapiv1alpha2.PolicyReasonAccepted),
-		Message: fmt.Sprintf("Object affected by %s %s", policyKind, lo.Map(policies, func(item machinery.Policy, _ int) client.ObjectKey {
-			return client.ObjectKey{Name: item.GetName(), Namespace: item.GetNamespace()}
-		})),
+		Type:    PolicyAffectedConditionType(policyKind),
+		Status:  metav1.ConditionTrue,
+		Reason:  string(gatewayapiv1alpha2.PolicyReasonAccepted),
+		Message: fmt.Sprintf("Object affected by %s %s", policyKind, policies),
 	}
 
 	return condition
@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kuadrant/policy-machinery/controller"
+	"github.com/kuadrant/policy-machinery/machinery"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/utils/ptr"
+
+	"github.com/kuadrant/kuadrant-operator/api/v1beta1"
+	"github.com/kuadrant/kuadrant-operator/internal/kuadrant"
+)
+
+//+kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
+
+const defaultAutoscalingTargetCPUUtilizationPercentage int32 = 80
+
+var (
+	// the deployments are created by the limitador and authorino operators, named after the custom resources
+	limitadorDeploymentName = fmt.Sprintf("limitador-%s", kuadrant.LimitadorName)
+	authorinoDeploymentName = "authorino"
+	authorinoContainerName  = "authorino"
+
+	// defaultAutoscalingCPURequest is the CPU requested by the pods of an autoscaled component when none is set, the
+	// default of the limitador operator
+	defaultAutoscalingCPURequest = resource.MustParse("250m")
+)
+
+// GetComponentAutoscalerFromTopology returns the HorizontalPodAutoscaler of the deployment of a component, if any.
+// The autoscaler is named after the deployment it scales.
+func GetComponentAutoscalerFromTopology(topology *machinery.Topology, namespace, deploymentName string) *autoscalingv2.HorizontalPodAutoscaler {
+	autoscalers := topology.Objects().Items(func(o machinery.Object) bool {
+		return o.GroupVersionKind().GroupKind() == v1beta1.HorizontalPodAutoscalerGroupKind && o.GetNamespace() == namespace && o.GetName() == deploymentName
+	})
+	if len(autoscalers) == 0 {
+		return nil
+	}
+	autoscaler, ok := autoscalers[0].(*controller.RuntimeObject).Object.(*autoscalingv2.HorizontalPodAutoscaler)
+	if !ok {
+		return nil
+	}
+	return autoscaler
+}
+
+// reconcileComponentAutoscaler applies the HorizontalPodAutoscaler of the deployment of a component when autoscaling
+// is configured in the Kuadrant CR, and deletes the existing one otherwise. The existing autoscaler is the one managed
+// by kuadrant, if any: an autoscaler of the same name not managed by kuadrant is never taken over.
+func reconcileComponentAutoscaler(ctx context.Context, client dynamic.Interface, kobj *v1beta1.Kuadrant, deploymentName string, autoscaling *v1beta1.ComponentAutoscaling, existing *autoscalingv2.HorizontalPodAutoscaler) error {
+	if autoscaling == nil {
+		if existing == nil {
+			return nil
+		}
+		if err := client.Resource(v1beta1.HorizontalPodAutoscalersResource).Namespace(existing.Namespace).Delete(ctx, existing.Name, metav1.DeleteOptions{}); err != nil && !apiErrors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+
+	// the autoscalers not managed by kuadrant are not in the topology
+	if existing == nil {
+		unmanaged, err := client.Resource(v1beta1.HorizontalPodAutoscalersResource).Namespace(kobj.Namespace).Get(ctx, deploymentName, metav1.GetOptions{})
+		if err != nil && !apiErrors.IsNotFound(err) {
+			return err
+		}
+		if err == nil && unmanaged.GetLabels()[kuadrantManagedLabelKey] != "true" {
+			return fmt.Errorf("horizontalpodautoscaler %s/%s already exists and is not managed by kuadrant", kobj.Namespace, deploymentName)
+		}
+	}
+
+	unstructuredAutoscaler, err := controller.Destruct(buildComponentAutoscaler(kobj, deploymentName, autoscaling))
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Resource(v1beta1.HorizontalPodAutoscalersResource).Namespace(kobj.Namespace).Apply(
+		ctx,
+		unstructuredAutoscaler.GetName(),
+		unstructuredAutoscaler,
+		metav1.ApplyOptions{
+			FieldManager: FieldManagerName,
+			Force:        true,
+		},
+	)
+	return err
+}
+
+func buildComponentAutoscaler(kobj *v1beta1.Kuadrant, deploymentName string, autoscaling *v1beta1.ComponentAutoscaling) *autoscalingv2.HorizontalPodAutoscaler {
+	return &autoscalingv2.HorizontalPodAutoscaler{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "HorizontalPodAutoscaler",
+			APIVersion: autoscalingv2.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploymentName,
+			Namespace: kobj.Namespace,
+			Labels:    KuadrantManagedObjectLabels(),
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         kobj.GroupVersionKind().GroupVersion().String(),
+					Kind:               kobj.GroupVersionKind().Kind,
+					Name:               kobj.Name,
+					UID:                kobj.UID,
+					BlockOwnerDeletion: ptr.To(true),
+					Controller:         ptr.To(true),
+				},
+			},
+		},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+				APIVersion: v1beta1.DeploymentsResource.GroupVersion().String(),
+				Kind:       v1beta1.DeploymentGroupKind.Kind,
+				Name:       deploymentName,
+			},
+			MinReplicas: ptr.To(autoscalingMinReplicas(autoscaling)),
+			MaxReplicas: autoscaling.MaxReplicas,
+			Metrics: []autoscalingv2.MetricSpec{
+				{
+					Type: autoscalingv2.ResourceMetricSourceType,
+					Resource: &autoscalingv2.ResourceMetricSource{
+						Name: corev1.ResourceCPU,
+						Target: autoscalingv2.MetricTarget{
+							Type:               autoscalingv2.UtilizationMetricType,
+							AverageUtilization: ptr.To(ptr.Deref(autoscaling.TargetCPUUtilizationPercentage, defaultAutoscalingTargetCPUUtilizationPercentage)),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// autoscaledReplicas returns the number of replicas desired by the autoscaler of a component, falling back to the
+// minimum number of replicas until the autoscaler has computed it
+func autoscaledReplicas(autoscaling *v1beta1.ComponentAutoscaling, autoscaler *autoscalingv2.HorizontalPodAutoscaler) int32 {
+	if autoscaler != nil && autoscaler.Status.DesiredReplicas > 0 {
+		return autoscaler.Status.DesiredReplicas
+	}
+	return autoscalingMinReplicas(autoscaling)
+}
+
+func autoscalingMinReplicas(autoscaling *v1beta1.ComponentAutoscaling) int32 {
+	return ptr.Deref(autoscaling.MinReplicas, 1)
+}
+
+// autoscaledResources returns the resource requirements of the pods of an autoscaled component, requesting the
+// default CPU when no CPU request is set, as the autoscaler scales on the CPU utilization relative to the request.
+// The CPU request defaults to the CPU limit, if any, as Kubernetes does.
+func autoscaledResources(resources *corev1.ResourceRequirements) *corev1.ResourceRequirements {
+	autoscaled := &corev1.ResourceRequirements{}
+	if resources != nil {
+		autoscaled = resources.DeepCopy()
+	}
+	if _, found := autoscaled.Requests[corev1.ResourceCPU]; found {
+		return autoscaled
+	}
+	if autoscaled.Requests == nil {
+		autoscaled.Requests = corev1.ResourceList{}
+	}
+	autoscaled.Requests[corev1.ResourceCPU] = defaultAutoscalingCPURequest
+	if limit, found := autoscaled.Limits[corev1.ResourceCPU]; found {
+		autoscaled.Requests[corev1.ResourceCPU] = limit
+	}
+	return autoscaled
+}
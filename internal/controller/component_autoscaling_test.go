@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	dfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/utils/ptr"
+
+	"github.com/kuadrant/kuadrant-operator/api/v1beta1"
+)
+
+func TestBuildComponentAutoscaler(t *testing.T) {
+	kobj := &v1beta1.Kuadrant{
+		TypeMeta:   metav1.TypeMeta{Kind: "Kuadrant", APIVersion: v1beta1.GroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: "kuadrant", Namespace: "kuadrant-system"},
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		autoscaler := buildComponentAutoscaler(kobj, limitadorDeploymentName, &v1beta1.ComponentAutoscaling{MaxReplicas: 3})
+		if autoscaler.Name != "limitador-limitador" || autoscaler.Namespace != "kuadrant-system" {
+			t.Errorf("unexpected autoscaler %s/%s", autoscaler.Namespace, autoscaler.Name)
+		}
+		if autoscaler.Labels[kuadrantManagedLabelKey] != "true" {
+			t.Errorf("expected the autoscaler to be labeled as managed by kuadrant, got %v", autoscaler.Labels)
+		}
+		if len(autoscaler.OwnerReferences) != 1 || autoscaler.OwnerReferences[0].Kind != "Kuadrant" {
+			t.Errorf("expected the autoscaler to be owned by the kuadrant CR, got %v", autoscaler.OwnerReferences)
+		}
+		target := autoscaler.Spec.ScaleTargetRef
+		if target.APIVersion != "apps/v1" || target.Kind != "Deployment" || target.Name != "limitador-limitador" {
+			t.Errorf("unexpected scale target %+v", target)
+		}
+		if ptr.Deref(autoscaler.Spec.MinReplicas, 0) != 1 || autoscaler.Spec.MaxReplicas != 3 {
+			t.Errorf("expected 1 to 3 replicas, got %v to %d", autoscaler.Spec.MinReplicas, autoscaler.Spec.MaxReplicas)
+		}
+		if len(autoscaler.Spec.Metrics) != 1 {
+			t.Fatalf("expected a single metric, got %d", len(autoscaler.Spec.Metrics))
+		}
+		metric := autoscaler.Spec.Metrics[0]
+		if metric.Type != autoscalingv2.ResourceMetricSourceType || metric.Resource.Name != corev1.ResourceCPU {
+			t.Errorf("expected a cpu metric, got %+v", metric)
+		}
+		if ptr.Deref(metric.Resource.Target.AverageUtilization, 0) != 80 {
+			t.Errorf("expected a target utilization of 80%%, got %v", metric.Resource.Target.AverageUtilization)
+		}
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		autoscaler := buildComponentAutoscaler(kobj, authorinoDeploymentName, &v1beta1.ComponentAutoscaling{
+			MinReplicas:                    ptr.To(int32(2)),
+			MaxReplicas:                    6,
+			TargetCPUUtilizationPercentage: ptr.To(int32(60)),
+		})
+		if autoscaler.Spec.ScaleTargetRef.Name != "authorino" {
+			t.Errorf("expected the authorino deployment to be scaled, got %s", autoscaler.Spec.ScaleTargetRef.Name)
+		}
+		if ptr.Deref(autoscaler.Spec.MinReplicas, 0) != 2 || autoscaler.Spec.MaxReplicas != 6 {
+			t.Errorf("expected 2 to 6 replicas, got %v to %d", autoscaler.Spec.MinReplicas, autoscaler.Spec.MaxReplicas)
+		}
+		if ptr.Deref(autoscaler.Spec.Metrics[0].Resource.Target.AverageUtilization, 0) != 60 {
+			t.Errorf("expected a target utilization of 60%%, got %v", autoscaler.Spec.Metrics[0].Resource.Target.AverageUtilization)
+		}
+	})
+}
+
+func TestReconcileComponentAutoscaler_Unmanaged(t *testing.T) {
+	kobj := &v1beta1.Kuadrant{
+		TypeMeta:   metav1.TypeMeta{Kind: "Kuadrant", APIVersion: v1beta1.GroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: "kuadrant", Namespace: "kuadrant-system"},
+	}
+	unmanaged := &autoscalingv2.HorizontalPodAutoscaler{
+		TypeMeta:   metav1.TypeMeta{Kind: "HorizontalPodAutoscaler", APIVersion: autoscalingv2.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: authorinoDeploymentName, Namespace: "kuadrant-system"},
+		Spec:       autoscalingv2.HorizontalPodAutoscalerSpec{MaxReplicas: 10},
+	}
+	scheme := runtime.NewScheme()
+	if err := autoscalingv2.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	client := dfake.NewSimpleDynamicClient(scheme, unmanaged)
+
+	err := reconcileComponentAutoscaler(context.Background(), client, kobj, authorinoDeploymentName, &v1beta1.ComponentAutoscaling{MaxReplicas: 3}, nil)
+	if err == nil || !strings.Contains(err.Error(), "not managed by kuadrant") {
+		t.Errorf("expected the unmanaged autoscaler not to be taken over, got %v", err)
+	}
+	for _, action := range client.Actions() {
+		if action.GetVerb() != "get" {
+			t.Errorf("expected the unmanaged autoscaler to be left untouched, got %s", action.GetVerb())
+		}
+	}
+}
+
+func TestAutoscaledResources(t *testing.T) {
+	t.Run("default cpu request", func(t *testing.T) {
+		resources := autoscaledResources(nil)
+		if cpu := resources.Requests[corev1.ResourceCPU]; cpu.String() != "250m" {
+			t.Errorf("expected a cpu request of 250m, got %s", cpu.String())
+		}
+	})
+
+	t.Run("cpu request defaults to the cpu limit", func(t *testing.T) {
+		limited := &corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")}}
+		resources := autoscaledResources(limited)
+		if cpu := resources.Requests[corev1.ResourceCPU]; cpu.String() != "100m" {
+			t.Errorf("expected a cpu request of 100m, got %s", cpu.String())
+		}
+		if len(limited.Requests) != 0 {
+			t.Error("expected the resources of the component to be left untouched")
+		}
+	})
+
+	t.Run("cpu request is kept", func(t *testing.T) {
+		requested := &corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")}}
+		if cpu := autoscaledResources(requested).Requests[corev1.ResourceCPU]; cpu.String() != "2" {
+			t.Errorf("expected a cpu request of 2, got %s", cpu.String())
+		}
+	})
+}
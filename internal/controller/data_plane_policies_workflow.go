@@ -77,7 +77,7 @@ var (
 //+kubebuilder:rbac:groups=kuadrant.io,resources=tokenratelimitpolicies/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=kuadrant.io,resources=tokenratelimitpolicies/finalizers,verbs=update
 
-func NewDataPlanePoliciesWorkflow(mgr controllerruntime.Manager, client *dynamic.DynamicClient, isGatewayAPInstalled, isIstioInstalled, isEnvoyGatewayInstalled, isLimitadorOperatorInstalled, isAuthorinoOperatorInstalled bool) *controller.Workflow {
+func NewDataPlanePoliciesWorkflow(mgr controllerruntime.Manager, client *dynamic.DynamicClient, eventRecorder *PolicyEventRecorder, isGatewayAPInstalled, isIstioInstalled, isEnvoyGatewayInstalled, isLimitadorOperatorInstalled, isAuthorinoOperatorInstalled bool) *controller.Workflow {
 	isGatewayProviderInstalled := isIstioInstalled || isEnvoyGatewayInstalled
 	dataPlanePoliciesValidation := &controller.Workflow{
 		Tasks: []controller.ReconcileFunc{
@@ -98,6 +98,7 @@ func NewDataPlanePoliciesWorkflow(mgr controllerruntime.Manager, client *dynamic
 		Tasks: []controller.ReconcileFunc{
 			traceReconcileFunc("reconciler.auth_configs", (&AuthConfigsReconciler{client: client}).Subscription().Reconcile),
 			traceReconcileFunc("reconciler.limitador_limits", (&LimitadorLimitsReconciler{client: client}).Subscription().Reconcile),
+			traceReconcileFunc("reconciler.effective_policies", (&EffectivePolicyReconciler{client: client}).Subscription().Reconcile),
 		},
 	}
 
@@ -109,7 +110,7 @@ func NewDataPlanePoliciesWorkflow(mgr controllerruntime.Manager, client *dynamic
 		effectiveDataPlanePoliciesWorkflow.Tasks = append(effectiveDataPlanePoliciesWorkflow.Tasks,
 			traceReconcileFunc("reconciler.istio_tracing_cluster", (&IstioTracingClusterReconciler{client: client}).Subscription().Reconcile))
 		effectiveDataPlanePoliciesWorkflow.Tasks = append(effectiveDataPlanePoliciesWorkflow.Tasks,
-			traceReconcileFunc("reconciler.istio_extension", (&IstioExtensionReconciler{client: client}).Subscription().Reconcile))
+			traceReconcileFunc("reconciler.istio_extension", (&IstioExtensionReconciler{client: client, eventRecorder: eventRecorder}).Subscription().Reconcile))
 	}
 
 	if isEnvoyGatewayInstalled {
@@ -120,7 +121,7 @@ func NewDataPlanePoliciesWorkflow(mgr controllerruntime.Manager, client *dynamic
 		effectiveDataPlanePoliciesWorkflow.Tasks = append(effectiveDataPlanePoliciesWorkflow.Tasks,
 			traceReconcileFunc("reconciler.envoy_gateway_tracing_cluster", (&EnvoyGatewayTracingClusterReconciler{client: client}).Subscription().Reconcile))
 		effectiveDataPlanePoliciesWorkflow.Tasks = append(effectiveDataPlanePoliciesWorkflow.Tasks,
-			traceReconcileFunc("reconciler.envoy_gateway_extension", (&EnvoyGatewayExtensionReconciler{client: client}).Subscription().Reconcile))
+			traceReconcileFunc("reconciler.envoy_gateway_extension", (&EnvoyGatewayExtensionReconciler{client: client, eventRecorder: eventRecorder}).Subscription().Reconcile))
 	}
 
 	if isIstioInstalled && isAuthorinoOperatorInstalled && isLimitadorOperatorInstalled {
@@ -133,9 +134,9 @@ func NewDataPlanePoliciesWorkflow(mgr controllerruntime.Manager, client *dynamic
 
 	dataPlanePoliciesStatus := &controller.Workflow{
 		Tasks: []controller.ReconcileFunc{
-			traceReconcileFunc("status.auth_policy", (&AuthPolicyStatusUpdater{client: client}).Subscription().Reconcile),
-			traceReconcileFunc("status.ratelimit_policy", (&RateLimitPolicyStatusUpdater{client: client}).Subscription().Reconcile),
-			traceReconcileFunc("status.token_ratelimit_policy", (&TokenRateLimitPolicyStatusUpdater{client: client}).Subscription().Reconcile),
+			traceReconcileFunc("status.auth_policy", (&AuthPolicyStatusUpdater{client: client, eventRecorder: eventRecorder}).Subscription().Reconcile),
+			traceReconcileFunc("status.ratelimit_policy", (&RateLimitPolicyStatusUpdater{client: client, eventRecorder: eventRecorder}).Subscription().Reconcile),
+			traceReconcileFunc("status.token_ratelimit_policy", (&TokenRateLimitPolicyStatusUpdater{client: client, eventRecorder: eventRecorder}).Subscription().Reconcile),
 		},
 	}
 
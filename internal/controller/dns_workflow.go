@@ -46,13 +46,13 @@ var (
 //+kubebuilder:rbac:groups=kuadrant.io,resources=dnsrecords,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=kuadrant.io,resources=dnsrecords/status,verbs=get
 
-func NewDNSWorkflow(client *dynamic.DynamicClient, scheme *runtime.Scheme, isGatewayAPIInstalled, isDNSOperatorInstalled bool) *controller.Workflow {
+func NewDNSWorkflow(client *dynamic.DynamicClient, scheme *runtime.Scheme, eventRecorder *PolicyEventRecorder, isGatewayAPIInstalled, isDNSOperatorInstalled bool) *controller.Workflow {
 	return &controller.Workflow{
 		Precondition: traceReconcileFunc("dns.validator", NewDNSPoliciesValidator(isGatewayAPIInstalled, isDNSOperatorInstalled).Subscription().Reconcile),
 		Tasks: []controller.ReconcileFunc{
 			traceReconcileFunc("dns.effective_policies", NewEffectiveDNSPoliciesReconciler(client, scheme).Subscription().Reconcile),
 		},
-		Postcondition: traceReconcileFunc("dns.status_updater", NewDNSPolicyStatusUpdater(client).Subscription().Reconcile),
+		Postcondition: traceReconcileFunc("dns.status_updater", NewDNSPolicyStatusUpdater(client, eventRecorder).Subscription().Reconcile),
 	}
 }
 
@@ -17,13 +17,11 @@ limitations under the License.
 package controllers
 
 import (
-	"github.com/prometheus/client_golang/prometheus"
-
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	kuadrantv1 "github.com/kuadrant/kuadrant-operator/api/v1"
+	operatormetrics "github.com/kuadrant/kuadrant-operator/internal/metrics"
 )
 
 const (
@@ -33,31 +31,25 @@ const (
 )
 
 var (
-	dnsPolicyReady = prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "kuadrant_dns_policy_ready",
-			Help: "DNS Policy ready",
-		},
-		[]string{dnsPolicyNameLabel, dnsPolicyNamespaceLabel, dnsPolicyCondition})
+	dnsPolicyReady = operatormetrics.NewGaugeVec(
+		"kuadrant_dns_policy_ready",
+		"DNS Policy ready",
+		dnsPolicyNameLabel, dnsPolicyNamespaceLabel, dnsPolicyCondition)
 )
 
 func emitConditionMetrics(dnsPolicy *kuadrantv1.DNSPolicy) {
 	readyStatus := meta.FindStatusCondition(dnsPolicy.Status.Conditions, ReadyConditionType)
 	if readyStatus == nil {
-		dnsPolicyReady.WithLabelValues(dnsPolicy.Name, dnsPolicy.Namespace, "true").Set(0)
-		dnsPolicyReady.WithLabelValues(dnsPolicy.Name, dnsPolicy.Namespace, "false").Set(0)
-		dnsPolicyReady.WithLabelValues(dnsPolicy.Name, dnsPolicy.Namespace, "unknown").Set(1)
+		dnsPolicyReady.Set(0, dnsPolicy.Name, dnsPolicy.Namespace, "true")
+		dnsPolicyReady.Set(0, dnsPolicy.Name, dnsPolicy.Namespace, "false")
+		dnsPolicyReady.Set(1, dnsPolicy.Name, dnsPolicy.Namespace, "unknown")
 	} else if readyStatus.Status != metav1.ConditionTrue {
-		dnsPolicyReady.WithLabelValues(dnsPolicy.Name, dnsPolicy.Namespace, "true").Set(0)
-		dnsPolicyReady.WithLabelValues(dnsPolicy.Name, dnsPolicy.Namespace, "false").Set(1)
-		dnsPolicyReady.WithLabelValues(dnsPolicy.Name, dnsPolicy.Namespace, "unknown").Set(0)
+		dnsPolicyReady.Set(0, dnsPolicy.Name, dnsPolicy.Namespace, "true")
+		dnsPolicyReady.Set(1, dnsPolicy.Name, dnsPolicy.Namespace, "false")
+		dnsPolicyReady.Set(0, dnsPolicy.Name, dnsPolicy.Namespace, "unknown")
 	} else {
-		dnsPolicyReady.WithLabelValues(dnsPolicy.Name, dnsPolicy.Namespace, "true").Set(1)
-		dnsPolicyReady.WithLabelValues(dnsPolicy.Name, dnsPolicy.Namespace, "false").Set(0)
-		dnsPolicyReady.WithLabelValues(dnsPolicy.Name, dnsPolicy.Namespace, "unknown").Set(0)
+		dnsPolicyReady.Set(1, dnsPolicy.Name, dnsPolicy.Namespace, "true")
+		dnsPolicyReady.Set(0, dnsPolicy.Name, dnsPolicy.Namespace, "false")
+		dnsPolicyReady.Set(0, dnsPolicy.Name, dnsPolicy.Namespace, "unknown")
 	}
 }
-
-func init() {
-	metrics.Registry.MustRegister(dnsPolicyReady)
-}
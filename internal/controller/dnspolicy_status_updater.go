@@ -27,12 +27,13 @@ import (
 	"github.com/kuadrant/kuadrant-operator/internal/utils"
 )
 
-func NewDNSPolicyStatusUpdater(client *dynamic.DynamicClient) *DNSPolicyStatusUpdater {
-	return &DNSPolicyStatusUpdater{client: client}
+func NewDNSPolicyStatusUpdater(client *dynamic.DynamicClient, eventRecorder *PolicyEventRecorder) *DNSPolicyStatusUpdater {
+	return &DNSPolicyStatusUpdater{client: client, eventRecorder: eventRecorder}
 }
 
 type DNSPolicyStatusUpdater struct {
-	client *dynamic.DynamicClient
+	client        *dynamic.DynamicClient
+	eventRecorder *PolicyEventRecorder
 }
 
 func (r *DNSPolicyStatusUpdater) Subscription() controller.Subscription {
@@ -134,6 +135,7 @@ func (r *DNSPolicyStatusUpdater) updateStatus(ctx context.Context, _ []controlle
 			continue
 		}
 		newStatus.ObservedGeneration = policy.Generation
+		previousConditions := policy.Status.Conditions
 		policy.Status = *newStatus
 
 		obj, err := controller.Destruct(policy)
@@ -155,6 +157,7 @@ func (r *DNSPolicyStatusUpdater) updateStatus(ctx context.Context, _ []controlle
 		}
 
 		emitConditionMetrics(policy)
+		r.eventRecorder.RecordConditionTransitions(policy, previousConditions, policy.Status.Conditions)
 
 		span.AddEvent("policy status updated successfully")
 		span.SetStatus(codes.Ok, "")
@@ -0,0 +1,271 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kuadrant/policy-machinery/controller"
+	"github.com/kuadrant/policy-machinery/machinery"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/utils/ptr"
+	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	kuadrantv1 "github.com/kuadrant/kuadrant-operator/api/v1"
+	kuadrantv1alpha1 "github.com/kuadrant/kuadrant-operator/api/v1alpha1"
+	kuadrantpolicymachinery "github.com/kuadrant/kuadrant-operator/internal/policymachinery"
+)
+
+//+kubebuilder:rbac:groups=kuadrant.io,resources=effectivepolicies,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=kuadrant.io,resources=effectivepolicies/status,verbs=get;update;patch
+
+const EffectivePolicyReconcilerName = "EffectivePolicyReconciler"
+
+// EffectivePolicyReconciler writes the effective auth, rate limit and token rate limit policies of each route rule
+// into a read-only EffectivePolicy object in the namespace of the route
+type EffectivePolicyReconciler struct {
+	client *dynamic.DynamicClient
+}
+
+// Subscription subscribes to the events with potential to change the effective policies. The events of the
+// EffectivePolicy objects themselves are left out, as the effective policies are not computed for them: changes
+// not made by the operator are reverted on the next reconciliation of the effective policies.
+func (r *EffectivePolicyReconciler) Subscription() controller.Subscription {
+	return controller.Subscription{
+		ReconcileFunc: r.Reconcile,
+		Events:        dataPlaneEffectivePoliciesEventMatchers,
+	}
+}
+
+func (r *EffectivePolicyReconciler) Reconcile(ctx context.Context, _ []controller.ResourceEvent, topology *machinery.Topology, _ error, state *sync.Map) error {
+	logger := controller.LoggerFromContext(ctx).WithName("EffectivePolicyReconciler").WithValues("context", ctx)
+
+	desiredEffectivePolicies, computed := buildDesiredEffectivePolicies(state)
+	if !computed {
+		logger.V(1).Info("effective policies not computed in this reconciliation, skipping")
+		return nil
+	}
+
+	logger.V(1).Info("reconciling effectivepolicy objects", "effectivePolicies", len(desiredEffectivePolicies))
+	defer logger.V(1).Info("finished reconciling effectivepolicy objects")
+
+	errorRegistry := GetOrCreateErrorRegistry(state)
+
+	existingEffectivePolicies := lo.SliceToMap(topology.Objects().Items(func(o machinery.Object) bool {
+		return o.GroupVersionKind().GroupKind() == kuadrantv1alpha1.EffectivePolicyGroupKind && labels.Set(o.(*controller.RuntimeObject).GetLabels()).AsSelector().Matches(KuadrantManagedObjectLabels())
+	}), func(o machinery.Object) (k8stypes.NamespacedName, *kuadrantv1alpha1.EffectivePolicy) {
+		return k8stypes.NamespacedName{Namespace: o.GetNamespace(), Name: o.GetName()}, o.(*controller.RuntimeObject).Object.(*kuadrantv1alpha1.EffectivePolicy)
+	})
+
+	for key, desired := range desiredEffectivePolicies {
+		resource := r.client.Resource(kuadrantv1alpha1.EffectivePoliciesResource).Namespace(key.Namespace)
+
+		existing, found := existingEffectivePolicies[key]
+		if found && equality.Semantic.DeepEqual(existing.Status, desired.Status) {
+			continue
+		}
+
+		operation := OperationUpdate
+		if !found {
+			operation = OperationCreate
+			obj, err := controller.Destruct(desired)
+			if err != nil {
+				logger.Error(err, "failed to destruct effectivepolicy object", "effectivepolicy", key.String())
+				continue
+			}
+			// the status is ignored on create, it is set right after
+			created, err := resource.Create(ctx, obj, metav1.CreateOptions{})
+			if err != nil {
+				logger.Error(err, "failed to create effectivepolicy object", "effectivepolicy", key.String())
+				errorRegistry.Record(EffectivePolicyReconcilerName, operation, key, kuadrantv1alpha1.EffectivePolicyGroupKind, err)
+				continue
+			}
+			existing = desired.DeepCopy()
+			existing.SetResourceVersion(created.GetResourceVersion())
+			existing.SetUID(created.GetUID())
+		}
+
+		updated := existing.DeepCopy()
+		updated.Status = desired.Status
+		obj, err := controller.Destruct(updated)
+		if err != nil {
+			logger.Error(err, "failed to destruct effectivepolicy object", "effectivepolicy", key.String())
+			continue
+		}
+		if _, err := resource.UpdateStatus(ctx, obj, metav1.UpdateOptions{}); err != nil {
+			logger.Error(err, "failed to update effectivepolicy status", "effectivepolicy", key.String())
+			errorRegistry.Record(EffectivePolicyReconcilerName, operation, key, kuadrantv1alpha1.EffectivePolicyGroupKind, err)
+		}
+	}
+
+	// cleanup effectivepolicies of route rules with no effective policy anymore
+	for key := range existingEffectivePolicies {
+		if _, desired := desiredEffectivePolicies[key]; desired {
+			continue
+		}
+		if err := r.client.Resource(kuadrantv1alpha1.EffectivePoliciesResource).Namespace(key.Namespace).Delete(ctx, key.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			logger.Error(err, "failed to delete effectivepolicy object", "effectivepolicy", key.String())
+			errorRegistry.Record(EffectivePolicyReconcilerName, OperationDelete, key, kuadrantv1alpha1.EffectivePolicyGroupKind, err)
+		}
+	}
+
+	return nil
+}
+
+// buildDesiredEffectivePolicies builds an EffectivePolicy object per route rule out of the effective policies stored
+// in the state. It returns false when none of the effective policies were computed in the current reconciliation.
+func buildDesiredEffectivePolicies(state *sync.Map) (map[k8stypes.NamespacedName]*kuadrantv1alpha1.EffectivePolicy, bool) {
+	effectiveAuthPolicies, authComputed := state.Load(StateEffectiveAuthPolicies)
+	effectiveRateLimitPolicies, rateLimitComputed := state.Load(StateEffectiveRateLimitPolicies)
+	effectiveTokenRateLimitPolicies, tokenRateLimitComputed := state.Load(StateEffectiveTokenRateLimitPolicies)
+	if !authComputed && !rateLimitComputed && !tokenRateLimitComputed {
+		return nil, false
+	}
+
+	effectivePolicies := make(map[k8stypes.NamespacedName]*kuadrantv1alpha1.EffectivePolicy)
+	add := func(path []machinery.Targetable, summary *kuadrantv1alpha1.EffectivePolicySummary, set func(*kuadrantv1alpha1.EffectivePolicyPath, *kuadrantv1alpha1.EffectivePolicySummary)) {
+		parsed, err := kuadrantpolicymachinery.ParseTopologyPath(path)
+		if err != nil {
+			return
+		}
+		effectivePolicy := ensureEffectivePolicy(effectivePolicies, parsed)
+		gateway := k8stypes.NamespacedName{Namespace: parsed.Gateway.GetNamespace(), Name: parsed.Gateway.GetName()}.String()
+		listener := string(parsed.Listener.Name)
+		i := slices.IndexFunc(effectivePolicy.Status.Paths, func(p kuadrantv1alpha1.EffectivePolicyPath) bool {
+			return p.Gateway == gateway && p.Listener == listener
+		})
+		if i < 0 {
+			effectivePolicy.Status.Paths = append(effectivePolicy.Status.Paths, kuadrantv1alpha1.EffectivePolicyPath{Gateway: gateway, Listener: listener})
+			i = len(effectivePolicy.Status.Paths) - 1
+		}
+		set(&effectivePolicy.Status.Paths[i], summary)
+	}
+
+	if authComputed {
+		for _, effectivePolicy := range effectiveAuthPolicies.(EffectiveAuthPolicies) {
+			add(effectivePolicy.Path, summarizeEffectivePolicy(&effectivePolicy.Spec, effectivePolicy.SourcePolicies), func(p *kuadrantv1alpha1.EffectivePolicyPath, s *kuadrantv1alpha1.EffectivePolicySummary) {
+				p.Auth = s
+			})
+		}
+	}
+	if rateLimitComputed {
+		for _, effectivePolicy := range effectiveRateLimitPolicies.(EffectiveRateLimitPolicies) {
+			add(effectivePolicy.Path, summarizeEffectivePolicy(&effectivePolicy.Spec, effectivePolicy.SourcePolicies), func(p *kuadrantv1alpha1.EffectivePolicyPath, s *kuadrantv1alpha1.EffectivePolicySummary) {
+				p.RateLimit = s
+			})
+		}
+	}
+	if tokenRateLimitComputed {
+		for _, effectivePolicy := range effectiveTokenRateLimitPolicies.(EffectiveTokenRateLimitPolicies) {
+			add(effectivePolicy.Path, summarizeEffectivePolicy(&effectivePolicy.Spec, effectivePolicy.SourcePolicies), func(p *kuadrantv1alpha1.EffectivePolicyPath, s *kuadrantv1alpha1.EffectivePolicySummary) {
+				p.TokenRateLimit = s
+			})
+		}
+	}
+
+	for _, effectivePolicy := range effectivePolicies {
+		sort.Slice(effectivePolicy.Status.Paths, func(i, j int) bool {
+			a, b := effectivePolicy.Status.Paths[i], effectivePolicy.Status.Paths[j]
+			if a.Gateway != b.Gateway {
+				return a.Gateway < b.Gateway
+			}
+			return a.Listener < b.Listener
+		})
+	}
+
+	return effectivePolicies, true
+}
+
+// ensureEffectivePolicy returns the EffectivePolicy object of the route rule of a path, adding it when missing
+func ensureEffectivePolicy(effectivePolicies map[k8stypes.NamespacedName]*kuadrantv1alpha1.EffectivePolicy, parsed *kuadrantpolicymachinery.ParsedTopologyPath) *kuadrantv1alpha1.EffectivePolicy {
+	routeKind := machinery.HTTPRouteGroupKind.Kind
+	if parsed.RouteType == kuadrantpolicymachinery.RouteTypeGRPC {
+		routeKind = machinery.GRPCRouteGroupKind.Kind
+	}
+	route := parsed.GetRoute()
+	key := k8stypes.NamespacedName{
+		Namespace: route.GetNamespace(),
+		Name:      EffectivePolicyName(routeKind, route.GetName(), parsed.GetRouteRuleName()),
+	}
+	if effectivePolicy, found := effectivePolicies[key]; found {
+		return effectivePolicy
+	}
+
+	effectivePolicy := &kuadrantv1alpha1.EffectivePolicy{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       kuadrantv1alpha1.EffectivePolicyGroupKind.Kind,
+			APIVersion: kuadrantv1alpha1.GroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      key.Name,
+			Namespace: key.Namespace,
+			Labels:    KuadrantManagedObjectLabels(),
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: gatewayapiv1.GroupVersion.String(),
+					Kind:       routeKind,
+					Name:       route.GetName(),
+					UID:        route.GetUID(),
+				},
+			},
+		},
+		Status: kuadrantv1alpha1.EffectivePolicyStatus{
+			TargetRef: gatewayapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+				LocalPolicyTargetReference: gatewayapiv1alpha2.LocalPolicyTargetReference{
+					Group: gatewayapiv1.GroupName,
+					Kind:  gatewayapiv1.Kind(routeKind),
+					Name:  gatewayapiv1.ObjectName(route.GetName()),
+				},
+				SectionName: ptr.To(gatewayapiv1.SectionName(parsed.GetRouteRuleName())),
+			},
+		},
+	}
+	effectivePolicies[key] = effectivePolicy
+	return effectivePolicy
+}
+
+// EffectivePolicyName returns the name of the EffectivePolicy object of a route rule
+func EffectivePolicyName(routeKind, routeName, ruleName string) string {
+	name := fmt.Sprintf("%s.%s.%s", strings.ToLower(routeKind), routeName, ruleName)
+	if len(name) <= validation.DNS1123SubdomainMaxLength {
+		return name
+	}
+	hash := sha256.Sum256([]byte(name))
+	suffix := hex.EncodeToString(hash[:])[:8]
+	return strings.TrimRight(name[:validation.DNS1123SubdomainMaxLength-len(suffix)-1], ".-") + "-" + suffix
+}
+
+// summarizeEffectivePolicy lists the merged rules of an effective policy along with the policy each one comes from
+func summarizeEffectivePolicy(policy kuadrantv1.MergeablePolicy, sourcePolicies []string) *kuadrantv1alpha1.EffectivePolicySummary {
+	summary := &kuadrantv1alpha1.EffectivePolicySummary{
+		SourcePolicies: slices.Clone(sourcePolicies),
+	}
+	for ruleID, rule := range policy.Rules() {
+		effectiveRule := kuadrantv1alpha1.EffectiveRule{
+			Name:   lo.Ternary(ruleID == kuadrantv1.RulesKeyTopLevelPredicates, "when#", ruleID),
+			Source: rule.GetSource(),
+		}
+		switch limit := rule.GetSpec().(type) {
+		case *kuadrantv1.Limit:
+			effectiveRule.Rates = limit.Rates
+		case *kuadrantv1alpha1.TokenLimit:
+			effectiveRule.Rates = limit.Rates
+		}
+		summary.Rules = append(summary.Rules, effectiveRule)
+	}
+	sort.Slice(summary.Rules, func(i, j int) bool { return summary.Rules[i].Name < summary.Rules[j].Name })
+	return summary
+}
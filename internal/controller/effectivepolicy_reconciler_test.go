@@ -0,0 +1,131 @@
+//go:build unit
+
+package controllers
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/utils/ptr"
+	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	kuadrantv1 "github.com/kuadrant/kuadrant-operator/api/v1"
+)
+
+func TestBuildDesiredEffectivePolicies(t *testing.T) {
+	gatewayClass := &machinery.GatewayClass{GatewayClass: &gatewayapiv1.GatewayClass{
+		TypeMeta:   metav1.TypeMeta{Kind: machinery.GatewayClassGroupKind.Kind, APIVersion: gatewayapiv1.GroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: "kuadrant"},
+	}}
+	gateway := &machinery.Gateway{Gateway: &gatewayapiv1.Gateway{
+		TypeMeta:   metav1.TypeMeta{Kind: machinery.GatewayGroupKind.Kind, APIVersion: gatewayapiv1.GroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: "gateway", Namespace: "gateway-system"},
+		Spec: gatewayapiv1.GatewaySpec{
+			GatewayClassName: "kuadrant",
+			Listeners:        []gatewayapiv1.Listener{{Name: "http"}, {Name: "https"}},
+		},
+	}}
+	httpRoute := &machinery.HTTPRoute{HTTPRoute: &gatewayapiv1.HTTPRoute{
+		TypeMeta:   metav1.TypeMeta{Kind: machinery.HTTPRouteGroupKind.Kind, APIVersion: gatewayapiv1.GroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: "toystore", Namespace: "toystore", UID: "route-uid"},
+		Spec: gatewayapiv1.HTTPRouteSpec{
+			CommonRouteSpec: gatewayapiv1.CommonRouteSpec{
+				ParentRefs: []gatewayapiv1.ParentReference{{Name: "gateway", Namespace: ptr.To(gatewayapiv1.Namespace("gateway-system"))}},
+			},
+			Rules: []gatewayapiv1.HTTPRouteRule{{Name: ptr.To(gatewayapiv1.SectionName("rule-1"))}},
+		},
+	}}
+	rule := &machinery.HTTPRouteRule{HTTPRoute: httpRoute, HTTPRouteRule: &httpRoute.Spec.Rules[0], Name: "rule-1"}
+	pathThrough := func(listener string) []machinery.Targetable {
+		return []machinery.Targetable{
+			gatewayClass,
+			gateway,
+			&machinery.Listener{Gateway: gateway, Listener: &gatewayapiv1.Listener{Name: gatewayapiv1.SectionName(listener)}},
+			httpRoute,
+			rule,
+		}
+	}
+
+	rateLimitPolicy := kuadrantv1.RateLimitPolicy{
+		TypeMeta:   metav1.TypeMeta{Kind: kuadrantv1.RateLimitPolicyGroupKind.Kind, APIVersion: kuadrantv1.GroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: "effective", Namespace: "toystore"},
+		Spec: kuadrantv1.RateLimitPolicySpec{
+			RateLimitPolicySpecProper: kuadrantv1.RateLimitPolicySpecProper{
+				Limits: map[string]kuadrantv1.Limit{
+					"per-user": {Rates: []kuadrantv1.Rate{{Limit: 5, Window: "10s"}}, Source: "ratelimitpolicy.kuadrant.io:toystore/route-rlp"},
+					"global":   {Rates: []kuadrantv1.Rate{{Limit: 100, Window: "1m"}}, Source: "ratelimitpolicy.kuadrant.io:gateway-system/gw-rlp"},
+				},
+			},
+		},
+	}
+	sourcePolicies := []string{"ratelimitpolicy.kuadrant.io:gateway-system/gw-rlp", "ratelimitpolicy.kuadrant.io:toystore/route-rlp"}
+
+	state := &sync.Map{}
+	if _, computed := buildDesiredEffectivePolicies(state); computed {
+		t.Fatal("expected no effective policies when they were not computed")
+	}
+
+	state.Store(StateEffectiveRateLimitPolicies, EffectiveRateLimitPolicies{
+		kuadrantv1.PathID(pathThrough("https")): {Path: pathThrough("https"), Spec: rateLimitPolicy, SourcePolicies: sourcePolicies},
+		kuadrantv1.PathID(pathThrough("http")):  {Path: pathThrough("http"), Spec: rateLimitPolicy, SourcePolicies: sourcePolicies},
+	})
+	effectivePolicies, computed := buildDesiredEffectivePolicies(state)
+	if !computed {
+		t.Fatal("expected the effective policies to be computed")
+	}
+	if len(effectivePolicies) != 1 {
+		t.Fatalf("expected 1 effective policy, got %d", len(effectivePolicies))
+	}
+
+	effectivePolicy, found := effectivePolicies[k8stypes.NamespacedName{Namespace: "toystore", Name: "httproute.toystore.rule-1"}]
+	if !found {
+		t.Fatalf("expected the effective policy of the route rule, got %v", effectivePolicies)
+	}
+	if owners := effectivePolicy.GetOwnerReferences(); len(owners) != 1 || owners[0].UID != "route-uid" || owners[0].Kind != "HTTPRoute" {
+		t.Errorf("expected the effective policy to be owned by the route, got %v", owners)
+	}
+	if targetRef := effectivePolicy.Status.TargetRef; targetRef.Kind != "HTTPRoute" || targetRef.Name != "toystore" || ptr.Deref(targetRef.SectionName, "") != "rule-1" {
+		t.Errorf("unexpected targetRef %v", targetRef)
+	}
+
+	paths := effectivePolicy.Status.Paths
+	if len(paths) != 2 || paths[0].Listener != "http" || paths[1].Listener != "https" || paths[0].Gateway != "gateway-system/gateway" {
+		t.Fatalf("expected a path per listener sorted by name, got %v", paths)
+	}
+	if paths[0].Auth != nil || paths[0].TokenRateLimit != nil {
+		t.Errorf("expected only the effective rate limit policy, got %v", paths[0])
+	}
+	summary := paths[0].RateLimit
+	if summary == nil || len(summary.Rules) != 2 || len(summary.SourcePolicies) != 2 {
+		t.Fatalf("unexpected rate limit summary %v", summary)
+	}
+	if summary.Rules[0].Name != "global" || summary.Rules[0].Source != "ratelimitpolicy.kuadrant.io:gateway-system/gw-rlp" || summary.Rules[0].Rates[0].Limit != 100 {
+		t.Errorf("unexpected rule %v", summary.Rules[0])
+	}
+	if summary.Rules[1].Name != "per-user" || summary.Rules[1].Source != "ratelimitpolicy.kuadrant.io:toystore/route-rlp" {
+		t.Errorf("unexpected rule %v", summary.Rules[1])
+	}
+}
+
+func TestEffectivePolicyName(t *testing.T) {
+	if name := EffectivePolicyName("GRPCRoute", "toystore", "rule-2"); name != "grpcroute.toystore.rule-2" {
+		t.Errorf("name = %s, want grpcroute.toystore.rule-2", name)
+	}
+
+	long := strings.Repeat("a", 250)
+	name := EffectivePolicyName("HTTPRoute", long, "rule-1")
+	if len(name) > validation.DNS1123SubdomainMaxLength {
+		t.Errorf("name exceeds %d characters: %d", validation.DNS1123SubdomainMaxLength, len(name))
+	}
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		t.Errorf("invalid name %s: %v", name, errs)
+	}
+	if name == EffectivePolicyName("HTTPRoute", long, "rule-2") {
+		t.Error("expected distinct names for distinct rules")
+	}
+}
@@ -44,7 +44,8 @@ const EnvoyGatewayExtensionReconcilerName = "EnvoyGatewayExtensionReconciler"
 
 // EnvoyGatewayExtensionReconciler reconciles Envoy Gateway EnvoyExtensionPolicy custom resources
 type EnvoyGatewayExtensionReconciler struct {
-	client *dynamic.DynamicClient
+	client        *dynamic.DynamicClient
+	eventRecorder *PolicyEventRecorder
 }
 
 // EnvoyGatewayExtensionReconciler subscribes to events with potential impact on the Envoy Gateway EnvoyExtensionPolicy custom resources
@@ -83,6 +84,12 @@ func (r *EnvoyGatewayExtensionReconciler) Reconcile(ctx context.Context, _ []con
 	// Reconcile EnvoyPatchPolicy cluster patches for registered upstreams
 	r.reconcileUpstreamClusters(ctx, topology, gateways, errorRegistry)
 
+	kObj := GetKuadrantFromTopology(topology, state)
+	var observabilitySpec *kuadrantv1beta1.Observability
+	if kObj != nil {
+		observabilitySpec = validObservabilitySpec(kObj)
+	}
+
 	// build wasm plugin configs for each gateway
 	wasmConfigs, observability, serviceBuilder, err := r.buildWasmConfigs(ctx, topology, state)
 	if err != nil {
@@ -109,6 +116,10 @@ func (r *EnvoyGatewayExtensionReconciler) Reconcile(ctx context.Context, _ []con
 			wasmConfig.Observability = observability
 			wasmConfig.Services = lo.Assign(wasmConfig.Services, serviceBuilder.Build())
 		}
+		if observabilitySpec != nil && len(wasmConfig.ActionSets) > 0 {
+			wasmConfig.Observability = wasm.ObservabilityForGateway(wasmConfig.Observability, observabilitySpec, gatewayKey)
+		}
+		emitWasmConfigSizeMetric(gateway, &wasmConfig)
 
 		desiredEnvoyExtensionPolicy := buildEnvoyExtensionPolicyForGateway(gateway, wasmConfig, ProtectedRegistry, WASMFilterImageURL)
 
@@ -140,6 +151,8 @@ func (r *EnvoyGatewayExtensionReconciler) Reconcile(ctx context.Context, _ []con
 					kuadrantenvoygateway.EnvoyExtensionPolicyGroupKind,
 					err,
 				)
+			} else {
+				r.eventRecorder.RecordWasmConfigPushed(gateway.Gateway, kuadrantenvoygateway.EnvoyExtensionPolicyGroupKind.Kind, desiredEnvoyExtensionPolicy.GetName())
 			}
 			continue
 		}
@@ -188,6 +201,8 @@ func (r *EnvoyGatewayExtensionReconciler) Reconcile(ctx context.Context, _ []con
 				kuadrantenvoygateway.EnvoyExtensionPolicyGroupKind,
 				err,
 			)
+		} else {
+			r.eventRecorder.RecordWasmConfigPushed(gateway.Gateway, kuadrantenvoygateway.EnvoyExtensionPolicyGroupKind.Kind, desiredEnvoyExtensionPolicy.GetName())
 		}
 	}
 
@@ -395,7 +410,7 @@ func (r *EnvoyGatewayExtensionReconciler) buildWasmConfigs(ctx context.Context,
 	kObj := GetKuadrantFromTopology(topology, state)
 	var observability *wasm.Observability
 	if kObj != nil {
-		observability = wasm.BuildObservabilityConfig(serviceBuilder, &kObj.Spec.Observability)
+		observability = wasm.BuildObservabilityConfig(serviceBuilder, validObservabilitySpec(kObj))
 	}
 
 	effectiveAuthPolicies, ok := state.Load(StateEffectiveAuthPolicies)
@@ -509,7 +524,8 @@ func (r *EnvoyGatewayExtensionReconciler) buildWasmConfigs(ctx context.Context,
 			validatorBuilder.PushPolicyBinding(celvalidator.TokenRateLimitPolicyKind, celvalidator.RateLimitName, cel.AnyType)
 		}
 
-		// Attach extension request bindings to specs based on store-path availability
+		// Attach extension request bindings to specs based on store-path availability.
+		// Bindings and functions of the route take precedence over the ones of the gateway.
 		var routeLocator string
 		switch parsed.RouteType {
 		case kuadrantpolicymachinery.RouteTypeHTTP:
@@ -517,7 +533,14 @@ func (r *EnvoyGatewayExtensionReconciler) buildWasmConfigs(ctx context.Context,
 		case kuadrantpolicymachinery.RouteTypeGRPC:
 			routeLocator = parsed.GRPCRoute.GetLocator()
 		}
-		wasm.AttachBindings(specs, extension.GetRequestBindings([]string{parsed.Gateway.GetLocator(), routeLocator}))
+		requestBindings, metrics, metricsErr := extension.GetRequestBindingsAndMetrics([]string{routeLocator, parsed.Gateway.GetLocator()})
+		if metricsErr != nil {
+			// the TelemetryPolicy rejects its invalid custom metrics, these come from other extensions
+			logger.Error(metricsErr, "ignoring invalid custom metrics", "pathID", pathID)
+		}
+		wasm.AttachBindings(specs, requestBindings)
+		functions := extension.GetFunctionBindings([]string{routeLocator, parsed.Gateway.GetLocator()})
+		celvalidator.AddFunctionBindings(validatorBuilder, functions)
 
 		pathSpan.SetAttributes(attribute.Int("specs.before_merge", len(specs)))
 
@@ -537,7 +560,8 @@ func (r *EnvoyGatewayExtensionReconciler) buildWasmConfigs(ctx context.Context,
 			return nil, nil, nil, fmt.Errorf("failed to merge/verify action specs for path %s: %w", pathID, err)
 		}
 
-		if len(specs) == 0 {
+		// custom metrics are recorded even for the paths without any action
+		if len(specs) == 0 && len(metrics) == 0 {
 			pathSpan.SetStatus(codes.Ok, "no specs after merge")
 			pathSpan.End()
 			continue
@@ -565,7 +589,9 @@ func (r *EnvoyGatewayExtensionReconciler) buildWasmConfigs(ctx context.Context,
 			}
 		}
 
-		builtActions := wasm.BuildActions(validSpecs)
+		// Calls to extension functions become lookups of values fetched by preceding actions
+		validSpecs, functionActions := wasm.BindFunctions(validSpecs, functions)
+		builtActions := append(functionActions, wasm.BuildActions(validSpecs)...)
 
 		pathSpan.SetAttributes(
 			attribute.Int("specs.after_merge", len(specs)),
@@ -573,7 +599,7 @@ func (r *EnvoyGatewayExtensionReconciler) buildWasmConfigs(ctx context.Context,
 			attribute.Int("specs.invalid", invalidCount),
 		)
 
-		if len(builtActions) == 0 {
+		if len(builtActions) == 0 && len(metrics) == 0 {
 			pathSpan.SetStatus(codes.Ok, "no validated actions")
 			pathSpan.End()
 			continue
@@ -588,6 +614,8 @@ func (r *EnvoyGatewayExtensionReconciler) buildWasmConfigs(ctx context.Context,
 			continue
 		}
 
+		wasm.WithMetrics(wasmActionSetsForPath, metrics)
+
 		pathSpan.SetAttributes(attribute.Int("actionsets.created", len(wasmActionSetsForPath)))
 		pathSpan.SetStatus(codes.Ok, "")
 		pathSpan.End()
@@ -61,7 +61,7 @@ func (r *EnvoyGatewayTracingClusterReconciler) Reconcile(ctx context.Context, _
 	var gateways []*machinery.Gateway
 
 	// Only build tracing clusters if tracing is configured
-	if kuadrant.Spec.Observability.Tracing != nil && kuadrant.Spec.Observability.Tracing.DefaultEndpoint != "" {
+	if kuadrant.Spec.Observability.IsTracingConfigured() {
 		// Get all envoy gateway gateways that have effective policies or extension policy actions
 		gateways = lo.FilterMap(
 			topology.Targetables().Items(func(o machinery.Object) bool {
@@ -76,7 +76,7 @@ func (r *EnvoyGatewayTracingClusterReconciler) Reconcile(ctx context.Context, _
 					return nil, false
 				}
 				isEnvoyGateway := lo.Contains(envoyGatewayGatewayControllerNames, gatewayClass.(*machinery.GatewayClass).Spec.ControllerName)
-				return gateway, isEnvoyGateway && targetIsInEffectivePolicyPath(gateway, topology, state)
+				return gateway, isEnvoyGateway && gatewayTracing(kuadrant, gateway) != nil && targetIsInEffectivePolicyPath(gateway, topology, state)
 			},
 		)
 	} else {
@@ -226,23 +226,23 @@ func (r *EnvoyGatewayTracingClusterReconciler) buildDesiredEnvoyPatchPolicy(kuad
 		},
 	}
 
-	tracingEndpoint := kuadrant.Spec.Observability.Tracing.DefaultEndpoint
+	tracing := gatewayTracing(kuadrant, gateway)
 
 	// Parse the tracing endpoint to extract host and port
-	host, port, err := parseTracingEndpoint(tracingEndpoint)
+	host, port, err := parseTracingEndpoint(tracing.Endpoint)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse tracing endpoint: %w", err)
 	}
 
 	// Use mTLS unless explicitly set to insecure
-	mTLS := !kuadrant.Spec.Observability.Tracing.Insecure
+	mTLS := !tracing.Insecure
 
 	jsonPatches, err := kuadrantenvoygateway.BuildEnvoyPatchPolicyClusterPatch(
 		wasm.TracingServiceName,
 		host,
 		port,
 		mTLS,
-		tracingClusterPatch,
+		tracingClusterPatch(envoyGatewayTracingTLSTransportSocket(tracing, gateway.GetNamespace())),
 	)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/kuadrant/kuadrant-operator/internal/extension"
+)
+
+// extensionPolicyKinds returns the kinds of the policies reported by extensions,
+// together with the kinds of any affected condition found in conditions that is
+// not a built-in policy kind, so stale conditions of extensions whose policies
+// are gone are cleaned up too.
+func extensionPolicyKinds(reported []extension.ReportedPolicy, conditions ...[]metav1.Condition) []string {
+	builtin := lo.Map(policyGroupKinds(), func(kind *schema.GroupKind, _ int) string { return kind.Kind })
+
+	kinds := lo.Map(reported, func(policy extension.ReportedPolicy, _ int) string { return policy.Policy.Kind })
+	for _, list := range conditions {
+		for _, condition := range list {
+			if kind, ok := policyKindFromAffectedConditionType(condition.Type); ok {
+				kinds = append(kinds, kind)
+			}
+		}
+	}
+
+	kinds = lo.Uniq(lo.Filter(kinds, func(kind string, _ int) bool { return !slices.Contains(builtin, kind) }))
+	slices.Sort(kinds)
+	return kinds
+}
+
+func policyKindFromAffectedConditionType(conditionType string) (string, bool) {
+	prefix, suffix, _ := strings.Cut(PolicyAffectedConditionPattern, "%s")
+	if !strings.HasPrefix(conditionType, prefix) || !strings.HasSuffix(conditionType, suffix) {
+		return "", false
+	}
+	kind := strings.TrimSuffix(strings.TrimPrefix(conditionType, prefix), suffix)
+	return kind, kind != ""
+}
+
+// enforcedExtensionPolicies returns the policies of the given kind reported as
+// enforced on any of the targetables.
+func enforcedExtensionPolicies(reported []extension.ReportedPolicy, kind string, targetables ...machinery.Targetable) []client.ObjectKey {
+	var keys []client.ObjectKey
+	for _, policy := range reported {
+		if policy.Policy.Kind != kind {
+			continue
+		}
+		enforced := lo.ContainsBy(policy.Targets, func(target extension.PolicyTargetStatus) bool {
+			return target.Enforced && lo.ContainsBy(targetables, func(targetable machinery.Targetable) bool {
+				return targetStatusMatches(target, targetable)
+			})
+		})
+		if enforced {
+			keys = append(keys, client.ObjectKey{Namespace: policy.Policy.Namespace, Name: policy.Policy.Name})
+		}
+	}
+	return keys
+}
+
+// targetStatusMatches tells whether the reported target refers to the targetable.
+// Gateways are only matched by refs without a section name, which target a
+// listener instead.
+func targetStatusMatches(target extension.PolicyTargetStatus, targetable machinery.Targetable) bool {
+	ref := target.TargetRef
+	if ref.Group != "" && ref.Group != gatewayapiv1.GroupName {
+		return false
+	}
+
+	switch t := targetable.(type) {
+	case *machinery.Gateway:
+		return ref.Kind == machinery.GatewayGroupKind.Kind && target.SectionName == "" &&
+			ref.Namespace == t.GetNamespace() && ref.Name == t.GetName()
+	case *machinery.Listener:
+		return ref.Kind == machinery.GatewayGroupKind.Kind && target.SectionName == string(t.Name) &&
+			t.Gateway != nil && ref.Namespace == t.Gateway.GetNamespace() && ref.Name == t.Gateway.GetName()
+	default:
+		return ref.Kind == targetable.GroupVersionKind().Kind &&
+			ref.Namespace == targetable.GetNamespace() && ref.Name == targetable.GetName()
+	}
+}
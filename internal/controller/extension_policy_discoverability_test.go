@@ -0,0 +1,145 @@
+//go:build unit
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/kuadrant/policy-machinery/machinery"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/kuadrant/kuadrant-operator/internal/extension"
+)
+
+func reportedPolicy(kind, name string, targets ...extension.PolicyTargetStatus) extension.ReportedPolicy {
+	return extension.ReportedPolicy{
+		Policy:  extension.ResourceID{Kind: kind, Namespace: "default", Name: name},
+		Targets: targets,
+	}
+}
+
+func gatewayTargetStatus(name, sectionName string, enforced bool) extension.PolicyTargetStatus {
+	return extension.PolicyTargetStatus{
+		TargetRef:   extension.TargetRef{Group: gatewayapiv1.GroupName, Kind: "Gateway", Namespace: "default", Name: name},
+		SectionName: sectionName,
+		Enforced:    enforced,
+	}
+}
+
+func discoverabilityTestGateway() (*machinery.Gateway, *machinery.Listener) {
+	gw := &machinery.Gateway{Gateway: &gatewayapiv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-gateway", Namespace: "default", Generation: 2},
+	}}
+	listener := &machinery.Listener{Listener: &gatewayapiv1.Listener{Name: "http"}, Gateway: gw}
+	return gw, listener
+}
+
+func TestExtensionPolicyKinds(t *testing.T) {
+	reported := []extension.ReportedPolicy{
+		reportedPolicy("ThreatPolicy", "a"),
+		reportedPolicy("PlanPolicy", "b"),
+		reportedPolicy("ThreatPolicy", "c"),
+	}
+	conditions := []metav1.Condition{
+		{Type: PolicyAffectedConditionType("OIDCPolicy")},
+		{Type: PolicyAffectedConditionType("AuthPolicy")},
+		{Type: "Accepted"},
+	}
+
+	kinds := extensionPolicyKinds(reported, conditions)
+	expected := []string{"OIDCPolicy", "PlanPolicy", "ThreatPolicy"}
+	if len(kinds) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, kinds)
+	}
+	for i := range expected {
+		if kinds[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, kinds)
+		}
+	}
+}
+
+func TestTargetStatusMatches(t *testing.T) {
+	gw, listener := discoverabilityTestGateway()
+
+	tests := []struct {
+		name       string
+		target     extension.PolicyTargetStatus
+		targetable machinery.Targetable
+		expected   bool
+	}{
+		{"gateway", gatewayTargetStatus("my-gateway", "", true), gw, true},
+		{"other gateway", gatewayTargetStatus("other", "", true), gw, false},
+		{"section does not match gateway", gatewayTargetStatus("my-gateway", "http", true), gw, false},
+		{"listener", gatewayTargetStatus("my-gateway", "http", true), listener, true},
+		{"other listener", gatewayTargetStatus("my-gateway", "https", true), listener, false},
+		{"whole gateway does not match listener", gatewayTargetStatus("my-gateway", "", true), listener, false},
+		{
+			"foreign group",
+			extension.PolicyTargetStatus{TargetRef: extension.TargetRef{Group: "example.com", Kind: "Gateway", Namespace: "default", Name: "my-gateway"}},
+			gw,
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := targetStatusMatches(tt.target, tt.targetable); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestEnforcedExtensionPolicies(t *testing.T) {
+	gw, listener := discoverabilityTestGateway()
+	reported := []extension.ReportedPolicy{
+		reportedPolicy("ThreatPolicy", "on-gateway", gatewayTargetStatus("my-gateway", "", true)),
+		reportedPolicy("ThreatPolicy", "on-listener", gatewayTargetStatus("my-gateway", "http", true)),
+		reportedPolicy("ThreatPolicy", "not-enforced", gatewayTargetStatus("my-gateway", "", false)),
+		reportedPolicy("PlanPolicy", "other-kind", gatewayTargetStatus("my-gateway", "", true)),
+	}
+
+	keys := enforcedExtensionPolicies(reported, "ThreatPolicy", gw)
+	if len(keys) != 1 || keys[0] != (client.ObjectKey{Namespace: "default", Name: "on-gateway"}) {
+		t.Errorf("unexpected policies for gateway: %v", keys)
+	}
+
+	keys = enforcedExtensionPolicies(reported, "ThreatPolicy", gw, listener)
+	if len(keys) != 2 {
+		t.Errorf("expected policies on the gateway and listener, got %v", keys)
+	}
+}
+
+func TestUpdateExtensionPolicyConditions(t *testing.T) {
+	gw, _ := discoverabilityTestGateway()
+	conditions := []metav1.Condition{{
+		Type:   PolicyAffectedConditionType("PlanPolicy"),
+		Status: metav1.ConditionTrue,
+		Reason: string(gatewayapiv1.GatewayConditionAccepted),
+	}}
+	reported := []extension.ReportedPolicy{
+		reportedPolicy("ThreatPolicy", "demo", gatewayTargetStatus("my-gateway", "", true)),
+	}
+
+	updateExtensionPolicyConditions(&conditions, reported, gw.GetGeneration(), logr.Discard(), gw.GetName(), gw)
+
+	condition := meta.FindStatusCondition(conditions, PolicyAffectedConditionType("ThreatPolicy"))
+	if condition == nil {
+		t.Fatal("expected ThreatPolicyAffected condition")
+	}
+	if condition.Status != metav1.ConditionTrue || condition.ObservedGeneration != 2 {
+		t.Errorf("unexpected condition: %+v", condition)
+	}
+	if meta.FindStatusCondition(conditions, PolicyAffectedConditionType("PlanPolicy")) != nil {
+		t.Error("expected stale PlanPolicyAffected condition to be removed")
+	}
+
+	updateExtensionPolicyConditions(&conditions, nil, gw.GetGeneration(), logr.Discard(), gw.GetName(), gw)
+	if len(conditions) != 0 {
+		t.Errorf("expected all extension conditions to be removed, got %v", conditions)
+	}
+}
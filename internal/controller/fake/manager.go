@@ -101,7 +101,7 @@ func (m *manager) GetAPIReader() client.Reader {
 }
 
 func (m *manager) GetEventRecorder(name string) events.EventRecorder {
-	panic("Not Implemented")
+	return &events.FakeRecorder{}
 }
 
 func (m *manager) GetConverterRegistry() conversion.Registry {
@@ -18,6 +18,8 @@ import (
 
 	kuadrantv1 "github.com/kuadrant/kuadrant-operator/api/v1"
 	kuadrantv1alpha1 "github.com/kuadrant/kuadrant-operator/api/v1alpha1"
+	kuadrantv1beta1 "github.com/kuadrant/kuadrant-operator/api/v1beta1"
+	"github.com/kuadrant/kuadrant-operator/internal/extension"
 )
 
 type GatewayPolicyDiscoverabilityReconciler struct {
@@ -37,6 +39,7 @@ func (r *GatewayPolicyDiscoverabilityReconciler) Subscription() *controller.Subs
 			{Kind: &kuadrantv1alpha1.TokenRateLimitPolicyGroupKind},
 			{Kind: &kuadrantv1.TLSPolicyGroupKind},
 			{Kind: &kuadrantv1.DNSPolicyGroupKind},
+			{Kind: &kuadrantv1beta1.KuadrantGroupKind}, // extension policy status changes
 		},
 		ReconcileFunc: r.reconcile,
 	}
@@ -50,9 +53,10 @@ func (r *GatewayPolicyDiscoverabilityReconciler) reconcile(ctx context.Context,
 		return gw, ok
 	})
 	policyKinds := policyGroupKinds()
+	extensionPolicies := extension.GetReportedPolicies()
 
 	for _, gw := range gateways {
-		updatedStatus := buildGatewayStatus(ctx, syncMap, gw, topology, logger, policyKinds)
+		updatedStatus := buildGatewayStatus(ctx, syncMap, gw, topology, logger, policyKinds, extensionPolicies)
 		if !equality.Semantic.DeepEqual(updatedStatus, gw.Status) {
 			gw.Status = *updatedStatus
 			if err := r.updateGatewayStatus(ctx, gw); err != nil {
@@ -77,7 +81,7 @@ func (r *GatewayPolicyDiscoverabilityReconciler) updateGatewayStatus(ctx context
 	return err
 }
 
-func buildGatewayStatus(ctx context.Context, syncMap *sync.Map, gw *machinery.Gateway, topology *machinery.Topology, logger logr.Logger, policyKinds []*schema.GroupKind) *gatewayapiv1.GatewayStatus {
+func buildGatewayStatus(ctx context.Context, syncMap *sync.Map, gw *machinery.Gateway, topology *machinery.Topology, logger logr.Logger, policyKinds []*schema.GroupKind, extensionPolicies []extension.ReportedPolicy) *gatewayapiv1.GatewayStatus {
 	status := gw.Status.DeepCopy()
 
 	listeners := lo.Map(topology.Targetables().Children(gw), func(item machinery.Targetable, _ int) *machinery.Listener {
@@ -87,16 +91,31 @@ func buildGatewayStatus(ctx context.Context, syncMap *sync.Map, gw *machinery.Ga
 
 	for _, listener := range listeners {
 		updatedListenerStatus := updateListenerStatus(ctx, syncMap, gw, listener, logger, policyKinds)
+		updateExtensionPolicyConditions(&updatedListenerStatus.Conditions, extensionPolicies, gw.GetGeneration(), logger, string(listener.Name), gw, listener)
 		status.Listeners = updateListenerList(status.Listeners, updatedListenerStatus)
 	}
 
 	for _, policyKind := range policyKinds {
 		updatePolicyConditions(ctx, syncMap, gw, policyKind, status, logger)
 	}
+	updateExtensionPolicyConditions(&status.Conditions, extensionPolicies, gw.GetGeneration(), logger, gw.GetName(), gw)
 
 	return status
 }
 
+// updateExtensionPolicyConditions sets an affected condition for each kind of
+// extension policy enforced on the targetables, and removes it otherwise.
+func updateExtensionPolicyConditions(conditions *[]metav1.Condition, extensionPolicies []extension.ReportedPolicy, generation int64, logger logr.Logger, name string, targetables ...machinery.Targetable) {
+	for _, kind := range extensionPolicyKinds(extensionPolicies, *conditions) {
+		policies := enforcedExtensionPolicies(extensionPolicies, kind, targetables...)
+		if len(policies) == 0 {
+			removeConditionIfExists(conditions, PolicyAffectedConditionType(kind), logger, name)
+		} else {
+			addOrUpdateCondition(conditions, policyKeysAffectedCondition(kind, policies), generation, logger)
+		}
+	}
+}
+
 func updateListenerStatus(ctx context.Context, syncMap *sync.Map, gw *machinery.Gateway, listener *machinery.Listener, logger logr.Logger, policyKinds []*schema.GroupKind) gatewayapiv1.ListenerStatus {
 	status, _, exists := findListenerStatus(gw.Status.Listeners, listener.Name)
 	if !exists {
@@ -42,7 +42,8 @@ const IstioExtensionReconcilerName = "IstioExtensionReconciler"
 
 // IstioExtensionReconciler reconciles Istio EnvoyFilter custom resources for wasm plugin injection
 type IstioExtensionReconciler struct {
-	client *dynamic.DynamicClient
+	client        *dynamic.DynamicClient
+	eventRecorder *PolicyEventRecorder
 }
 
 // IstioExtensionReconciler subscribes to events with potential impact on the Istio EnvoyFilter custom resources
@@ -89,6 +90,12 @@ func (r *IstioExtensionReconciler) Reconcile(ctx context.Context, _ []controller
 	// Reconcile EnvoyFilter cluster patches for registered upstreams
 	r.reconcileUpstreamClusters(ctx, topology, gateways, errorRegistry)
 
+	kObj := GetKuadrantFromTopology(topology, state)
+	var observabilitySpec *kuadrantv1beta1.Observability
+	if kObj != nil {
+		observabilitySpec = validObservabilitySpec(kObj)
+	}
+
 	// build wasm plugin configs for each gateway
 	wasmConfigs, observability, serviceBuilder, err := r.buildWasmConfigs(ctx, topology, state)
 	if err != nil {
@@ -115,6 +122,10 @@ func (r *IstioExtensionReconciler) Reconcile(ctx context.Context, _ []controller
 			wasmConfig.Observability = observability
 			wasmConfig.Services = lo.Assign(wasmConfig.Services, serviceBuilder.Build())
 		}
+		if observabilitySpec != nil && len(wasmConfig.ActionSets) > 0 {
+			wasmConfig.Observability = wasm.ObservabilityForGateway(wasmConfig.Observability, observabilitySpec, gatewayKey)
+		}
+		emitWasmConfigSizeMetric(gateway, &wasmConfig)
 
 		desiredEnvoyFilter := buildIstioEnvoyFilterForGateway(gateway, wasmConfig, wasmURL, wasmServerHost, wasmServerPort, WasmFileSHA256)
 
@@ -146,6 +157,8 @@ func (r *IstioExtensionReconciler) Reconcile(ctx context.Context, _ []controller
 					kuadrantistio.EnvoyFilterGroupKind,
 					err,
 				)
+			} else {
+				r.eventRecorder.RecordWasmConfigPushed(gateway.Gateway, kuadrantistio.EnvoyFilterGroupKind.Kind, desiredEnvoyFilter.GetName())
 			}
 			continue
 		}
@@ -202,6 +215,8 @@ func (r *IstioExtensionReconciler) Reconcile(ctx context.Context, _ []controller
 				kuadrantistio.EnvoyFilterGroupKind,
 				err,
 			)
+		} else {
+			r.eventRecorder.RecordWasmConfigPushed(gateway.Gateway, kuadrantistio.EnvoyFilterGroupKind.Kind, desiredEnvoyFilter.GetName())
 		}
 	}
 
@@ -409,7 +424,7 @@ func (r *IstioExtensionReconciler) buildWasmConfigs(ctx context.Context, topolog
 	kObj := GetKuadrantFromTopology(topology, state)
 	var observability *wasm.Observability
 	if kObj != nil {
-		observability = wasm.BuildObservabilityConfig(serviceBuilder, &kObj.Spec.Observability)
+		observability = wasm.BuildObservabilityConfig(serviceBuilder, validObservabilitySpec(kObj))
 	}
 
 	effectiveAuthPolicies, ok := state.Load(StateEffectiveAuthPolicies)
@@ -532,7 +547,8 @@ func (r *IstioExtensionReconciler) buildWasmConfigs(ctx context.Context, topolog
 			validatorBuilder.PushPolicyBinding(celvalidator.TokenRateLimitPolicyKind, celvalidator.RateLimitName, cel.AnyType)
 		}
 
-		// Attach extension request bindings to specs based on store-path availability
+		// Attach extension request bindings to specs based on store-path availability.
+		// Bindings and functions of the route take precedence over the ones of the gateway.
 		var routeLocator string
 		switch parsed.RouteType {
 		case kuadrantpolicymachinery.RouteTypeHTTP:
@@ -540,7 +556,14 @@ func (r *IstioExtensionReconciler) buildWasmConfigs(ctx context.Context, topolog
 		case kuadrantpolicymachinery.RouteTypeGRPC:
 			routeLocator = parsed.GRPCRoute.GetLocator()
 		}
-		wasm.AttachBindings(specs, extension.GetRequestBindings([]string{parsed.Gateway.GetLocator(), routeLocator}))
+		requestBindings, metrics, metricsErr := extension.GetRequestBindingsAndMetrics([]string{routeLocator, parsed.Gateway.GetLocator()})
+		if metricsErr != nil {
+			// the TelemetryPolicy rejects its invalid custom metrics, these come from other extensions
+			logger.Error(metricsErr, "ignoring invalid custom metrics", "pathID", pathID)
+		}
+		wasm.AttachBindings(specs, requestBindings)
+		functions := extension.GetFunctionBindings([]string{routeLocator, parsed.Gateway.GetLocator()})
+		celvalidator.AddFunctionBindings(validatorBuilder, functions)
 
 		pathSpan.SetAttributes(attribute.Int("specs.before_merge", len(specs)))
 
@@ -560,7 +583,8 @@ func (r *IstioExtensionReconciler) buildWasmConfigs(ctx context.Context, topolog
 			return nil, nil, nil, fmt.Errorf("failed to merge/verify action specs for path %s: %w", pathID, err)
 		}
 
-		if len(specs) == 0 {
+		// custom metrics are recorded even for the paths without any action
+		if len(specs) == 0 && len(metrics) == 0 {
 			pathSpan.SetStatus(codes.Ok, "no specs after merge")
 			pathSpan.End()
 			continue
@@ -588,7 +612,9 @@ func (r *IstioExtensionReconciler) buildWasmConfigs(ctx context.Context, topolog
 			}
 		}
 
-		builtActions := wasm.BuildActions(validSpecs)
+		// Calls to extension functions become lookups of values fetched by preceding actions
+		validSpecs, functionActions := wasm.BindFunctions(validSpecs, functions)
+		builtActions := append(functionActions, wasm.BuildActions(validSpecs)...)
 
 		pathSpan.SetAttributes(
 			attribute.Int("specs.after_merge", len(specs)),
@@ -596,7 +622,7 @@ func (r *IstioExtensionReconciler) buildWasmConfigs(ctx context.Context, topolog
 			attribute.Int("specs.invalid", invalidCount),
 		)
 
-		if len(builtActions) == 0 {
+		if len(builtActions) == 0 && len(metrics) == 0 {
 			pathSpan.SetStatus(codes.Ok, "no validated actions")
 			pathSpan.End()
 			continue
@@ -617,6 +643,8 @@ func (r *IstioExtensionReconciler) buildWasmConfigs(ctx context.Context, topolog
 			continue
 		}
 
+		wasm.WithMetrics(wasmActionSetsForPath, metrics)
+
 		pathSpan.SetAttributes(attribute.Int("actionsets.created", len(wasmActionSetsForPath)))
 		pathSpan.SetStatus(codes.Ok, "")
 		pathSpan.End()
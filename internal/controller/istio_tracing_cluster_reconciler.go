@@ -60,7 +60,7 @@ func (r *IstioTracingClusterReconciler) Reconcile(ctx context.Context, _ []contr
 	var gateways []*machinery.Gateway
 
 	// Only build tracing clusters if tracing is configured
-	if kuadrant.Spec.Observability.Tracing != nil && kuadrant.Spec.Observability.Tracing.DefaultEndpoint != "" {
+	if kuadrant.Spec.Observability.IsTracingConfigured() {
 		// Get all istio gateways that have effective policies or extension policy actions
 		gateways = lo.FilterMap(
 			topology.Targetables().Items(func(o machinery.Object) bool {
@@ -75,7 +75,7 @@ func (r *IstioTracingClusterReconciler) Reconcile(ctx context.Context, _ []contr
 					return nil, false
 				}
 				isIstioGateway := lo.Contains(istioGatewayControllerNames, gatewayClass.(*machinery.GatewayClass).Spec.ControllerName)
-				return gateway, isIstioGateway && targetIsInEffectivePolicyPath(gateway, topology, state)
+				return gateway, isIstioGateway && gatewayTracing(kuadrant, gateway) != nil && targetIsInEffectivePolicyPath(gateway, topology, state)
 			},
 		)
 	} else {
@@ -223,18 +223,18 @@ func (r *IstioTracingClusterReconciler) buildDesiredEnvoyFilter(kuadrant *kuadra
 		},
 	}
 
-	tracingEndpoint := kuadrant.Spec.Observability.Tracing.DefaultEndpoint
+	tracing := gatewayTracing(kuadrant, gateway)
 
 	// Parse the tracing endpoint to extract host and port
-	host, port, err := parseTracingEndpoint(tracingEndpoint)
+	host, port, err := parseTracingEndpoint(tracing.Endpoint)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse tracing endpoint: %w", err)
 	}
 
 	// Use mTLS unless explicitly set to insecure
-	mTLS := !kuadrant.Spec.Observability.Tracing.Insecure
+	mTLS := !tracing.Insecure
 
-	configPatches, err := kuadrantistio.BuildEnvoyFilterClusterPatch(host, port, mTLS, tracingClusterPatch)
+	configPatches, err := kuadrantistio.BuildEnvoyFilterClusterPatch(host, port, mTLS, tracingClusterPatch(istioTracingTLSTransportSocket(tracing)))
 	if err != nil {
 		return nil, err
 	}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"slices"
 	"sync"
@@ -19,12 +20,14 @@ import (
 
 	kuadrantv1beta1 "github.com/kuadrant/kuadrant-operator/api/v1beta1"
 	"github.com/kuadrant/kuadrant-operator/internal/authorino"
+	"github.com/kuadrant/kuadrant-operator/internal/cel"
 	"github.com/kuadrant/kuadrant-operator/internal/kuadrant"
 	operatormetrics "github.com/kuadrant/kuadrant-operator/internal/metrics"
 )
 
 const (
-	ReadyConditionType string = "Ready"
+	ReadyConditionType       string = "Ready"
+	DecisionLogConditionType string = "DecisionLogValid"
 )
 
 type KuadrantStatusUpdater struct {
@@ -137,9 +140,38 @@ func (r *KuadrantStatusUpdater) calculateStatus(topology *machinery.Topology, lo
 
 	meta.SetStatusCondition(&newStatus.Conditions, *availableCond)
 
+	// the condition is only reported while some decision log field is invalid
+	if decisionLogCond := decisionLogCondition(kObj); decisionLogCond != nil {
+		meta.SetStatusCondition(&newStatus.Conditions, *decisionLogCond)
+	} else {
+		meta.RemoveStatusCondition(&newStatus.Conditions, DecisionLogConditionType)
+	}
+
 	return newStatus
 }
 
+// decisionLogCondition returns a false DecisionLogValid condition listing the decision log fields that are not valid
+// CEL expressions, or nil if all of them are valid
+func decisionLogCondition(kObj *kuadrantv1beta1.Kuadrant) *metav1.Condition {
+	_, errs := cel.ValidateDecisionLogs(&kObj.Spec.Observability)
+	if len(errs) == 0 {
+		return nil
+	}
+	return &metav1.Condition{
+		Type:    DecisionLogConditionType,
+		Status:  metav1.ConditionFalse,
+		Reason:  string(kuadrant.PolicyReasonInvalidCelExpression),
+		Message: fmt.Sprintf("the invalid fields are left out of the decision log: %s", errors.Join(errs...)),
+	}
+}
+
+// validObservabilitySpec returns the Observability spec of the Kuadrant CR without the decision log fields that are
+// not valid CEL expressions, which are reported in the DecisionLogValid condition of the Kuadrant status instead
+func validObservabilitySpec(kObj *kuadrantv1beta1.Kuadrant) *kuadrantv1beta1.Observability {
+	observability, _ := cel.ValidateDecisionLogs(&kObj.Spec.Observability)
+	return observability
+}
+
 func mtlsAuthorino(kObj *kuadrantv1beta1.Kuadrant, state *sync.Map) *bool {
 	effectiveAuthPolicies, ok := state.Load(StateEffectiveAuthPolicies)
 	if !ok {
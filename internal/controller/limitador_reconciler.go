@@ -41,6 +41,7 @@ func (r *LimitadorReconciler) Subscription() *controller.Subscription {
 			{Kind: ptr.To(v1beta1.KuadrantGroupKind), EventType: ptr.To(controller.CreateEvent)},
 			{Kind: ptr.To(v1beta1.KuadrantGroupKind), EventType: ptr.To(controller.UpdateEvent)},
 			{Kind: ptr.To(v1beta1.LimitadorGroupKind)},
+			{Kind: ptr.To(v1beta1.HorizontalPodAutoscalerGroupKind), EventType: ptr.To(controller.DeleteEvent)},
 		},
 	}
 }
@@ -63,6 +64,20 @@ func (r *LimitadorReconciler) Reconcile(ctx context.Context, _ []controller.Reso
 		attribute.String("kuadrant.namespace", kobj.Namespace),
 	)
 
+	component := kobj.Spec.Components.LimitadorComponent()
+
+	var autoscaling *v1beta1.ComponentAutoscaling
+	if component != nil {
+		autoscaling = component.Autoscaling
+	}
+	autoscaler := GetComponentAutoscalerFromTopology(topology, kobj.Namespace, limitadorDeploymentName)
+	if err := reconcileComponentAutoscaler(ctx, r.Client, kobj, limitadorDeploymentName, autoscaling, autoscaler); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to reconcile limitador autoscaler")
+		logger.Error(err, "failed to reconcile limitador autoscaler", "status", "error")
+		return err
+	}
+
 	// Build the desired Limitador spec
 	desiredLimitador := &limitadorv1alpha1.Limitador{
 		TypeMeta: metav1.TypeMeta{
@@ -94,6 +109,8 @@ func (r *LimitadorReconciler) Reconcile(ctx context.Context, _ []controller.Reso
 		}
 	}
 
+	applyLimitadorComponent(&desiredLimitador.Spec, component)
+
 	unstructuredLimitador, err := controller.Destruct(desiredLimitador)
 	if err != nil {
 		span.RecordError(err)
@@ -129,7 +146,7 @@ func (r *LimitadorReconciler) Reconcile(ctx context.Context, _ []controller.Reso
 				}
 			}
 
-			// MetricLabelsDefault must always to reconciled to our set value
+			// MetricLabelsDefault and the fields set in the Kuadrant CR components must always be reconciled to our set values
 			_, err = r.Client.Resource(v1beta1.LimitadorsResource).Namespace(kobj.Namespace).Apply(
 				ctx,
 				unstructuredLimitador.GetName(),
@@ -165,3 +182,21 @@ func (r *LimitadorReconciler) Reconcile(ctx context.Context, _ []controller.Reso
 
 	return nil
 }
+
+// applyLimitadorComponent merges the Limitador configuration of the Kuadrant CR into the desired Limitador spec.
+// Only the fields set are applied, so the fields left unset remain free to be managed on the Limitador CR.
+// The replicas are left unset when autoscaling, as the limitador operator does not reconcile them then, and the
+// resources set request CPU, which the limitador operator only requests by default when no resources are set.
+func applyLimitadorComponent(spec *limitadorv1alpha1.LimitadorSpec, component *v1beta1.LimitadorComponent) {
+	if component == nil {
+		return
+	}
+	if component.Replicas != nil {
+		spec.Replicas = ptr.To(int(*component.Replicas))
+	}
+	spec.ResourceRequirements = component.Resources
+	if component.Autoscaling != nil && component.Resources != nil {
+		spec.ResourceRequirements = autoscaledResources(component.Resources)
+	}
+	spec.Storage = component.Storage
+}
@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"testing"
+
+	limitadorv1alpha1 "github.com/kuadrant/limitador-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+
+	"github.com/kuadrant/kuadrant-operator/api/v1beta1"
+)
+
+func TestApplyLimitadorComponent(t *testing.T) {
+	t.Run("no component leaves the spec untouched", func(t *testing.T) {
+		spec := limitadorv1alpha1.LimitadorSpec{MetricLabelsDefault: ptr.To("descriptors[1]")}
+		applyLimitadorComponent(&spec, nil)
+		if spec.Replicas != nil || spec.ResourceRequirements != nil || spec.Storage != nil {
+			t.Errorf("expected no component fields, got %+v", spec)
+		}
+	})
+
+	t.Run("only the fields set are merged into the spec", func(t *testing.T) {
+		spec := limitadorv1alpha1.LimitadorSpec{MetricLabelsDefault: ptr.To("descriptors[1]")}
+		applyLimitadorComponent(&spec, &v1beta1.LimitadorComponent{Replicas: ptr.To(int32(2))})
+		if spec.Replicas == nil || *spec.Replicas != 2 {
+			t.Errorf("expected 2 replicas, got %v", spec.Replicas)
+		}
+		if spec.ResourceRequirements != nil || spec.Storage != nil {
+			t.Errorf("expected the fields left unset not to be applied, got %+v", spec)
+		}
+		if ptr.Deref(spec.MetricLabelsDefault, "") != "descriptors[1]" {
+			t.Error("expected the other fields to be untouched")
+		}
+	})
+
+	t.Run("resources and storage are merged into the spec", func(t *testing.T) {
+		resources := &corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+		}
+		storage := &limitadorv1alpha1.Storage{
+			Redis: &limitadorv1alpha1.Redis{ConfigSecretRef: &corev1.LocalObjectReference{Name: "redis-config"}},
+		}
+		spec := limitadorv1alpha1.LimitadorSpec{}
+		applyLimitadorComponent(&spec, &v1beta1.LimitadorComponent{Resources: resources, Storage: storage})
+		if spec.Replicas != nil {
+			t.Errorf("expected no replicas, got %v", *spec.Replicas)
+		}
+		if spec.ResourceRequirements != resources || spec.Storage != storage {
+			t.Errorf("expected the resources and storage of the component, got %+v", spec)
+		}
+	})
+	t.Run("replicas are left to the autoscaler", func(t *testing.T) {
+		spec := limitadorv1alpha1.LimitadorSpec{}
+		applyLimitadorComponent(&spec, &v1beta1.LimitadorComponent{Autoscaling: &v1beta1.ComponentAutoscaling{MaxReplicas: 3}})
+		if spec.Replicas != nil {
+			t.Errorf("expected no replicas, got %v", *spec.Replicas)
+		}
+		if spec.ResourceRequirements != nil {
+			t.Errorf("expected the default resources of the limitador operator, got %+v", spec.ResourceRequirements)
+		}
+	})
+
+	t.Run("autoscaled resources request cpu", func(t *testing.T) {
+		spec := limitadorv1alpha1.LimitadorSpec{}
+		applyLimitadorComponent(&spec, &v1beta1.LimitadorComponent{
+			Autoscaling: &v1beta1.ComponentAutoscaling{MaxReplicas: 3},
+			Resources:   &corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("64Mi")}},
+		})
+		if cpu := spec.ResourceRequirements.Requests[corev1.ResourceCPU]; cpu.String() != "250m" {
+			t.Errorf("expected a cpu request of 250m, got %s", cpu.String())
+		}
+		if memory := spec.ResourceRequirements.Requests[corev1.ResourceMemory]; memory.String() != "64Mi" {
+			t.Errorf("expected the memory request to be kept, got %s", memory.String())
+		}
+	})
+}
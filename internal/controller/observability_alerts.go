@@ -0,0 +1,140 @@
+package controllers
+
+import (
+	"fmt"
+	"reflect"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+
+	kuadrantv1beta1 "github.com/kuadrant/kuadrant-operator/api/v1beta1"
+	"github.com/kuadrant/kuadrant-operator/internal/kuadrant"
+	"github.com/kuadrant/kuadrant-operator/internal/utils"
+)
+
+const (
+	defaultPolicyNotEnforcedFor    = "10m"
+	defaultAuthDenialRateThreshold = 10
+)
+
+// kuadrantAlertsName returns the name of the PrometheusRule with the alerts of a Kuadrant instance
+func kuadrantAlertsName(kObj *kuadrantv1beta1.Kuadrant) string {
+	return fmt.Sprintf("%s-alerts", kObj.GetName())
+}
+
+// kuadrantAlertsBuild builds the PrometheusRule with the alerts of a Kuadrant instance.
+// The PrometheusRule is tagged to be deleted when observability or the alerts are disabled.
+func kuadrantAlertsBuild(kObj *kuadrantv1beta1.Kuadrant) *monitoringv1.PrometheusRule {
+	alerts := ptr.Deref(kObj.Spec.Observability.Alerts, kuadrantv1beta1.Alerts{})
+
+	policyNotEnforcedFor := monitoringv1.Duration(lo.CoalesceOrEmpty(alerts.PolicyNotEnforcedFor, defaultPolicyNotEnforcedFor))
+	authDenialRateThreshold := lo.CoalesceOrEmpty(alerts.AuthDenialRateThreshold, defaultAuthDenialRateThreshold)
+	ns := kObj.GetNamespace()
+
+	rule := func(alert, severity, expr string, forDuration monitoringv1.Duration, summary, description string) monitoringv1.Rule {
+		return monitoringv1.Rule{
+			Alert:  alert,
+			Expr:   intstr.FromString(expr),
+			For:    ptr.To(forDuration),
+			Labels: lo.Assign(map[string]string{"severity": severity}, alerts.Labels),
+			Annotations: map[string]string{
+				"summary":     summary,
+				"description": description,
+			},
+		}
+	}
+
+	prometheusRule := &monitoringv1.PrometheusRule{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       monitoringv1.PrometheusRuleKind,
+			APIVersion: monitoringv1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      kuadrantAlertsName(kObj),
+			Namespace: ns,
+			Labels: map[string]string{
+				kuadrant.ObservabilityLabel: "true",
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         kObj.GroupVersionKind().GroupVersion().String(),
+					Kind:               kObj.GroupVersionKind().Kind,
+					Name:               kObj.Name,
+					UID:                kObj.UID,
+					BlockOwnerDeletion: ptr.To(true),
+					Controller:         ptr.To(true),
+				},
+			},
+		},
+		Spec: monitoringv1.PrometheusRuleSpec{
+			Groups: []monitoringv1.RuleGroup{
+				{
+					Name: "kuadrant-policies",
+					Rules: []monitoringv1.Rule{
+						rule("KuadrantPolicyNotEnforced", "warning",
+							`sum by (kind) (kuadrant_policies_enforced{status="false"}) > 0`,
+							policyNotEnforcedFor,
+							"Kuadrant policies are not enforced",
+							fmt.Sprintf("{{ $value }} {{ $labels.kind }}(s) have not been enforced for more than %s.", policyNotEnforcedFor),
+						),
+						rule("KuadrantReconcileErrors", "warning",
+							`sum by (subscriber, operation) (increase(kuadrant_reconcile_errors_total[10m])) > 0`,
+							"10m",
+							"The Kuadrant operator fails to reconcile resources",
+							"{{ $labels.subscriber }} failed to {{ $labels.operation }} resources {{ $value }} times in the last 10 minutes.",
+						),
+					},
+				},
+				{
+					Name: "kuadrant-components",
+					Rules: []monitoringv1.Rule{
+						rule("KuadrantLimitadorUnavailable", "critical",
+							fmt.Sprintf(`kuadrant_component_ready{component="limitador",namespace=%q} == 0`, ns),
+							"5m",
+							"Limitador is unavailable",
+							fmt.Sprintf("Limitador in namespace %s has not been ready for more than 5 minutes. Rate limits are not enforced.", ns),
+						),
+						rule("KuadrantAuthorinoUnavailable", "critical",
+							fmt.Sprintf(`kuadrant_component_ready{component="authorino",namespace=%q} == 0`, ns),
+							"5m",
+							"Authorino is unavailable",
+							fmt.Sprintf("Authorino in namespace %s has not been ready for more than 5 minutes. Auth policies are not enforced.", ns),
+						),
+						rule("KuadrantAuthDenialRateSpike", "warning",
+							fmt.Sprintf(`sum(rate(auth_server_response_status{namespace=%q,status=~"PERMISSION_DENIED|UNAUTHENTICATED"}[5m])) > %d`, ns, authDenialRateThreshold),
+							"5m",
+							"Spike of requests denied by AuthPolicies",
+							fmt.Sprintf("Authorino in namespace %s is denying {{ $value | humanize }} requests per second, above the threshold of %d.", ns, authDenialRateThreshold),
+						),
+					},
+				},
+			},
+		},
+	}
+
+	if !kObj.Spec.Observability.Enable || alerts.Disable {
+		utils.TagObjectToDelete(prometheusRule)
+	}
+
+	return prometheusRule
+}
+
+// prometheusRuleMutator reverts changes to the labels and rules of the Kuadrant alerts
+func prometheusRuleMutator(desired, existing *monitoringv1.PrometheusRule) bool {
+	update := false
+
+	if !reflect.DeepEqual(existing.GetLabels(), desired.GetLabels()) {
+		existing.SetLabels(desired.GetLabels())
+		update = true
+	}
+
+	if !reflect.DeepEqual(existing.Spec, desired.Spec) {
+		existing.Spec = desired.Spec
+		update = true
+	}
+
+	return update
+}
@@ -0,0 +1,142 @@
+//go:build unit
+
+package controllers
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	kuadrantv1beta1 "github.com/kuadrant/kuadrant-operator/api/v1beta1"
+	"github.com/kuadrant/kuadrant-operator/internal/utils"
+)
+
+func observabilityTestKuadrant(observability kuadrantv1beta1.Observability) *kuadrantv1beta1.Kuadrant {
+	return &kuadrantv1beta1.Kuadrant{
+		TypeMeta:   metav1.TypeMeta{Kind: "Kuadrant", APIVersion: kuadrantv1beta1.GroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: "kuadrant", Namespace: "kuadrant-system", UID: "kuadrant-uid"},
+		Spec:       kuadrantv1beta1.KuadrantSpec{Observability: observability},
+	}
+}
+
+func findAlert(prometheusRule *monitoringv1.PrometheusRule, name string) *monitoringv1.Rule {
+	for _, group := range prometheusRule.Spec.Groups {
+		for i := range group.Rules {
+			if group.Rules[i].Alert == name {
+				return &group.Rules[i]
+			}
+		}
+	}
+	return nil
+}
+
+func TestKuadrantAlertsBuild(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		prometheusRule := kuadrantAlertsBuild(observabilityTestKuadrant(kuadrantv1beta1.Observability{Enable: true}))
+
+		if prometheusRule.GetName() != "kuadrant-alerts" || prometheusRule.GetNamespace() != "kuadrant-system" {
+			t.Errorf("unexpected prometheusrule %s/%s", prometheusRule.GetNamespace(), prometheusRule.GetName())
+		}
+		if utils.IsObjectTaggedToDelete(prometheusRule) {
+			t.Error("expected the prometheusrule not to be tagged to delete")
+		}
+		if owners := prometheusRule.GetOwnerReferences(); len(owners) != 1 || owners[0].UID != "kuadrant-uid" {
+			t.Errorf("expected the prometheusrule to be owned by the kuadrant instance, got %v", owners)
+		}
+
+		for _, name := range []string{"KuadrantPolicyNotEnforced", "KuadrantReconcileErrors", "KuadrantLimitadorUnavailable", "KuadrantAuthorinoUnavailable", "KuadrantAuthDenialRateSpike"} {
+			if findAlert(prometheusRule, name) == nil {
+				t.Errorf("missing alert %s", name)
+			}
+		}
+		if notEnforced := findAlert(prometheusRule, "KuadrantPolicyNotEnforced"); ptr.Deref(notEnforced.For, "") != "10m" {
+			t.Errorf("expected the policy not enforced alert to fire after 10m, got %v", notEnforced.For)
+		}
+		if limitador := findAlert(prometheusRule, "KuadrantLimitadorUnavailable"); !strings.Contains(limitador.Expr.String(), `namespace="kuadrant-system"`) {
+			t.Errorf("expected the limitador alert to be scoped to the kuadrant namespace, got %s", limitador.Expr.String())
+		}
+		if denials := findAlert(prometheusRule, "KuadrantAuthDenialRateSpike"); !strings.HasSuffix(denials.Expr.String(), "> 10") {
+			t.Errorf("expected the default auth denial rate threshold, got %s", denials.Expr.String())
+		}
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		prometheusRule := kuadrantAlertsBuild(observabilityTestKuadrant(kuadrantv1beta1.Observability{
+			Enable: true,
+			Alerts: &kuadrantv1beta1.Alerts{
+				PolicyNotEnforcedFor:    "30m",
+				AuthDenialRateThreshold: 50,
+				Labels:                  map[string]string{"team": "platform"},
+			},
+		}))
+
+		notEnforced := findAlert(prometheusRule, "KuadrantPolicyNotEnforced")
+		if ptr.Deref(notEnforced.For, "") != "30m" {
+			t.Errorf("expected the policy not enforced alert to fire after 30m, got %v", notEnforced.For)
+		}
+		if notEnforced.Labels["team"] != "platform" || notEnforced.Labels["severity"] != "warning" {
+			t.Errorf("unexpected labels %v", notEnforced.Labels)
+		}
+		if denials := findAlert(prometheusRule, "KuadrantAuthDenialRateSpike"); !strings.HasSuffix(denials.Expr.String(), "> 50") {
+			t.Errorf("expected the configured auth denial rate threshold, got %s", denials.Expr.String())
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		if !utils.IsObjectTaggedToDelete(kuadrantAlertsBuild(observabilityTestKuadrant(kuadrantv1beta1.Observability{Enable: false}))) {
+			t.Error("expected the prometheusrule to be deleted when observability is disabled")
+		}
+		if !utils.IsObjectTaggedToDelete(kuadrantAlertsBuild(observabilityTestKuadrant(kuadrantv1beta1.Observability{Enable: true, Alerts: &kuadrantv1beta1.Alerts{Disable: true}}))) {
+			t.Error("expected the prometheusrule to be deleted when alerts are disabled")
+		}
+	})
+}
+
+func TestKuadrantDashboardsBuild(t *testing.T) {
+	configMaps, err := kuadrantDashboardsBuild(observabilityTestKuadrant(kuadrantv1beta1.Observability{Enable: true}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(configMaps) != 2 {
+		t.Fatalf("expected 2 dashboards, got %d", len(configMaps))
+	}
+
+	configMap := configMaps[0]
+	if configMap.GetName() != "kuadrant-dashboard-policies" || configMap.GetLabels()["grafana_dashboard"] != "1" {
+		t.Errorf("unexpected dashboard configmap %s with labels %v", configMap.GetName(), configMap.GetLabels())
+	}
+	var model struct {
+		UID    string `json:"uid"`
+		Panels []struct {
+			Targets []struct {
+				Expr string `json:"expr"`
+			} `json:"targets"`
+		} `json:"panels"`
+	}
+	if err := json.Unmarshal([]byte(configMap.Data["kuadrant-policies.json"]), &model); err != nil {
+		t.Fatalf("invalid dashboard json: %v", err)
+	}
+	if len(model.UID) > 40 || len(model.Panels) == 0 || model.Panels[0].Targets[0].Expr == "" {
+		t.Errorf("unexpected dashboard %+v", model)
+	}
+
+	configMaps, err = kuadrantDashboardsBuild(observabilityTestKuadrant(kuadrantv1beta1.Observability{
+		Enable:     true,
+		Dashboards: &kuadrantv1beta1.Dashboards{Disable: true, Labels: map[string]string{"dashboards": "kuadrant"}},
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, configMap := range configMaps {
+		if !utils.IsObjectTaggedToDelete(configMap) {
+			t.Errorf("expected dashboard %s to be deleted when dashboards are disabled", configMap.GetName())
+		}
+		if _, found := configMap.GetLabels()["grafana_dashboard"]; found || configMap.GetLabels()["dashboards"] != "kuadrant" {
+			t.Errorf("expected the configured labels to replace the defaults, got %v", configMap.GetLabels())
+		}
+	}
+}
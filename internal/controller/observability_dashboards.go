@@ -0,0 +1,189 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kuadrantv1beta1 "github.com/kuadrant/kuadrant-operator/api/v1beta1"
+	"github.com/kuadrant/kuadrant-operator/internal/kuadrant"
+	"github.com/kuadrant/kuadrant-operator/internal/utils"
+)
+
+// defaultDashboardLabels match the default label selector of the Grafana dashboards sidecar
+var defaultDashboardLabels = map[string]string{"grafana_dashboard": "1"}
+
+// dashboardPanel is a timeseries panel of a generated Grafana dashboard
+type dashboardPanel struct {
+	title  string
+	unit   string
+	expr   string
+	legend string
+}
+
+// dashboard is a Grafana dashboard generated for a Kuadrant instance
+type dashboard struct {
+	name   string
+	title  string
+	panels []dashboardPanel
+}
+
+// kuadrantDashboards returns the Grafana dashboards of a Kuadrant instance
+func kuadrantDashboards(kObj *kuadrantv1beta1.Kuadrant) []dashboard {
+	ns := kObj.GetNamespace()
+	return []dashboard{
+		{
+			name:  "policies",
+			title: "Kuadrant policies",
+			panels: []dashboardPanel{
+				{title: "Policies", expr: `sum by (kind) (kuadrant_policies_total)`, legend: "{{kind}}"},
+				{title: "Policies not enforced", expr: `sum by (kind) (kuadrant_policies_enforced{status="false"})`, legend: "{{kind}}"},
+				{title: "Time to enforce policy changes (p95)", unit: "s", expr: `histogram_quantile(0.95, sum by (kind, le) (rate(kuadrant_policy_enforcement_duration_seconds_bucket[5m])))`, legend: "{{kind}}"},
+				{title: "Reconcile errors", unit: "ops", expr: `sum by (subscriber, operation) (rate(kuadrant_reconcile_errors_total[5m]))`, legend: "{{subscriber}} {{operation}}"},
+				{title: "Wasm config size", unit: "bytes", expr: `kuadrant_wasm_config_size_bytes`, legend: "{{gateway_namespace}}/{{gateway_name}}"},
+			},
+		},
+		{
+			name:  "data-plane",
+			title: "Kuadrant data plane",
+			panels: []dashboardPanel{
+				{title: "Components ready", expr: fmt.Sprintf(`kuadrant_component_ready{namespace=%q}`, ns), legend: "{{component}}"},
+				{title: "Auth responses", unit: "reqps", expr: fmt.Sprintf(`sum by (status) (rate(auth_server_response_status{namespace=%q}[5m]))`, ns), legend: "{{status}}"},
+				{title: "Authorized calls", unit: "reqps", expr: fmt.Sprintf(`sum by (limitador_namespace) (rate(authorized_calls{namespace=%q}[5m]))`, ns), legend: "{{limitador_namespace}}"},
+				{title: "Limited calls", unit: "reqps", expr: fmt.Sprintf(`sum by (limitador_namespace) (rate(limited_calls{namespace=%q}[5m]))`, ns), legend: "{{limitador_namespace}}"},
+			},
+		},
+	}
+}
+
+// dashboardJSON renders a dashboard in the Grafana dashboard JSON model, with panels laid out in two columns
+func (d dashboard) dashboardJSON(kObj *kuadrantv1beta1.Kuadrant) (string, error) {
+	datasource := map[string]any{"type": "prometheus", "uid": "${datasource}"}
+	// grafana dashboard uids are limited to 40 characters
+	instanceHash := fmt.Sprintf("%x", sha256.Sum256([]byte(client.ObjectKeyFromObject(kObj).String())))
+
+	panels := make([]map[string]any, 0, len(d.panels))
+	for i, panel := range d.panels {
+		panels = append(panels, map[string]any{
+			"id":         i + 1,
+			"type":       "timeseries",
+			"title":      panel.title,
+			"datasource": datasource,
+			"gridPos":    map[string]int{"h": 8, "w": 12, "x": (i % 2) * 12, "y": (i / 2) * 8},
+			"fieldConfig": map[string]any{
+				"defaults":  map[string]any{"unit": lo.CoalesceOrEmpty(panel.unit, "short")},
+				"overrides": []any{},
+			},
+			"targets": []map[string]any{{
+				"datasource":   datasource,
+				"expr":         panel.expr,
+				"legendFormat": panel.legend,
+				"refId":        "A",
+			}},
+		})
+	}
+
+	model := map[string]any{
+		"uid":           fmt.Sprintf("kuadrant-%s-%s", instanceHash[:8], d.name),
+		"title":         fmt.Sprintf("%s (%s/%s)", d.title, kObj.GetNamespace(), kObj.GetName()),
+		"tags":          []string{"kuadrant"},
+		"editable":      true,
+		"schemaVersion": 39,
+		"refresh":       "30s",
+		"time":          map[string]string{"from": "now-6h", "to": "now"},
+		"templating": map[string]any{
+			"list": []map[string]any{{
+				"name":  "datasource",
+				"label": "Data source",
+				"type":  "datasource",
+				"query": "prometheus",
+			}},
+		},
+		"panels": panels,
+	}
+
+	dashboardJSON, err := json.MarshalIndent(model, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(dashboardJSON), nil
+}
+
+// kuadrantDashboardsBuild builds a ConfigMap per Grafana dashboard of a Kuadrant instance.
+// The ConfigMaps are tagged to be deleted when observability or the dashboards are disabled.
+func kuadrantDashboardsBuild(kObj *kuadrantv1beta1.Kuadrant) ([]*corev1.ConfigMap, error) {
+	dashboards := ptr.Deref(kObj.Spec.Observability.Dashboards, kuadrantv1beta1.Dashboards{})
+
+	labels := defaultDashboardLabels
+	if len(dashboards.Labels) > 0 {
+		labels = dashboards.Labels
+	}
+
+	var configMaps []*corev1.ConfigMap
+	for _, d := range kuadrantDashboards(kObj) {
+		dashboardJSON, err := d.dashboardJSON(kObj)
+		if err != nil {
+			return nil, err
+		}
+
+		configMap := &corev1.ConfigMap{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "ConfigMap",
+				APIVersion: corev1.SchemeGroupVersion.String(),
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("%s-dashboard-%s", kObj.GetName(), d.name),
+				Namespace: kObj.GetNamespace(),
+				Labels:    map[string]string{kuadrant.ObservabilityLabel: "true"},
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						APIVersion:         kObj.GroupVersionKind().GroupVersion().String(),
+						Kind:               kObj.GroupVersionKind().Kind,
+						Name:               kObj.Name,
+						UID:                kObj.UID,
+						BlockOwnerDeletion: ptr.To(true),
+						Controller:         ptr.To(true),
+					},
+				},
+			},
+			Data: map[string]string{
+				fmt.Sprintf("kuadrant-%s.json", d.name): dashboardJSON,
+			},
+		}
+		for key, value := range labels {
+			configMap.Labels[key] = value
+		}
+
+		if !kObj.Spec.Observability.Enable || dashboards.Disable {
+			utils.TagObjectToDelete(configMap)
+		}
+
+		configMaps = append(configMaps, configMap)
+	}
+
+	return configMaps, nil
+}
+
+// dashboardConfigMapMutator reverts changes to the labels and dashboards of the Kuadrant dashboard ConfigMaps
+func dashboardConfigMapMutator(desired, existing *corev1.ConfigMap) bool {
+	update := false
+
+	if !reflect.DeepEqual(existing.GetLabels(), desired.GetLabels()) {
+		existing.SetLabels(desired.GetLabels())
+		update = true
+	}
+
+	if !reflect.DeepEqual(existing.Data, desired.Data) {
+		existing.Data = desired.Data
+		update = true
+	}
+
+	return update
+}
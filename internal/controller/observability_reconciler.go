@@ -10,6 +10,7 @@ import (
 	"github.com/kuadrant/policy-machinery/machinery"
 	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/dynamic"
@@ -21,9 +22,10 @@ import (
 	"github.com/kuadrant/kuadrant-operator/internal/kuadrant"
 	"github.com/kuadrant/kuadrant-operator/internal/observability"
 	"github.com/kuadrant/kuadrant-operator/internal/reconcilers"
+	"github.com/kuadrant/kuadrant-operator/internal/utils"
 )
 
-//+kubebuilder:rbac:groups=monitoring.coreos.com,resources=servicemonitors;podmonitors,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=monitoring.coreos.com,resources=servicemonitors;podmonitors;prometheusrules,verbs=get;list;watch;create;update;patch;delete
 
 const (
 	authOpMonitorName           = "authorino-operator-monitor"
@@ -412,6 +414,10 @@ func (r *ObservabilityReconciler) Reconcile(baseCtx context.Context, _ []control
 	if kObj == nil || !kObj.Spec.Observability.Enable {
 		logger.V(1).Info("deleting any existing monitors", "kuadrant", kObj != nil)
 		r.deleteAllMonitors(ctx, monitorObjs, logger)
+		// alerts and dashboards of deleted kuadrant instances are garbage collected by their owner reference
+		if kObj != nil {
+			return r.reconcileAlertsAndDashboards(ctx, kObj, logger)
+		}
 		return nil
 	}
 
@@ -458,6 +464,11 @@ func (r *ObservabilityReconciler) Reconcile(baseCtx context.Context, _ []control
 		return err
 	}
 
+	// Alerts and dashboards
+	if err := r.reconcileAlertsAndDashboards(ctx, kObj, logger); err != nil {
+		return err
+	}
+
 	// Create monitors for each gateway instance of each gateway class
 	gatewayClasses := topology.Targetables().Items(func(o machinery.Object) bool {
 		return o.GroupVersionKind().GroupKind() == machinery.GatewayClassGroupKind
@@ -545,6 +556,37 @@ func (r *ObservabilityReconciler) createPodMonitor(ctx context.Context, monitor
 	return nil
 }
 
+// reconcileAlertsAndDashboards reconciles the PrometheusRule with the Kuadrant alerts and the Grafana dashboard
+// ConfigMaps of a Kuadrant instance. Unlike monitors, these are kept in sync with the Kuadrant CR.
+func (r *ObservabilityReconciler) reconcileAlertsAndDashboards(ctx context.Context, kObj *kuadrantv1beta1.Kuadrant, logger logr.Logger) error {
+	isPrometheusRuleInstalled, err := utils.IsCRDInstalled(r.restMapper, monitoringv1.SchemeGroupVersion.Group, monitoringv1.PrometheusRuleKind, monitoringv1.SchemeGroupVersion.Version)
+	if err != nil {
+		return err
+	}
+	if isPrometheusRuleInstalled {
+		alerts := kuadrantAlertsBuild(kObj)
+		if _, err := r.ReconcileResource(ctx, &monitoringv1.PrometheusRule{}, alerts, reconcilers.Mutator[*monitoringv1.PrometheusRule](prometheusRuleMutator)); err != nil {
+			logger.Error(err, "reconciling prometheus rule", "key", client.ObjectKeyFromObject(alerts))
+			return err
+		}
+	} else {
+		logger.V(1).Info("prometheus operator PrometheusRule CRD not found, skipping alerts")
+	}
+
+	dashboards, err := kuadrantDashboardsBuild(kObj)
+	if err != nil {
+		return err
+	}
+	for _, dashboard := range dashboards {
+		if _, err := r.ReconcileResource(ctx, &corev1.ConfigMap{}, dashboard, reconcilers.Mutator[*corev1.ConfigMap](dashboardConfigMapMutator)); err != nil {
+			logger.Error(err, "reconciling dashboard configmap", "key", client.ObjectKeyFromObject(dashboard))
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (r *ObservabilityReconciler) deleteAllMonitors(ctx context.Context, monitorObjs []machinery.Object, logger logr.Logger) {
 	for _, monitor := range monitorObjs {
 		logger.V(1).Info(fmt.Sprintf("deleting monitor %s %s/%s", monitor.GroupVersionKind().Kind, monitor.GetNamespace(), monitor.GetName()))
@@ -0,0 +1,161 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	"github.com/kuadrant/kuadrant-operator/internal/kuadrant"
+)
+
+const (
+	// PolicyEventRecorderName is the source of the Kubernetes Events emitted by the operator
+	PolicyEventRecorderName = "kuadrant-operator"
+
+	// EventReasonWasmConfigPushed is the reason of the events emitted when the wasm config of a gateway is pushed
+	EventReasonWasmConfigPushed = "WasmConfigPushed"
+
+	// EventActionUpdateStatus is the action of the events emitted on transitions of the conditions of a policy
+	EventActionUpdateStatus = "UpdateStatus"
+	// EventActionPushWasmConfig is the action of the events emitted when the wasm config of a gateway is pushed
+	EventActionPushWasmConfig = "PushWasmConfig"
+
+	// eventDedupWindow is the period during which an event identical to one already emitted for an object is dropped
+	eventDedupWindow = 10 * time.Minute
+	// eventRateLimit is the rate at which events are refilled for each object, once its burst is exhausted
+	eventRateLimit = rate.Limit(1.0 / 30) // one every 30 seconds
+	eventBurst     = 5
+)
+
+// policyEventConditionTypes are the conditions of the policies whose transitions are emitted as events
+var policyEventConditionTypes = []string{
+	string(gatewayapiv1alpha2.PolicyConditionAccepted),
+	string(kuadrant.PolicyConditionEnforced),
+}
+
+// PolicyEventRecorder emits Kubernetes Events on lifecycle transitions of the policies and the gateways they target.
+// Events identical to one emitted for the same object within the dedup window are dropped, and the events of each
+// object are rate limited, so flapping conditions do not flood the API server.
+// A nil PolicyEventRecorder emits no events.
+type PolicyEventRecorder struct {
+	recorder events.EventRecorder
+	now      func() time.Time
+
+	mu        sync.Mutex
+	emitted   map[string]time.Time
+	limiters  map[string]*rate.Limiter
+	lastPrune time.Time
+}
+
+func NewPolicyEventRecorder(recorder events.EventRecorder) *PolicyEventRecorder {
+	return &PolicyEventRecorder{
+		recorder: recorder,
+		now:      time.Now,
+		emitted:  make(map[string]time.Time),
+		limiters: make(map[string]*rate.Limiter),
+	}
+}
+
+// RecordConditionTransitions emits an event for each Accepted or Enforced condition of a policy whose status or reason
+// changed, e.g. a policy that lost its target or got overridden by another policy.
+// Events of conditions with status True are of type Normal; otherwise, of type Warning.
+func (r *PolicyEventRecorder) RecordConditionTransitions(policy client.Object, previous, current []metav1.Condition) {
+	if r == nil {
+		return
+	}
+	for _, conditionType := range policyEventConditionTypes {
+		condition := meta.FindStatusCondition(current, conditionType)
+		if condition == nil {
+			continue
+		}
+		if previousCondition := meta.FindStatusCondition(previous, conditionType); previousCondition != nil && previousCondition.Status == condition.Status && previousCondition.Reason == condition.Reason {
+			continue
+		}
+		eventType := corev1.EventTypeWarning
+		if condition.Status == metav1.ConditionTrue {
+			eventType = corev1.EventTypeNormal
+		}
+		r.record(policy, eventType, condition.Reason, EventActionUpdateStatus, condition.Message)
+	}
+}
+
+// RecordWasmConfigPushed emits an event for a gateway whose wasm config was pushed to the data plane in the given
+// resource, e.g. an EnvoyFilter or EnvoyExtensionPolicy
+func (r *PolicyEventRecorder) RecordWasmConfigPushed(gateway client.Object, kind, name string) {
+	if r == nil {
+		return
+	}
+	r.record(gateway, corev1.EventTypeNormal, EventReasonWasmConfigPushed, EventActionPushWasmConfig, fmt.Sprintf("Wasm config pushed to the gateway via %s %s", kind, name))
+}
+
+func (r *PolicyEventRecorder) record(obj client.Object, eventType, reason, action, note string) {
+	objectKey := string(obj.GetUID())
+	if objectKey == "" {
+		objectKey = client.ObjectKeyFromObject(obj).String()
+	}
+	eventKey := fmt.Sprintf("%s/%s/%s/%s", objectKey, eventType, reason, note)
+
+	r.mu.Lock()
+	now := r.now()
+	r.prune(now)
+	if emittedAt, found := r.emitted[eventKey]; found && now.Sub(emittedAt) < eventDedupWindow {
+		r.mu.Unlock()
+		return
+	}
+	limiter, found := r.limiters[objectKey]
+	if !found {
+		limiter = rate.NewLimiter(eventRateLimit, eventBurst)
+		r.limiters[objectKey] = limiter
+	}
+	if !limiter.AllowN(now, 1) {
+		r.mu.Unlock()
+		return
+	}
+	r.emitted[eventKey] = now
+	r.mu.Unlock()
+
+	r.recorder.Eventf(obj, nil, eventType, reason, action, "%s", note)
+}
+
+// prune forgets the events out of the dedup window and the objects whose events are not rate limited anymore.
+// Must be called with the lock held.
+func (r *PolicyEventRecorder) prune(now time.Time) {
+	if now.Sub(r.lastPrune) < eventDedupWindow {
+		return
+	}
+	r.lastPrune = now
+	for key, emittedAt := range r.emitted {
+		if now.Sub(emittedAt) >= eventDedupWindow {
+			delete(r.emitted, key)
+		}
+	}
+	for key, limiter := range r.limiters {
+		if limiter.TokensAt(now) >= eventBurst {
+			delete(r.limiters, key)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+//go:build unit
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	kuadrantv1 "github.com/kuadrant/kuadrant-operator/api/v1"
+	"github.com/kuadrant/kuadrant-operator/internal/kuadrant"
+)
+
+func testPolicyEventRecorder(now *time.Time) (*PolicyEventRecorder, *events.FakeRecorder) {
+	fakeRecorder := events.NewFakeRecorder(100)
+	recorder := NewPolicyEventRecorder(fakeRecorder)
+	recorder.now = func() time.Time { return *now }
+	return recorder, fakeRecorder
+}
+
+func receivedEvents(fakeRecorder *events.FakeRecorder) []string {
+	var received []string
+	for {
+		select {
+		case event := <-fakeRecorder.Events:
+			received = append(received, event)
+		default:
+			return received
+		}
+	}
+}
+
+func TestPolicyEventRecorderConditionTransitions(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	recorder, fakeRecorder := testPolicyEventRecorder(&now)
+	policy := &kuadrantv1.AuthPolicy{ObjectMeta: metav1.ObjectMeta{Name: "auth", Namespace: "default", UID: k8stypes.UID("auth-uid")}}
+
+	accepted := metav1.Condition{Type: string(gatewayapiv1alpha2.PolicyConditionAccepted), Status: metav1.ConditionTrue, Reason: string(gatewayapiv1alpha2.PolicyReasonAccepted), Message: "AuthPolicy has been accepted"}
+	enforced := metav1.Condition{Type: string(kuadrant.PolicyConditionEnforced), Status: metav1.ConditionTrue, Reason: string(kuadrant.PolicyReasonEnforced), Message: "AuthPolicy has been successfully enforced"}
+	overridden := metav1.Condition{Type: string(kuadrant.PolicyConditionEnforced), Status: metav1.ConditionFalse, Reason: string(kuadrant.PolicyReasonOverridden), Message: "AuthPolicy is overridden by [default/other]"}
+	targetLost := metav1.Condition{Type: string(gatewayapiv1alpha2.PolicyConditionAccepted), Status: metav1.ConditionFalse, Reason: string(gatewayapiv1alpha2.PolicyReasonTargetNotFound), Message: "AuthPolicy target route was not found"}
+
+	testCases := []struct {
+		name     string
+		previous []metav1.Condition
+		current  []metav1.Condition
+		expected []string
+	}{
+		{
+			name:     "new policy accepted and enforced",
+			current:  []metav1.Condition{accepted, enforced},
+			expected: []string{"Normal Accepted AuthPolicy has been accepted", "Normal Enforced AuthPolicy has been successfully enforced"},
+		},
+		{
+			name:     "unchanged conditions",
+			previous: []metav1.Condition{accepted, enforced},
+			current:  []metav1.Condition{accepted, enforced},
+		},
+		{
+			name:     "overridden by another policy",
+			previous: []metav1.Condition{accepted, enforced},
+			current:  []metav1.Condition{accepted, overridden},
+			expected: []string{"Warning Overridden AuthPolicy is overridden by [default/other]"},
+		},
+		{
+			name:     "target lost",
+			previous: []metav1.Condition{accepted, overridden},
+			current:  []metav1.Condition{targetLost},
+			expected: []string{"Warning TargetNotFound AuthPolicy target route was not found"},
+		},
+		{
+			name:     "identical event within the dedup window",
+			previous: []metav1.Condition{accepted, enforced},
+			current:  []metav1.Condition{accepted, overridden},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(subT *testing.T) {
+			recorder.RecordConditionTransitions(policy, tc.previous, tc.current)
+			received := receivedEvents(fakeRecorder)
+			if len(received) != len(tc.expected) {
+				subT.Fatalf("expected events %v, got %v", tc.expected, received)
+			}
+			for i := range tc.expected {
+				if received[i] != tc.expected[i] {
+					subT.Errorf("expected event %q, got %q", tc.expected[i], received[i])
+				}
+			}
+		})
+	}
+
+	// identical events are emitted again once out of the dedup window
+	now = now.Add(eventDedupWindow)
+	recorder.RecordConditionTransitions(policy, []metav1.Condition{accepted, enforced}, []metav1.Condition{accepted, overridden})
+	if received := receivedEvents(fakeRecorder); len(received) != 1 {
+		t.Errorf("expected the event to be emitted out of the dedup window, got %v", received)
+	}
+}
+
+func TestPolicyEventRecorderRateLimit(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	recorder, fakeRecorder := testPolicyEventRecorder(&now)
+	gateway := &gatewayapiv1.Gateway{ObjectMeta: metav1.ObjectMeta{Name: "gateway", Namespace: "default", UID: k8stypes.UID("gateway-uid")}}
+	other := &gatewayapiv1.Gateway{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default", UID: k8stypes.UID("other-uid")}}
+
+	for i := 0; i < eventBurst+3; i++ {
+		recorder.RecordWasmConfigPushed(gateway, "EnvoyFilter", string(rune('a'+i)))
+	}
+	if received := receivedEvents(fakeRecorder); len(received) != eventBurst {
+		t.Fatalf("expected %d events within the burst, got %d", eventBurst, len(received))
+	}
+
+	// events of other objects are not rate limited
+	recorder.RecordWasmConfigPushed(other, "EnvoyFilter", "kuadrant-other")
+	if received := receivedEvents(fakeRecorder); len(received) != 1 || received[0] != "Normal WasmConfigPushed Wasm config pushed to the gateway via EnvoyFilter kuadrant-other" {
+		t.Fatalf("expected the event of the other gateway, got %v", received)
+	}
+
+	// the limit refills over time
+	now = now.Add(30 * time.Second)
+	recorder.RecordWasmConfigPushed(gateway, "EnvoyFilter", "z")
+	if received := receivedEvents(fakeRecorder); len(received) != 1 {
+		t.Errorf("expected an event after the limit refilled, got %v", received)
+	}
+}
+
+func TestPolicyEventRecorderNil(_ *testing.T) {
+	var recorder *PolicyEventRecorder
+	recorder.RecordConditionTransitions(&kuadrantv1.AuthPolicy{}, nil, []metav1.Condition{{Type: string(kuadrant.PolicyConditionEnforced), Status: metav1.ConditionTrue}})
+	recorder.RecordWasmConfigPushed(&gatewayapiv1.Gateway{}, "EnvoyFilter", "kuadrant-gateway")
+}
@@ -19,40 +19,77 @@ package controllers
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	k8stypes "k8s.io/apimachinery/pkg/types"
 
 	"github.com/kuadrant/policy-machinery/controller"
 	"github.com/kuadrant/policy-machinery/machinery"
 
 	kuadrantgatewayapi "github.com/kuadrant/kuadrant-operator/internal/gatewayapi"
 	"github.com/kuadrant/kuadrant-operator/internal/kuadrant"
+	operatormetrics "github.com/kuadrant/kuadrant-operator/internal/metrics"
+	"github.com/kuadrant/kuadrant-operator/internal/wasm"
 )
 
 const (
-	policyKindLabel   = "kind"
-	policyStatusLabel = "status"
+	policyKindLabel       = "kind"
+	policyStatusLabel     = "status"
+	subscriberLabel       = "subscriber"
+	operationLabel        = "operation"
+	gatewayNameLabel      = "gateway_name"
+	gatewayNamespaceLabel = "gateway_namespace"
+	reconcilerLabel       = "reconciler"
+	resultLabel           = "result"
+
+	reconcileResultSuccess = "success"
+	reconcileResultError   = "error"
 )
 
 var (
 	// policiesTotal tracks the total number of policies by kind
-	policiesTotal = prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "kuadrant_policies_total",
-			Help: "Total number of Kuadrant policies by kind",
-		},
-		[]string{policyKindLabel})
+	policiesTotal = operatormetrics.NewGaugeVec(
+		"kuadrant_policies_total",
+		"Total number of Kuadrant policies by kind",
+		policyKindLabel)
 
 	// policiesEnforced tracks the enforcement status of policies
-	policiesEnforced = prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "kuadrant_policies_enforced",
-			Help: "Number of Kuadrant policies by kind and enforcement status",
-		},
-		[]string{policyKindLabel, policyStatusLabel})
+	policiesEnforced = operatormetrics.NewGaugeVec(
+		"kuadrant_policies_enforced",
+		"Number of Kuadrant policies by kind and enforcement status",
+		policyKindLabel, policyStatusLabel)
+
+	// policyEnforcementDuration tracks the time from a change of generation of a policy to the policy being enforced
+	policyEnforcementDuration = operatormetrics.NewHistogramVec(
+		"kuadrant_policy_enforcement_duration_seconds",
+		"Time from a change of generation of a Kuadrant policy to the policy being enforced, by kind",
+		prometheus.ExponentialBuckets(0.25, 2, 14),
+		policyKindLabel)
+
+	// reconcileErrorsTotal counts the non-blocking errors recorded by the reconcilers
+	reconcileErrorsTotal = operatormetrics.NewCounterVec(
+		"kuadrant_reconcile_errors_total",
+		"Total number of non-blocking reconciliation errors by subscriber and operation",
+		subscriberLabel, operationLabel)
+
+	// reconcileDuration tracks the duration of the traced reconcile functions, with exemplars linking to their spans
+	reconcileDuration = operatormetrics.NewHistogramVec(
+		"kuadrant_reconcile_duration_seconds",
+		"Duration of the reconcile functions of the Kuadrant operator, by reconciler and result",
+		prometheus.DefBuckets,
+		reconcilerLabel, resultLabel)
+
+	// wasmConfigSize tracks the size of the wasm config of each gateway
+	wasmConfigSize = operatormetrics.NewGaugeVec(
+		"kuadrant_wasm_config_size_bytes",
+		"Size in bytes of the wasm plugin config of a gateway",
+		gatewayNameLabel, gatewayNamespaceLabel)
+
+	// wasmConfigSizeGateways holds the gateways with a wasm config size series, so the series of deleted gateways can be removed
+	wasmConfigSizeGateways sync.Map
 )
 
 // PolicyStatus represents the enforcement status of a policy
@@ -63,12 +100,26 @@ const (
 	PolicyStatusFalse PolicyStatus = "false"
 )
 
-// PolicyMetricsReconciler emits Prometheus metrics for all Kuadrant policies
-type PolicyMetricsReconciler struct{}
+// policyGeneration tracks when the operator first saw a generation of a policy and whether its enforcement was observed
+type policyGeneration struct {
+	generation int64
+	since      time.Time
+	observed   bool
+}
+
+// PolicyMetricsReconciler emits metrics for all Kuadrant policies
+type PolicyMetricsReconciler struct {
+	mu          sync.Mutex
+	generations map[k8stypes.UID]*policyGeneration
+	now         func() time.Time
+}
 
 // NewPolicyMetricsReconciler creates a new PolicyMetricsReconciler
 func NewPolicyMetricsReconciler() *PolicyMetricsReconciler {
-	return &PolicyMetricsReconciler{}
+	return &PolicyMetricsReconciler{
+		generations: make(map[k8stypes.UID]*policyGeneration),
+		now:         time.Now,
+	}
 }
 
 // Reconcile collects and emits metrics for all policies in the topology.
@@ -95,6 +146,9 @@ func (r *PolicyMetricsReconciler) Reconcile(ctx context.Context, _ []controller.
 		r.emitMetricsForPolicies(kind, policies)
 	}
 
+	r.observeEnforcementDurations(ctx, topology.Policies().Items())
+	pruneWasmConfigSizeMetrics(topology)
+
 	logger.V(1).Info("policy metrics updated", "policyKinds", len(policiesByKind))
 	return nil
 }
@@ -102,7 +156,7 @@ func (r *PolicyMetricsReconciler) Reconcile(ctx context.Context, _ []controller.
 // emitMetricsForPolicies emits metrics for a list of policies of a given kind
 func (r *PolicyMetricsReconciler) emitMetricsForPolicies(kind string, policies []machinery.Policy) {
 	total := len(policies)
-	policiesTotal.WithLabelValues(kind).Set(float64(total))
+	policiesTotal.Set(float64(total), kind)
 
 	// Track enforcement status counts
 	enforcedCounts := map[PolicyStatus]int{
@@ -117,7 +171,7 @@ func (r *PolicyMetricsReconciler) emitMetricsForPolicies(kind string, policies [
 
 	// Emit enforcement metrics
 	for status, count := range enforcedCounts {
-		policiesEnforced.WithLabelValues(kind, string(status)).Set(float64(count))
+		policiesEnforced.Set(float64(count), kind, string(status))
 	}
 }
 
@@ -141,7 +195,106 @@ func (r *PolicyMetricsReconciler) getEnforcementStatus(policy machinery.Policy)
 	return PolicyStatusTrue
 }
 
-func init() {
-	// Register metrics with controller-runtime's Prometheus registry
-	metrics.Registry.MustRegister(policiesTotal, policiesEnforced)
+// isGenerationEnforced tells whether the current generation of the policy is enforced, i.e. whether its Enforced
+// condition is true and was set for that generation rather than for a previous one
+func isGenerationEnforced(p kuadrantgatewayapi.Policy) bool {
+	condition := meta.FindStatusCondition(p.GetStatus().GetConditions(), string(kuadrant.PolicyConditionEnforced))
+	return condition != nil && condition.Status == metav1.ConditionTrue && condition.ObservedGeneration == p.GetGeneration()
+}
+
+// observeEnforcementDurations observes the time it took for each new generation of a policy to be enforced.
+// A generation is timed from the creation of the policy for the first generation, or from the first time the
+// operator saw the generation otherwise. Policies already enforced when first seen (e.g. after a restart of the
+// operator) are not observed.
+func (r *PolicyMetricsReconciler) observeEnforcementDurations(ctx context.Context, policies []machinery.Policy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	seen := make(map[k8stypes.UID]struct{}, len(policies))
+
+	for _, policy := range policies {
+		p, ok := policy.(kuadrantgatewayapi.Policy)
+		if !ok {
+			continue
+		}
+		uid := p.GetUID()
+		seen[uid] = struct{}{}
+		enforced := isGenerationEnforced(p)
+
+		tracked, found := r.generations[uid]
+		if !found || tracked.generation != p.GetGeneration() {
+			since := now
+			if created := p.GetCreationTimestamp(); p.GetGeneration() == 1 && !created.IsZero() {
+				since = created.Time
+			}
+			tracked = &policyGeneration{
+				generation: p.GetGeneration(),
+				since:      since,
+				observed:   !found && enforced,
+			}
+			r.generations[uid] = tracked
+		}
+
+		if tracked.observed || !enforced {
+			continue
+		}
+		policyEnforcementDuration.Observe(ctx, now.Sub(tracked.since).Seconds(), policy.GroupVersionKind().Kind)
+		tracked.observed = true
+	}
+
+	for uid := range r.generations {
+		if _, found := seen[uid]; !found {
+			delete(r.generations, uid)
+		}
+	}
+}
+
+// recordReconcileErrorMetric counts a non-blocking error recorded by a reconciler
+func recordReconcileErrorMetric(subscriber, operation string) {
+	reconcileErrorsTotal.Inc(context.Background(), subscriber, operation)
+}
+
+// instrumentReconcileFunc observes the duration of a reconcile function. Wrapped by the tracing of the reconcile
+// function, the observations record exemplars linking to the span of the reconciliation.
+func instrumentReconcileFunc(name string, reconcileFunc controller.ReconcileFunc) controller.ReconcileFunc {
+	return func(ctx context.Context, resourceEvents []controller.ResourceEvent, topology *machinery.Topology, err error, state *sync.Map) error {
+		start := time.Now()
+		reconcileErr := reconcileFunc(ctx, resourceEvents, topology, err, state)
+		result := reconcileResultSuccess
+		if reconcileErr != nil {
+			result = reconcileResultError
+		}
+		reconcileDuration.Observe(ctx, time.Since(start).Seconds(), name, result)
+		return reconcileErr
+	}
+}
+
+// emitWasmConfigSizeMetric sets the size of the wasm config of a gateway
+func emitWasmConfigSizeMetric(gateway *machinery.Gateway, config *wasm.Config) {
+	configJSON, err := config.ToJSON()
+	if err != nil {
+		return
+	}
+	wasmConfigSize.Set(float64(len(configJSON.Raw)), gateway.GetName(), gateway.GetNamespace())
+	wasmConfigSizeGateways.Store(k8stypes.NamespacedName{Namespace: gateway.GetNamespace(), Name: gateway.GetName()}, struct{}{})
+}
+
+// pruneWasmConfigSizeMetrics removes the wasm config size series of the gateways that no longer exist
+func pruneWasmConfigSizeMetrics(topology *machinery.Topology) {
+	gateways := make(map[k8stypes.NamespacedName]struct{})
+	for _, gateway := range topology.Targetables().Items(func(o machinery.Object) bool {
+		return o.GroupVersionKind().GroupKind() == machinery.GatewayGroupKind
+	}) {
+		gateways[k8stypes.NamespacedName{Namespace: gateway.GetNamespace(), Name: gateway.GetName()}] = struct{}{}
+	}
+
+	wasmConfigSizeGateways.Range(func(key, _ any) bool {
+		gatewayKey := key.(k8stypes.NamespacedName)
+		if _, found := gateways[gatewayKey]; !found {
+			wasmConfigSize.Delete(gatewayKey.Name, gatewayKey.Namespace)
+			wasmConfigSizeGateways.Delete(key)
+		}
+		return true
+	})
 }
@@ -17,9 +17,21 @@ limitations under the License.
 package controllers
 
 import (
+	"context"
+	"errors"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/kuadrant/policy-machinery/controller"
+	"github.com/kuadrant/policy-machinery/machinery"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/trace"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
 	gatewayapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 
 	kuadrantv1 "github.com/kuadrant/kuadrant-operator/api/v1"
@@ -130,3 +142,169 @@ func TestMetricLabels(t *testing.T) {
 		t.Errorf("expected policyStatusLabel to be 'status', got %s", policyStatusLabel)
 	}
 }
+
+var (
+	metricsTestReader     = sdkmetric.NewManualReader()
+	metricsTestReaderOnce sync.Once
+)
+
+// collectTestMetric returns the metric with the given name emitted through the global meter provider.
+// Instruments created before the meter provider is set are delegated to it.
+func collectTestMetric(t *testing.T, name string) metricdata.Metrics {
+	t.Helper()
+	metricsTestReaderOnce.Do(func() {
+		otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(metricsTestReader)))
+	})
+	rm := metricdata.ResourceMetrics{}
+	if err := metricsTestReader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("failed to collect metrics: %v", err)
+	}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == name {
+				return m
+			}
+		}
+	}
+	return metricdata.Metrics{}
+}
+
+// collectTestHistogram returns the series of the histogram with the given name and attributes
+func collectTestHistogram(t *testing.T, name string, attrs ...attribute.KeyValue) metricdata.HistogramDataPoint[float64] {
+	t.Helper()
+	set := attribute.NewSet(attrs...)
+	if data, ok := collectTestMetric(t, name).Data.(metricdata.Histogram[float64]); ok {
+		for _, point := range data.DataPoints {
+			if point.Attributes.Equals(&set) {
+				return point
+			}
+		}
+	}
+	return metricdata.HistogramDataPoint[float64]{}
+}
+
+// collectTestCounter returns the value of the series of the counter with the given name and attributes
+func collectTestCounter(t *testing.T, name string, attrs ...attribute.KeyValue) float64 {
+	t.Helper()
+	set := attribute.NewSet(attrs...)
+	if data, ok := collectTestMetric(t, name).Data.(metricdata.Sum[float64]); ok {
+		for _, point := range data.DataPoints {
+			if point.Attributes.Equals(&set) {
+				return point.Value
+			}
+		}
+	}
+	return 0
+}
+
+func TestObserveEnforcementDurations(t *testing.T) {
+	ctx := context.Background()
+	kindAttr := attribute.String(policyKindLabel, kuadrantv1.AuthPolicyGroupKind.Kind)
+	// series are cumulative, so observations are counted from the ones recorded before the test
+	baseline := collectTestHistogram(t, "kuadrant_policy_enforcement_duration_seconds", kindAttr)
+	observations := func() (uint64, float64) {
+		point := collectTestHistogram(t, "kuadrant_policy_enforcement_duration_seconds", kindAttr)
+		return point.Count - baseline.Count, point.Sum - baseline.Sum
+	}
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	reconciler := NewPolicyMetricsReconciler()
+	reconciler.now = func() time.Time { return now }
+
+	enforced := func(generation int64) []metav1.Condition {
+		return []metav1.Condition{{Type: string(kuadrant.PolicyConditionEnforced), Status: metav1.ConditionTrue, Reason: string(kuadrant.PolicyReasonEnforced), ObservedGeneration: generation}}
+	}
+	policy := &kuadrantv1.AuthPolicy{
+		TypeMeta: metav1.TypeMeta{Kind: kuadrantv1.AuthPolicyGroupKind.Kind, APIVersion: kuadrantv1.GroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "auth",
+			Namespace:         "default",
+			UID:               k8stypes.UID("auth-uid"),
+			Generation:        1,
+			CreationTimestamp: metav1.NewTime(now.Add(-3 * time.Second)),
+		},
+	}
+	// new policy not enforced yet
+	reconciler.observeEnforcementDurations(ctx, []machinery.Policy{policy})
+	if count, _ := observations(); count != 0 {
+		t.Fatalf("expected no observation before enforcement, got %d", count)
+	}
+
+	// first generation enforced, timed from creation
+	policy.Status.Conditions = enforced(1)
+	reconciler.observeEnforcementDurations(ctx, []machinery.Policy{policy})
+	if count, sum := observations(); count != 1 || sum != 3 {
+		t.Fatalf("expected one observation of 3s, got %d observations summing %fs", count, sum)
+	}
+
+	// same generation is only observed once
+	reconciler.observeEnforcementDurations(ctx, []machinery.Policy{policy})
+	if count, _ := observations(); count != 1 {
+		t.Fatalf("expected a single observation per generation, got %d", count)
+	}
+
+	// new generation, timed from when it was first seen, while still holding the condition of the previous one
+	policy.Generation = 2
+	reconciler.observeEnforcementDurations(ctx, []machinery.Policy{policy})
+	if count, _ := observations(); count != 1 {
+		t.Fatalf("expected the condition of the previous generation not to count, got %d observations", count)
+	}
+	now = now.Add(5 * time.Second)
+	policy.Status.Conditions = enforced(2)
+	reconciler.observeEnforcementDurations(ctx, []machinery.Policy{policy})
+	if count, sum := observations(); count != 2 || sum != 8 {
+		t.Fatalf("expected two observations summing 8s, got %d observations summing %fs", count, sum)
+	}
+
+	// deleted policies are forgotten
+	reconciler.observeEnforcementDurations(ctx, nil)
+	if len(reconciler.generations) != 0 {
+		t.Errorf("expected deleted policies to be forgotten, got %v", reconciler.generations)
+	}
+
+	// policies already enforced when first seen are not observed
+	reconciler.observeEnforcementDurations(ctx, []machinery.Policy{policy})
+	if count, _ := observations(); count != 2 {
+		t.Errorf("expected no observation for a policy already enforced, got %d", count)
+	}
+}
+
+func TestRecordReconcileErrorMetric(t *testing.T) {
+	attrs := []attribute.KeyValue{attribute.String(subscriberLabel, EffectivePolicyReconcilerName), attribute.String(operationLabel, OperationCreate)}
+	baseline := collectTestCounter(t, "kuadrant_reconcile_errors_total", attrs...)
+
+	registry := NewErrorRegistry()
+	registry.Record(EffectivePolicyReconcilerName, OperationCreate, k8stypes.NamespacedName{Namespace: "default", Name: "a"}, kuadrantv1.AuthPolicyGroupKind, errors.New("boom"))
+	registry.Record(EffectivePolicyReconcilerName, OperationCreate, k8stypes.NamespacedName{Namespace: "default", Name: "b"}, kuadrantv1.AuthPolicyGroupKind, errors.New("boom"))
+
+	if errs := collectTestCounter(t, "kuadrant_reconcile_errors_total", attrs...) - baseline; errs != 2 {
+		t.Errorf("expected 2 errors, got %f", errs)
+	}
+}
+
+func TestInstrumentReconcileFunc(t *testing.T) {
+	reconcileErr := errors.New("boom")
+	reconcileFunc := func(_ context.Context, _ []controller.ResourceEvent, _ *machinery.Topology, _ error, _ *sync.Map) error {
+		return reconcileErr
+	}
+	attrs := []attribute.KeyValue{attribute.String(reconcilerLabel, "test"), attribute.String(resultLabel, reconcileResultError)}
+	baseline := collectTestHistogram(t, "kuadrant_reconcile_duration_seconds", attrs...)
+
+	traceID := trace.TraceID{0x01}
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     trace.SpanID{0x02},
+		TraceFlags: trace.FlagsSampled,
+	}))
+	if err := instrumentReconcileFunc("test", reconcileFunc)(ctx, nil, nil, nil, &sync.Map{}); !errors.Is(err, reconcileErr) {
+		t.Fatalf("expected the error of the reconcile function, got %v", err)
+	}
+
+	point := collectTestHistogram(t, "kuadrant_reconcile_duration_seconds", attrs...)
+	if point.Count-baseline.Count != 1 {
+		t.Fatalf("expected one observation of the failed reconciliation, got %d", point.Count-baseline.Count)
+	}
+	if len(point.Exemplars) == 0 || trace.TraceID(point.Exemplars[len(point.Exemplars)-1].TraceID) != traceID {
+		t.Errorf("expected an exemplar with the trace id of the span, got %v", point.Exemplars)
+	}
+}
@@ -39,7 +39,8 @@ import (
 const RateLimitPolicyStatusUpdaterName = "RateLimitPolicyStatusUpdater"
 
 type RateLimitPolicyStatusUpdater struct {
-	client *dynamic.DynamicClient
+	client        *dynamic.DynamicClient
+	eventRecorder *PolicyEventRecorder
 }
 
 // RateLimitPolicyStatusUpdater subscribe to events with potential impact on the status of RateLimitPolicy resources
@@ -120,6 +121,7 @@ func (r *RateLimitPolicyStatusUpdater) UpdateStatus(ctx context.Context, _ []con
 			continue
 		}
 		newStatus.ObservedGeneration = policy.Generation
+		previousConditions := policy.Status.Conditions
 		policy.Status = *newStatus
 
 		obj, err := controller.Destruct(policy)
@@ -156,6 +158,8 @@ func (r *RateLimitPolicyStatusUpdater) UpdateStatus(ctx context.Context, _ []con
 			continue
 		}
 
+		r.eventRecorder.RecordConditionTransitions(policy, previousConditions, policy.Status.Conditions)
+
 		span.AddEvent("policy status updated successfully")
 		span.SetStatus(codes.Ok, "")
 		span.End()
@@ -111,6 +111,7 @@ func (r *ErrorRegistry) Record(source, operation string, resource k8stypes.Names
 	}
 
 	r.errors[rec.Key()] = rec
+	recordReconcileErrorMetric(source, operation)
 }
 
 // GetErrors returns all recorded errors
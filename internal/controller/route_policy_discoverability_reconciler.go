@@ -21,6 +21,8 @@ import (
 
 	kuadrantv1 "github.com/kuadrant/kuadrant-operator/api/v1"
 	kuadrantv1alpha1 "github.com/kuadrant/kuadrant-operator/api/v1alpha1"
+	kuadrantv1beta1 "github.com/kuadrant/kuadrant-operator/api/v1beta1"
+	"github.com/kuadrant/kuadrant-operator/internal/extension"
 	"github.com/kuadrant/kuadrant-operator/internal/kuadrant"
 	"github.com/kuadrant/kuadrant-operator/internal/utils"
 )
@@ -55,6 +57,7 @@ func (r *RoutePolicyDiscoverabilityReconciler) Subscription() *controller.Subscr
 			{Kind: &kuadrantv1alpha1.TokenRateLimitPolicyGroupKind},
 			{Kind: &kuadrantv1.TLSPolicyGroupKind},
 			{Kind: &kuadrantv1.DNSPolicyGroupKind},
+			{Kind: &kuadrantv1beta1.KuadrantGroupKind}, // extension policy status changes
 		},
 		ReconcileFunc: r.reconcile,
 	}
@@ -65,25 +68,39 @@ func (r *RoutePolicyDiscoverabilityReconciler) reconcile(ctx context.Context, _
 
 	routes := extractDiscoverableRoutes(topology)
 	policyKinds := policyGroupKinds()
+	extensionPolicies := extension.GetReportedPolicies()
 
 	for _, route := range routes {
 		routeStatusParents := deepCopyParents(route.getParents())
 
-		for _, policyKind := range policyKinds {
-			path := getRoutePath(topology, route.targetable)
-			gateways := lo.FilterMap(path, func(item machinery.Targetable, _ int) (*machinery.Gateway, bool) {
-				ob, ok := item.(*machinery.Gateway)
-				return ob, ok
-			})
+		path := getRoutePath(topology, route.targetable)
+		gateways := lo.FilterMap(path, func(item machinery.Targetable, _ int) (*machinery.Gateway, bool) {
+			ob, ok := item.(*machinery.Gateway)
+			return ob, ok
+		})
 
+		for _, policyKind := range policyKinds {
 			policies := kuadrantv1.PoliciesInPath(path, func(policy machinery.Policy) bool {
 				return policy.GroupVersionKind().GroupKind() == *policyKind && IsPolicyAccepted(ctx, policy, s)
 			})
 
 			if len(policies) == 0 {
-				routeStatusParents = removePolicyConditions(routeStatusParents, gateways, policyKind, route.targetable, logger)
+				routeStatusParents = removePolicyConditions(routeStatusParents, gateways, policyKind.Kind, route.targetable, logger)
+			} else {
+				routeStatusParents = addPolicyConditions(routeStatusParents, gateways, PolicyAffectedCondition(policyKind.Kind, policies), route, logger)
+			}
+		}
+
+		ownConditions := lo.FilterMap(routeStatusParents, func(parent gatewayapiv1.RouteParentStatus, _ int) ([]metav1.Condition, bool) {
+			return parent.Conditions, parent.ControllerName == kuadrant.ControllerName
+		})
+		for _, kind := range extensionPolicyKinds(extensionPolicies, ownConditions...) {
+			policies := enforcedExtensionPolicies(extensionPolicies, kind, path...)
+
+			if len(policies) == 0 {
+				routeStatusParents = removePolicyConditions(routeStatusParents, gateways, kind, route.targetable, logger)
 			} else {
-				routeStatusParents = addPolicyConditions(routeStatusParents, gateways, policies, policyKind, route, logger)
+				routeStatusParents = addPolicyConditions(routeStatusParents, gateways, policyKeysAffectedCondition(kind, policies), route, logger)
 			}
 		}
 
@@ -158,8 +175,8 @@ func getRoutePath(topology *machinery.Topology, route machinery.Targetable) []ma
 	})
 }
 
-func removePolicyConditions(routeStatusParents []gatewayapiv1.RouteParentStatus, gateways []*machinery.Gateway, policyKind *schema.GroupKind, route machinery.Targetable, logger logr.Logger) []gatewayapiv1.RouteParentStatus {
-	conditionType := PolicyAffectedConditionType(policyKind.Kind)
+func removePolicyConditions(routeStatusParents []gatewayapiv1.RouteParentStatus, gateways []*machinery.Gateway, policyKind string, route machinery.Targetable, logger logr.Logger) []gatewayapiv1.RouteParentStatus {
+	conditionType := PolicyAffectedConditionType(policyKind)
 	for _, gw := range gateways {
 		i := utils.Index(routeStatusParents, FindRouteParentStatusFunc(route.GetNamespace(), client.ObjectKey{Namespace: gw.GetNamespace(), Name: gw.GetName()}, kuadrant.ControllerName))
 		if i < 0 {
@@ -179,8 +196,7 @@ func removePolicyConditions(routeStatusParents []gatewayapiv1.RouteParentStatus,
 	return routeStatusParents
 }
 
-func addPolicyConditions(routeStatusParents []gatewayapiv1.RouteParentStatus, gateways []*machinery.Gateway, policies []machinery.Policy, policyKind *schema.GroupKind, route discoverableRoute, logger logr.Logger) []gatewayapiv1.RouteParentStatus {
-	condition := PolicyAffectedCondition(policyKind.Kind, policies)
+func addPolicyConditions(routeStatusParents []gatewayapiv1.RouteParentStatus, gateways []*machinery.Gateway, condition metav1.Condition, route discoverableRoute, logger logr.Logger) []gatewayapiv1.RouteParentStatus {
 	for _, gw := range gateways {
 		i := ensureRouteParentStatus(&routeStatusParents, route.targetable, gw)
 		if currentCondition := meta.FindStatusCondition(routeStatusParents[i].Conditions, condition.Type); currentCondition != nil &&
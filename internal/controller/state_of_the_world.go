@@ -27,6 +27,7 @@ import (
 	"go.opentelemetry.io/otel/trace"
 	istioclientnetworkingv1alpha3 "istio.io/client-go/pkg/apis/networking/v1alpha3"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -85,6 +86,7 @@ var (
 //+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=coordination.k8s.io,resources=configmaps;leases,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=create;patch
 //+kubebuilder:rbac:groups="",resources=leases,verbs=get;list;watch;create;update;patch;delete
 
 func NewPolicyMachineryController(manager ctrlruntime.Manager, client *dynamic.DynamicClient, logger logr.Logger, opts ...controller.ControllerOption) (*KuadrantController, error) {
@@ -132,6 +134,12 @@ func NewPolicyMachineryController(manager ctrlruntime.Manager, client *dynamic.D
 			metav1.NamespaceAll,
 			controller.WithPredicates(&ctrlruntimepredicate.TypedGenerationChangedPredicate[*kuadrantv1alpha1.TokenRateLimitPolicy]{}),
 		)),
+		controller.WithRunnable("effectivepolicy watcher", controller.Watch(
+			&kuadrantv1alpha1.EffectivePolicy{},
+			kuadrantv1alpha1.EffectivePoliciesResource,
+			metav1.NamespaceAll,
+			controller.FilterResourcesByLabel[*kuadrantv1alpha1.EffectivePolicy](fmt.Sprintf("%s=true", kuadrantManagedLabelKey)),
+		)),
 		controller.WithRunnable("topology configmap watcher", controller.Watch(
 			&corev1.ConfigMap{},
 			controller.ConfigMapsResource,
@@ -157,6 +165,12 @@ func NewPolicyMachineryController(manager ctrlruntime.Manager, client *dynamic.D
 			// labels propagation pattern would be more reliable as the kuadrant operator would be owning these labels
 			controller.FilterResourcesByLabel[*appsv1.Deployment]("app=limitador"),
 		)),
+		controller.WithRunnable("component autoscaler watcher", controller.Watch(
+			&autoscalingv2.HorizontalPodAutoscaler{},
+			kuadrantv1beta1.HorizontalPodAutoscalersResource,
+			metav1.NamespaceAll,
+			controller.FilterResourcesByLabel[*autoscalingv2.HorizontalPodAutoscaler](fmt.Sprintf("%s=true", kuadrantManagedLabelKey)),
+		)),
 		controller.WithPolicyKinds(
 			kuadrantv1.DNSPolicyGroupKind,
 			kuadrantv1.TLSPolicyGroupKind,
@@ -168,6 +182,8 @@ func NewPolicyMachineryController(manager ctrlruntime.Manager, client *dynamic.D
 			kuadrantv1beta1.KuadrantGroupKind,
 			ConfigMapGroupKind,
 			kuadrantv1beta1.DeploymentGroupKind,
+			kuadrantv1beta1.HorizontalPodAutoscalerGroupKind,
+			kuadrantv1alpha1.EffectivePolicyGroupKind,
 		),
 		controller.WithObjectLinks(
 			kuadrantv1beta1.LinkKuadrantToGatewayClasses,
@@ -236,6 +252,9 @@ type BootOptionsBuilder struct {
 
 	extensionManager *extension.Manager
 
+	// Read-only API answering queries about the topology of the last reconciliation
+	topologyQueryServer *TopologyQueryServer
+
 	// Error tracking for non-blocking errors
 	errorTracker   *PersistentErrorTracker
 	retryScheduler RetryScheduler
@@ -347,6 +366,9 @@ func (b *BootOptionsBuilder) getGatewayAPIOptions() ([]controller.ControllerOpti
 		)),
 	)
 
+	b.topologyQueryServer = NewTopologyQueryServer(b.logger)
+	opts = append(opts, controller.WithRunnable("topology query server", b.topologyQueryServer.runnable()))
+
 	return opts, nil
 }
 
@@ -749,7 +771,7 @@ type additionalAttrsFn func(resourceEvents []controller.ResourceEvent, topology
 // The tracer is automatically retrieved from context (injected via controller.WithTracer).
 func traceReconcileFunc(name string, reconcileFunc controller.ReconcileFunc, additionalAttrs ...additionalAttrsFn) controller.ReconcileFunc {
 	// First wrap with policy-machinery's TraceReconcileFunc (handles basic tracing)
-	baseTraced := controller.TraceReconcileFunc(name, reconcileFunc)
+	baseTraced := controller.TraceReconcileFunc(name, instrumentReconcileFunc(name, reconcileFunc))
 
 	// If no additional attributes needed, return the base
 	if len(additionalAttrs) == 0 {
@@ -788,12 +810,14 @@ func additionalMainTraceAttributes(resourceEvents []controller.ResourceEvent, _
 }
 
 func (b *BootOptionsBuilder) Reconciler() controller.ReconcileFunc {
+	eventRecorder := NewPolicyEventRecorder(b.manager.GetEventRecorder(PolicyEventRecorderName))
+
 	mainWorkflow := &controller.Workflow{
 		Precondition: traceReconcileFunc("workflow.init", initWorkflow(b.client).Run),
 		Tasks: []controller.ReconcileFunc{
-			traceReconcileFunc("workflow.dns", NewDNSWorkflow(b.client, b.manager.GetScheme(), b.isGatewayAPIInstalled, b.isDNSOperatorInstalled).Run),
-			traceReconcileFunc("workflow.tls", NewTLSWorkflow(b.client, b.manager.GetScheme(), b.isGatewayAPIInstalled, b.isCertManagerInstalled).Run),
-			traceReconcileFunc("workflow.data_plane_policies", NewDataPlanePoliciesWorkflow(b.manager, b.client, b.isGatewayAPIInstalled, b.isIstioInstalled, b.isEnvoyGatewayInstalled, b.isLimitadorOperatorInstalled, b.isAuthorinoOperatorInstalled).Run),
+			traceReconcileFunc("workflow.dns", NewDNSWorkflow(b.client, b.manager.GetScheme(), eventRecorder, b.isGatewayAPIInstalled, b.isDNSOperatorInstalled).Run),
+			traceReconcileFunc("workflow.tls", NewTLSWorkflow(b.client, b.manager.GetScheme(), eventRecorder, b.isGatewayAPIInstalled, b.isCertManagerInstalled).Run),
+			traceReconcileFunc("workflow.data_plane_policies", NewDataPlanePoliciesWorkflow(b.manager, b.client, eventRecorder, b.isGatewayAPIInstalled, b.isIstioInstalled, b.isEnvoyGatewayInstalled, b.isLimitadorOperatorInstalled, b.isAuthorinoOperatorInstalled).Run),
 			traceReconcileFunc("workflow.observability", NewObservabilityReconciler(b.client, b.manager, operatorNamespace).Subscription().Reconcile),
 			traceReconcileFunc("workflow.developer_portal", NewDeveloperPortalReconciler(b.manager).Subscription().Reconcile),
 		},
@@ -898,6 +922,9 @@ func (b *BootOptionsBuilder) finalStepsWorkflow() *controller.Workflow {
 			traceReconcileFunc("finalize.gateway_policy_discoverability", NewGatewayPolicyDiscoverabilityReconciler(b.client).Subscription().Reconcile),
 			traceReconcileFunc("finalize.route_policy_discoverability", NewRoutePolicyDiscoverabilityReconciler(b.client).Subscription().Reconcile),
 		)
+		if b.topologyQueryServer != nil {
+			workflow.Tasks = append(workflow.Tasks, traceReconcileFunc("finalize.topology_query", b.topologyQueryServer.Reconcile))
+		}
 	}
 
 	if b.isUsingExtensions {
@@ -40,13 +40,13 @@ var (
 //+kubebuilder:rbac:groups="cert-manager.io",resources=clusterissuers,verbs=get;list;watch;
 //+kubebuilder:rbac:groups="cert-manager.io",resources=certificates,verbs=get;list;watch;create;update;patch;delete
 
-func NewTLSWorkflow(client *dynamic.DynamicClient, scheme *runtime.Scheme, isGatewayAPIInstalled, isCertManagerInstalled bool) *controller.Workflow {
+func NewTLSWorkflow(client *dynamic.DynamicClient, scheme *runtime.Scheme, eventRecorder *PolicyEventRecorder, isGatewayAPIInstalled, isCertManagerInstalled bool) *controller.Workflow {
 	return &controller.Workflow{
 		Precondition: traceReconcileFunc("tls.validator", NewTLSPoliciesValidator(isGatewayAPIInstalled, isCertManagerInstalled).Subscription().Reconcile),
 		Tasks: []controller.ReconcileFunc{
 			traceReconcileFunc("tls.effective_policies", NewEffectiveTLSPoliciesReconciler(client, scheme).Subscription().Reconcile),
 		},
-		Postcondition: traceReconcileFunc("tls.status_updater", NewTLSPolicyStatusUpdater(client).Subscription().Reconcile),
+		Postcondition: traceReconcileFunc("tls.status_updater", NewTLSPolicyStatusUpdater(client, eventRecorder).Subscription().Reconcile),
 	}
 }
 
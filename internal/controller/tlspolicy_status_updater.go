@@ -28,11 +28,12 @@ import (
 )
 
 type TLSPolicyStatusUpdater struct {
-	Client *dynamic.DynamicClient
+	Client        *dynamic.DynamicClient
+	EventRecorder *PolicyEventRecorder
 }
 
-func NewTLSPolicyStatusUpdater(client *dynamic.DynamicClient) *TLSPolicyStatusUpdater {
-	return &TLSPolicyStatusUpdater{Client: client}
+func NewTLSPolicyStatusUpdater(client *dynamic.DynamicClient, eventRecorder *PolicyEventRecorder) *TLSPolicyStatusUpdater {
+	return &TLSPolicyStatusUpdater{Client: client, EventRecorder: eventRecorder}
 }
 
 func (t *TLSPolicyStatusUpdater) Subscription() *controller.Subscription {
@@ -101,6 +102,7 @@ func (t *TLSPolicyStatusUpdater) UpdateStatus(ctx context.Context, _ []controlle
 			continue
 		}
 		newStatus.ObservedGeneration = p.Generation
+		previousConditions := p.Status.Conditions
 		p.Status = *newStatus
 
 		resource := t.Client.Resource(kuadrantv1.TLSPoliciesResource).Namespace(policy.GetNamespace())
@@ -122,6 +124,10 @@ func (t *TLSPolicyStatusUpdater) UpdateStatus(ctx context.Context, _ []controlle
 			continue
 		}
 
+		if err == nil {
+			t.EventRecorder.RecordConditionTransitions(p, previousConditions, p.Status.Conditions)
+		}
+
 		span.AddEvent("policy status updated successfully")
 		span.SetStatus(codes.Ok, "")
 		span.End()
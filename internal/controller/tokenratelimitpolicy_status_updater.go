@@ -36,7 +36,8 @@ import (
 )
 
 type TokenRateLimitPolicyStatusUpdater struct {
-	client *dynamic.DynamicClient
+	client        *dynamic.DynamicClient
+	eventRecorder *PolicyEventRecorder
 }
 
 // TokenRateLimitPolicyStatusUpdater subscribes to events with potential impact on the status of TokenRateLimitPolicy resources
@@ -114,6 +115,7 @@ func (r *TokenRateLimitPolicyStatusUpdater) UpdateStatus(ctx context.Context, _
 			continue
 		}
 		newStatus.ObservedGeneration = policy.Generation
+		previousConditions := policy.Status.Conditions
 		policy.Status = *newStatus
 
 		obj, err := controller.Destruct(policy)
@@ -134,6 +136,8 @@ func (r *TokenRateLimitPolicyStatusUpdater) UpdateStatus(ctx context.Context, _
 			continue
 		}
 
+		r.eventRecorder.RecordConditionTransitions(policy, previousConditions, policy.Status.Conditions)
+
 		span.AddEvent("policy status updated successfully")
 		span.SetStatus(codes.Ok, "")
 		span.End()
@@ -0,0 +1,195 @@
+package controllers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kuadrant/kuadrant-operator/internal/kuadrant"
+)
+
+const (
+	// TopologyManifestKey is the key of the topology configmap holding the manifest of the chunks of the
+	// exported topology, for the consumers to reassemble it
+	TopologyManifestKey = "manifest"
+	// TopologyChunkKey is the key of the binary data of the chunk configmaps holding a chunk of an exported topology
+	TopologyChunkKey = "chunk"
+
+	TopologyFormatDot  = "dot"
+	TopologyFormatJSON = "json"
+
+	topologyManifestVersion = 1
+	topologyEncodingGzip    = "gzip"
+	// maxTopologyChunkBytes is the size of the chunks of the compressed topology, leaving room for the
+	// metadata of the chunk configmaps under the 1MB limit
+	maxTopologyChunkBytes = 768 * 1024
+	// maxTopologyChunks bounds the number of chunk configmaps of a format of the topology
+	maxTopologyChunks = 16
+	// topologyChunkVersionLength is the length of the prefix of the checksum of the topology versioning the
+	// names of its chunk configmaps
+	topologyChunkVersionLength = 12
+)
+
+// TopologyManifest lists the chunks of the exported topology, in every format
+type TopologyManifest struct {
+	Version int                      `json:"version"`
+	Formats []TopologyFormatManifest `json:"formats"`
+}
+
+// TopologyFormatManifest lists the chunks of the topology exported in a format. The topology is
+// reassembled by concatenating the binary data of the chunk configmaps, in order, and decoding the result.
+type TopologyFormatManifest struct {
+	Format   string `json:"format"`
+	Encoding string `json:"encoding"`
+	// Size is the size of the decoded topology, in bytes
+	Size int `json:"size"`
+	// SHA256 is the checksum of the decoded topology
+	SHA256 string `json:"sha256"`
+	// Chunks are the names of the configmaps holding the chunks of the encoded topology, in order
+	Chunks []string `json:"chunks"`
+}
+
+// chunkNames returns the names of the chunk configmaps of all formats
+func (m *TopologyManifest) chunkNames() []string {
+	if m == nil {
+		return nil
+	}
+	var names []string
+	for _, format := range m.Formats {
+		names = append(names, format.Chunks...)
+	}
+	return names
+}
+
+// topologyGraph is the JSON representation of the topology
+type topologyGraph struct {
+	Nodes []topologyNode `json:"nodes"`
+	Edges []topologyEdge `json:"edges"`
+}
+
+type topologyNode struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	Group     string `json:"group,omitempty"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+type topologyEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// topologyToJSON returns the JSON graph of the topology, with the nodes and edges sorted for a stable output
+func topologyToJSON(topology *machinery.Topology) ([]byte, error) {
+	targetables := make(map[string]struct{})
+	for _, t := range topology.Targetables().Items() {
+		targetables[t.GetLocator()] = struct{}{}
+	}
+	policies := make(map[string]struct{})
+	for _, p := range topology.Policies().Items() {
+		policies[p.GetLocator()] = struct{}{}
+	}
+
+	all := topology.All()
+	graph := topologyGraph{Nodes: []topologyNode{}, Edges: []topologyEdge{}}
+	for _, object := range all.Items() {
+		locator := object.GetLocator()
+		nodeType := "object"
+		if _, ok := targetables[locator]; ok {
+			nodeType = "targetable"
+		} else if _, ok := policies[locator]; ok {
+			nodeType = "policy"
+		}
+		gvk := object.GroupVersionKind()
+		graph.Nodes = append(graph.Nodes, topologyNode{
+			ID:        locator,
+			Type:      nodeType,
+			Group:     gvk.Group,
+			Kind:      gvk.Kind,
+			Namespace: object.GetNamespace(),
+			Name:      object.GetName(),
+		})
+		for _, child := range all.Children(object) {
+			graph.Edges = append(graph.Edges, topologyEdge{From: locator, To: child.GetLocator()})
+		}
+	}
+
+	sort.Slice(graph.Nodes, func(i, j int) bool { return graph.Nodes[i].ID < graph.Nodes[j].ID })
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		if graph.Edges[i].From != graph.Edges[j].From {
+			return graph.Edges[i].From < graph.Edges[j].From
+		}
+		return graph.Edges[i].To < graph.Edges[j].To
+	})
+	return json.Marshal(graph)
+}
+
+// chunkTopology compresses the topology exported in a format and splits it into chunk configmaps,
+// returning the manifest of the format along with the chunks. The names of the chunks are versioned by the
+// checksum of the topology, so that a chunk never changes once written: the consumers reading the chunks
+// of a manifest never get the ones of another version of the topology.
+func chunkTopology(namespace, format string, data []byte) (TopologyFormatManifest, []*corev1.ConfigMap, error) {
+	checksum := sha256.Sum256(data)
+	manifest := TopologyFormatManifest{
+		Format:   format,
+		Encoding: topologyEncodingGzip,
+		Size:     len(data),
+		SHA256:   hex.EncodeToString(checksum[:]),
+	}
+
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err := writer.Write(data); err != nil {
+		return manifest, nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return manifest, nil, err
+	}
+
+	encoded := compressed.Bytes()
+	if chunks := (len(encoded) + maxTopologyChunkBytes - 1) / maxTopologyChunkBytes; chunks > maxTopologyChunks {
+		return manifest, nil, fmt.Errorf("compressed %s topology is %d bytes, exceeds the %d byte limit of %d chunks", format, len(encoded), maxTopologyChunks*maxTopologyChunkBytes, maxTopologyChunks)
+	}
+
+	var configMaps []*corev1.ConfigMap
+	for i := 0; len(encoded) > 0; i++ {
+		size := min(len(encoded), maxTopologyChunkBytes)
+		name := fmt.Sprintf("%s-%s-%s-%d", TopologyConfigMapName, format, manifest.SHA256[:topologyChunkVersionLength], i)
+		configMaps = append(configMaps, &corev1.ConfigMap{
+			TypeMeta: metav1.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels:    map[string]string{kuadrant.TopologyChunkLabel: format},
+			},
+			BinaryData: map[string][]byte{TopologyChunkKey: bytes.Clone(encoded[:size])},
+		})
+		manifest.Chunks = append(manifest.Chunks, name)
+		encoded = encoded[size:]
+	}
+	return manifest, configMaps, nil
+}
+
+// parseTopologyManifest reads the manifest of the topology configmap, if any
+func parseTopologyManifest(cm *corev1.ConfigMap) *TopologyManifest {
+	data, found := cm.Data[TopologyManifestKey]
+	if !found || strings.TrimSpace(data) == "" {
+		return nil
+	}
+	manifest := &TopologyManifest{}
+	if err := json.Unmarshal([]byte(data), manifest); err != nil {
+		return nil
+	}
+	return manifest
+}
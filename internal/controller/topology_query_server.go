@@ -0,0 +1,338 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/kuadrant/policy-machinery/controller"
+	"github.com/kuadrant/policy-machinery/machinery"
+	"github.com/samber/lo"
+	"k8s.io/utils/env"
+
+	kuadrantv1 "github.com/kuadrant/kuadrant-operator/api/v1"
+	kuadrantv1alpha1 "github.com/kuadrant/kuadrant-operator/api/v1alpha1"
+)
+
+const defaultTopologyQueryServerPort = 8083
+
+// topologySnapshot is the topology and the effective policies of the last reconciliation
+type topologySnapshot struct {
+	topology                        *machinery.Topology
+	effectiveRateLimitPolicies      EffectiveRateLimitPolicies
+	effectiveTokenRateLimitPolicies EffectiveTokenRateLimitPolicies
+}
+
+// TopologyQueryServer serves a read-only HTTP API answering queries about the topology of the last
+// reconciliation, such as the policies affecting a target, the effective rate limit policies of the paths
+// to a route rule, or the gateways reached by a policy
+type TopologyQueryServer struct {
+	server   *http.Server
+	logger   logr.Logger
+	snapshot atomic.Pointer[topologySnapshot]
+}
+
+func NewTopologyQueryServer(logger logr.Logger) *TopologyQueryServer {
+	return &TopologyQueryServer{logger: logger.WithName("TopologyQueryServer")}
+}
+
+// Reconcile stores the topology and the effective policies of the reconciliation, to answer the next queries
+func (s *TopologyQueryServer) Reconcile(_ context.Context, _ []controller.ResourceEvent, topology *machinery.Topology, _ error, state *sync.Map) error {
+	snapshot := &topologySnapshot{topology: topology}
+	if effectivePolicies, ok := state.Load(StateEffectiveRateLimitPolicies); ok {
+		snapshot.effectiveRateLimitPolicies, _ = effectivePolicies.(EffectiveRateLimitPolicies)
+	}
+	if effectivePolicies, ok := state.Load(StateEffectiveTokenRateLimitPolicies); ok {
+		snapshot.effectiveTokenRateLimitPolicies, _ = effectivePolicies.(EffectiveTokenRateLimitPolicies)
+	}
+	s.snapshot.Store(snapshot)
+	return nil
+}
+
+func (s *TopologyQueryServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/policies", s.withSnapshot(s.policies))
+	mux.HandleFunc("GET /v1/effectivepolicies", s.withSnapshot(s.effectivePolicies))
+	mux.HandleFunc("GET /v1/gateways", s.withSnapshot(s.gateways))
+	return mux
+}
+
+func (s *TopologyQueryServer) Run(stopCh <-chan struct{}) {
+	port, err := env.GetInt("TOPOLOGY_QUERY_SERVER_PORT", defaultTopologyQueryServerPort)
+	if err != nil {
+		s.logger.Error(err, "invalid TOPOLOGY_QUERY_SERVER_PORT, using default", "default", defaultTopologyQueryServerPort)
+		port = defaultTopologyQueryServerPort
+	}
+
+	// the API is not authenticated: it listens on the loopback interface only, reached with a port forward to
+	// the operator pod, which access is granted by RBAC
+	s.server = &http.Server{
+		Addr:              fmt.Sprintf("127.0.0.1:%d", port),
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		s.logger.Info("starting topology query server", "port", port)
+		if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Error(err, "topology query server failed")
+		}
+	}()
+
+	<-stopCh
+
+	s.logger.Info("stopping topology query server")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.server.Shutdown(ctx); err != nil {
+		s.logger.Error(err, "topology query server shutdown error")
+	}
+}
+
+func (s *TopologyQueryServer) HasSynced() bool {
+	return true
+}
+
+func (s *TopologyQueryServer) runnable() controller.RunnableBuilder {
+	return func(*controller.Controller) controller.Runnable {
+		return s
+	}
+}
+
+// PolicyRef identifies a policy in the responses of the topology query API
+type PolicyRef struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Locator   string `json:"locator"`
+	// TargetRef is the locator of the targetable the policy is attached to
+	TargetRef string `json:"targetRef,omitempty"`
+}
+
+// TargetableRef identifies a targetable in the responses of the topology query API
+type TargetableRef struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Locator   string `json:"locator"`
+}
+
+// EffectivePolicyResult is the effective policy of a path of the topology
+type EffectivePolicyResult struct {
+	PathID         string         `json:"pathID"`
+	Path           []string       `json:"path"`
+	Spec           any            `json:"spec"`
+	SourcePolicies []string       `json:"sourcePolicies"`
+	Gateway        *TargetableRef `json:"gateway,omitempty"`
+	Listener       *TargetableRef `json:"listener,omitempty"`
+}
+
+type queryHandler func(snapshot *topologySnapshot, r *http.Request) (any, int, error)
+
+func (s *TopologyQueryServer) withSnapshot(handler queryHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		snapshot := s.snapshot.Load()
+		if snapshot == nil || snapshot.topology == nil {
+			writeQueryError(w, http.StatusServiceUnavailable, errors.New("topology not reconciled yet"))
+			return
+		}
+		result, status, err := handler(snapshot, r)
+		if err != nil {
+			writeQueryError(w, status, err)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			s.logger.Error(err, "failed to encode topology query result", "path", r.URL.Path)
+		}
+	}
+}
+
+func writeQueryError(w http.ResponseWriter, status int, err error) {
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// requiredParam returns the value of a query parameter, or an error when missing
+func requiredParam(r *http.Request, name string) (string, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return "", fmt.Errorf("missing %s query parameter", name)
+	}
+	return value, nil
+}
+
+// policies answers the policies affecting a targetable: the policies attached to it and to its ancestors
+func (s *TopologyQueryServer) policies(snapshot *topologySnapshot, r *http.Request) (any, int, error) {
+	locator, err := requiredParam(r, "target")
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	targetables := snapshot.topology.Targetables()
+	target, found := findTargetable(targetables, locator)
+	if !found {
+		return nil, http.StatusNotFound, fmt.Errorf("target %s not found", locator)
+	}
+
+	kind := r.URL.Query().Get("kind")
+	var result []PolicyRef
+	for _, targetable := range append([]machinery.Targetable{target}, ancestors(targetables, target)...) {
+		for _, policy := range targetable.Policies() {
+			if kind != "" && policy.GroupVersionKind().Kind != kind {
+				continue
+			}
+			result = append(result, PolicyRef{
+				Kind:      policy.GroupVersionKind().Kind,
+				Namespace: policy.GetNamespace(),
+				Name:      policy.GetName(),
+				Locator:   policy.GetLocator(),
+				TargetRef: targetable.GetLocator(),
+			})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Locator != result[j].Locator {
+			return result[i].Locator < result[j].Locator
+		}
+		return result[i].TargetRef < result[j].TargetRef
+	})
+	return lo.Ternary(result == nil, []PolicyRef{}, result), http.StatusOK, nil
+}
+
+// effectivePolicies answers the effective policies of a kind of the paths going through a targetable,
+// e.g. the paths to an HTTPRoute rule, optionally restricted to the paths going through a gateway
+func (s *TopologyQueryServer) effectivePolicies(snapshot *topologySnapshot, r *http.Request) (any, int, error) {
+	locator, err := requiredParam(r, "target")
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	kind, err := requiredParam(r, "kind")
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	gateway := r.URL.Query().Get("gateway")
+
+	var results []EffectivePolicyResult
+	switch kind {
+	case kuadrantv1.RateLimitPolicyGroupKind.Kind:
+		for pathID, effectivePolicy := range snapshot.effectiveRateLimitPolicies {
+			results = append(results, newEffectivePolicyResult(pathID, effectivePolicy.Path, effectivePolicy.Spec.Spec, effectivePolicy.SourcePolicies))
+		}
+	case kuadrantv1alpha1.TokenRateLimitPolicyGroupKind.Kind:
+		for pathID, effectivePolicy := range snapshot.effectiveTokenRateLimitPolicies {
+			results = append(results, newEffectivePolicyResult(pathID, effectivePolicy.Path, effectivePolicy.Spec.Spec, effectivePolicy.SourcePolicies))
+		}
+	default:
+		return nil, http.StatusBadRequest, fmt.Errorf("unsupported kind %s, supported kinds are %s and %s", kind, kuadrantv1.RateLimitPolicyGroupKind.Kind, kuadrantv1alpha1.TokenRateLimitPolicyGroupKind.Kind)
+	}
+
+	results = lo.Filter(results, func(result EffectivePolicyResult, _ int) bool {
+		return lo.Contains(result.Path, locator) && (gateway == "" || lo.Contains(result.Path, gateway))
+	})
+	sort.Slice(results, func(i, j int) bool { return results[i].PathID < results[j].PathID })
+	return lo.Ternary(results == nil, []EffectivePolicyResult{}, results), http.StatusOK, nil
+}
+
+func newEffectivePolicyResult(pathID string, path []machinery.Targetable, spec any, sourcePolicies []string) EffectivePolicyResult {
+	result := EffectivePolicyResult{
+		PathID:         pathID,
+		Path:           lo.Map(path, machinery.MapTargetableToLocatorFunc),
+		Spec:           spec,
+		SourcePolicies: lo.Ternary(sourcePolicies == nil, []string{}, sourcePolicies),
+	}
+	for _, targetable := range path {
+		switch targetable.(type) {
+		case *machinery.Gateway:
+			result.Gateway = lo.ToPtr(newTargetableRef(targetable))
+		case *machinery.Listener:
+			result.Listener = lo.ToPtr(newTargetableRef(targetable))
+		}
+	}
+	return result
+}
+
+// gateways answers the gateways reached by a policy: the gateways of the paths going through its targets
+func (s *TopologyQueryServer) gateways(snapshot *topologySnapshot, r *http.Request) (any, int, error) {
+	locator, err := requiredParam(r, "policy")
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	policy, found := lo.Find(snapshot.topology.Policies().Items(), func(p machinery.Policy) bool {
+		return p.GetLocator() == locator
+	})
+	if !found {
+		return nil, http.StatusNotFound, fmt.Errorf("policy %s not found", locator)
+	}
+
+	targetables := snapshot.topology.Targetables()
+	gateways := make(map[string]machinery.Targetable)
+	for _, targetRef := range policy.GetTargetRefs() {
+		target, found := findTargetable(targetables, targetRef.GetLocator())
+		if !found {
+			continue
+		}
+		related := append([]machinery.Targetable{target}, ancestors(targetables, target)...)
+		if _, isGatewayClass := target.(*machinery.GatewayClass); isGatewayClass {
+			related = append(related, targetables.Children(target)...)
+		}
+		for _, targetable := range related {
+			if _, isGateway := targetable.(*machinery.Gateway); isGateway {
+				gateways[targetable.GetLocator()] = targetable
+			}
+		}
+	}
+
+	result := lo.Map(lo.Values(gateways), func(gateway machinery.Targetable, _ int) TargetableRef {
+		return newTargetableRef(gateway)
+	})
+	sort.Slice(result, func(i, j int) bool { return result[i].Locator < result[j].Locator })
+	return result, http.StatusOK, nil
+}
+
+func newTargetableRef(targetable machinery.Targetable) TargetableRef {
+	return TargetableRef{
+		Kind:      targetable.GroupVersionKind().Kind,
+		Namespace: targetable.GetNamespace(),
+		Name:      targetable.GetName(),
+		Locator:   targetable.GetLocator(),
+	}
+}
+
+func findTargetable(targetables interface {
+	Items(...machinery.FilterFunc) []machinery.Targetable
+}, locator string) (machinery.Targetable, bool) {
+	items := targetables.Items(func(o machinery.Object) bool { return o.GetLocator() == locator })
+	if len(items) == 0 {
+		return nil, false
+	}
+	return items[0], true
+}
+
+// ancestors returns the targetables from which there is a path to the targetable, up to the gateway classes
+func ancestors(targetables interface {
+	Parents(machinery.Object) []machinery.Targetable
+}, targetable machinery.Targetable) []machinery.Targetable {
+	visited := map[string]struct{}{targetable.GetLocator(): {}}
+	var result []machinery.Targetable
+	queue := []machinery.Targetable{targetable}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, parent := range targetables.Parents(current) {
+			if _, seen := visited[parent.GetLocator()]; seen {
+				continue
+			}
+			visited[parent.GetLocator()] = struct{}{}
+			result = append(result, parent)
+			queue = append(queue, parent)
+		}
+	}
+	return result
+}
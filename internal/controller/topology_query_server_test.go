@@ -0,0 +1,212 @@
+//go:build unit
+
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/kuadrant/policy-machinery/machinery"
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	kuadrantv1 "github.com/kuadrant/kuadrant-operator/api/v1"
+)
+
+func newTopologyQueryTestServer(t *testing.T) (*httptest.Server, *kuadrantv1.RateLimitPolicy, *kuadrantv1.RateLimitPolicy) {
+	t.Helper()
+
+	gatewayClass := &gatewayapiv1.GatewayClass{
+		TypeMeta:   metav1.TypeMeta{Kind: machinery.GatewayClassGroupKind.Kind, APIVersion: gatewayapiv1.GroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: "kuadrant", UID: types.UID("gatewayclass")},
+	}
+	gateways := lo.Map([]string{"gw-a", "gw-b"}, func(name string, _ int) *gatewayapiv1.Gateway {
+		return &gatewayapiv1.Gateway{
+			TypeMeta:   metav1.TypeMeta{Kind: machinery.GatewayGroupKind.Kind, APIVersion: gatewayapiv1.GroupVersion.String()},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID(name)},
+			Spec: gatewayapiv1.GatewaySpec{
+				GatewayClassName: "kuadrant",
+				Listeners:        []gatewayapiv1.Listener{{Name: "http"}},
+			},
+		}
+	})
+	httpRoute := &gatewayapiv1.HTTPRoute{
+		TypeMeta:   metav1.TypeMeta{Kind: machinery.HTTPRouteGroupKind.Kind, APIVersion: gatewayapiv1.GroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: "toystore", Namespace: "default", UID: types.UID("toystore")},
+		Spec: gatewayapiv1.HTTPRouteSpec{
+			CommonRouteSpec: gatewayapiv1.CommonRouteSpec{
+				ParentRefs: []gatewayapiv1.ParentReference{{Name: "gw-a"}},
+			},
+			Rules: []gatewayapiv1.HTTPRouteRule{
+				{Name: ptr.To(gatewayapiv1.SectionName("get"))},
+				{Name: ptr.To(gatewayapiv1.SectionName("post"))},
+			},
+		},
+	}
+
+	policyFactory := func(name string, targetRef gatewayapiv1alpha2.LocalPolicyTargetReferenceWithSectionName) *kuadrantv1.RateLimitPolicy {
+		return &kuadrantv1.RateLimitPolicy{
+			TypeMeta:   metav1.TypeMeta{Kind: kuadrantv1.RateLimitPolicyGroupKind.Kind, APIVersion: kuadrantv1.GroupVersion.String()},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID(name)},
+			Spec:       kuadrantv1.RateLimitPolicySpec{TargetRef: targetRef},
+		}
+	}
+	gatewayPolicy := policyFactory("gw-rlp", gatewayapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+		LocalPolicyTargetReference: gatewayapiv1alpha2.LocalPolicyTargetReference{
+			Group: gatewayapiv1.GroupName,
+			Kind:  gatewayapiv1.Kind(machinery.GatewayGroupKind.Kind),
+			Name:  "gw-a",
+		},
+	})
+	routeRulePolicy := policyFactory("get-rlp", gatewayapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+		LocalPolicyTargetReference: gatewayapiv1alpha2.LocalPolicyTargetReference{
+			Group: gatewayapiv1.GroupName,
+			Kind:  gatewayapiv1.Kind(machinery.HTTPRouteGroupKind.Kind),
+			Name:  "toystore",
+		},
+		SectionName: ptr.To(gatewayapiv1.SectionName("get")),
+	})
+
+	topology, err := machinery.NewGatewayAPITopology(
+		machinery.WithGatewayClasses(gatewayClass),
+		machinery.WithGateways(gateways...),
+		machinery.ExpandGatewayListeners(),
+		machinery.WithHTTPRoutes(httpRoute),
+		machinery.ExpandHTTPRouteRules(),
+		machinery.WithGatewayAPITopologyPolicies(gatewayPolicy, routeRulePolicy),
+	)
+	if err != nil {
+		t.Fatalf("failed to create topology: %v", err)
+	}
+
+	effectivePolicies := make(EffectiveRateLimitPolicies)
+	targetables := topology.Targetables()
+	gatewayClassTargetable, _ := findTargetable(targetables, "gatewayclass.gateway.networking.k8s.io:kuadrant")
+	for _, rule := range targetables.Items(func(o machinery.Object) bool { _, ok := o.(*machinery.HTTPRouteRule); return ok }) {
+		for _, path := range targetables.Paths(gatewayClassTargetable, rule) {
+			effectivePolicies[kuadrantv1.PathID(path)] = EffectiveRateLimitPolicy{
+				Path:           path,
+				Spec:           *gatewayPolicy,
+				SourcePolicies: []string{gatewayPolicy.GetLocator()},
+			}
+		}
+	}
+	state := &sync.Map{}
+	state.Store(StateEffectiveRateLimitPolicies, effectivePolicies)
+
+	queryServer := NewTopologyQueryServer(logr.Discard())
+	if err := queryServer.Reconcile(t.Context(), nil, topology, nil, state); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(queryServer.Handler())
+	t.Cleanup(server.Close)
+	return server, gatewayPolicy, routeRulePolicy
+}
+
+func queryTopology(t *testing.T, server *httptest.Server, path string, params url.Values, result any) int {
+	t.Helper()
+	resp, err := http.Get(server.URL + path + "?" + params.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestTopologyQueryServer_Policies(t *testing.T) {
+	server, gatewayPolicy, routeRulePolicy := newTopologyQueryTestServer(t)
+
+	var policies []PolicyRef
+	if status := queryTopology(t, server, "/v1/policies", url.Values{"target": {"httproute.gateway.networking.k8s.io:default/toystore#get"}}, &policies); status != http.StatusOK {
+		t.Fatalf("status = %d, want %d", status, http.StatusOK)
+	}
+	locators := lo.Map(policies, func(p PolicyRef, _ int) string { return p.Locator })
+	if want := []string{routeRulePolicy.GetLocator(), gatewayPolicy.GetLocator()}; !lo.Every(locators, want) || len(locators) != len(want) {
+		t.Errorf("policies = %v, want %v", locators, want)
+	}
+
+	if status := queryTopology(t, server, "/v1/policies", url.Values{"target": {"httproute.gateway.networking.k8s.io:default/toystore#post"}}, &policies); status != http.StatusOK {
+		t.Fatalf("status = %d, want %d", status, http.StatusOK)
+	}
+	if len(policies) != 1 || policies[0].Locator != gatewayPolicy.GetLocator() || policies[0].TargetRef != "gateway.gateway.networking.k8s.io:default/gw-a" {
+		t.Errorf("policies = %v, want only %s", policies, gatewayPolicy.GetLocator())
+	}
+
+	if status := queryTopology(t, server, "/v1/policies", url.Values{"target": {"httproute.gateway.networking.k8s.io:default/unknown"}}, &policies); status != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", status, http.StatusNotFound)
+	}
+	if status := queryTopology(t, server, "/v1/policies", url.Values{}, &policies); status != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", status, http.StatusBadRequest)
+	}
+}
+
+func TestTopologyQueryServer_EffectivePolicies(t *testing.T) {
+	server, gatewayPolicy, _ := newTopologyQueryTestServer(t)
+
+	var results []EffectivePolicyResult
+	params := url.Values{"kind": {"RateLimitPolicy"}, "target": {"httproute.gateway.networking.k8s.io:default/toystore#get"}}
+	if status := queryTopology(t, server, "/v1/effectivepolicies", params, &results); status != http.StatusOK {
+		t.Fatalf("status = %d, want %d", status, http.StatusOK)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 effective policy, got %d", len(results))
+	}
+	if results[0].Gateway == nil || results[0].Gateway.Name != "gw-a" || results[0].Listener == nil {
+		t.Errorf("expected the path through gw-a, got %v", results[0].Path)
+	}
+	if !lo.Contains(results[0].SourcePolicies, gatewayPolicy.GetLocator()) {
+		t.Errorf("source policies = %v, want %s", results[0].SourcePolicies, gatewayPolicy.GetLocator())
+	}
+
+	params.Set("gateway", "gateway.gateway.networking.k8s.io:default/gw-b")
+	if status := queryTopology(t, server, "/v1/effectivepolicies", params, &results); status != http.StatusOK || len(results) != 0 {
+		t.Errorf("expected no effective policy through gw-b, got status %d and %v", status, results)
+	}
+
+	params.Set("kind", "AuthPolicy")
+	if status := queryTopology(t, server, "/v1/effectivepolicies", params, &results); status != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", status, http.StatusBadRequest)
+	}
+}
+
+func TestTopologyQueryServer_Gateways(t *testing.T) {
+	server, gatewayPolicy, routeRulePolicy := newTopologyQueryTestServer(t)
+
+	for _, policy := range []*kuadrantv1.RateLimitPolicy{gatewayPolicy, routeRulePolicy} {
+		var gateways []TargetableRef
+		if status := queryTopology(t, server, "/v1/gateways", url.Values{"policy": {policy.GetLocator()}}, &gateways); status != http.StatusOK {
+			t.Fatalf("status = %d, want %d", status, http.StatusOK)
+		}
+		if len(gateways) != 1 || gateways[0].Name != "gw-a" {
+			t.Errorf("gateways of %s = %v, want gw-a", policy.GetName(), gateways)
+		}
+	}
+}
+
+func TestTopologyQueryServer_NotReconciled(t *testing.T) {
+	server := httptest.NewServer(NewTopologyQueryServer(logr.Discard()).Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/v1/policies?target=gatewayclass.gateway.networking.k8s.io:kuadrant")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusServiceUnavailable)
+	}
+}
@@ -2,8 +2,11 @@ package controllers
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"strings"
+	"maps"
+	"reflect"
+	"slices"
 	"sync"
 
 	"github.com/kuadrant/policy-machinery/controller"
@@ -21,11 +24,10 @@ import (
 
 const (
 	TopologyConfigMapName = "topology"
-	// TODO: This size cap is a temporary workaround. The topology can outgrow a single ConfigMap,
-	// and other resources that consume it (e.g. the console-plugin) will need coordinated changes
-	// to support a different storage or serialization strategy.
+	// maxTopologyBytes caps the DOT topology stored inline in the topology configmap, read by the console-plugin.
+	// The complete topology is always exported compressed into chunk configmaps, listed in the manifest.
 	maxTopologyBytes     = 900 * 1024 // ~900KB, safely under the 1MB ConfigMap limit
-	oversizedPlaceholder = `digraph { "error" [label="Topology exceeds ConfigMap 1MB limit"] }`
+	oversizedPlaceholder = `digraph { "error" [label="Topology exceeds ConfigMap 1MB limit, see the topology manifest"] }`
 )
 
 type TopologyReconciler struct {
@@ -49,7 +51,8 @@ func (r *TopologyReconciler) Reconcile(ctx context.Context, _ []controller.Resou
 		attribute.String("configmap.namespace", r.Namespace),
 	)
 
-	topologyData := topology.ToDot()
+	dotData := topology.ToDot()
+	topologyData := dotData
 	topologySize := len(topologyData)
 	span.SetAttributes(attribute.Int("topology.size_bytes", topologySize))
 
@@ -62,6 +65,16 @@ func (r *TopologyReconciler) Reconcile(ctx context.Context, _ []controller.Resou
 		topologyData = oversizedPlaceholder
 	}
 
+	manifest, chunks := r.exportTopology(ctx, topology, dotData)
+	manifestData, err := json.Marshal(manifest)
+	if err != nil {
+		logger.Error(err, "failed to marshal topology manifest")
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to marshal topology manifest")
+		return err
+	}
+	span.SetAttributes(attribute.Int("topology.chunks", len(chunks)))
+
 	cm := &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      TopologyConfigMapName,
@@ -69,7 +82,8 @@ func (r *TopologyReconciler) Reconcile(ctx context.Context, _ []controller.Resou
 			Labels:    map[string]string{kuadrant.TopologyLabel: "true"},
 		},
 		Data: map[string]string{
-			"topology": topologyData,
+			"topology":          topologyData,
+			TopologyManifestKey: string(manifestData),
 		},
 	}
 	unstructuredCM, err := controller.Destruct(cm)
@@ -84,7 +98,29 @@ func (r *TopologyReconciler) Reconcile(ctx context.Context, _ []controller.Resou
 		return object.GetName() == cm.GetName() && object.GetNamespace() == cm.GetNamespace() && object.GroupVersionKind().Kind == ConfigMapGroupKind.Kind
 	})
 
-	if len(existingTopologyConfigMaps) == 0 {
+	var cmTopology *corev1.ConfigMap
+	var previousManifest *TopologyManifest
+	if len(existingTopologyConfigMaps) > 0 {
+		if len(existingTopologyConfigMaps) > 1 {
+			logger.Info("multiple topology configmaps found, continuing but unexpected behaviour may occur")
+		}
+		existingTopologyConfigMap := existingTopologyConfigMaps[0].(controller.Object).(*controller.RuntimeObject)
+		cmTopology = existingTopologyConfigMap.Object.(*corev1.ConfigMap)
+		previousManifest = parseTopologyManifest(cmTopology)
+	}
+
+	// the chunks are written before the manifest referencing them is flipped, and the stale ones deleted after
+	chunksChanged := !reflect.DeepEqual(previousManifest, manifest)
+	if chunksChanged {
+		if err := r.writeTopologyChunks(ctx, chunks); err != nil {
+			logger.Error(err, "failed to write topology chunks")
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to write topology chunks")
+			return err
+		}
+	}
+
+	if cmTopology == nil {
 		_, err := r.Client.Resource(controller.ConfigMapsResource).Namespace(cm.Namespace).Create(ctx, unstructuredCM, metav1.CreateOptions{})
 		if errors.IsAlreadyExists(err) {
 			logger.Info("already created topology configmap, must not be in topology yet")
@@ -97,17 +133,12 @@ func (r *TopologyReconciler) Reconcile(ctx context.Context, _ []controller.Resou
 		} else {
 			span.AddEvent("topology configmap created")
 			span.SetStatus(codes.Ok, "")
+			r.deleteStaleTopologyChunks(ctx, manifest)
 		}
 		return err
 	}
 
-	if len(existingTopologyConfigMaps) > 1 {
-		logger.Info("multiple topology configmaps found, continuing but unexpected behaviour may occur")
-	}
-	existingTopologyConfigMap := existingTopologyConfigMaps[0].(controller.Object).(*controller.RuntimeObject)
-	cmTopology := existingTopologyConfigMap.Object.(*corev1.ConfigMap)
-
-	if d, found := cmTopology.Data["topology"]; !found || strings.Compare(d, cm.Data["topology"]) != 0 {
+	if !maps.Equal(cmTopology.Data, cm.Data) {
 		_, err := r.Client.Resource(controller.ConfigMapsResource).Namespace(cm.Namespace).Update(ctx, unstructuredCM, metav1.UpdateOptions{})
 		if err != nil {
 			logger.Error(err, "failed to update topology configmap")
@@ -116,6 +147,9 @@ func (r *TopologyReconciler) Reconcile(ctx context.Context, _ []controller.Resou
 		} else {
 			span.AddEvent("topology configmap updated")
 			span.SetStatus(codes.Ok, "")
+			if chunksChanged {
+				r.deleteStaleTopologyChunks(ctx, manifest)
+			}
 		}
 		return err
 	}
@@ -124,3 +158,79 @@ func (r *TopologyReconciler) Reconcile(ctx context.Context, _ []controller.Resou
 	span.SetStatus(codes.Ok, "")
 	return nil
 }
+
+// exportTopology exports the topology in every format, compressed and split into chunk configmaps, and
+// returns the manifest listing the chunks. A format failing to export is left out of the manifest.
+func (r *TopologyReconciler) exportTopology(ctx context.Context, topology *machinery.Topology, dotData string) (*TopologyManifest, []*corev1.ConfigMap) {
+	logger := controller.LoggerFromContext(ctx).WithName("topology file")
+	span := trace.SpanFromContext(ctx)
+
+	manifest := &TopologyManifest{Version: topologyManifestVersion, Formats: []TopologyFormatManifest{}}
+	var chunks []*corev1.ConfigMap
+
+	jsonData, jsonErr := topologyToJSON(topology)
+	exports := []struct {
+		format string
+		data   []byte
+		err    error
+	}{
+		{format: TopologyFormatDot, data: []byte(dotData)},
+		{format: TopologyFormatJSON, data: jsonData, err: jsonErr},
+	}
+
+	for _, export := range exports {
+		err := export.err
+		var formatManifest TopologyFormatManifest
+		var formatChunks []*corev1.ConfigMap
+		if err == nil {
+			formatManifest, formatChunks, err = chunkTopology(r.Namespace, export.format, export.data)
+		}
+		if err != nil {
+			logger.Error(err, "failed to export topology", "format", export.format)
+			span.RecordError(err)
+			continue
+		}
+		manifest.Formats = append(manifest.Formats, formatManifest)
+		chunks = append(chunks, formatChunks...)
+	}
+
+	return manifest, chunks
+}
+
+// writeTopologyChunks creates the chunk configmaps. Chunks are versioned by the checksum of the topology,
+// so an existing chunk already holds the data.
+func (r *TopologyReconciler) writeTopologyChunks(ctx context.Context, chunks []*corev1.ConfigMap) error {
+	for _, chunk := range chunks {
+		unstructuredChunk, err := controller.Destruct(chunk)
+		if err != nil {
+			return err
+		}
+		_, err = r.Client.Resource(controller.ConfigMapsResource).Namespace(chunk.Namespace).Create(ctx, unstructuredChunk, metav1.CreateOptions{})
+		if err != nil && !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to write topology chunk %s: %w", chunk.Name, err)
+		}
+	}
+	return nil
+}
+
+// deleteStaleTopologyChunks deletes the chunk configmaps not referenced by the current manifest, including
+// the ones left behind by manifests that were never written or whose chunks failed to be deleted
+func (r *TopologyReconciler) deleteStaleTopologyChunks(ctx context.Context, current *TopologyManifest) {
+	logger := controller.LoggerFromContext(ctx).WithName("topology file")
+	chunks, err := r.Client.Resource(controller.ConfigMapsResource).Namespace(r.Namespace).List(ctx, metav1.ListOptions{LabelSelector: kuadrant.TopologyChunkLabel})
+	if err != nil {
+		logger.Error(err, "failed to list topology chunks")
+		return
+	}
+	currentChunks := current.chunkNames()
+	for _, chunk := range chunks.Items {
+		name := chunk.GetName()
+		if slices.Contains(currentChunks, name) {
+			continue
+		}
+		err := r.Client.Resource(controller.ConfigMapsResource).Namespace(r.Namespace).Delete(ctx, name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			logger.Error(err, "failed to delete stale topology chunk", "name", name)
+		}
+	}
+}
@@ -3,15 +3,25 @@
 package controllers
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
 	"strings"
 	"sync"
 	"testing"
 
 	"github.com/kuadrant/policy-machinery/controller"
 	"github.com/kuadrant/policy-machinery/machinery"
+	"github.com/samber/lo"
 	"gotest.tools/assert"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	metav1unstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -156,3 +166,206 @@ func TestNewTopologyReconciler_PanicsOnEmptyNamespace(t *testing.T) {
 	}()
 	NewTopologyReconciler(nil, "")
 }
+
+// reassembleTopology reads the topology exported in a format by following the manifest of the topology configmap
+func reassembleTopology(t *testing.T, client *dfake.FakeDynamicClient, namespace, format string) []byte {
+	t.Helper()
+	ctx := context.Background()
+	cm, err := client.Resource(controller.ConfigMapsResource).Namespace(namespace).Get(ctx, TopologyConfigMapName, metav1.GetOptions{})
+	assert.NilError(t, err)
+	manifestData, found, err := metav1unstructured.NestedString(cm.Object, "data", TopologyManifestKey)
+	assert.NilError(t, err)
+	assert.Assert(t, found, "topology manifest key should exist")
+
+	manifest := &TopologyManifest{}
+	assert.NilError(t, json.Unmarshal([]byte(manifestData), manifest))
+	formatManifest, found := lo.Find(manifest.Formats, func(f TopologyFormatManifest) bool { return f.Format == format })
+	assert.Assert(t, found, "format %s should be in the manifest", format)
+	assert.Equal(t, formatManifest.Encoding, "gzip")
+
+	var encoded bytes.Buffer
+	for _, name := range formatManifest.Chunks {
+		chunk, err := client.Resource(controller.ConfigMapsResource).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+		assert.NilError(t, err)
+		data, found, err := metav1unstructured.NestedString(chunk.Object, "binaryData", TopologyChunkKey)
+		assert.NilError(t, err)
+		assert.Assert(t, found, "chunk key should exist in %s", name)
+		decoded, err := base64.StdEncoding.DecodeString(data)
+		assert.NilError(t, err)
+		encoded.Write(decoded)
+	}
+
+	reader, err := gzip.NewReader(&encoded)
+	assert.NilError(t, err)
+	decoded, err := io.ReadAll(reader)
+	assert.NilError(t, err)
+	checksum := sha256.Sum256(decoded)
+	assert.Equal(t, formatManifest.SHA256, hex.EncodeToString(checksum[:]))
+	assert.Equal(t, formatManifest.Size, len(decoded))
+	return decoded
+}
+
+func TestTopologyReconciler_ExportsChunks(t *testing.T) {
+	namespace := "test-ns"
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	fakeClient := dfake.NewSimpleDynamicClient(scheme)
+	reconciler := NewTopologyReconciler(fakeClient, namespace)
+
+	topology, err := machinery.NewTopology(
+		machinery.WithObjects(
+			&controller.RuntimeObject{
+				Object: &corev1.ConfigMap{
+					TypeMeta:   metav1.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"},
+					ObjectMeta: metav1.ObjectMeta{Name: "some-other-configmap", Namespace: namespace},
+				},
+			},
+		),
+	)
+	assert.NilError(t, err)
+
+	err = reconciler.Reconcile(context.Background(), nil, topology, nil, &sync.Map{})
+	assert.NilError(t, err)
+
+	assert.Equal(t, string(reassembleTopology(t, fakeClient, namespace, TopologyFormatDot)), topology.ToDot())
+
+	graph := topologyGraph{}
+	assert.NilError(t, json.Unmarshal(reassembleTopology(t, fakeClient, namespace, TopologyFormatJSON), &graph))
+	assert.Equal(t, len(graph.Nodes), 1)
+	assert.Equal(t, graph.Nodes[0].Kind, "ConfigMap")
+	assert.Equal(t, graph.Nodes[0].Name, "some-other-configmap")
+	assert.Equal(t, graph.Nodes[0].Type, "object")
+}
+
+func TestTopologyReconciler_DeletesStaleChunks(t *testing.T) {
+	namespace := "test-ns"
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	ctx := context.Background()
+
+	chunk := func(name string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			TypeMeta: metav1.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels:    map[string]string{kuadrant.TopologyChunkLabel: TopologyFormatDot},
+			},
+			BinaryData: map[string][]byte{TopologyChunkKey: []byte("stale")},
+		}
+	}
+	staleChunk := chunk(TopologyConfigMapName + "-dot-1")
+	// a chunk of a manifest that was never written, not referenced by the previous manifest
+	orphanChunk := chunk(TopologyConfigMapName + "-dot-orphan-0")
+	otherCM := &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "some-other-configmap", Namespace: namespace},
+	}
+	staleManifest, err := json.Marshal(TopologyManifest{
+		Version: topologyManifestVersion,
+		Formats: []TopologyFormatManifest{{Format: TopologyFormatDot, Encoding: "gzip", Chunks: []string{TopologyConfigMapName + "-dot-0", staleChunk.Name}}},
+	})
+	assert.NilError(t, err)
+	existingCM := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      TopologyConfigMapName,
+			Namespace: namespace,
+			Labels:    map[string]string{kuadrant.TopologyLabel: "true"},
+		},
+		Data: map[string]string{"topology": "old-data", TopologyManifestKey: string(staleManifest)},
+	}
+
+	fakeClient := dfake.NewSimpleDynamicClient(scheme)
+	for _, cm := range []*corev1.ConfigMap{existingCM, staleChunk, orphanChunk, otherCM} {
+		unstructuredCM, err := controller.Destruct(cm)
+		assert.NilError(t, err)
+		_, err = fakeClient.Resource(controller.ConfigMapsResource).Namespace(namespace).Create(ctx, unstructuredCM, metav1.CreateOptions{})
+		assert.NilError(t, err)
+	}
+
+	topology, err := machinery.NewTopology(machinery.WithObjects(&controller.RuntimeObject{Object: existingCM}))
+	assert.NilError(t, err)
+
+	reconciler := NewTopologyReconciler(fakeClient, namespace)
+	err = reconciler.Reconcile(ctx, nil, topology, nil, &sync.Map{})
+	assert.NilError(t, err)
+
+	_, err = fakeClient.Resource(controller.ConfigMapsResource).Namespace(namespace).Get(ctx, staleChunk.Name, metav1.GetOptions{})
+	assert.Assert(t, errors.IsNotFound(err), "stale chunk should have been deleted, got %v", err)
+	_, err = fakeClient.Resource(controller.ConfigMapsResource).Namespace(namespace).Get(ctx, orphanChunk.Name, metav1.GetOptions{})
+	assert.Assert(t, errors.IsNotFound(err), "orphan chunk should have been deleted, got %v", err)
+	_, err = fakeClient.Resource(controller.ConfigMapsResource).Namespace(namespace).Get(ctx, otherCM.Name, metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, string(reassembleTopology(t, fakeClient, namespace, TopologyFormatDot)), topology.ToDot())
+}
+
+func TestTopologyReconciler_VersionsChunks(t *testing.T) {
+	namespace := "test-ns"
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	ctx := context.Background()
+
+	fakeClient := dfake.NewSimpleDynamicClient(scheme)
+	reconciler := NewTopologyReconciler(fakeClient, namespace)
+
+	configMap := func(name string, data map[string]string) *controller.RuntimeObject {
+		return &controller.RuntimeObject{Object: &corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Data:       data,
+		}}
+	}
+	readManifest := func() (string, *TopologyManifest) {
+		cm, err := fakeClient.Resource(controller.ConfigMapsResource).Namespace(namespace).Get(ctx, TopologyConfigMapName, metav1.GetOptions{})
+		assert.NilError(t, err)
+		data, _, err := metav1unstructured.NestedString(cm.Object, "data", TopologyManifestKey)
+		assert.NilError(t, err)
+		manifest := &TopologyManifest{}
+		assert.NilError(t, json.Unmarshal([]byte(data), manifest))
+		return data, manifest
+	}
+
+	topology, err := machinery.NewTopology(machinery.WithObjects(configMap("first", nil)))
+	assert.NilError(t, err)
+	assert.NilError(t, reconciler.Reconcile(ctx, nil, topology, nil, &sync.Map{}))
+	previousData, previous := readManifest()
+
+	existing := configMap(TopologyConfigMapName, map[string]string{"topology": topology.ToDot(), TopologyManifestKey: previousData})
+	topology, err = machinery.NewTopology(machinery.WithObjects(configMap("first", nil), configMap("second", nil), existing))
+	assert.NilError(t, err)
+	assert.NilError(t, reconciler.Reconcile(ctx, nil, topology, nil, &sync.Map{}))
+
+	// a new version of the topology is written to new chunks rather than over the ones of the previous manifest
+	assert.Equal(t, string(reassembleTopology(t, fakeClient, namespace, TopologyFormatDot)), topology.ToDot())
+	_, current := readManifest()
+	for _, name := range previous.chunkNames() {
+		assert.Assert(t, !lo.Contains(current.chunkNames(), name), "chunk %s of the previous topology should not be reused", name)
+		_, err := fakeClient.Resource(controller.ConfigMapsResource).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+		assert.Assert(t, errors.IsNotFound(err), "chunk %s of the previous topology should have been deleted, got %v", name, err)
+	}
+}
+
+func TestChunkTopology(t *testing.T) {
+	// random data does not compress, so that it spans several chunks
+	data := make([]byte, 2*maxTopologyChunkBytes+1024)
+	_, err := rand.Read(data)
+	assert.NilError(t, err)
+
+	manifest, chunks, err := chunkTopology("test-ns", TopologyFormatDot, data)
+	assert.NilError(t, err)
+	assert.Equal(t, len(chunks), 3)
+	version := manifest.SHA256[:topologyChunkVersionLength]
+	assert.DeepEqual(t, manifest.Chunks, []string{"topology-dot-" + version + "-0", "topology-dot-" + version + "-1", "topology-dot-" + version + "-2"})
+	for _, chunk := range chunks {
+		assert.Assert(t, len(chunk.BinaryData[TopologyChunkKey]) <= maxTopologyChunkBytes)
+		assert.Equal(t, chunk.Labels[kuadrant.TopologyChunkLabel], TopologyFormatDot)
+	}
+
+	oversized := make([]byte, (maxTopologyChunks+1)*maxTopologyChunkBytes)
+	_, err = rand.Read(oversized)
+	assert.NilError(t, err)
+	_, _, err = chunkTopology("test-ns", TopologyFormatDot, oversized)
+	assert.ErrorContains(t, err, "exceeds")
+}
@@ -8,7 +8,9 @@ import (
 
 	"github.com/kuadrant/policy-machinery/machinery"
 	"k8s.io/apimachinery/pkg/labels"
+	k8stypes "k8s.io/apimachinery/pkg/types"
 
+	kuadrantv1beta1 "github.com/kuadrant/kuadrant-operator/api/v1beta1"
 	"github.com/kuadrant/kuadrant-operator/internal/extension"
 	"github.com/kuadrant/kuadrant-operator/internal/kuadrant"
 )
@@ -33,9 +35,49 @@ func TracingClusterName(gatewayName string) string {
 	return fmt.Sprintf("kuadrant-tracing-%s", gatewayName)
 }
 
-// tracingClusterPatch returns the Envoy cluster configuration for the tracing service
-func tracingClusterPatch(host string, port int, mTLS bool) map[string]any {
-	return buildClusterPatch(kuadrant.KuadrantTracingClusterName, host, port, mTLS)
+// tracingClusterPatch returns a builder of the Envoy cluster configuration for the tracing service of a gateway,
+// securing the connection to the collector with the TLS transport socket returned for the host of the collector
+func tracingClusterPatch(tlsTransportSocket func(host string) map[string]interface{}) func(string, int, bool) map[string]any {
+	return func(host string, port int, mTLS bool) map[string]any {
+		patch := buildClusterPatch(kuadrant.KuadrantTracingClusterName, host, port, false)
+		if mTLS {
+			patch["transport_socket"] = tlsTransportSocket(host)
+		}
+		return patch
+	}
+}
+
+// istioTracingTLSTransportSocket returns the TLS transport socket of an Istio gateway to the tracing collector. The
+// gateway presents the client certificate of the gateway tracing config, that istiod serves over ADS as it does for
+// the credentialName of a DestinationRule, or its mesh certificate otherwise. The certificate of the collector is
+// validated against the mesh root CA.
+func istioTracingTLSTransportSocket(tracing *kuadrantv1beta1.GatewayTracing) func(string) map[string]interface{} {
+	return func(string) map[string]interface{} {
+		if tracing.ClientCertificateSecretRef != nil {
+			return buildTLSTransportSocket(meshTLSContext(fmt.Sprintf("kubernetes://%s", tracing.ClientCertificateSecretRef.Name), adsConfigSource()), "")
+		}
+		return buildMTLSTransportSocket()
+	}
+}
+
+// envoyGatewayTracingTLSTransportSocket returns the TLS transport socket of an Envoy Gateway gateway to the tracing
+// collector. Envoy Gateway gateways are not part of a mesh: the certificate of the collector is validated against the
+// system trust store for the host of the collector, and the gateway presents the client certificate of the gateway
+// tracing config, if any, that Envoy Gateway serves over ADS as the client certificate of the backend TLS settings of
+// the EnvoyProxy, i.e. the `backendTLS.clientCertificateRef` Secret in the namespace of the EnvoyProxy
+func envoyGatewayTracingTLSTransportSocket(tracing *kuadrantv1beta1.GatewayTracing, namespace string) func(string) map[string]interface{} {
+	return func(host string) map[string]interface{} {
+		var certificateSDSName string
+		if tracing.ClientCertificateSecretRef != nil {
+			certificateSDSName = fmt.Sprintf("%s/%s", namespace, tracing.ClientCertificateSecretRef.Name)
+		}
+		return buildTLSTransportSocket(systemTrustTLSContext(host, certificateSDSName), host)
+	}
+}
+
+// gatewayTracing returns the tracing collector config of a gateway, or nil if tracing is not configured for the gateway
+func gatewayTracing(kObj *kuadrantv1beta1.Kuadrant, gateway *machinery.Gateway) *kuadrantv1beta1.GatewayTracing {
+	return kObj.Spec.Observability.TracingFor(k8stypes.NamespacedName{Name: gateway.GetName(), Namespace: gateway.GetNamespace()})
 }
 
 // parseTracingEndpoint parses a tracing endpoint URL and returns host and port
@@ -0,0 +1,105 @@
+//go:build unit
+
+package controllers
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	kuadrantv1beta1 "github.com/kuadrant/kuadrant-operator/api/v1beta1"
+)
+
+func TestTracingClusterPatch(t *testing.T) {
+	tlsContext := func(patch map[string]any) map[string]interface{} {
+		return patch["transport_socket"].(map[string]interface{})["typed_config"].(map[string]interface{})
+	}
+	commonTLSContext := func(patch map[string]any) map[string]interface{} {
+		return tlsContext(patch)["common_tls_context"].(map[string]interface{})
+	}
+	clientCertificateSDSConfig := func(patch map[string]any) map[string]interface{} {
+		return commonTLSContext(patch)["tls_certificate_sds_secret_configs"].([]interface{})[0].(map[string]interface{})
+	}
+	meshRootCA := map[string]interface{}{
+		"name":       "ROOTCA",
+		"sds_config": sdsGRPCConfigSource(),
+	}
+	systemTrust := func(sanType, host string) map[string]interface{} {
+		return map[string]interface{}{
+			"trusted_ca": map[string]interface{}{"filename": "/etc/ssl/certs/ca-certificates.crt"},
+			"match_typed_subject_alt_names": []interface{}{
+				map[string]interface{}{
+					"san_type": sanType,
+					"matcher":  map[string]interface{}{"exact": host},
+				},
+			},
+		}
+	}
+
+	t.Run("istio mesh certificate without client certificate", func(t *testing.T) {
+		tracing := &kuadrantv1beta1.GatewayTracing{Endpoint: "rpc://collector:4317"}
+		patch := tracingClusterPatch(istioTracingTLSTransportSocket(tracing))("collector", 4317, true)
+		assert.DeepEqual(t, clientCertificateSDSConfig(patch), map[string]interface{}{
+			"name":       "default",
+			"sds_config": sdsGRPCConfigSource(),
+		})
+		assert.DeepEqual(t, commonTLSContext(patch)["validation_context_sds_secret_config"], meshRootCA)
+	})
+
+	t.Run("istio client certificate", func(t *testing.T) {
+		tracing := &kuadrantv1beta1.GatewayTracing{
+			Endpoint:                   "rpc://collector:4317",
+			ClientCertificateSecretRef: &corev1.LocalObjectReference{Name: "tracing-client"},
+		}
+		patch := tracingClusterPatch(istioTracingTLSTransportSocket(tracing))("collector", 4317, true)
+		assert.DeepEqual(t, clientCertificateSDSConfig(patch), map[string]interface{}{
+			"name": "kubernetes://tracing-client",
+			"sds_config": map[string]interface{}{
+				"ads":                  map[string]interface{}{},
+				"resource_api_version": "V3",
+			},
+		})
+		assert.DeepEqual(t, commonTLSContext(patch)["validation_context_sds_secret_config"], meshRootCA)
+	})
+
+	t.Run("envoy gateway client certificate", func(t *testing.T) {
+		tracing := &kuadrantv1beta1.GatewayTracing{
+			Endpoint:                   "rpc://otel-collector.example.com:4317",
+			ClientCertificateSecretRef: &corev1.LocalObjectReference{Name: "tracing-client"},
+		}
+		patch := tracingClusterPatch(envoyGatewayTracingTLSTransportSocket(tracing, "gateway-system"))("otel-collector.example.com", 4317, true)
+		assert.DeepEqual(t, clientCertificateSDSConfig(patch), map[string]interface{}{
+			"name":       "gateway-system/tracing-client",
+			"sds_config": adsConfigSource(),
+		})
+		assert.DeepEqual(t, commonTLSContext(patch)["validation_context"], systemTrust("DNS", "otel-collector.example.com"))
+		assert.Equal(t, tlsContext(patch)["sni"], "otel-collector.example.com")
+		_, found := commonTLSContext(patch)["validation_context_sds_secret_config"]
+		assert.Assert(t, !found, "expected no mesh root CA on envoy gateway")
+	})
+
+	t.Run("envoy gateway without client certificate", func(t *testing.T) {
+		tracing := &kuadrantv1beta1.GatewayTracing{Endpoint: "rpc://10.0.0.1:4317"}
+		patch := tracingClusterPatch(envoyGatewayTracingTLSTransportSocket(tracing, "gateway-system"))("10.0.0.1", 4317, true)
+		_, found := commonTLSContext(patch)["tls_certificate_sds_secret_configs"]
+		assert.Assert(t, !found, "expected no client certificate")
+		assert.DeepEqual(t, commonTLSContext(patch)["validation_context"], systemTrust("IP_ADDRESS", "10.0.0.1"))
+	})
+
+	t.Run("insecure", func(t *testing.T) {
+		tracing := &kuadrantv1beta1.GatewayTracing{
+			Endpoint:                   "rpc://collector:4317",
+			Insecure:                   true,
+			ClientCertificateSecretRef: &corev1.LocalObjectReference{Name: "tracing-client"},
+		}
+		for _, tlsTransportSocket := range []func(string) map[string]interface{}{
+			istioTracingTLSTransportSocket(tracing),
+			envoyGatewayTracingTLSTransportSocket(tracing, "gateway-system"),
+		} {
+			patch := tracingClusterPatch(tlsTransportSocket)("collector", 4317, false)
+			_, found := patch["transport_socket"]
+			assert.Assert(t, !found, "expected no transport socket for an insecure collector")
+		}
+	})
+}
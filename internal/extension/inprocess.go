@@ -0,0 +1,77 @@
+/*
+Copyright 2025 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extension
+
+import (
+	"sync"
+
+	"github.com/go-logr/logr"
+	"github.com/kuadrant/policy-machinery/machinery"
+
+	extpb "github.com/kuadrant/kuadrant-operator/pkg/extension/grpc/v1"
+)
+
+// InProcessService is an extension service with its own DAG and registered
+// data store. Unlike the service started by the Manager, its mutators are not
+// added to the GlobalMutatorRegistry, so several instances can coexist in the
+// same process. It backs the SDK test harness in pkg/extension/testing.
+type InProcessService struct {
+	service *extensionService
+}
+
+func NewInProcessService(logger logr.Logger) *InProcessService {
+	return &InProcessService{
+		service: &extensionService{
+			dag:               newNilGuardedPointer[StateAwareDAG](),
+			registeredData:    NewRegisteredDataStore(),
+			sessionStore:      NewSessionStore(logger.WithName("sessions")),
+			reflectionFetcher: NewReflectionClient().FetchServiceDescriptors,
+			logger:            logger.WithName("extensionService"),
+		},
+	}
+}
+
+// Server returns the gRPC implementation of the extension service.
+func (s *InProcessService) Server() extpb.ExtensionServiceServer {
+	return s.service
+}
+
+// RegisteredData returns the store holding everything registered by extensions.
+func (s *InProcessService) RegisteredData() *RegisteredDataStore {
+	return s.service.registeredData
+}
+
+// SetTopology replaces the DAG the service resolves against. Subscriptions
+// whose value changes with the new topology are notified, same as when the
+// operator reconciles.
+func (s *InProcessService) SetTopology(topology *machinery.Topology) {
+	s.service.dag.set(StateAwareDAG{
+		topology: topology,
+		state:    &sync.Map{},
+	})
+}
+
+// SetReflectionFetcher overrides how action method descriptors are fetched.
+func (s *InProcessService) SetReflectionFetcher(fetcher ReflectionFetcher) {
+	s.service.reflectionFetcher = fetcher
+}
+
+// SetChangeNotifier sets the function called whenever registered data changes
+// in a way that would trigger a reconciliation of the operator.
+func (s *InProcessService) SetChangeNotifier(notifier ChangeNotifier) {
+	s.service.changeNotifier = notifier
+}
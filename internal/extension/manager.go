@@ -28,6 +28,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -563,9 +564,15 @@ func (s *extensionService) Subscribe(request *extpb.SubscribeRequest, stream grp
 		return fmt.Errorf("policy_kind is required for subscription")
 	}
 
-	channel := BlockingDAG.newUpdateChannel()
+	channel := s.dag.newUpdateChannel()
+	defer s.dag.removeUpdateChannel(channel)
 	for {
-		dag := <-channel
+		var dag StateAwareDAG
+		select {
+		case dag = <-channel:
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
 		opts := []cel.EnvOption{
 			kuadrant.CelExt(&dag),
 		}
@@ -720,10 +727,11 @@ func (s *extensionService) ClearPolicy(_ context.Context, request *extpb.ClearPo
 		Name:      request.Policy.Metadata.Name,
 	}
 
+	hadStatus := s.registeredData.HasPolicyStatus(policyID)
 	clearedMutators, clearedSubscriptions, clearedUpstreams, clearedPipelineActions := s.registeredData.ClearPolicyData(policyID)
 
-	// Trigger notifier when mutators, upstreams, or pipeline actions are cleared
-	if (clearedMutators > 0 || clearedUpstreams > 0 || clearedPipelineActions > 0) && s.changeNotifier != nil {
+	// Trigger notifier when mutators, upstreams, pipeline actions or a reported status are cleared
+	if (clearedMutators > 0 || clearedUpstreams > 0 || clearedPipelineActions > 0 || hadStatus) && s.changeNotifier != nil {
 		reason := fmt.Sprintf("data cleared for policy %s/%s (mutators: %d, upstreams: %d, pipeline actions: %d)", request.Policy.Metadata.Namespace, request.Policy.Metadata.Name, clearedMutators, clearedUpstreams, clearedPipelineActions)
 		if err := s.changeNotifier(reason); err != nil {
 			s.logger.Error(err, "failed to trigger change notification", "reason", reason)
@@ -873,6 +881,123 @@ func (s *extensionService) RegisterActionMethod(ctx context.Context, request *ex
 	return &emptypb.Empty{}, nil
 }
 
+var functionNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*(\.[a-zA-Z_][a-zA-Z0-9_]*)+$`)
+
+func (s *extensionService) RegisterFunction(_ context.Context, request *extpb.RegisterFunctionRequest) (*emptypb.Empty, error) {
+	if request == nil {
+		return nil, errors.New("request cannot be nil")
+	}
+
+	policyID, err := validatePolicyRequest(request.Policy)
+	if err != nil {
+		return nil, err
+	}
+
+	if !functionNameRegexp.MatchString(request.Name) {
+		return nil, grpcstatus.Errorf(codes.InvalidArgument, "function name %q must be qualified, e.g. \"threat.score\"", request.Name)
+	}
+	if request.Method == "" {
+		return nil, grpcstatus.Error(codes.InvalidArgument, "method cannot be empty")
+	}
+	if request.ResultType == extpb.FunctionType_FUNCTION_TYPE_UNSPECIFIED {
+		return nil, grpcstatus.Error(codes.InvalidArgument, "result type must be specified")
+	}
+
+	_, upstreamEntry, found := s.registeredData.GetUpstreamByName(policyID, request.Method)
+	if !found {
+		return nil, grpcstatus.Errorf(codes.FailedPrecondition, "method %q is not a registered action method for policy %s/%s", request.Method, policyID.Namespace, policyID.Name)
+	}
+
+	if request.ResultField != "" {
+		cacheKey := ProtoCacheKey{ClusterName: upstreamEntry.ClusterName, Service: upstreamEntry.Service}
+		if fds, ok := s.registeredData.GetProtoDescriptor(cacheKey); ok {
+			if responseType := findResponseMessageType(fds, upstreamEntry.Service, upstreamEntry.Method); responseType != "" {
+				if err := validateFieldAccess(fds, responseType, strings.Split(request.ResultField, ".")); err != nil {
+					return nil, grpcstatus.Errorf(codes.InvalidArgument, "result field %q: %v", request.ResultField, err)
+				}
+			}
+		}
+	}
+
+	s.registeredData.SetFunction(RegisteredFunctionKey{Policy: policyID, Name: request.Name}, RegisteredFunctionEntry{
+		Method:      request.Method,
+		ResultField: request.ResultField,
+		ResultType:  request.ResultType,
+	})
+
+	s.logger.Info("registered function",
+		"policy", fmt.Sprintf("%s/%s", policyID.Namespace, policyID.Name),
+		"name", request.Name,
+		"method", request.Method,
+		"resultField", request.ResultField,
+		"resultType", request.ResultType.String())
+
+	if s.changeNotifier != nil {
+		reason := fmt.Sprintf("function %q registered for policy %s/%s", request.Name, policyID.Namespace, policyID.Name)
+		if err := s.changeNotifier(reason); err != nil {
+			s.logger.Error(err, "failed to trigger change notification", "reason", reason)
+		}
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *extensionService) ReportPolicyStatus(_ context.Context, request *extpb.ReportPolicyStatusRequest) (*emptypb.Empty, error) {
+	if request == nil {
+		return nil, errors.New("request cannot be nil")
+	}
+
+	policyID, err := validatePolicyRequest(request.Policy)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]PolicyTargetStatus, 0, len(request.Targets))
+	for i, target := range request.Targets {
+		ref := target.GetTargetRef()
+		if ref == nil {
+			return nil, grpcstatus.Errorf(codes.InvalidArgument, "targets[%d]: target reference cannot be nil", i)
+		}
+		if ref.Kind == "" || ref.Name == "" {
+			return nil, grpcstatus.Errorf(codes.InvalidArgument, "targets[%d]: target kind and name must be specified", i)
+		}
+		namespace := ref.Namespace
+		if namespace == "" {
+			// policies can only target resources in their own namespace
+			namespace = policyID.Namespace
+		}
+		targets = append(targets, PolicyTargetStatus{
+			TargetRef: TargetRef{
+				Group:     ref.Group,
+				Kind:      ref.Kind,
+				Name:      ref.Name,
+				Namespace: namespace,
+			},
+			SectionName: ref.SectionName,
+			Enforced:    target.Enforced,
+			Message:     target.Message,
+		})
+	}
+
+	if !s.registeredData.SetPolicyStatus(policyID, targets) {
+		return &emptypb.Empty{}, nil
+	}
+
+	s.logger.V(1).Info("policy status reported",
+		"policy", fmt.Sprintf("%s/%s", policyID.Namespace, policyID.Name),
+		"kind", policyID.Kind,
+		"targets", len(targets))
+
+	if s.changeNotifier != nil {
+		reason := fmt.Sprintf("status reported for policy %s/%s", policyID.Namespace, policyID.Name)
+		if err := s.changeNotifier(reason); err != nil {
+			s.logger.Error(err, "failed to trigger change notification", "reason", reason)
+		}
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
 // validatePolicyRequest validates the common policy fields required by pipeline handlers.
 func validatePolicyRequest(policy *extpb.Policy) (ResourceID, error) {
 	if policy == nil {
@@ -1042,8 +1167,11 @@ func (s *extensionService) validateActions(policyID ResourceID, actions []*extpb
 		if action.ActionType == extpb.ActionType_ACTION_TYPE_UNSPECIFIED {
 			return nil, fmt.Errorf("actions[%d]: action_type must be specified", i)
 		}
-		if action.Phase != string(PipelinePhaseRequest) && action.Phase != string(PipelinePhaseResponse) {
-			return nil, fmt.Errorf("actions[%d]: phase must be %q or %q, got %q", i, PipelinePhaseRequest, PipelinePhaseResponse, action.Phase)
+		if !slices.Contains(pipelinePhases, PipelinePhase(action.Phase)) {
+			return nil, fmt.Errorf("actions[%d]: phase must be %q, %q or %q, got %q", i, PipelinePhaseAccess, PipelinePhaseRequest, PipelinePhaseResponse, action.Phase)
+		}
+		if action.Phase == string(PipelinePhaseAccess) && action.ActionType != extpb.ActionType_ACTION_TYPE_DENY {
+			return nil, fmt.Errorf("actions[%d]: only deny actions are allowed in phase %q", i, PipelinePhaseAccess)
 		}
 		if action.Predicate != "" {
 			if err := validateCELExpression(action.Predicate); err != nil {
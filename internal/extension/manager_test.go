@@ -1475,3 +1475,254 @@ func TestWarmupTimeout(t *testing.T) {
 		})
 	}
 }
+
+func validFunctionRequest() *extpb.RegisterFunctionRequest {
+	return &extpb.RegisterFunctionRequest{
+		Policy: testPolicy("DemoPolicy", "default", "demo",
+			testTargetRef("gateway.networking.k8s.io", "HTTPRoute", "my-route", "default")),
+		Name:        "threat.score",
+		Method:      "assess-threat",
+		ResultField: "threat_level",
+		ResultType:  extpb.FunctionType_FUNCTION_TYPE_INT,
+	}
+}
+
+func TestRegisterFunction_Validation(t *testing.T) {
+	tests := []struct {
+		name     string
+		mutate   func(*extpb.RegisterFunctionRequest)
+		code     codes.Code
+		contains string
+	}{
+		{
+			name:     "unqualified name",
+			mutate:   func(r *extpb.RegisterFunctionRequest) { r.Name = "score" },
+			code:     codes.InvalidArgument,
+			contains: "must be qualified",
+		},
+		{
+			name:     "invalid name",
+			mutate:   func(r *extpb.RegisterFunctionRequest) { r.Name = "threat.score()" },
+			code:     codes.InvalidArgument,
+			contains: "must be qualified",
+		},
+		{
+			name:     "missing method",
+			mutate:   func(r *extpb.RegisterFunctionRequest) { r.Method = "" },
+			code:     codes.InvalidArgument,
+			contains: "method cannot be empty",
+		},
+		{
+			name:     "unspecified result type",
+			mutate:   func(r *extpb.RegisterFunctionRequest) { r.ResultType = extpb.FunctionType_FUNCTION_TYPE_UNSPECIFIED },
+			code:     codes.InvalidArgument,
+			contains: "result type must be specified",
+		},
+		{
+			name:     "unregistered method",
+			mutate:   func(r *extpb.RegisterFunctionRequest) { r.Method = "unknown" },
+			code:     codes.FailedPrecondition,
+			contains: "is not a registered action method",
+		},
+		{
+			name:     "unknown result field",
+			mutate:   func(r *extpb.RegisterFunctionRequest) { r.ResultField = "nonexistent" },
+			code:     codes.InvalidArgument,
+			contains: "nonexistent",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestExtensionService()
+			registerTestActionMethodWithFDS(t, svc, "demo", "assess-threat")
+
+			req := validFunctionRequest()
+			tt.mutate(req)
+			_, err := svc.RegisterFunction(context.Background(), req)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			st, ok := grpcstatus.FromError(err)
+			if !ok || st.Code() != tt.code {
+				t.Fatalf("expected %s, got: %v", tt.code, err)
+			}
+			if !strings.Contains(st.Message(), tt.contains) {
+				t.Fatalf("expected error containing %q, got: %s", tt.contains, st.Message())
+			}
+		})
+	}
+}
+
+func TestRegisterFunction_NilPolicy(t *testing.T) {
+	svc := newTestExtensionService()
+
+	_, err := svc.RegisterFunction(context.Background(), &extpb.RegisterFunctionRequest{Name: "threat.score"})
+	if err == nil || err.Error() != "policy cannot be nil" {
+		t.Fatalf("expected policy cannot be nil error, got: %v", err)
+	}
+}
+
+func TestRegisterFunction_Success(t *testing.T) {
+	svc := newTestExtensionService()
+	registerTestActionMethodWithFDS(t, svc, "demo", "assess-threat")
+
+	var notified string
+	svc.changeNotifier = func(reason string) error {
+		notified = reason
+		return nil
+	}
+
+	if _, err := svc.RegisterFunction(context.Background(), validFunctionRequest()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	policyID := ResourceID{Kind: "DemoPolicy", Namespace: "default", Name: "demo"}
+	entry, found := svc.registeredData.GetFunction(RegisteredFunctionKey{Policy: policyID, Name: "threat.score"})
+	if !found {
+		t.Fatal("expected function to be stored")
+	}
+	if entry.Method != "assess-threat" || entry.ResultField != "threat_level" || entry.ResultType != extpb.FunctionType_FUNCTION_TYPE_INT {
+		t.Fatalf("unexpected entry: %+v", entry)
+	}
+	if !strings.Contains(notified, `function "threat.score" registered`) {
+		t.Fatalf("expected change notification, got %q", notified)
+	}
+
+	// functions are removed together with the policy's other data
+	if _, err := svc.ClearPolicy(context.Background(), &extpb.ClearPolicyRequest{Policy: validFunctionRequest().Policy}); err != nil {
+		t.Fatalf("unexpected error clearing policy: %v", err)
+	}
+	if _, found := svc.registeredData.GetFunction(RegisteredFunctionKey{Policy: policyID, Name: "threat.score"}); found {
+		t.Fatal("expected function to be cleared with the policy")
+	}
+}
+
+func TestReportPolicyStatus_Validation(t *testing.T) {
+	svc := newTestExtensionService()
+	policy := testPolicy("DemoPolicy", "default", "demo")
+
+	tests := []struct {
+		name    string
+		request *extpb.ReportPolicyStatusRequest
+		errMsg  string
+	}{
+		{
+			name:    "nil target reference",
+			request: &extpb.ReportPolicyStatusRequest{Policy: policy, Targets: []*extpb.TargetStatus{{Enforced: true}}},
+			errMsg:  "targets[0]: target reference cannot be nil",
+		},
+		{
+			name: "missing target name",
+			request: &extpb.ReportPolicyStatusRequest{Policy: policy, Targets: []*extpb.TargetStatus{
+				{TargetRef: testTargetRef("gateway.networking.k8s.io", "Gateway", "", "default")},
+			}},
+			errMsg: "targets[0]: target kind and name must be specified",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.ReportPolicyStatus(context.Background(), tt.request)
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+			if grpcstatus.Code(err) != codes.InvalidArgument {
+				t.Errorf("Expected InvalidArgument, got %v", grpcstatus.Code(err))
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Expected error containing %q, got %q", tt.errMsg, err.Error())
+			}
+		})
+	}
+
+	if len(svc.registeredData.GetReportedPolicies()) != 0 {
+		t.Error("Expected no status to be stored for invalid requests")
+	}
+}
+
+func TestReportPolicyStatus_Success(t *testing.T) {
+	svc := newTestExtensionService()
+
+	notifications := 0
+	svc.changeNotifier = func(reason string) error {
+		notifications++
+		return nil
+	}
+
+	targetRef := testTargetRef("gateway.networking.k8s.io", "Gateway", "my-gateway", "")
+	targetRef.SectionName = "http"
+	request := &extpb.ReportPolicyStatusRequest{
+		Policy: testPolicy("DemoPolicy", "default", "demo"),
+		Targets: []*extpb.TargetStatus{
+			{TargetRef: targetRef, Enforced: false, Message: "upstream unavailable"},
+		},
+	}
+
+	if _, err := svc.ReportPolicyStatus(context.Background(), request); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if notifications != 1 {
+		t.Fatalf("Expected 1 notification, got %d", notifications)
+	}
+
+	reported := svc.registeredData.GetReportedPolicies()
+	if len(reported) != 1 || len(reported[0].Targets) != 1 {
+		t.Fatalf("Expected one policy with one target, got %+v", reported)
+	}
+	target := reported[0].Targets[0]
+	if target.TargetRef.Namespace != "default" {
+		t.Errorf("Expected target namespace to default to the policy namespace, got %q", target.TargetRef.Namespace)
+	}
+	if target.SectionName != "http" || target.Enforced || target.Message != "upstream unavailable" {
+		t.Errorf("Unexpected target status: %+v", target)
+	}
+
+	// reporting the same status again is not a change
+	if _, err := svc.ReportPolicyStatus(context.Background(), request); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if notifications != 1 {
+		t.Errorf("Expected unchanged status not to notify, got %d notifications", notifications)
+	}
+
+	request.Targets[0].Enforced = true
+	request.Targets[0].Message = ""
+	if _, err := svc.ReportPolicyStatus(context.Background(), request); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if notifications != 2 {
+		t.Errorf("Expected changed status to notify, got %d notifications", notifications)
+	}
+}
+
+func TestReportPolicyStatus_ClearedWithPolicy(t *testing.T) {
+	svc := newTestExtensionService()
+
+	notifications := 0
+	svc.changeNotifier = func(reason string) error {
+		notifications++
+		return nil
+	}
+
+	policy := testPolicy("DemoPolicy", "default", "demo")
+	_, err := svc.ReportPolicyStatus(context.Background(), &extpb.ReportPolicyStatusRequest{
+		Policy: policy,
+		Targets: []*extpb.TargetStatus{
+			{TargetRef: testTargetRef("gateway.networking.k8s.io", "Gateway", "my-gateway", "default"), Enforced: true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, err := svc.ClearPolicy(context.Background(), &extpb.ClearPolicyRequest{Policy: policy}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(svc.registeredData.GetReportedPolicies()) != 0 {
+		t.Error("Expected status to be cleared with the policy")
+	}
+	if notifications != 2 {
+		t.Errorf("Expected clearing a reported status to notify, got %d notifications", notifications)
+	}
+}
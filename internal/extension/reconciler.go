@@ -3,6 +3,7 @@ package extension
 import (
 	"context"
 	"reflect"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -56,6 +57,25 @@ func (ngp *nilGuardedPointer[T]) newUpdateChannel() chan T {
 	return channel
 }
 
+// removeUpdateChannel stops the updates to a channel returned by newUpdateChannel. The updates sent in the
+// meantime are drained, so set does not block on a channel no longer read.
+func (ngp *nilGuardedPointer[T]) removeUpdateChannel(channel chan T) {
+	removed := make(chan struct{})
+	go func() {
+		ngp.mu.Lock()
+		defer ngp.mu.Unlock()
+		ngp.updates = slices.DeleteFunc(ngp.updates, func(update chan T) bool { return update == channel })
+		close(removed)
+	}()
+	for {
+		select {
+		case <-channel:
+		case <-removed:
+			return
+		}
+	}
+}
+
 // get returns the current value of the pointer without blocking.
 //
 //lint:ignore U1000
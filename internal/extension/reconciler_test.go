@@ -585,6 +585,32 @@ func TestNilGuardedPointer(t *testing.T) {
 		}
 	})
 
+	t.Run("removed update channel", func(t *testing.T) {
+		ptr := newNilGuardedPointer[string]()
+
+		ch := ptr.newUpdateChannel()
+		ptr.set("first")
+
+		setDone := make(chan struct{})
+		go func() {
+			ptr.set("second")
+			close(setDone)
+		}()
+		ptr.removeUpdateChannel(ch)
+
+		select {
+		case <-setDone:
+		case <-time.After(time.Second):
+			t.Fatal("set blocked on a removed update channel")
+		}
+
+		// no longer updated
+		ptr.set("third")
+		if len(ptr.updates) != 0 {
+			t.Errorf("expected no update channels, got %d", len(ptr.updates))
+		}
+	})
+
 	t.Run("multiple sets and updates", func(t *testing.T) {
 		ptr := newNilGuardedPointer[string]()
 
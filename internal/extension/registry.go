@@ -18,6 +18,7 @@ package extension
 
 import (
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"maps"
 	"regexp"
@@ -200,7 +201,7 @@ func (r *MutatorRegistry) hasRelevantPipelineActions(targetRefs []machinery.Poli
 		policyIDs = append(policyIDs, m.store.GetPoliciesWithPipelineActionsForTargetRefs(targetRefs)...)
 		policyIDs = lo.Uniq(policyIDs)
 		for _, policyID := range policyIDs {
-			for _, phase := range []PipelinePhase{PipelinePhaseRequest, PipelinePhaseResponse} {
+			for _, phase := range pipelinePhases {
 				if actions := m.store.GetPipelineActions(policyID, phase); len(actions) > 0 {
 					return true
 				}
@@ -367,20 +368,66 @@ type TargetRef struct {
 	Namespace string
 }
 
-// PipelinePhase identifies whether actions run in the request or response phase.
+// locator returns the target's locator in the same format as createLocatorFromProtobuf
+func (t TargetRef) locator() string {
+	return createLocatorFromProtobuf(&extpb.TargetRef{
+		Group:     t.Group,
+		Kind:      t.Kind,
+		Name:      t.Name,
+		Namespace: t.Namespace,
+	})
+}
+
+// RegisteredFunctionKey identifies a CEL function registered by a policy.
+type RegisteredFunctionKey struct {
+	Policy ResourceID
+	Name   string
+}
+
+// RegisteredFunctionEntry describes a CEL function backed by one of the
+// policy's registered action methods.
+type RegisteredFunctionEntry struct {
+	Method      string
+	ResultField string
+	ResultType  extpb.FunctionType
+}
+
+// PolicyTargetStatus is the enforcement of an extension policy on one of its
+// targets, as reported by the extension.
+type PolicyTargetStatus struct {
+	TargetRef   TargetRef
+	SectionName string
+	Enforced    bool
+	Message     string
+}
+
+// ReportedPolicy is an extension policy together with its reported status.
+type ReportedPolicy struct {
+	Policy  ResourceID
+	Targets []PolicyTargetStatus
+}
+
+// PipelinePhase identifies whether actions run in the access, request or response phase.
+// The actions of the access phase are denials evaluated before any other action of the
+// data plane, e.g. auth and rate limiting, whereas the actions of the request phase run
+// after them.
 type PipelinePhase string
 
 const (
+	PipelinePhaseAccess   PipelinePhase = "access"
 	PipelinePhaseRequest  PipelinePhase = "request"
 	PipelinePhaseResponse PipelinePhase = "response"
 )
 
+// pipelinePhases are the phases of a pipeline, in order
+var pipelinePhases = []PipelinePhase{PipelinePhaseAccess, PipelinePhaseRequest, PipelinePhaseResponse}
+
 // PipelineActionEntry represents a single stored pipeline action.
 type PipelineActionEntry struct {
 	Index        int
 	ActionType   extpb.ActionType
 	Predicate    string
-	Phase        string // "request" or "response"
+	Phase        string // "access", "request" or "response"
 	Method       string // registered action method name (grpc_method)
 	Var          string // variable name for gRPC response (grpc_method)
 	WithStatus   int    // HTTP status code (deny); 0 means unset
@@ -411,6 +458,12 @@ type RegisteredDataStore struct {
 	pipelineCounters   map[pipelineKey]int
 	pipelineTargetRefs map[ResourceID][]TargetRef
 	pipelineMutex      sync.RWMutex
+
+	functions      map[RegisteredFunctionKey]RegisteredFunctionEntry
+	functionsMutex sync.RWMutex
+
+	policyStatuses      map[ResourceID][]PolicyTargetStatus
+	policyStatusesMutex sync.RWMutex
 }
 
 func NewRegisteredDataStore() *RegisteredDataStore {
@@ -422,6 +475,8 @@ func NewRegisteredDataStore() *RegisteredDataStore {
 		pipelineActions:     make(map[pipelineKey][]PipelineActionEntry),
 		pipelineCounters:    make(map[pipelineKey]int),
 		pipelineTargetRefs:  make(map[ResourceID][]TargetRef),
+		functions:           make(map[RegisteredFunctionKey]RegisteredFunctionEntry),
+		policyStatuses:      make(map[ResourceID][]PolicyTargetStatus),
 	}
 }
 
@@ -789,15 +844,15 @@ func (r *RegisteredDataStore) ClearPipelinePhase(policy ResourceID, phase Pipeli
 // Returns an error if any entry has an invalid phase.
 func (r *RegisteredDataStore) ReplacePipelineActions(policy ResourceID, entries []PipelineActionEntry) error {
 	for i, entry := range entries {
-		if entry.Phase != string(PipelinePhaseRequest) && entry.Phase != string(PipelinePhaseResponse) {
-			return fmt.Errorf("entries[%d]: invalid phase %q, must be %q or %q", i, entry.Phase, PipelinePhaseRequest, PipelinePhaseResponse)
+		if !slices.Contains(pipelinePhases, PipelinePhase(entry.Phase)) {
+			return fmt.Errorf("entries[%d]: invalid phase %q, must be %q, %q or %q", i, entry.Phase, PipelinePhaseAccess, PipelinePhaseRequest, PipelinePhaseResponse)
 		}
 	}
 
 	r.pipelineMutex.Lock()
 	defer r.pipelineMutex.Unlock()
 
-	for _, phase := range []PipelinePhase{PipelinePhaseRequest, PipelinePhaseResponse} {
+	for _, phase := range pipelinePhases {
 		key := pipelineKey{Policy: policy, Phase: phase}
 		delete(r.pipelineActions, key)
 		delete(r.pipelineCounters, key)
@@ -821,14 +876,14 @@ func (r *RegisteredDataStore) ReplacePipelineActions(policy ResourceID, entries
 	return nil
 }
 
-// ClearPipelineActions removes all pipeline actions for a policy across both phases
+// ClearPipelineActions removes all pipeline actions for a policy across all phases
 // and resets the index counters. Returns the number of actions cleared.
 func (r *RegisteredDataStore) ClearPipelineActions(policy ResourceID) int {
 	r.pipelineMutex.Lock()
 	defer r.pipelineMutex.Unlock()
 
 	cleared := 0
-	for _, phase := range []PipelinePhase{PipelinePhaseRequest, PipelinePhaseResponse} {
+	for _, phase := range pipelinePhases {
 		key := pipelineKey{Policy: policy, Phase: phase}
 		cleared += len(r.pipelineActions[key])
 		delete(r.pipelineActions, key)
@@ -920,15 +975,138 @@ func pipelineTargetRefsMatch(storedRefs []TargetRef, targetRefs []machinery.Poli
 	return false
 }
 
+// SetFunction stores a CEL function registered by a policy, replacing any
+// previous registration of the same name by that policy.
+func (r *RegisteredDataStore) SetFunction(key RegisteredFunctionKey, entry RegisteredFunctionEntry) {
+	r.functionsMutex.Lock()
+	defer r.functionsMutex.Unlock()
+	r.functions[key] = entry
+}
+
+func (r *RegisteredDataStore) GetFunction(key RegisteredFunctionKey) (RegisteredFunctionEntry, bool) {
+	r.functionsMutex.RLock()
+	defer r.functionsMutex.RUnlock()
+	entry, exists := r.functions[key]
+	return entry, exists
+}
+
+// GetFunctionsForTargetRef returns the functions whose backing action method
+// targets the given locator, deduplicated by function name. When several policies
+// register the same name for a target, the first policy in kind/namespace/name
+// order wins.
+func (r *RegisteredDataStore) GetFunctionsForTargetRef(targetRefLocator string) []wasm.FunctionBinding {
+	r.functionsMutex.RLock()
+	keys := lo.Keys(r.functions)
+	entries := maps.Clone(r.functions)
+	r.functionsMutex.RUnlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Name != keys[j].Name {
+			return keys[i].Name < keys[j].Name
+		}
+		if keys[i].Policy.Kind != keys[j].Policy.Kind {
+			return keys[i].Policy.Kind < keys[j].Policy.Kind
+		}
+		if keys[i].Policy.Namespace != keys[j].Policy.Namespace {
+			return keys[i].Policy.Namespace < keys[j].Policy.Namespace
+		}
+		return keys[i].Policy.Name < keys[j].Policy.Name
+	})
+
+	var result []wasm.FunctionBinding
+	seen := make(map[string]struct{})
+	for _, key := range keys {
+		if _, exists := seen[key.Name]; exists {
+			continue
+		}
+		entry := entries[key]
+		_, upstream, found := r.GetUpstreamByName(key.Policy, entry.Method)
+		if !found || upstream.TargetRef.locator() != targetRefLocator {
+			continue
+		}
+		seen[key.Name] = struct{}{}
+		result = append(result, wasm.FunctionBinding{
+			Name:           key.Name,
+			ResultType:     functionResultTypeName(entry.ResultType),
+			ServiceName:    upstreamWasmServiceKey(upstream),
+			MessageBuilder: upstream.MessageTemplate,
+			ResultField:    entry.ResultField,
+			Sources:        []string{fmt.Sprintf("%s/%s/%s", key.Policy.Kind, key.Policy.Namespace, key.Policy.Name)},
+		})
+	}
+	return result
+}
+
+// SetPolicyStatus replaces the reported status of a policy. It returns whether
+// the status differs from the one previously reported.
+func (r *RegisteredDataStore) SetPolicyStatus(policy ResourceID, targets []PolicyTargetStatus) bool {
+	r.policyStatusesMutex.Lock()
+	defer r.policyStatusesMutex.Unlock()
+	previous, exists := r.policyStatuses[policy]
+	r.policyStatuses[policy] = slices.Clone(targets)
+	return !exists || !slices.Equal(previous, targets)
+}
+
+func (r *RegisteredDataStore) HasPolicyStatus(policy ResourceID) bool {
+	r.policyStatusesMutex.RLock()
+	defer r.policyStatusesMutex.RUnlock()
+	_, exists := r.policyStatuses[policy]
+	return exists
+}
+
+// GetReportedPolicies returns all policies with a reported status, ordered by
+// kind, namespace and name.
+func (r *RegisteredDataStore) GetReportedPolicies() []ReportedPolicy {
+	r.policyStatusesMutex.RLock()
+	defer r.policyStatusesMutex.RUnlock()
+
+	policies := make([]ReportedPolicy, 0, len(r.policyStatuses))
+	for policy, targets := range r.policyStatuses {
+		policies = append(policies, ReportedPolicy{Policy: policy, Targets: slices.Clone(targets)})
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		a, b := policies[i].Policy, policies[j].Policy
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return policies
+}
+
+func functionResultTypeName(t extpb.FunctionType) string {
+	switch t {
+	case extpb.FunctionType_FUNCTION_TYPE_BOOL:
+		return "bool"
+	case extpb.FunctionType_FUNCTION_TYPE_INT:
+		return "int"
+	case extpb.FunctionType_FUNCTION_TYPE_UINT:
+		return "uint"
+	case extpb.FunctionType_FUNCTION_TYPE_DOUBLE:
+		return "double"
+	case extpb.FunctionType_FUNCTION_TYPE_STRING:
+		return "string"
+	default:
+		return "dyn"
+	}
+}
+
 func (r *RegisteredDataStore) ClearPolicyData(policy ResourceID) (clearedMutators int, clearedSubscriptions int, clearedUpstreams int, clearedPipelineActions int) {
 	r.dataMutex.Lock()
 	r.subsMutex.Lock()
 	r.upstreamsMutex.Lock()
 	r.pipelineMutex.Lock()
+	r.functionsMutex.Lock()
+	r.policyStatusesMutex.Lock()
 	defer r.dataMutex.Unlock()
 	defer r.subsMutex.Unlock()
 	defer r.upstreamsMutex.Unlock()
 	defer r.pipelineMutex.Unlock()
+	defer r.functionsMutex.Unlock()
+	defer r.policyStatusesMutex.Unlock()
 
 	// clear data providers
 	for key := range r.dataProviders {
@@ -982,7 +1160,7 @@ func (r *RegisteredDataStore) ClearPolicyData(policy ResourceID) (clearedMutator
 	}
 
 	// clear pipeline actions and target refs (lock already held)
-	for _, phase := range []PipelinePhase{PipelinePhaseRequest, PipelinePhaseResponse} {
+	for _, phase := range pipelinePhases {
 		key := pipelineKey{Policy: policy, Phase: phase}
 		clearedPipelineActions += len(r.pipelineActions[key])
 		delete(r.pipelineActions, key)
@@ -990,9 +1168,31 @@ func (r *RegisteredDataStore) ClearPolicyData(policy ResourceID) (clearedMutator
 	}
 	delete(r.pipelineTargetRefs, policy)
 
+	// functions are backed by the policy's upstreams, so they go with them
+	for key := range r.functions {
+		if key.Policy == policy {
+			delete(r.functions, key)
+		}
+	}
+
+	delete(r.policyStatuses, policy)
+
 	return clearedMutators, clearedSubscriptions, clearedUpstreams, clearedPipelineActions
 }
 
+func (r *RegisteredDataStore) GetPolicyDataProviders(policy ResourceID) map[DataProviderKey]DataProviderEntry {
+	r.dataMutex.RLock()
+	defer r.dataMutex.RUnlock()
+
+	dataProviders := make(map[DataProviderKey]DataProviderEntry)
+	for key, entry := range r.dataProviders {
+		if key.Policy == policy {
+			dataProviders[key] = entry
+		}
+	}
+	return dataProviders
+}
+
 func (r *RegisteredDataStore) GetPolicySubscriptions(policy ResourceID) []SubscriptionKey {
 	r.subsMutex.RLock()
 	defer r.subsMutex.RUnlock()
@@ -1081,15 +1281,7 @@ func (m *RegisteredDataMutator[TResource]) mutateWasmConfig(wasmConfig *wasm.Con
 	// Build method name → wasm service key lookup and inject services
 	methodServiceKeys := make(map[ResourceID]map[string]string) // policy → method name → service key
 	for key, entry := range relevantUpstreamKeys {
-		timeout := entry.Timeout
-		svc := wasm.Service{
-			Endpoint:    entry.ClusterName,
-			Type:        wasm.DynamicServiceType,
-			FailureMode: wasm.FailureModeType(entry.FailureMode),
-			Timeout:     &timeout,
-			GrpcService: &entry.Service,
-			GrpcMethod:  &entry.Method,
-		}
+		svc := upstreamWasmService(entry)
 		wasmServiceKey := "ext-" + HashUpstreamServiceConfig(svc)
 		wasmConfig.Services[wasmServiceKey] = svc
 
@@ -1151,7 +1343,11 @@ func (m *RegisteredDataMutator[TResource]) mutateWasmConfig(wasmConfig *wasm.Con
 			requestEntries, responseEntries,
 			methods, upstreamByMethod[policyID], sources,
 		)
-		if len(pipelineActions) == 0 {
+		// the denials of the access phase are evaluated before any other action of the action set
+		accessActions := lo.Map(m.store.GetPipelineActions(policyID, PipelinePhaseAccess), func(e PipelineActionEntry, _ int) wasm.Action {
+			return entryToAction(e, sources, string(PipelinePhaseAccess))
+		})
+		if len(pipelineActions) == 0 && len(accessActions) == 0 {
 			continue
 		}
 
@@ -1161,7 +1357,7 @@ func (m *RegisteredDataMutator[TResource]) mutateWasmConfig(wasmConfig *wasm.Con
 			if len(routeLocators) > 0 && asRoute != "" && !slices.Contains(routeLocators, asRoute) {
 				continue
 			}
-			wasmConfig.ActionSets[i].Actions = append(wasmConfig.ActionSets[i].Actions, pipelineActions...)
+			wasmConfig.ActionSets[i].Actions = append(append(slices.Clone(accessActions), wasmConfig.ActionSets[i].Actions...), pipelineActions...)
 		}
 	}
 
@@ -1317,6 +1513,25 @@ func grpcToAction(entry PipelineActionEntry, methods map[string]string, upstream
 		WithSources(sources)
 }
 
+// upstreamWasmService builds the wasm service calling a registered upstream.
+func upstreamWasmService(entry RegisteredUpstreamEntry) wasm.Service {
+	timeout := entry.Timeout
+	return wasm.Service{
+		Endpoint:    entry.ClusterName,
+		Type:        wasm.DynamicServiceType,
+		FailureMode: wasm.FailureModeType(entry.FailureMode),
+		Timeout:     &timeout,
+		GrpcService: &entry.Service,
+		GrpcMethod:  &entry.Method,
+	}
+}
+
+// upstreamWasmServiceKey returns the key under which the upstream's service is
+// injected into the wasm config.
+func upstreamWasmServiceKey(entry RegisteredUpstreamEntry) string {
+	return "ext-" + HashUpstreamServiceConfig(upstreamWasmService(entry))
+}
+
 // HashUpstreamServiceConfig produces a deterministic short hash from a wasm.Service
 // config. Identical configurations produce the same hash, providing natural deduplication.
 func HashUpstreamServiceConfig(svc wasm.Service) string {
@@ -1389,8 +1604,59 @@ func GetRegisteredUpstreamsByTargetRef(targetRef TargetRef) []RegisteredUpstream
 	return result
 }
 
+// GetFunctionBindings returns the extension provided CEL functions from all
+// registered wasm config mutators for the given target ref locators. The
+// locators are in order of precedence, the most specific target (i.e. the
+// route) first: a function registered for several of them is taken from the
+// first one.
+func GetFunctionBindings(targetRefLocators []string) []wasm.FunctionBinding {
+	GlobalMutatorRegistry.mutex.RLock()
+	defer GlobalMutatorRegistry.mutex.RUnlock()
+
+	seen := make(map[string]struct{})
+	var functions []wasm.FunctionBinding
+
+	for _, mutator := range GlobalMutatorRegistry.wasmConfigMutators {
+		m, ok := mutator.(*RegisteredDataMutator[*wasm.Config])
+		if !ok {
+			continue
+		}
+		for _, locator := range targetRefLocators {
+			for _, fn := range m.store.GetFunctionsForTargetRef(locator) {
+				if _, exists := seen[fn.Name]; exists {
+					continue
+				}
+				seen[fn.Name] = struct{}{}
+				functions = append(functions, fn)
+			}
+		}
+	}
+
+	return functions
+}
+
+// GetReportedPolicies returns the extension policies whose status was reported
+// through the extension service.
+func GetReportedPolicies() []ReportedPolicy {
+	GlobalMutatorRegistry.mutex.RLock()
+	defer GlobalMutatorRegistry.mutex.RUnlock()
+
+	var policies []ReportedPolicy
+	for _, mutator := range GlobalMutatorRegistry.wasmConfigMutators {
+		m, ok := mutator.(*RegisteredDataMutator[*wasm.Config])
+		if !ok {
+			continue
+		}
+		policies = append(policies, m.store.GetReportedPolicies()...)
+	}
+	return policies
+}
+
 // GetRequestBindings returns DOMAIN_REQUEST bindings from all registered wasm
 // config mutators for the given target ref locators, converted to DataBinding.
+// The locators are in order of precedence, the most specific target (i.e. the
+// route) first: a binding registered for several of them is taken from the
+// first one.
 func GetRequestBindings(targetRefLocators []string) []wasm.DataBinding {
 	GlobalMutatorRegistry.mutex.RLock()
 	defer GlobalMutatorRegistry.mutex.RUnlock()
@@ -1421,3 +1687,24 @@ func GetRequestBindings(targetRefLocators []string) []wasm.DataBinding {
 
 	return bindings
 }
+
+// GetRequestBindingsAndMetrics returns the DOMAIN_REQUEST bindings for the
+// given target ref locators, in order of precedence as for GetRequestBindings,
+// with the custom metrics split off. Unlike the other bindings, which override
+// each other one by one, a custom metric is resolved as a whole from the first
+// target defining it.
+func GetRequestBindingsAndMetrics(targetRefLocators []string) ([]wasm.DataBinding, []wasm.Metric, error) {
+	others, _, _ := wasm.MetricsFromBindings(GetRequestBindings(targetRefLocators))
+
+	var errs []error
+	metricsByPrecedence := make([][]wasm.Metric, 0, len(targetRefLocators))
+	for _, locator := range targetRefLocators {
+		_, metrics, err := wasm.MetricsFromBindings(GetRequestBindings([]string{locator}))
+		if err != nil {
+			errs = append(errs, err)
+		}
+		metricsByPrecedence = append(metricsByPrecedence, metrics)
+	}
+
+	return others, wasm.MergeMetrics(metricsByPrecedence...), errors.Join(errs...)
+}
@@ -2787,6 +2787,52 @@ func TestMutateWasmConfig_DenyOnlyPipelineProducesRootAction(t *testing.T) {
 	}
 }
 
+func TestMutateWasmConfig_AccessPhaseDeniesFirst(t *testing.T) {
+	store := NewRegisteredDataStore()
+	policyID := testResourceID("IPPolicy", "default", "blocklist")
+	routeRef := TargetRef{Group: "gateway.networking.k8s.io", Kind: "HTTPRoute", Name: "test-route", Namespace: "test-namespace"}
+
+	store.AppendPipelineActions(policyID, PipelinePhaseAccess, []PipelineActionEntry{
+		{ActionType: extpb.ActionType_ACTION_TYPE_DENY, Predicate: `source.address == "192.0.2.1:1234"`, WithStatus: 403},
+	})
+	store.AppendPipelineActions(policyID, PipelinePhaseRequest, []PipelineActionEntry{
+		{ActionType: extpb.ActionType_ACTION_TYPE_DENY, Predicate: `request.url_path == "/admin"`, WithStatus: 404},
+	})
+	store.SetPipelineTargetRefs(policyID, []TargetRef{routeRef})
+
+	// the action of a built-in policy, e.g. of an AuthPolicy
+	builtinAction := &wasm.DenyAction{
+		ActionBase: wasm.ActionBase{Predicate: "true", Terminal: true},
+		DenyWith:   "DenyResponse{status: 401u}",
+	}
+	wasmConfig := wasm.Config{
+		ActionSets: []wasm.ActionSet{{
+			Name:                "access-test",
+			RouteRuleConditions: wasm.RouteRuleConditions{Hostnames: []string{"example.com"}},
+			Actions:             []wasm.Action{builtinAction},
+		}},
+	}
+
+	mutator := NewRegisteredDataMutator[*wasm.Config](store)
+	if err := mutator.Mutate(&wasmConfig, []machinery.PolicyTargetReference{createMockHTTPRouteTargetRef()}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	actions := wasmConfig.ActionSets[0].Actions
+	if len(actions) != 3 {
+		t.Fatalf("Expected 3 actions, got %d", len(actions))
+	}
+	if deny, ok := actions[0].(*wasm.DenyAction); !ok || deny.DenyWith != "DenyResponse{status: 403u}" {
+		t.Errorf("Expected the access denial first, got %+v", actions[0])
+	}
+	if actions[1] != builtinAction {
+		t.Errorf("Expected the built-in action second, got %+v", actions[1])
+	}
+	if deny, ok := actions[2].(*wasm.DenyAction); !ok || deny.DenyWith != "DenyResponse{status: 404u}" {
+		t.Errorf("Expected the request denial last, got %+v", actions[2])
+	}
+}
+
 func TestMutateWasmConfig_CrossGatewayIsolation(t *testing.T) {
 	store := NewRegisteredDataStore()
 	policyID := testResourceID("ThreatPolicy", "test-ns", "my-threat")
@@ -3006,3 +3052,98 @@ func TestPipelineTargetRefCleanup(t *testing.T) {
 		t.Fatalf("Expected nil target refs after ClearPolicyData, got %v", got)
 	}
 }
+
+func TestGetFunctionBindings(t *testing.T) {
+	store := NewRegisteredDataStore()
+	policyID := ResourceID{Kind: "ThreatPolicy", Namespace: "test-namespace", Name: "threat"}
+	gatewayTargetRef := TargetRef{Group: "gateway.networking.k8s.io", Kind: "Gateway", Name: "test-gateway", Namespace: "test-namespace"}
+
+	upstream := RegisteredUpstreamEntry{ClusterName: "ext-svc-8081", Host: "svc", Port: 8081, TargetRef: gatewayTargetRef, FailureMode: "deny", Timeout: "100ms", Service: "threat.Service", Method: "Check", MessageTemplate: "threat.CheckRequest{}"}
+	store.SetUpstream(
+		RegisteredUpstreamKey{Policy: policyID, Name: "assess-threat", URL: "grpc://svc:8081", Service: "threat.Service", Method: "Check"},
+		upstream,
+		testFileDescriptorSet(),
+	)
+	store.SetFunction(RegisteredFunctionKey{Policy: policyID, Name: "threat.score"}, RegisteredFunctionEntry{
+		Method:      "assess-threat",
+		ResultField: "threat_level",
+		ResultType:  extpb.FunctionType_FUNCTION_TYPE_INT,
+	})
+	// function backed by a method that was never registered is ignored
+	store.SetFunction(RegisteredFunctionKey{Policy: policyID, Name: "threat.orphan"}, RegisteredFunctionEntry{
+		Method:     "missing",
+		ResultType: extpb.FunctionType_FUNCTION_TYPE_BOOL,
+	})
+
+	savedRegistry := GlobalMutatorRegistry
+	defer func() { GlobalMutatorRegistry = savedRegistry }()
+	GlobalMutatorRegistry = &MutatorRegistry{}
+	GlobalMutatorRegistry.RegisterWasmConfigMutator(NewRegisteredDataMutator[*wasm.Config](store))
+
+	if functions := GetFunctionBindings([]string{"gateway.gateway.networking.k8s.io:test-namespace/other-gateway"}); len(functions) != 0 {
+		t.Fatalf("expected no functions for unrelated target, got %v", functions)
+	}
+
+	functions := GetFunctionBindings([]string{"gateway.gateway.networking.k8s.io:test-namespace/test-gateway"})
+	if len(functions) != 1 {
+		t.Fatalf("expected 1 function, got %d: %v", len(functions), functions)
+	}
+	fn := functions[0]
+	if fn.Name != "threat.score" || fn.ResultType != "int" || fn.ResultField != "threat_level" {
+		t.Errorf("unexpected function binding: %+v", fn)
+	}
+	if fn.ServiceName != upstreamWasmServiceKey(upstream) {
+		t.Errorf("expected service %q, got %q", upstreamWasmServiceKey(upstream), fn.ServiceName)
+	}
+	if fn.MessageBuilder != "threat.CheckRequest{}" {
+		t.Errorf("expected message builder from upstream, got %q", fn.MessageBuilder)
+	}
+	if len(fn.Sources) != 1 || fn.Sources[0] != "ThreatPolicy/test-namespace/threat" {
+		t.Errorf("unexpected sources: %v", fn.Sources)
+	}
+}
+
+func TestGetRequestBindingsAndMetrics(t *testing.T) {
+	store := NewRegisteredDataStore()
+	policyID := ResourceID{Kind: "TelemetryPolicy", Namespace: "test-namespace", Name: "telemetry"}
+	gatewayLocator := "gateway.gateway.networking.k8s.io:test-namespace/test-gateway"
+	routeLocator := "httproute.gateway.networking.k8s.io:test-namespace/test-route"
+
+	set := func(locator, binding, expression string) {
+		store.Set(policyID, locator, extpb.Domain_DOMAIN_REQUEST, binding, DataProviderEntry{Policy: policyID, Binding: binding, Expression: expression})
+	}
+	set(gatewayLocator, "metrics.labels.model", "requestBodyJSON('/model')")
+	set(gatewayLocator, "metrics.custom.requests.value", "1")
+	set(gatewayLocator, "metrics.custom.tokens.type", `"histogram"`)
+	set(gatewayLocator, "metrics.custom.tokens.value", "1")
+	set(gatewayLocator, "metrics.custom.tokens.buckets", "[10]")
+	set(gatewayLocator, "metrics.custom.tokens.labels.user", "auth.identity.sub")
+	set(routeLocator, "metrics.labels.model", "responseBodyJSON('/model')")
+	set(routeLocator, "metrics.custom.tokens.value", "responseBodyJSON('/usage/total_tokens')")
+
+	savedRegistry := GlobalMutatorRegistry
+	defer func() { GlobalMutatorRegistry = savedRegistry }()
+	GlobalMutatorRegistry = &MutatorRegistry{}
+	GlobalMutatorRegistry.RegisterWasmConfigMutator(NewRegisteredDataMutator[*wasm.Config](store))
+
+	bindings, metrics, err := GetRequestBindingsAndMetrics([]string{routeLocator, gatewayLocator})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bindings) != 1 || bindings[0].Expression != "responseBodyJSON('/model')" {
+		t.Errorf("expected the binding of the route to override the one of the gateway, got %v", bindings)
+	}
+	expected := []wasm.Metric{
+		{Name: "requests", Type: wasm.MetricTypeCounter, Value: "1"},
+		// the metric of the route overrides the one of the gateway as a whole
+		{Name: "tokens", Type: wasm.MetricTypeCounter, Value: "responseBodyJSON('/usage/total_tokens')"},
+	}
+	if len(metrics) != len(expected) {
+		t.Fatalf("expected %d metrics, got %v", len(expected), metrics)
+	}
+	for i := range expected {
+		if !metrics[i].EqualTo(expected[i]) {
+			t.Errorf("expected metric %+v, got %+v", expected[i], metrics[i])
+		}
+	}
+}
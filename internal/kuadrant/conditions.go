@@ -57,7 +57,8 @@ func AcceptedCondition(p Policy, err error) *metav1.Condition {
 	return cond
 }
 
-// EnforcedCondition returns an enforced conditions with common reasons for a kuadrant policy
+// EnforcedCondition returns an enforced conditions with common reasons for a kuadrant policy, observed at the current
+// generation of the policy
 func EnforcedCondition(policy Policy, err PolicyError, fully bool) *metav1.Condition {
 	// Enforced
 	message := fmt.Sprintf("%s has been successfully enforced", policy.Kind())
@@ -65,10 +66,11 @@ func EnforcedCondition(policy Policy, err PolicyError, fully bool) *metav1.Condi
 		message = fmt.Sprintf("%s has been partially enforced", policy.Kind())
 	}
 	cond := &metav1.Condition{
-		Type:    string(PolicyConditionEnforced),
-		Status:  metav1.ConditionTrue,
-		Reason:  string(PolicyReasonEnforced),
-		Message: message,
+		Type:               string(PolicyConditionEnforced),
+		Status:             metav1.ConditionTrue,
+		Reason:             string(PolicyReasonEnforced),
+		Message:            message,
+		ObservedGeneration: policy.GetGeneration(),
 	}
 	if err == nil {
 		return cond
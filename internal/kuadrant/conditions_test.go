@@ -231,7 +231,7 @@ func TestEnforcedCondition(t *testing.T) {
 		policy Policy
 		err    PolicyError
 	}
-	policy := &FakePolicy{}
+	policy := &FakePolicy{Object: &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Generation: 2}}}
 	tests := []struct {
 		name string
 		args args
@@ -240,39 +240,42 @@ func TestEnforcedCondition(t *testing.T) {
 		{
 			name: "enforced true",
 			args: args{
-				policy: &FakePolicy{},
+				policy: policy,
 			},
 			want: &metav1.Condition{
-				Type:    string(PolicyConditionEnforced),
-				Status:  metav1.ConditionTrue,
-				Reason:  string(PolicyReasonEnforced),
-				Message: "FakePolicy has been successfully enforced",
+				Type:               string(PolicyConditionEnforced),
+				Status:             metav1.ConditionTrue,
+				Reason:             string(PolicyReasonEnforced),
+				Message:            "FakePolicy has been successfully enforced",
+				ObservedGeneration: 2,
 			},
 		},
 		{
 			name: "enforced false - unknown",
 			args: args{
-				policy: &FakePolicy{},
+				policy: policy,
 				err:    NewErrUnknown(policy.Kind(), errors.New("unknown err")),
 			},
 			want: &metav1.Condition{
-				Type:    string(PolicyConditionEnforced),
-				Status:  metav1.ConditionFalse,
-				Reason:  string(PolicyReasonUnknown),
-				Message: "FakePolicy has encountered some issues: unknown err",
+				Type:               string(PolicyConditionEnforced),
+				Status:             metav1.ConditionFalse,
+				Reason:             string(PolicyReasonUnknown),
+				Message:            "FakePolicy has encountered some issues: unknown err",
+				ObservedGeneration: 2,
 			},
 		},
 		{
 			name: "enforced false - overridden",
 			args: args{
-				policy: &FakePolicy{},
+				policy: policy,
 				err:    NewErrOverridden(policy.Kind(), []client.ObjectKey{{Namespace: "ns1", Name: "policy1"}, {Namespace: "ns2", Name: "policy2"}}),
 			},
 			want: &metav1.Condition{
-				Type:    string(PolicyConditionEnforced),
-				Status:  metav1.ConditionFalse,
-				Reason:  string(PolicyReasonOverridden),
-				Message: "FakePolicy is overridden by [ns1/policy1 ns2/policy2]",
+				Type:               string(PolicyConditionEnforced),
+				Status:             metav1.ConditionFalse,
+				Reason:             string(PolicyReasonOverridden),
+				Message:            "FakePolicy is overridden by [ns1/policy1 ns2/policy2]",
+				ObservedGeneration: 2,
 			},
 		},
 	}
@@ -7,6 +7,7 @@ import (
 const (
 	ControllerName               = "kuadrant.io/policy-controller"
 	TopologyLabel                = "kuadrant.io/topology"
+	TopologyChunkLabel           = "kuadrant.io/topology-chunk"
 	ObservabilityLabel           = "kuadrant.io/observability"
 	DeveloperPortalLabel         = "kuadrant.io/developerportal"
 	KuadrantRateLimitClusterName = "kuadrant-ratelimit-service"
@@ -31,14 +31,25 @@ type Config struct {
 	// This can be any type that implements prometheus.Gatherer interface
 	// (e.g., prometheus.Registry, controller-runtime's metrics.Registry)
 	PrometheusGatherer prometheus.Gatherer
+	// PrometheusRegisterer is the Prometheus registerer the metrics emitted through
+	// the OpenTelemetry meter provider are exposed with. No Prometheus exporter is
+	// set up if nil.
+	PrometheusRegisterer prometheus.Registerer
 }
 
 // NewConfig creates metrics configuration from environment variables
-func NewConfig(gatherer prometheus.Gatherer) *Config {
+func NewConfig(registry RegistererGatherer) *Config {
 	intervalSeconds, _ := env.GetInt("OTEL_METRICS_INTERVAL_SECONDS", 15)
 
 	return &Config{
-		ExportInterval:     time.Duration(intervalSeconds) * time.Second,
-		PrometheusGatherer: gatherer,
+		ExportInterval:       time.Duration(intervalSeconds) * time.Second,
+		PrometheusGatherer:   registry,
+		PrometheusRegisterer: registry,
 	}
 }
+
+// RegistererGatherer is a Prometheus registry, such as controller-runtime's metrics.Registry
+type RegistererGatherer interface {
+	prometheus.Registerer
+	prometheus.Gatherer
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// MeterName is the instrumentation scope of the Kuadrant operator metrics
+const MeterName = "github.com/kuadrant/kuadrant-operator"
+
+// nativeMetrics holds the names of the metrics emitted through the OpenTelemetry meter provider.
+// These are exposed to Prometheus by the Prometheus exporter of the meter provider, and excluded
+// from the Prometheus bridge so they are not exported twice via OTLP.
+var nativeMetrics sync.Map
+
+// meter returns the meter of the Kuadrant operator metrics.
+// Instruments created before the global MeterProvider is set are delegated to it once it is set.
+func meter(name string) metric.Meter {
+	nativeMetrics.Store(name, struct{}{})
+	return otel.Meter(MeterName)
+}
+
+// attributeSet returns the attributes of a series from the values of its labels
+func attributeSet(labels, labelValues []string) attribute.Set {
+	if len(labels) != len(labelValues) {
+		panic(fmt.Sprintf("expected %d label values, got %d", len(labels), len(labelValues)))
+	}
+	attrs := make([]attribute.KeyValue, len(labels))
+	for i := range labels {
+		attrs[i] = attribute.String(labels[i], labelValues[i])
+	}
+	return attribute.NewSet(attrs...)
+}
+
+type gaugeSeries struct {
+	attributes attribute.Set
+	value      float64
+}
+
+// GaugeVec is a gauge partitioned by labels, observed by the OpenTelemetry meter provider.
+// Unlike synchronous OpenTelemetry gauges, its series can be deleted, e.g. when the object they describe is deleted.
+type GaugeVec struct {
+	name   string
+	labels []string
+	mu     sync.RWMutex
+	series map[attribute.Distinct]gaugeSeries
+}
+
+// NewGaugeVec creates a gauge with the given labels, observed by the OpenTelemetry meter provider
+func NewGaugeVec(name, description string, labels ...string) *GaugeVec {
+	g := &GaugeVec{
+		name:   name,
+		labels: labels,
+		series: make(map[attribute.Distinct]gaugeSeries),
+	}
+	_, err := meter(name).Float64ObservableGauge(name,
+		metric.WithDescription(description),
+		metric.WithFloat64Callback(g.observe),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+	return g
+}
+
+func (g *GaugeVec) observe(_ context.Context, o metric.Float64Observer) error {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	for _, s := range g.series {
+		o.Observe(s.value, metric.WithAttributeSet(s.attributes))
+	}
+	return nil
+}
+
+// Set sets the value of the series with the given label values
+func (g *GaugeVec) Set(value float64, labelValues ...string) {
+	attrs := attributeSet(g.labels, labelValues)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.series[attrs.Equivalent()] = gaugeSeries{attributes: attrs, value: value}
+}
+
+// Delete removes the series with the given label values
+func (g *GaugeVec) Delete(labelValues ...string) {
+	attrs := attributeSet(g.labels, labelValues)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.series, attrs.Equivalent())
+}
+
+// Reset removes all the series
+func (g *GaugeVec) Reset() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	clear(g.series)
+}
+
+// Value returns the value of the series with the given label values, and whether the series exists
+func (g *GaugeVec) Value(labelValues ...string) (float64, bool) {
+	attrs := attributeSet(g.labels, labelValues)
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	s, found := g.series[attrs.Equivalent()]
+	return s.value, found
+}
+
+// Len returns the number of series
+func (g *GaugeVec) Len() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.series)
+}
+
+// CounterVec is a counter partitioned by labels, emitted through the OpenTelemetry meter provider.
+// Measurements record exemplars of the sampled span of the context, if any.
+type CounterVec struct {
+	labels  []string
+	counter metric.Float64Counter
+}
+
+// NewCounterVec creates a counter with the given labels, emitted through the OpenTelemetry meter provider
+func NewCounterVec(name, description string, labels ...string) *CounterVec {
+	counter, err := meter(name).Float64Counter(name, metric.WithDescription(description))
+	if err != nil {
+		otel.Handle(err)
+	}
+	return &CounterVec{labels: labels, counter: counter}
+}
+
+// Inc increments the series with the given label values
+func (c *CounterVec) Inc(ctx context.Context, labelValues ...string) {
+	c.counter.Add(ctx, 1, metric.WithAttributeSet(attributeSet(c.labels, labelValues)))
+}
+
+// HistogramVec is a histogram partitioned by labels, emitted through the OpenTelemetry meter provider.
+// Measurements record exemplars of the sampled span of the context, if any.
+type HistogramVec struct {
+	labels    []string
+	histogram metric.Float64Histogram
+}
+
+// NewHistogramVec creates a histogram with the given bucket boundaries and labels, emitted through the
+// OpenTelemetry meter provider
+func NewHistogramVec(name, description string, buckets []float64, labels ...string) *HistogramVec {
+	histogram, err := meter(name).Float64Histogram(name,
+		metric.WithDescription(description),
+		metric.WithExplicitBucketBoundaries(buckets...),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+	return &HistogramVec{labels: labels, histogram: histogram}
+}
+
+// Observe records a value in the series with the given label values
+func (h *HistogramVec) Observe(ctx context.Context, value float64, labelValues ...string) {
+	h.histogram.Record(ctx, value, metric.WithAttributeSet(attributeSet(h.labels, labelValues)))
+}
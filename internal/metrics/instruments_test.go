@@ -0,0 +1,198 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/utils/ptr"
+)
+
+// testReader reads the metrics emitted through the global meter provider in the tests
+var testReader = sdkmetric.NewManualReader()
+
+func TestMain(m *testing.M) {
+	// The instruments of the package are delegated to the first global meter provider set
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(testReader)))
+	os.Exit(m.Run())
+}
+
+// collectMetric returns the metric with the given name emitted through the global meter provider
+func collectMetric(t *testing.T, name string) (metricdata.Metrics, bool) {
+	t.Helper()
+	rm := metricdata.ResourceMetrics{}
+	if err := testReader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("failed to collect metrics: %v", err)
+	}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == name {
+				return m, true
+			}
+		}
+	}
+	return metricdata.Metrics{}, false
+}
+
+// collectGauge returns the data points of the gauge with the given name
+func collectGauge(t *testing.T, name string) []metricdata.DataPoint[float64] {
+	t.Helper()
+	m, found := collectMetric(t, name)
+	if !found {
+		return nil
+	}
+	return m.Data.(metricdata.Gauge[float64]).DataPoints
+}
+
+// writeGauge writes the collected series of a gauge with the given label values in the Prometheus data model
+func writeGauge(g *GaugeVec, metric *dto.Metric, labelValues ...string) error {
+	attrs := attributeSet(g.labels, labelValues)
+	rm := metricdata.ResourceMetrics{}
+	if err := testReader.Collect(context.Background(), &rm); err != nil {
+		return err
+	}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != g.name {
+				continue
+			}
+			for _, point := range m.Data.(metricdata.Gauge[float64]).DataPoints {
+				if !point.Attributes.Equals(&attrs) {
+					continue
+				}
+				metric.Gauge = &dto.Gauge{Value: ptr.To(point.Value)}
+				for _, attr := range point.Attributes.ToSlice() {
+					metric.Label = append(metric.Label, &dto.LabelPair{Name: ptr.To(string(attr.Key)), Value: ptr.To(attr.Value.AsString())})
+				}
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("series %v of %s not found", labelValues, g.name)
+}
+
+func TestGaugeVec(t *testing.T) {
+	g := NewGaugeVec("test_gauge_vec", "A test gauge", "gateway_name", "gateway_namespace")
+
+	g.Set(10, "a", "default")
+	g.Set(20, "b", "default")
+	g.Set(30, "a", "default")
+
+	if value, found := g.Value("a", "default"); !found || value != 30 {
+		t.Errorf("expected value 30, got %v (found: %v)", value, found)
+	}
+	if points := collectGauge(t, "test_gauge_vec"); len(points) != 2 {
+		t.Fatalf("expected 2 series, got %d", len(points))
+	}
+
+	g.Delete("b", "default")
+	points := collectGauge(t, "test_gauge_vec")
+	if len(points) != 1 || points[0].Value != 30 {
+		t.Fatalf("expected a single series with value 30, got %v", points)
+	}
+	if name, _ := points[0].Attributes.Value("gateway_name"); name.AsString() != "a" {
+		t.Errorf("expected the series of gateway a, got %v", points[0].Attributes)
+	}
+
+	g.Reset()
+	if g.Len() != 0 || len(collectGauge(t, "test_gauge_vec")) != 0 {
+		t.Error("expected no series after reset")
+	}
+}
+
+func TestCounterVec(t *testing.T) {
+	c := NewCounterVec("test_counter_vec_total", "A test counter", "subscriber", "operation")
+
+	c.Inc(context.Background(), "reconciler", "create")
+	c.Inc(context.Background(), "reconciler", "create")
+	c.Inc(context.Background(), "reconciler", "delete")
+
+	m, found := collectMetric(t, "test_counter_vec_total")
+	if !found {
+		t.Fatal("expected the counter to be collected")
+	}
+	for _, point := range m.Data.(metricdata.Sum[float64]).DataPoints {
+		operation, _ := point.Attributes.Value("operation")
+		expected := map[string]float64{"create": 2, "delete": 1}[operation.AsString()]
+		if point.Value != expected {
+			t.Errorf("expected %v %s operations, got %v", expected, operation.AsString(), point.Value)
+		}
+	}
+}
+
+func TestHistogramVecExemplars(t *testing.T) {
+	h := NewHistogramVec("test_histogram_vec_seconds", "A test histogram", []float64{0.1, 1, 10}, "reconciler")
+
+	traceID := trace.TraceID{0x01}
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     trace.SpanID{0x02},
+		TraceFlags: trace.FlagsSampled,
+	}))
+	h.Observe(ctx, 0.5, "reconciler")
+
+	m, found := collectMetric(t, "test_histogram_vec_seconds")
+	if !found {
+		t.Fatal("expected the histogram to be collected")
+	}
+	points := m.Data.(metricdata.Histogram[float64]).DataPoints
+	if len(points) != 1 || points[0].Count != 1 || !points[0].Attributes.HasValue("reconciler") {
+		t.Fatalf("expected a single observation of the reconciler, got %v", points)
+	}
+	if len(points[0].Bounds) != 3 {
+		t.Errorf("expected the configured buckets, got %v", points[0].Bounds)
+	}
+	if exemplars := points[0].Exemplars; len(exemplars) != 1 || trace.TraceID(exemplars[0].TraceID) != traceID {
+		t.Errorf("expected an exemplar with the trace id of the span, got %v", exemplars)
+	}
+}
+
+func TestAttributeSetPanicsOnLabelMismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic on missing label values")
+		}
+	}()
+	attributeSet([]string{"kind", "status"}, []string{"AuthPolicy"})
+}
+
+func TestWithoutNativeMetrics(t *testing.T) {
+	NewGaugeVec("test_native_gauge", "A gauge emitted through the meter provider")
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_native_gauge", Help: "A gauge exposed by the Prometheus exporter"}),
+		prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_bridged_gauge", Help: "A Prometheus gauge"}),
+	)
+
+	families, err := withoutNativeMetrics(registry).Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	if len(families) != 1 || families[0].GetName() != "test_bridged_gauge" {
+		t.Errorf("expected only the Prometheus gauge to be bridged, got %v", families)
+	}
+}
@@ -19,7 +19,6 @@ package metrics
 import (
 	"testing"
 
-	"github.com/prometheus/client_golang/prometheus"
 	dto "github.com/prometheus/client_model/go"
 )
 
@@ -78,7 +77,7 @@ func TestSetDependencyDetected(t *testing.T) {
 
 			// Verify the metric value
 			metric := &dto.Metric{}
-			if err := dependencyDetected.WithLabelValues(tt.dependency).Write(metric); err != nil {
+			if err := writeGauge(dependencyDetected, metric, tt.dependency); err != nil {
 				t.Fatalf("failed to write metric: %v", err)
 			}
 
@@ -147,7 +146,7 @@ func TestSetControllerRegistered(t *testing.T) {
 
 			// Verify the metric value
 			metric := &dto.Metric{}
-			if err := controllerRegistered.WithLabelValues(tt.controller).Write(metric); err != nil {
+			if err := writeGauge(controllerRegistered, metric, tt.controller); err != nil {
 				t.Fatalf("failed to write metric: %v", err)
 			}
 
@@ -214,7 +213,7 @@ func TestSetKuadrantReady(t *testing.T) {
 
 			// Verify the metric value
 			metric := &dto.Metric{}
-			if err := kuadrantReady.WithLabelValues(tt.namespace, tt.crName).Write(metric); err != nil {
+			if err := writeGauge(kuadrantReady, metric, tt.namespace, tt.crName); err != nil {
 				t.Fatalf("failed to write metric: %v", err)
 			}
 
@@ -292,7 +291,7 @@ func TestSetComponentReady(t *testing.T) {
 
 			// Verify the metric value
 			metric := &dto.Metric{}
-			if err := kuadrantComponentReady.WithLabelValues(tt.component, tt.namespace).Write(metric); err != nil {
+			if err := writeGauge(kuadrantComponentReady, metric, tt.component, tt.namespace); err != nil {
 				t.Fatalf("failed to write metric: %v", err)
 			}
 
@@ -354,7 +353,7 @@ func TestSetKuadrantExists(t *testing.T) {
 
 			// Verify the metric value
 			metric := &dto.Metric{}
-			if err := kuadrantExists.Write(metric); err != nil {
+			if err := writeGauge(kuadrantExists, metric); err != nil {
 				t.Fatalf("failed to write metric: %v", err)
 			}
 
@@ -383,23 +382,10 @@ func TestResetKuadrantMetrics(t *testing.T) {
 	// Reset the metrics
 	ResetKuadrantMetrics()
 
-	// Try to gather metrics - after reset, the metrics should not have any values
-	// We can verify this by checking that Write returns an error or that the collector has no metrics
-	registry := prometheus.NewRegistry()
-	registry.MustRegister(kuadrantReady)
-	registry.MustRegister(kuadrantComponentReady)
-
-	metrics, err := registry.Gather()
-	if err != nil {
-		t.Fatalf("failed to gather metrics: %v", err)
-	}
-
-	// After reset, the metric families should exist but have no metrics
-	for _, mf := range metrics {
-		if mf.GetName() == "kuadrant_ready" || mf.GetName() == "kuadrant_component_ready" {
-			if len(mf.GetMetric()) != 0 {
-				t.Errorf("expected metric %s to have 0 entries after reset, got %d", mf.GetName(), len(mf.GetMetric()))
-			}
+	// After reset, the metrics should not have any series
+	for _, g := range []*GaugeVec{kuadrantReady, kuadrantComponentReady} {
+		if points := collectGauge(t, g.name); len(points) != 0 {
+			t.Errorf("expected metric %s to have 0 entries after reset, got %d", g.name, len(points))
 		}
 	}
 }
@@ -412,7 +398,7 @@ func TestMultipleUpdates(t *testing.T) {
 		SetDependencyDetected("authorino", true)
 
 		metric := &dto.Metric{}
-		if err := dependencyDetected.WithLabelValues("authorino").Write(metric); err != nil {
+		if err := writeGauge(dependencyDetected, metric, "authorino"); err != nil {
 			t.Fatalf("failed to write metric: %v", err)
 		}
 		if *metric.Gauge.Value != 1.0 {
@@ -423,7 +409,7 @@ func TestMultipleUpdates(t *testing.T) {
 		SetDependencyDetected("authorino", false)
 
 		metric = &dto.Metric{}
-		if err := dependencyDetected.WithLabelValues("authorino").Write(metric); err != nil {
+		if err := writeGauge(dependencyDetected, metric, "authorino"); err != nil {
 			t.Fatalf("failed to write metric: %v", err)
 		}
 		if *metric.Gauge.Value != 0.0 {
@@ -438,7 +424,7 @@ func TestMultipleUpdates(t *testing.T) {
 		SetKuadrantReady("kuadrant-system", "kuadrant", false)
 
 		metric := &dto.Metric{}
-		if err := kuadrantReady.WithLabelValues("kuadrant-system", "kuadrant").Write(metric); err != nil {
+		if err := writeGauge(kuadrantReady, metric, "kuadrant-system", "kuadrant"); err != nil {
 			t.Fatalf("failed to write metric: %v", err)
 		}
 		if *metric.Gauge.Value != 0.0 {
@@ -449,7 +435,7 @@ func TestMultipleUpdates(t *testing.T) {
 		SetKuadrantReady("kuadrant-system", "kuadrant", true)
 
 		metric = &dto.Metric{}
-		if err := kuadrantReady.WithLabelValues("kuadrant-system", "kuadrant").Write(metric); err != nil {
+		if err := writeGauge(kuadrantReady, metric, "kuadrant-system", "kuadrant"); err != nil {
 			t.Fatalf("failed to write metric: %v", err)
 		}
 		if *metric.Gauge.Value != 1.0 {
@@ -478,7 +464,7 @@ func TestMultipleDependencies(t *testing.T) {
 	// Verify each dependency
 	for dep, detected := range dependencies {
 		metric := &dto.Metric{}
-		if err := dependencyDetected.WithLabelValues(dep).Write(metric); err != nil {
+		if err := writeGauge(dependencyDetected, metric, dep); err != nil {
 			t.Fatalf("failed to write metric for %s: %v", dep, err)
 		}
 
@@ -514,7 +500,7 @@ func TestMultipleControllers(t *testing.T) {
 	// Verify each controller
 	for controller, registered := range controllers {
 		metric := &dto.Metric{}
-		if err := controllerRegistered.WithLabelValues(controller).Write(metric); err != nil {
+		if err := writeGauge(controllerRegistered, metric, controller); err != nil {
 			t.Fatalf("failed to write metric for %s: %v", controller, err)
 		}
 
@@ -538,7 +524,7 @@ func TestMultipleComponents(t *testing.T) {
 
 	// Verify authorino is ready
 	authMetric := &dto.Metric{}
-	if err := kuadrantComponentReady.WithLabelValues("authorino", "kuadrant-system").Write(authMetric); err != nil {
+	if err := writeGauge(kuadrantComponentReady, authMetric, "authorino", "kuadrant-system"); err != nil {
 		t.Fatalf("failed to write authorino metric: %v", err)
 	}
 	if *authMetric.Gauge.Value != 1.0 {
@@ -547,7 +533,7 @@ func TestMultipleComponents(t *testing.T) {
 
 	// Verify limitador is not ready
 	limitadorMetric := &dto.Metric{}
-	if err := kuadrantComponentReady.WithLabelValues("limitador", "kuadrant-system").Write(limitadorMetric); err != nil {
+	if err := writeGauge(kuadrantComponentReady, limitadorMetric, "limitador", "kuadrant-system"); err != nil {
 		t.Fatalf("failed to write limitador metric: %v", err)
 	}
 	if *limitadorMetric.Gauge.Value != 0.0 {
@@ -21,10 +21,15 @@ import (
 	"fmt"
 	"net/url"
 
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/otlptranslator"
+	"github.com/samber/lo"
 	prombridge "go.opentelemetry.io/contrib/bridges/prometheus"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	otelprom "go.opentelemetry.io/otel/exporters/prometheus"
 	"go.opentelemetry.io/otel/sdk/metric"
 
 	kuadrantotel "github.com/kuadrant/kuadrant-operator/internal/otel"
@@ -36,14 +41,13 @@ type Provider struct {
 	otlpEnabled   bool
 }
 
-// NewProvider creates a new OpenTelemetry metrics provider that bridges
-// existing Prometheus metrics to OTLP export.
+// NewProvider creates a new OpenTelemetry metrics provider for the Kuadrant
+// operator metrics, which are emitted through the OpenTelemetry meter provider.
 //
-// This allows all existing Prometheus metrics (including controller-runtime
-// metrics and custom metrics like kuadrant_dns_policy_ready) to be exported
-// via OTLP without any code changes to the metrics themselves.
-//
-// The Prometheus /metrics endpoint continues to work as before.
+// The operator metrics are exposed in the Prometheus /metrics endpoint by a
+// Prometheus exporter registered with the configured Prometheus registerer.
+// The remaining Prometheus metrics (e.g. controller-runtime metrics) are bridged
+// to OTLP export, alongside the operator metrics, when OTLP export is enabled.
 //
 // otelConfig provides shared service identity (used across logs, traces, metrics)
 // metricsConfig provides metrics-specific settings (export interval, Prometheus gatherer)
@@ -57,13 +61,29 @@ func NewProvider(ctx context.Context, otelConfig *kuadrantotel.Config, metricsCo
 	// Bridge Prometheus metrics to OpenTelemetry
 	// This creates a MetricProducer that reads from the Prometheus gatherer
 	// and converts metrics to OpenTelemetry format
+	// Metrics emitted through the meter provider are excluded, as these are exported by the meter provider itself
 	promBridge := prombridge.NewMetricProducer(
-		prombridge.WithGatherer(metricsConfig.PrometheusGatherer),
+		prombridge.WithGatherer(withoutNativeMetrics(metricsConfig.PrometheusGatherer)),
 	)
 
 	var readers []metric.Reader
 	otlpEnabled := false
 
+	// Expose the metrics emitted through the meter provider in the Prometheus /metrics endpoint,
+	// keeping the names of the metrics as they are
+	if metricsConfig.PrometheusRegisterer != nil {
+		promExporter, err := otelprom.New(
+			otelprom.WithRegisterer(metricsConfig.PrometheusRegisterer),
+			otelprom.WithTranslationStrategy(otlptranslator.UnderscoreEscapingWithoutSuffixes),
+			otelprom.WithoutScopeInfo(),
+			otelprom.WithoutTargetInfo(),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Prometheus exporter: %w", err)
+		}
+		readers = append(readers, promExporter)
+	}
+
 	// Only setup OTLP export if metrics endpoint is configured
 	endpoint := otelConfig.MetricsEndpoint()
 	if endpoint != "" {
@@ -74,7 +94,7 @@ func NewProvider(ctx context.Context, otelConfig *kuadrantotel.Config, metricsCo
 		}
 
 		// Create periodic reader that:
-		// 1. Reads metrics from the Prometheus bridge
+		// 1. Reads the metrics emitted through the meter provider and from the Prometheus bridge
 		// 2. Exports them via OTLP at the configured interval
 		reader := metric.NewPeriodicReader(
 			otlpExporter,
@@ -94,7 +114,7 @@ func NewProvider(ctx context.Context, otelConfig *kuadrantotel.Config, metricsCo
 	}
 	meterProvider := metric.NewMeterProvider(opts...)
 
-	// Set as global MeterProvider for any future OTel metrics
+	// Set as global MeterProvider of the operator metrics
 	otel.SetMeterProvider(meterProvider)
 
 	return &Provider{
@@ -103,6 +123,18 @@ func NewProvider(ctx context.Context, otelConfig *kuadrantotel.Config, metricsCo
 	}, nil
 }
 
+// withoutNativeMetrics returns a gatherer of the metrics of a Prometheus gatherer that are not emitted
+// through the OpenTelemetry meter provider
+func withoutNativeMetrics(gatherer prometheus.Gatherer) prometheus.Gatherer {
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		families, err := gatherer.Gather()
+		return lo.Filter(families, func(family *dto.MetricFamily, _ int) bool {
+			_, native := nativeMetrics.Load(family.GetName())
+			return !native
+		}), err
+	})
+}
+
 // newMetricExporter creates an OTLP metric exporter based on endpoint URL scheme.
 // Following the Authorino pattern:
 //   - rpc://host:port  → gRPC exporter
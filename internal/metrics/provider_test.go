@@ -177,3 +177,47 @@ func TestBridgePrometheusMetrics(t *testing.T) {
 		t.Error("test_counter not found in registry")
 	}
 }
+
+func TestPrometheusExporter(t *testing.T) {
+	ctx := context.Background()
+	registry := prometheus.NewRegistry()
+
+	otelConfig := &kuadrantotel.Config{
+		ServiceName:    "test",
+		ServiceVersion: "v1.0.0",
+	}
+	metricsConfig := &Config{
+		PrometheusGatherer:   registry,
+		PrometheusRegisterer: registry,
+		ExportInterval:       30 * time.Second,
+	}
+
+	provider, err := NewProvider(ctx, otelConfig, metricsConfig)
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+	defer provider.Shutdown(ctx)
+
+	// Instruments created after the provider is set up are emitted through it
+	NewGaugeVec("test_exported_gauge", "A gauge exposed by the Prometheus exporter", "kind").Set(3, "AuthPolicy")
+	NewCounterVec("test_exported_total", "A counter exposed by the Prometheus exporter", "kind").Inc(ctx, "AuthPolicy")
+
+	metrics, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+
+	exported := map[string]float64{}
+	for _, m := range metrics {
+		for _, metric := range m.GetMetric() {
+			if len(metric.GetLabel()) != 1 || metric.GetLabel()[0].GetName() != "kind" {
+				t.Errorf("expected only the kind label in %s, got %v", m.GetName(), metric.GetLabel())
+			}
+			exported[m.GetName()] = metric.GetGauge().GetValue() + metric.GetCounter().GetValue()
+		}
+	}
+	// metric names are kept as they are, with no unit or counter suffixes
+	if exported["test_exported_gauge"] != 3 || exported["test_exported_total"] != 1 {
+		t.Errorf("expected the metrics to be exposed with their names, got %v", exported)
+	}
+}
@@ -25,6 +25,17 @@ type DataBinding struct {
 	Expression string
 }
 
+// FunctionBinding binds a zero-argument CEL function contributed by an extension
+// (e.g. `threat.score()`) to the gRPC service whose response provides its value.
+type FunctionBinding struct {
+	Name           string // qualified function name, e.g. "threat.score"
+	ResultType     string // CEL type name of the result, e.g. "int"
+	ServiceName    string
+	MessageBuilder string
+	ResultField    string // dot-separated field of the response; empty for the whole response
+	Sources        []string
+}
+
 // ActionSetSpec is the intermediate between policy processing and final Config construction.
 type ActionSetSpec struct {
 	Name                string
@@ -437,6 +448,7 @@ var bodyJSONPattern = regexp.MustCompile(`(response|request)BodyJSON\(["']([^"']
 const (
 	responseBodyStorePath = "kuadrant.internal.response.body"
 	requestBodyStorePath  = "kuadrant.internal.request.body"
+	functionsStorePath    = "kuadrant.internal.functions"
 )
 
 type bodyRef struct {
@@ -485,6 +497,142 @@ func extractBodyRefs(expr string) []bodyRef {
 	return refs
 }
 
+// --- Function helpers ---
+
+// FunctionStorePath returns the store path holding the value of an extension function.
+// "threat.score" → "kuadrant.internal.functions.threat_score"
+func FunctionStorePath(name string) string {
+	return functionsStorePath + "." + functionVarName(name)
+}
+
+func functionVarName(name string) string {
+	return strings.ReplaceAll(name, ".", "_")
+}
+
+// functionCallPattern matches calls to the function that are not member calls, e.g. `threat.score()` but not
+// `x.threat.score()`. The preceding character, if any, is captured to be kept on replacement.
+func functionCallPattern(name string) *regexp.Regexp {
+	return regexp.MustCompile(`(^|[^.\w])` + regexp.QuoteMeta(name) + `\(\s*\)`)
+}
+
+// BindFunctions rewrites calls to extension functions in the specs' predicates,
+// data expressions and bindings into reads of the function's store path, and
+// returns the actions that populate those paths. Only functions referenced by at
+// least one spec produce an action, which only calls the function when the predicates of at least one of the
+// referencing specs hold; the returned actions must run before the specs.
+func BindFunctions(specs []ActionSpec, functions []FunctionBinding) ([]ActionSpec, []Action) {
+	if len(functions) == 0 {
+		return specs, nil
+	}
+
+	replaced := make([]ActionSpec, len(specs))
+	copy(replaced, specs)
+
+	var actions []Action
+	for _, fn := range functions {
+		pattern := functionCallPattern(fn.Name)
+		storePath := FunctionStorePath(fn.Name)
+		var gates []string
+		unconditional := false
+		for i := range replaced {
+			var changed bool
+			if replaced[i], changed = replaced[i].replaceFunctionCalls(pattern, storePath); changed {
+				fn.Sources = appendUnique(fn.Sources, replaced[i].Sources...)
+				gate := functionGate(replaced[i].Predicates)
+				if gate == "true" {
+					unconditional = true
+				}
+				gates = appendUnique(gates, gate)
+			}
+		}
+		if len(gates) == 0 {
+			continue
+		}
+		predicate := "true"
+		if !unconditional {
+			predicate = joinPredicates(gates, "||")
+		}
+
+		varName := functionVarName(fn.Name) + "_response"
+		value := varName
+		if fn.ResultField != "" {
+			value = fmt.Sprintf("%s.%s", varName, fn.ResultField)
+		}
+		actions = append(actions,
+			NewGrpcAction(predicate, varName, fn.ServiceName, fn.MessageBuilder, "function").
+				WithSources(fn.Sources).
+				WithOnReply(NewStoreAction("true", storePath, value)),
+		)
+	}
+	return replaced, actions
+}
+
+// functionGate builds the predicate under which a spec needs the value of a function, out of the predicates of the
+// spec that can be evaluated before any action ran, i.e. that read neither the store nor the auth results
+func functionGate(predicates []string) string {
+	var gate []string
+	for _, predicate := range predicates {
+		if strings.Contains(predicate, "kuadrant.internal.") || strings.Contains(predicate, "auth.") || isResponsePhaseExpression(predicate) {
+			continue
+		}
+		gate = append(gate, predicate)
+	}
+	return buildActionPredicate(gate)
+}
+
+func (s ActionSpec) replaceFunctionCalls(pattern *regexp.Regexp, storePath string) (ActionSpec, bool) {
+	changed := false
+	replace := func(expr string) string {
+		if !pattern.MatchString(expr) {
+			return expr
+		}
+		changed = true
+		return pattern.ReplaceAllString(expr, "${1}"+storePath)
+	}
+
+	predicates := make([]string, len(s.Predicates))
+	for i, predicate := range s.Predicates {
+		predicates[i] = replace(predicate)
+	}
+
+	conditionalData := make([]ConditionalData, len(s.ConditionalData))
+	for i, cd := range s.ConditionalData {
+		cdPredicates := make([]string, len(cd.Predicates))
+		for j, predicate := range cd.Predicates {
+			cdPredicates[j] = replace(predicate)
+		}
+		data := make([]DataType, len(cd.Data))
+		for j, item := range cd.Data {
+			data[j] = item
+			if expr, ok := item.Value.(*Expression); ok {
+				if newValue := replace(expr.ExpressionItem.Value); newValue != expr.ExpressionItem.Value {
+					data[j] = DataType{Value: &Expression{
+						ExpressionItem: ExpressionItem{
+							Key:   expr.ExpressionItem.Key,
+							Value: newValue,
+						},
+					}}
+				}
+			}
+		}
+		conditionalData[i] = ConditionalData{Predicates: cdPredicates, Data: data}
+	}
+
+	bindings := make([]DataBinding, len(s.Bindings))
+	for i, b := range s.Bindings {
+		bindings[i] = DataBinding{Domain: b.Domain, Field: b.Field, Expression: replace(b.Expression)}
+	}
+
+	if !changed {
+		return s, false
+	}
+
+	s.Predicates = predicates
+	s.ConditionalData = conditionalData
+	s.Bindings = bindings
+	return s, true
+}
+
 // --- Auth message construction ---
 
 func buildMetadataContext(bindings []DataBinding) MetadataCEL {
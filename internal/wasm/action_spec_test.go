@@ -1036,3 +1036,133 @@ func TestAttachBindings_NoBindings(t *testing.T) {
 		}
 	}
 }
+
+func TestFunctionStorePath(t *testing.T) {
+	if got := FunctionStorePath("threat.score"); got != "kuadrant.internal.functions.threat_score" {
+		t.Errorf("FunctionStorePath() = %q, want %q", got, "kuadrant.internal.functions.threat_score")
+	}
+}
+
+func TestBindFunctions_RewritesReferencedFunctions(t *testing.T) {
+	specs := []ActionSpec{
+		{
+			ServiceName: RateLimitServiceName,
+			Scope:       "my-rl",
+			Predicates:  []string{"threat.score() > 5"},
+			ConditionalData: []ConditionalData{
+				{
+					Predicates: []string{"plan.tier( ) == 'gold'"},
+					Data: []DataType{
+						{Value: &Expression{ExpressionItem: ExpressionItem{Key: "score", Value: "threat.score()"}}},
+					},
+				},
+			},
+			Sources: []string{"RateLimitPolicy/default/my-rlp"},
+		},
+	}
+	functions := []FunctionBinding{
+		{Name: "threat.score", ServiceName: "ext-abc", MessageBuilder: "{}", ResultField: "score", Sources: []string{"ThreatPolicy/default/tp"}},
+		{Name: "plan.tier", ServiceName: "ext-def", MessageBuilder: "{}"},
+		{Name: "unused.fn", ServiceName: "ext-ghi"},
+	}
+
+	rewritten, actions := BindFunctions(specs, functions)
+
+	if len(actions) != 2 {
+		t.Fatalf("expected 2 function actions, got %d", len(actions))
+	}
+	score, ok := actions[0].(*GrpcAction)
+	if !ok {
+		t.Fatalf("actions[0] type = %T, want *GrpcAction", actions[0])
+	}
+	if score.Service != "ext-abc" || score.Var != "threat_score_response" {
+		t.Errorf("unexpected grpc action: service=%q var=%q", score.Service, score.Var)
+	}
+	if !strings.Contains(strings.Join(score.SourcePolicyLocators, ","), "RateLimitPolicy/default/my-rlp") {
+		t.Errorf("expected referencing spec sources to be included, got %v", score.SourcePolicyLocators)
+	}
+	store, ok := score.OnReply[0].(*StoreAction)
+	if !ok {
+		t.Fatalf("onReply[0] type = %T, want *StoreAction", score.OnReply[0])
+	}
+	if store.Path != "kuadrant.internal.functions.threat_score" || store.Value != "threat_score_response.score" {
+		t.Errorf("unexpected store action: path=%q value=%q", store.Path, store.Value)
+	}
+	if tier := actions[1].(*GrpcAction).OnReply[0].(*StoreAction); tier.Value != "plan_tier_response" {
+		t.Errorf("expected whole response to be stored, got %q", tier.Value)
+	}
+
+	if got := rewritten[0].Predicates[0]; got != "kuadrant.internal.functions.threat_score > 5" {
+		t.Errorf("predicate = %q", got)
+	}
+	if got := rewritten[0].ConditionalData[0].Predicates[0]; got != "kuadrant.internal.functions.plan_tier == 'gold'" {
+		t.Errorf("conditional predicate = %q", got)
+	}
+	if got := rewritten[0].ConditionalData[0].Data[0].Value.(*Expression).ExpressionItem.Value; got != "kuadrant.internal.functions.threat_score" {
+		t.Errorf("data expression = %q", got)
+	}
+	if specs[0].Predicates[0] != "threat.score() > 5" {
+		t.Errorf("input specs must not be modified, got %q", specs[0].Predicates[0])
+	}
+}
+
+func TestBindFunctions_NoFunctions(t *testing.T) {
+	specs := []ActionSpec{{ServiceName: AuthServiceName, Predicates: []string{"threat.score() > 5"}}}
+
+	rewritten, actions := BindFunctions(specs, nil)
+
+	if len(actions) != 0 {
+		t.Fatalf("expected no actions, got %d", len(actions))
+	}
+	if rewritten[0].Predicates[0] != "threat.score() > 5" {
+		t.Errorf("predicate should be untouched, got %q", rewritten[0].Predicates[0])
+	}
+}
+
+func TestBindFunctions_IgnoresMemberCalls(t *testing.T) {
+	specs := []ActionSpec{{ServiceName: RateLimitServiceName, Predicates: []string{"x.threat.score() > 5"}}}
+	functions := []FunctionBinding{{Name: "threat.score", ServiceName: "ext-abc"}}
+
+	rewritten, actions := BindFunctions(specs, functions)
+
+	if len(actions) != 0 {
+		t.Fatalf("expected no function actions, got %d", len(actions))
+	}
+	if rewritten[0].Predicates[0] != "x.threat.score() > 5" {
+		t.Errorf("member call should be untouched, got %q", rewritten[0].Predicates[0])
+	}
+}
+
+func TestBindFunctions_GatesCallOnReferencingSpecs(t *testing.T) {
+	specs := []ActionSpec{
+		{
+			ServiceName: RateLimitServiceName,
+			Predicates:  []string{"request.url_path.startsWith('/api')", "auth.identity.group == 'free'"},
+			ConditionalData: []ConditionalData{{
+				Data: []DataType{{Value: &Expression{ExpressionItem: ExpressionItem{Key: "score", Value: "threat.score()"}}}},
+			}},
+		},
+		{
+			ServiceName: RateLimitServiceName,
+			Predicates:  []string{"request.method == 'POST'", "(threat.score() > 5)"},
+		},
+		{
+			ServiceName: RateLimitServiceName,
+			Predicates:  []string{"request.method == 'GET'"},
+		},
+	}
+	functions := []FunctionBinding{{Name: "threat.score", ServiceName: "ext-abc"}}
+
+	rewritten, actions := BindFunctions(specs, functions)
+
+	if len(actions) != 1 {
+		t.Fatalf("expected 1 function action, got %d", len(actions))
+	}
+	want := "(request.url_path.startsWith('/api')) || (request.method == 'POST')"
+	if got := actions[0].(*GrpcAction).Predicate; got != want {
+		t.Errorf("predicate = %q, want %q", got, want)
+	}
+	if got := rewritten[1].Predicates[1]; got != "(kuadrant.internal.functions.threat_score > 5)" {
+		t.Errorf("predicate = %q", got)
+	}
+}
@@ -0,0 +1,165 @@
+package wasm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	kuadrantgatewayapi "github.com/kuadrant/kuadrant-operator/internal/gatewayapi"
+)
+
+// customMetricsDomainPrefix is the prefix of the request bindings defining custom metrics, of the form
+// "metrics.custom.<metric>.<attribute>", with attribute one of "type", "value", "buckets" or "labels.<label>"
+const customMetricsDomainPrefix = "metrics.custom"
+
+// metricLabelName is the pattern of the label names of the custom metrics, as accepted by Prometheus
+var metricLabelName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+type MetricType string
+
+const (
+	MetricTypeCounter   MetricType = "counter"
+	MetricTypeHistogram MetricType = "histogram"
+)
+
+// Metric is a custom metric recorded by the wasm-shim into the Envoy stats of the gateway for every request
+// matching the action set
+type Metric struct {
+	Name string     `json:"name"`
+	Type MetricType `json:"type"`
+	// Value is the CEL expression of the amount added to the counter or of the value observed by the histogram
+	Value string `json:"value"`
+	// Labels maps label names to CEL expressions
+	Labels map[string]string `json:"labels,omitempty"`
+	// Buckets are the upper bounds of the buckets of a histogram
+	Buckets []int64 `json:"buckets,omitempty"`
+}
+
+func (m Metric) EqualTo(other Metric) bool {
+	return m.Name == other.Name &&
+		m.Type == other.Type &&
+		m.Value == other.Value &&
+		maps.Equal(m.Labels, other.Labels) &&
+		slices.Equal(m.Buckets, other.Buckets)
+}
+
+// MetricsFromBindings splits the custom metric definitions off the request bindings. It returns the
+// remaining bindings, to be attached to the action specs, and the custom metrics sorted by name.
+// Metrics without a value are dropped, as well as the invalid ones, reported in the returned error.
+func MetricsFromBindings(bindings []DataBinding) ([]DataBinding, []Metric, error) {
+	var others []DataBinding
+	var errs []error
+	metrics := make(map[string]*Metric)
+	invalid := make(map[string]struct{})
+
+	for _, b := range bindings {
+		rest, isMetric := strings.CutPrefix(b.Domain+"."+b.Field, customMetricsDomainPrefix+".")
+		name, attribute, found := strings.Cut(rest, ".")
+		if !isMetric || !found {
+			others = append(others, b)
+			continue
+		}
+
+		metric, ok := metrics[name]
+		if !ok {
+			metric = &Metric{Name: name, Type: MetricTypeCounter}
+			metrics[name] = metric
+		}
+
+		switch {
+		case attribute == "type":
+			metricType, err := strconv.Unquote(b.Expression)
+			if err != nil || (MetricType(metricType) != MetricTypeCounter && MetricType(metricType) != MetricTypeHistogram) {
+				errs = append(errs, fmt.Errorf("invalid type %s of custom metric %s", b.Expression, name))
+				invalid[name] = struct{}{}
+			}
+			metric.Type = MetricType(metricType)
+		case attribute == "value":
+			metric.Value = b.Expression
+		case attribute == "buckets":
+			if err := json.Unmarshal([]byte(b.Expression), &metric.Buckets); err != nil {
+				errs = append(errs, fmt.Errorf("invalid buckets of custom metric %s: %w", name, err))
+				invalid[name] = struct{}{}
+			}
+		case strings.HasPrefix(attribute, "labels."):
+			label := strings.TrimPrefix(attribute, "labels.")
+			if !metricLabelName.MatchString(label) {
+				errs = append(errs, fmt.Errorf("invalid label name %q of custom metric %s", label, name))
+				invalid[name] = struct{}{}
+			}
+			if metric.Labels == nil {
+				metric.Labels = make(map[string]string)
+			}
+			metric.Labels[label] = b.Expression
+		default:
+			errs = append(errs, fmt.Errorf("unknown attribute %s of custom metric %s", attribute, name))
+			invalid[name] = struct{}{}
+		}
+	}
+
+	for name, metric := range metrics {
+		if _, isInvalid := invalid[name]; isInvalid || len(metric.Buckets) == 0 {
+			continue
+		}
+		if metric.Type != MetricTypeHistogram {
+			errs = append(errs, fmt.Errorf("buckets of custom metric %s are only allowed for histograms", name))
+			invalid[name] = struct{}{}
+		} else if !slices.IsSorted(metric.Buckets) || len(slices.Compact(slices.Clone(metric.Buckets))) != len(metric.Buckets) {
+			errs = append(errs, fmt.Errorf("buckets of custom metric %s must be strictly increasing", name))
+			invalid[name] = struct{}{}
+		}
+	}
+
+	names := make([]string, 0, len(metrics))
+	for name, metric := range metrics {
+		if _, isInvalid := invalid[name]; !isInvalid && metric.Value != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	result := make([]Metric, 0, len(names))
+	for _, name := range names {
+		result = append(result, *metrics[name])
+	}
+	return others, result, errors.Join(errs...)
+}
+
+// MergeMetrics merges the custom metrics of several targets, given in order of precedence, e.g. the metrics of
+// the route before the ones of the gateway. A metric is taken as a whole from the first target defining it, so
+// that the attributes of a metric defined for a less specific target never mix into the definition of a more
+// specific one. The result is sorted by name.
+func MergeMetrics(metricsByPrecedence ...[]Metric) []Metric {
+	var result []Metric
+	seen := make(map[string]struct{})
+	for _, metrics := range metricsByPrecedence {
+		for _, metric := range metrics {
+			if _, exists := seen[metric.Name]; exists {
+				continue
+			}
+			seen[metric.Name] = struct{}{}
+			result = append(result, metric)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// WithMetrics sets the custom metrics recorded for the requests matching the action sets
+func WithMetrics(configs []kuadrantgatewayapi.HTTPRouteMatchConfig, metrics []Metric) {
+	if len(metrics) == 0 {
+		return
+	}
+	for i := range configs {
+		if actionSet, ok := configs[i].Config.(ActionSet); ok {
+			actionSet.Metrics = metrics
+			configs[i].Config = actionSet
+		}
+	}
+}
@@ -0,0 +1,104 @@
+//go:build unit
+
+package wasm
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMetricsFromBindings(t *testing.T) {
+	bindings := []DataBinding{
+		{Domain: "metrics.labels", Field: "model", Expression: "requestBodyJSON('/model')"},
+		{Domain: "metrics.custom.response_bytes", Field: "type", Expression: `"histogram"`},
+		{Domain: "metrics.custom.response_bytes", Field: "value", Expression: "response.size"},
+		{Domain: "metrics.custom.response_bytes", Field: "buckets", Expression: "[1024,65536]"},
+		{Domain: "metrics.custom.tokens.labels", Field: "model", Expression: "responseBodyJSON('/model')"},
+		{Domain: "metrics.custom.tokens", Field: "value", Expression: "responseBodyJSON('/usage/total_tokens')"},
+		{Domain: "metrics.custom.unvalued.labels", Field: "user", Expression: "auth.identity.sub"},
+	}
+
+	others, metrics, err := MetricsFromBindings(bindings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]DataBinding{bindings[0]}, others); diff != "" {
+		t.Errorf("unexpected remaining bindings (-want +got):\n%s", diff)
+	}
+	expected := []Metric{
+		{Name: "response_bytes", Type: MetricTypeHistogram, Value: "response.size", Buckets: []int64{1024, 65536}},
+		{Name: "tokens", Type: MetricTypeCounter, Value: "responseBodyJSON('/usage/total_tokens')", Labels: map[string]string{"model": "responseBodyJSON('/model')"}},
+	}
+	if diff := cmp.Diff(expected, metrics); diff != "" {
+		t.Errorf("unexpected metrics (-want +got):\n%s", diff)
+	}
+}
+
+func TestMetricsFromBindings_Invalid(t *testing.T) {
+	_, metrics, err := MetricsFromBindings([]DataBinding{
+		{Domain: "metrics.custom.bad_type", Field: "type", Expression: `"gauge"`},
+		{Domain: "metrics.custom.bad_type", Field: "value", Expression: "1"},
+		{Domain: "metrics.custom.bad_buckets", Field: "buckets", Expression: "[0.5"},
+		{Domain: "metrics.custom.bad_buckets", Field: "value", Expression: "1"},
+		{Domain: "metrics.custom.unsorted_buckets", Field: "type", Expression: `"histogram"`},
+		{Domain: "metrics.custom.unsorted_buckets", Field: "buckets", Expression: "[10, 1]"},
+		{Domain: "metrics.custom.unsorted_buckets", Field: "value", Expression: "1"},
+		{Domain: "metrics.custom.counter_buckets", Field: "buckets", Expression: "[1, 10]"},
+		{Domain: "metrics.custom.counter_buckets", Field: "value", Expression: "1"},
+		{Domain: "metrics.custom.bad_label.labels", Field: "model-name", Expression: "1"},
+		{Domain: "metrics.custom.bad_label", Field: "value", Expression: "1"},
+		{Domain: "metrics.custom.good", Field: "value", Expression: "1"},
+	})
+	if err == nil {
+		t.Fatal("expected an error for the invalid metrics")
+	}
+	if len(metrics) != 1 || metrics[0].Name != "good" {
+		t.Errorf("expected only the valid metric to be kept, got %v", metrics)
+	}
+}
+
+func TestMergeMetrics(t *testing.T) {
+	route := []Metric{
+		{Name: "tokens", Type: MetricTypeCounter, Value: "responseBodyJSON('/usage/total_tokens')"},
+	}
+	gateway := []Metric{
+		{Name: "requests", Type: MetricTypeCounter, Value: "1"},
+		{Name: "tokens", Type: MetricTypeHistogram, Value: "1", Labels: map[string]string{"user": "auth.identity.sub"}, Buckets: []int64{10}},
+	}
+
+	expected := []Metric{
+		{Name: "requests", Type: MetricTypeCounter, Value: "1"},
+		// the metric of the route overrides the one of the gateway as a whole, without its labels nor buckets
+		{Name: "tokens", Type: MetricTypeCounter, Value: "responseBodyJSON('/usage/total_tokens')"},
+	}
+	if diff := cmp.Diff(expected, MergeMetrics(route, gateway)); diff != "" {
+		t.Errorf("unexpected metrics (-want +got):\n%s", diff)
+	}
+}
+
+func TestActionSet_MetricsJSON(t *testing.T) {
+	actionSet := ActionSet{
+		Name:    "some_name",
+		Actions: []Action{NewFailAction("true", "fail")},
+		Metrics: []Metric{{Name: "requests", Type: MetricTypeCounter, Value: "1", Labels: map[string]string{"method": "request.method"}}},
+	}
+	raw, err := json.Marshal(actionSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var unmarshalled ActionSet
+	if err := json.Unmarshal(raw, &unmarshalled); err != nil {
+		t.Fatal(err)
+	}
+	if !actionSet.EqualTo(unmarshalled) {
+		t.Errorf("expected action sets to be equal after round trip, got %s", raw)
+	}
+
+	other := unmarshalled
+	other.Metrics = []Metric{{Name: "requests", Type: MetricTypeCounter, Value: "2"}}
+	if actionSet.EqualTo(other) {
+		t.Error("expected action sets with different metrics not to be equal")
+	}
+}
@@ -5,10 +5,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"slices"
 
 	_struct "google.golang.org/protobuf/types/known/structpb"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/utils/ptr"
 )
 
 type Config struct {
@@ -144,6 +146,10 @@ type ActionSet struct {
 	// +optional
 	Actions []Action `json:"-"`
 
+	// Metrics holds the custom metrics recorded for the requests matching the action set.
+	// +optional
+	Metrics []Metric `json:"-"`
+
 	// SourceRoute records which route (Kind/Namespace/Name) this action set was
 	// created from. Not serialized — used only to match pipeline actions to the
 	// correct action sets at reconcile time.
@@ -155,12 +161,14 @@ type actionSetJSON struct {
 	Name                string              `json:"name"`
 	RouteRuleConditions RouteRuleConditions `json:"routeRuleConditions,omitempty"`
 	Actions             []json.RawMessage   `json:"actions,omitempty"`
+	Metrics             []Metric            `json:"metrics,omitempty"`
 }
 
 func (s ActionSet) MarshalJSON() ([]byte, error) {
 	alias := actionSetJSON{
 		Name:                s.Name,
 		RouteRuleConditions: s.RouteRuleConditions,
+		Metrics:             s.Metrics,
 	}
 
 	for _, action := range s.Actions {
@@ -182,6 +190,7 @@ func (s *ActionSet) UnmarshalJSON(data []byte) error {
 
 	s.Name = alias.Name
 	s.RouteRuleConditions = alias.RouteRuleConditions
+	s.Metrics = alias.Metrics
 	s.Actions = nil
 
 	for _, raw := range alias.Actions {
@@ -201,11 +210,12 @@ func (s *ActionSet) UnmarshalJSON(data []byte) error {
 //   - Name: String comparison
 //   - RouteRuleConditions: Uses RouteRuleConditions.EqualTo (which has its own ordering rules)
 //   - Actions: Order matters - compared by index position
+//   - Metrics: Order matters - compared by index position (sorted by name when built)
 //
 // Note: Actions order is significant because it affects the evaluation order
 // in the data plane.
 func (s *ActionSet) EqualTo(other ActionSet) bool {
-	if s.Name != other.Name || !s.RouteRuleConditions.EqualTo(other.RouteRuleConditions) || len(s.Actions) != len(other.Actions) {
+	if s.Name != other.Name || !s.RouteRuleConditions.EqualTo(other.RouteRuleConditions) || len(s.Actions) != len(other.Actions) || len(s.Metrics) != len(other.Metrics) {
 		return false
 	}
 
@@ -215,6 +225,12 @@ func (s *ActionSet) EqualTo(other ActionSet) bool {
 		}
 	}
 
+	for i := range s.Metrics {
+		if !s.Metrics[i].EqualTo(other.Metrics[i]) {
+			return false
+		}
+	}
+
 	return true
 }
 
@@ -573,13 +589,24 @@ func UnmarshalAction(data []byte) (Action, error) {
 }
 
 type Observability struct {
-	HTTPHeaderIdentifier *string  `json:"httpHeaderIdentifier,omitempty"`
-	DefaultLevel         *string  `json:"defaultLevel,omitempty"`
-	Tracing              *Tracing `json:"tracing,omitempty"`
+	HTTPHeaderIdentifier *string      `json:"httpHeaderIdentifier,omitempty"`
+	DefaultLevel         *string      `json:"defaultLevel,omitempty"`
+	Tracing              *Tracing     `json:"tracing,omitempty"`
+	DecisionLog          *DecisionLog `json:"decisionLog,omitempty"`
 }
 
 type Tracing struct {
-	Service string `json:"service,omitempty"`
+	Service            string `json:"service,omitempty"`
+	SamplingPercentage *int32 `json:"samplingPercentage,omitempty"`
+	Propagation        string `json:"propagation,omitempty"`
+}
+
+// DecisionLog configures the structured log entries emitted by the wasm-shim with the outcome of each sampled request.
+// Fields are CEL expressions evaluated by the wasm-shim on the request.
+type DecisionLog struct {
+	Outcomes           []string          `json:"outcomes"`
+	SamplingPercentage int32             `json:"samplingPercentage"`
+	Fields             map[string]string `json:"fields,omitempty"`
 }
 
 func (o *Observability) EqualTo(other *Observability) bool {
@@ -611,7 +638,7 @@ func (o *Observability) EqualTo(other *Observability) bool {
 		return false
 	}
 
-	return true
+	return o.DecisionLog.EqualTo(other.DecisionLog)
 }
 
 func (t *Tracing) EqualTo(other *Tracing) bool {
@@ -621,5 +648,17 @@ func (t *Tracing) EqualTo(other *Tracing) bool {
 	if t == nil || other == nil {
 		return false
 	}
-	return t.Service == other.Service
+	return t.Service == other.Service &&
+		ptr.Equal(t.SamplingPercentage, other.SamplingPercentage) &&
+		t.Propagation == other.Propagation
+}
+
+func (d *DecisionLog) EqualTo(other *DecisionLog) bool {
+	if d == nil && other == nil {
+		return true
+	}
+	if d == nil || other == nil {
+		return false
+	}
+	return d.SamplingPercentage == other.SamplingPercentage && slices.Equal(d.Outcomes, other.Outcomes) && maps.Equal(d.Fields, other.Fields)
 }
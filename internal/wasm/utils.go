@@ -19,6 +19,7 @@ import (
 	"go.opentelemetry.io/otel/codes"
 	"google.golang.org/protobuf/types/known/structpb"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/env"
 	"k8s.io/utils/ptr"
 	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
@@ -154,10 +155,10 @@ func BuildObservabilityConfig(serviceBuilder *ServiceBuilder, observabilitySpec
 	var tracing *Tracing
 	if observabilitySpec.Tracing != nil && observabilitySpec.Tracing.DefaultEndpoint != "" {
 		// Reference the tracing service that will be created in BuildConfigForActionSet
-		tracing = &Tracing{
-			Service: TracingServiceName,
-		}
-
+		tracing = buildTracing(observabilitySpec.Tracing)
+	}
+	// The tracing service is also needed by the gateways that override the tracing endpoint
+	if observabilitySpec.IsTracingConfigured() {
 		serviceBuilder.WithTracing()
 	}
 
@@ -165,6 +166,65 @@ func BuildObservabilityConfig(serviceBuilder *ServiceBuilder, observabilitySpec
 		DefaultLevel:         ptr.To(logLevel.String()),
 		HTTPHeaderIdentifier: dataPlane.HTTPHeaderIdentifier,
 		Tracing:              tracing,
+		DecisionLog:          buildDecisionLog(dataPlane.DecisionLog),
+	}
+}
+
+// ObservabilityForGateway returns the wasm-shim observability config of a gateway, applying the overrides of the
+// gateway in the Observability spec to the default observability config
+func ObservabilityForGateway(observability *Observability, observabilitySpec *v1beta1.Observability, gateway k8stypes.NamespacedName) *Observability {
+	if observabilitySpec == nil {
+		return observability
+	}
+	override, found := lo.Find(observabilitySpec.Gateways, func(g v1beta1.GatewayObservability) bool {
+		return g.Namespace == gateway.Namespace && g.Name == gateway.Name
+	})
+	// the tracing service is only registered when the data plane observability is configured
+	overrideTracing := override.Tracing != nil && observability != nil
+	if !found || (override.DecisionLog == nil && !overrideTracing) {
+		return observability
+	}
+
+	gatewayObservability := &Observability{}
+	if observability != nil {
+		*gatewayObservability = *observability
+	}
+	if override.DecisionLog != nil {
+		gatewayObservability.DecisionLog = buildDecisionLog(override.DecisionLog)
+	}
+	if overrideTracing {
+		gatewayObservability.Tracing = buildTracing(observabilitySpec.Tracing)
+	}
+	return gatewayObservability
+}
+
+// buildTracing builds the wasm-shim tracing config referencing the tracing service
+func buildTracing(tracing *v1beta1.Tracing) *Tracing {
+	if tracing == nil {
+		return &Tracing{Service: TracingServiceName}
+	}
+	return &Tracing{
+		Service:            TracingServiceName,
+		SamplingPercentage: tracing.SamplingPercentage,
+		Propagation:        string(tracing.Propagation),
+	}
+}
+
+// buildDecisionLog builds the wasm-shim decision log config, defaulting to log all denied and limited requests
+func buildDecisionLog(decisionLog *v1beta1.DecisionLog) *DecisionLog {
+	if decisionLog == nil || !decisionLog.Enable {
+		return nil
+	}
+
+	outcomes := lo.Map(decisionLog.Outcomes, func(o v1beta1.DecisionOutcome, _ int) string { return string(o) })
+	if len(outcomes) == 0 {
+		outcomes = []string{string(v1beta1.DecisionOutcomeDenied), string(v1beta1.DecisionOutcomeLimited)}
+	}
+
+	return &DecisionLog{
+		Outcomes:           lo.Uniq(outcomes),
+		SamplingPercentage: ptr.Deref(decisionLog.SamplingPercentage, 100),
+		Fields:             decisionLog.Fields,
 	}
 }
 
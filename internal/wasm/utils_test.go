@@ -17,6 +17,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"google.golang.org/protobuf/types/known/structpb"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
 	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
 	"sigs.k8s.io/yaml"
 
@@ -703,6 +704,29 @@ func TestBuildObservabilityConfig(t *testing.T) {
 			},
 			expectedObservabilityNil: false,
 		},
+		{
+			name: "tracing with sampling and propagation",
+			observability: &v1beta1.Observability{
+				DataPlane: &v1beta1.DataPlane{
+					DefaultLevels: []v1beta1.LogLevel{
+						{Info: ptr.To("true")},
+					},
+				},
+				Tracing: &v1beta1.Tracing{
+					DefaultEndpoint:    "rpc://tempo:4317",
+					SamplingPercentage: ptr.To(int32(10)),
+					Propagation:        v1beta1.TracingPropagationB3,
+				},
+			},
+			expectedDefaultLevel: "INFO",
+			expectedHttpHeaderId: nil,
+			expectedTracing: &Tracing{
+				Service:            TracingServiceName,
+				SamplingPercentage: ptr.To(int32(10)),
+				Propagation:        "b3",
+			},
+			expectedObservabilityNil: false,
+		},
 		{
 			name: "only dataplane without tracing",
 			observability: &v1beta1.Observability{
@@ -758,7 +782,7 @@ func TestBuildObservabilityConfig(t *testing.T) {
 				assert.Assert(subT, result.Tracing == nil, "expected Tracing to be nil")
 			} else {
 				assert.Assert(subT, result.Tracing != nil, "expected Tracing to be non-nil")
-				assert.Equal(subT, result.Tracing.Service, tc.expectedTracing.Service)
+				assert.Assert(subT, result.Tracing.EqualTo(tc.expectedTracing), "unexpected Tracing %+v", result.Tracing)
 			}
 		})
 	}
@@ -1010,6 +1034,24 @@ func TestTracingEqualTo(t *testing.T) {
 			tracing2: &Tracing{Service: "different-service"},
 			expected: false,
 		},
+		{
+			name:     "same sampling and propagation",
+			tracing1: &Tracing{Service: TracingServiceName, SamplingPercentage: ptr.To(int32(50)), Propagation: "w3c"},
+			tracing2: &Tracing{Service: TracingServiceName, SamplingPercentage: ptr.To(int32(50)), Propagation: "w3c"},
+			expected: true,
+		},
+		{
+			name:     "different sampling",
+			tracing1: &Tracing{Service: TracingServiceName, SamplingPercentage: ptr.To(int32(50))},
+			tracing2: &Tracing{Service: TracingServiceName},
+			expected: false,
+		},
+		{
+			name:     "different propagation",
+			tracing1: &Tracing{Service: TracingServiceName, Propagation: "w3c"},
+			tracing2: &Tracing{Service: TracingServiceName, Propagation: "b3"},
+			expected: false,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -1290,7 +1332,7 @@ func TestBuildObservabilityConfigWithTracing(t *testing.T) {
 
 			if tc.shouldHaveTracing {
 				assert.Assert(subT, result.Tracing != nil, "expected Tracing to be non-nil")
-				assert.Equal(subT, result.Tracing.Service, tc.expectedTracing.Service)
+				assert.Assert(subT, result.Tracing.EqualTo(tc.expectedTracing), "unexpected Tracing %+v", result.Tracing)
 			} else {
 				assert.Assert(subT, result.Tracing == nil, "expected Tracing to be nil")
 			}
@@ -1298,6 +1340,106 @@ func TestBuildObservabilityConfigWithTracing(t *testing.T) {
 	}
 }
 
+func TestBuildObservabilityConfigWithDecisionLog(t *testing.T) {
+	logger := logr.Discard()
+
+	result := BuildObservabilityConfig(NewServiceBuilder(&logger), &v1beta1.Observability{
+		DataPlane: &v1beta1.DataPlane{DecisionLog: &v1beta1.DecisionLog{Enable: false}},
+	})
+	assert.Assert(t, result.DecisionLog == nil, "expected DecisionLog to be nil when disabled")
+
+	result = BuildObservabilityConfig(NewServiceBuilder(&logger), &v1beta1.Observability{
+		DataPlane: &v1beta1.DataPlane{DecisionLog: &v1beta1.DecisionLog{Enable: true}},
+	})
+	assert.DeepEqual(t, result.DecisionLog, &DecisionLog{Outcomes: []string{"denied", "limited"}, SamplingPercentage: 100})
+
+	result = BuildObservabilityConfig(NewServiceBuilder(&logger), &v1beta1.Observability{
+		DataPlane: &v1beta1.DataPlane{DecisionLog: &v1beta1.DecisionLog{
+			Enable:             true,
+			Outcomes:           []v1beta1.DecisionOutcome{v1beta1.DecisionOutcomeAllowed, v1beta1.DecisionOutcomeDenied},
+			SamplingPercentage: ptr.To(int32(10)),
+			Fields:             map[string]string{"user": "auth.identity.sub"},
+		}},
+	})
+	assert.DeepEqual(t, result.DecisionLog, &DecisionLog{
+		Outcomes:           []string{"allowed", "denied"},
+		SamplingPercentage: 10,
+		Fields:             map[string]string{"user": "auth.identity.sub"},
+	})
+}
+
+func TestObservabilityForGateway(t *testing.T) {
+	logger := logr.Discard()
+	observabilitySpec := &v1beta1.Observability{
+		DataPlane: &v1beta1.DataPlane{
+			DefaultLevels: []v1beta1.LogLevel{{Info: ptr.To("true")}},
+			DecisionLog:   &v1beta1.DecisionLog{Enable: true},
+		},
+		Gateways: []v1beta1.GatewayObservability{
+			{Name: "internal", Namespace: "gateway-system", DecisionLog: &v1beta1.DecisionLog{Enable: false}},
+			{Name: "external", Namespace: "gateway-system", DecisionLog: &v1beta1.DecisionLog{Enable: true, SamplingPercentage: ptr.To(int32(1))}},
+			{Name: "other", Namespace: "gateway-system"},
+		},
+	}
+	observability := BuildObservabilityConfig(NewServiceBuilder(&logger), observabilitySpec)
+
+	result := ObservabilityForGateway(observability, observabilitySpec, k8stypes.NamespacedName{Namespace: "gateway-system", Name: "default"})
+	assert.Assert(t, result == observability, "expected the default observability config for gateways with no override")
+
+	result = ObservabilityForGateway(observability, observabilitySpec, k8stypes.NamespacedName{Namespace: "gateway-system", Name: "other"})
+	assert.Assert(t, result == observability, "expected the default observability config for gateways with no decision log override")
+
+	result = ObservabilityForGateway(observability, observabilitySpec, k8stypes.NamespacedName{Namespace: "gateway-system", Name: "internal"})
+	assert.Assert(t, result.DecisionLog == nil, "expected the decision log to be disabled for the gateway")
+	assert.Equal(t, *result.DefaultLevel, "INFO")
+	assert.Assert(t, observability.DecisionLog != nil, "expected the default observability config not to be modified")
+
+	result = ObservabilityForGateway(observability, observabilitySpec, k8stypes.NamespacedName{Namespace: "gateway-system", Name: "external"})
+	assert.Equal(t, result.DecisionLog.SamplingPercentage, int32(1))
+
+	result = ObservabilityForGateway(nil, observabilitySpec, k8stypes.NamespacedName{Namespace: "gateway-system", Name: "external"})
+	assert.Assert(t, result != nil && result.DecisionLog != nil, "expected the decision log of the gateway without default observability config")
+}
+
+func TestObservabilityForGatewayWithTracing(t *testing.T) {
+	logger := logr.Discard()
+	observabilitySpec := &v1beta1.Observability{
+		DataPlane: &v1beta1.DataPlane{
+			DefaultLevels: []v1beta1.LogLevel{{Info: ptr.To("true")}},
+		},
+		Tracing: &v1beta1.Tracing{
+			SamplingPercentage: ptr.To(int32(5)),
+			Propagation:        v1beta1.TracingPropagationJaeger,
+		},
+		Gateways: []v1beta1.GatewayObservability{
+			{Name: "external", Namespace: "gateway-system", Tracing: &v1beta1.GatewayTracing{Endpoint: "rpc://otel-collector.external:4317"}},
+		},
+	}
+	serviceBuilder := NewServiceBuilder(&logger)
+	observability := BuildObservabilityConfig(serviceBuilder, observabilitySpec)
+	assert.Assert(t, observability.Tracing == nil, "expected no tracing by default without a default endpoint")
+	_, found := serviceBuilder.Build()[TracingServiceName]
+	assert.Assert(t, found, "expected the tracing service for the gateways that override the tracing endpoint")
+
+	result := ObservabilityForGateway(observability, observabilitySpec, k8stypes.NamespacedName{Namespace: "gateway-system", Name: "default"})
+	assert.Assert(t, result.Tracing == nil, "expected no tracing for gateways with no override")
+
+	result = ObservabilityForGateway(observability, observabilitySpec, k8stypes.NamespacedName{Namespace: "gateway-system", Name: "external"})
+	assert.Assert(t, result.Tracing.EqualTo(&Tracing{Service: TracingServiceName, SamplingPercentage: ptr.To(int32(5)), Propagation: "jaeger"}), "unexpected Tracing %+v", result.Tracing)
+	assert.Assert(t, observability.Tracing == nil, "expected the default observability config not to be modified")
+}
+
+func TestDecisionLogEqualTo(t *testing.T) {
+	a := &DecisionLog{Outcomes: []string{"denied"}, SamplingPercentage: 100, Fields: map[string]string{"user": "auth.identity.sub"}}
+	b := &DecisionLog{Outcomes: []string{"denied"}, SamplingPercentage: 100, Fields: map[string]string{"user": "auth.identity.sub"}}
+	assert.Assert(t, a.EqualTo(b))
+	assert.Assert(t, (*DecisionLog)(nil).EqualTo(nil))
+	assert.Assert(t, !a.EqualTo(nil))
+	assert.Assert(t, !a.EqualTo(&DecisionLog{Outcomes: []string{"denied"}, SamplingPercentage: 50, Fields: a.Fields}))
+	assert.Assert(t, !a.EqualTo(&DecisionLog{Outcomes: []string{"denied", "limited"}, SamplingPercentage: 100, Fields: a.Fields}))
+	assert.Assert(t, !(&Observability{DecisionLog: a}).EqualTo(&Observability{}))
+}
+
 func TestConfigToStructWithObservability(t *testing.T) {
 	testCases := []struct {
 		name        string
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -17,6 +18,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	ctrlruntimesrc "sigs.k8s.io/controller-runtime/pkg/source"
 
 	basereconciler "github.com/kuadrant/kuadrant-operator/internal/reconcilers"
@@ -32,13 +34,24 @@ var (
 // controller-runtime's builder, adding extension specific concerns (gRPC
 // client, event cache, unix socket path).
 type Builder struct {
-	name       string
-	scheme     *runtime.Scheme
-	logger     logr.Logger
-	reconcile  exttypes.ReconcileFn
-	forType    client.Object
-	watchTypes []client.Object
-	ownTypes   []client.Object
+	name        string
+	scheme      *runtime.Scheme
+	logger      logr.Logger
+	reconcile   exttypes.ReconcileFn
+	forType     client.Object
+	watchTypes  []client.Object
+	mappedTypes []mappedWatch
+	ownTypes    []client.Object
+	driftPolicy exttypes.DriftPolicy
+}
+
+// MapFunc maps an object of a watched type to the reconcile requests of the
+// policies depending on it, reading them through the client of the manager.
+type MapFunc func(ctx context.Context, c client.Reader, obj client.Object) []reconcile.Request
+
+type mappedWatch struct {
+	obj   client.Object
+	mapFn MapFunc
 }
 
 // NewBuilder creates a new Builder for a given controller name and returns it
@@ -87,13 +100,29 @@ func (b *Builder) Watches(obj client.Object) *Builder {
 	return b
 }
 
+// WatchesMapped registers additional object types whose events enqueue the
+// reconcile requests of the policies returned by the map function, e.g. the
+// policies referencing the object.
+func (b *Builder) WatchesMapped(obj client.Object, mapFn MapFunc) *Builder {
+	b.mappedTypes = append(b.mappedTypes, mappedWatch{obj: obj, mapFn: mapFn})
+	return b
+}
+
 // Owns registers owned object types so that owner references are resolved and
-// reconcile requests enqueued for the owning policy kind.
+// reconcile requests enqueued for the owning policy kind. Only the objects of
+// owned types are pruned when stale.
 func (b *Builder) Owns(obj client.Object) *Builder {
 	b.ownTypes = append(b.ownTypes, obj)
 	return b
 }
 
+// WithDriftPolicy sets how out-of-band changes to objects managed through
+// ReconcileObject are handled. Defaults to reverting them.
+func (b *Builder) WithDriftPolicy(policy exttypes.DriftPolicy) *Builder {
+	b.driftPolicy = policy
+	return b
+}
+
 // Build validates the configuration, creates the underlying manager, gRPC
 // client and returns a ready to Start ExtensionController.
 func (b *Builder) Build() (*ExtensionController, error) {
@@ -153,6 +182,15 @@ func (b *Builder) Build() (*ExtensionController, error) {
 		watchSources = append(watchSources, source)
 	}
 
+	for _, watch := range b.mappedTypes {
+		mapFn := watch.mapFn
+		hdler := ctrlruntimehandler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []reconcile.Request {
+			return mapFn(ctx, mgr.GetClient(), obj)
+		})
+		source := ctrlruntimesrc.Kind(mgr.GetCache(), watch.obj, hdler)
+		watchSources = append(watchSources, source)
+	}
+
 	for _, obj := range b.ownTypes {
 		var hdler ctrlruntimehandler.EventHandler
 		reflect.ValueOf(&hdler).Elem().Set(reflect.ValueOf(ctrlruntimehandler.EnqueueRequestForOwner(
@@ -173,11 +211,13 @@ func (b *Builder) Build() (*ExtensionController, error) {
 		Name:         b.name,
 		PolicyKind:   policyKind,
 		ForType:      b.forType,
+		OwnedTypes:   b.ownTypes,
+		DriftPolicy:  b.driftPolicy,
 		Reconcile:    b.reconcile,
 		WatchSources: watchSources,
 	}
 
-	return &ExtensionController{
+	ec := &ExtensionController{
 		config:          config,
 		manager:         mgr,
 		logger:          b.logger,
@@ -186,5 +226,7 @@ func (b *Builder) Build() (*ExtensionController, error) {
 		credential:      credential,
 		eventCache:      eventCache,
 		BaseReconciler:  basereconciler.NewBaseReconciler(mgr.GetClient(), mgr.GetScheme(), mgr.GetAPIReader()),
-	}, nil
+	}
+	ec.registerOwnedTypes()
+	return ec, nil
 }
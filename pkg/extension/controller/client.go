@@ -60,6 +60,16 @@ func newExtensionClient(address string) (*extensionClient, error) {
 	}, nil
 }
 
+// newExtensionClientFromConn wraps an already established connection to the
+// extension service.
+func newExtensionClientFromConn(conn *grpc.ClientConn) *extensionClient {
+	return &extensionClient{
+		conn:    conn,
+		client:  extpb.NewExtensionServiceClient(conn),
+		session: &sessionCredentials{},
+	}
+}
+
 func (ec *extensionClient) handshake(ctx context.Context, name string, credential []byte, policyKind string) error {
 	resp, err := ec.client.Handshake(ctx, &extpb.HandshakeRequest{
 		Name:       name,
@@ -10,10 +10,13 @@ import (
 	"github.com/google/cel-go/cel"
 	celtypes "github.com/google/cel-go/common/types"
 	"github.com/google/cel-go/common/types/ref"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 	ctrlruntime "sigs.k8s.io/controller-runtime"
@@ -25,6 +28,7 @@ import (
 	ctrlruntimesrc "sigs.k8s.io/controller-runtime/pkg/source"
 
 	basereconciler "github.com/kuadrant/kuadrant-operator/internal/reconcilers"
+	"github.com/kuadrant/kuadrant-operator/internal/utils"
 	extpb "github.com/kuadrant/kuadrant-operator/pkg/extension/grpc/v1"
 	exttypes "github.com/kuadrant/kuadrant-operator/pkg/extension/types"
 	extutils "github.com/kuadrant/kuadrant-operator/pkg/extension/utils"
@@ -43,12 +47,16 @@ const (
 //	Name:        controller name (also used for logging prefix)
 //	PolicyKind:  the Kind of the primary policy CRD managed
 //	ForType:     the primary object type reconciled
+//	OwnedTypes:  object types created for the policy, pruned when stale (see MarkDesiredStateComplete)
+//	DriftPolicy: how out-of-band changes to owned objects are handled
 //	Reconcile:   the user provided reconcile function
 //	WatchSources: dynamic sources watched (primary, additional and owned)
 type ExtensionConfig struct {
 	Name         string
 	PolicyKind   string
 	ForType      client.Object
+	OwnedTypes   []client.Object
+	DriftPolicy  exttypes.DriftPolicy
 	Reconcile    exttypes.ReconcileFn
 	WatchSources []ctrlruntimesrc.Source
 }
@@ -66,10 +74,27 @@ type ExtensionController struct {
 	extensionName   string
 	credential      []byte
 	eventCache      *EventTypeCache
+	ownedTypes      ownedTypeSet
 
 	*basereconciler.BaseReconciler // TODO(didierofrivia): Next iteration, use policy machinery
 }
 
+// NewControllerForConn builds an ExtensionController that is not backed by a
+// controller-runtime manager. It talks to the extension service over conn and
+// to the API server through k8sClient, so Reconcile can be driven directly,
+// e.g. by the pkg/extension/testing harness. No handshake is performed.
+func NewControllerForConn(config ExtensionConfig, conn *grpc.ClientConn, k8sClient client.Client, scheme *runtime.Scheme, logger logr.Logger) *ExtensionController {
+	ec := &ExtensionController{
+		config:          config,
+		logger:          logger,
+		extensionClient: newExtensionClientFromConn(conn),
+		eventCache:      newEventTypeCache(),
+		BaseReconciler:  basereconciler.NewBaseReconciler(k8sClient, scheme, k8sClient),
+	}
+	ec.registerOwnedTypes()
+	return ec
+}
+
 // Start launches the controller manager and begins processing events.
 func (ec *ExtensionController) Start(ctx context.Context) error {
 	handshakeCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
@@ -166,11 +191,16 @@ func (ec *ExtensionController) Reconcile(ctx context.Context, request reconcile.
 
 	// Call user reconcile function
 	ec.logger.Info("reconciling request", "namespace", request.Namespace, "name", request.Name, "event", eventType)
-	result, err := ec.config.Reconcile(ctx, request, ec)
+	tracker := newOwnedObjects()
+	result, err := ec.config.Reconcile(context.WithValue(ctx, ownedObjectsKey, tracker), request, ec)
 	if err != nil {
 		return result, err
 	}
 
+	if err := ec.pruneOwnedObjects(ctx, request, tracker); err != nil {
+		return reconcile.Result{RequeueAfter: time.Second}, err
+	}
+
 	if eventType == EventTypeUpdate {
 		if err := ec.cleanupFinalizer(ctx, request); err != nil {
 			if errors.IsNotFound(err) {
@@ -188,8 +218,8 @@ func (ec *ExtensionController) setupContext(ctx context.Context) context.Context
 	//  have to inject here instead of in Start(). Is there any benefit to us storing this in the context for it be
 	//  retrieved by the user in their Reconcile method, or should it just pass them as parameters?
 	ctx = context.WithValue(ctx, logr.Logger{}, ec.logger)
-	ctx = context.WithValue(ctx, extutils.SchemeKey, ec.manager.GetScheme())
-	ctx = context.WithValue(ctx, extutils.ClientKey, ec.manager.GetClient())
+	ctx = context.WithValue(ctx, extutils.SchemeKey, ec.Scheme())
+	ctx = context.WithValue(ctx, extutils.ClientKey, ec.Client())
 	return ctx
 }
 
@@ -291,12 +321,26 @@ func (ec *ExtensionController) AddDataTo(ctx context.Context, policy exttypes.Po
 }
 
 // ReconcileObject performs a create/update patch against the API server for a
-// desired object applying the provided mutate function on differences.
+// desired object applying the provided mutate function on differences. It
+// returns the existing object, or desired once created.
+//
+// Objects with a controller owner reference to the policy being reconciled
+// are tracked: those the policy stops producing are pruned after a successful
+// reconcile, and out-of-band changes are reverted or reported according to
+// the controller's DriftPolicy.
 func (ec *ExtensionController) ReconcileObject(ctx context.Context, obj client.Object, desired client.Object, mutateFn exttypes.MutateFn) (client.Object, error) {
-	obj, err := ec.ReconcileResource(ctx, obj, desired, basereconciler.MutateFn(mutateFn)) // TODO(didierofrivia): Next iteration, use policy machinery
+	var err error
+	if tracker, owner := ownedObjectsFromContext(ctx), metav1.GetControllerOf(desired); tracker != nil && owner != nil {
+		obj, err = ec.reconcileOwnedObject(ctx, tracker, owner, obj, desired, mutateFn)
+	} else {
+		obj, err = ec.ReconcileResource(ctx, obj, desired, basereconciler.MutateFn(mutateFn)) // TODO(didierofrivia): Next iteration, use policy machinery
+	}
 	if err != nil {
 		return nil, err
 	}
+	if obj == nil && !utils.IsObjectTaggedToDelete(desired) {
+		return desired, nil
+	}
 	return obj, nil
 }
 
@@ -343,6 +387,48 @@ func (ec *ExtensionController) RegisterActionMethod(ctx context.Context, policy
 	return nil
 }
 
+// RegisterFunction exposes one of the policy's action methods as a CEL function
+// callable from core policy predicates on the same targets.
+func (ec *ExtensionController) RegisterFunction(ctx context.Context, policy exttypes.Policy, fn exttypes.FunctionConfig) error {
+	pbPolicy := convertPolicyToProtobuf(policy)
+
+	_, err := ec.extensionClient.client.RegisterFunction(ctx, &extpb.RegisterFunctionRequest{
+		Policy:      pbPolicy,
+		Name:        fn.Name,
+		Method:      fn.Method,
+		ResultField: fn.ResultField,
+		ResultType:  convertFunctionTypeToProtobuf(fn.ResultType),
+	})
+	if err != nil {
+		if st, ok := status.FromError(err); ok && st.Code() == codes.Unavailable {
+			return fmt.Errorf("%w: %s", exttypes.ErrUpstreamUnreachable, st.Message())
+		}
+		return err
+	}
+	return nil
+}
+
+// ReportPolicyStatus replaces the per-target enforcement reported for the
+// policy, which the operator reflects on the targeted Gateways and routes.
+func (ec *ExtensionController) ReportPolicyStatus(ctx context.Context, policy exttypes.Policy, targets []exttypes.TargetStatus) error {
+	pbPolicy := convertPolicyToProtobuf(policy)
+
+	pbTargets := make([]*extpb.TargetStatus, 0, len(targets))
+	for _, target := range targets {
+		pbTargets = append(pbTargets, &extpb.TargetStatus{
+			TargetRef: convertTargetRefToProtobuf(target.TargetRef, policy.GetNamespace()),
+			Enforced:  target.Enforced,
+			Message:   target.Message,
+		})
+	}
+
+	_, err := ec.extensionClient.client.ReportPolicyStatus(ctx, &extpb.ReportPolicyStatusRequest{
+		Policy:  pbPolicy,
+		Targets: pbTargets,
+	})
+	return err
+}
+
 // NewPipeline creates a Pipeline bound to the given policy. No I/O is performed;
 // the returned Pipeline captures the policy and gRPC client for later use.
 func (ec *ExtensionController) NewPipeline(policy exttypes.Policy) exttypes.Pipeline {
@@ -356,6 +442,7 @@ func (ec *ExtensionController) NewPipeline(policy exttypes.Policy) exttypes.Pipe
 type pipelinePhase = string
 
 const (
+	phaseAccess   pipelinePhase = "access"
 	phaseRequest  pipelinePhase = "request"
 	phaseResponse pipelinePhase = "response"
 )
@@ -374,6 +461,23 @@ type PipelineImpl struct {
 	populatedVars map[string]bool
 }
 
+func (p *PipelineImpl) OnHTTPAccess(actions ...exttypes.DenyAction) error {
+	denials := make([]exttypes.Action, 0, len(actions))
+	for _, action := range actions {
+		// the denials of the access phase are evaluated before any gRPC method populates its variable
+		for varName := range p.populatedVars {
+			pattern := regexp.MustCompile(`\b` + regexp.QuoteMeta(varName) + `\b`)
+			for _, expr := range action.CelExpressions() {
+				if pattern.MatchString(expr) {
+					return fmt.Errorf("access action references variable %q, populated after it", varName)
+				}
+			}
+		}
+		denials = append(denials, action)
+	}
+	return p.validateAndAppend(phaseAccess, denials)
+}
+
 func (p *PipelineImpl) OnHTTPRequest(actions ...exttypes.Action) error {
 	for _, entry := range p.actions {
 		if entry.phase == phaseResponse {
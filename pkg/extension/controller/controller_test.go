@@ -33,18 +33,20 @@ type mockKuadrantCtx struct {
 	resolvePolicyFn        func(ctx context.Context, policy exttypes.Policy, expression string, subscribe bool) (exttypes.Policy, error)
 	addDataToFn            func(ctx context.Context, policy exttypes.Policy, domain exttypes.Domain, binding string, expression string) error
 	registerActionMethodFn func(ctx context.Context, policy exttypes.Policy, svc exttypes.ActionMethodConfig) error
+	reportPolicyStatusFn   func(ctx context.Context, policy exttypes.Policy, targets []exttypes.TargetStatus) error
 }
 
 type mockPolicy struct {
-	name      string
-	namespace string
+	name       string
+	namespace  string
+	targetRefs []gatewayapiv1alpha2.LocalPolicyTargetReferenceWithSectionName
 }
 
 func (m *mockPolicy) GetName() string                  { return m.name }
 func (m *mockPolicy) GetNamespace() string             { return m.namespace }
 func (m *mockPolicy) GetObjectKind() schema.ObjectKind { return &mockObjectKind{} }
 func (m *mockPolicy) GetTargetRefs() []gatewayapiv1alpha2.LocalPolicyTargetReferenceWithSectionName {
-	return nil
+	return m.targetRefs
 }
 
 type mockObjectKind struct{}
@@ -104,6 +106,17 @@ func (m *mockKuadrantCtx) RegisterActionMethod(ctx context.Context, policy extty
 	return nil
 }
 
+func (m *mockKuadrantCtx) RegisterFunction(ctx context.Context, policy exttypes.Policy, fn exttypes.FunctionConfig) error {
+	return nil
+}
+
+func (m *mockKuadrantCtx) ReportPolicyStatus(ctx context.Context, policy exttypes.Policy, targets []exttypes.TargetStatus) error {
+	if m.reportPolicyStatusFn != nil {
+		return m.reportPolicyStatusFn(ctx, policy, targets)
+	}
+	return nil
+}
+
 func (m *mockKuadrantCtx) NewPipeline(policy exttypes.Policy) exttypes.Pipeline {
 	return nil
 }
@@ -361,6 +374,8 @@ type mockExtensionServiceClient struct {
 	handshakeFn            func(ctx context.Context, in *extpb.HandshakeRequest, opts ...grpc.CallOption) (*extpb.HandshakeResponse, error)
 	registerActionMethodFn func(ctx context.Context, in *extpb.RegisterActionMethodRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	pipelineCommitFn       func(ctx context.Context, in *extpb.PipelineCommitRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	registerFunctionFn     func(ctx context.Context, in *extpb.RegisterFunctionRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	reportPolicyStatusFn   func(ctx context.Context, in *extpb.ReportPolicyStatusRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 }
 
 func (m *mockExtensionServiceClient) Handshake(ctx context.Context, in *extpb.HandshakeRequest, opts ...grpc.CallOption) (*extpb.HandshakeResponse, error) {
@@ -397,6 +412,20 @@ func (m *mockExtensionServiceClient) PipelineCommit(ctx context.Context, in *ext
 	return &emptypb.Empty{}, nil
 }
 
+func (m *mockExtensionServiceClient) RegisterFunction(ctx context.Context, in *extpb.RegisterFunctionRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	if m.registerFunctionFn != nil {
+		return m.registerFunctionFn(ctx, in, opts...)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (m *mockExtensionServiceClient) ReportPolicyStatus(ctx context.Context, in *extpb.ReportPolicyStatusRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	if m.reportPolicyStatusFn != nil {
+		return m.reportPolicyStatusFn(ctx, in, opts...)
+	}
+	return &emptypb.Empty{}, nil
+}
+
 func newTestExtensionController(mockClient *mockExtensionServiceClient) *ExtensionController {
 	return &ExtensionController{
 		extensionClient: &extensionClient{
@@ -472,6 +501,86 @@ func TestRegisterActionMethod_OtherGRPCError(t *testing.T) {
 	assert.Equal(t, st.Code(), codes.InvalidArgument)
 }
 
+func TestRegisterFunction_Success(t *testing.T) {
+	var capturedReq *extpb.RegisterFunctionRequest
+	mock := &mockExtensionServiceClient{
+		registerFunctionFn: func(_ context.Context, in *extpb.RegisterFunctionRequest, _ ...grpc.CallOption) (*emptypb.Empty, error) {
+			capturedReq = in
+			return &emptypb.Empty{}, nil
+		},
+	}
+
+	ec := newTestExtensionController(mock)
+	policy := &mockPolicy{name: "my-policy", namespace: "default"}
+	fn := exttypes.FunctionConfig{
+		Name:        "threat.score",
+		Method:      "assess-threat",
+		ResultField: "threat_level",
+		ResultType:  exttypes.FunctionTypeInt,
+	}
+
+	err := ec.RegisterFunction(context.Background(), policy, fn)
+	assert.NilError(t, err)
+	assert.Equal(t, capturedReq.Name, "threat.score")
+	assert.Equal(t, capturedReq.Method, "assess-threat")
+	assert.Equal(t, capturedReq.ResultField, "threat_level")
+	assert.Equal(t, capturedReq.ResultType, extpb.FunctionType_FUNCTION_TYPE_INT)
+	assert.Equal(t, capturedReq.Policy.Metadata.Name, "my-policy")
+}
+
+func TestRegisterFunction_Unavailable(t *testing.T) {
+	mock := &mockExtensionServiceClient{
+		registerFunctionFn: func(_ context.Context, _ *extpb.RegisterFunctionRequest, _ ...grpc.CallOption) (*emptypb.Empty, error) {
+			return nil, status.Error(codes.Unavailable, "connection refused")
+		},
+	}
+
+	ec := newTestExtensionController(mock)
+	policy := &mockPolicy{name: "my-policy", namespace: "default"}
+
+	err := ec.RegisterFunction(context.Background(), policy, exttypes.FunctionConfig{Name: "threat.score"})
+	assert.Assert(t, errors.Is(err, exttypes.ErrUpstreamUnreachable))
+}
+
+func TestReportPolicyStatus(t *testing.T) {
+	var capturedReq *extpb.ReportPolicyStatusRequest
+	mock := &mockExtensionServiceClient{
+		reportPolicyStatusFn: func(_ context.Context, in *extpb.ReportPolicyStatusRequest, _ ...grpc.CallOption) (*emptypb.Empty, error) {
+			capturedReq = in
+			return &emptypb.Empty{}, nil
+		},
+	}
+
+	ec := newTestExtensionController(mock)
+	policy := &mockPolicy{name: "my-policy", namespace: "default"}
+	section := gatewayapiv1alpha2.SectionName("http")
+
+	err := ec.ReportPolicyStatus(context.Background(), policy, []exttypes.TargetStatus{
+		{
+			TargetRef: gatewayapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+				LocalPolicyTargetReference: gatewayapiv1alpha2.LocalPolicyTargetReference{Group: "gateway.networking.k8s.io", Kind: "Gateway", Name: "gw"},
+				SectionName:                &section,
+			},
+			Enforced: true,
+		},
+		{
+			TargetRef: gatewayapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+				LocalPolicyTargetReference: gatewayapiv1alpha2.LocalPolicyTargetReference{Group: "gateway.networking.k8s.io", Kind: "HTTPRoute", Name: "route"},
+			},
+			Message: "upstream unavailable",
+		},
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, capturedReq.Policy.Metadata.Name, "my-policy")
+	assert.Equal(t, len(capturedReq.Targets), 2)
+	assert.Equal(t, capturedReq.Targets[0].TargetRef.Namespace, "default")
+	assert.Equal(t, capturedReq.Targets[0].TargetRef.SectionName, "http")
+	assert.Assert(t, capturedReq.Targets[0].Enforced)
+	assert.Equal(t, capturedReq.Targets[1].TargetRef.Kind, "HTTPRoute")
+	assert.Assert(t, !capturedReq.Targets[1].Enforced)
+	assert.Equal(t, capturedReq.Targets[1].Message, "upstream unavailable")
+}
+
 func TestNewPipeline_ReturnsNonNil(t *testing.T) {
 	mock := &mockExtensionServiceClient{}
 	ec := newTestExtensionController(mock)
@@ -515,6 +624,31 @@ func TestPipeline_AccumulatesBothPhases(t *testing.T) {
 	assert.Equal(t, p.actions[3].phase, "response")
 }
 
+func TestPipeline_AccessPhase(t *testing.T) {
+	p := &PipelineImpl{populatedVars: make(map[string]bool)}
+
+	err := p.OnHTTPRequest(exttypes.GRPCMethodAction{
+		Method: "assess-threat",
+		Var:    "threatResponse",
+	})
+	assert.NilError(t, err)
+
+	err = p.OnHTTPAccess(exttypes.DenyAction{
+		Predicate:  `source.address.startsWith("192.0.2.")`,
+		WithStatus: 403,
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(p.actions), 2)
+	assert.Equal(t, p.actions[1].phase, "access")
+
+	err = p.OnHTTPAccess(exttypes.DenyAction{
+		Predicate:  "threatResponse.threat_level >= 5",
+		WithStatus: 403,
+	})
+	assert.Assert(t, err != nil)
+	assert.Assert(t, cmp.Contains(err.Error(), "access action references variable \"threatResponse\""))
+}
+
 func TestPipeline_PhaseOrdering_RequestAfterResponse(t *testing.T) {
 	p := &PipelineImpl{populatedVars: make(map[string]bool)}
 
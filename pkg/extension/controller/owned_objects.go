@@ -0,0 +1,316 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/kuadrant/kuadrant-operator/internal/utils"
+	exttypes "github.com/kuadrant/kuadrant-operator/pkg/extension/types"
+)
+
+const (
+	// OwnerUIDLabel is set on every object reconciled through ReconcileObject
+	// to the UID of the policy controlling it, so objects the policy no longer
+	// produces can be found and pruned.
+	OwnerUIDLabel = "extensions.kuadrant.io/owner-uid"
+	// DesiredStateHashAnnotation holds a hash of the desired state last
+	// applied to an object, used to tell out-of-band edits from spec changes.
+	DesiredStateHashAnnotation = "extensions.kuadrant.io/desired-state-hash"
+)
+
+type ownedObjectsKeyType struct{}
+
+var ownedObjectsKey = ownedObjectsKeyType{}
+
+type ownedObjectKey struct {
+	gvk schema.GroupVersionKind
+	key client.ObjectKey
+}
+
+// ownedObjects records the objects reconciled for a policy during a single
+// reconcile, along with those found modified out of band, whether the
+// reconcile produced the complete desired set, and the reasons the policy is
+// not fully enforced.
+type ownedObjects struct {
+	mutex           sync.Mutex
+	reconciled      map[ownedObjectKey]struct{}
+	drifted         []string
+	complete        bool
+	enforcementErrs []error
+}
+
+func newOwnedObjects() *ownedObjects {
+	return &ownedObjects{reconciled: make(map[ownedObjectKey]struct{})}
+}
+
+func ownedObjectsFromContext(ctx context.Context) *ownedObjects {
+	tracker, _ := ctx.Value(ownedObjectsKey).(*ownedObjects)
+	return tracker
+}
+
+func (o *ownedObjects) add(gvk schema.GroupVersionKind, key client.ObjectKey) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.reconciled[ownedObjectKey{gvk: gvk, key: key}] = struct{}{}
+}
+
+func (o *ownedObjects) has(gvk schema.GroupVersionKind, key client.ObjectKey) bool {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	_, found := o.reconciled[ownedObjectKey{gvk: gvk, key: key}]
+	return found
+}
+
+func (o *ownedObjects) addDrifted(gvk schema.GroupVersionKind, key client.ObjectKey) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.drifted = append(o.drifted, fmt.Sprintf("%s %s", gvk.Kind, key))
+}
+
+// MarkDesiredStateComplete tells the SDK that every object desired for the
+// policy was reconciled during the current reconcile. Only then are the
+// objects previously reconciled for the policy, but not this time, pruned: a
+// reconcile returning early, e.g. because the target is missing, leaves them
+// in place.
+func MarkDesiredStateComplete(ctx context.Context) {
+	tracker := ownedObjectsFromContext(ctx)
+	if tracker == nil {
+		return
+	}
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	tracker.complete = true
+}
+
+func (o *ownedObjects) isComplete() bool {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return o.complete
+}
+
+// DriftError returns an error listing the objects left modified out of band
+// during the current reconcile, or nil if there are none. It is only ever set
+// when the controller runs with DriftPolicyReport.
+func DriftError(ctx context.Context) error {
+	tracker := ownedObjectsFromContext(ctx)
+	if tracker == nil {
+		return nil
+	}
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	if len(tracker.drifted) == 0 {
+		return nil
+	}
+	drifted := append([]string(nil), tracker.drifted...)
+	sort.Strings(drifted)
+	errs := make([]error, 0, len(drifted))
+	for _, object := range drifted {
+		errs = append(errs, fmt.Errorf("%s was modified out of band", object))
+	}
+	return errors.Join(errs...)
+}
+
+// ReportEnforcementError records a reason the policy is not fully enforced,
+// although its spec was reconciled, e.g. an external input that could not be
+// refreshed and whose last known value is enforced instead. UpdatePolicyStatus
+// reports it in the Enforced condition of the policy.
+func ReportEnforcementError(ctx context.Context, err error) {
+	tracker := ownedObjectsFromContext(ctx)
+	if tracker == nil || err == nil {
+		return
+	}
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	tracker.enforcementErrs = append(tracker.enforcementErrs, err)
+}
+
+// enforcementError returns the errors recorded by ReportEnforcementError
+// during the current reconcile, or nil if there are none.
+func enforcementError(ctx context.Context) error {
+	tracker := ownedObjectsFromContext(ctx)
+	if tracker == nil {
+		return nil
+	}
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	return errors.Join(tracker.enforcementErrs...)
+}
+
+// ownedTypeSet is the set of object types the controller prunes for its
+// policies. It is only made of the types declared when the controller is built,
+// so it is the same across restarts. The zero value is ready to use.
+type ownedTypeSet struct {
+	mutex sync.RWMutex
+	gvks  map[schema.GroupVersionKind]struct{}
+}
+
+func (s *ownedTypeSet) add(gvk schema.GroupVersionKind) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.gvks == nil {
+		s.gvks = make(map[schema.GroupVersionKind]struct{})
+	}
+	s.gvks[gvk] = struct{}{}
+}
+
+func (s *ownedTypeSet) list() []schema.GroupVersionKind {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	gvks := make([]schema.GroupVersionKind, 0, len(s.gvks))
+	for gvk := range s.gvks {
+		gvks = append(gvks, gvk)
+	}
+	sort.Slice(gvks, func(i, j int) bool { return gvks[i].String() < gvks[j].String() })
+	return gvks
+}
+
+func desiredStateHash(desired client.Object) (string, error) {
+	raw, err := json.Marshal(desired)
+	if err != nil {
+		return "", err
+	}
+	return utils.ToBase36Hash(string(raw)), nil
+}
+
+// setTrackingMetadata sets the owner label and desired state hash on obj. It
+// returns true when any of them changed.
+func setTrackingMetadata(obj client.Object, owner k8stypes.UID, hash string) bool {
+	changed := false
+
+	labels := obj.GetLabels()
+	if labels[OwnerUIDLabel] != string(owner) {
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels[OwnerUIDLabel] = string(owner)
+		obj.SetLabels(labels)
+		changed = true
+	}
+
+	annotations := obj.GetAnnotations()
+	if annotations[DesiredStateHashAnnotation] != hash {
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[DesiredStateHashAnnotation] = hash
+		obj.SetAnnotations(annotations)
+		changed = true
+	}
+
+	return changed
+}
+
+// reconcileOwnedObject reconciles desired on behalf of the policy controlling
+// it, recording it so that it is not pruned and checking the existing object
+// for out-of-band changes.
+func (ec *ExtensionController) reconcileOwnedObject(ctx context.Context, tracker *ownedObjects, owner *metav1.OwnerReference, obj, desired client.Object, mutateFn exttypes.MutateFn) (client.Object, error) {
+	gvk, err := apiutil.GVKForObject(desired, ec.Scheme())
+	if err != nil {
+		return nil, err
+	}
+	// hashed before tracking metadata is added, so the hash only reflects the
+	// state produced by the extension
+	hash, err := desiredStateHash(desired)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash desired state: %w", err)
+	}
+	setTrackingMetadata(desired, owner.UID, hash)
+
+	tracker.add(gvk, client.ObjectKeyFromObject(desired))
+
+	return ec.ReconcileResource(ctx, obj, desired, func(existing, desired client.Object) (bool, error) {
+		if existing.GetAnnotations()[DesiredStateHashAnnotation] == hash {
+			// the desired state has not changed since it was last applied, so
+			// any difference was introduced out of band
+			probe, ok := existing.DeepCopyObject().(client.Object)
+			if !ok {
+				return false, fmt.Errorf("%T is not a client.Object", existing)
+			}
+			drifted, err := mutateFn(probe, desired)
+			if err != nil {
+				return false, err
+			}
+			if drifted {
+				if ec.config.DriftPolicy == exttypes.DriftPolicyReport {
+					ec.logger.Info("object modified out of band", "kind", gvk.Kind, "namespace", existing.GetNamespace(), "name", existing.GetName())
+					tracker.addDrifted(gvk, client.ObjectKeyFromObject(existing))
+					return false, nil
+				}
+				ec.logger.Info("reverting out-of-band changes", "kind", gvk.Kind, "namespace", existing.GetNamespace(), "name", existing.GetName())
+			}
+		}
+
+		update, err := mutateFn(existing, desired)
+		if err != nil {
+			return false, err
+		}
+		return setTrackingMetadata(existing, owner.UID, hash) || update, nil
+	})
+}
+
+// pruneOwnedObjects deletes the objects of the owned types previously
+// reconciled for the policy that were not reconciled again during the last
+// reconcile, provided it marked the desired state complete.
+func (ec *ExtensionController) pruneOwnedObjects(ctx context.Context, request reconcile.Request, tracker *ownedObjects) error {
+	if ec.config.ForType == nil || !tracker.isComplete() {
+		return nil
+	}
+
+	policy, ok := ec.config.ForType.DeepCopyObject().(client.Object)
+	if !ok {
+		return fmt.Errorf("%T is not a client.Object", ec.config.ForType)
+	}
+	if err := ec.Client().Get(ctx, request.NamespacedName, policy); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if policy.GetDeletionTimestamp() != nil {
+		// owned objects are garbage collected with the policy
+		return nil
+	}
+
+	for _, gvk := range ec.ownedTypes.list() {
+		list := &metav1.PartialObjectMetadataList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		if err := ec.Client().List(ctx, list, client.InNamespace(request.Namespace), client.MatchingLabels{OwnerUIDLabel: string(policy.GetUID())}); err != nil {
+			return fmt.Errorf("failed to list owned %s objects: %w", gvk.Kind, err)
+		}
+
+		for i := range list.Items {
+			item := &list.Items[i]
+			if tracker.has(gvk, client.ObjectKeyFromObject(item)) {
+				continue
+			}
+			item.SetGroupVersionKind(gvk)
+			ec.logger.Info("pruning stale object", "kind", gvk.Kind, "namespace", item.GetNamespace(), "name", item.GetName())
+			if err := ec.DeleteResource(ctx, item); err != nil && !apierrors.IsNotFound(err) {
+				return fmt.Errorf("failed to prune %s %s: %w", gvk.Kind, client.ObjectKeyFromObject(item), err)
+			}
+		}
+	}
+	return nil
+}
+
+// registerOwnedTypes adds the configured owned types to the set of types
+// pruned for stale objects.
+func (ec *ExtensionController) registerOwnedTypes() {
+	for _, obj := range ec.config.OwnedTypes {
+		gvk, err := apiutil.GVKForObject(obj, ec.Scheme())
+		if err != nil {
+			ec.logger.Error(err, "failed to register owned type", "type", fmt.Sprintf("%T", obj))
+			continue
+		}
+		ec.ownedTypes.add(gvk)
+	}
+}
@@ -0,0 +1,245 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"maps"
+	"testing"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	exttypes "github.com/kuadrant/kuadrant-operator/pkg/extension/types"
+	extutils "github.com/kuadrant/kuadrant-operator/pkg/extension/utils"
+)
+
+var ownerRequest = reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "owner"}}
+
+func secretDataMutator(existingObj, desiredObj client.Object) (bool, error) {
+	existing := existingObj.(*corev1.Secret)
+	desired := desiredObj.(*corev1.Secret)
+	if maps.EqualFunc(existing.Data, desired.Data, func(a, b []byte) bool { return string(a) == string(b) }) {
+		return false, nil
+	}
+	existing.Data = desired.Data
+	return true, nil
+}
+
+// ownedSecretsReconciler returns a reconcile function that reconciles a
+// secret owned by the request's ConfigMap for every entry of secrets, and
+// stores the drift error seen during the last reconcile in driftErr.
+func ownedSecretsReconciler(scheme *runtime.Scheme, secrets map[string]string, driftErr *error) exttypes.ReconcileFn {
+	return func(ctx context.Context, request reconcile.Request, kctx exttypes.KuadrantCtx) (reconcile.Result, error) {
+		c, err := extutils.ClientFromContext(ctx)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		owner := &corev1.ConfigMap{}
+		if err := c.Get(ctx, request.NamespacedName, owner); err != nil {
+			return reconcile.Result{}, err
+		}
+		for name, value := range secrets {
+			desired := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: request.Namespace},
+				Data:       map[string][]byte{"value": []byte(value)},
+			}
+			if err := controllerutil.SetControllerReference(owner, desired, scheme); err != nil {
+				return reconcile.Result{}, err
+			}
+			if _, err := kctx.ReconcileObject(ctx, &corev1.Secret{}, desired, secretDataMutator); err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+		MarkDesiredStateComplete(ctx)
+		if driftErr != nil {
+			*driftErr = DriftError(ctx)
+		}
+		return reconcile.Result{}, nil
+	}
+}
+
+func newOwnedObjectsTestController(t *testing.T, driftPolicy exttypes.DriftPolicy) (*ExtensionController, client.Client) {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to build scheme: %v", err)
+	}
+	owner := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "owner", Namespace: "default", UID: "owner-uid"}}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(owner).Build()
+	ec := NewControllerForConn(ExtensionConfig{
+		Name:        "test",
+		PolicyKind:  "ConfigMap",
+		ForType:     &corev1.ConfigMap{},
+		OwnedTypes:  []client.Object{&corev1.Secret{}},
+		DriftPolicy: driftPolicy,
+	}, nil, c, scheme, logr.Discard())
+	return ec, c
+}
+
+func getSecret(t *testing.T, c client.Client, name string) (*corev1.Secret, bool) {
+	t.Helper()
+	secret := &corev1.Secret{}
+	if err := c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: name}, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, false
+		}
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return secret, true
+}
+
+func TestReconcileObject_PrunesStaleObjects(t *testing.T) {
+	secrets := map[string]string{"a": "1", "b": "2"}
+	ec, c := newOwnedObjectsTestController(t, exttypes.DriftPolicyRevert)
+	ec.config.Reconcile = ownedSecretsReconciler(ec.Scheme(), secrets, nil)
+
+	if _, err := ec.Reconcile(context.Background(), ownerRequest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	secret, found := getSecret(t, c, "b")
+	if !found {
+		t.Fatal("expected secret b to be created")
+	}
+	if secret.Labels[OwnerUIDLabel] != "owner-uid" || secret.Annotations[DesiredStateHashAnnotation] == "" {
+		t.Errorf("expected tracking metadata, got labels %v annotations %v", secret.Labels, secret.Annotations)
+	}
+
+	// an unrelated secret carrying no tracking label is left alone
+	if err := c.Create(context.Background(), &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: "default"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	delete(secrets, "b")
+	if _, err := ec.Reconcile(context.Background(), ownerRequest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, found := getSecret(t, c, "b"); found {
+		t.Error("expected stale secret b to be pruned")
+	}
+	if _, found := getSecret(t, c, "a"); !found {
+		t.Error("expected secret a to be kept")
+	}
+	if _, found := getSecret(t, c, "unrelated"); !found {
+		t.Error("expected untracked secret to be kept")
+	}
+}
+
+func TestReconcileObject_KeepsObjectsOfIncompleteReconciles(t *testing.T) {
+	ec, c := newOwnedObjectsTestController(t, exttypes.DriftPolicyRevert)
+	ec.config.Reconcile = ownedSecretsReconciler(ec.Scheme(), map[string]string{"a": "1"}, nil)
+	if _, err := ec.Reconcile(context.Background(), ownerRequest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the reconcile returns early, e.g. because the target is missing, without marking the desired state complete
+	ec.config.Reconcile = func(context.Context, reconcile.Request, exttypes.KuadrantCtx) (reconcile.Result, error) {
+		return reconcile.Result{}, nil
+	}
+	if _, err := ec.Reconcile(context.Background(), ownerRequest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, found := getSecret(t, c, "a"); !found {
+		t.Error("expected secret a to be kept after an incomplete reconcile")
+	}
+}
+
+func TestReconcileObject_PrunesOwnedTypesOnly(t *testing.T) {
+	ec, c := newOwnedObjectsTestController(t, exttypes.DriftPolicyRevert)
+	ec.config.OwnedTypes = nil
+	ec.ownedTypes = ownedTypeSet{}
+
+	secrets := map[string]string{"a": "1"}
+	ec.config.Reconcile = ownedSecretsReconciler(ec.Scheme(), secrets, nil)
+	if _, err := ec.Reconcile(context.Background(), ownerRequest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	delete(secrets, "a")
+	if _, err := ec.Reconcile(context.Background(), ownerRequest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, found := getSecret(t, c, "a"); !found {
+		t.Error("expected secret a, whose type is not owned, not to be pruned")
+	}
+}
+
+func TestReconcileObject_RevertsDrift(t *testing.T) {
+	ec, c := newOwnedObjectsTestController(t, exttypes.DriftPolicyRevert)
+	var driftErr error
+	ec.config.Reconcile = ownedSecretsReconciler(ec.Scheme(), map[string]string{"a": "1"}, &driftErr)
+
+	if _, err := ec.Reconcile(context.Background(), ownerRequest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	secret, _ := getSecret(t, c, "a")
+	secret.Data["value"] = []byte("edited")
+	if err := c.Update(context.Background(), secret); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := ec.Reconcile(context.Background(), ownerRequest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	secret, _ = getSecret(t, c, "a")
+	if string(secret.Data["value"]) != "1" {
+		t.Errorf("expected out-of-band change to be reverted, got %q", secret.Data["value"])
+	}
+	if driftErr != nil {
+		t.Errorf("expected reverted drift not to be reported, got %v", driftErr)
+	}
+}
+
+func TestReconcileObject_ReportsDrift(t *testing.T) {
+	ec, c := newOwnedObjectsTestController(t, exttypes.DriftPolicyReport)
+	secrets := map[string]string{"a": "1"}
+	var driftErr error
+	ec.config.Reconcile = ownedSecretsReconciler(ec.Scheme(), secrets, &driftErr)
+
+	if _, err := ec.Reconcile(context.Background(), ownerRequest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if driftErr != nil {
+		t.Fatalf("expected no drift, got %v", driftErr)
+	}
+
+	secret, _ := getSecret(t, c, "a")
+	secret.Data["value"] = []byte("edited")
+	if err := c.Update(context.Background(), secret); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := ec.Reconcile(context.Background(), ownerRequest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if driftErr == nil || driftErr.Error() != "Secret default/a was modified out of band" {
+		t.Errorf("unexpected drift error: %v", driftErr)
+	}
+	secret, _ = getSecret(t, c, "a")
+	if string(secret.Data["value"]) != "edited" {
+		t.Errorf("expected out-of-band change to be kept, got %q", secret.Data["value"])
+	}
+
+	// a change of the desired state is applied even over drifted objects
+	secrets["a"] = "2"
+	if _, err := ec.Reconcile(context.Background(), ownerRequest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if driftErr != nil {
+		t.Errorf("expected no drift after desired state changed, got %v", driftErr)
+	}
+	secret, _ = getSecret(t, c, "a")
+	if string(secret.Data["value"]) != "2" {
+		t.Errorf("expected desired state to be applied, got %q", secret.Data["value"])
+	}
+}
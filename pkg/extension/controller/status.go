@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	kuadrantgatewayapi "github.com/kuadrant/kuadrant-operator/internal/gatewayapi"
+	exttypes "github.com/kuadrant/kuadrant-operator/pkg/extension/types"
+)
+
+// ErrPolicyGenerationChanged is returned by UpdatePolicyStatus when the spec of
+// the policy changed while its status was being updated.
+var ErrPolicyGenerationChanged = errors.New("policy generation changed, status not updated")
+
+// SetPolicyStatus computes the Accepted and Enforced conditions of the policy
+// and sets them on its status, along with the observed generation. A policy
+// whose spec could not be reconciled (specErr != nil) is not accepted and has
+// no Enforced condition; otherwise it is enforced unless enforcedErr is set.
+// It reports whether the status changed.
+func SetPolicyStatus(policy exttypes.StatusPolicy, specErr, enforcedErr error) bool {
+	status := policy.GetStatus()
+
+	// Copy initial conditions. Otherwise, status will always be updated
+	conditions := slices.Clone(status.GetConditions())
+	meta.SetStatusCondition(&conditions, *AcceptedCondition(policy, specErr))
+	if specErr != nil {
+		meta.RemoveStatusCondition(&conditions, string(exttypes.PolicyConditionEnforced))
+	} else {
+		meta.SetStatusCondition(&conditions, *EnforcedCondition(policy, enforcedErr, true))
+	}
+
+	// Marshalling sorts by condition type
+	currentJSON, _ := ConditionMarshal(status.GetConditions())
+	desiredJSON, _ := ConditionMarshal(conditions)
+	if status.GetObservedGeneration() == policy.GetGeneration() && string(currentJSON) == string(desiredJSON) {
+		return false
+	}
+
+	status.SetObservedGeneration(policy.GetGeneration())
+	status.SetConditions(conditions)
+	return true
+}
+
+// UpdatePolicyStatus sets the Accepted and Enforced conditions of the policy,
+// deriving Enforced from the core policies created by the extension for it,
+// such as an AuthPolicy or a RateLimitPolicy, from any object reported as
+// modified out of band (see DriftError) and from the errors reported through
+// ReportEnforcementError. It patches the status subresource
+// when it changed. On conflict, the policy is fetched again and the patch
+// retried, unless the spec of the policy changed in the meantime: specErr was
+// computed for the previous generation, so ErrPolicyGenerationChanged is
+// returned without writing the status, for the new generation to be
+// reconciled.
+func UpdatePolicyStatus(ctx context.Context, c client.Client, policy exttypes.StatusPolicy, specErr error, corePolicies ...client.Object) error {
+	enforcedErr := errors.Join(PoliciesEnforced(corePolicies...), DriftError(ctx), enforcementError(ctx))
+	key := client.ObjectKeyFromObject(policy)
+	generation := policy.GetGeneration()
+
+	refetch := false
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if refetch {
+			if err := c.Get(ctx, key, policy); err != nil {
+				return err
+			}
+			if policy.GetGeneration() != generation {
+				return ErrPolicyGenerationChanged
+			}
+		}
+		refetch = true
+
+		base, ok := policy.DeepCopyObject().(client.Object)
+		if !ok {
+			return fmt.Errorf("%T is not a client.Object", policy)
+		}
+		if !SetPolicyStatus(policy, specErr, enforcedErr) {
+			return nil
+		}
+		return c.Status().Patch(ctx, policy, client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{}))
+	})
+}
+
+// ReportTargetStatus reports to the operator whether the policy is enforced on
+// each of its targets, so it shows up in the discoverability conditions of the
+// targets. The policy is enforced on all its targets unless specErr is set.
+func ReportTargetStatus(ctx context.Context, kuadrantCtx exttypes.KuadrantCtx, policy exttypes.Policy, specErr error) error {
+	targets := lo.Map(policy.GetTargetRefs(), func(ref gatewayapiv1alpha2.LocalPolicyTargetReferenceWithSectionName, _ int) exttypes.TargetStatus {
+		status := exttypes.TargetStatus{TargetRef: ref, Enforced: specErr == nil}
+		if specErr != nil {
+			status.Message = specErr.Error()
+		}
+		return status
+	})
+	return kuadrantCtx.ReportPolicyStatus(ctx, policy, targets)
+}
+
+// PoliciesEnforced returns an error for the first of the given policies that
+// does not have an Enforced condition set to True. Policies are either core
+// Kuadrant policies or extension policies implementing StatusPolicy.
+func PoliciesEnforced(policies ...client.Object) error {
+	for _, policy := range policies {
+		var conditions []metav1.Condition
+		switch p := policy.(type) {
+		case interface {
+			GetStatus() kuadrantgatewayapi.PolicyStatus
+		}:
+			conditions = p.GetStatus().GetConditions()
+		case exttypes.StatusPolicy:
+			conditions = p.GetStatus().GetConditions()
+		default:
+			return fmt.Errorf("%T does not expose a policy status", policy)
+		}
+
+		cond, found := lo.Find(conditions, func(c metav1.Condition) bool {
+			return c.Type == string(exttypes.PolicyConditionEnforced)
+		})
+		if !found || cond.Status != metav1.ConditionTrue {
+			return fmt.Errorf("%s %s is not enforced", policyKind(policy), policy.GetName())
+		}
+	}
+	return nil
+}
+
+func policyKind(policy client.Object) string {
+	if p, ok := policy.(interface{ Kind() string }); ok {
+		return p.Kind()
+	}
+	if kind := policy.GetObjectKind().GroupVersionKind().Kind; kind != "" {
+		return kind
+	}
+	return fmt.Sprintf("%T", policy)
+}
@@ -0,0 +1,268 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	gatewayapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	kuadrantv1 "github.com/kuadrant/kuadrant-operator/api/v1"
+	"github.com/kuadrant/kuadrant-operator/internal/kuadrant"
+	exttypes "github.com/kuadrant/kuadrant-operator/pkg/extension/types"
+)
+
+var statusTestPolicyGVK = schema.GroupVersionKind{Group: "extensions.kuadrant.io", Version: "v1alpha1", Kind: "DemoPolicy"}
+
+type statusTestPolicyStatus struct {
+	ObservedGeneration int64              `json:"observedGeneration,omitempty"`
+	Conditions         []metav1.Condition `json:"conditions,omitempty"`
+}
+
+func (s *statusTestPolicyStatus) GetObservedGeneration() int64 { return s.ObservedGeneration }
+
+func (s *statusTestPolicyStatus) SetObservedGeneration(generation int64) {
+	s.ObservedGeneration = generation
+}
+
+func (s *statusTestPolicyStatus) GetConditions() []metav1.Condition { return s.Conditions }
+
+func (s *statusTestPolicyStatus) SetConditions(conditions []metav1.Condition) {
+	s.Conditions = conditions
+}
+
+type statusTestPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Status statusTestPolicyStatus `json:"status,omitempty"`
+}
+
+func (p *statusTestPolicy) GetTargetRefs() []gatewayapiv1alpha2.LocalPolicyTargetReferenceWithSectionName {
+	return nil
+}
+
+func (p *statusTestPolicy) GetStatus() exttypes.PolicyStatus {
+	return &p.Status
+}
+
+func (p *statusTestPolicy) DeepCopyObject() runtime.Object {
+	out := &statusTestPolicy{TypeMeta: p.TypeMeta, Status: p.Status}
+	p.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Status.Conditions = make([]metav1.Condition, len(p.Status.Conditions))
+	for i := range p.Status.Conditions {
+		p.Status.Conditions[i].DeepCopyInto(&out.Status.Conditions[i])
+	}
+	return out
+}
+
+func newStatusTestPolicy() *statusTestPolicy {
+	policy := &statusTestPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "default", Generation: 3},
+	}
+	policy.SetGroupVersionKind(statusTestPolicyGVK)
+	return policy
+}
+
+func newStatusTestClient(objs ...client.Object) client.Client {
+	scheme := runtime.NewScheme()
+	scheme.AddKnownTypeWithName(statusTestPolicyGVK, &statusTestPolicy{})
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).WithStatusSubresource(objs...).Build()
+}
+
+func TestSetPolicyStatus(t *testing.T) {
+	policy := newStatusTestPolicy()
+
+	if !SetPolicyStatus(policy, nil, nil) {
+		t.Fatal("expected initial status to be a change")
+	}
+	if policy.Status.ObservedGeneration != 3 {
+		t.Errorf("expected observed generation 3, got %d", policy.Status.ObservedGeneration)
+	}
+	if !meta.IsStatusConditionTrue(policy.Status.Conditions, string(gatewayapiv1alpha2.PolicyConditionAccepted)) {
+		t.Error("expected policy to be accepted")
+	}
+	if !meta.IsStatusConditionTrue(policy.Status.Conditions, string(kuadrant.PolicyConditionEnforced)) {
+		t.Error("expected policy to be enforced")
+	}
+
+	if SetPolicyStatus(policy, nil, nil) {
+		t.Error("expected identical status not to be a change")
+	}
+
+	if !SetPolicyStatus(policy, nil, errors.New("AuthPolicy demo is not enforced")) {
+		t.Fatal("expected enforcement failure to be a change")
+	}
+	enforced := meta.FindStatusCondition(policy.Status.Conditions, string(kuadrant.PolicyConditionEnforced))
+	if enforced == nil || enforced.Status != metav1.ConditionFalse || enforced.Message != "AuthPolicy demo is not enforced" {
+		t.Errorf("unexpected enforced condition: %+v", enforced)
+	}
+
+	if !SetPolicyStatus(policy, errors.New("invalid spec"), nil) {
+		t.Fatal("expected spec failure to be a change")
+	}
+	if !meta.IsStatusConditionFalse(policy.Status.Conditions, string(gatewayapiv1alpha2.PolicyConditionAccepted)) {
+		t.Error("expected policy not to be accepted")
+	}
+	if meta.FindStatusCondition(policy.Status.Conditions, string(kuadrant.PolicyConditionEnforced)) != nil {
+		t.Error("expected enforced condition to be removed")
+	}
+}
+
+func TestPoliciesEnforced(t *testing.T) {
+	enforced := &kuadrantv1.AuthPolicy{ObjectMeta: metav1.ObjectMeta{Name: "enforced"}}
+	enforced.Status.Conditions = []metav1.Condition{{Type: string(kuadrant.PolicyConditionEnforced), Status: metav1.ConditionTrue}}
+	notEnforced := &kuadrantv1.RateLimitPolicy{ObjectMeta: metav1.ObjectMeta{Name: "not-enforced"}}
+	notEnforced.Status.Conditions = []metav1.Condition{{Type: string(kuadrant.PolicyConditionEnforced), Status: metav1.ConditionFalse}}
+	noStatus := &kuadrantv1.AuthPolicy{ObjectMeta: metav1.ObjectMeta{Name: "no-status"}}
+
+	if err := PoliciesEnforced(); err != nil {
+		t.Errorf("expected no error without policies, got %v", err)
+	}
+	if err := PoliciesEnforced(enforced); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if err := PoliciesEnforced(enforced, notEnforced); err == nil || err.Error() != "RateLimitPolicy not-enforced is not enforced" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := PoliciesEnforced(noStatus); err == nil || err.Error() != "AuthPolicy no-status is not enforced" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	extensionPolicy := newStatusTestPolicy()
+	SetPolicyStatus(extensionPolicy, nil, nil)
+	if err := PoliciesEnforced(extensionPolicy); err != nil {
+		t.Errorf("expected extension policy to be enforced, got %v", err)
+	}
+}
+
+func TestUpdatePolicyStatus(t *testing.T) {
+	ctx := context.Background()
+	policy := newStatusTestPolicy()
+	c := newStatusTestClient(policy.DeepCopyObject().(client.Object))
+
+	stored := &statusTestPolicy{}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(policy), stored); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	notEnforced := &kuadrantv1.AuthPolicy{ObjectMeta: metav1.ObjectMeta{Name: "child"}}
+	if err := UpdatePolicyStatus(ctx, c, stored, nil, notEnforced); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := c.Get(ctx, client.ObjectKeyFromObject(policy), stored); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	enforced := meta.FindStatusCondition(stored.Status.Conditions, string(kuadrant.PolicyConditionEnforced))
+	if enforced == nil || enforced.Status != metav1.ConditionFalse || !strings.Contains(enforced.Message, "AuthPolicy child") {
+		t.Errorf("unexpected enforced condition: %+v", enforced)
+	}
+}
+
+func TestUpdatePolicyStatus_RetriesOnConflict(t *testing.T) {
+	ctx := context.Background()
+	policy := newStatusTestPolicy()
+	c := newStatusTestClient(policy.DeepCopyObject().(client.Object))
+
+	stale := &statusTestPolicy{}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(policy), stale); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// a concurrent writer bumps the resource version
+	current := stale.DeepCopyObject().(*statusTestPolicy)
+	current.Labels = map[string]string{"updated": "true"}
+	if err := c.Update(ctx, current); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := UpdatePolicyStatus(ctx, c, stale, nil); err != nil {
+		t.Fatalf("expected conflict to be retried, got %v", err)
+	}
+
+	stored := &statusTestPolicy{}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(policy), stored); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !meta.IsStatusConditionTrue(stored.Status.Conditions, string(kuadrant.PolicyConditionEnforced)) {
+		t.Errorf("expected policy to be enforced, got %+v", stored.Status.Conditions)
+	}
+	if stored.Labels["updated"] != "true" {
+		t.Error("expected concurrent update to be preserved")
+	}
+}
+
+func TestUpdatePolicyStatus_GenerationChanged(t *testing.T) {
+	ctx := context.Background()
+	policy := newStatusTestPolicy()
+	c := newStatusTestClient(policy.DeepCopyObject().(client.Object))
+
+	stale := &statusTestPolicy{}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(policy), stale); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the spec changes while the stale generation is being reconciled
+	current := stale.DeepCopyObject().(*statusTestPolicy)
+	current.Generation = stale.Generation + 1
+	if err := c.Update(ctx, current); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := UpdatePolicyStatus(ctx, c, stale, errors.New("invalid spec")); !errors.Is(err, ErrPolicyGenerationChanged) {
+		t.Fatalf("expected ErrPolicyGenerationChanged, got %v", err)
+	}
+
+	stored := &statusTestPolicy{}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(policy), stored); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stored.Status.Conditions) != 0 || stored.Status.ObservedGeneration != 0 {
+		t.Errorf("expected the status not to be written, got %+v", stored.Status)
+	}
+}
+
+func TestReportTargetStatus(t *testing.T) {
+	policy := &mockPolicy{
+		name:      "demo",
+		namespace: "default",
+		targetRefs: []gatewayapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+			{LocalPolicyTargetReference: gatewayapiv1alpha2.LocalPolicyTargetReference{Group: "gateway.networking.k8s.io", Kind: "Gateway", Name: "gw"}},
+			{LocalPolicyTargetReference: gatewayapiv1alpha2.LocalPolicyTargetReference{Group: "gateway.networking.k8s.io", Kind: "HTTPRoute", Name: "route"}},
+		},
+	}
+
+	var reported []exttypes.TargetStatus
+	kuadrantCtx := &mockKuadrantCtx{
+		reportPolicyStatusFn: func(_ context.Context, _ exttypes.Policy, targets []exttypes.TargetStatus) error {
+			reported = targets
+			return nil
+		},
+	}
+
+	if err := ReportTargetStatus(context.Background(), kuadrantCtx, policy, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(reported) != 2 || !reported[0].Enforced || !reported[1].Enforced || reported[1].TargetRef.Name != "route" {
+		t.Errorf("expected both targets to be enforced, got %+v", reported)
+	}
+
+	if err := ReportTargetStatus(context.Background(), kuadrantCtx, policy, errors.New("invalid spec")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, target := range reported {
+		if target.Enforced || target.Message != "invalid spec" {
+			t.Errorf("expected target %s not to be enforced because of the spec error, got %+v", target.TargetRef.Name, target)
+		}
+	}
+}
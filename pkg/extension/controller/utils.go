@@ -29,6 +29,26 @@ func convertDomainToProtobuf(domain exttypes.Domain) extpb.Domain {
 	}
 }
 
+// convertFunctionTypeToProtobuf maps the public FunctionType enum to the protobuf enum.
+func convertFunctionTypeToProtobuf(functionType exttypes.FunctionType) extpb.FunctionType {
+	switch functionType {
+	case exttypes.FunctionTypeBool:
+		return extpb.FunctionType_FUNCTION_TYPE_BOOL
+	case exttypes.FunctionTypeInt:
+		return extpb.FunctionType_FUNCTION_TYPE_INT
+	case exttypes.FunctionTypeUint:
+		return extpb.FunctionType_FUNCTION_TYPE_UINT
+	case exttypes.FunctionTypeDouble:
+		return extpb.FunctionType_FUNCTION_TYPE_DOUBLE
+	case exttypes.FunctionTypeString:
+		return extpb.FunctionType_FUNCTION_TYPE_STRING
+	case exttypes.FunctionTypeDyn:
+		return extpb.FunctionType_FUNCTION_TYPE_DYN
+	default:
+		return extpb.FunctionType_FUNCTION_TYPE_UNSPECIFIED
+	}
+}
+
 // convertPolicyToProtobuf builds a protobuf Policy message from the generic
 // Policy interface collecting metadata and target references.
 func convertPolicyToProtobuf(policy exttypes.Policy) *extpb.Policy {
@@ -43,20 +63,27 @@ func convertPolicyToProtobuf(policy exttypes.Policy) *extpb.Policy {
 	}
 
 	for i, ref := range policy.GetTargetRefs() {
-		pbPolicy.TargetRefs[i] = &extpb.TargetRef{
-			Group:     string(ref.Group),
-			Kind:      string(ref.Kind),
-			Name:      string(ref.Name),
-			Namespace: policy.GetNamespace(), // Use policy namespace for target refs
-		}
-		if ref.SectionName != nil {
-			pbPolicy.TargetRefs[i].SectionName = string(*ref.SectionName)
-		}
+		pbPolicy.TargetRefs[i] = convertTargetRefToProtobuf(ref, policy.GetNamespace())
 	}
 
 	return pbPolicy
 }
 
+// convertTargetRefToProtobuf builds a protobuf TargetRef from a local policy
+// target reference, which always refers to the policy namespace.
+func convertTargetRefToProtobuf(ref gatewayapiv1alpha2.LocalPolicyTargetReferenceWithSectionName, namespace string) *extpb.TargetRef {
+	pbRef := &extpb.TargetRef{
+		Group:     string(ref.Group),
+		Kind:      string(ref.Kind),
+		Name:      string(ref.Name),
+		Namespace: namespace,
+	}
+	if ref.SectionName != nil {
+		pbRef.SectionName = string(*ref.SectionName)
+	}
+	return pbRef
+}
+
 // Resolve is a generic helper that evaluates a CEL expression via the
 // KuadrantCtx and converts the result to the requested Go type T.
 //
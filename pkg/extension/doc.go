@@ -38,6 +38,8 @@
 //	types      - Interfaces (Policy, KuadrantCtx, ReconcileFn) and helpers
 //	             shared between implementations and the controller.
 //	utils      - Context helper accessors for logger, client and scheme.
+//	testing    - In-memory extension service for unit testing reconcilers
+//	             without a running operator.
 //	grpc       - Protobuf generated types and small adapters (do not edit).
 //
 // See also the cel/ext package which defines CEL functions and type registry
@@ -130,6 +130,68 @@ func (ActionType) EnumDescriptor() ([]byte, []int) {
 	return file_v1_kuadrant_proto_rawDescGZIP(), []int{1}
 }
 
+// FunctionType is the CEL type returned by an extension provided function.
+type FunctionType int32
+
+const (
+	FunctionType_FUNCTION_TYPE_UNSPECIFIED FunctionType = 0
+	FunctionType_FUNCTION_TYPE_BOOL        FunctionType = 1
+	FunctionType_FUNCTION_TYPE_INT         FunctionType = 2
+	FunctionType_FUNCTION_TYPE_UINT        FunctionType = 3
+	FunctionType_FUNCTION_TYPE_DOUBLE      FunctionType = 4
+	FunctionType_FUNCTION_TYPE_STRING      FunctionType = 5
+	FunctionType_FUNCTION_TYPE_DYN         FunctionType = 6
+)
+
+// Enum value maps for FunctionType.
+var (
+	FunctionType_name = map[int32]string{
+		0: "FUNCTION_TYPE_UNSPECIFIED",
+		1: "FUNCTION_TYPE_BOOL",
+		2: "FUNCTION_TYPE_INT",
+		3: "FUNCTION_TYPE_UINT",
+		4: "FUNCTION_TYPE_DOUBLE",
+		5: "FUNCTION_TYPE_STRING",
+		6: "FUNCTION_TYPE_DYN",
+	}
+	FunctionType_value = map[string]int32{
+		"FUNCTION_TYPE_UNSPECIFIED": 0,
+		"FUNCTION_TYPE_BOOL":        1,
+		"FUNCTION_TYPE_INT":         2,
+		"FUNCTION_TYPE_UINT":        3,
+		"FUNCTION_TYPE_DOUBLE":      4,
+		"FUNCTION_TYPE_STRING":      5,
+		"FUNCTION_TYPE_DYN":         6,
+	}
+)
+
+func (x FunctionType) Enum() *FunctionType {
+	p := new(FunctionType)
+	*p = x
+	return p
+}
+
+func (x FunctionType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (FunctionType) Descriptor() protoreflect.EnumDescriptor {
+	return file_v1_kuadrant_proto_enumTypes[2].Descriptor()
+}
+
+func (FunctionType) Type() protoreflect.EnumType {
+	return &file_v1_kuadrant_proto_enumTypes[2]
+}
+
+func (x FunctionType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use FunctionType.Descriptor instead.
+func (FunctionType) EnumDescriptor() ([]byte, []int) {
+	return file_v1_kuadrant_proto_rawDescGZIP(), []int{2}
+}
+
 // The request message containing the time the request was dispatched.
 type PingRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -1012,6 +1074,199 @@ func (x *PipelineCommitRequest) GetActions() []*ActionEntry {
 	return nil
 }
 
+// RegisterFunctionRequest declares a zero-argument CEL function, e.g. threat.score(),
+// callable from the predicates of core policies targeting the same resources.
+type RegisterFunctionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Policy        *Policy                `protobuf:"bytes,1,opt,name=policy,proto3" json:"policy,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`                                  // Qualified function name, e.g. "threat.score"
+	Method        string                 `protobuf:"bytes,3,opt,name=method,proto3" json:"method,omitempty"`                              // Name of a registered ActionMethod computing the value
+	ResultField   string                 `protobuf:"bytes,4,opt,name=result_field,json=resultField,proto3" json:"result_field,omitempty"` // Dot-separated field of the method response returned; empty for the whole response
+	ResultType    FunctionType           `protobuf:"varint,5,opt,name=result_type,json=resultType,proto3,enum=kuadrant.v1.FunctionType" json:"result_type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterFunctionRequest) Reset() {
+	*x = RegisterFunctionRequest{}
+	mi := &file_v1_kuadrant_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterFunctionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterFunctionRequest) ProtoMessage() {}
+
+func (x *RegisterFunctionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_kuadrant_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterFunctionRequest.ProtoReflect.Descriptor instead.
+func (*RegisterFunctionRequest) Descriptor() ([]byte, []int) {
+	return file_v1_kuadrant_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *RegisterFunctionRequest) GetPolicy() *Policy {
+	if x != nil {
+		return x.Policy
+	}
+	return nil
+}
+
+func (x *RegisterFunctionRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *RegisterFunctionRequest) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *RegisterFunctionRequest) GetResultField() string {
+	if x != nil {
+		return x.ResultField
+	}
+	return ""
+}
+
+func (x *RegisterFunctionRequest) GetResultType() FunctionType {
+	if x != nil {
+		return x.ResultType
+	}
+	return FunctionType_FUNCTION_TYPE_UNSPECIFIED
+}
+
+// Enforcement of a policy on one of its targets.
+type TargetStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TargetRef     *TargetRef             `protobuf:"bytes,1,opt,name=target_ref,json=targetRef,proto3" json:"target_ref,omitempty"`
+	Enforced      bool                   `protobuf:"varint,2,opt,name=enforced,proto3" json:"enforced,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"` // Optional human readable detail, e.g. why it is not enforced
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TargetStatus) Reset() {
+	*x = TargetStatus{}
+	mi := &file_v1_kuadrant_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TargetStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TargetStatus) ProtoMessage() {}
+
+func (x *TargetStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_kuadrant_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TargetStatus.ProtoReflect.Descriptor instead.
+func (*TargetStatus) Descriptor() ([]byte, []int) {
+	return file_v1_kuadrant_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *TargetStatus) GetTargetRef() *TargetRef {
+	if x != nil {
+		return x.TargetRef
+	}
+	return nil
+}
+
+func (x *TargetStatus) GetEnforced() bool {
+	if x != nil {
+		return x.Enforced
+	}
+	return false
+}
+
+func (x *TargetStatus) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// Replaces the previously reported status of the policy. Enforced targets are
+// reflected in the policy discoverability conditions of Gateways and routes.
+type ReportPolicyStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Policy        *Policy                `protobuf:"bytes,1,opt,name=policy,proto3" json:"policy,omitempty"`
+	Targets       []*TargetStatus        `protobuf:"bytes,2,rep,name=targets,proto3" json:"targets,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportPolicyStatusRequest) Reset() {
+	*x = ReportPolicyStatusRequest{}
+	mi := &file_v1_kuadrant_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportPolicyStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportPolicyStatusRequest) ProtoMessage() {}
+
+func (x *ReportPolicyStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_kuadrant_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportPolicyStatusRequest.ProtoReflect.Descriptor instead.
+func (*ReportPolicyStatusRequest) Descriptor() ([]byte, []int) {
+	return file_v1_kuadrant_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ReportPolicyStatusRequest) GetPolicy() *Policy {
+	if x != nil {
+		return x.Policy
+	}
+	return nil
+}
+
+func (x *ReportPolicyStatusRequest) GetTargets() []*TargetStatus {
+	if x != nil {
+		return x.Targets
+	}
+	return nil
+}
+
 var File_v1_kuadrant_proto protoreflect.FileDescriptor
 
 const file_v1_kuadrant_proto_rawDesc = "" +
@@ -1086,7 +1341,22 @@ const file_v1_kuadrant_proto_rawDesc = "" +
 	"logMessage\"x\n" +
 	"\x15PipelineCommitRequest\x12+\n" +
 	"\x06policy\x18\x01 \x01(\v2\x13.kuadrant.v1.PolicyR\x06policy\x122\n" +
-	"\aactions\x18\x02 \x03(\v2\x18.kuadrant.v1.ActionEntryR\aactions*E\n" +
+	"\aactions\x18\x02 \x03(\v2\x18.kuadrant.v1.ActionEntryR\aactions\"\xd1\x01\n" +
+	"\x17RegisterFunctionRequest\x12+\n" +
+	"\x06policy\x18\x01 \x01(\v2\x13.kuadrant.v1.PolicyR\x06policy\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x16\n" +
+	"\x06method\x18\x03 \x01(\tR\x06method\x12!\n" +
+	"\fresult_field\x18\x04 \x01(\tR\vresultField\x12:\n" +
+	"\vresult_type\x18\x05 \x01(\x0e2\x19.kuadrant.v1.FunctionTypeR\n" +
+	"resultType\"{\n" +
+	"\fTargetStatus\x125\n" +
+	"\n" +
+	"target_ref\x18\x01 \x01(\v2\x16.kuadrant.v1.TargetRefR\ttargetRef\x12\x1a\n" +
+	"\benforced\x18\x02 \x01(\bR\benforced\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\"}\n" +
+	"\x19ReportPolicyStatusRequest\x12+\n" +
+	"\x06policy\x18\x01 \x01(\v2\x13.kuadrant.v1.PolicyR\x06policy\x123\n" +
+	"\atargets\x18\x02 \x03(\v2\x19.kuadrant.v1.TargetStatusR\atargets*E\n" +
 	"\x06Domain\x12\x16\n" +
 	"\x12DOMAIN_UNSPECIFIED\x10\x00\x12\x0f\n" +
 	"\vDOMAIN_AUTH\x10\x01\x12\x12\n" +
@@ -1097,7 +1367,15 @@ const file_v1_kuadrant_proto_rawDesc = "" +
 	"\x17ACTION_TYPE_GRPC_METHOD\x10\x01\x12\x14\n" +
 	"\x10ACTION_TYPE_DENY\x10\x02\x12\x1b\n" +
 	"\x17ACTION_TYPE_ADD_HEADERS\x10\x03\x12\x14\n" +
-	"\x10ACTION_TYPE_FAIL\x10\x042\x89\x05\n" +
+	"\x10ACTION_TYPE_FAIL\x10\x04*\xbf\x01\n" +
+	"\fFunctionType\x12\x1d\n" +
+	"\x19FUNCTION_TYPE_UNSPECIFIED\x10\x00\x12\x16\n" +
+	"\x12FUNCTION_TYPE_BOOL\x10\x01\x12\x15\n" +
+	"\x11FUNCTION_TYPE_INT\x10\x02\x12\x16\n" +
+	"\x12FUNCTION_TYPE_UINT\x10\x03\x12\x18\n" +
+	"\x14FUNCTION_TYPE_DOUBLE\x10\x04\x12\x18\n" +
+	"\x14FUNCTION_TYPE_STRING\x10\x05\x12\x15\n" +
+	"\x11FUNCTION_TYPE_DYN\x10\x062\xb5\x06\n" +
 	"\x10ExtensionService\x12L\n" +
 	"\tHandshake\x12\x1d.kuadrant.v1.HandshakeRequest\x1a\x1e.kuadrant.v1.HandshakeResponse\"\x00\x12=\n" +
 	"\x04Ping\x12\x18.kuadrant.v1.PingRequest\x1a\x19.kuadrant.v1.PongResponse\"\x00\x12N\n" +
@@ -1106,7 +1384,9 @@ const file_v1_kuadrant_proto_rawDesc = "" +
 	"\x0fRegisterMutator\x12#.kuadrant.v1.RegisterMutatorRequest\x1a\x16.google.protobuf.Empty\"\x00\x12R\n" +
 	"\vClearPolicy\x12\x1f.kuadrant.v1.ClearPolicyRequest\x1a .kuadrant.v1.ClearPolicyResponse\"\x00\x12Z\n" +
 	"\x14RegisterActionMethod\x12(.kuadrant.v1.RegisterActionMethodRequest\x1a\x16.google.protobuf.Empty\"\x00\x12N\n" +
-	"\x0ePipelineCommit\x12\".kuadrant.v1.PipelineCommitRequest\x1a\x16.google.protobuf.Empty\"\x00B\x05Z\x03/v1b\x06proto3"
+	"\x0ePipelineCommit\x12\".kuadrant.v1.PipelineCommitRequest\x1a\x16.google.protobuf.Empty\"\x00\x12R\n" +
+	"\x10RegisterFunction\x12$.kuadrant.v1.RegisterFunctionRequest\x1a\x16.google.protobuf.Empty\"\x00\x12V\n" +
+	"\x12ReportPolicyStatus\x12&.kuadrant.v1.ReportPolicyStatusRequest\x1a\x16.google.protobuf.Empty\"\x00B\x05Z\x03/v1b\x06proto3"
 
 var (
 	file_v1_kuadrant_proto_rawDescOnce sync.Once
@@ -1120,69 +1400,83 @@ func file_v1_kuadrant_proto_rawDescGZIP() []byte {
 	return file_v1_kuadrant_proto_rawDescData
 }
 
-var file_v1_kuadrant_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_v1_kuadrant_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_v1_kuadrant_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_v1_kuadrant_proto_msgTypes = make([]protoimpl.MessageInfo, 18)
 var file_v1_kuadrant_proto_goTypes = []any{
 	(Domain)(0),                         // 0: kuadrant.v1.Domain
 	(ActionType)(0),                     // 1: kuadrant.v1.ActionType
-	(*PingRequest)(nil),                 // 2: kuadrant.v1.PingRequest
-	(*PongResponse)(nil),                // 3: kuadrant.v1.PongResponse
-	(*HandshakeRequest)(nil),            // 4: kuadrant.v1.HandshakeRequest
-	(*HandshakeResponse)(nil),           // 5: kuadrant.v1.HandshakeResponse
-	(*ResolveRequest)(nil),              // 6: kuadrant.v1.ResolveRequest
-	(*ResolveResponse)(nil),             // 7: kuadrant.v1.ResolveResponse
-	(*SubscribeResponse)(nil),           // 8: kuadrant.v1.SubscribeResponse
-	(*SubscribeRequest)(nil),            // 9: kuadrant.v1.SubscribeRequest
-	(*Event)(nil),                       // 10: kuadrant.v1.Event
-	(*RegisterMutatorRequest)(nil),      // 11: kuadrant.v1.RegisterMutatorRequest
-	(*ClearPolicyRequest)(nil),          // 12: kuadrant.v1.ClearPolicyRequest
-	(*ClearPolicyResponse)(nil),         // 13: kuadrant.v1.ClearPolicyResponse
-	(*RegisterActionMethodRequest)(nil), // 14: kuadrant.v1.RegisterActionMethodRequest
-	(*ActionEntry)(nil),                 // 15: kuadrant.v1.ActionEntry
-	(*PipelineCommitRequest)(nil),       // 16: kuadrant.v1.PipelineCommitRequest
-	(*timestamp.Timestamp)(nil),         // 17: google.protobuf.Timestamp
-	(*Policy)(nil),                      // 18: kuadrant.v1.Policy
-	(*v1alpha1.Value)(nil),              // 19: google.api.expr.v1alpha1.Value
-	(*status.Status)(nil),               // 20: google.rpc.Status
-	(*Metadata)(nil),                    // 21: kuadrant.v1.Metadata
-	(*empty.Empty)(nil),                 // 22: google.protobuf.Empty
+	(FunctionType)(0),                   // 2: kuadrant.v1.FunctionType
+	(*PingRequest)(nil),                 // 3: kuadrant.v1.PingRequest
+	(*PongResponse)(nil),                // 4: kuadrant.v1.PongResponse
+	(*HandshakeRequest)(nil),            // 5: kuadrant.v1.HandshakeRequest
+	(*HandshakeResponse)(nil),           // 6: kuadrant.v1.HandshakeResponse
+	(*ResolveRequest)(nil),              // 7: kuadrant.v1.ResolveRequest
+	(*ResolveResponse)(nil),             // 8: kuadrant.v1.ResolveResponse
+	(*SubscribeResponse)(nil),           // 9: kuadrant.v1.SubscribeResponse
+	(*SubscribeRequest)(nil),            // 10: kuadrant.v1.SubscribeRequest
+	(*Event)(nil),                       // 11: kuadrant.v1.Event
+	(*RegisterMutatorRequest)(nil),      // 12: kuadrant.v1.RegisterMutatorRequest
+	(*ClearPolicyRequest)(nil),          // 13: kuadrant.v1.ClearPolicyRequest
+	(*ClearPolicyResponse)(nil),         // 14: kuadrant.v1.ClearPolicyResponse
+	(*RegisterActionMethodRequest)(nil), // 15: kuadrant.v1.RegisterActionMethodRequest
+	(*ActionEntry)(nil),                 // 16: kuadrant.v1.ActionEntry
+	(*PipelineCommitRequest)(nil),       // 17: kuadrant.v1.PipelineCommitRequest
+	(*RegisterFunctionRequest)(nil),     // 18: kuadrant.v1.RegisterFunctionRequest
+	(*TargetStatus)(nil),                // 19: kuadrant.v1.TargetStatus
+	(*ReportPolicyStatusRequest)(nil),   // 20: kuadrant.v1.ReportPolicyStatusRequest
+	(*timestamp.Timestamp)(nil),         // 21: google.protobuf.Timestamp
+	(*Policy)(nil),                      // 22: kuadrant.v1.Policy
+	(*v1alpha1.Value)(nil),              // 23: google.api.expr.v1alpha1.Value
+	(*status.Status)(nil),               // 24: google.rpc.Status
+	(*Metadata)(nil),                    // 25: kuadrant.v1.Metadata
+	(*TargetRef)(nil),                   // 26: kuadrant.v1.TargetRef
+	(*empty.Empty)(nil),                 // 27: google.protobuf.Empty
 }
 var file_v1_kuadrant_proto_depIdxs = []int32{
-	17, // 0: kuadrant.v1.PingRequest.out:type_name -> google.protobuf.Timestamp
-	17, // 1: kuadrant.v1.PongResponse.in:type_name -> google.protobuf.Timestamp
-	18, // 2: kuadrant.v1.ResolveRequest.policy:type_name -> kuadrant.v1.Policy
-	19, // 3: kuadrant.v1.ResolveResponse.cel_result:type_name -> google.api.expr.v1alpha1.Value
-	10, // 4: kuadrant.v1.SubscribeResponse.event:type_name -> kuadrant.v1.Event
-	20, // 5: kuadrant.v1.SubscribeResponse.error:type_name -> google.rpc.Status
-	21, // 6: kuadrant.v1.Event.metadata:type_name -> kuadrant.v1.Metadata
-	18, // 7: kuadrant.v1.RegisterMutatorRequest.policy:type_name -> kuadrant.v1.Policy
+	21, // 0: kuadrant.v1.PingRequest.out:type_name -> google.protobuf.Timestamp
+	21, // 1: kuadrant.v1.PongResponse.in:type_name -> google.protobuf.Timestamp
+	22, // 2: kuadrant.v1.ResolveRequest.policy:type_name -> kuadrant.v1.Policy
+	23, // 3: kuadrant.v1.ResolveResponse.cel_result:type_name -> google.api.expr.v1alpha1.Value
+	11, // 4: kuadrant.v1.SubscribeResponse.event:type_name -> kuadrant.v1.Event
+	24, // 5: kuadrant.v1.SubscribeResponse.error:type_name -> google.rpc.Status
+	25, // 6: kuadrant.v1.Event.metadata:type_name -> kuadrant.v1.Metadata
+	22, // 7: kuadrant.v1.RegisterMutatorRequest.policy:type_name -> kuadrant.v1.Policy
 	0,  // 8: kuadrant.v1.RegisterMutatorRequest.domain:type_name -> kuadrant.v1.Domain
-	18, // 9: kuadrant.v1.ClearPolicyRequest.policy:type_name -> kuadrant.v1.Policy
-	18, // 10: kuadrant.v1.RegisterActionMethodRequest.policy:type_name -> kuadrant.v1.Policy
+	22, // 9: kuadrant.v1.ClearPolicyRequest.policy:type_name -> kuadrant.v1.Policy
+	22, // 10: kuadrant.v1.RegisterActionMethodRequest.policy:type_name -> kuadrant.v1.Policy
 	1,  // 11: kuadrant.v1.ActionEntry.action_type:type_name -> kuadrant.v1.ActionType
-	18, // 12: kuadrant.v1.PipelineCommitRequest.policy:type_name -> kuadrant.v1.Policy
-	15, // 13: kuadrant.v1.PipelineCommitRequest.actions:type_name -> kuadrant.v1.ActionEntry
-	4,  // 14: kuadrant.v1.ExtensionService.Handshake:input_type -> kuadrant.v1.HandshakeRequest
-	2,  // 15: kuadrant.v1.ExtensionService.Ping:input_type -> kuadrant.v1.PingRequest
-	9,  // 16: kuadrant.v1.ExtensionService.Subscribe:input_type -> kuadrant.v1.SubscribeRequest
-	6,  // 17: kuadrant.v1.ExtensionService.Resolve:input_type -> kuadrant.v1.ResolveRequest
-	11, // 18: kuadrant.v1.ExtensionService.RegisterMutator:input_type -> kuadrant.v1.RegisterMutatorRequest
-	12, // 19: kuadrant.v1.ExtensionService.ClearPolicy:input_type -> kuadrant.v1.ClearPolicyRequest
-	14, // 20: kuadrant.v1.ExtensionService.RegisterActionMethod:input_type -> kuadrant.v1.RegisterActionMethodRequest
-	16, // 21: kuadrant.v1.ExtensionService.PipelineCommit:input_type -> kuadrant.v1.PipelineCommitRequest
-	5,  // 22: kuadrant.v1.ExtensionService.Handshake:output_type -> kuadrant.v1.HandshakeResponse
-	3,  // 23: kuadrant.v1.ExtensionService.Ping:output_type -> kuadrant.v1.PongResponse
-	8,  // 24: kuadrant.v1.ExtensionService.Subscribe:output_type -> kuadrant.v1.SubscribeResponse
-	7,  // 25: kuadrant.v1.ExtensionService.Resolve:output_type -> kuadrant.v1.ResolveResponse
-	22, // 26: kuadrant.v1.ExtensionService.RegisterMutator:output_type -> google.protobuf.Empty
-	13, // 27: kuadrant.v1.ExtensionService.ClearPolicy:output_type -> kuadrant.v1.ClearPolicyResponse
-	22, // 28: kuadrant.v1.ExtensionService.RegisterActionMethod:output_type -> google.protobuf.Empty
-	22, // 29: kuadrant.v1.ExtensionService.PipelineCommit:output_type -> google.protobuf.Empty
-	22, // [22:30] is the sub-list for method output_type
-	14, // [14:22] is the sub-list for method input_type
-	14, // [14:14] is the sub-list for extension type_name
-	14, // [14:14] is the sub-list for extension extendee
-	0,  // [0:14] is the sub-list for field type_name
+	22, // 12: kuadrant.v1.PipelineCommitRequest.policy:type_name -> kuadrant.v1.Policy
+	16, // 13: kuadrant.v1.PipelineCommitRequest.actions:type_name -> kuadrant.v1.ActionEntry
+	22, // 14: kuadrant.v1.RegisterFunctionRequest.policy:type_name -> kuadrant.v1.Policy
+	2,  // 15: kuadrant.v1.RegisterFunctionRequest.result_type:type_name -> kuadrant.v1.FunctionType
+	26, // 16: kuadrant.v1.TargetStatus.target_ref:type_name -> kuadrant.v1.TargetRef
+	22, // 17: kuadrant.v1.ReportPolicyStatusRequest.policy:type_name -> kuadrant.v1.Policy
+	19, // 18: kuadrant.v1.ReportPolicyStatusRequest.targets:type_name -> kuadrant.v1.TargetStatus
+	5,  // 19: kuadrant.v1.ExtensionService.Handshake:input_type -> kuadrant.v1.HandshakeRequest
+	3,  // 20: kuadrant.v1.ExtensionService.Ping:input_type -> kuadrant.v1.PingRequest
+	10, // 21: kuadrant.v1.ExtensionService.Subscribe:input_type -> kuadrant.v1.SubscribeRequest
+	7,  // 22: kuadrant.v1.ExtensionService.Resolve:input_type -> kuadrant.v1.ResolveRequest
+	12, // 23: kuadrant.v1.ExtensionService.RegisterMutator:input_type -> kuadrant.v1.RegisterMutatorRequest
+	13, // 24: kuadrant.v1.ExtensionService.ClearPolicy:input_type -> kuadrant.v1.ClearPolicyRequest
+	15, // 25: kuadrant.v1.ExtensionService.RegisterActionMethod:input_type -> kuadrant.v1.RegisterActionMethodRequest
+	17, // 26: kuadrant.v1.ExtensionService.PipelineCommit:input_type -> kuadrant.v1.PipelineCommitRequest
+	18, // 27: kuadrant.v1.ExtensionService.RegisterFunction:input_type -> kuadrant.v1.RegisterFunctionRequest
+	20, // 28: kuadrant.v1.ExtensionService.ReportPolicyStatus:input_type -> kuadrant.v1.ReportPolicyStatusRequest
+	6,  // 29: kuadrant.v1.ExtensionService.Handshake:output_type -> kuadrant.v1.HandshakeResponse
+	4,  // 30: kuadrant.v1.ExtensionService.Ping:output_type -> kuadrant.v1.PongResponse
+	9,  // 31: kuadrant.v1.ExtensionService.Subscribe:output_type -> kuadrant.v1.SubscribeResponse
+	8,  // 32: kuadrant.v1.ExtensionService.Resolve:output_type -> kuadrant.v1.ResolveResponse
+	27, // 33: kuadrant.v1.ExtensionService.RegisterMutator:output_type -> google.protobuf.Empty
+	14, // 34: kuadrant.v1.ExtensionService.ClearPolicy:output_type -> kuadrant.v1.ClearPolicyResponse
+	27, // 35: kuadrant.v1.ExtensionService.RegisterActionMethod:output_type -> google.protobuf.Empty
+	27, // 36: kuadrant.v1.ExtensionService.PipelineCommit:output_type -> google.protobuf.Empty
+	27, // 37: kuadrant.v1.ExtensionService.RegisterFunction:output_type -> google.protobuf.Empty
+	27, // 38: kuadrant.v1.ExtensionService.ReportPolicyStatus:output_type -> google.protobuf.Empty
+	29, // [29:39] is the sub-list for method output_type
+	19, // [19:29] is the sub-list for method input_type
+	19, // [19:19] is the sub-list for extension type_name
+	19, // [19:19] is the sub-list for extension extendee
+	0,  // [0:19] is the sub-list for field type_name
 }
 
 func init() { file_v1_kuadrant_proto_init() }
@@ -1197,8 +1491,8 @@ func file_v1_kuadrant_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_v1_kuadrant_proto_rawDesc), len(file_v1_kuadrant_proto_rawDesc)),
-			NumEnums:      2,
-			NumMessages:   15,
+			NumEnums:      3,
+			NumMessages:   18,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -28,6 +28,8 @@ const (
 	ExtensionService_ClearPolicy_FullMethodName          = "/kuadrant.v1.ExtensionService/ClearPolicy"
 	ExtensionService_RegisterActionMethod_FullMethodName = "/kuadrant.v1.ExtensionService/RegisterActionMethod"
 	ExtensionService_PipelineCommit_FullMethodName       = "/kuadrant.v1.ExtensionService/PipelineCommit"
+	ExtensionService_RegisterFunction_FullMethodName     = "/kuadrant.v1.ExtensionService/RegisterFunction"
+	ExtensionService_ReportPolicyStatus_FullMethodName   = "/kuadrant.v1.ExtensionService/ReportPolicyStatus"
 )
 
 // ExtensionServiceClient is the client API for ExtensionService service.
@@ -49,6 +51,10 @@ type ExtensionServiceClient interface {
 	RegisterActionMethod(ctx context.Context, in *RegisterActionMethodRequest, opts ...grpc.CallOption) (*empty.Empty, error)
 	// Commit all pipeline actions for a policy atomically
 	PipelineCommit(ctx context.Context, in *PipelineCommitRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	// Register a CEL function backed by a registered action method
+	RegisterFunction(ctx context.Context, in *RegisterFunctionRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	// Report the enforcement of a policy on each of its targets
+	ReportPolicyStatus(ctx context.Context, in *ReportPolicyStatusRequest, opts ...grpc.CallOption) (*empty.Empty, error)
 }
 
 type extensionServiceClient struct {
@@ -148,6 +154,26 @@ func (c *extensionServiceClient) PipelineCommit(ctx context.Context, in *Pipelin
 	return out, nil
 }
 
+func (c *extensionServiceClient) RegisterFunction(ctx context.Context, in *RegisterFunctionRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, ExtensionService_RegisterFunction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *extensionServiceClient) ReportPolicyStatus(ctx context.Context, in *ReportPolicyStatusRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, ExtensionService_ReportPolicyStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ExtensionServiceServer is the server API for ExtensionService service.
 // All implementations must embed UnimplementedExtensionServiceServer
 // for forward compatibility.
@@ -167,6 +193,10 @@ type ExtensionServiceServer interface {
 	RegisterActionMethod(context.Context, *RegisterActionMethodRequest) (*empty.Empty, error)
 	// Commit all pipeline actions for a policy atomically
 	PipelineCommit(context.Context, *PipelineCommitRequest) (*empty.Empty, error)
+	// Register a CEL function backed by a registered action method
+	RegisterFunction(context.Context, *RegisterFunctionRequest) (*empty.Empty, error)
+	// Report the enforcement of a policy on each of its targets
+	ReportPolicyStatus(context.Context, *ReportPolicyStatusRequest) (*empty.Empty, error)
 	mustEmbedUnimplementedExtensionServiceServer()
 }
 
@@ -201,6 +231,12 @@ func (UnimplementedExtensionServiceServer) RegisterActionMethod(context.Context,
 func (UnimplementedExtensionServiceServer) PipelineCommit(context.Context, *PipelineCommitRequest) (*empty.Empty, error) {
 	return nil, status.Error(codes.Unimplemented, "method PipelineCommit not implemented")
 }
+func (UnimplementedExtensionServiceServer) RegisterFunction(context.Context, *RegisterFunctionRequest) (*empty.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method RegisterFunction not implemented")
+}
+func (UnimplementedExtensionServiceServer) ReportPolicyStatus(context.Context, *ReportPolicyStatusRequest) (*empty.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method ReportPolicyStatus not implemented")
+}
 func (UnimplementedExtensionServiceServer) mustEmbedUnimplementedExtensionServiceServer() {}
 func (UnimplementedExtensionServiceServer) testEmbeddedByValue()                          {}
 
@@ -359,6 +395,42 @@ func _ExtensionService_PipelineCommit_Handler(srv interface{}, ctx context.Conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ExtensionService_RegisterFunction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterFunctionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExtensionServiceServer).RegisterFunction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExtensionService_RegisterFunction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExtensionServiceServer).RegisterFunction(ctx, req.(*RegisterFunctionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ExtensionService_ReportPolicyStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReportPolicyStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExtensionServiceServer).ReportPolicyStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExtensionService_ReportPolicyStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExtensionServiceServer).ReportPolicyStatus(ctx, req.(*ReportPolicyStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ExtensionService_ServiceDesc is the grpc.ServiceDesc for ExtensionService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -394,6 +466,14 @@ var ExtensionService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "PipelineCommit",
 			Handler:    _ExtensionService_PipelineCommit_Handler,
 		},
+		{
+			MethodName: "RegisterFunction",
+			Handler:    _ExtensionService_RegisterFunction_Handler,
+		},
+		{
+			MethodName: "ReportPolicyStatus",
+			Handler:    _ExtensionService_ReportPolicyStatus_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
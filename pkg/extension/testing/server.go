@@ -0,0 +1,273 @@
+// Package testing provides an in-memory Kuadrant extension service for unit
+// testing extension reconcilers without a running operator.
+//
+// A Server runs the operator's extension service in process, backed by a
+// topology supplied by the test instead of a cluster. Controllers obtained from
+// NewController talk to it over an in-memory connection, so a reconcile
+// function can be invoked end to end and the test can then assert on what it
+// registered:
+//
+//	srv := exttesting.NewServer(t)
+//	srv.SetTopology(topology)
+//	ctrl := srv.NewController(controller.ExtensionConfig{
+//	    Name:       "my-policy",
+//	    PolicyKind: "MyPolicy",
+//	    ForType:    &myv1.MyPolicy{},
+//	    Reconcile:  reconciler.Reconcile,
+//	}, fakeClient, scheme)
+//	_, err := ctrl.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+//	actions := srv.Pipeline(policy, exttesting.PhaseRequest)
+//
+// The server does not authenticate callers; Handshake behaves as in the
+// operator but sessions are not enforced on other calls.
+package testing
+
+import (
+	"context"
+	"net"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/kuadrant/policy-machinery/machinery"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kuadrant/kuadrant-operator/internal/extension"
+	"github.com/kuadrant/kuadrant-operator/pkg/extension/controller"
+	extpb "github.com/kuadrant/kuadrant-operator/pkg/extension/grpc/v1"
+	exttypes "github.com/kuadrant/kuadrant-operator/pkg/extension/types"
+)
+
+const bufferSize = 1024 * 1024
+
+// PipelineAction is an action committed by a policy's pipeline.
+type PipelineAction = extension.PipelineActionEntry
+
+// Upstream is an action method registered by a policy.
+type Upstream = extension.RegisteredUpstreamEntry
+
+// ReflectionFetcher resolves the descriptors of an action method's service.
+type ReflectionFetcher = extension.ReflectionFetcher
+
+// TargetStatus is the enforcement reported by a policy for one of its targets.
+type TargetStatus = extension.PolicyTargetStatus
+
+// Phase selects the access, request or response part of a committed pipeline.
+type Phase = extension.PipelinePhase
+
+const (
+	PhaseAccess   = extension.PipelinePhaseAccess
+	PhaseRequest  = extension.PipelinePhaseRequest
+	PhaseResponse = extension.PipelinePhaseResponse
+)
+
+// Mutator is a data binding registered by a policy through AddDataTo.
+type Mutator struct {
+	TargetRef  string // locator of the target, e.g. "gateway.gateway.networking.k8s.io:default/my-gateway"
+	Domain     extpb.Domain
+	Binding    string
+	Expression string
+}
+
+// Server is an in-memory extension service.
+type Server struct {
+	service  *extension.InProcessService
+	server   *grpc.Server
+	listener *bufconn.Listener
+	conn     *grpc.ClientConn
+
+	mu          sync.Mutex
+	subscribers map[string][]*subscriber
+	changes     []string
+}
+
+// NewServer starts an in-memory extension service. It is stopped when the test
+// and all its subtests complete.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+
+	s := &Server{
+		service:     extension.NewInProcessService(logr.Discard()),
+		server:      grpc.NewServer(),
+		listener:    bufconn.Listen(bufferSize),
+		subscribers: make(map[string][]*subscriber),
+	}
+	s.service.SetChangeNotifier(func(reason string) error {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.changes = append(s.changes, reason)
+		return nil
+	})
+
+	extpb.RegisterExtensionServiceServer(s.server, &service{ExtensionServiceServer: s.service.Server(), harness: s})
+	go func() {
+		_ = s.server.Serve(s.listener)
+	}()
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return s.listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		s.server.Stop()
+		t.Fatalf("failed to connect to in-memory extension service: %v", err)
+	}
+	s.conn = conn
+
+	t.Cleanup(func() {
+		_ = s.conn.Close()
+		s.server.Stop()
+	})
+
+	return s
+}
+
+// Conn returns the client connection to the server.
+func (s *Server) Conn() *grpc.ClientConn {
+	return s.conn
+}
+
+// Client returns a raw extension service client connected to the server.
+func (s *Server) Client() extpb.ExtensionServiceClient {
+	return extpb.NewExtensionServiceClient(s.conn)
+}
+
+// NewController returns a controller connected to the server that reads and
+// writes objects through k8sClient, typically a controller-runtime fake client.
+func (s *Server) NewController(config controller.ExtensionConfig, k8sClient client.Client, scheme *runtime.Scheme) *controller.ExtensionController {
+	return controller.NewControllerForConn(config, s.conn, k8sClient, scheme, logr.Discard())
+}
+
+// SetTopology replaces the topology CEL expressions are resolved against.
+// Subscribed expressions whose result changes are sent to subscribers.
+func (s *Server) SetTopology(topology *machinery.Topology) {
+	s.service.SetTopology(topology)
+}
+
+// SetReflectionFetcher replaces the gRPC reflection lookup performed when an
+// action method is registered, so tests need not run the upstream service.
+func (s *Server) SetReflectionFetcher(fetcher ReflectionFetcher) {
+	s.service.SetReflectionFetcher(fetcher)
+}
+
+// PushEvent sends a synthetic event for the policy to every subscriber of its
+// kind, as if a subscribed expression had changed.
+func (s *Server) PushEvent(policy exttypes.Policy) {
+	id := resourceID(policy)
+	response := &extpb.SubscribeResponse{Event: &extpb.Event{Metadata: &extpb.Metadata{
+		Kind:      id.Kind,
+		Namespace: id.Namespace,
+		Name:      id.Name,
+	}}}
+
+	s.mu.Lock()
+	subscribers := append([]*subscriber(nil), s.subscribers[id.Kind]...)
+	s.mu.Unlock()
+
+	for _, sub := range subscribers {
+		select {
+		case sub.events <- response:
+		case <-sub.done:
+		}
+	}
+}
+
+// Pipeline returns the actions committed by the policy for the given phase.
+func (s *Server) Pipeline(policy exttypes.Policy, phase Phase) []PipelineAction {
+	return s.service.RegisteredData().GetPipelineActions(resourceID(policy), phase)
+}
+
+// Mutators returns the data bindings registered by the policy, ordered by
+// target, domain and binding.
+func (s *Server) Mutators(policy exttypes.Policy) []Mutator {
+	var mutators []Mutator
+	for key, entry := range s.service.RegisteredData().GetPolicyDataProviders(resourceID(policy)) {
+		mutators = append(mutators, Mutator{
+			TargetRef:  key.TargetRefLocator,
+			Domain:     key.Domain,
+			Binding:    key.Binding,
+			Expression: entry.Expression,
+		})
+	}
+	sort.Slice(mutators, func(i, j int) bool {
+		if mutators[i].TargetRef != mutators[j].TargetRef {
+			return mutators[i].TargetRef < mutators[j].TargetRef
+		}
+		if mutators[i].Domain != mutators[j].Domain {
+			return mutators[i].Domain < mutators[j].Domain
+		}
+		return mutators[i].Binding < mutators[j].Binding
+	})
+	return mutators
+}
+
+// Upstream returns the action method registered by the policy under name.
+func (s *Server) Upstream(policy exttypes.Policy, name string) (Upstream, bool) {
+	_, entry, found := s.service.RegisteredData().GetUpstreamByName(resourceID(policy), name)
+	return entry, found
+}
+
+// Upstreams returns all action methods registered by the policy.
+func (s *Server) Upstreams(policy exttypes.Policy) []Upstream {
+	return s.service.RegisteredData().GetUpstreamsForPolicy(resourceID(policy))
+}
+
+// Status returns the per-target enforcement last reported by the policy.
+func (s *Server) Status(policy exttypes.Policy) ([]TargetStatus, bool) {
+	id := resourceID(policy)
+	for _, reported := range s.service.RegisteredData().GetReportedPolicies() {
+		if reported.Policy == id {
+			return reported.Targets, true
+		}
+	}
+	return nil, false
+}
+
+// Changes returns the reasons of every change that would have triggered a
+// reconciliation of the operator, in order.
+func (s *Server) Changes() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.changes...)
+}
+
+type subscriber struct {
+	events chan *extpb.SubscribeResponse
+	done   chan struct{}
+}
+
+func (s *Server) subscribe(policyKind string) (*subscriber, func()) {
+	sub := &subscriber{
+		events: make(chan *extpb.SubscribeResponse),
+		done:   make(chan struct{}),
+	}
+
+	s.mu.Lock()
+	s.subscribers[policyKind] = append(s.subscribers[policyKind], sub)
+	s.mu.Unlock()
+
+	return sub, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.subscribers[policyKind] = slices.DeleteFunc(s.subscribers[policyKind], func(other *subscriber) bool {
+			return other == sub
+		})
+		close(sub.done)
+	}
+}
+
+func resourceID(policy exttypes.Policy) extension.ResourceID {
+	return extension.ResourceID{
+		Kind:      policy.GetObjectKind().GroupVersionKind().Kind,
+		Namespace: policy.GetNamespace(),
+		Name:      policy.GetName(),
+	}
+}
@@ -0,0 +1,278 @@
+//go:build unit
+
+package testing
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	ctrlruntimeevent "sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	gatewayapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	"github.com/kuadrant/kuadrant-operator/pkg/extension/controller"
+	extpb "github.com/kuadrant/kuadrant-operator/pkg/extension/grpc/v1"
+	exttypes "github.com/kuadrant/kuadrant-operator/pkg/extension/types"
+)
+
+func testPolicy() *extpb.PolicyAdapter {
+	return extpb.NewPolicyAdapter(&extpb.Policy{
+		Metadata: &extpb.Metadata{
+			Kind:      "DemoPolicy",
+			Namespace: "default",
+			Name:      "demo",
+		},
+		TargetRefs: []*extpb.TargetRef{{
+			Group:     "gateway.networking.k8s.io",
+			Kind:      "Gateway",
+			Name:      "my-gateway",
+			Namespace: "default",
+		}},
+	})
+}
+
+func exampleFetcher(_ context.Context, _, _, _ string) (*descriptorpb.FileDescriptorSet, error) {
+	return &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{{
+			Name:    proto.String("example.proto"),
+			Package: proto.String("example.v1"),
+			Service: []*descriptorpb.ServiceDescriptorProto{{
+				Name:   proto.String("ExampleService"),
+				Method: []*descriptorpb.MethodDescriptorProto{{Name: proto.String("Check")}},
+			}},
+		}},
+	}, nil
+}
+
+func newTestController(srv *Server, reconcileFn exttypes.ReconcileFn) *controller.ExtensionController {
+	return srv.NewController(controller.ExtensionConfig{
+		Name:       "demo",
+		PolicyKind: "DemoPolicy",
+		Reconcile:  reconcileFn,
+	}, fake.NewClientBuilder().Build(), runtime.NewScheme())
+}
+
+func TestServer_ReconcileRecordsRegistrations(t *testing.T) {
+	srv := NewServer(t)
+	srv.SetReflectionFetcher(exampleFetcher)
+	policy := testPolicy()
+
+	ctrl := newTestController(srv, func(ctx context.Context, _ reconcile.Request, kctx exttypes.KuadrantCtx) (reconcile.Result, error) {
+		if err := kctx.AddDataTo(ctx, policy, exttypes.DomainRequest, "tier", `"gold"`); err != nil {
+			return reconcile.Result{}, err
+		}
+		if err := kctx.RegisterActionMethod(ctx, policy, exttypes.ActionMethodConfig{
+			Name:    "check",
+			URL:     "grpc://example:8081",
+			Service: "example.v1.ExampleService",
+			Method:  "Check",
+		}); err != nil {
+			return reconcile.Result{}, err
+		}
+		pipeline := kctx.NewPipeline(policy)
+		if err := pipeline.OnHTTPRequest(
+			exttypes.GRPCMethodAction{Method: "check", Var: "checkResponse"},
+			exttypes.DenyAction{Predicate: `request.path == "/blocked"`, WithStatus: 403},
+		); err != nil {
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{}, pipeline.Commit(ctx)
+	})
+
+	if _, err := ctrl.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "demo"}}); err != nil {
+		t.Fatalf("unexpected reconcile error: %v", err)
+	}
+
+	mutators := srv.Mutators(policy)
+	if len(mutators) != 1 {
+		t.Fatalf("expected 1 mutator, got %d: %v", len(mutators), mutators)
+	}
+	if mutators[0].Binding != "tier" || mutators[0].Expression != `"gold"` || mutators[0].Domain != extpb.Domain_DOMAIN_REQUEST {
+		t.Errorf("unexpected mutator: %+v", mutators[0])
+	}
+
+	upstream, found := srv.Upstream(policy, "check")
+	if !found {
+		t.Fatal("expected action method to be registered")
+	}
+	if upstream.Host != "example" || upstream.Port != 8081 || upstream.Method != "Check" {
+		t.Errorf("unexpected upstream: %+v", upstream)
+	}
+	if len(srv.Upstreams(policy)) != 1 {
+		t.Errorf("expected 1 upstream, got %d", len(srv.Upstreams(policy)))
+	}
+
+	actions := srv.Pipeline(policy, PhaseRequest)
+	if len(actions) != 2 {
+		t.Fatalf("expected 2 request actions, got %d", len(actions))
+	}
+	if actions[0].Method != "check" || actions[0].Var != "checkResponse" {
+		t.Errorf("unexpected first action: %+v", actions[0])
+	}
+	if actions[1].ActionType != extpb.ActionType_ACTION_TYPE_DENY || actions[1].WithStatus != 403 {
+		t.Errorf("unexpected second action: %+v", actions[1])
+	}
+	if len(srv.Pipeline(policy, PhaseResponse)) != 0 {
+		t.Error("expected no response actions")
+	}
+
+	if len(srv.Changes()) == 0 {
+		t.Error("expected registrations to be reported as changes")
+	}
+}
+
+func TestServer_Resolve(t *testing.T) {
+	srv := NewServer(t)
+	topology, err := machinery.NewTopology()
+	if err != nil {
+		t.Fatalf("failed to build topology: %v", err)
+	}
+	srv.SetTopology(topology)
+
+	ctrl := newTestController(srv, nil)
+	val, err := ctrl.Resolve(context.Background(), testPolicy(), "self.metadata.name", false)
+	if err != nil {
+		t.Fatalf("unexpected resolve error: %v", err)
+	}
+	if val.Value() != "demo" {
+		t.Errorf("expected %q, got %v", "demo", val.Value())
+	}
+}
+
+func TestServer_PushEvent(t *testing.T) {
+	srv := NewServer(t)
+	ctrl := newTestController(srv, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := make(chan ctrlruntimeevent.GenericEvent, 1)
+	go ctrl.Subscribe(ctx, events)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !hasSubscriber(srv, "DemoPolicy") {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for subscription")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	srv.PushEvent(testPolicy())
+
+	select {
+	case event := <-events:
+		if event.Object.GetNamespace() != "default" || event.Object.GetName() != "demo" {
+			t.Errorf("unexpected event object %s/%s", event.Object.GetNamespace(), event.Object.GetName())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+}
+
+func TestServer_SubscriptionEndsWithContext(t *testing.T) {
+	srv := NewServer(t)
+	ctrl := newTestController(srv, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan ctrlruntimeevent.GenericEvent, 1)
+	go ctrl.Subscribe(ctx, events)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !hasSubscriber(srv, "DemoPolicy") {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for subscription")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+
+	deadline = time.Now().Add(5 * time.Second)
+	for hasSubscriber(srv, "DemoPolicy") {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the subscription to end")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServer_PushEventWithoutSubscribers(t *testing.T) {
+	srv := NewServer(t)
+
+	done := make(chan struct{})
+	go func() {
+		srv.PushEvent(testPolicy())
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("PushEvent blocked without subscribers")
+	}
+}
+
+func TestServer_ChangesReportReasons(t *testing.T) {
+	srv := NewServer(t)
+
+	_, err := srv.Client().RegisterMutator(context.Background(), &extpb.RegisterMutatorRequest{
+		Policy:     testPolicy().Policy,
+		Domain:     extpb.Domain_DOMAIN_AUTH,
+		Binding:    "plan",
+		Expression: `"gold"`,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	changes := srv.Changes()
+	if len(changes) != 1 || !strings.Contains(changes[0], "demo") {
+		t.Errorf("unexpected changes: %v", changes)
+	}
+}
+
+func TestServer_Status(t *testing.T) {
+	srv := NewServer(t)
+	policy := testPolicy()
+
+	ctrl := newTestController(srv, func(ctx context.Context, _ reconcile.Request, kctx exttypes.KuadrantCtx) (reconcile.Result, error) {
+		return reconcile.Result{}, kctx.ReportPolicyStatus(ctx, policy, []exttypes.TargetStatus{{
+			TargetRef: gatewayapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+				LocalPolicyTargetReference: gatewayapiv1alpha2.LocalPolicyTargetReference{
+					Group: "gateway.networking.k8s.io",
+					Kind:  "Gateway",
+					Name:  "my-gateway",
+				},
+			},
+			Enforced: true,
+		}})
+	})
+
+	if _, found := srv.Status(policy); found {
+		t.Fatal("expected no status before reconciling")
+	}
+	if _, err := ctrl.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "demo"}}); err != nil {
+		t.Fatalf("unexpected reconcile error: %v", err)
+	}
+
+	targets, found := srv.Status(policy)
+	if !found || len(targets) != 1 {
+		t.Fatalf("expected one reported target, got %v", targets)
+	}
+	if targets[0].TargetRef.Name != "my-gateway" || targets[0].TargetRef.Namespace != "default" || !targets[0].Enforced {
+		t.Errorf("unexpected target status: %+v", targets[0])
+	}
+}
+
+func hasSubscriber(srv *Server, policyKind string) bool {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	return len(srv.subscribers[policyKind]) > 0
+}
@@ -0,0 +1,54 @@
+package testing
+
+import (
+	"sync"
+
+	"google.golang.org/grpc"
+
+	extpb "github.com/kuadrant/kuadrant-operator/pkg/extension/grpc/v1"
+)
+
+// service wraps the in-process extension service so that synthetic events
+// pushed by the test are delivered alongside the ones it emits.
+type service struct {
+	extpb.ExtensionServiceServer
+	harness *Server
+}
+
+func (s *service) Subscribe(request *extpb.SubscribeRequest, stream grpc.ServerStreamingServer[extpb.SubscribeResponse]) error {
+	sub, unsubscribe := s.harness.subscribe(request.GetPolicyKind())
+	defer unsubscribe()
+
+	locked := &lockedStream{ServerStreamingServer: stream}
+	done := make(chan error, 1)
+	go func() {
+		done <- s.ExtensionServiceServer.Subscribe(request, locked)
+	}()
+
+	for {
+		select {
+		case response := <-sub.events:
+			if err := locked.Send(response); err != nil {
+				return err
+			}
+		case err := <-done:
+			return err
+		case <-stream.Context().Done():
+			// the wrapped subscription ends with the stream, so its goroutine does not outlive it
+			<-done
+			return stream.Context().Err()
+		}
+	}
+}
+
+// lockedStream serialises sends from the wrapped service and the harness.
+type lockedStream struct {
+	grpc.ServerStreamingServer[extpb.SubscribeResponse]
+	mu sync.Mutex
+}
+
+func (l *lockedStream) Send(response *extpb.SubscribeResponse) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.ServerStreamingServer.Send(response)
+}
@@ -142,7 +142,12 @@ func (a AddHeadersAction) PopulateProtobuf(entry *extpb.ActionEntry) {
 // Pipeline provides a builder for composing ordered actions on HTTP request
 // and response phases. Actions accumulate locally with immediate ordering
 // validation. Commit sends all actions atomically to the operator.
+//
+// The request actions run after the actions of the core policies, e.g. auth
+// and rate limiting. The access denials run before any of them, so that the
+// denied requests are neither authenticated nor counted.
 type Pipeline interface {
+	OnHTTPAccess(actions ...DenyAction) error
 	OnHTTPRequest(actions ...Action) error
 	OnHTTPResponse(actions ...Action) error
 	Commit(ctx context.Context) error
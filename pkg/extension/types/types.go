@@ -8,6 +8,7 @@ import (
 
 	"github.com/go-logr/logr"
 	celref "github.com/google/cel-go/common/types/ref"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -24,6 +25,9 @@ const (
 	// KuadrantMetricsPrefix is the prefix applied to metric bindings injected
 	// via AddDataTo / KuadrantMetricBinding.
 	KuadrantMetricsPrefix = "metrics.labels"
+	// KuadrantCustomMetricsPrefix is the prefix applied to custom metric
+	// bindings injected via AddDataTo / KuadrantCustomMetricBinding.
+	KuadrantCustomMetricsPrefix = "metrics.custom"
 )
 
 // Domain enumerates the supported logical domains for mutator injected data.
@@ -49,6 +53,24 @@ type Policy interface {
 	GetTargetRefs() []gatewayapiv1alpha2.LocalPolicyTargetReferenceWithSectionName
 }
 
+// PolicyStatus is the status of an extension policy: the generation it was
+// computed for and the policy conditions, such as Accepted and Enforced.
+type PolicyStatus interface {
+	GetObservedGeneration() int64
+	SetObservedGeneration(generation int64)
+	GetConditions() []metav1.Condition
+	SetConditions(conditions []metav1.Condition)
+}
+
+// StatusPolicy is an extension policy exposing its status, so the SDK can
+// compute and persist its conditions. GetStatus must return a pointer into the
+// object so that changes made through it are reflected on the object.
+type StatusPolicy interface {
+	Policy
+	client.Object
+	GetStatus() PolicyStatus
+}
+
 // ActionMethodConfig holds the configuration for an external gRPC service that an
 // extension wants to register with the data plane.
 type ActionMethodConfig struct {
@@ -59,6 +81,41 @@ type ActionMethodConfig struct {
 	MessageTemplate string // Opaque template string for building the gRPC request message
 }
 
+// FunctionType enumerates the result types of extension provided CEL functions.
+type FunctionType int
+
+const (
+	// FunctionTypeUnspecified is not a valid result type and is rejected on registration.
+	FunctionTypeUnspecified FunctionType = iota
+	FunctionTypeBool
+	FunctionTypeInt
+	FunctionTypeUint
+	FunctionTypeDouble
+	FunctionTypeString
+	// FunctionTypeDyn leaves the result untyped, e.g. when returning a whole message.
+	FunctionTypeDyn
+)
+
+// FunctionConfig describes a zero-argument CEL function that core policy
+// predicates (AuthPolicy, RateLimitPolicy, ...) can call, e.g. `threat.score() > 50`.
+// The function is backed by a previously registered action method and evaluates to
+// the given field of its response.
+type FunctionConfig struct {
+	Name        string       // Qualified function name, e.g. "threat.score"
+	Method      string       // Name of the action method registered by the same policy
+	ResultField string       // Dot-separated response field; empty for the whole response
+	ResultType  FunctionType // CEL type of the function result
+}
+
+// TargetStatus reports whether a policy is enforced on one of its targets.
+// Enforced targets are reflected in the policy discoverability conditions
+// (kuadrant.io/<Kind>Affected) of the targeted Gateways and routes.
+type TargetStatus struct {
+	TargetRef gatewayapiv1alpha2.LocalPolicyTargetReferenceWithSectionName
+	Enforced  bool
+	Message   string // Optional detail, e.g. why the policy is not enforced
+}
+
 // KuadrantCtx is passed to ReconcileFn providing access to CEL resolution,
 // mutator registration, object reconciliation helpers and action method registration.
 type KuadrantCtx interface {
@@ -67,6 +124,8 @@ type KuadrantCtx interface {
 	AddDataTo(context.Context, Policy, Domain, string, string) error
 	ReconcileObject(context.Context, client.Object, client.Object, MutateFn) (client.Object, error)
 	RegisterActionMethod(ctx context.Context, policy Policy, svc ActionMethodConfig) error
+	RegisterFunction(ctx context.Context, policy Policy, fn FunctionConfig) error
+	ReportPolicyStatus(ctx context.Context, policy Policy, targets []TargetStatus) error
 	NewPipeline(policy Policy) Pipeline
 }
 
@@ -78,6 +137,18 @@ type ReconcileFn func(ctx context.Context, request reconcile.Request, kuadrant K
 // when a change was applied requiring an update.
 type MutateFn func(existing, desired client.Object) (bool, error)
 
+// DriftPolicy determines how ReconcileObject handles an object modified out of
+// band, i.e. one that no longer matches the desired state last applied to it.
+type DriftPolicy int
+
+const (
+	// DriftPolicyRevert overwrites out-of-band changes with the desired state.
+	DriftPolicyRevert DriftPolicy = iota
+	// DriftPolicyReport leaves out-of-band changes in place and reports them
+	// through the policy's Enforced condition.
+	DriftPolicyReport
+)
+
 // ExtensionBase is a base struct for the extension controllers.
 // ExtensionBase is an embeddable struct providing common fields (logger,
 // client, scheme) and a helper Configure method for extension controllers.
@@ -114,3 +185,10 @@ func (eb *ExtensionBase) Configure(ctx context.Context) error {
 func KuadrantMetricBinding(binding string) string {
 	return fmt.Sprintf("%s.%s", KuadrantMetricsPrefix, binding)
 }
+
+// KuadrantCustomMetricBinding creates a fully qualified binding name for an
+// attribute of a custom metric: "type" (CEL string literal, "counter" or
+// "histogram"), "value", "buckets" (list of integers) or "labels.<label>".
+func KuadrantCustomMetricBinding(metric, attribute string) string {
+	return fmt.Sprintf("%s.%s.%s", KuadrantCustomMetricsPrefix, metric, attribute)
+}
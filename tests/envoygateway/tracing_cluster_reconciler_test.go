@@ -154,17 +154,17 @@ var _ = Describe("tracing cluster controller", Serial, func() {
 			Expect(clusterConfig).NotTo(HaveKey("transport_socket"))
 		}, testTimeOut)
 
-		It("Creates envoypatchpolicy for tracing cluster with mTLS when policy exists", func(ctx SpecContext) {
+		It("Creates envoypatchpolicy for tracing cluster with TLS when policy exists", func(ctx SpecContext) {
 			createAuthPolicy(ctx)
 
-			// Configure tracing with mTLS
+			// Configure tracing with TLS
 			kuadrantKey := client.ObjectKey{Name: "kuadrant-sample", Namespace: kuadrantInstallationNS}
 			kuadrantObj := &kuadrantv1beta1.Kuadrant{}
 			Eventually(testClient().Get).WithContext(ctx).WithArguments(kuadrantKey, kuadrantObj).Should(Succeed())
 			original := kuadrantObj.DeepCopy()
 			kuadrantObj.Spec.Observability.Tracing = &kuadrantv1beta1.Tracing{
 				DefaultEndpoint: "https://secure-collector.observability.svc:443",
-				Insecure:        false, // mTLS enabled
+				Insecure:        false, // TLS enabled
 			}
 			Expect(testClient().Patch(ctx, kuadrantObj, client.MergeFrom(original))).To(Succeed())
 
@@ -187,7 +187,7 @@ var _ = Describe("tracing cluster controller", Serial, func() {
 			err = json.Unmarshal(envoyPatch.Spec.JSONPatches[0].Operation.Value.Raw, &clusterConfig)
 			Expect(err).ToNot(HaveOccurred())
 
-			// Verify mTLS configuration is present
+			// Verify TLS configuration is present
 			Expect(clusterConfig).To(HaveKey("transport_socket"))
 			transportSocket := clusterConfig["transport_socket"].(map[string]interface{})
 			Expect(transportSocket["name"]).To(Equal("envoy.transport_sockets.tls"))
@@ -195,9 +195,14 @@ var _ = Describe("tracing cluster controller", Serial, func() {
 			typedConfig := transportSocket["typed_config"].(map[string]interface{})
 			Expect(typedConfig["@type"]).To(Equal("type.googleapis.com/envoy.extensions.transport_sockets.tls.v3.UpstreamTlsContext"))
 
+			Expect(typedConfig["sni"]).To(Equal("secure-collector.observability.svc"))
+
+			// The collector is validated against the system trust store, envoy gateway has no mesh root CA
 			commonTLS := typedConfig["common_tls_context"].(map[string]interface{})
-			Expect(commonTLS).To(HaveKey("tls_certificate_sds_secret_configs"))
-			Expect(commonTLS).To(HaveKey("validation_context_sds_secret_config"))
+			Expect(commonTLS).NotTo(HaveKey("tls_certificate_sds_secret_configs"))
+			Expect(commonTLS).NotTo(HaveKey("validation_context_sds_secret_config"))
+			Expect(commonTLS).To(HaveKeyWithValue("validation_context", HaveKeyWithValue("trusted_ca",
+				HaveKeyWithValue("filename", "/etc/ssl/certs/ca-certificates.crt"))))
 		}, testTimeOut)
 
 		It("Does not create envoypatchpolicy when tracing is not configured", func(ctx SpecContext) {